@@ -0,0 +1,62 @@
+package broadcast
+
+import "sync"
+
+// subscriberBuffer размер буфера канала подписчика
+const subscriberBuffer = 64
+
+// Event событие для рассылки подписчикам
+type Event struct {
+	Type string      // Тип события (например, "queue_join")
+	Data interface{} // Данные события
+}
+
+// Hub рассылает события всем подписчикам в пределах реплики. Каждый подписчик
+// получает собственный буферизованный канал; медленному подписчику события,
+// не поместившиеся в буфер, не доставляются, чтобы Publish не блокировался.
+type Hub struct {
+	mu   sync.Mutex
+	subs map[<-chan Event]chan Event
+}
+
+// NewHub создает хаб без подписчиков
+func NewHub() *Hub {
+	return &Hub{
+		subs: make(map[<-chan Event]chan Event),
+	}
+}
+
+// Subscribe возвращает канал, в который будут приходить новые события
+func (h *Hub) Subscribe() <-chan Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan Event, subscriberBuffer)
+	h.subs[ch] = ch
+	return ch
+}
+
+// Unsubscribe удаляет подписку и закрывает ее канал
+func (h *Hub) Unsubscribe(ch <-chan Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if sub, ok := h.subs[ch]; ok {
+		close(sub)
+		delete(h.subs, ch)
+	}
+}
+
+// Publish отправляет событие всем подписчикам
+func (h *Hub) Publish(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, ch := range h.subs {
+		select {
+		case ch <- event:
+		default:
+			// Буфер подписчика заполнен — событие для него пропускается
+		}
+	}
+}
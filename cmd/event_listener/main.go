@@ -0,0 +1,62 @@
+// Пример потребителя событий о матчах: подписывается на канал
+// matchmaking:events:{region}:{game_mode} и печатает каждый созданный матч.
+// Адрес Redis берется из тех же переменных окружения, что и у сервиса.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"chrono-matchmaking/config"
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/storage"
+	"go.uber.org/zap"
+)
+
+func main() {
+	region := flag.String("region", "EU", "queue region")
+	gameMode := flag.String("mode", "3v3", "queue game mode")
+	flag.Parse()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		panic(fmt.Sprintf("Failed to initialize logger: %v", err))
+	}
+	defer logger.Sync()
+	logging.SetLogger(logger)
+
+	cfg := config.Default()
+	if err := cfg.ApplyEnv(); err != nil {
+		logger.Fatal("Invalid environment configuration", zap.Error(err))
+	}
+
+	redisStorage, err := storage.NewRedisStorage(cfg.Redis.Addr, cfg.Redis.Password, cfg.Redis.DB, cfg.Redis.Pool, logger)
+	if err != nil {
+		logger.Fatal("Failed to connect to Redis", zap.Error(err))
+	}
+	defer redisStorage.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	matches, err := redisStorage.SubscribeMatches(ctx, *region, *gameMode)
+	if err != nil {
+		logger.Fatal("Failed to subscribe to match events", zap.Error(err))
+	}
+	logger.Info("Listening for match events",
+		zap.String("region", *region),
+		zap.String("game_mode", *gameMode),
+	)
+
+	encoder := json.NewEncoder(os.Stdout)
+	for match := range matches {
+		if err := encoder.Encode(match); err != nil {
+			logger.Warn("Failed to print match", zap.String("match_id", match.MatchID), zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,37 @@
+// Записывает описание HTTP API в формате OpenAPI 3.0 в файл, например для генерации
+// клиентов. Перед записью спецификация проверяется; при ошибках файл не создается.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"chrono-matchmaking/docs"
+)
+
+func main() {
+	output := flag.String("o", "openapi.json", "output file")
+	flag.Parse()
+
+	spec := docs.Build()
+	if problems := spec.Validate(); len(problems) > 0 {
+		for _, p := range problems {
+			fmt.Fprintln(os.Stderr, p)
+		}
+		os.Exit(1)
+	}
+
+	data, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to encode spec: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*output, append(data, '\n'), 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write spec: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("OpenAPI spec written to %s (%d paths)\n", *output, len(spec.Paths))
+}
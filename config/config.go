@@ -0,0 +1,230 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"chrono-matchmaking/service"
+	"chrono-matchmaking/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// Значения по умолчанию для настроек, не заданных ни в файле, ни в окружении
+const (
+	defaultRedisAddr      = "localhost:6379"
+	defaultRedisPassword  = "0000" // Пароль из docker-compose.yml
+	defaultRedisDB        = 0
+	defaultHTTPPort       = 8080
+	defaultGRPCPort       = 9090
+	defaultGameServiceURL = "http://localhost:8081"
+)
+
+// Config настройки запуска сервиса: хранилище, серверы и конфигурация матчмейкера
+type Config struct {
+	Redis   RedisConfig
+	Server  ServerConfig
+	Matcher *service.MatcherConfig
+}
+
+// RedisConfig настройки хранилища
+type RedisConfig struct {
+	Backend  string `yaml:"backend"` // "memory" — хранилище в памяти процесса, иначе Redis
+	Addr     string `yaml:"addr"`
+	Password string `yaml:"password"`
+	DB       int    `yaml:"db"`
+
+	Pool storage.RedisPoolConfig `yaml:"pool"` // Пул соединений; нулевые значения — настройки клиента по умолчанию
+}
+
+// ServerConfig настройки HTTP и gRPC серверов
+type ServerConfig struct {
+	HTTPPort       int    `yaml:"http_port"`
+	GRPCPort       int    `yaml:"grpc_port"`
+	GameServiceURL string `yaml:"game_service_url"`
+
+	QueueDrainTTL time.Duration `yaml:"queue_drain_ttl"` // Через сколько после остановки удалить оставшихся в очереди игроков (0 — не удалять)
+}
+
+// fileConfig структура YAML-файла. Раздел matcher разбирается так же, как JSON
+// конфигурации матчмейкера, чтобы ключи и проверки полей совпадали с PUT /admin/config.
+type fileConfig struct {
+	Redis   RedisConfig            `yaml:"redis"`
+	Server  ServerConfig           `yaml:"server"`
+	Matcher map[string]interface{} `yaml:"matcher"`
+}
+
+// Default возвращает конфигурацию со значениями по умолчанию
+func Default() *Config {
+	return &Config{
+		Redis: RedisConfig{
+			Addr:     defaultRedisAddr,
+			Password: defaultRedisPassword,
+			DB:       defaultRedisDB,
+		},
+		Server: ServerConfig{
+			HTTPPort:       defaultHTTPPort,
+			GRPCPort:       defaultGRPCPort,
+			GameServiceURL: defaultGameServiceURL,
+		},
+		Matcher: service.DefaultMatcherConfig(),
+	}
+}
+
+// LoadFromFile читает YAML-файл конфигурации. Отсутствующие в файле поля
+// получают значения по умолчанию.
+func LoadFromFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	cfg := Default()
+	file := fileConfig{Redis: cfg.Redis, Server: cfg.Server}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid config yaml %s: %w", path, err)
+	}
+	cfg.Redis = file.Redis
+	cfg.Server = file.Server
+
+	if len(file.Matcher) > 0 {
+		matcherJSON, err := json.Marshal(file.Matcher)
+		if err != nil {
+			return nil, fmt.Errorf("invalid matcher section: %w", err)
+		}
+		if cfg.Matcher, err = service.ParseMatcherConfig(matcherJSON); err != nil {
+			return nil, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// ApplyEnv заменяет значения конфигурации заданными переменными окружения:
+// переменные окружения важнее файла
+func (c *Config) ApplyEnv() error {
+	if value := os.Getenv("STORAGE_BACKEND"); value != "" {
+		c.Redis.Backend = value
+	}
+	if value := os.Getenv("REDIS_ADDR"); value != "" {
+		c.Redis.Addr = value
+	}
+	if value := os.Getenv("REDIS_PASSWORD"); value != "" {
+		c.Redis.Password = value
+	}
+	if value := os.Getenv("REDIS_DB"); value != "" {
+		db, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid REDIS_DB %q: must be an integer", value)
+		}
+		c.Redis.DB = db
+	}
+	if value := os.Getenv("REDIS_MAX_CONNS"); value != "" {
+		conns, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid REDIS_MAX_CONNS %q: must be an integer", value)
+		}
+		c.Redis.Pool.MaxConnections = conns
+	}
+	if value := os.Getenv("REDIS_MIN_IDLE_CONNS"); value != "" {
+		conns, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid REDIS_MIN_IDLE_CONNS %q: must be an integer", value)
+		}
+		c.Redis.Pool.MinIdleConns = conns
+	}
+	if value := os.Getenv("REDIS_CONN_MAX_LIFETIME"); value != "" {
+		lifetime, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid REDIS_CONN_MAX_LIFETIME %q: %w", value, err)
+		}
+		c.Redis.Pool.ConnMaxLifetime = lifetime
+	}
+	if value := os.Getenv("REDIS_CONN_MAX_IDLE_TIME"); value != "" {
+		idleTime, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid REDIS_CONN_MAX_IDLE_TIME %q: %w", value, err)
+		}
+		c.Redis.Pool.ConnMaxIdleTime = idleTime
+	}
+
+	if value := os.Getenv("HTTP_PORT"); value != "" {
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid HTTP_PORT %q: must be an integer", value)
+		}
+		c.Server.HTTPPort = port
+	}
+	if value := os.Getenv("GRPC_PORT"); value != "" {
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid GRPC_PORT %q: must be an integer", value)
+		}
+		c.Server.GRPCPort = port
+	}
+	if value := os.Getenv("GAME_SERVICE_URL"); value != "" {
+		c.Server.GameServiceURL = value
+	}
+	if value := os.Getenv("QUEUE_DRAIN_TTL"); value != "" {
+		ttl, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid QUEUE_DRAIN_TTL %q: %w", value, err)
+		}
+		c.Server.QueueDrainTTL = ttl
+	}
+
+	if value := os.Getenv("RATE_LIMIT"); value != "" {
+		rateLimit, err := strconv.Atoi(value)
+		if err != nil || rateLimit < 0 {
+			return fmt.Errorf("invalid RATE_LIMIT %q: must be a non-negative integer", value)
+		}
+		c.Matcher.RateLimit = rateLimit
+	}
+	if value := os.Getenv("MATCHING_ALGORITHM"); value != "" {
+		c.Matcher.Algorithm = value
+	}
+
+	return nil
+}
+
+// Validate проверяет обязательные настройки запуска
+func (c *Config) Validate() error {
+	if c.Redis.Backend != "memory" && c.Redis.Addr == "" {
+		return fmt.Errorf("invalid config: redis.addr must not be empty")
+	}
+	pool := c.Redis.Pool
+	if pool.MaxConnections < 0 || pool.MinIdleConns < 0 || pool.ConnMaxLifetime < 0 || pool.ConnMaxIdleTime < 0 {
+		return fmt.Errorf("invalid config: redis.pool values must not be negative")
+	}
+	if pool.MaxConnections > 0 && pool.MinIdleConns > pool.MaxConnections {
+		return fmt.Errorf("invalid config: redis.pool.min_idle_conns must not exceed max_connections")
+	}
+	if c.Server.HTTPPort <= 0 || c.Server.HTTPPort > 65535 {
+		return fmt.Errorf("invalid config: server.http_port must be between 1 and 65535, got %d", c.Server.HTTPPort)
+	}
+	if c.Server.GRPCPort <= 0 || c.Server.GRPCPort > 65535 {
+		return fmt.Errorf("invalid config: server.grpc_port must be between 1 and 65535, got %d", c.Server.GRPCPort)
+	}
+	if c.Server.QueueDrainTTL < 0 {
+		return fmt.Errorf("invalid config: server.queue_drain_ttl must not be negative")
+	}
+	if c.Server.HTTPPort == c.Server.GRPCPort {
+		return fmt.Errorf("invalid config: server.http_port and server.grpc_port must differ")
+	}
+	if _, err := service.NewMatchingAlgorithm(c.Matcher.Algorithm); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	return nil
+}
+
+// HTTPAddr возвращает адрес HTTP-сервера
+func (c *Config) HTTPAddr() string {
+	return fmt.Sprintf(":%d", c.Server.HTTPPort)
+}
+
+// GRPCAddr возвращает адрес gRPC-сервера
+func (c *Config) GRPCAddr() string {
+	return fmt.Sprintf(":%d", c.Server.GRPCPort)
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"chrono-matchmaking/service"
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+// Watcher следит за JSON-файлом конфигурации матчмейкера и применяет его
+// без перезапуска сервиса, не затрагивая очереди
+type Watcher struct {
+	path    string
+	matcher *service.MatcherService
+	logger  *zap.Logger
+}
+
+// NewWatcher создает наблюдатель за файлом конфигурации
+func NewWatcher(path string, matcher *service.MatcherService, logger *zap.Logger) *Watcher {
+	return &Watcher{
+		path:    filepath.Clean(path),
+		matcher: matcher,
+		logger:  logger,
+	}
+}
+
+// Load читает файл и применяет конфигурацию
+func (w *Watcher) Load() error {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	cfg, err := service.ParseMatcherConfig(data)
+	if err != nil {
+		return err
+	}
+	return w.matcher.UpdateConfig(cfg)
+}
+
+// Run применяет файл при каждом изменении, пока не будет отменен ctx.
+// Наблюдаем за каталогом, а не за файлом: редакторы и ConfigMap в Kubernetes
+// заменяют файл переименованием, и наблюдение за самим файлом терялось бы.
+func (w *Watcher) Run(ctx context.Context) error {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	defer fsWatcher.Close()
+
+	if err := fsWatcher.Add(filepath.Dir(w.path)); err != nil {
+		return fmt.Errorf("failed to watch config directory: %w", err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case event, ok := <-fsWatcher.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(event.Name) != w.path || !event.Has(fsnotify.Write|fsnotify.Create) {
+				continue
+			}
+
+			// Невалидный файл не применяется, сервис продолжает работать на прежней конфигурации
+			if err := w.Load(); err != nil {
+				w.logger.Error("Failed to reload matcher config",
+					zap.String("path", w.path),
+					zap.Error(err),
+				)
+				continue
+			}
+			w.logger.Info("Matcher config reloaded from file", zap.String("path", w.path))
+
+		case err, ok := <-fsWatcher.Errors:
+			if !ok {
+				return nil
+			}
+			w.logger.Warn("Config file watcher error", zap.Error(err))
+		}
+	}
+}
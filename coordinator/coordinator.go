@@ -0,0 +1,186 @@
+package coordinator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/storage"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	leaderTTL           = 15 * time.Second // Время жизни блокировки лидера
+	leaderRenewInterval = 5 * time.Second  // Период продления блокировки
+)
+
+// MatchFormedEvent событие о созданном матче, рассылаемое репликам
+type MatchFormedEvent struct {
+	InstanceID string        `json:"instance_id"` // Реплика-лидер, создавшая матч
+	Match      *models.Match `json:"match"`
+}
+
+// Coordinator выбирает лидера для каждой пары регион/режим среди реплик сервиса.
+// Только лидер обрабатывает очередь, остальные реплики получают события о матчах через pub/sub.
+type Coordinator struct {
+	storage    storage.Storage
+	logger     *zap.Logger
+	instanceID string
+
+	mu     sync.Mutex
+	leases map[string]context.CancelFunc // Ключ блокировки -> остановка продления
+}
+
+// NewCoordinator создает координатор с уникальным идентификатором реплики
+func NewCoordinator(storage storage.Storage, logger *zap.Logger) *Coordinator {
+	return &Coordinator{
+		storage:    storage,
+		logger:     logger,
+		instanceID: uuid.New().String(),
+		leases:     make(map[string]context.CancelFunc),
+	}
+}
+
+// InstanceID возвращает идентификатор этой реплики
+func (c *Coordinator) InstanceID() string {
+	return c.instanceID
+}
+
+// BecomeLeader пытается стать лидером для очереди. Если блокировка захвачена,
+// она продлевается в фоне, пока не будет вызван ResignLeadership или блокировка не будет потеряна.
+func (c *Coordinator) BecomeLeader(ctx context.Context, region, gameMode string) (bool, error) {
+	key := leaderKey(region, gameMode)
+
+	c.mu.Lock()
+	_, held := c.leases[key]
+	c.mu.Unlock()
+	if held {
+		return true, nil
+	}
+
+	acquired, err := c.storage.AcquireLock(ctx, key, c.instanceID, leaderTTL)
+	if err != nil {
+		return false, err
+	}
+	if !acquired {
+		return false, nil
+	}
+
+	renewCtx, cancel := context.WithCancel(context.Background())
+	c.mu.Lock()
+	c.leases[key] = cancel
+	c.mu.Unlock()
+
+	go c.renew(renewCtx, key)
+
+	c.logger.Info("Became queue leader",
+		zap.String("region", region),
+		zap.String("game_mode", gameMode),
+		zap.String("instance_id", c.instanceID),
+	)
+
+	return true, nil
+}
+
+// ResignLeadership прекращает продление и снимает блокировку лидера
+func (c *Coordinator) ResignLeadership(ctx context.Context, region, gameMode string) error {
+	key := leaderKey(region, gameMode)
+
+	c.mu.Lock()
+	cancel, held := c.leases[key]
+	delete(c.leases, key)
+	c.mu.Unlock()
+
+	if !held {
+		return nil
+	}
+	cancel()
+
+	if err := c.storage.ReleaseLock(ctx, key, c.instanceID); err != nil {
+		return err
+	}
+
+	c.logger.Info("Resigned queue leadership",
+		zap.String("region", region),
+		zap.String("game_mode", gameMode),
+	)
+
+	return nil
+}
+
+// renew периодически продлевает блокировку до отмены ctx или потери лидерства
+func (c *Coordinator) renew(ctx context.Context, key string) {
+	ticker := time.NewTicker(leaderRenewInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ok, err := c.storage.RenewLock(ctx, key, c.instanceID, leaderTTL)
+			if err != nil {
+				// Временная ошибка: блокировка еще может быть жива, попробуем снова
+				c.logger.Warn("Failed to renew leader lock", zap.String("key", key), zap.Error(err))
+				continue
+			}
+			if !ok {
+				c.logger.Warn("Lost queue leadership", zap.String("key", key))
+				c.mu.Lock()
+				delete(c.leases, key)
+				c.mu.Unlock()
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// PublishMatchFormed сообщает остальным репликам о созданном матче
+func (c *Coordinator) PublishMatchFormed(ctx context.Context, region, gameMode string, match *models.Match) error {
+	payload, err := json.Marshal(MatchFormedEvent{
+		InstanceID: c.instanceID,
+		Match:      match,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal match formed event: %w", err)
+	}
+
+	return c.storage.Publish(ctx, channelKey(region, gameMode), payload)
+}
+
+// SubscribeMatchFormed вызывает handler для каждого матча, созданного другой репликой.
+// Блокируется до отмены ctx.
+func (c *Coordinator) SubscribeMatchFormed(ctx context.Context, region, gameMode string, handler func(match *models.Match)) error {
+	messages, err := c.storage.Subscribe(ctx, channelKey(region, gameMode))
+	if err != nil {
+		return err
+	}
+
+	for payload := range messages {
+		var event MatchFormedEvent
+		if err := json.Unmarshal(payload, &event); err != nil {
+			c.logger.Warn("Failed to unmarshal match formed event", zap.Error(err))
+			continue
+		}
+		if event.InstanceID == c.instanceID || event.Match == nil {
+			continue // Собственные события уже обработаны локально
+		}
+		handler(event.Match)
+	}
+
+	return ctx.Err()
+}
+
+// leaderKey возвращает ключ блокировки лидера очереди
+func leaderKey(region, gameMode string) string {
+	return fmt.Sprintf("leader:%s:%s", region, gameMode)
+}
+
+// channelKey возвращает канал событий очереди
+func channelKey(region, gameMode string) string {
+	return fmt.Sprintf("coord:queue:%s:%s", region, gameMode)
+}
@@ -0,0 +1,278 @@
+// Package docs описывает HTTP API сервиса в формате OpenAPI 3.0. Маршруты перечислены
+// в routes, схемы запросов и ответов строятся по структурам пакета models, поэтому
+// изменение модели сразу попадает в описание.
+package docs
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// OpenAPIVersion версия формата спецификации
+const OpenAPIVersion = "3.0.3"
+
+// APIVersion версия описываемого API
+const APIVersion = "1.0.0"
+
+// Spec корневой объект спецификации OpenAPI
+type Spec struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+// Info сведения об API
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// PathItem операции одного пути по HTTP-методам
+type PathItem struct {
+	Get    *Operation `json:"get,omitempty"`
+	Put    *Operation `json:"put,omitempty"`
+	Post   *Operation `json:"post,omitempty"`
+	Delete *Operation `json:"delete,omitempty"`
+	Patch  *Operation `json:"patch,omitempty"`
+}
+
+// Operation описание одной операции
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter параметр пути или строки запроса
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody тело запроса
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response ответ операции
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType схема тела запроса или ответа
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components именованные схемы, на которые ссылаются операции
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Schema схема JSON-значения (подмножество OpenAPI Schema Object, которого хватает
+// для моделей сервиса)
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// pathParamPattern параметры пути вида {player_id}
+var pathParamPattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+// Build строит спецификацию по таблице маршрутов routes
+func Build() *Spec {
+	spec := &Spec{
+		OpenAPI: OpenAPIVersion,
+		Info: Info{
+			Title:   "Chrono Matchmaking API",
+			Version: APIVersion,
+		},
+		Paths: make(map[string]*PathItem),
+	}
+	schemas := newSchemaRegistry()
+
+	for _, route := range routes {
+		op := &Operation{
+			OperationID: route.operationID(),
+			Summary:     route.Summary,
+			Tags:        []string{route.Tag},
+			Responses:   make(map[string]*Response),
+		}
+		for _, match := range pathParamPattern.FindAllStringSubmatch(route.Path, -1) {
+			op.Parameters = append(op.Parameters, &Parameter{
+				Name:     match[1],
+				In:       "path",
+				Required: true,
+				Schema:   &Schema{Type: "string"},
+			})
+		}
+		for _, name := range route.Query {
+			op.Parameters = append(op.Parameters, &Parameter{
+				Name:   name,
+				In:     "query",
+				Schema: &Schema{Type: "string"},
+			})
+		}
+		if route.Request != nil {
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content: map[string]*MediaType{
+					route.contentType(): {Schema: schemas.schemaFor(route.Request)},
+				},
+			}
+		}
+
+		response := &Response{Description: route.Summary}
+		if route.Response != nil {
+			response.Content = map[string]*MediaType{
+				route.contentType(): {Schema: schemas.schemaFor(route.Response)},
+			}
+		}
+		op.Responses[route.status()] = response
+		op.Responses["default"] = &Response{
+			Description: "Ошибка",
+			Content: map[string]*MediaType{
+				"application/json": {Schema: schemas.schemaFor(errorResponseType)},
+			},
+		}
+
+		item, ok := spec.Paths[route.Path]
+		if !ok {
+			item = &PathItem{}
+			spec.Paths[route.Path] = item
+		}
+		item.set(route.Method, op)
+	}
+
+	spec.Components.Schemas = schemas.schemas
+	return spec
+}
+
+// set записывает операцию в поле метода
+func (p *PathItem) set(method string, op *Operation) {
+	switch method {
+	case http.MethodGet:
+		p.Get = op
+	case http.MethodPut:
+		p.Put = op
+	case http.MethodPost:
+		p.Post = op
+	case http.MethodDelete:
+		p.Delete = op
+	case http.MethodPatch:
+		p.Patch = op
+	}
+}
+
+// Validate проверяет спецификацию: у каждой операции есть ответ, а все ссылки $ref
+// ведут на объявленные схемы. Возвращает найденные ошибки, пустой список — ошибок нет.
+func (s *Spec) Validate() []string {
+	var problems []string
+	if len(s.Paths) == 0 {
+		problems = append(problems, "spec has no paths")
+	}
+
+	var checkSchema func(where string, schema *Schema)
+	checkSchema = func(where string, schema *Schema) {
+		if schema == nil {
+			return
+		}
+		if schema.Ref != "" {
+			name := strings.TrimPrefix(schema.Ref, schemaRefPrefix)
+			if _, ok := s.Components.Schemas[name]; !ok {
+				problems = append(problems, where+": unresolved reference "+schema.Ref)
+			}
+		}
+		for name, prop := range schema.Properties {
+			checkSchema(where+"."+name, prop)
+		}
+		checkSchema(where+"[]", schema.Items)
+		checkSchema(where+"{}", schema.AdditionalProperties)
+	}
+
+	for name, schema := range s.Components.Schemas {
+		checkSchema("components.schemas."+name, schema)
+	}
+	for path, item := range s.Paths {
+		for method, op := range item.operations() {
+			where := method + " " + path
+			if op.OperationID == "" {
+				problems = append(problems, where+": missing operationId")
+			}
+			if len(op.Responses) == 0 {
+				problems = append(problems, where+": no responses")
+			}
+			for _, param := range op.Parameters {
+				if param.In == "path" && !strings.Contains(path, "{"+param.Name+"}") {
+					problems = append(problems, where+": path parameter "+param.Name+" is not in path")
+				}
+			}
+			if op.RequestBody != nil {
+				for _, media := range op.RequestBody.Content {
+					checkSchema(where+" request", media.Schema)
+				}
+			}
+			for code, resp := range op.Responses {
+				for _, media := range resp.Content {
+					checkSchema(where+" "+code, media.Schema)
+				}
+			}
+		}
+	}
+
+	sort.Strings(problems)
+	return problems
+}
+
+// operations возвращает заданные операции пути по HTTP-методам
+func (p *PathItem) operations() map[string]*Operation {
+	ops := make(map[string]*Operation)
+	for method, op := range map[string]*Operation{
+		http.MethodGet:    p.Get,
+		http.MethodPut:    p.Put,
+		http.MethodPost:   p.Post,
+		http.MethodDelete: p.Delete,
+		http.MethodPatch:  p.Patch,
+	} {
+		if op != nil {
+			ops[method] = op
+		}
+	}
+	return ops
+}
+
+var (
+	specOnce sync.Once
+	specJSON []byte
+)
+
+// Handler отдает спецификацию в JSON. Спецификация строится один раз при первом запросе.
+func Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		specOnce.Do(func() {
+			specJSON, _ = json.Marshal(Build())
+		})
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(specJSON)
+	}
+}
@@ -0,0 +1,97 @@
+package docs
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBuildValidates(t *testing.T) {
+	if problems := Build().Validate(); len(problems) != 0 {
+		t.Errorf("Validate() found %d problems:", len(problems))
+		for _, p := range problems {
+			t.Error(p)
+		}
+	}
+}
+
+// TestHandlerServesValidSpec проверяет спецификацию в том виде, в каком ее получает
+// клиент: ответ GET /openapi.json разбирается обратно и проходит проверку
+func TestHandlerServesValidSpec(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handler()(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json = %d, want 200", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+
+	var spec Spec
+	if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("invalid spec JSON: %v", err)
+	}
+	if spec.OpenAPI != OpenAPIVersion {
+		t.Errorf("openapi = %q, want %q", spec.OpenAPI, OpenAPIVersion)
+	}
+	if len(spec.Paths) != len(Build().Paths) {
+		t.Errorf("served spec has %d paths, want %d", len(spec.Paths), len(Build().Paths))
+	}
+	for _, p := range spec.Validate() {
+		t.Errorf("served spec: %s", p)
+	}
+}
+
+// TestBuildDeclaresPathParameters проверяет, что каждый параметр в шаблоне пути
+// описан в операции
+func TestBuildDeclaresPathParameters(t *testing.T) {
+	for path, item := range Build().Paths {
+		for method, op := range item.operations() {
+			declared := make(map[string]bool)
+			for _, param := range op.Parameters {
+				if param.In == "path" {
+					declared[param.Name] = true
+				}
+			}
+			for _, match := range pathParamPattern.FindAllStringSubmatch(path, -1) {
+				if !declared[match[1]] {
+					t.Errorf("%s %s: path parameter %s is not declared", method, path, match[1])
+				}
+			}
+		}
+	}
+}
+
+func TestValidateReportsProblems(t *testing.T) {
+	spec := &Spec{
+		OpenAPI: OpenAPIVersion,
+		Paths: map[string]*PathItem{
+			"/players/{player_id}": {Get: &Operation{
+				Parameters: []*Parameter{{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "string"}}},
+				Responses: map[string]*Response{"200": {
+					Description: "OK",
+					Content:     map[string]*MediaType{"application/json": {Schema: &Schema{Ref: schemaRefPrefix + "Missing"}}},
+				}},
+			}},
+			"/players": {Post: &Operation{OperationID: "createPlayer"}},
+		},
+		Components: Components{Schemas: map[string]*Schema{}},
+	}
+
+	want := []string{
+		"GET /players/{player_id} 200: unresolved reference " + schemaRefPrefix + "Missing",
+		"GET /players/{player_id}: missing operationId",
+		"GET /players/{player_id}: path parameter id is not in path",
+		"POST /players: no responses",
+	}
+	got := spec.Validate()
+	if len(got) != len(want) {
+		t.Fatalf("Validate() = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Validate()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package docs
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/service"
+	"github.com/gorilla/mux"
+)
+
+// route описание одного маршрута HTTP API
+type route struct {
+	Method      string
+	Path        string
+	Tag         string
+	Summary     string
+	Query       []string     // Параметры строки запроса
+	Request     reflect.Type // Тип тела запроса; nil — без тела
+	Response    reflect.Type // Тип тела успешного ответа; nil — без JSON-тела
+	Status      int          // Код успешного ответа; 0 — 200
+	ContentType string       // Тип содержимого тела; пусто — application/json
+}
+
+// typeOf возвращает reflect.Type для значения; короче в таблице маршрутов
+func typeOf(v interface{}) reflect.Type {
+	return reflect.TypeOf(v)
+}
+
+// operationIDPattern символы пути, которые не попадают в operationId
+var operationIDPattern = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// operationID строит operationId из метода и пути: POST /api/v1/queue/join — post_queue_join
+func (r route) operationID() string {
+	path := strings.TrimPrefix(r.Path, "/api/v1")
+	id := operationIDPattern.ReplaceAllString(path, "_")
+	return strings.ToLower(r.Method) + "_" + strings.Trim(id, "_")
+}
+
+// status возвращает код успешного ответа строкой
+func (r route) status() string {
+	if r.Status == 0 {
+		return strconv.Itoa(http.StatusOK)
+	}
+	return strconv.Itoa(r.Status)
+}
+
+// contentType возвращает тип содержимого тел запроса и ответа
+func (r route) contentType() string {
+	if r.ContentType == "" {
+		return "application/json"
+	}
+	return r.ContentType
+}
+
+// Ответы, которые обработчики собирают из map; поля повторяют ключи этих map
+
+// errorResponse ответ с ошибкой
+type errorResponse struct {
+	Error   string `json:"error"`
+	Details string `json:"details,omitempty"`
+}
+
+var errorResponseType = typeOf(errorResponse{})
+
+// playerStatusResponse ответ на действие с игроком в очереди
+type playerStatusResponse struct {
+	PlayerID string `json:"player_id"`
+	Status   string `json:"status"`
+	Message  string `json:"message,omitempty"`
+}
+
+// partyQueuedResponse ответ на постановку группы в очередь
+type partyQueuedResponse struct {
+	PartyID   string   `json:"party_id"`
+	PlayerIDs []string `json:"player_ids"`
+	Status    string   `json:"status"`
+	Message   string   `json:"message"`
+}
+
+// heartbeatResponse ответ на heartbeat игрока
+type heartbeatResponse struct {
+	PlayerID         string `json:"player_id"`
+	Status           string `json:"status"`
+	ExpiresInSeconds int64  `json:"expires_in_seconds"`
+}
+
+// pendingStatusResponse ответ на подтверждение или отказ от матча
+type pendingStatusResponse struct {
+	PendingID string `json:"pending_id"`
+	PlayerID  string `json:"player_id"`
+	Status    string `json:"status"`
+	Message   string `json:"message"`
+}
+
+// privateMatchCreatedResponse ответ на создание приватного матча
+type privateMatchCreatedResponse struct {
+	InviteCode    string    `json:"invite_code"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	PlayersNeeded int       `json:"players_needed"`
+}
+
+// queueStatusResponse состояние очереди
+type queueStatusResponse struct {
+	Region           string                `json:"region"`
+	GameMode         string                `json:"game_mode"`
+	QueueSize        int64                 `json:"queue_size"`
+	PremiumQueueSize int64                 `json:"premium_queue_size"`
+	ActivePlayers    int64                 `json:"active_players"`
+	StalePlayers     int64                 `json:"stale_players"`
+	Timestamp        int64                 `json:"timestamp"`
+	Brackets         []models.BracketDepth `json:"brackets,omitempty"` // Только с ?breakdown=true
+}
+
+// regionLatencyResponse задержка между двумя регионами
+type regionLatencyResponse struct {
+	Region1   string `json:"region1"`
+	Region2   string `json:"region2"`
+	LatencyMs int    `json:"latency_ms"`
+}
+
+// ratingHistoryResponse история рейтинга игрока
+type ratingHistoryResponse struct {
+	PlayerID string               `json:"player_id"`
+	History  []models.RatingPoint `json:"history"`
+}
+
+// matchHistoryResponse история матчей игрока
+type matchHistoryResponse struct {
+	PlayerID string          `json:"player_id"`
+	Matches  []*models.Match `json:"matches"`
+}
+
+// configResponse конфигурация матчмейкера в ответе GET /admin/config
+type configResponse struct {
+	service.MatcherConfig
+	OffPeakActive bool `json:"off_peak_active"`
+}
+
+// topWaitingResponse дольше всех ожидающие игроки
+type topWaitingResponse struct {
+	Region   string                     `json:"region"`
+	GameMode string                     `json:"game_mode"`
+	Players  []models.WaitingPlayerInfo `json:"players"`
+}
+
+// reindexResponse результат переиндексации очереди
+type reindexResponse struct {
+	Updated int `json:"updated"`
+}
+
+// banResponse результат бана игрока
+type banResponse struct {
+	PlayerID    string    `json:"player_id"`
+	BannedUntil time.Time `json:"banned_until"`
+}
+
+// flaggedPlayersResponse игроки, отмеченные за частые выходы из очереди
+type flaggedPlayersResponse struct {
+	PlayerIDs []string `json:"player_ids"`
+}
+
+// unflagPlayerResponse результат снятия отметки с игрока
+type unflagPlayerResponse struct {
+	PlayerID string `json:"player_id"`
+	Status   string `json:"status"`
+}
+
+// serverStatusResponse результат регистрации или удаления игрового сервера
+type serverStatusResponse struct {
+	ServerID string `json:"server_id"`
+	Status   string `json:"status"`
+}
+
+// matchValidationResponse результат проверки целостности матча
+type matchValidationResponse struct {
+	MatchID    string   `json:"match_id"`
+	Valid      bool     `json:"valid"`
+	Violations []string `json:"violations"`
+}
+
+// queueQuery параметры очереди в строке запроса
+var queueQuery = []string{"region", "game_mode"}
+
+// routes все маршруты HTTP API. Новый маршрут в main.go добавляется и сюда:
+// при запуске сервис пишет в лог маршруты, которых здесь нет (Undocumented).
+var routes = []route{
+	// Очередь
+	{Method: http.MethodPost, Path: "/api/v1/queue/join", Tag: "queue", Summary: "Добавить игрока в очередь", Request: typeOf(models.MatchRequest{}), Response: typeOf(playerStatusResponse{})},
+	{Method: http.MethodPost, Path: "/api/v1/queue/join/batch", Tag: "queue", Summary: "Добавить в очередь несколько игроков", Request: typeOf(models.BatchJoinRequest{}), Response: typeOf(models.BatchJoinResponse{})},
+	{Method: http.MethodPost, Path: "/api/v1/queue/party/join", Tag: "queue", Summary: "Добавить группу в очередь", Request: typeOf(models.PartyRequest{}), Response: typeOf(partyQueuedResponse{})},
+	{Method: http.MethodDelete, Path: "/api/v1/queue/leave/{player_id}", Tag: "queue", Summary: "Удалить игрока из очереди", Response: typeOf(playerStatusResponse{})},
+	{Method: http.MethodPut, Path: "/api/v1/queue/player/{player_id}", Tag: "queue", Summary: "Изменить игрока в очереди", Request: typeOf(models.UpdateQueuedPlayerRequest{}), Response: typeOf(playerStatusResponse{})},
+	{Method: http.MethodGet, Path: "/api/v1/queue/match/{player_id}", Tag: "queue", Summary: "Найти матч для игрока (202 и ожидающий подтверждения матч, если он требует подтверждения)", Response: typeOf(models.Match{})},
+	{Method: http.MethodGet, Path: "/api/v1/queue/match/{player_id}/reconnect", Tag: "queue", Summary: "Переподключиться к матчу", Response: typeOf(models.Match{})},
+	{Method: http.MethodPost, Path: "/api/v1/queue/match/{pending_id}/accept", Tag: "queue", Summary: "Подтвердить матч (202, пока подтвердили не все)", Request: typeOf(models.ConfirmMatchRequest{}), Response: typeOf(models.Match{})},
+	{Method: http.MethodPost, Path: "/api/v1/queue/match/{pending_id}/decline", Tag: "queue", Summary: "Отказаться от матча", Request: typeOf(models.ConfirmMatchRequest{}), Response: typeOf(pendingStatusResponse{})},
+	{Method: http.MethodPost, Path: "/api/v1/match/private/create", Tag: "queue", Summary: "Создать приватный матч", Request: typeOf(models.CreatePrivateMatchRequest{}), Response: typeOf(privateMatchCreatedResponse{}), Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/api/v1/match/private/join", Tag: "queue", Summary: "Войти в приватный матч по коду", Request: typeOf(models.JoinPrivateMatchRequest{}), Response: typeOf(models.JoinPrivateMatchResponse{})},
+	{Method: http.MethodGet, Path: "/api/v1/queue/status", Tag: "queue", Summary: "Статус очереди", Query: []string{"region", "game_mode", "breakdown"}, Response: typeOf(queueStatusResponse{})},
+	{Method: http.MethodGet, Path: "/api/v1/queue/ws/{player_id}", Tag: "queue", Summary: "Уведомление о матче по WebSocket", Status: http.StatusSwitchingProtocols},
+	{Method: http.MethodGet, Path: "/api/v1/queue/segment-stats", Tag: "queue", Summary: "Статистика по рейтинговым сегментам", Query: queueQuery, Response: typeOf([]models.SegmentStats{})},
+	{Method: http.MethodGet, Path: "/api/v1/queue/position/{player_id}", Tag: "queue", Summary: "Позиция в очереди", Response: typeOf(models.QueuePosition{})},
+	{Method: http.MethodGet, Path: "/api/v1/queue/wait-estimate", Tag: "queue", Summary: "Оценка времени ожидания", Query: queueQuery, Response: typeOf(models.WaitEstimate{})},
+	{Method: http.MethodPost, Path: "/api/v1/queue/heartbeat/{player_id}", Tag: "queue", Summary: "Heartbeat игрока в очереди", Response: typeOf(heartbeatResponse{})},
+	{Method: http.MethodGet, Path: "/api/v1/events/queue", Tag: "queue", Summary: "Поток событий очереди (SSE)", Response: typeOf(models.QueueEvent{}), ContentType: "text/event-stream"},
+
+	// Матчи
+	{Method: http.MethodGet, Path: "/api/v1/matches/{match_id}/satisfaction", Tag: "matches", Summary: "Удовлетворенность матчем", Response: typeOf(models.SatisfactionScore{})},
+	{Method: http.MethodPost, Path: "/api/v1/matches/{match_id}/backfill", Tag: "matches", Summary: "Заменить отключившихся игроков", Request: typeOf(models.BackfillRequest{}), Response: typeOf(models.Match{})},
+	{Method: http.MethodPost, Path: "/api/v1/match/{match_id}/result", Tag: "matches", Summary: "Результат матча", Request: typeOf(models.MatchResultRequest{}), Response: typeOf(models.MatchResultResponse{})},
+	{Method: http.MethodPost, Path: "/api/v1/matches/{match_id}/ack", Tag: "matches", Summary: "Подтверждение матча игровым сервером", Response: typeOf(models.Match{})},
+
+	// Турниры
+	{Method: http.MethodPost, Path: "/api/v1/tournament", Tag: "tournaments", Summary: "Создать турнир", Request: typeOf(models.CreateTournamentRequest{}), Response: typeOf(models.Tournament{}), Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/api/v1/tournament/{id}", Tag: "tournaments", Summary: "Получить турнир", Response: typeOf(models.Tournament{})},
+	{Method: http.MethodPost, Path: "/api/v1/tournament/{id}/advance", Tag: "tournaments", Summary: "Перейти к следующему раунду", Request: typeOf(models.AdvanceTournamentRequest{}), Response: typeOf(models.Tournament{})},
+
+	// Игроки
+	{Method: http.MethodPost, Path: "/api/v1/players/register", Tag: "players", Summary: "Зарегистрировать игрока (201 для нового профиля)", Request: typeOf(models.RegisterRequest{}), Response: typeOf(models.PlayerProfile{})},
+	{Method: http.MethodGet, Path: "/api/v1/players/{player_id}/rating-advice", Tag: "players", Summary: "Рекомендации по рейтингу", Response: typeOf(models.RatingAdvice{})},
+	{Method: http.MethodGet, Path: "/api/v1/players/{player_id}/rating-history", Tag: "players", Summary: "История рейтинга игрока", Response: typeOf(ratingHistoryResponse{})},
+	{Method: http.MethodGet, Path: "/api/v1/players/{player_id}/history", Tag: "players", Summary: "История матчей игрока", Query: []string{"limit"}, Response: typeOf(matchHistoryResponse{})},
+	{Method: http.MethodGet, Path: "/api/v1/players/{player_id}/stats", Tag: "players", Summary: "Статистика игрока", Response: typeOf(models.PlayerStats{})},
+	{Method: http.MethodPost, Path: "/api/v1/players/{player_id}/push-token", Tag: "players", Summary: "Зарегистрировать токен устройства", Request: typeOf(models.PushTokenRequest{}), Response: typeOf(playerStatusResponse{})},
+
+	// Справочники и аналитика
+	{Method: http.MethodGet, Path: "/api/v1/config/region-latency", Tag: "config", Summary: "Задержки между регионами", Response: typeOf(map[string]map[string]int{})},
+	{Method: http.MethodGet, Path: "/api/v1/config/region-latency/{region1}/{region2}", Tag: "config", Summary: "Задержка между двумя регионами", Response: typeOf(regionLatencyResponse{})},
+	{Method: http.MethodGet, Path: "/api/v1/analytics/peak-hours", Tag: "analytics", Summary: "Пиковые часы очереди", Query: queueQuery, Response: typeOf(models.PeakHoursReport{})},
+
+	// Администрирование
+	{Method: http.MethodGet, Path: "/api/v1/admin/config", Tag: "admin", Summary: "Текущая конфигурация", Response: typeOf(configResponse{})},
+	{Method: http.MethodPut, Path: "/api/v1/admin/config", Tag: "admin", Summary: "Замена конфигурации", Request: typeOf(service.MatcherConfig{}), Response: typeOf(service.MatcherConfig{})},
+	{Method: http.MethodPatch, Path: "/api/v1/admin/config", Tag: "admin", Summary: "Частичное обновление конфигурации", Request: typeOf(map[string]interface{}{}), Response: typeOf(service.MatcherConfig{})},
+	{Method: http.MethodGet, Path: "/api/v1/admin/regions", Tag: "admin", Summary: "Активные регионы", Response: typeOf(models.RegionsConfig{})},
+	{Method: http.MethodPut, Path: "/api/v1/admin/regions", Tag: "admin", Summary: "Задать активные регионы", Request: typeOf(models.RegionsConfig{}), Response: typeOf(models.RegionsConfig{})},
+	{Method: http.MethodGet, Path: "/api/v1/admin/game_modes", Tag: "admin", Summary: "Режимы игры и размеры матчей", Response: typeOf(map[string]int{})},
+	{Method: http.MethodPut, Path: "/api/v1/admin/game_modes", Tag: "admin", Summary: "Задать режимы игры", Request: typeOf(map[string]int{}), Response: typeOf(map[string]int{})},
+	{Method: http.MethodGet, Path: "/api/v1/admin/queue/top-waiting", Tag: "admin", Summary: "Дольше всех ожидающие игроки", Query: []string{"region", "game_mode", "limit"}, Response: typeOf(topWaitingResponse{})},
+	{Method: http.MethodGet, Path: "/api/v1/admin/queue/snapshot", Tag: "admin", Summary: "Снимок очередей (NDJSON, по записи на строку)", Response: typeOf(models.QueueSnapshotEntry{}), ContentType: "application/x-ndjson"},
+	{Method: http.MethodPost, Path: "/api/v1/admin/queue/restore", Tag: "admin", Summary: "Восстановить очереди из снимка (NDJSON)", Request: typeOf(models.QueueSnapshotEntry{}), Response: typeOf(models.QueueRestoreResult{}), ContentType: "application/x-ndjson"},
+	{Method: http.MethodPost, Path: "/api/v1/admin/queue/reindex", Tag: "admin", Summary: "Переиндексация очереди", Query: queueQuery, Response: typeOf(reindexResponse{})},
+	{Method: http.MethodPost, Path: "/api/v1/admin/simulate", Tag: "admin", Summary: "Симуляция подбора", Query: queueQuery, Request: typeOf(models.SimulateRequest{}), Response: typeOf([]models.SimulatedMatch{})},
+	{Method: http.MethodGet, Path: "/api/v1/admin/dlq", Tag: "admin", Summary: "Группы с несохраненным матчем", Response: typeOf([]models.DLQEntry{})},
+	{Method: http.MethodPost, Path: "/api/v1/admin/dlq/{index}/retry", Tag: "admin", Summary: "Повторно сформировать матч группы из DLQ", Response: typeOf(models.Match{})},
+	{Method: http.MethodGet, Path: "/api/v1/admin/stats/distribution-comparison", Tag: "admin", Summary: "Сравнение распределений рейтинга", Query: []string{"region", "game_mode", "buckets"}, Response: typeOf(models.DistributionComparison{})},
+	{Method: http.MethodGet, Path: "/api/v1/admin/memory-usage", Tag: "admin", Summary: "Память Redis", Response: typeOf(models.MemoryUsageReport{})},
+	{Method: http.MethodGet, Path: "/api/v1/admin/ws/metrics", Tag: "admin", Summary: "Живые показатели очередей по WebSocket", Status: http.StatusSwitchingProtocols},
+	{Method: http.MethodPost, Path: "/api/v1/admin/ban", Tag: "admin", Summary: "Бан игрока", Request: typeOf(models.BanRequest{}), Response: typeOf(banResponse{})},
+	{Method: http.MethodGet, Path: "/api/v1/admin/flagged", Tag: "admin", Summary: "Игроки, часто выходящие из очереди", Response: typeOf(flaggedPlayersResponse{})},
+	{Method: http.MethodDelete, Path: "/api/v1/admin/flagged/{player_id}", Tag: "admin", Summary: "Снять отметку с игрока", Response: typeOf(unflagPlayerResponse{})},
+	{Method: http.MethodPost, Path: "/api/v1/admin/servers", Tag: "admin", Summary: "Зарегистрировать игровой сервер", Request: typeOf(models.RegisterServerRequest{}), Response: typeOf(serverStatusResponse{}), Status: http.StatusCreated},
+	{Method: http.MethodDelete, Path: "/api/v1/admin/servers/{server_id}", Tag: "admin", Summary: "Удалить игровой сервер", Response: typeOf(serverStatusResponse{})},
+	{Method: http.MethodPost, Path: "/api/v1/admin/season/reset", Tag: "admin", Summary: "Новый сезон", Request: typeOf(models.SeasonResetRequest{}), Response: typeOf(models.Season{})},
+	{Method: http.MethodPost, Path: "/api/v1/admin/matches/schedule", Tag: "admin", Summary: "Запланировать матч турнира", Request: typeOf(models.ScheduleMatchRequest{}), Response: typeOf(models.Match{}), Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/api/v1/admin/match/{match_id}", Tag: "admin", Summary: "Просмотр матча", Response: typeOf(models.Match{})},
+	{Method: http.MethodGet, Path: "/api/v1/admin/matches/{match_id}/validate", Tag: "admin", Summary: "Проверка целостности матча", Response: typeOf(matchValidationResponse{})},
+
+	// Служебные
+	{Method: http.MethodGet, Path: "/healthz/live", Tag: "health", Summary: "Проверка живости", ContentType: "text/plain"},
+	{Method: http.MethodGet, Path: "/healthz/ready", Tag: "health", Summary: "Проверка готовности (503, если Redis недоступен)", Response: typeOf(models.ReadinessResponse{})},
+	{Method: http.MethodGet, Path: "/metrics", Tag: "health", Summary: "Метрики Prometheus", ContentType: "text/plain"},
+	{Method: http.MethodGet, Path: "/openapi.json", Tag: "health", Summary: "Эта спецификация"},
+}
+
+// Undocumented возвращает маршруты роутера ("METHOD /path"), которых нет в описании API
+func Undocumented(router *mux.Router) []string {
+	documented := make(map[string]bool, len(routes))
+	for _, r := range routes {
+		documented[r.Method+" "+r.Path] = true
+	}
+
+	var missing []string
+	router.Walk(func(rt *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := rt.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := rt.GetMethods()
+		if err != nil {
+			// Маршрут без метода — префикс подроутера
+			return nil
+		}
+		for _, method := range methods {
+			if !documented[method+" "+path] {
+				missing = append(missing, method+" "+path)
+			}
+		}
+		return nil
+	})
+	return missing
+}
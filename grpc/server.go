@@ -0,0 +1,225 @@
+// Package grpc реализует gRPC API матчмейкинга поверх service.MatcherService
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/proto/matchmakingpb"
+	"chrono-matchmaking/service"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCServer обрабатывает вызовы MatchmakingService, делегируя их MatcherService.
+// Проверки запросов и коды ошибок соответствуют HTTP API.
+type GRPCServer struct {
+	matchmakingpb.UnimplementedMatchmakingServiceServer
+
+	matcher  *service.MatcherService
+	notifier *service.MatchNotifier
+	logger   *zap.Logger
+	server   *grpc.Server
+}
+
+// NewGRPCServer создает gRPC сервер с перехватчиками логирования и восстановления после паники
+func NewGRPCServer(matcher *service.MatcherService, notifier *service.MatchNotifier, logger *zap.Logger) *GRPCServer {
+	s := &GRPCServer{
+		matcher:  matcher,
+		notifier: notifier,
+		logger:   logger,
+	}
+	s.server = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(recoveryUnaryInterceptor(logger), loggingUnaryInterceptor(logger)),
+		grpc.ChainStreamInterceptor(recoveryStreamInterceptor(logger), loggingStreamInterceptor(logger)),
+	)
+	matchmakingpb.RegisterMatchmakingServiceServer(s.server, s)
+	return s
+}
+
+// Serve принимает соединения на lis до вызова Shutdown
+func (s *GRPCServer) Serve(lis net.Listener) error {
+	return s.server.Serve(lis)
+}
+
+// Shutdown перестает принимать соединения и дожидается завершения текущих вызовов.
+// Потоки уведомлений могут ждать матча сколько угодно, поэтому по истечении ctx
+// оставшиеся вызовы прерываются
+func (s *GRPCServer) Shutdown(ctx context.Context) {
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.server.Stop()
+	}
+}
+
+// JoinQueue ставит зарегистрированного игрока в очередь
+func (s *GRPCServer) JoinQueue(ctx context.Context, req *matchmakingpb.JoinQueueRequest) (*matchmakingpb.JoinQueueResponse, error) {
+	p := models.PlayerFromProto(req.GetPlayer())
+	if p == nil || p.ID == "" {
+		return nil, status.Error(codes.InvalidArgument, "player.id is required, register via POST /api/v1/players/register")
+	}
+
+	if _, err := s.matcher.GetPlayerProfile(ctx, p.ID); err != nil {
+		if errors.Is(err, service.ErrProfileNotFound) {
+			return nil, status.Error(codes.NotFound, "player profile not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to verify player profile: %v", err)
+	}
+
+	switch p.VoicePreference {
+	case "", models.VoicePreferenceRequired, models.VoicePreferencePreferred, models.VoicePreferenceNone:
+	default:
+		return nil, status.Error(codes.InvalidArgument, "voice_preference must be one of: required, preferred, none")
+	}
+	if p.VoicePreference == models.VoicePreferenceRequired && p.VoiceLanguage == "" {
+		return nil, status.Error(codes.InvalidArgument, "voice_language is required when voice_preference is required")
+	}
+	if !models.IsValidRole(p.Role) {
+		return nil, status.Error(codes.InvalidArgument, "role must be one of: tank, healer, dps")
+	}
+
+	// Время входа, возраст аккаунта и группу заполняет сервис, как и для HTTP запроса
+	player := models.NewPlayerFromRequest(&models.MatchRequest{
+		PlayerID:         p.ID,
+		Rating:           p.Rating,
+		Region:           p.Region,
+		GameMode:         p.GameMode,
+		PlayerLevel:      p.PlayerLevel,
+		VoicePreference:  p.VoicePreference,
+		VoiceLanguage:    p.VoiceLanguage,
+		AccountCreatedAt: p.AccountCreatedAt,
+		RatingDeviation:  p.RatingDeviation,
+		Volatility:       p.Volatility,
+		WinRate:          p.WinRate,
+		Role:             p.Role,
+		IsPremium:        p.IsPremium,
+	})
+
+	if err := s.matcher.AddPlayerToQueue(ctx, player); err != nil {
+		var bannedErr *service.PlayerBannedError
+		switch {
+		case errors.As(err, &bannedErr):
+			return nil, status.Errorf(codes.PermissionDenied, "player is banned until %s", bannedErr.BannedUntil.Format(time.RFC3339))
+		case errors.Is(err, service.ErrPlayerAlreadyQueued):
+			return nil, status.Error(codes.AlreadyExists, "player is already in queue")
+		case errors.Is(err, service.ErrPlayerOnCooldown):
+			return nil, status.Error(codes.ResourceExhausted, "player recently declined a match")
+		case errors.Is(err, service.ErrPlayerFlagged):
+			return nil, status.Error(codes.PermissionDenied, "player is flagged for behavioral review")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to add player to queue: %v", err)
+	}
+
+	return &matchmakingpb.JoinQueueResponse{PlayerId: player.ID, Status: "queued"}, nil
+}
+
+// LeaveQueue убирает игрока из очереди
+func (s *GRPCServer) LeaveQueue(ctx context.Context, req *matchmakingpb.LeaveQueueRequest) (*matchmakingpb.LeaveQueueResponse, error) {
+	if req.GetPlayerId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "player_id is required")
+	}
+
+	if err := s.matcher.RemovePlayerFromQueue(ctx, req.GetPlayerId()); err != nil {
+		return nil, status.Errorf(codes.NotFound, "failed to remove player from queue: %v", err)
+	}
+
+	return &matchmakingpb.LeaveQueueResponse{PlayerId: req.GetPlayerId(), Status: "removed"}, nil
+}
+
+// FindMatch возвращает матч игрока. Матч, ожидающий подтверждения игроков,
+// возвращается как FAILED_PRECONDITION: подтверждение доступно только через HTTP API
+func (s *GRPCServer) FindMatch(ctx context.Context, req *matchmakingpb.FindMatchRequest) (*matchmakingpb.Match, error) {
+	playerID := req.GetPlayerId()
+	if playerID == "" {
+		return nil, status.Error(codes.InvalidArgument, "player_id is required")
+	}
+
+	if pending, err := s.matcher.GetPlayerPendingMatch(ctx, playerID); err == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "match %s is awaiting confirmation", pending.PendingID)
+	}
+
+	match, err := s.matcher.FindMatch(ctx, playerID)
+	if err != nil {
+		if errors.Is(err, service.ErrMatchAwaitingConfirmation) {
+			return nil, status.Error(codes.FailedPrecondition, "match is awaiting confirmation")
+		}
+		return nil, status.Errorf(codes.NotFound, "match not found: %v", err)
+	}
+
+	return models.MatchToProto(match), nil
+}
+
+// GetQueueStatus возвращает размеры обычной и приоритетной очередей
+func (s *GRPCServer) GetQueueStatus(ctx context.Context, req *matchmakingpb.GetQueueStatusRequest) (*matchmakingpb.QueueStatus, error) {
+	region, gameMode := req.GetRegion(), req.GetGameMode()
+	if region == "" || gameMode == "" {
+		return nil, status.Error(codes.InvalidArgument, "region and game_mode are required")
+	}
+
+	queueSize, premiumQueueSize, err := s.matcher.GetQueueSizes(ctx, region, gameMode)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get queue size: %v", err)
+	}
+	active, stale, _, err := s.matcher.GetQueueMemberCount(ctx, region, gameMode)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to count queue members: %v", err)
+	}
+
+	return &matchmakingpb.QueueStatus{
+		Region:           region,
+		GameMode:         gameMode,
+		QueueSize:        queueSize,
+		PremiumQueueSize: premiumQueueSize,
+		ActivePlayers:    active,
+		StalePlayers:     stale,
+	}, nil
+}
+
+// StreamMatchNotifications ждет создания матча игрока, отправляет его и завершает поток.
+// Как и WebSocket, у игрока может быть только одна открытая подписка
+func (s *GRPCServer) StreamMatchNotifications(req *matchmakingpb.StreamMatchNotificationsRequest, stream matchmakingpb.MatchmakingService_StreamMatchNotificationsServer) error {
+	playerID := req.GetPlayerId()
+	if playerID == "" {
+		return status.Error(codes.InvalidArgument, "player_id is required")
+	}
+
+	matches, err := s.notifier.Subscribe(playerID)
+	if err != nil {
+		return status.Error(codes.AlreadyExists, "player already has an open subscription")
+	}
+	defer s.notifier.Unsubscribe(playerID)
+
+	ctx := stream.Context()
+
+	// Матч мог быть создан до подписки
+	if match, err := s.matcher.GetPlayerMatch(ctx, playerID); err == nil {
+		return stream.Send(models.MatchToProto(match))
+	} else if !errors.Is(err, service.ErrMatchNotFound) {
+		s.logger.Warn("Failed to check existing match",
+			zap.String("player_id", playerID),
+			zap.Error(err),
+		)
+	}
+
+	select {
+	case match, ok := <-matches:
+		if !ok {
+			return nil
+		}
+		return stream.Send(models.MatchToProto(match))
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,620 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/service"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// AdminHandler обрабатывает административные HTTP запросы
+type AdminHandler struct {
+	matcher *service.MatcherService
+	seasons *service.SeasonManager
+	servers *service.ServerRegistry
+	logger  *zap.Logger
+}
+
+// NewAdminHandler создает новый административный обработчик
+func NewAdminHandler(matcher *service.MatcherService, seasons *service.SeasonManager, servers *service.ServerRegistry, logger *zap.Logger) *AdminHandler {
+	return &AdminHandler{
+		matcher: matcher,
+		seasons: seasons,
+		servers: servers,
+		logger:  logger,
+	}
+}
+
+// configResponse конфигурация матчмейкера с признаком непиковых часов, при которых
+// проходы ProcessQueue идут с ослабленными критериями
+type configResponse struct {
+	service.MatcherConfig
+	OffPeakActive bool `json:"off_peak_active"`
+}
+
+// GetConfig возвращает текущую конфигурацию матчмейкера
+func (h *AdminHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	h.respondJSON(w, http.StatusOK, configResponse{
+		MatcherConfig: h.matcher.GetMatcherConfig(),
+		OffPeakActive: h.matcher.IsOffPeakNow(),
+	})
+}
+
+// ReplaceConfig целиком заменяет конфигурацию матчмейкера. Отсутствующие в теле поля
+// принимают значения по умолчанию.
+func (h *AdminHandler) ReplaceConfig(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	cfg, err := service.ParseMatcherConfig(body)
+	if err == nil {
+		err = h.matcher.UpdateConfig(cfg)
+	}
+	if err != nil {
+		var validationErr *service.ConfigValidationError
+		if errors.As(err, &validationErr) {
+			h.respondJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+				"error":  "Invalid config values",
+				"fields": validationErr.Fields,
+			})
+			return
+		}
+		h.respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, h.matcher.GetMatcherConfig())
+}
+
+// PatchConfig частично обновляет конфигурацию матчмейкера
+func (h *AdminHandler) PatchConfig(w http.ResponseWriter, r *http.Request) {
+	var patch map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if len(patch) == 0 {
+		h.respondError(w, http.StatusBadRequest, "At least one config field is required", nil)
+		return
+	}
+
+	if err := h.matcher.UpdateMatcherConfigPartial(r.Context(), patch); err != nil {
+		var validationErr *service.ConfigValidationError
+		if errors.As(err, &validationErr) {
+			h.respondJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+				"error":  "Invalid config values",
+				"fields": validationErr.Fields,
+			})
+			return
+		}
+		h.respondError(w, http.StatusConflict, "Failed to update config", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, h.matcher.GetMatcherConfig())
+}
+
+// GetTopWaitingPlayers возвращает игроков, дольше всех ожидающих в очереди
+func (h *AdminHandler) GetTopWaitingPlayers(w http.ResponseWriter, r *http.Request) {
+	region := r.URL.Query().Get("region")
+	gameMode := r.URL.Query().Get("game_mode")
+
+	if region == "" || gameMode == "" {
+		h.respondError(w, http.StatusBadRequest, "Region and game_mode are required", nil)
+		return
+	}
+
+	limit := 20
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			h.respondError(w, http.StatusBadRequest, "Limit must be a positive integer", err)
+			return
+		}
+		limit = parsed
+	}
+
+	players, err := h.matcher.GetTopWaitingPlayers(r.Context(), region, gameMode, limit)
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to get waiting players", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"region":    region,
+		"game_mode": gameMode,
+		"players":   players,
+	})
+}
+
+// ReindexQueue приводит score элементов очереди к актуальным рейтингам игроков
+func (h *AdminHandler) ReindexQueue(w http.ResponseWriter, r *http.Request) {
+	region := r.URL.Query().Get("region")
+	gameMode := r.URL.Query().Get("game_mode")
+
+	if region == "" || gameMode == "" {
+		h.respondError(w, http.StatusBadRequest, "Region and game_mode are required", nil)
+		return
+	}
+
+	updated, err := h.matcher.ReindexPlayerRatings(r.Context(), region, gameMode)
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to reindex queue", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"updated": updated,
+	})
+}
+
+// ScheduleMatch создает матч турнира, который станет доступен игрокам в start_time
+func (h *AdminHandler) ScheduleMatch(w http.ResponseWriter, r *http.Request) {
+	var req models.ScheduleMatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if len(req.PlayerIDs) == 0 || req.Region == "" || req.GameMode == "" || req.StartTime.IsZero() {
+		h.respondError(w, http.StatusBadRequest, "player_ids, region, game_mode and start_time are required", nil)
+		return
+	}
+
+	match, err := h.matcher.ScheduleMatch(r.Context(), &req)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "Failed to schedule match", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, match)
+}
+
+// BanPlayer запрещает игроку вход в очередь на заданное время
+func (h *AdminHandler) BanPlayer(w http.ResponseWriter, r *http.Request) {
+	var req models.BanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if req.PlayerID == "" || req.DurationSeconds <= 0 {
+		h.respondError(w, http.StatusBadRequest, "player_id and positive duration_seconds are required", nil)
+		return
+	}
+
+	bannedUntil, err := h.matcher.BanPlayer(r.Context(), req.PlayerID, time.Duration(req.DurationSeconds)*time.Second)
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to ban player", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"player_id":    req.PlayerID,
+		"banned_until": bannedUntil.UTC(),
+	})
+
+	logging.FromContext(r.Context()).Info("Player banned by admin",
+		zap.String("player_id", req.PlayerID),
+		zap.Int64("duration_seconds", req.DurationSeconds),
+	)
+}
+
+// ResetSeason начинает новый сезон и мягко сбрасывает рейтинги игроков к базовому
+func (h *AdminHandler) ResetSeason(w http.ResponseWriter, r *http.Request) {
+	var req models.SeasonResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if req.SeasonID == "" {
+		h.respondError(w, http.StatusBadRequest, "season_id is required", nil)
+		return
+	}
+	if req.CompressionFactor < 0 || req.CompressionFactor > 1 {
+		h.respondError(w, http.StatusBadRequest, "compression_factor must be between 0 and 1", nil)
+		return
+	}
+
+	season, err := h.seasons.StartSeason(r.Context(), req.SeasonID, req.CompressionFactor)
+	if err != nil {
+		if errors.Is(err, service.ErrSeasonExists) {
+			h.respondError(w, http.StatusConflict, "Season already started", err)
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, "Failed to reset season", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, season)
+
+	logging.FromContext(r.Context()).Info("Season started by admin",
+		zap.String("season_id", season.SeasonID),
+		zap.Int64("players_affected", season.PlayersAffected),
+	)
+}
+
+// RegisterServer регистрирует игровой сервер, на который будут назначаться матчи
+func (h *AdminHandler) RegisterServer(w http.ResponseWriter, r *http.Request) {
+	var req models.RegisterServerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if req.ServerID == "" || req.Addr == "" || req.Region == "" || req.Capacity <= 0 {
+		h.respondError(w, http.StatusBadRequest, "server_id, addr, region and positive capacity are required", nil)
+		return
+	}
+
+	if err := h.servers.RegisterServer(r.Context(), req.ServerID, req.Addr, req.Region, req.Capacity); err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to register server", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"server_id": req.ServerID,
+		"status":    "registered",
+	})
+}
+
+// DeregisterServer снимает игровой сервер с учета
+func (h *AdminHandler) DeregisterServer(w http.ResponseWriter, r *http.Request) {
+	serverID := mux.Vars(r)["server_id"]
+	if serverID == "" {
+		h.respondError(w, http.StatusBadRequest, "Server ID is required", nil)
+		return
+	}
+
+	if err := h.servers.DeregisterServer(r.Context(), serverID); err != nil {
+		if errors.Is(err, service.ErrServerNotFound) {
+			h.respondError(w, http.StatusNotFound, "Server not found", err)
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, "Failed to deregister server", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"server_id": serverID,
+		"status":    "deregistered",
+	})
+}
+
+// GetMatch возвращает сохраненный матч целиком, включая оценку качества
+func (h *AdminHandler) GetMatch(w http.ResponseWriter, r *http.Request) {
+	matchID := mux.Vars(r)["match_id"]
+	if matchID == "" {
+		h.respondError(w, http.StatusBadRequest, "Match ID is required", nil)
+		return
+	}
+
+	match, err := h.matcher.GetMatchByID(r.Context(), matchID)
+	if err != nil {
+		if errors.Is(err, service.ErrMatchNotFound) {
+			h.respondError(w, http.StatusNotFound, "Match not found", err)
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, "Failed to get match", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, match)
+}
+
+// ValidateMatch проверяет согласованность сохраненного матча
+func (h *AdminHandler) ValidateMatch(w http.ResponseWriter, r *http.Request) {
+	matchID := mux.Vars(r)["match_id"]
+	if matchID == "" {
+		h.respondError(w, http.StatusBadRequest, "Match ID is required", nil)
+		return
+	}
+
+	violations, err := h.matcher.ValidateMatchIntegrity(r.Context(), matchID)
+	if err != nil {
+		if errors.Is(err, service.ErrMatchNotFound) {
+			h.respondError(w, http.StatusNotFound, "Match not found", err)
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, "Failed to validate match", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"match_id":   matchID,
+		"valid":      len(violations) == 0,
+		"violations": violations,
+	})
+}
+
+// GetDistributionComparison сравнивает распределения рейтинга очереди и сматченных игроков.
+// Необязательный параметр buckets задает границы диапазонов через запятую.
+func (h *AdminHandler) GetDistributionComparison(w http.ResponseWriter, r *http.Request) {
+	region := r.URL.Query().Get("region")
+	gameMode := r.URL.Query().Get("game_mode")
+
+	if region == "" || gameMode == "" {
+		h.respondError(w, http.StatusBadRequest, "Region and game_mode are required", nil)
+		return
+	}
+
+	var buckets []int
+	if raw := r.URL.Query().Get("buckets"); raw != "" {
+		for _, part := range strings.Split(raw, ",") {
+			bound, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				h.respondError(w, http.StatusBadRequest, "Buckets must be comma-separated integers", err)
+				return
+			}
+			buckets = append(buckets, bound)
+		}
+	}
+
+	comparison, err := h.matcher.GetRatingDistributionComparison(r.Context(), region, gameMode, buckets)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "Failed to compare distributions", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, comparison)
+}
+
+// GetMemoryUsage возвращает оценку памяти Redis по шаблонам ключей
+func (h *AdminHandler) GetMemoryUsage(w http.ResponseWriter, r *http.Request) {
+	report, err := h.matcher.InspectMemoryUsage(r.Context())
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to inspect memory usage", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, report)
+}
+
+// GetGameModes возвращает режимы игры и количество игроков в их матчах
+func (h *AdminHandler) GetGameModes(w http.ResponseWriter, r *http.Request) {
+	modes, err := h.matcher.GetGameModes(r.Context())
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to get game modes", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, modes)
+}
+
+// SetGameModes заменяет режимы игры без перезапуска сервиса. Тело — объект
+// "режим": количество игроков в матче, например {"1v1": 2, "3v3": 6}.
+func (h *AdminHandler) SetGameModes(w http.ResponseWriter, r *http.Request) {
+	var modes map[string]int
+	if err := json.NewDecoder(r.Body).Decode(&modes); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := h.matcher.SetGameModes(r.Context(), modes); err != nil {
+		if errors.Is(err, service.ErrInvalidGameModes) {
+			h.respondError(w, http.StatusBadRequest, "Invalid game modes", err)
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, "Failed to update game modes", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, modes)
+}
+
+// QueueSnapshot выгружает содержимое всех очередей в формате NDJSON: по строке
+// models.QueueSnapshotEntry на очередь. Строки отправляются по мере записи.
+func (h *AdminHandler) QueueSnapshot(w http.ResponseWriter, r *http.Request) {
+	entries, err := h.matcher.SnapshotQueues(r.Context())
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to snapshot queues", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+	players := 0
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			logging.FromContext(r.Context()).Warn("Queue snapshot interrupted", zap.Error(err))
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		players += len(entry.Players)
+	}
+
+	logging.FromContext(r.Context()).Info("Queue snapshot exported",
+		zap.Int("queues", len(entries)),
+		zap.Int("players", players),
+	)
+}
+
+// RestoreQueues заполняет очереди из снимка QueueSnapshot (NDJSON), например после
+// потери данных Redis. Строки читаются и применяются по одной.
+func (h *AdminHandler) RestoreQueues(w http.ResponseWriter, r *http.Request) {
+	var result models.QueueRestoreResult
+	decoder := json.NewDecoder(r.Body)
+	for {
+		var entry models.QueueSnapshotEntry
+		if err := decoder.Decode(&entry); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			h.respondJSON(w, http.StatusBadRequest, map[string]interface{}{
+				"error":   "Invalid snapshot line",
+				"details": err.Error(),
+				"result":  result,
+			})
+			return
+		}
+		h.matcher.RestoreQueue(r.Context(), entry, &result)
+	}
+
+	h.respondJSON(w, http.StatusOK, result)
+
+	logging.FromContext(r.Context()).Info("Queues restored from snapshot",
+		zap.Int("queues", result.Queues),
+		zap.Int("restored", result.Restored),
+		zap.Int("already_queued", result.AlreadyQueued),
+		zap.Int("failed", result.Failed),
+	)
+}
+
+// Simulate показывает матчи, которые сформировала бы обработка очереди, не меняя ее.
+// Необязательное тело {"inject_players": [...]} добавляет к очереди гипотетических игроков.
+func (h *AdminHandler) Simulate(w http.ResponseWriter, r *http.Request) {
+	region := r.URL.Query().Get("region")
+	gameMode := r.URL.Query().Get("game_mode")
+
+	if region == "" || gameMode == "" {
+		h.respondError(w, http.StatusBadRequest, "Region and game_mode are required", nil)
+		return
+	}
+
+	var req models.SimulateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	matches, err := h.matcher.SimulateQueue(r.Context(), region, gameMode, req.InjectPlayers)
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to simulate queue", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, matches)
+}
+
+// GetDLQ возвращает группы, матч которых не удалось сохранить, от старых к новым.
+// Индекс записи в ответе используется в POST /admin/dlq/{index}/retry.
+func (h *AdminHandler) GetDLQ(w http.ResponseWriter, r *http.Request) {
+	entries, err := h.matcher.GetDLQ(r.Context())
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to get dlq", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, entries)
+}
+
+// GetFlaggedPlayers возвращает игроков, отмеченных для проверки из-за частых выходов
+// из очереди
+func (h *AdminHandler) GetFlaggedPlayers(w http.ResponseWriter, r *http.Request) {
+	playerIDs, err := h.matcher.GetFlaggedPlayers(r.Context())
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to get flagged players", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"player_ids": playerIDs,
+	})
+}
+
+// UnflagPlayer снимает с игрока отметку за частые выходы из очереди
+func (h *AdminHandler) UnflagPlayer(w http.ResponseWriter, r *http.Request) {
+	playerID := mux.Vars(r)["player_id"]
+	if playerID == "" {
+		h.respondError(w, http.StatusBadRequest, "Player ID is required", nil)
+		return
+	}
+
+	if err := h.matcher.UnflagPlayer(r.Context(), playerID); err != nil {
+		if errors.Is(err, service.ErrPlayerNotFlagged) {
+			h.respondError(w, http.StatusNotFound, "Player is not flagged", err)
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, "Failed to unflag player", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"player_id": playerID,
+		"status":    "unflagged",
+	})
+}
+
+// RetryDLQEntry повторно формирует матч группы из DLQ
+func (h *AdminHandler) RetryDLQEntry(w http.ResponseWriter, r *http.Request) {
+	index, err := strconv.ParseInt(mux.Vars(r)["index"], 10, 64)
+	if err != nil || index < 0 {
+		h.respondError(w, http.StatusBadRequest, "Index must be a non-negative integer", err)
+		return
+	}
+
+	match, err := h.matcher.RetryDLQEntry(r.Context(), index)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrDLQEntryNotFound):
+			h.respondError(w, http.StatusNotFound, "DLQ entry not found", err)
+		case errors.Is(err, service.ErrMatchConflict):
+			h.respondError(w, http.StatusConflict, "Some players of the group are no longer in queue", err)
+		default:
+			h.respondError(w, http.StatusInternalServerError, "Failed to retry dlq entry", err)
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, match)
+}
+
+// GetRegions возвращает список регионов, очереди которых обрабатываются
+func (h *AdminHandler) GetRegions(w http.ResponseWriter, r *http.Request) {
+	regions, err := h.matcher.GetRegions(r.Context())
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to get regions", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, models.RegionsConfig{Regions: regions})
+}
+
+// SetRegions заменяет список регионов без перезапуска сервиса
+func (h *AdminHandler) SetRegions(w http.ResponseWriter, r *http.Request) {
+	var req models.RegionsConfig
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := h.matcher.SetRegions(r.Context(), req.Regions); err != nil {
+		if errors.Is(err, service.ErrInvalidRegions) {
+			h.respondError(w, http.StatusBadRequest, "Invalid regions", err)
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, "Failed to update regions", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, req)
+}
+
+// respondJSON отправляет JSON ответ
+func (h *AdminHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	writeJSON(w, h.logger, status, data)
+}
+
+// respondError отправляет ошибку в формате JSON
+func (h *AdminHandler) respondError(w http.ResponseWriter, status int, message string, err error) {
+	writeError(w, h.logger, status, message, err)
+}
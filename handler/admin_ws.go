@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// LiveMetricsSource рассылает снимки живых показателей очередей
+type LiveMetricsSource interface {
+	Subscribe() <-chan *models.LiveMetricsSnapshot
+	Unsubscribe(ch <-chan *models.LiveMetricsSnapshot)
+}
+
+// AdminMetricsHandler отдает админ-консоли живые показатели очередей через WebSocket
+type AdminMetricsHandler struct {
+	source LiveMetricsSource
+	logger *zap.Logger
+}
+
+// NewAdminMetricsHandler создает обработчик потока показателей
+func NewAdminMetricsHandler(source LiveMetricsSource, logger *zap.Logger) *AdminMetricsHandler {
+	return &AdminMetricsHandler{
+		source: source,
+		logger: logger,
+	}
+}
+
+// LiveMetrics держит WebSocket соединение и отправляет снимок показателей очередей
+// каждые 5 секунд, пока клиент не отключится. Подключиться могут несколько консолей
+// одновременно; каждая получает одни и те же снимки.
+func (h *AdminMetricsHandler) LiveMetrics(w http.ResponseWriter, r *http.Request) {
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade сам отвечает клиенту при ошибке
+		logging.FromContext(r.Context()).Warn("Failed to upgrade admin metrics WebSocket connection", zap.Error(err))
+		return
+	}
+	defer conn.Close()
+
+	snapshots := h.source.Subscribe()
+	defer h.source.Unsubscribe(snapshots)
+
+	// Чтение нужно, чтобы обрабатывать pong и заметить отключение клиента
+	disconnected := make(chan struct{})
+	conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	})
+	go func() {
+		defer close(disconnected)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(wsPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case snapshot := <-snapshots:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := conn.WriteJSON(snapshot); err != nil {
+				logging.FromContext(r.Context()).Debug("Failed to send live metrics", zap.Error(err))
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return
+			}
+		case <-disconnected:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"net/http"
+
+	"chrono-matchmaking/service"
+	"go.uber.org/zap"
+)
+
+// AnalyticsHandler обрабатывает HTTP запросы аналитики очередей
+type AnalyticsHandler struct {
+	matcher *service.MatcherService
+	logger  *zap.Logger
+}
+
+// NewAnalyticsHandler создает новый обработчик аналитики
+func NewAnalyticsHandler(matcher *service.MatcherService, logger *zap.Logger) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		matcher: matcher,
+		logger:  logger,
+	}
+}
+
+// GetPeakHours возвращает отчет о загруженности очереди по часам суток
+func (h *AnalyticsHandler) GetPeakHours(w http.ResponseWriter, r *http.Request) {
+	region := r.URL.Query().Get("region")
+	gameMode := r.URL.Query().Get("game_mode")
+
+	if region == "" || gameMode == "" {
+		h.respondError(w, http.StatusBadRequest, "Region and game_mode are required", nil)
+		return
+	}
+
+	report, err := h.matcher.GetPeakHoursReport(r.Context(), region, gameMode)
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to build peak hours report", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, report)
+}
+
+// respondJSON отправляет JSON ответ
+func (h *AnalyticsHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	writeJSON(w, h.logger, status, data)
+}
+
+// respondError отправляет ошибку в формате JSON
+func (h *AnalyticsHandler) respondError(w http.ResponseWriter, status int, message string, err error) {
+	writeError(w, h.logger, status, message, err)
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"chrono-matchmaking/broadcast"
+	"chrono-matchmaking/logging"
+	"go.uber.org/zap"
+)
+
+// sseHeartbeatInterval период комментария-пинга, чтобы балансировщики не закрывали простаивающее соединение
+const sseHeartbeatInterval = 15 * time.Second
+
+// EventHandler отдает события очереди потоком Server-Sent Events
+type EventHandler struct {
+	hub    *broadcast.Hub
+	logger *zap.Logger
+}
+
+// NewEventHandler создает обработчик потока событий очереди
+func NewEventHandler(hub *broadcast.Hub, logger *zap.Logger) *EventHandler {
+	return &EventHandler{
+		hub:    hub,
+		logger: logger,
+	}
+}
+
+// QueueEvents держит соединение и отправляет события queue_join, queue_leave и
+// match_created, пока клиент не отключится
+func (h *EventHandler) QueueEvents(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+
+	// Поток живет дольше WriteTimeout сервера
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		writeError(w, h.logger, http.StatusInternalServerError, "Streaming is not supported", err)
+		return
+	}
+
+	events := h.hub.Subscribe()
+	defer h.hub.Unsubscribe(events)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Отключает буферизацию в nginx
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event.Data)
+			if err != nil {
+				logging.FromContext(r.Context()).Warn("Failed to marshal queue event",
+					zap.String("event", event.Type),
+					zap.Error(err),
+				)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// readinessTimeout время, за которое Redis должен ответить, чтобы сервис считался готовым
+const readinessTimeout = 500 * time.Millisecond
+
+// Pinger проверяет доступность хранилища
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// PoolStatser отдает состояние пула соединений хранилища
+type PoolStatser interface {
+	PoolStats() redis.PoolStats
+}
+
+// OffPeakChecker сообщает, действуют ли сейчас непиковые часы
+type OffPeakChecker interface {
+	IsOffPeakNow() bool
+}
+
+// HealthHandler отвечает на проверки живости и готовности сервиса
+type HealthHandler struct {
+	storage          Pinger
+	processorRunning *atomic.Bool
+	offPeak          OffPeakChecker
+	startedAt        time.Time
+	logger           *zap.Logger
+}
+
+// NewHealthHandler создает обработчик проверок. processorRunning выставляется
+// горутиной обработки очередей, пока она работает.
+func NewHealthHandler(storage Pinger, processorRunning *atomic.Bool, offPeak OffPeakChecker, logger *zap.Logger) *HealthHandler {
+	return &HealthHandler{
+		storage:          storage,
+		processorRunning: processorRunning,
+		offPeak:          offPeak,
+		startedAt:        time.Now(),
+		logger:           logger,
+	}
+}
+
+// Live отвечает 200, пока процесс жив
+func (h *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("OK"))
+}
+
+// Ready отвечает 200, только если Redis ответил за readinessTimeout, иначе 503.
+// Для Redis в ответ добавляется состояние пула соединений.
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	if err := h.storage.Ping(ctx); err != nil {
+		logging.FromContext(r.Context()).Warn("Readiness check failed", zap.Error(err))
+		writeJSON(w, h.logger, http.StatusServiceUnavailable, models.ReadinessErrorResponse{
+			Redis: "unreachable",
+			Error: err.Error(),
+		})
+		return
+	}
+
+	processor := models.QueueProcessorStopped
+	if h.processorRunning.Load() {
+		processor = models.QueueProcessorRunning
+	}
+	response := models.ReadinessResponse{
+		Redis:          "ok",
+		QueueProcessor: processor,
+		UptimeSeconds:  int64(time.Since(h.startedAt).Seconds()),
+		OffPeak:        h.offPeak.IsOffPeakNow(),
+	}
+	if pool, ok := h.storage.(PoolStatser); ok {
+		stats := pool.PoolStats()
+		response.RedisPool = &models.RedisPoolStats{
+			TotalConns: stats.TotalConns,
+			IdleConns:  stats.IdleConns,
+			StaleConns: stats.StaleConns,
+		}
+	}
+	writeJSON(w, h.logger, http.StatusOK, response)
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/service"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// MatchHandler обрабатывает HTTP запросы по созданным матчам
+type MatchHandler struct {
+	matcher *service.MatcherService
+	logger  *zap.Logger
+}
+
+// NewMatchHandler создает новый обработчик запросов по матчам
+func NewMatchHandler(matcher *service.MatcherService, logger *zap.Logger) *MatchHandler {
+	return &MatchHandler{
+		matcher: matcher,
+		logger:  logger,
+	}
+}
+
+// GetSatisfaction возвращает оценку удовлетворенности игроков матчем
+func (h *MatchHandler) GetSatisfaction(w http.ResponseWriter, r *http.Request) {
+	matchID := mux.Vars(r)["match_id"]
+	if matchID == "" {
+		h.respondError(w, http.StatusBadRequest, "Match ID is required", nil)
+		return
+	}
+
+	score, err := h.matcher.ComputeSatisfactionScore(r.Context(), matchID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrMatchNotFound):
+			h.respondError(w, http.StatusNotFound, "Match not found", err)
+		case errors.Is(err, service.ErrSatisfactionNotReady):
+			h.respondError(w, http.StatusConflict, "Satisfaction is not available yet", err)
+		default:
+			h.respondError(w, http.StatusInternalServerError, "Failed to compute satisfaction", err)
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, score)
+}
+
+// Backfill добирает в созданный матч игроков взамен отключившихся
+func (h *MatchHandler) Backfill(w http.ResponseWriter, r *http.Request) {
+	matchID := mux.Vars(r)["match_id"]
+
+	var req models.BackfillRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if matchID == "" || req.Region == "" || req.GameMode == "" {
+		h.respondError(w, http.StatusBadRequest, "Match ID, region and game_mode are required", nil)
+		return
+	}
+
+	match, err := h.matcher.BackfillMatch(r.Context(), matchID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrMatchNotFound):
+			h.respondError(w, http.StatusNotFound, "Match not found", err)
+		case errors.Is(err, service.ErrNoBackfillCandidates):
+			h.respondError(w, http.StatusServiceUnavailable, "Not enough players in queue for backfill", err)
+		case errors.Is(err, service.ErrMatchConflict):
+			h.respondError(w, http.StatusConflict, "Backfill players left the queue, retry", err)
+		default:
+			h.respondError(w, http.StatusBadRequest, "Failed to backfill match", err)
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, match)
+}
+
+// ReportResult принимает результат завершенного матча и пересчитывает рейтинги его участников
+func (h *MatchHandler) ReportResult(w http.ResponseWriter, r *http.Request) {
+	matchID := mux.Vars(r)["match_id"]
+
+	var req models.MatchResultRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if matchID == "" || len(req.WinnerPlayerIDs) == 0 {
+		h.respondError(w, http.StatusBadRequest, "Match ID and winner_player_ids are required", nil)
+		return
+	}
+
+	players, err := h.matcher.ReportMatchResult(r.Context(), matchID, req.WinnerPlayerIDs)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrMatchNotFound):
+			h.respondError(w, http.StatusNotFound, "Match not found", err)
+		case errors.Is(err, service.ErrInvalidMatchResult):
+			h.respondError(w, http.StatusBadRequest, "Winners must be exactly the players of one team", err)
+		case errors.Is(err, service.ErrMatchResultReported):
+			h.respondError(w, http.StatusConflict, "Match result already reported", err)
+		default:
+			h.respondError(w, http.StatusInternalServerError, "Failed to report match result", err)
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, models.MatchResultResponse{
+		MatchID: matchID,
+		Players: players,
+	})
+}
+
+// Acknowledge отмечает, что игровой сервер принял матч. Неподтвержденные матчи после
+// истечения считаются брошенными, и их игроки возвращаются в очередь.
+func (h *MatchHandler) Acknowledge(w http.ResponseWriter, r *http.Request) {
+	matchID := mux.Vars(r)["match_id"]
+	if matchID == "" {
+		h.respondError(w, http.StatusBadRequest, "Match ID is required", nil)
+		return
+	}
+
+	match, err := h.matcher.AcknowledgeMatch(r.Context(), matchID)
+	if err != nil {
+		if errors.Is(err, service.ErrMatchNotFound) {
+			h.respondError(w, http.StatusNotFound, "Match not found", err)
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, "Failed to acknowledge match", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, match)
+}
+
+// respondJSON отправляет JSON ответ
+func (h *MatchHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	writeJSON(w, h.logger, status, data)
+}
+
+// respondError отправляет ошибку в формате JSON
+func (h *MatchHandler) respondError(w http.ResponseWriter, status int, message string, err error) {
+	writeError(w, h.logger, status, message, err)
+}
@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/service"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// PlayerHandler обрабатывает HTTP запросы, связанные с данными игрока
+type PlayerHandler struct {
+	matcher *service.MatcherService
+	logger  *zap.Logger
+}
+
+// NewPlayerHandler создает новый обработчик запросов игрока
+func NewPlayerHandler(matcher *service.MatcherService, logger *zap.Logger) *PlayerHandler {
+	return &PlayerHandler{
+		matcher: matcher,
+		logger:  logger,
+	}
+}
+
+// Register регистрирует игрока и возвращает его постоянный идентификатор
+func (h *PlayerHandler) Register(w http.ResponseWriter, r *http.Request) {
+	var req models.RegisterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if req.DisplayName == "" || req.Platform == "" || !strings.Contains(req.Email, "@") {
+		h.respondError(w, http.StatusBadRequest, "display_name, platform and a valid email are required", nil)
+		return
+	}
+
+	profile, created, err := h.matcher.RegisterPlayer(r.Context(), &req)
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to register player", err)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+		logging.FromContext(r.Context()).Info("Player registered",
+			zap.String("player_id", profile.PlayerID),
+			zap.String("platform", profile.Platform),
+		)
+	}
+
+	h.respondJSON(w, status, profile)
+}
+
+// RegisterPushToken регистрирует токен устройства игрока для push-уведомлений
+func (h *PlayerHandler) RegisterPushToken(w http.ResponseWriter, r *http.Request) {
+	playerID := mux.Vars(r)["player_id"]
+	if playerID == "" {
+		h.respondError(w, http.StatusBadRequest, "Player ID is required", nil)
+		return
+	}
+	if isOtherPlayer(r, playerID) {
+		h.respondError(w, http.StatusForbidden, notOwnPlayerMessage, nil)
+		return
+	}
+
+	var req models.PushTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if strings.TrimSpace(req.Token) == "" {
+		h.respondError(w, http.StatusBadRequest, "Token is required", nil)
+		return
+	}
+
+	if err := h.matcher.RegisterPushToken(r.Context(), playerID, req.Token); err != nil {
+		if errors.Is(err, service.ErrProfileNotFound) {
+			h.respondError(w, http.StatusNotFound, "Player is not registered", err)
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, "Failed to register push token", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"player_id": playerID,
+		"status":    "registered",
+	})
+}
+
+// GetRatingAdvice возвращает персональные рекомендации по рейтингу игрока
+func (h *PlayerHandler) GetRatingAdvice(w http.ResponseWriter, r *http.Request) {
+	playerID := mux.Vars(r)["player_id"]
+	if playerID == "" {
+		h.respondError(w, http.StatusBadRequest, "Player ID is required", nil)
+		return
+	}
+	if isOtherPlayer(r, playerID) {
+		h.respondError(w, http.StatusForbidden, notOwnPlayerMessage, nil)
+		return
+	}
+
+	advice, err := h.matcher.SuggestRatingImprovement(r.Context(), playerID)
+	if err != nil {
+		h.respondError(w, http.StatusNotFound, "Failed to build rating advice", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, advice)
+}
+
+// GetRatingHistory возвращает историю изменения рейтинга игрока
+func (h *PlayerHandler) GetRatingHistory(w http.ResponseWriter, r *http.Request) {
+	playerID := mux.Vars(r)["player_id"]
+	if playerID == "" {
+		h.respondError(w, http.StatusBadRequest, "Player ID is required", nil)
+		return
+	}
+	if isOtherPlayer(r, playerID) {
+		h.respondError(w, http.StatusForbidden, notOwnPlayerMessage, nil)
+		return
+	}
+
+	points, err := h.matcher.GetRatingProgression(r.Context(), playerID)
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to get rating history", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"player_id": playerID,
+		"history":   points,
+	})
+}
+
+// GetStats возвращает сводную статистику игрока
+func (h *PlayerHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	playerID := mux.Vars(r)["player_id"]
+	if playerID == "" {
+		h.respondError(w, http.StatusBadRequest, "Player ID is required", nil)
+		return
+	}
+	if isOtherPlayer(r, playerID) {
+		h.respondError(w, http.StatusForbidden, notOwnPlayerMessage, nil)
+		return
+	}
+
+	stats, err := h.matcher.GetPlayerStats(r.Context(), playerID)
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to get player stats", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, stats)
+}
+
+// GetMatchHistory возвращает последние матчи игрока (limit — до 50, по умолчанию 20).
+// Если игрок еще не сыграл ни одного матча, возвращается 404.
+func (h *PlayerHandler) GetMatchHistory(w http.ResponseWriter, r *http.Request) {
+	playerID := mux.Vars(r)["player_id"]
+	if playerID == "" {
+		h.respondError(w, http.StatusBadRequest, "Player ID is required", nil)
+		return
+	}
+	if isOtherPlayer(r, playerID) {
+		h.respondError(w, http.StatusForbidden, notOwnPlayerMessage, nil)
+		return
+	}
+
+	limit := 20
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			h.respondError(w, http.StatusBadRequest, "Limit must be a positive integer", err)
+			return
+		}
+		limit = parsed
+	}
+
+	matches, err := h.matcher.GetMatchHistory(r.Context(), playerID, limit)
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to get match history", err)
+		return
+	}
+	if len(matches) == 0 {
+		h.respondError(w, http.StatusNotFound, "Match history not found", nil)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"player_id": playerID,
+		"matches":   matches,
+	})
+}
+
+// respondJSON отправляет JSON ответ
+func (h *PlayerHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	writeJSON(w, h.logger, status, data)
+}
+
+// respondError отправляет ошибку в формате JSON
+func (h *PlayerHandler) respondError(w http.ResponseWriter, status int, message string, err error) {
+	writeError(w, h.logger, status, message, err)
+}
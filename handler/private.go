@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/service"
+	"go.uber.org/zap"
+)
+
+// CreatePrivateMatch создает приватный матч и возвращает код приглашения
+func (h *QueueHandler) CreatePrivateMatch(w http.ResponseWriter, r *http.Request) {
+	var req models.CreatePrivateMatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if req.HostPlayerID == "" || req.Region == "" || req.GameMode == "" {
+		h.respondError(w, http.StatusBadRequest, "host_player_id, region and game_mode are required", nil)
+		return
+	}
+
+	// Создать матч можно только от своего имени
+	if isOtherPlayer(r, req.HostPlayerID) {
+		h.respondError(w, http.StatusForbidden, "host_player_id does not match the authenticated player", nil)
+		return
+	}
+	if _, err := h.matcher.GetPlayerProfile(r.Context(), req.HostPlayerID); err != nil {
+		if errors.Is(err, service.ErrProfileNotFound) {
+			h.respondError(w, http.StatusNotFound, "Player profile not found, register via POST /api/v1/players/register", err)
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, "Failed to verify player profile", err)
+		return
+	}
+
+	invite, err := h.matcher.CreatePrivateMatch(r.Context(), &req)
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to create private match", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"invite_code":    invite.InviteCode,
+		"expires_at":     invite.ExpiresAt.UTC(),
+		"players_needed": invite.PlayersNeeded,
+	})
+}
+
+// JoinPrivateMatch добавляет игрока в приватный матч по коду приглашения
+func (h *QueueHandler) JoinPrivateMatch(w http.ResponseWriter, r *http.Request) {
+	var req models.JoinPrivateMatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if req.PlayerID == "" || req.InviteCode == "" {
+		h.respondError(w, http.StatusBadRequest, "player_id and invite_code are required", nil)
+		return
+	}
+
+	// Войти в матч можно только от своего имени
+	if isOtherPlayer(r, req.PlayerID) {
+		h.respondError(w, http.StatusForbidden, notOwnPlayerMessage, nil)
+		return
+	}
+	if _, err := h.matcher.GetPlayerProfile(r.Context(), req.PlayerID); err != nil {
+		if errors.Is(err, service.ErrProfileNotFound) {
+			h.respondError(w, http.StatusNotFound, "Player profile not found, register via POST /api/v1/players/register", err)
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, "Failed to verify player profile", err)
+		return
+	}
+
+	if msg := validateMatchRequest(&req.MatchRequest); msg != "" {
+		h.respondError(w, http.StatusBadRequest, msg, nil)
+		return
+	}
+
+	player := models.NewPlayerFromRequest(&req.MatchRequest)
+
+	resp, err := h.matcher.JoinPrivateMatch(r.Context(), req.InviteCode, player)
+	if err != nil {
+		var bannedErr *service.PlayerBannedError
+		switch {
+		case errors.As(err, &bannedErr):
+			h.respondBanned(w, bannedErr)
+		case errors.Is(err, service.ErrInviteNotFound):
+			h.respondError(w, http.StatusNotFound, "Invite not found or expired", err)
+		case errors.Is(err, service.ErrAlreadyInInvite):
+			h.respondError(w, http.StatusConflict, "Player already joined this private match", err)
+		case errors.Is(err, service.ErrPlayerAlreadyQueued):
+			h.respondError(w, http.StatusConflict, "Player is in queue, leave it before joining a private match", err)
+		default:
+			h.respondError(w, http.StatusInternalServerError, "Failed to join private match", err)
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, resp)
+
+	logging.FromContext(r.Context()).Info("Player joined private match",
+		zap.String("player_id", player.ID),
+		zap.String("invite_code", req.InviteCode),
+		zap.Int("players_joined", resp.PlayersJoined),
+		zap.Int("players_needed", resp.PlayersNeeded),
+	)
+}
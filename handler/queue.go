@@ -2,30 +2,49 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"sort"
+	"strconv"
 	"time"
 
-	"github.com/gorilla/mux"
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/middleware"
 	"chrono-matchmaking/models"
 	"chrono-matchmaking/service"
+	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 )
 
+// queueFullRetryAfterSeconds через сколько секунд клиенту предлагается повторить вход в заполненную очередь
+const queueFullRetryAfterSeconds = 30
+
+const (
+	IdempotencyKeyHeader = "X-Idempotency-Key" // Ключ, по которому повтор JoinQueue возвращает исходный ответ
+	IdempotencyHitHeader = "X-Idempotency-Hit" // "true", если ответ взят по ключу идемпотентности
+
+	maxIdempotencyKeyLength = 255
+)
+
 // QueueHandler обрабатывает HTTP запросы для матчмейкинга
 type QueueHandler struct {
-	matcher *service.MatcherService
+	matcher service.Matcher
 	logger  *zap.Logger
 }
 
 // NewQueueHandler создает новый обработчик очереди
-func NewQueueHandler(matcher *service.MatcherService, logger *zap.Logger) *QueueHandler {
+func NewQueueHandler(matcher service.Matcher, logger *zap.Logger) *QueueHandler {
 	return &QueueHandler{
 		matcher: matcher,
 		logger:  logger,
 	}
 }
 
-// JoinQueue обрабатывает запрос на вход в очередь
+// JoinQueue обрабатывает запрос на вход в очередь. Если задан заголовок X-Idempotency-Key,
+// повтор запроса с тем же ключом в течение 5 минут возвращает исходный ответ
+// с заголовком X-Idempotency-Hit: true и не ставит игрока в очередь заново.
 func (h *QueueHandler) JoinQueue(w http.ResponseWriter, r *http.Request) {
 	var req models.MatchRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -33,22 +52,101 @@ func (h *QueueHandler) JoinQueue(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	idempotencyKey := r.Header.Get(IdempotencyKeyHeader)
+	if len(idempotencyKey) > maxIdempotencyKeyLength {
+		h.respondError(w, http.StatusBadRequest, fmt.Sprintf("%s must be at most %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLength), nil)
+		return
+	}
+
+	// Игрок должен быть зарегистрирован через POST /api/v1/players/register
+	if req.PlayerID == "" {
+		h.respondError(w, http.StatusBadRequest, "player_id is required, register via POST /api/v1/players/register", nil)
+		return
+	}
+
+	// Встать в очередь можно только от своего имени
+	if isOtherPlayer(r, req.PlayerID) {
+		h.respondError(w, http.StatusForbidden, notOwnPlayerMessage, nil)
+		return
+	}
+
+	// Повтор запроса, на который клиент не дождался ответа
+	if idempotencyKey != "" {
+		playerID, err := h.matcher.GetIdempotentJoin(r.Context(), idempotencyKey)
+		switch {
+		case err == nil && playerID != req.PlayerID:
+			h.respondError(w, http.StatusUnprocessableEntity, "Idempotency key was already used for another player", nil)
+			return
+		case err == nil:
+			w.Header().Set(IdempotencyHitHeader, "true")
+			h.respondJSON(w, http.StatusOK, queuedResponse(playerID))
+			return
+		case !errors.Is(err, service.ErrIdempotencyKeyNotFound):
+			h.respondError(w, http.StatusInternalServerError, "Failed to check idempotency key", err)
+			return
+		}
+	}
+
+	if _, err := h.matcher.GetPlayerProfile(r.Context(), req.PlayerID); err != nil {
+		if errors.Is(err, service.ErrProfileNotFound) {
+			h.respondError(w, http.StatusNotFound, "Player profile not found, register via POST /api/v1/players/register", err)
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, "Failed to verify player profile", err)
+		return
+	}
+
+	if msg := validateMatchRequest(&req); msg != "" {
+		h.respondError(w, http.StatusBadRequest, msg, nil)
+		return
+	}
+
 	// Создаем игрока
-	player := models.NewPlayer(req.Rating, req.Region, req.GameMode, req.PlayerLevel)
+	player := models.NewPlayerFromRequest(&req)
 
 	// Добавляем игрока в очередь
 	if err := h.matcher.AddPlayerToQueue(r.Context(), player); err != nil {
+		var bannedErr *service.PlayerBannedError
+		if errors.As(err, &bannedErr) {
+			h.respondBanned(w, bannedErr)
+			return
+		}
+		if errors.Is(err, service.ErrPlayerAlreadyQueued) {
+			h.respondError(w, http.StatusConflict, "Player is already in queue", err)
+			return
+		}
+		if errors.Is(err, service.ErrPlayerOnCooldown) {
+			h.respondError(w, http.StatusTooManyRequests, "Player recently declined a match", err)
+			return
+		}
+		if errors.Is(err, service.ErrPlayerFlagged) {
+			h.respondError(w, http.StatusForbidden, "Player is flagged for behavioral review", err)
+			return
+		}
+		if errors.Is(err, service.ErrQueueFull) {
+			w.Header().Set("Retry-After", strconv.Itoa(queueFullRetryAfterSeconds))
+			h.respondJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+				"error":               "queue_full",
+				"retry_after_seconds": queueFullRetryAfterSeconds,
+			})
+			return
+		}
 		h.respondError(w, http.StatusInternalServerError, "Failed to add player to queue", err)
 		return
 	}
 
-	h.respondJSON(w, http.StatusOK, map[string]interface{}{
-		"player_id": player.ID,
-		"status":    "queued",
-		"message":   "Player added to queue",
-	})
+	if idempotencyKey != "" {
+		if err := h.matcher.SaveIdempotentJoin(r.Context(), idempotencyKey, player.ID); err != nil {
+			logging.FromContext(r.Context()).Warn("Failed to save idempotency key",
+				zap.String("player_id", player.ID),
+				zap.Error(err),
+			)
+		}
+	}
 
-	h.logger.Info("Player joined queue",
+	h.respondJSON(w, http.StatusOK, queuedResponse(player.ID))
+
+	logging.FromContext(r.Context()).Info("Player joined queue",
 		zap.String("player_id", player.ID),
 		zap.String("region", player.Region),
 		zap.String("game_mode", player.GameMode),
@@ -56,6 +154,171 @@ func (h *QueueHandler) JoinQueue(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
+// queuedResponse ответ на успешный вход игрока в очередь
+func queuedResponse(playerID string) map[string]interface{} {
+	return map[string]interface{}{
+		"player_id": playerID,
+		"status":    "queued",
+		"message":   "Player added to queue",
+	}
+}
+
+// validateMatchRequest проверяет поля запроса на вход в очередь и возвращает
+// текст ошибки (пустой, если запрос корректен)
+func validateMatchRequest(req *models.MatchRequest) string {
+	switch req.VoicePreference {
+	case "", models.VoicePreferenceRequired, models.VoicePreferencePreferred, models.VoicePreferenceNone:
+	default:
+		return "voice_preference must be one of: required, preferred, none"
+	}
+	if req.VoicePreference == models.VoicePreferenceRequired && req.VoiceLanguage == "" {
+		return "voice_language is required when voice_preference is required"
+	}
+
+	if !models.IsValidRole(req.Role) {
+		return "role must be one of: tank, healer, dps"
+	}
+	return ""
+}
+
+// maxBatchJoinPlayers наибольшее количество игроков в одном пакетном запросе
+const maxBatchJoinPlayers = 100
+
+// JoinQueueBatch обрабатывает запрос на вход в очередь сразу нескольких игроков
+// (нагрузочное тестирование, боты). Каждый игрок проверяется так же, как в JoinQueue;
+// ошибка одного игрока не мешает остальным.
+func (h *QueueHandler) JoinQueueBatch(w http.ResponseWriter, r *http.Request) {
+	var req models.BatchJoinRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if len(req.Players) == 0 {
+		h.respondError(w, http.StatusBadRequest, "players is required", nil)
+		return
+	}
+	if len(req.Players) > maxBatchJoinPlayers {
+		h.respondError(w, http.StatusBadRequest, fmt.Sprintf("at most %d players per batch", maxBatchJoinPlayers), nil)
+		return
+	}
+
+	resp := models.BatchJoinResponse{
+		Succeeded: make([]string, 0, len(req.Players)),
+		Failed:    make([]models.BatchJoinFailure, 0),
+	}
+
+	players := make([]*models.Player, 0, len(req.Players))
+	indexes := make([]int, 0, len(req.Players))
+	for i := range req.Players {
+		if msg := h.checkBatchEntry(r, &req.Players[i]); msg != "" {
+			resp.Failed = append(resp.Failed, models.BatchJoinFailure{Index: i, Error: msg})
+			continue
+		}
+		players = append(players, models.NewPlayerFromRequest(&req.Players[i]))
+		indexes = append(indexes, i)
+	}
+
+	if len(players) > 0 {
+		for j, err := range h.matcher.AddPlayersToQueue(r.Context(), players) {
+			if err != nil {
+				resp.Failed = append(resp.Failed, models.BatchJoinFailure{Index: indexes[j], Error: err.Error()})
+				continue
+			}
+			resp.Succeeded = append(resp.Succeeded, players[j].ID)
+		}
+	}
+	sort.Slice(resp.Failed, func(i, j int) bool {
+		return resp.Failed[i].Index < resp.Failed[j].Index
+	})
+
+	h.respondJSON(w, http.StatusOK, resp)
+
+	logging.FromContext(r.Context()).Info("Batch queue join",
+		zap.Int("requested", len(req.Players)),
+		zap.Int("succeeded", len(resp.Succeeded)),
+		zap.Int("failed", len(resp.Failed)),
+	)
+}
+
+// checkBatchEntry проверяет одного игрока пакетного запроса и возвращает текст ошибки
+// (пустой, если игрока можно ставить в очередь)
+func (h *QueueHandler) checkBatchEntry(r *http.Request, req *models.MatchRequest) string {
+	if req.PlayerID == "" {
+		return "player_id is required"
+	}
+	if isOtherPlayer(r, req.PlayerID) {
+		return notOwnPlayerMessage
+	}
+	if msg := validateMatchRequest(req); msg != "" {
+		return msg
+	}
+
+	if _, err := h.matcher.GetPlayerProfile(r.Context(), req.PlayerID); err != nil {
+		if errors.Is(err, service.ErrProfileNotFound) {
+			return "player profile not found"
+		}
+		return err.Error()
+	}
+	return ""
+}
+
+// JoinParty обрабатывает запрос на вход группы игроков в очередь
+func (h *QueueHandler) JoinParty(w http.ResponseWriter, r *http.Request) {
+	var req models.PartyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if len(req.PlayerIDs) == 0 || req.Region == "" || req.GameMode == "" {
+		h.respondError(w, http.StatusBadRequest, "player_ids, region and game_mode are required", nil)
+		return
+	}
+
+	// Поставить группу в очередь может только ее участник
+	if authID, ok := middleware.PlayerIDFromContext(r.Context()); ok && !slices.Contains(req.PlayerIDs, authID) {
+		h.respondError(w, http.StatusForbidden, "Authenticated player is not a member of the party", nil)
+		return
+	}
+
+	party, err := h.matcher.JoinPartyQueue(r.Context(), &req)
+	if err != nil {
+		var bannedErr *service.PlayerBannedError
+		if errors.As(err, &bannedErr) {
+			h.respondBanned(w, bannedErr)
+			return
+		}
+		switch {
+		case errors.Is(err, service.ErrProfileNotFound):
+			h.respondError(w, http.StatusNotFound, "Player profile not found, register via POST /api/v1/players/register", err)
+		case errors.Is(err, service.ErrPlayerAlreadyQueued):
+			h.respondError(w, http.StatusConflict, "Party member is already in queue", err)
+		case errors.Is(err, service.ErrPlayerOnCooldown):
+			h.respondError(w, http.StatusTooManyRequests, "Party member recently declined a match", err)
+		case errors.Is(err, service.ErrPlayerFlagged):
+			h.respondError(w, http.StatusForbidden, "Party member is flagged for behavioral review", err)
+		default:
+			h.respondError(w, http.StatusBadRequest, "Failed to add party to queue", err)
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"party_id":   party.PartyID,
+		"player_ids": party.MemberIDs,
+		"status":     "queued",
+		"message":    "Party added to queue",
+	})
+
+	logging.FromContext(r.Context()).Info("Party joined queue",
+		zap.String("party_id", party.PartyID),
+		zap.String("region", party.Region),
+		zap.String("game_mode", party.GameMode),
+		zap.Int("players_count", len(party.MemberIDs)),
+	)
+}
+
 // LeaveQueue обрабатывает запрос на выход из очереди
 func (h *QueueHandler) LeaveQueue(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -65,6 +328,11 @@ func (h *QueueHandler) LeaveQueue(w http.ResponseWriter, r *http.Request) {
 		h.respondError(w, http.StatusBadRequest, "Player ID is required", nil)
 		return
 	}
+	// Выйти из очереди можно только самому: выход засчитывается игроку как брошенный поиск
+	if isOtherPlayer(r, playerID) {
+		h.respondError(w, http.StatusForbidden, notOwnPlayerMessage, nil)
+		return
+	}
 
 	// Удаляем игрока из очереди
 	if err := h.matcher.RemovePlayerFromQueue(r.Context(), playerID); err != nil {
@@ -78,11 +346,115 @@ func (h *QueueHandler) LeaveQueue(w http.ResponseWriter, r *http.Request) {
 		"message":   "Player removed from queue",
 	})
 
-	h.logger.Info("Player left queue",
+	logging.FromContext(r.Context()).Info("Player left queue",
 		zap.String("player_id", playerID),
 	)
 }
 
+// UpdateQueuedPlayer меняет регион, режим игры или рейтинг игрока в очереди без
+// повторного входа: время ожидания сохраняется
+func (h *QueueHandler) UpdateQueuedPlayer(w http.ResponseWriter, r *http.Request) {
+	playerID := mux.Vars(r)["player_id"]
+	if playerID == "" {
+		h.respondError(w, http.StatusBadRequest, "Player ID is required", nil)
+		return
+	}
+
+	// Менять можно только свою запись в очереди
+	if isOtherPlayer(r, playerID) {
+		h.respondError(w, http.StatusForbidden, notOwnPlayerMessage, nil)
+		return
+	}
+
+	var req models.UpdateQueuedPlayerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	var region, gameMode string
+	var rating int
+	if req.Region != nil {
+		if *req.Region == "" {
+			h.respondError(w, http.StatusBadRequest, "region must not be empty", nil)
+			return
+		}
+		region = *req.Region
+	}
+	if req.GameMode != nil {
+		if *req.GameMode == "" {
+			h.respondError(w, http.StatusBadRequest, "game_mode must not be empty", nil)
+			return
+		}
+		gameMode = *req.GameMode
+	}
+	if req.Rating != nil {
+		if *req.Rating <= 0 {
+			h.respondError(w, http.StatusBadRequest, "rating must be positive", nil)
+			return
+		}
+		rating = *req.Rating
+	}
+
+	if err := h.matcher.UpdateQueuedPlayer(r.Context(), playerID, region, gameMode, rating); err != nil {
+		switch {
+		case errors.Is(err, service.ErrPlayerNotInQueue):
+			h.respondError(w, http.StatusNotFound, "Player is not in queue", err)
+		case errors.Is(err, service.ErrRegionNotActive):
+			h.respondError(w, http.StatusBadRequest, "Region is not active", err)
+		case errors.Is(err, service.ErrPartyMemberUpdate):
+			h.respondError(w, http.StatusConflict, "Party members cannot change queue individually", err)
+		case errors.Is(err, service.ErrQueueFull):
+			w.Header().Set("Retry-After", strconv.Itoa(queueFullRetryAfterSeconds))
+			h.respondJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+				"error":               "queue_full",
+				"retry_after_seconds": queueFullRetryAfterSeconds,
+			})
+		default:
+			h.respondError(w, http.StatusInternalServerError, "Failed to update queued player", err)
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"player_id": playerID,
+		"status":    "updated",
+		"message":   "Queued player updated",
+	})
+}
+
+// Heartbeat продлевает ожидание игрока в очереди. Истекшему игроку возвращается 410
+func (h *QueueHandler) Heartbeat(w http.ResponseWriter, r *http.Request) {
+	playerID := mux.Vars(r)["player_id"]
+	if playerID == "" {
+		h.respondError(w, http.StatusBadRequest, "Player ID is required", nil)
+		return
+	}
+	if isOtherPlayer(r, playerID) {
+		h.respondError(w, http.StatusForbidden, notOwnPlayerMessage, nil)
+		return
+	}
+
+	ttl, err := h.matcher.Heartbeat(r.Context(), playerID)
+	if err != nil {
+		if errors.Is(err, service.ErrPlayerNotInQueue) {
+			h.respondJSON(w, http.StatusGone, map[string]interface{}{
+				"status":  "expired",
+				"message": "Re-join queue",
+			})
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, "Failed to refresh player", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"player_id":          playerID,
+		"status":             "alive",
+		"expires_in_seconds": int64(ttl.Seconds()),
+	})
+}
+
 // FindMatch обрабатывает запрос на поиск матча
 func (h *QueueHandler) FindMatch(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -92,22 +464,172 @@ func (h *QueueHandler) FindMatch(w http.ResponseWriter, r *http.Request) {
 		h.respondError(w, http.StatusBadRequest, "Player ID is required", nil)
 		return
 	}
+	if isOtherPlayer(r, playerID) {
+		h.respondError(w, http.StatusForbidden, notOwnPlayerMessage, nil)
+		return
+	}
+
+	// Матч уже собран и ждет подтверждения игрока
+	if pending, err := h.matcher.GetPlayerPendingMatch(r.Context(), playerID); err == nil {
+		h.respondJSON(w, http.StatusAccepted, pending)
+		return
+	}
 
 	// Ищем матч
 	match, err := h.matcher.FindMatch(r.Context(), playerID)
 	if err != nil {
+		if errors.Is(err, service.ErrMatchAwaitingConfirmation) {
+			if pending, err := h.matcher.GetPlayerPendingMatch(r.Context(), playerID); err == nil {
+				h.respondJSON(w, http.StatusAccepted, pending)
+				return
+			}
+		}
 		h.respondError(w, http.StatusNotFound, "Match not found", err)
 		return
 	}
 
 	h.respondJSON(w, http.StatusOK, match)
 
-	h.logger.Info("Match found",
+	logging.FromContext(r.Context()).Info("Match found",
 		zap.String("match_id", match.MatchID),
 		zap.String("player_id", playerID),
 	)
 }
 
+// ReconnectMatch возвращает матч игрока после отключения, не начиная новый поиск:
+// активный матч, а если он уже истек — последний матч из истории со статусом completed
+func (h *QueueHandler) ReconnectMatch(w http.ResponseWriter, r *http.Request) {
+	playerID := mux.Vars(r)["player_id"]
+	if isOtherPlayer(r, playerID) {
+		h.respondError(w, http.StatusForbidden, notOwnPlayerMessage, nil)
+		return
+	}
+
+	match, completed, err := h.matcher.ReconnectToMatch(r.Context(), playerID)
+	if errors.Is(err, service.ErrMatchNotFound) {
+		h.respondJSON(w, http.StatusNotFound, models.ReconnectResponse{Status: models.ReconnectStatusNotInMatch})
+		return
+	}
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to get player match", err)
+		return
+	}
+
+	logging.FromContext(r.Context()).Info("Player reconnected to match",
+		zap.String("player_id", playerID),
+		zap.String("match_id", match.MatchID),
+		zap.Bool("completed", completed),
+	)
+
+	if completed {
+		h.respondJSON(w, http.StatusOK, models.ReconnectResponse{
+			Status: models.ReconnectStatusCompleted,
+			Match:  match,
+		})
+		return
+	}
+	h.respondJSON(w, http.StatusOK, match)
+}
+
+// AcceptMatch обрабатывает подтверждение игроком собранного матча
+func (h *QueueHandler) AcceptMatch(w http.ResponseWriter, r *http.Request) {
+	pendingID := mux.Vars(r)["pending_id"]
+
+	var req models.ConfirmMatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if pendingID == "" || req.PlayerID == "" {
+		h.respondError(w, http.StatusBadRequest, "Pending ID and player_id are required", nil)
+		return
+	}
+	if isOtherPlayer(r, req.PlayerID) {
+		h.respondError(w, http.StatusForbidden, notOwnPlayerMessage, nil)
+		return
+	}
+
+	match, err := h.matcher.AcceptPendingMatch(r.Context(), pendingID, req.PlayerID)
+	if err != nil {
+		h.respondConfirmError(w, err)
+		return
+	}
+
+	// Пока подтвердили не все, матч еще не создан
+	if match == nil {
+		h.respondJSON(w, http.StatusAccepted, map[string]interface{}{
+			"pending_id": pendingID,
+			"player_id":  req.PlayerID,
+			"status":     "accepted",
+			"message":    "Waiting for other players to accept",
+		})
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, match)
+
+	logging.FromContext(r.Context()).Info("Match confirmed",
+		zap.String("pending_id", pendingID),
+		zap.String("match_id", match.MatchID),
+	)
+}
+
+// DeclineMatch обрабатывает отказ игрока от собранного матча
+func (h *QueueHandler) DeclineMatch(w http.ResponseWriter, r *http.Request) {
+	pendingID := mux.Vars(r)["pending_id"]
+
+	var req models.ConfirmMatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if pendingID == "" || req.PlayerID == "" {
+		h.respondError(w, http.StatusBadRequest, "Pending ID and player_id are required", nil)
+		return
+	}
+	if isOtherPlayer(r, req.PlayerID) {
+		h.respondError(w, http.StatusForbidden, notOwnPlayerMessage, nil)
+		return
+	}
+
+	if err := h.matcher.DeclinePendingMatch(r.Context(), pendingID, req.PlayerID); err != nil {
+		h.respondConfirmError(w, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"pending_id": pendingID,
+		"player_id":  req.PlayerID,
+		"status":     "declined",
+		"message":    "Match cancelled, other players returned to queue",
+	})
+}
+
+// respondBanned отправляет отказ во входе в очередь забаненному игроку
+func (h *QueueHandler) respondBanned(w http.ResponseWriter, err *service.PlayerBannedError) {
+	h.logger.Warn("Banned player tried to join queue",
+		zap.String("player_id", err.PlayerID),
+		zap.Time("banned_until", err.BannedUntil),
+	)
+	h.respondJSON(w, http.StatusForbidden, map[string]interface{}{
+		"error":        "Player is banned",
+		"player_id":    err.PlayerID,
+		"banned_until": err.BannedUntil.UTC(),
+	})
+}
+
+// respondConfirmError отправляет ошибку подтверждения или отказа от матча
+func (h *QueueHandler) respondConfirmError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrPendingMatchNotFound):
+		h.respondError(w, http.StatusNotFound, "Pending match not found or expired", err)
+	case errors.Is(err, service.ErrPlayerNotInPendingMatch):
+		h.respondError(w, http.StatusForbidden, "Player is not part of this match", err)
+	default:
+		h.respondError(w, http.StatusInternalServerError, "Failed to confirm match", err)
+	}
+}
+
 // GetQueueStatus возвращает статус очереди
 func (h *QueueHandler) GetQueueStatus(w http.ResponseWriter, r *http.Request) {
 	region := r.URL.Query().Get("region")
@@ -118,47 +640,140 @@ func (h *QueueHandler) GetQueueStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Получаем размер очереди
-	queueSize, err := h.matcher.GetQueueSize(r.Context(), region, gameMode)
+	// Получаем размеры обычной и приоритетной очередей
+	queueSize, premiumQueueSize, err := h.matcher.GetQueueSizes(r.Context(), region, gameMode)
 	if err != nil {
 		h.respondError(w, http.StatusInternalServerError, "Failed to get queue size", err)
 		return
 	}
 
-	h.respondJSON(w, http.StatusOK, map[string]interface{}{
-		"region":     region,
-		"game_mode":  gameMode,
-		"queue_size": queueSize,
-		"timestamp":  time.Now().Unix(),
-	})
+	// Разбивка на активных и устаревших игроков
+	active, stale, _, err := h.matcher.GetQueueMemberCount(r.Context(), region, gameMode)
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "Failed to count queue members", err)
+		return
+	}
+
+	status := map[string]interface{}{
+		"region":             region,
+		"game_mode":          gameMode,
+		"queue_size":         queueSize,
+		"premium_queue_size": premiumQueueSize,
+		"active_players":     active,
+		"stale_players":      stale,
+		"timestamp":          time.Now().Unix(),
+	}
+
+	// Разбивка по диапазонам рейтинга по запросу (?breakdown=true)
+	if r.URL.Query().Get("breakdown") == "true" {
+		brackets, err := h.matcher.GetQueueDepthByBracket(r.Context(), region, gameMode)
+		if err != nil {
+			h.respondError(w, http.StatusInternalServerError, "Failed to get queue breakdown", err)
+			return
+		}
+		status["brackets"] = brackets
+	}
+
+	h.respondJSON(w, http.StatusOK, status)
 }
 
-// respondJSON отправляет JSON ответ
-func (h *QueueHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	if err := json.NewEncoder(w).Encode(data); err != nil {
-		h.logger.Error("Failed to encode JSON response", zap.Error(err))
+// GetQueuePosition возвращает позицию игрока в очереди и текущий диапазон поиска
+func (h *QueueHandler) GetQueuePosition(w http.ResponseWriter, r *http.Request) {
+	playerID := mux.Vars(r)["player_id"]
+	if playerID == "" {
+		h.respondError(w, http.StatusBadRequest, "Player ID is required", nil)
+		return
 	}
+	if isOtherPlayer(r, playerID) {
+		h.respondError(w, http.StatusForbidden, notOwnPlayerMessage, nil)
+		return
+	}
+
+	position, err := h.matcher.GetQueuePosition(r.Context(), playerID)
+	if err != nil {
+		if errors.Is(err, service.ErrPlayerNotInQueue) {
+			h.respondError(w, http.StatusNotFound, "Player not in queue", err)
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, "Failed to get queue position", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, position)
 }
 
-// respondError отправляет ошибку в формате JSON
-func (h *QueueHandler) respondError(w http.ResponseWriter, status int, message string, err error) {
-	h.logger.Warn("Request error",
-		zap.Int("status", status),
-		zap.String("message", message),
-		zap.Error(err),
-	)
+// GetQueueSegmentStats возвращает статистику очереди по рейтинговым сегментам
+func (h *QueueHandler) GetQueueSegmentStats(w http.ResponseWriter, r *http.Request) {
+	region := r.URL.Query().Get("region")
+	gameMode := r.URL.Query().Get("game_mode")
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	
-	errorResp := map[string]interface{}{
-		"error": message,
+	if region == "" || gameMode == "" {
+		h.respondError(w, http.StatusBadRequest, "Region and game_mode are required", nil)
+		return
 	}
+
+	segments, err := h.matcher.GetQueueSegmentStats(r.Context(), region, gameMode)
 	if err != nil {
-		errorResp["details"] = err.Error()
+		h.respondError(w, http.StatusInternalServerError, "Failed to get segment stats", err)
+		return
 	}
-	json.NewEncoder(w).Encode(errorResp)
+
+	h.respondJSON(w, http.StatusOK, segments)
+}
+
+// GetWaitEstimate возвращает оценку времени ожидания матча в очереди
+func (h *QueueHandler) GetWaitEstimate(w http.ResponseWriter, r *http.Request) {
+	region := r.URL.Query().Get("region")
+	gameMode := r.URL.Query().Get("game_mode")
+
+	if region == "" || gameMode == "" {
+		h.respondError(w, http.StatusBadRequest, "Region and game_mode are required", nil)
+		return
+	}
+
+	estimate, err := h.matcher.EstimateWaitTime(r.Context(), region, gameMode)
+	if err != nil {
+		if errors.Is(err, service.ErrInsufficientWaitData) {
+			h.respondError(w, http.StatusServiceUnavailable, "Not enough recent matches to estimate wait time", err)
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, "Failed to estimate wait time", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, estimate)
+}
+
+// GetRegionLatencyMap возвращает матрицу ожидаемых задержек между регионами
+func (h *QueueHandler) GetRegionLatencyMap(w http.ResponseWriter, r *http.Request) {
+	h.respondJSON(w, http.StatusOK, h.matcher.GetRegionLatencyMap())
 }
 
+// GetRegionLatency возвращает ожидаемую задержку между двумя регионами
+func (h *QueueHandler) GetRegionLatency(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	region1 := vars["region1"]
+	region2 := vars["region2"]
+
+	latency, ok := h.matcher.GetRegionLatency(region1, region2)
+	if !ok {
+		h.respondError(w, http.StatusNotFound, "Latency for region pair is not configured", nil)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"region1":    region1,
+		"region2":    region2,
+		"latency_ms": latency,
+	})
+}
+
+// respondJSON отправляет JSON ответ
+func (h *QueueHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	writeJSON(w, h.logger, status, data)
+}
+
+// respondError отправляет ошибку в формате JSON
+func (h *QueueHandler) respondError(w http.ResponseWriter, status int, message string, err error) {
+	writeError(w, h.logger, status, message, err)
+}
@@ -0,0 +1,681 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"chrono-matchmaking/middleware"
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/service"
+	"chrono-matchmaking/storage"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// newQueueRouter возвращает маршрутизатор с маршрутами очереди, как в main.go
+func newQueueRouter(matcher service.Matcher) *mux.Router {
+	h := NewQueueHandler(matcher, zap.NewNop())
+	router := mux.NewRouter()
+	api := router.PathPrefix("/api/v1").Subrouter()
+	api.HandleFunc("/queue/join", h.JoinQueue).Methods("POST")
+	api.HandleFunc("/queue/join/batch", h.JoinQueueBatch).Methods("POST")
+	api.HandleFunc("/queue/party/join", h.JoinParty).Methods("POST")
+	api.HandleFunc("/queue/leave/{player_id}", h.LeaveQueue).Methods("DELETE")
+	api.HandleFunc("/queue/player/{player_id}", h.UpdateQueuedPlayer).Methods("PUT")
+	api.HandleFunc("/queue/match/{player_id}", h.FindMatch).Methods("GET")
+	api.HandleFunc("/queue/match/{player_id}/reconnect", h.ReconnectMatch).Methods("GET")
+	api.HandleFunc("/queue/match/{pending_id}/accept", h.AcceptMatch).Methods("POST")
+	api.HandleFunc("/queue/match/{pending_id}/decline", h.DeclineMatch).Methods("POST")
+	api.HandleFunc("/queue/status", h.GetQueueStatus).Methods("GET")
+	api.HandleFunc("/queue/position/{player_id}", h.GetQueuePosition).Methods("GET")
+	api.HandleFunc("/queue/wait-estimate", h.GetWaitEstimate).Methods("GET")
+	api.HandleFunc("/queue/heartbeat/{player_id}", h.Heartbeat).Methods("POST")
+	api.HandleFunc("/config/region-latency", h.GetRegionLatencyMap).Methods("GET")
+	api.HandleFunc("/config/region-latency/{region1}/{region2}", h.GetRegionLatency).Methods("GET")
+	return router
+}
+
+// serve выполняет запрос к маршрутизатору и возвращает ответ
+func serve(t *testing.T, router http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	return serveWithHeaders(t, router, method, path, body, nil)
+}
+
+// serveWithHeaders выполняет запрос с заданными заголовками и возвращает ответ
+func serveWithHeaders(t *testing.T, router http.Handler, method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	var req *http.Request
+	if body == "" {
+		req = httptest.NewRequest(method, path, nil)
+	} else {
+		req = httptest.NewRequest(method, path, strings.NewReader(body))
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// decodeBody разбирает JSON-ответ в v
+func decodeBody(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("invalid JSON response %q: %v", rec.Body.String(), err)
+	}
+}
+
+// TestRegionLatencySymmetry проверяет, что задержка, заданная в конфигурации в одном
+// направлении, отдается одинаковой в обоих: EU→US == US→EU
+func TestRegionLatencySymmetry(t *testing.T) {
+	config := service.DefaultMatcherConfig()
+	config.RegionLatencyMatrix = map[string]map[string]int{
+		"EU": {"US": 80, "ASIA": 200},
+		"US": {"ASIA": 150},
+	}
+	matcher := service.NewMatcherService(storage.NewInMemoryStorage(), zap.NewNop(), config)
+	router := newQueueRouter(matcher)
+
+	rec := serve(t, router, "GET", "/api/v1/config/region-latency", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET region-latency = %d, want 200", rec.Code)
+	}
+	var matrix map[string]map[string]int
+	decodeBody(t, rec, &matrix)
+
+	want := map[string]map[string]int{
+		"EU":   {"US": 80, "ASIA": 200},
+		"US":   {"EU": 80, "ASIA": 150},
+		"ASIA": {"EU": 200, "US": 150},
+	}
+	for from, row := range want {
+		for to, latency := range row {
+			if matrix[from][to] != latency {
+				t.Errorf("matrix[%s][%s] = %d, want %d", from, to, matrix[from][to], latency)
+			}
+		}
+	}
+	for from, row := range matrix {
+		for to, latency := range row {
+			if back, ok := matrix[to][from]; !ok || back != latency {
+				t.Errorf("%s→%s = %d, but %s→%s = %d", from, to, latency, to, from, back)
+			}
+		}
+	}
+
+	for _, pair := range [][2]string{{"EU", "US"}, {"US", "EU"}, {"ASIA", "US"}, {"US", "ASIA"}} {
+		rec := serve(t, router, "GET", "/api/v1/config/region-latency/"+pair[0]+"/"+pair[1], "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s/%s = %d, want 200", pair[0], pair[1], rec.Code)
+		}
+		var resp struct {
+			LatencyMS int `json:"latency_ms"`
+		}
+		decodeBody(t, rec, &resp)
+		if resp.LatencyMS != matrix[pair[0]][pair[1]] {
+			t.Errorf("GET %s/%s latency = %d, want %d from the matrix", pair[0], pair[1], resp.LatencyMS, matrix[pair[0]][pair[1]])
+		}
+	}
+}
+
+func TestRegionLatencyPair(t *testing.T) {
+	config := service.DefaultMatcherConfig()
+	config.RegionLatencyMatrix = map[string]map[string]int{"EU": {"US": 80}}
+	router := newQueueRouter(service.NewMatcherService(storage.NewInMemoryStorage(), zap.NewNop(), config))
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantMS     int
+	}{
+		{"/api/v1/config/region-latency/EU/EU", http.StatusOK, 0},
+		{"/api/v1/config/region-latency/US/EU", http.StatusOK, 80},
+		{"/api/v1/config/region-latency/EU/ASIA", http.StatusNotFound, 0},
+	}
+	for _, tt := range tests {
+		rec := serve(t, router, "GET", tt.path, "")
+		if rec.Code != tt.wantStatus {
+			t.Errorf("GET %s = %d, want %d", tt.path, rec.Code, tt.wantStatus)
+			continue
+		}
+		if tt.wantStatus != http.StatusOK {
+			continue
+		}
+		var resp struct {
+			LatencyMS int `json:"latency_ms"`
+		}
+		decodeBody(t, rec, &resp)
+		if resp.LatencyMS != tt.wantMS {
+			t.Errorf("GET %s latency = %d, want %d", tt.path, resp.LatencyMS, tt.wantMS)
+		}
+	}
+}
+
+// errBoom непредвиденная ошибка сервиса для проверки ответов 500
+var errBoom = errors.New("boom")
+
+// joinBody тело запроса на вход в очередь игрока p1
+const joinBody = `{"player_id":"p1","rating":1500,"region":"EU","game_mode":"3v3"}`
+
+// bearerToken возвращает заголовок Authorization с токеном игрока playerID,
+// подписанным secret, как его проверяет middleware.JWTAuth
+func bearerToken(t *testing.T, secret, playerID string) map[string]string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": playerID}).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+	return map[string]string{"Authorization": "Bearer " + token}
+}
+
+func TestJoinQueueStatus(t *testing.T) {
+	addErr := func(err error) *service.MockMatcher {
+		return &service.MockMatcher{AddPlayerToQueueFn: func(context.Context, *models.Player) error { return err }}
+	}
+
+	tests := []struct {
+		name       string
+		matcher    *service.MockMatcher
+		body       string
+		headers    map[string]string
+		wantStatus int
+	}{
+		{"queued", &service.MockMatcher{}, joinBody, nil, http.StatusOK},
+		{"invalid body", &service.MockMatcher{}, `{`, nil, http.StatusBadRequest},
+		{"missing player_id", &service.MockMatcher{}, `{"rating":1500}`, nil, http.StatusBadRequest},
+		{"idempotency key too long", &service.MockMatcher{}, joinBody,
+			map[string]string{IdempotencyKeyHeader: strings.Repeat("k", maxIdempotencyKeyLength+1)}, http.StatusBadRequest},
+		{"invalid voice preference", &service.MockMatcher{},
+			`{"player_id":"p1","region":"EU","game_mode":"3v3","voice_preference":"always"}`, nil, http.StatusBadRequest},
+		{"invalid role", &service.MockMatcher{},
+			`{"player_id":"p1","region":"EU","game_mode":"3v3","role":"bard"}`, nil, http.StatusBadRequest},
+		{"idempotency key of another player", &service.MockMatcher{
+			GetIdempotentJoinFn: func(context.Context, string) (string, error) { return "p2", nil },
+		}, joinBody, map[string]string{IdempotencyKeyHeader: "key"}, http.StatusUnprocessableEntity},
+		{"idempotency lookup failed", &service.MockMatcher{
+			GetIdempotentJoinFn: func(context.Context, string) (string, error) { return "", errBoom },
+		}, joinBody, map[string]string{IdempotencyKeyHeader: "key"}, http.StatusInternalServerError},
+		{"profile not found", &service.MockMatcher{
+			GetPlayerProfileFn: func(context.Context, string) (*models.PlayerProfile, error) { return nil, service.ErrProfileNotFound },
+		}, joinBody, nil, http.StatusNotFound},
+		{"profile lookup failed", &service.MockMatcher{
+			GetPlayerProfileFn: func(context.Context, string) (*models.PlayerProfile, error) { return nil, errBoom },
+		}, joinBody, nil, http.StatusInternalServerError},
+		{"banned", addErr(&service.PlayerBannedError{PlayerID: "p1", BannedUntil: time.Now().Add(time.Hour)}), joinBody, nil, http.StatusForbidden},
+		{"already queued", addErr(service.ErrPlayerAlreadyQueued), joinBody, nil, http.StatusConflict},
+		{"on cooldown", addErr(service.ErrPlayerOnCooldown), joinBody, nil, http.StatusTooManyRequests},
+		{"flagged", addErr(service.ErrPlayerFlagged), joinBody, nil, http.StatusForbidden},
+		{"queue full", addErr(service.ErrQueueFull), joinBody, nil, http.StatusServiceUnavailable},
+		{"add failed", addErr(errBoom), joinBody, nil, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveWithHeaders(t, newQueueRouter(tt.matcher), "POST", "/api/v1/queue/join", tt.body, tt.headers)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("POST /queue/join = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestJoinQueueQueued(t *testing.T) {
+	var queued *models.Player
+	var savedKey, savedPlayer string
+	matcher := &service.MockMatcher{
+		GetIdempotentJoinFn: func(context.Context, string) (string, error) { return "", service.ErrIdempotencyKeyNotFound },
+		AddPlayerToQueueFn: func(_ context.Context, p *models.Player) error {
+			queued = p
+			return nil
+		},
+		SaveIdempotentJoinFn: func(_ context.Context, key, playerID string) error {
+			savedKey, savedPlayer = key, playerID
+			return nil
+		},
+	}
+
+	rec := serveWithHeaders(t, newQueueRouter(matcher), "POST", "/api/v1/queue/join", joinBody,
+		map[string]string{IdempotencyKeyHeader: "key"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /queue/join = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	var resp map[string]string
+	decodeBody(t, rec, &resp)
+	if resp["player_id"] != "p1" || resp["status"] != "queued" {
+		t.Errorf("response = %v, want player p1 queued", resp)
+	}
+	if queued == nil || queued.ID != "p1" || queued.Region != "EU" || queued.GameMode != "3v3" || queued.Rating != 1500 {
+		t.Errorf("queued player = %+v, want p1 from the request", queued)
+	}
+	if savedKey != "key" || savedPlayer != "p1" {
+		t.Errorf("SaveIdempotentJoin(%q, %q), want (key, p1)", savedKey, savedPlayer)
+	}
+	if hit := rec.Header().Get(IdempotencyHitHeader); hit != "" {
+		t.Errorf("%s = %q on the first join", IdempotencyHitHeader, hit)
+	}
+}
+
+func TestJoinQueueIdempotencyHit(t *testing.T) {
+	matcher := &service.MockMatcher{
+		GetIdempotentJoinFn: func(context.Context, string) (string, error) { return "p1", nil },
+		AddPlayerToQueueFn: func(context.Context, *models.Player) error {
+			t.Error("AddPlayerToQueue called on an idempotency hit")
+			return nil
+		},
+	}
+
+	rec := serveWithHeaders(t, newQueueRouter(matcher), "POST", "/api/v1/queue/join", joinBody,
+		map[string]string{IdempotencyKeyHeader: "key"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /queue/join = %d, want 200", rec.Code)
+	}
+	if hit := rec.Header().Get(IdempotencyHitHeader); hit != "true" {
+		t.Errorf("%s = %q, want true", IdempotencyHitHeader, hit)
+	}
+}
+
+func TestJoinQueueFull(t *testing.T) {
+	matcher := &service.MockMatcher{
+		AddPlayerToQueueFn: func(context.Context, *models.Player) error { return service.ErrQueueFull },
+	}
+
+	rec := serve(t, newQueueRouter(matcher), "POST", "/api/v1/queue/join", joinBody)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("POST /queue/join = %d, want 503", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != strconv.Itoa(queueFullRetryAfterSeconds) {
+		t.Errorf("Retry-After = %q, want %d", got, queueFullRetryAfterSeconds)
+	}
+	var resp struct {
+		Error             string `json:"error"`
+		RetryAfterSeconds int    `json:"retry_after_seconds"`
+	}
+	decodeBody(t, rec, &resp)
+	if resp.Error != "queue_full" || resp.RetryAfterSeconds != queueFullRetryAfterSeconds {
+		t.Errorf("response = %+v, want queue_full with retry after %d", resp, queueFullRetryAfterSeconds)
+	}
+}
+
+// TestJoinQueueAuthenticatedPlayer проверяет, что аутентифицированный игрок может
+// встать в очередь только от своего имени
+func TestJoinQueueAuthenticatedPlayer(t *testing.T) {
+	const secret = "test-secret"
+	router := newQueueRouter(&service.MockMatcher{})
+	router.Use(middleware.JWTAuth(secret))
+
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		wantStatus int
+	}{
+		{"own player", bearerToken(t, secret, "p1"), http.StatusOK},
+		{"another player", bearerToken(t, secret, "p2"), http.StatusForbidden},
+		{"no token", nil, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		rec := serveWithHeaders(t, router, "POST", "/api/v1/queue/join", joinBody, tt.headers)
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: POST /queue/join = %d, want %d", tt.name, rec.Code, tt.wantStatus)
+		}
+	}
+}
+
+func TestJoinQueueBatchStatus(t *testing.T) {
+	players := func(n int) string {
+		entries := make([]string, n)
+		for i := range entries {
+			entries[i] = fmt.Sprintf(`{"player_id":"p%d","rating":1500,"region":"EU","game_mode":"3v3"}`, i)
+		}
+		return `{"players":[` + strings.Join(entries, ",") + `]}`
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"batch", players(3), http.StatusOK},
+		{"largest batch", players(maxBatchJoinPlayers), http.StatusOK},
+		{"invalid body", `{`, http.StatusBadRequest},
+		{"empty batch", players(0), http.StatusBadRequest},
+		{"too many players", players(maxBatchJoinPlayers + 1), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rec := serve(t, newQueueRouter(&service.MockMatcher{}), "POST", "/api/v1/queue/join/batch", tt.body)
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: POST /queue/join/batch = %d, want %d: %s", tt.name, rec.Code, tt.wantStatus, rec.Body.String())
+		}
+	}
+}
+
+func TestJoinPartyStatus(t *testing.T) {
+	const body = `{"player_ids":["p1","p2"],"region":"EU","game_mode":"3v3"}`
+	joinErr := func(err error) *service.MockMatcher {
+		return &service.MockMatcher{JoinPartyQueueFn: func(context.Context, *models.PartyRequest) (*models.Party, error) {
+			return nil, err
+		}}
+	}
+	joined := &service.MockMatcher{JoinPartyQueueFn: func(_ context.Context, req *models.PartyRequest) (*models.Party, error) {
+		return &models.Party{PartyID: "party", MemberIDs: req.PlayerIDs, Region: req.Region, GameMode: req.GameMode}, nil
+	}}
+
+	tests := []struct {
+		name       string
+		matcher    *service.MockMatcher
+		body       string
+		wantStatus int
+	}{
+		{"queued", joined, body, http.StatusOK},
+		{"invalid body", joined, `{`, http.StatusBadRequest},
+		{"missing region", joined, `{"player_ids":["p1"],"game_mode":"3v3"}`, http.StatusBadRequest},
+		{"profile not found", joinErr(service.ErrProfileNotFound), body, http.StatusNotFound},
+		{"already queued", joinErr(service.ErrPlayerAlreadyQueued), body, http.StatusConflict},
+		{"on cooldown", joinErr(service.ErrPlayerOnCooldown), body, http.StatusTooManyRequests},
+		{"flagged", joinErr(service.ErrPlayerFlagged), body, http.StatusForbidden},
+		{"banned", joinErr(&service.PlayerBannedError{PlayerID: "p2"}), body, http.StatusForbidden},
+		{"rejected", joinErr(errBoom), body, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rec := serve(t, newQueueRouter(tt.matcher), "POST", "/api/v1/queue/party/join", tt.body)
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: POST /queue/party/join = %d, want %d: %s", tt.name, rec.Code, tt.wantStatus, rec.Body.String())
+		}
+	}
+}
+
+func TestLeaveQueueStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{"removed", nil, http.StatusOK},
+		{"not in queue", service.ErrPlayerNotInQueue, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		matcher := &service.MockMatcher{RemovePlayerFromQueueFn: func(context.Context, string) error { return tt.err }}
+		rec := serve(t, newQueueRouter(matcher), "DELETE", "/api/v1/queue/leave/p1", "")
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: DELETE /queue/leave = %d, want %d", tt.name, rec.Code, tt.wantStatus)
+		}
+	}
+}
+
+func TestUpdateQueuedPlayerStatus(t *testing.T) {
+	updateErr := func(err error) *service.MockMatcher {
+		return &service.MockMatcher{UpdateQueuedPlayerFn: func(context.Context, string, string, string, int) error { return err }}
+	}
+
+	tests := []struct {
+		name       string
+		matcher    *service.MockMatcher
+		body       string
+		wantStatus int
+	}{
+		{"updated", &service.MockMatcher{}, `{"region":"US"}`, http.StatusOK},
+		{"invalid body", &service.MockMatcher{}, `{`, http.StatusBadRequest},
+		{"empty region", &service.MockMatcher{}, `{"region":""}`, http.StatusBadRequest},
+		{"empty game mode", &service.MockMatcher{}, `{"game_mode":""}`, http.StatusBadRequest},
+		{"non-positive rating", &service.MockMatcher{}, `{"rating":0}`, http.StatusBadRequest},
+		{"not in queue", updateErr(service.ErrPlayerNotInQueue), `{"rating":1600}`, http.StatusNotFound},
+		{"region not active", updateErr(service.ErrRegionNotActive), `{"region":"US"}`, http.StatusBadRequest},
+		{"party member", updateErr(service.ErrPartyMemberUpdate), `{"rating":1600}`, http.StatusConflict},
+		{"queue full", updateErr(service.ErrQueueFull), `{"region":"US"}`, http.StatusServiceUnavailable},
+		{"update failed", updateErr(errBoom), `{"rating":1600}`, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		rec := serve(t, newQueueRouter(tt.matcher), "PUT", "/api/v1/queue/player/p1", tt.body)
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: PUT /queue/player = %d, want %d: %s", tt.name, rec.Code, tt.wantStatus, rec.Body.String())
+		}
+	}
+}
+
+func TestHeartbeatStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{"alive", nil, http.StatusOK},
+		{"expired", service.ErrPlayerNotInQueue, http.StatusGone},
+		{"refresh failed", errBoom, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		matcher := &service.MockMatcher{HeartbeatFn: func(context.Context, string) (time.Duration, error) {
+			return time.Minute, tt.err
+		}}
+		rec := serve(t, newQueueRouter(matcher), "POST", "/api/v1/queue/heartbeat/p1", "")
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: POST /queue/heartbeat = %d, want %d", tt.name, rec.Code, tt.wantStatus)
+		}
+	}
+}
+
+func TestFindMatchStatus(t *testing.T) {
+	noPending := func(context.Context, string) (*models.PendingMatch, error) {
+		return nil, service.ErrPendingMatchNotFound
+	}
+	pending := func(context.Context, string) (*models.PendingMatch, error) {
+		return &models.PendingMatch{PendingID: "pending"}, nil
+	}
+
+	tests := []struct {
+		name       string
+		matcher    *service.MockMatcher
+		wantStatus int
+	}{
+		{"match found", &service.MockMatcher{
+			GetPlayerPendingMatchFn: noPending,
+			FindMatchFn:             func(context.Context, string) (*models.Match, error) { return &models.Match{MatchID: "match"}, nil },
+		}, http.StatusOK},
+		{"awaiting confirmation", &service.MockMatcher{GetPlayerPendingMatchFn: pending}, http.StatusAccepted},
+		{"no match", &service.MockMatcher{
+			GetPlayerPendingMatchFn: noPending,
+			FindMatchFn:             func(context.Context, string) (*models.Match, error) { return nil, service.ErrMatchNotFound },
+		}, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rec := serve(t, newQueueRouter(tt.matcher), "GET", "/api/v1/queue/match/p1", "")
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: GET /queue/match = %d, want %d", tt.name, rec.Code, tt.wantStatus)
+		}
+	}
+}
+
+func TestReconnectMatchStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		match      *models.Match
+		completed  bool
+		err        error
+		wantStatus int
+	}{
+		{"active match", &models.Match{MatchID: "match"}, false, nil, http.StatusOK},
+		{"completed match", &models.Match{MatchID: "match"}, true, nil, http.StatusOK},
+		{"not in match", nil, false, service.ErrMatchNotFound, http.StatusNotFound},
+		{"lookup failed", nil, false, errBoom, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		matcher := &service.MockMatcher{ReconnectToMatchFn: func(context.Context, string) (*models.Match, bool, error) {
+			return tt.match, tt.completed, tt.err
+		}}
+		rec := serve(t, newQueueRouter(matcher), "GET", "/api/v1/queue/match/p1/reconnect", "")
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: GET /queue/match/reconnect = %d, want %d", tt.name, rec.Code, tt.wantStatus)
+		}
+	}
+}
+
+func TestConfirmMatchStatus(t *testing.T) {
+	const body = `{"player_id":"p1"}`
+
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		match      *models.Match
+		err        error
+		wantStatus int
+	}{
+		{"all accepted", "/accept", body, &models.Match{MatchID: "match"}, nil, http.StatusOK},
+		{"waiting for others", "/accept", body, nil, nil, http.StatusAccepted},
+		{"accept without player", "/accept", `{}`, nil, nil, http.StatusBadRequest},
+		{"accept expired", "/accept", body, nil, service.ErrPendingMatchNotFound, http.StatusNotFound},
+		{"accept by outsider", "/accept", body, nil, service.ErrPlayerNotInPendingMatch, http.StatusForbidden},
+		{"accept failed", "/accept", body, nil, errBoom, http.StatusInternalServerError},
+		{"declined", "/decline", body, nil, nil, http.StatusOK},
+		{"decline invalid body", "/decline", `{`, nil, nil, http.StatusBadRequest},
+		{"decline expired", "/decline", body, nil, service.ErrPendingMatchNotFound, http.StatusNotFound},
+		{"decline by outsider", "/decline", body, nil, service.ErrPlayerNotInPendingMatch, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		matcher := &service.MockMatcher{
+			AcceptPendingMatchFn:  func(context.Context, string, string) (*models.Match, error) { return tt.match, tt.err },
+			DeclinePendingMatchFn: func(context.Context, string, string) error { return tt.err },
+		}
+		rec := serve(t, newQueueRouter(matcher), "POST", "/api/v1/queue/match/pending"+tt.path, tt.body)
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: POST /queue/match%s = %d, want %d", tt.name, tt.path, rec.Code, tt.wantStatus)
+		}
+	}
+}
+
+func TestGetQueueStatusStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		matcher    *service.MockMatcher
+		path       string
+		wantStatus int
+	}{
+		{"status", &service.MockMatcher{}, "/api/v1/queue/status?region=EU&game_mode=3v3", http.StatusOK},
+		{"with breakdown", &service.MockMatcher{}, "/api/v1/queue/status?region=EU&game_mode=3v3&breakdown=true", http.StatusOK},
+		{"missing game mode", &service.MockMatcher{}, "/api/v1/queue/status?region=EU", http.StatusBadRequest},
+		{"sizes failed", &service.MockMatcher{
+			GetQueueSizesFn: func(context.Context, string, string) (int64, int64, error) { return 0, 0, errBoom },
+		}, "/api/v1/queue/status?region=EU&game_mode=3v3", http.StatusInternalServerError},
+		{"member count failed", &service.MockMatcher{
+			GetQueueMemberCountFn: func(context.Context, string, string) (int64, int64, int64, error) { return 0, 0, 0, errBoom },
+		}, "/api/v1/queue/status?region=EU&game_mode=3v3", http.StatusInternalServerError},
+		{"breakdown failed", &service.MockMatcher{
+			GetQueueDepthByBracketFn: func(context.Context, string, string) ([]models.BracketDepth, error) { return nil, errBoom },
+		}, "/api/v1/queue/status?region=EU&game_mode=3v3&breakdown=true", http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		rec := serve(t, newQueueRouter(tt.matcher), "GET", tt.path, "")
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: GET %s = %d, want %d", tt.name, tt.path, rec.Code, tt.wantStatus)
+		}
+	}
+}
+
+func TestGetQueuePositionStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{"queued", nil, http.StatusOK},
+		{"not in queue", service.ErrPlayerNotInQueue, http.StatusNotFound},
+		{"lookup failed", errBoom, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		matcher := &service.MockMatcher{GetQueuePositionFn: func(_ context.Context, playerID string) (*models.QueuePosition, error) {
+			if tt.err != nil {
+				return nil, tt.err
+			}
+			return &models.QueuePosition{PlayerID: playerID, Position: 1, TotalInQueue: 1}, nil
+		}}
+		rec := serve(t, newQueueRouter(matcher), "GET", "/api/v1/queue/position/p1", "")
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: GET /queue/position = %d, want %d", tt.name, rec.Code, tt.wantStatus)
+		}
+	}
+}
+
+func TestGetWaitEstimateStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		err        error
+		wantStatus int
+	}{
+		{"estimate", "/api/v1/queue/wait-estimate?region=EU&game_mode=3v3", nil, http.StatusOK},
+		{"missing region", "/api/v1/queue/wait-estimate?game_mode=3v3", nil, http.StatusBadRequest},
+		{"not enough matches", "/api/v1/queue/wait-estimate?region=EU&game_mode=3v3", service.ErrInsufficientWaitData, http.StatusServiceUnavailable},
+		{"estimate failed", "/api/v1/queue/wait-estimate?region=EU&game_mode=3v3", errBoom, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		matcher := &service.MockMatcher{EstimateWaitTimeFn: func(_ context.Context, region, gameMode string) (*models.WaitEstimate, error) {
+			if tt.err != nil {
+				return nil, tt.err
+			}
+			return &models.WaitEstimate{Region: region, GameMode: gameMode, EstimatedSeconds: 30, SampleSize: 10}, nil
+		}}
+		rec := serve(t, newQueueRouter(matcher), "GET", tt.path, "")
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: GET %s = %d, want %d", tt.name, tt.path, rec.Code, tt.wantStatus)
+		}
+	}
+}
+
+// TestPlayerScopedRoutesRejectOtherPlayers проверяет, что аутентифицированный игрок
+// не может действовать в очереди от имени другого игрока
+func TestPlayerScopedRoutesRejectOtherPlayers(t *testing.T) {
+	const secret = "test-secret"
+	removed := false
+	matcher := &service.MockMatcher{
+		RemovePlayerFromQueueFn: func(context.Context, string) error {
+			removed = true
+			return nil
+		},
+		GetPlayerPendingMatchFn: func(context.Context, string) (*models.PendingMatch, error) {
+			return nil, service.ErrPendingMatchNotFound
+		},
+		FindMatchFn: func(context.Context, string) (*models.Match, error) { return &models.Match{MatchID: "match"}, nil },
+		ReconnectToMatchFn: func(context.Context, string) (*models.Match, bool, error) {
+			return &models.Match{MatchID: "match"}, false, nil
+		},
+		AcceptPendingMatchFn: func(context.Context, string, string) (*models.Match, error) { return nil, nil },
+		HeartbeatFn:          func(context.Context, string) (time.Duration, error) { return time.Minute, nil },
+		GetQueuePositionFn: func(_ context.Context, playerID string) (*models.QueuePosition, error) {
+			return &models.QueuePosition{PlayerID: playerID}, nil
+		},
+	}
+	router := newQueueRouter(matcher)
+	router.Use(middleware.JWTAuth(secret))
+
+	routes := []struct {
+		method, path, body string
+		ownStatus          int
+	}{
+		{"DELETE", "/api/v1/queue/leave/p1", "", http.StatusOK},
+		{"PUT", "/api/v1/queue/player/p1", `{"rating":1600}`, http.StatusOK},
+		{"GET", "/api/v1/queue/match/p1", "", http.StatusOK},
+		{"GET", "/api/v1/queue/match/p1/reconnect", "", http.StatusOK},
+		{"POST", "/api/v1/queue/match/pending/accept", `{"player_id":"p1"}`, http.StatusAccepted},
+		{"POST", "/api/v1/queue/match/pending/decline", `{"player_id":"p1"}`, http.StatusOK},
+		{"POST", "/api/v1/queue/heartbeat/p1", "", http.StatusOK},
+		{"GET", "/api/v1/queue/position/p1", "", http.StatusOK},
+	}
+	for _, rt := range routes {
+		if rec := serveWithHeaders(t, router, rt.method, rt.path, rt.body, bearerToken(t, secret, "p2")); rec.Code != http.StatusForbidden {
+			t.Errorf("%s %s by another player = %d, want 403", rt.method, rt.path, rec.Code)
+		}
+		if rec := serveWithHeaders(t, router, rt.method, rt.path, rt.body, bearerToken(t, secret, "p1")); rec.Code != rt.ownStatus {
+			t.Errorf("%s %s by the player = %d, want %d", rt.method, rt.path, rec.Code, rt.ownStatus)
+		}
+	}
+	if !removed {
+		t.Error("the player could not leave the queue")
+	}
+}
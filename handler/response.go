@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"chrono-matchmaking/middleware"
+	"go.uber.org/zap"
+)
+
+// writeJSON отправляет JSON ответ
+func writeJSON(w http.ResponseWriter, logger *zap.Logger, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		logger.Error("Failed to encode JSON response", zap.Error(err))
+	}
+}
+
+// notOwnPlayerMessage ответ 403 на запрос от имени другого игрока
+const notOwnPlayerMessage = "player_id does not match the authenticated player"
+
+// isOtherPlayer проверяет, что запрос прошел JWTAuth от имени игрока, отличного от
+// playerID. Без аутентификации (JWTAuth отключен) ограничений нет.
+func isOtherPlayer(r *http.Request, playerID string) bool {
+	authID, ok := middleware.PlayerIDFromContext(r.Context())
+	return ok && authID != playerID
+}
+
+// writeError отправляет ошибку в формате JSON. ID запроса для лога берется из заголовка
+// ответа, который выставляет middleware.RequestID.
+func writeError(w http.ResponseWriter, logger *zap.Logger, status int, message string, err error) {
+	if requestID := w.Header().Get(middleware.RequestIDHeader); requestID != "" {
+		logger = logger.With(zap.String("request_id", requestID))
+	}
+	logger.Warn("Request error",
+		zap.Int("status", status),
+		zap.String("message", message),
+		zap.Error(err),
+	)
+
+	errorResp := map[string]interface{}{
+		"error": message,
+	}
+	if err != nil {
+		errorResp["details"] = err.Error()
+	}
+	writeJSON(w, logger, status, errorResp)
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/service"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// TournamentHandler обрабатывает HTTP запросы турниров на выбывание
+type TournamentHandler struct {
+	tournaments *service.TournamentService
+	logger      *zap.Logger
+}
+
+// NewTournamentHandler создает новый обработчик запросов турниров
+func NewTournamentHandler(tournaments *service.TournamentService, logger *zap.Logger) *TournamentHandler {
+	return &TournamentHandler{
+		tournaments: tournaments,
+		logger:      logger,
+	}
+}
+
+// CreateTournament создает турнир из лучших игроков очереди региона/режима
+func (h *TournamentHandler) CreateTournament(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateTournamentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if req.Region == "" || req.GameMode == "" {
+		h.respondError(w, http.StatusBadRequest, "Region and game_mode are required", nil)
+		return
+	}
+
+	tournament, err := h.tournaments.CreateTournament(r.Context(), req.Region, req.GameMode, req.MaxPlayers)
+	if err != nil {
+		if errors.Is(err, service.ErrNotEnoughTournamentPlayers) {
+			h.respondError(w, http.StatusConflict, "Not enough players in queue for tournament", err)
+			return
+		}
+		h.respondError(w, http.StatusBadRequest, "Failed to create tournament", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, tournament)
+}
+
+// GetTournament возвращает сетку и состояние турнира
+func (h *TournamentHandler) GetTournament(w http.ResponseWriter, r *http.Request) {
+	tournamentID := mux.Vars(r)["id"]
+
+	tournament, err := h.tournaments.GetTournament(r.Context(), tournamentID)
+	if err != nil {
+		if errors.Is(err, service.ErrTournamentNotFound) {
+			h.respondError(w, http.StatusNotFound, "Tournament not found", err)
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, "Failed to get tournament", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, tournament)
+}
+
+// AdvanceTournament принимает победителей текущего раунда и переводит турнир в следующий
+func (h *TournamentHandler) AdvanceTournament(w http.ResponseWriter, r *http.Request) {
+	tournamentID := mux.Vars(r)["id"]
+
+	var req models.AdvanceTournamentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if len(req.WinnerPlayerIDs) == 0 {
+		h.respondError(w, http.StatusBadRequest, "winner_player_ids is required", nil)
+		return
+	}
+
+	tournament, err := h.tournaments.AdvanceRound(r.Context(), tournamentID, req.WinnerPlayerIDs)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrTournamentNotFound):
+			h.respondError(w, http.StatusNotFound, "Tournament not found", err)
+		case errors.Is(err, service.ErrInvalidTournamentResult):
+			h.respondError(w, http.StatusBadRequest, "Winners must be exactly one side of every match in the round", err)
+		case errors.Is(err, service.ErrTournamentCompleted):
+			h.respondError(w, http.StatusConflict, "Tournament is already completed", err)
+		case errors.Is(err, service.ErrTournamentBusy):
+			h.respondError(w, http.StatusConflict, "Tournament round is being advanced, retry", err)
+		default:
+			h.respondError(w, http.StatusInternalServerError, "Failed to advance tournament", err)
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, tournament)
+}
+
+// respondJSON отправляет JSON ответ
+func (h *TournamentHandler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	writeJSON(w, h.logger, status, data)
+}
+
+// respondError отправляет ошибку в формате JSON
+func (h *TournamentHandler) respondError(w http.ResponseWriter, status int, message string, err error) {
+	writeError(w, h.logger, status, message, err)
+}
@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/service"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+const (
+	wsWriteTimeout = 10 * time.Second // Время на отправку одного сообщения
+	wsPongTimeout  = 60 * time.Second // Соединение считается разорванным без pong
+	wsPingInterval = 30 * time.Second // Должен быть меньше wsPongTimeout
+)
+
+// MatchNotifier доставляет игроку созданный для него матч
+type MatchNotifier interface {
+	Subscribe(playerID string) (<-chan *models.Match, error)
+	Unsubscribe(playerID string)
+}
+
+// wsUpgrader переводит HTTP соединение в WebSocket. Клиенты — игровые приложения,
+// а не браузеры, поэтому Origin не проверяется.
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// WebSocketHandler отправляет игрокам уведомления о матче через WebSocket
+type WebSocketHandler struct {
+	matcher  *service.MatcherService
+	notifier MatchNotifier
+	logger   *zap.Logger
+}
+
+// NewWebSocketHandler создает новый обработчик WebSocket
+func NewWebSocketHandler(matcher *service.MatcherService, notifier MatchNotifier, logger *zap.Logger) *WebSocketHandler {
+	return &WebSocketHandler{
+		matcher:  matcher,
+		notifier: notifier,
+		logger:   logger,
+	}
+}
+
+// MatchUpdates держит WebSocket соединение с игроком и отправляет ему матч,
+// как только тот будет создан. После отправки матча соединение закрывается.
+func (h *WebSocketHandler) MatchUpdates(w http.ResponseWriter, r *http.Request) {
+	playerID := mux.Vars(r)["player_id"]
+	if playerID == "" {
+		writeError(w, h.logger, http.StatusBadRequest, "Player ID is required", nil)
+		return
+	}
+	if isOtherPlayer(r, playerID) {
+		writeError(w, h.logger, http.StatusForbidden, notOwnPlayerMessage, nil)
+		return
+	}
+
+	// Подписываемся до апгрейда, чтобы вернуть обычную HTTP ошибку
+	matches, err := h.notifier.Subscribe(playerID)
+	if err != nil {
+		writeError(w, h.logger, http.StatusConflict, "Player already has an open connection", err)
+		return
+	}
+	defer h.notifier.Unsubscribe(playerID)
+
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade сам отвечает клиенту при ошибке
+		logging.FromContext(r.Context()).Warn("Failed to upgrade WebSocket connection",
+			zap.String("player_id", playerID),
+			zap.Error(err),
+		)
+		return
+	}
+	defer conn.Close()
+
+	// Матч мог быть создан до подключения
+	if match, err := h.matcher.GetPlayerMatch(r.Context(), playerID); err == nil {
+		h.sendMatch(conn, playerID, match)
+		return
+	} else if !errors.Is(err, service.ErrMatchNotFound) {
+		logging.FromContext(r.Context()).Warn("Failed to check existing match",
+			zap.String("player_id", playerID),
+			zap.Error(err),
+		)
+	}
+
+	// Чтение нужно, чтобы обрабатывать pong и заметить отключение клиента
+	disconnected := make(chan struct{})
+	conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	})
+	go func() {
+		defer close(disconnected)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(wsPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case match, ok := <-matches:
+			if !ok {
+				return
+			}
+			h.sendMatch(conn, playerID, match)
+			return
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return
+			}
+		case <-disconnected:
+			logging.FromContext(r.Context()).Debug("WebSocket client disconnected", zap.String("player_id", playerID))
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// sendMatch отправляет матч игроку и корректно закрывает соединение
+func (h *WebSocketHandler) sendMatch(conn *websocket.Conn, playerID string, match *models.Match) {
+	conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if err := conn.WriteJSON(match); err != nil {
+		h.logger.Warn("Failed to send match over WebSocket",
+			zap.String("player_id", playerID),
+			zap.String("match_id", match.MatchID),
+			zap.Error(err),
+		)
+		return
+	}
+
+	h.logger.Info("Match pushed over WebSocket",
+		zap.String("player_id", playerID),
+		zap.String("match_id", match.MatchID),
+	)
+
+	conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, "match found"),
+		time.Now().Add(wsWriteTimeout),
+	)
+}
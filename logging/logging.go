@@ -0,0 +1,59 @@
+// Package logging хранит базовый логгер сервиса и логгер запроса в контексте, чтобы
+// строки логов обработчика, сервиса и хранилища для одного запроса можно было связать
+// по request_id
+package logging
+
+import (
+	"context"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+// base логгер сервиса; до SetLogger — no-op
+var base atomic.Pointer[zap.Logger]
+
+func init() {
+	base.Store(zap.NewNop())
+}
+
+// contextKey ключ значений пакета в контексте
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	loggerKey
+)
+
+// SetLogger задает базовый логгер сервиса. Вызывается при запуске до обработки запросов.
+func SetLogger(logger *zap.Logger) {
+	base.Store(logger)
+}
+
+// Logger возвращает базовый логгер сервиса
+func Logger() *zap.Logger {
+	return base.Load()
+}
+
+// WithRequestID возвращает контекст с ID запроса и логгером, в каждой строке
+// которого есть поле request_id
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	ctx = context.WithValue(ctx, requestIDKey, requestID)
+	return context.WithValue(ctx, loggerKey, Logger().With(zap.String("request_id", requestID)))
+}
+
+// RequestIDFromContext возвращает ID запроса или пустую строку, если контекст
+// не относится к HTTP-запросу (например, фоновая обработка очереди)
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// FromContext возвращает логгер запроса с полем request_id или базовый логгер,
+// если в контексте нет ID запроса
+func FromContext(ctx context.Context) *zap.Logger {
+	if logger, ok := ctx.Value(loggerKey).(*zap.Logger); ok {
+		return logger
+	}
+	return Logger()
+}
@@ -2,26 +2,46 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 	"time"
 
-	"github.com/gorilla/mux"
+	"chrono-matchmaking/broadcast"
+	"chrono-matchmaking/config"
+	"chrono-matchmaking/coordinator"
+	"chrono-matchmaking/docs"
+	mmgrpc "chrono-matchmaking/grpc"
 	"chrono-matchmaking/handler"
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/metrics"
+	"chrono-matchmaking/middleware"
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/notification"
 	"chrono-matchmaking/service"
 	"chrono-matchmaking/storage"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.uber.org/zap"
 )
 
-const (
-	defaultRedisAddr     = "localhost:6379"
-	defaultRedisPassword = "0000" // Пароль из docker-compose.yml
-	defaultRedisDB       = 0
-	serverPort           = ":8080"
-)
+const defaultServiceName = "chrono-matchmaking"
+
+// gzipMinSizeBytes ответы больше этого размера сжимаются для клиентов с Accept-Encoding: gzip
+const gzipMinSizeBytes = 1024
+
+// serverShutdownTimeout время на остановку HTTP и gRPC серверов перед последним проходом по очередям
+const serverShutdownTimeout = 10 * time.Second
 
 // getEnv получает значение переменной окружения или возвращает значение по умолчанию
 func getEnv(key, defaultValue string) string {
@@ -31,64 +51,271 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// setupTracing настраивает экспорт трейсов OpenTelemetry по OTLP/HTTP на адрес из
+// OTEL_EXPORTER_OTLP_ENDPOINT. Если переменная не задана, остается no-op провайдер
+// по умолчанию и спаны никуда не отправляются. Возвращает функцию, дописывающую
+// накопленные спаны при остановке.
+func setupTracing(ctx context.Context, serviceName string) (func(context.Context) error, error) {
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	// Адрес и заголовки экспортер читает из стандартных переменных OTEL_EXPORTER_OTLP_*
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
+
 func main() {
+	configPath := flag.String("config", "", "path to YAML config file; environment variables override its values")
+	flag.Parse()
+
 	// Инициализация логгера
 	logger, err := zap.NewProduction()
 	if err != nil {
 		panic(fmt.Sprintf("Failed to initialize logger: %v", err))
 	}
 	defer logger.Sync()
+	logging.SetLogger(logger)
 
 	logger.Info("Starting Chrono Matchmaking Service")
 
-	// Получаем настройки Redis из переменных окружения или используем значения по умолчанию
-	redisAddr := getEnv("REDIS_ADDR", defaultRedisAddr)
-	redisPassword := getEnv("REDIS_PASSWORD", defaultRedisPassword)
-	redisDB := defaultRedisDB
-	if db := os.Getenv("REDIS_DB"); db != "" {
-		fmt.Sscanf(db, "%d", &redisDB)
+	// Трассировка OpenTelemetry (OTEL_EXPORTER_OTLP_ENDPOINT, OTEL_SERVICE_NAME)
+	serviceName := getEnv("OTEL_SERVICE_NAME", defaultServiceName)
+	shutdownTracing, err := setupTracing(context.Background(), serviceName)
+	if err != nil {
+		logger.Fatal("Failed to initialize tracing", zap.Error(err))
+	}
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" {
+		logger.Info("OpenTelemetry tracing enabled", zap.String("service_name", serviceName))
 	}
 
-	// Инициализация Redis хранилища
-	redisStorage, err := storage.NewRedisStorage(redisAddr, redisPassword, redisDB, logger)
-	if err != nil {
-		logger.Fatal("Failed to initialize Redis storage", zap.Error(err))
+	// Настройки запуска: значения по умолчанию, затем файл из --config, затем переменные окружения
+	appConfig := config.Default()
+	if *configPath != "" {
+		appConfig, err = config.LoadFromFile(*configPath)
+		if err != nil {
+			logger.Fatal("Failed to load config file", zap.String("path", *configPath), zap.Error(err))
+		}
+		logger.Info("Config loaded from file", zap.String("path", *configPath))
+	}
+	if err := appConfig.ApplyEnv(); err != nil {
+		logger.Fatal("Invalid environment configuration", zap.Error(err))
+	}
+	if err := appConfig.Validate(); err != nil {
+		logger.Fatal("Invalid configuration", zap.Error(err))
 	}
-	defer redisStorage.Close()
 
-	logger.Info("Connected to Redis", zap.String("addr", redisAddr))
+	// Инициализация хранилища: в памяти процесса при STORAGE_BACKEND=memory, иначе Redis
+	var redisStorage storage.Storage
+	if appConfig.Redis.Backend == "memory" {
+		redisStorage = storage.NewInMemoryStorage()
+		logger.Warn("Using in-memory storage: data is lost on restart and not shared between replicas")
+	} else {
+		redisStorage, err = storage.NewRedisStorage(appConfig.Redis.Addr, appConfig.Redis.Password, appConfig.Redis.DB, appConfig.Redis.Pool, logger)
+		if err != nil {
+			logger.Fatal("Failed to initialize Redis storage", zap.Error(err))
+		}
+		logger.Info("Connected to Redis", zap.String("addr", appConfig.Redis.Addr))
+	}
+	defer redisStorage.Close()
 
 	// Инициализация сервиса матчмейкинга
-	matcherConfig := service.DefaultMatcherConfig()
-	matcherService := service.NewMatcherService(redisStorage, logger, matcherConfig)
-	
+	matcherConfig := appConfig.Matcher
+	matchingAlgorithm, err := service.NewMatchingAlgorithm(matcherConfig.Algorithm)
+	if err != nil {
+		logger.Fatal("Invalid MATCHING_ALGORITHM", zap.Error(err))
+	}
+	matcherService := service.NewMatcherServiceWithAlgorithm(redisStorage, logger, matcherConfig, matchingAlgorithm)
+	logger.Info("Matching algorithm selected", zap.String("algorithm", matcherConfig.Algorithm))
+
+	// Конфигурация из файла (CONFIG_FILE) заменяет значения по умолчанию и перечитывается при изменении
+	var configWatcher *config.Watcher
+	if configFile := os.Getenv("CONFIG_FILE"); configFile != "" {
+		configWatcher = config.NewWatcher(configFile, matcherService, logger)
+		if err := configWatcher.Load(); err != nil {
+			logger.Fatal("Failed to load matcher config", zap.String("path", configFile), zap.Error(err))
+		}
+		logger.Info("Matcher config loaded from file", zap.String("path", configFile))
+	}
+
 	// Настройка URL game-service из переменной окружения
-	gameServiceURL := getEnv("GAME_SERVICE_URL", "http://localhost:8081")
+	gameServiceURL := appConfig.Server.GameServiceURL
 	matcherService.SetGameServiceURL(gameServiceURL)
 	logger.Info("Game service URL configured", zap.String("url", gameServiceURL))
 
+	// Координация реплик: очередь обрабатывает только лидер для пары регион/режим
+	queueCoordinator := coordinator.NewCoordinator(redisStorage, logger)
+	matcherService.SetCoordinator(queueCoordinator)
+
+	// Уведомления о матчах для WebSocket подписчиков
+	matchNotifier := service.NewMatchNotifier()
+	matcherService.SetMatchNotifier(matchNotifier)
+	logger.Info("Queue coordinator initialized", zap.String("instance_id", queueCoordinator.InstanceID()))
+
+	// События очереди для SSE-потока дашбордов
+	eventHub := broadcast.NewHub()
+	matcherService.SetEventHub(eventHub)
+
+	// Живые показатели очередей для WebSocket админ-консоли
+	metricsCollector := service.NewMetricsCollector(matcherService, eventHub, logger)
+
+	// Push-уведомления о продвижении в очереди (PUSH_PROVIDER=fcm)
+	switch pushProvider := getEnv("PUSH_PROVIDER", ""); pushProvider {
+	case "":
+	case "fcm":
+		fcmProvider, err := notification.NewFCMPushProvider(os.Getenv("FCM_CREDENTIALS_FILE"), redisStorage, logger)
+		if err != nil {
+			logger.Fatal("Failed to initialize FCM push provider", zap.Error(err))
+		}
+		matcherService.SetPushProvider(fcmProvider)
+		logger.Info("Push notifications enabled", zap.String("provider", pushProvider))
+	default:
+		logger.Fatal("Unknown push provider", zap.String("provider", pushProvider))
+	}
+
+	// Назначение игровых серверов матчам
+	serverRegistry := service.NewServerRegistry(redisStorage, logger)
+	matcherService.SetServerRegistry(serverRegistry)
+
 	// Инициализация HTTP handlers
 	queueHandler := handler.NewQueueHandler(matcherService, logger)
+	seasonManager := service.NewSeasonManager(redisStorage, logger)
+	tournamentHandler := handler.NewTournamentHandler(service.NewTournamentService(redisStorage, matcherService, logger), logger)
+	adminHandler := handler.NewAdminHandler(matcherService, seasonManager, serverRegistry, logger)
+	playerHandler := handler.NewPlayerHandler(matcherService, logger)
+	analyticsHandler := handler.NewAnalyticsHandler(matcherService, logger)
+	matchHandler := handler.NewMatchHandler(matcherService, logger)
+	wsHandler := handler.NewWebSocketHandler(matcherService, matchNotifier, logger)
+	eventHandler := handler.NewEventHandler(eventHub, logger)
+	adminMetricsHandler := handler.NewAdminMetricsHandler(metricsCollector, logger)
+
+	// Признак работы горутины обработки очередей для проверки готовности
+	var queueProcessorRunning atomic.Bool
+	healthHandler := handler.NewHealthHandler(redisStorage, &queueProcessorRunning, matcherService, logger)
 
 	// Настройка маршрутов
 	router := mux.NewRouter()
+	router.Use(middleware.Tracing(serviceName))
+	router.Use(middleware.RequestID())
+	router.Use(middleware.Gzip(gzipMinSizeBytes))
 	api := router.PathPrefix("/api/v1").Subrouter()
 
+	// Аутентификация по JWT (JWT_SECRET); /healthz/* и /metrics доступны без токена
+	if jwtSecret := os.Getenv("JWT_SECRET"); jwtSecret != "" {
+		api.Use(middleware.JWTAuth(jwtSecret))
+		logger.Info("JWT authentication enabled")
+	} else {
+		logger.Warn("JWT_SECRET is not set, API authentication is disabled and /api/v1/admin and game server endpoints reject every request")
+	}
+
 	// Эндпоинты матчмейкинга
-	api.HandleFunc("/queue/join", queueHandler.JoinQueue).Methods("POST")
+	// Вход в очередь ограничен по IP, чтобы один клиент не заполнил очередь
+	joinRateLimit := middleware.NewRateLimitMiddleware(redisStorage, matcherService.GetMatcherConfig().RateLimit)
+	api.Handle("/queue/join", joinRateLimit(http.HandlerFunc(queueHandler.JoinQueue))).Methods("POST")
+	api.Handle("/queue/join/batch", joinRateLimit(http.HandlerFunc(queueHandler.JoinQueueBatch))).Methods("POST")
+	api.HandleFunc("/queue/party/join", queueHandler.JoinParty).Methods("POST")
 	api.HandleFunc("/queue/leave/{player_id}", queueHandler.LeaveQueue).Methods("DELETE")
+	api.HandleFunc("/queue/player/{player_id}", queueHandler.UpdateQueuedPlayer).Methods("PUT")
 	api.HandleFunc("/queue/match/{player_id}", queueHandler.FindMatch).Methods("GET")
+	api.HandleFunc("/queue/match/{player_id}/reconnect", queueHandler.ReconnectMatch).Methods("GET")
+	api.HandleFunc("/queue/match/{pending_id}/accept", queueHandler.AcceptMatch).Methods("POST")
+	api.HandleFunc("/queue/match/{pending_id}/decline", queueHandler.DeclineMatch).Methods("POST")
+	api.HandleFunc("/match/private/create", queueHandler.CreatePrivateMatch).Methods("POST")
+	api.Handle("/match/private/join", joinRateLimit(http.HandlerFunc(queueHandler.JoinPrivateMatch))).Methods("POST")
 	api.HandleFunc("/queue/status", queueHandler.GetQueueStatus).Methods("GET")
+	api.HandleFunc("/queue/ws/{player_id}", wsHandler.MatchUpdates).Methods("GET")
+	api.HandleFunc("/queue/segment-stats", queueHandler.GetQueueSegmentStats).Methods("GET")
+	api.HandleFunc("/queue/position/{player_id}", queueHandler.GetQueuePosition).Methods("GET")
+	api.HandleFunc("/queue/wait-estimate", queueHandler.GetWaitEstimate).Methods("GET")
+	api.HandleFunc("/queue/heartbeat/{player_id}", queueHandler.Heartbeat).Methods("POST")
+	api.HandleFunc("/events/queue", eventHandler.QueueEvents).Methods("GET")
+
+	// Эндпоинты матчей. Результат, подтверждение и добор игроков присылает игровой
+	// сервер: они доступны только с ролью game_server
+	gameServerOnly := middleware.RequireRole(middleware.RoleGameServer)
+	api.HandleFunc("/matches/{match_id}/satisfaction", matchHandler.GetSatisfaction).Methods("GET")
+	api.Handle("/matches/{match_id}/backfill", gameServerOnly(http.HandlerFunc(matchHandler.Backfill))).Methods("POST")
+	api.Handle("/match/{match_id}/result", gameServerOnly(http.HandlerFunc(matchHandler.ReportResult))).Methods("POST")
+	api.Handle("/matches/{match_id}/ack", gameServerOnly(http.HandlerFunc(matchHandler.Acknowledge))).Methods("POST")
+
+	// Эндпоинты турниров
+	api.HandleFunc("/tournament", tournamentHandler.CreateTournament).Methods("POST")
+	api.HandleFunc("/tournament/{id}", tournamentHandler.GetTournament).Methods("GET")
+	api.HandleFunc("/tournament/{id}/advance", tournamentHandler.AdvanceTournament).Methods("POST")
 
-	// Health check
-	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	}).Methods("GET")
+	// Эндпоинты игрока
+	api.HandleFunc("/players/register", playerHandler.Register).Methods("POST")
+	api.HandleFunc("/players/{player_id}/rating-advice", playerHandler.GetRatingAdvice).Methods("GET")
+	api.HandleFunc("/players/{player_id}/rating-history", playerHandler.GetRatingHistory).Methods("GET")
+	api.HandleFunc("/players/{player_id}/history", playerHandler.GetMatchHistory).Methods("GET")
+	api.HandleFunc("/players/{player_id}/stats", playerHandler.GetStats).Methods("GET")
+	api.HandleFunc("/players/{player_id}/push-token", playerHandler.RegisterPushToken).Methods("POST")
+
+	// Публичная конфигурация (без чувствительных данных)
+	api.HandleFunc("/config/region-latency", queueHandler.GetRegionLatencyMap).Methods("GET")
+	api.HandleFunc("/config/region-latency/{region1}/{region2}", queueHandler.GetRegionLatency).Methods("GET")
+
+	// Аналитика очередей
+	api.HandleFunc("/analytics/peak-hours", analyticsHandler.GetPeakHours).Methods("GET")
+
+	// Административные эндпоинты доступны только с ролью admin (см. middleware.RequireAdmin):
+	// без JWT_SECRET роль проверить нечем, и все они отвечают 401
+	admin := api.PathPrefix("/admin").Subrouter()
+	admin.Use(middleware.RequireAdmin)
+	admin.HandleFunc("/config", adminHandler.GetConfig).Methods("GET")
+	admin.HandleFunc("/config", adminHandler.ReplaceConfig).Methods("PUT")
+	admin.HandleFunc("/config", adminHandler.PatchConfig).Methods("PATCH")
+	admin.HandleFunc("/regions", adminHandler.GetRegions).Methods("GET")
+	admin.HandleFunc("/regions", adminHandler.SetRegions).Methods("PUT")
+	admin.HandleFunc("/game_modes", adminHandler.GetGameModes).Methods("GET")
+	admin.HandleFunc("/game_modes", adminHandler.SetGameModes).Methods("PUT")
+	admin.HandleFunc("/queue/top-waiting", adminHandler.GetTopWaitingPlayers).Methods("GET")
+	admin.HandleFunc("/queue/snapshot", adminHandler.QueueSnapshot).Methods("GET")
+	admin.HandleFunc("/queue/restore", adminHandler.RestoreQueues).Methods("POST")
+	admin.HandleFunc("/queue/reindex", adminHandler.ReindexQueue).Methods("POST")
+	admin.HandleFunc("/simulate", adminHandler.Simulate).Methods("POST")
+	admin.HandleFunc("/dlq", adminHandler.GetDLQ).Methods("GET")
+	admin.HandleFunc("/dlq/{index}/retry", adminHandler.RetryDLQEntry).Methods("POST")
+	admin.HandleFunc("/stats/distribution-comparison", adminHandler.GetDistributionComparison).Methods("GET")
+	admin.HandleFunc("/memory-usage", adminHandler.GetMemoryUsage).Methods("GET")
+	admin.HandleFunc("/ws/metrics", adminMetricsHandler.LiveMetrics).Methods("GET")
+	admin.HandleFunc("/ban", adminHandler.BanPlayer).Methods("POST")
+	admin.HandleFunc("/flagged", adminHandler.GetFlaggedPlayers).Methods("GET")
+	admin.HandleFunc("/flagged/{player_id}", adminHandler.UnflagPlayer).Methods("DELETE")
+	admin.HandleFunc("/servers", adminHandler.RegisterServer).Methods("POST")
+	admin.HandleFunc("/servers/{server_id}", adminHandler.DeregisterServer).Methods("DELETE")
+	admin.HandleFunc("/season/reset", adminHandler.ResetSeason).Methods("POST")
+	admin.HandleFunc("/matches/schedule", adminHandler.ScheduleMatch).Methods("POST")
+	admin.HandleFunc("/match/{match_id}", adminHandler.GetMatch).Methods("GET")
+	admin.HandleFunc("/matches/{match_id}/validate", adminHandler.ValidateMatch).Methods("GET")
+
+	// Проверки живости и готовности
+	router.HandleFunc("/healthz/live", healthHandler.Live).Methods("GET")
+	router.HandleFunc("/healthz/ready", healthHandler.Ready).Methods("GET")
+
+	// Метрики Prometheus
+	router.Handle("/metrics", metrics.Handler()).Methods("GET")
+
+	// Описание API в формате OpenAPI 3.0
+	router.HandleFunc("/openapi.json", docs.Handler()).Methods("GET")
+	if missing := docs.Undocumented(router); len(missing) > 0 {
+		logger.Warn("Routes missing from OpenAPI spec", zap.Strings("routes", missing))
+	}
 
 	// Настройка HTTP сервера
 	srv := &http.Server{
-		Addr:         serverPort,
+		Addr:         appConfig.HTTPAddr(),
 		Handler:      router,
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
@@ -97,31 +324,163 @@ func main() {
 
 	// Запуск сервера в горутине
 	go func() {
-		logger.Info("Starting HTTP server", zap.String("port", serverPort))
+		logger.Info("Starting HTTP server", zap.String("port", appConfig.HTTPAddr()))
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			logger.Fatal("Failed to start server", zap.Error(err))
 		}
 	}()
 
+	// gRPC API для внутренних сервисов с теми же операциями очереди
+	grpcServer := mmgrpc.NewGRPCServer(matcherService, matchNotifier, logger)
+	grpcListener, err := net.Listen("tcp", appConfig.GRPCAddr())
+	if err != nil {
+		logger.Fatal("Failed to listen for gRPC", zap.String("port", appConfig.GRPCAddr()), zap.Error(err))
+	}
+	go func() {
+		logger.Info("Starting gRPC server", zap.String("port", appConfig.GRPCAddr()))
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			logger.Fatal("Failed to start gRPC server", zap.Error(err))
+		}
+	}()
+
 	// Запуск обработчика очереди в фоне
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Отслеживание истекших и не подтвержденных вовремя матчей через Redis Stream:
+	// освобождение серверов, возврат игроков брошенных матчей в очередь и отмена
+	// ожидающих матчей
+	go func() {
+		err := redisStorage.WatchForMatchExpiry(ctx, matcherService.HandleMatchExpiry)
+		if err != nil && err != context.Canceled {
+			logger.Error("Match expiry watcher stopped", zap.Error(err))
+		}
+	}()
+
+	// Сбор созданных матчей и рассылка снимков админ-консолям
+	go metricsCollector.Run(ctx)
+
+	// Применяем частичные обновления конфигурации, принятые другими репликами
+	go func() {
+		if err := matcherService.WatchConfigUpdates(ctx); err != nil && err != context.Canceled {
+			logger.Error("Matcher config update subscription stopped", zap.Error(err))
+		}
+	}()
+
+	// Применяем изменения файла конфигурации без перезапуска
+	if configWatcher != nil {
+		go func() {
+			if err := configWatcher.Run(ctx); err != nil && err != context.Canceled {
+				logger.Error("Config watcher stopped", zap.Error(err))
+			}
+		}()
+	}
+
+	// Регионы и режимы, очереди которых обрабатываются в фоне. Списки хранятся в Redis
+	// и меняются через /admin/regions и /admin/game_modes; фоновые задачи перечитывают
+	// их на каждом проходе.
+	activeRegions := func(ctx context.Context) []string {
+		regions, err := matcherService.GetRegions(ctx)
+		if err != nil {
+			logger.Warn("Failed to get active regions, using defaults", zap.Error(err))
+			return service.DefaultRegions()
+		}
+		return regions
+	}
+	activeGameModes := func(ctx context.Context) []string {
+		modes, err := matcherService.GetGameModes(ctx)
+		if err != nil {
+			logger.Warn("Failed to get game modes, using defaults", zap.Error(err))
+			return service.GameModeNames(service.DefaultGameModes())
+		}
+		return service.GameModeNames(modes)
+	}
+	regions := activeRegions(ctx)
+	gameModes := activeGameModes(ctx)
+
+	// Реплики, не являющиеся лидером, получают события о матчах от лидера
+	for _, region := range regions {
+		for _, gameMode := range gameModes {
+			go func(region, gameMode string) {
+				err := queueCoordinator.SubscribeMatchFormed(ctx, region, gameMode, func(match *models.Match) {
+					logger.Info("Match formed by leader replica",
+						zap.String("match_id", match.MatchID),
+						zap.String("region", region),
+						zap.String("game_mode", gameMode),
+					)
+					// Игрок мог подключиться по WebSocket к этой реплике
+					matchNotifier.Notify(match)
+					eventHub.Publish(broadcast.Event{
+						Type: models.QueueEventMatchCreated,
+						Data: models.QueueEvent{Region: region, GameMode: gameMode, Match: match},
+					})
+				})
+				if err != nil && err != context.Canceled {
+					logger.Warn("Match formed subscription stopped",
+						zap.String("region", region),
+						zap.String("game_mode", gameMode),
+						zap.Error(err),
+					)
+				}
+			}(region, gameMode)
+		}
+	}
+
+	// Очереди обрабатываются параллельно пулом воркеров
+	queueProcessor := service.NewQueueProcessor(matcherService, matcherService.GetMatcherConfig().WorkerPoolSize, logger)
+	go func() {
+		queueProcessorRunning.Store(true)
+		defer queueProcessorRunning.Store(false)
+
+		queueProcessor.Run(ctx)
+	}()
+
+	// Размер пула воркеров подстраивается под заполненность канала заданий
+	go func() {
+		if err := queueProcessor.AutoScaleQueueWorkers(ctx, service.DefaultQueueWorkerUtilization); err != nil {
+			logger.Error("Queue worker autoscaling stopped", zap.Error(err))
+		}
+	}()
+
+	// Активация запланированных матчей, время начала которых наступило
+	go func() {
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				modes := activeGameModes(ctx)
+				for _, region := range activeRegions(ctx) {
+					for _, gameMode := range modes {
+						if err := matcherService.PromoteScheduledMatches(ctx, region, gameMode); err != nil {
+							logger.Warn("Failed to promote scheduled matches",
+								zap.String("region", region),
+								zap.String("game_mode", gameMode),
+								zap.Error(err),
+							)
+						}
+					}
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// Периодическое сравнение распределений рейтинга для метрики rating_distribution_kl_divergence
 	go func() {
-		ticker := time.NewTicker(10 * time.Second) // Проверяем очередь каждые 10 секунд
+		ticker := time.NewTicker(5 * time.Minute)
 		defer ticker.Stop()
 
 		for {
 			select {
 			case <-ticker.C:
-				// Обрабатываем очереди для разных регионов и режимов
-				regions := []string{"EU", "US", "ASIA"}
-				gameModes := []string{"1v1", "3v3"}
-
-				for _, region := range regions {
-					for _, gameMode := range gameModes {
-						if err := matcherService.ProcessQueue(ctx, region, gameMode); err != nil {
-							logger.Warn("Failed to process queue",
+				modes := activeGameModes(ctx)
+				for _, region := range activeRegions(ctx) {
+					for _, gameMode := range modes {
+						if _, err := matcherService.GetRatingDistributionComparison(ctx, region, gameMode, nil); err != nil {
+							logger.Warn("Failed to compare rating distributions",
 								zap.String("region", region),
 								zap.String("game_mode", gameMode),
 								zap.Error(err),
@@ -135,6 +494,98 @@ func main() {
 		}
 	}()
 
+	// Ежедневная очистка очередей от игроков, ожидающих дольше MaxSearchTime
+	go func() {
+		ticker := time.NewTicker(24 * time.Hour)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				modes := activeGameModes(ctx)
+				for _, region := range activeRegions(ctx) {
+					for _, gameMode := range modes {
+						if _, err := matcherService.PurgeInactivePlayers(ctx, region, gameMode); err != nil {
+							logger.Warn("Failed to purge inactive players",
+								zap.String("region", region),
+								zap.String("game_mode", gameMode),
+								zap.Error(err),
+							)
+						}
+					}
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// Поиск брошенных матчей при старте и затем каждые MatchTTL: игроки матчей, которые
+	// не подтвердил ни один игровой сервер, возвращаются в очередь. Интервал перечитывается
+	// из конфигурации после каждого прохода
+	go func() {
+		for {
+			requeued, err := matcherService.CleanupExpiredMatches(ctx)
+			if err != nil {
+				logger.Warn("Failed to clean up expired matches", zap.Error(err))
+			} else if requeued > 0 {
+				logger.Info("Players of orphaned matches requeued", zap.Int("players", requeued))
+			}
+
+			timer := time.NewTimer(matcherService.GetMatcherConfig().MatchTTL)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			}
+		}
+	}()
+
+	// Ежедневное снижение рейтинга игроков, вернувшихся после долгого перерыва
+	ratingDecayJob := service.NewRatingDecayJob(matcherService, logger)
+	go func() {
+		ticker := time.NewTicker(24 * time.Hour)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := ratingDecayJob.Run(ctx); err != nil {
+					logger.Warn("Failed to decay inactive player ratings", zap.Error(err))
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// Игроки, ключ которых скоро истечет без heartbeat: по этим предупреждениям
+	// подбирается рекомендуемый клиентам интервал heartbeat
+	go func() {
+		ticker := time.NewTicker(30 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				expiring, err := matcherService.GetExpiringPlayers(ctx, 60*time.Second)
+				if err != nil {
+					logger.Warn("Failed to check expiring players", zap.Error(err))
+					continue
+				}
+				for playerID, ttl := range expiring {
+					logger.Warn("Queued player is about to expire without heartbeat",
+						zap.String("player_id", playerID),
+						zap.Duration("ttl", ttl),
+					)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
 	// Ожидание сигнала для graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -148,10 +599,43 @@ func main() {
 
 	cancel() // Останавливаем обработчик очереди
 
-	if err := srv.Shutdown(shutdownCtx); err != nil {
+	// Сначала перестаем принимать запросы, чтобы во время последнего прохода
+	// в очереди не вставали новые игроки. Открытые SSE-потоки держат сервер до
+	// таймаута, поэтому на остановку серверов отводится только часть времени
+	serverCtx, serverCancel := context.WithTimeout(shutdownCtx, serverShutdownTimeout)
+	if err := srv.Shutdown(serverCtx); err != nil {
 		logger.Error("Server forced to shutdown", zap.Error(err))
 	}
+	grpcServer.Shutdown(serverCtx)
+	serverCancel()
+
+	// Последний проход по очередям, чтобы ожидающие игроки не остались без матча
+	regions, gameModes = activeRegions(shutdownCtx), activeGameModes(shutdownCtx)
+	if err := matcherService.DrainQueues(shutdownCtx, regions, gameModes); err != nil {
+		logger.Warn("Failed to drain queues", zap.Error(err))
+	}
+	if ttl := appConfig.Server.QueueDrainTTL; ttl > 0 {
+		if err := matcherService.ExpireLedQueues(shutdownCtx, regions, gameModes, ttl); err != nil {
+			logger.Warn("Failed to expire queues", zap.Error(err))
+		}
+	}
+
+	// Освобождаем лидерство, чтобы другие реплики подхватили очереди без ожидания TTL
+	for _, region := range regions {
+		for _, gameMode := range gameModes {
+			if err := matcherService.ResignLeadership(shutdownCtx, region, gameMode); err != nil {
+				logger.Warn("Failed to resign queue leadership",
+					zap.String("region", region),
+					zap.String("game_mode", gameMode),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Warn("Failed to flush traces", zap.Error(err))
+	}
 
 	logger.Info("Server exited")
 }
-
@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry отдельный реестр метрик сервиса (не глобальный, чтобы избежать конфликтов в тестах)
+var Registry = prometheus.NewRegistry()
+
+var (
+	// QueueDepth количество игроков в очереди
+	QueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "queue_depth",
+		Help: "Number of players waiting in the queue.",
+	}, []string{"region", "game_mode"})
+
+	// MatchCreationTotal количество созданных матчей
+	MatchCreationTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "match_creation_total",
+		Help: "Number of matches created.",
+	}, []string{"region", "game_mode"})
+
+	// MatchWaitSeconds время ожидания игроков в очереди до создания матча
+	MatchWaitSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "match_wait_seconds",
+		Help:    "Time players spent in the queue before their match was created, in seconds.",
+		Buckets: []float64{5, 10, 20, 30, 60, 90, 120, 180, 300, 600},
+	}, []string{"region", "game_mode"})
+
+	// RedisOpErrorsTotal количество ошибок команд Redis
+	RedisOpErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "redis_op_errors_total",
+		Help: "Number of failed Redis commands by command name.",
+	}, []string{"op"})
+
+	// StorageRetryTotal количество повторов команд Redis после временных ошибок
+	StorageRetryTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "storage_retry_total",
+		Help: "Number of Redis command retries after transient errors by command and attempt number.",
+	}, []string{"op", "attempt"})
+
+	// QueueOldestWaiterSeconds время ожидания самого старого игрока в очереди
+	QueueOldestWaiterSeconds = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "queue_oldest_waiter_seconds",
+		Help: "Wait time of the longest-waiting player in the queue, in seconds.",
+	}, []string{"region", "game_mode"})
+
+	// AutoPurgeTriggeredTotal количество автоматических очисток очереди при ее росте
+	AutoPurgeTriggeredTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "auto_purge_triggered_total",
+		Help: "Number of automatic stale player purges triggered by queue growth.",
+	}, []string{"region", "game_mode"})
+
+	// RedisEstimatedMemoryMB оценка памяти Redis по шаблонам ключей
+	RedisEstimatedMemoryMB = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "redis_estimated_memory_mb",
+		Help: "Estimated Redis memory used by keys of a pattern, in megabytes.",
+	}, []string{"pattern"})
+
+	// RatingDistributionKLDivergence расхождение распределений рейтинга сматченных игроков и очереди
+	RatingDistributionKLDivergence = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rating_distribution_kl_divergence",
+		Help: "KL divergence of matched player ratings (last 24h) from the current queue rating distribution.",
+	}, []string{"region", "game_mode"})
+
+	// QueueFullRejectionsTotal количество входов в очередь, отклоненных из-за ее размера
+	QueueFullRejectionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "queue_full_rejections_total",
+		Help: "Number of queue joins rejected because the queue reached its maximum size.",
+	}, []string{"region", "game_mode"})
+
+	// DLQEnqueuedTotal количество групп, попавших в DLQ после неудачных попыток сохранить матч
+	DLQEnqueuedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "dlq_enqueued_total",
+		Help: "Number of player groups moved to the dead-letter queue after repeated match formation failures.",
+	}, []string{"region", "game_mode"})
+
+	// QueueWorkerCount количество воркеров, обрабатывающих очереди
+	QueueWorkerCount = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "queue_worker_count",
+		Help: "Number of workers processing matchmaking queues.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		QueueDepth,
+		MatchCreationTotal,
+		MatchWaitSeconds,
+		RedisOpErrorsTotal,
+		StorageRetryTotal,
+		QueueOldestWaiterSeconds,
+		AutoPurgeTriggeredTotal,
+		RedisEstimatedMemoryMB,
+		RatingDistributionKLDivergence,
+		QueueFullRejectionsTotal,
+		DLQEnqueuedTotal,
+		QueueWorkerCount,
+	)
+}
+
+// Handler возвращает HTTP обработчик для эндпоинта /metrics
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
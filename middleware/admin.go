@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// roleKey ключ роли аутентифицированного пользователя в контексте запроса
+const roleKey contextKey = "role"
+
+const (
+	RoleAdmin      = "admin"       // Значение claim role у администраторов
+	RoleGameServer = "game_server" // Значение claim role у игровых серверов
+)
+
+// RequireAdmin пропускает только запросы с токеном, у которого claim role равен "admin".
+// Работает после JWTAuth: запрос без аутентифицированного пользователя (в том числе
+// когда JWTAuth не подключен) отклоняется с 401, с другой ролью — с 403.
+func RequireAdmin(next http.Handler) http.Handler {
+	return RequireRole(RoleAdmin)(next)
+}
+
+// RequireRole пропускает только запросы с токеном, у которого claim role равен role.
+// Без аутентифицированного пользователя возвращается 401, с другой ролью — 403.
+func RequireRole(role string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, authenticated := PlayerIDFromContext(r.Context()); !authenticated {
+				writeUnauthorized(w, "Authentication required")
+				return
+			}
+			if got, _ := RoleFromContext(r.Context()); got != role {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"error":"Role ` + role + ` required"}`))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RoleFromContext возвращает роль аутентифицированного пользователя из claim role
+func RoleFromContext(ctx context.Context) (string, bool) {
+	role, ok := ctx.Value(roleKey).(string)
+	return role, ok
+}
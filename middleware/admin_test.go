@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+)
+
+const testJWTSecret = "test-secret"
+
+// signedToken возвращает заголовок Authorization с токеном, подписанным testJWTSecret
+func signedToken(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testJWTSecret))
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+	return "Bearer " + token
+}
+
+// newAdminRouter возвращает маршрутизатор с одним административным маршрутом,
+// как в main.go; withAuth подключает JWTAuth
+func newAdminRouter(withAuth bool) *mux.Router {
+	router := mux.NewRouter()
+	api := router.PathPrefix("/api/v1").Subrouter()
+	if withAuth {
+		api.Use(JWTAuth(testJWTSecret))
+	}
+	admin := api.PathPrefix("/admin").Subrouter()
+	admin.Use(RequireAdmin)
+	admin.HandleFunc("/ban", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}).Methods("POST")
+	return router
+}
+
+func TestRequireAdmin(t *testing.T) {
+	tests := []struct {
+		name          string
+		withAuth      bool
+		authorization string
+		wantStatus    int
+	}{
+		{"admin", true, signedToken(t, jwt.MapClaims{"sub": "ops", "role": RoleAdmin}), http.StatusOK},
+		{"player", true, signedToken(t, jwt.MapClaims{"sub": "p1"}), http.StatusForbidden},
+		{"other role", true, signedToken(t, jwt.MapClaims{"sub": "gs", "role": "game_server"}), http.StatusForbidden},
+		{"no token", true, "", http.StatusUnauthorized},
+		{"auth disabled", false, "", http.StatusUnauthorized},
+		{"auth disabled with admin token", false, signedToken(t, jwt.MapClaims{"sub": "ops", "role": RoleAdmin}), http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/ban", nil)
+		if tt.authorization != "" {
+			req.Header.Set("Authorization", tt.authorization)
+		}
+		rec := httptest.NewRecorder()
+		newAdminRouter(tt.withAuth).ServeHTTP(rec, req)
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: POST /admin/ban = %d, want %d", tt.name, rec.Code, tt.wantStatus)
+		}
+	}
+}
+
+func TestRequireRoleGameServer(t *testing.T) {
+	router := mux.NewRouter()
+	api := router.PathPrefix("/api/v1").Subrouter()
+	api.Use(JWTAuth(testJWTSecret))
+	api.Handle("/match/{match_id}/result", RequireRole(RoleGameServer)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))).Methods("POST")
+
+	tests := []struct {
+		name       string
+		claims     jwt.MapClaims
+		wantStatus int
+	}{
+		{"game server", jwt.MapClaims{"sub": "gs-1", "role": RoleGameServer}, http.StatusOK},
+		{"player", jwt.MapClaims{"sub": "p1"}, http.StatusForbidden},
+		{"admin", jwt.MapClaims{"sub": "ops", "role": RoleAdmin}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/match/m1/result", nil)
+		req.Header.Set("Authorization", signedToken(t, tt.claims))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: POST /match/result = %d, want %d", tt.name, rec.Code, tt.wantStatus)
+		}
+	}
+}
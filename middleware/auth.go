@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+)
+
+// contextKey тип ключей значений, которые middleware кладет в контекст запроса
+type contextKey string
+
+// playerIDKey ключ ID аутентифицированного игрока в контексте запроса
+const playerIDKey contextKey = "player_id"
+
+// publicPaths пути API, доступные без токена вместе с вложенными путями: задержки
+// между регионами нужны клиенту до входа в аккаунт
+var publicPaths = []string{
+	"/api/v1/config/region-latency",
+}
+
+// isPublicPath проверяет, что путь совпадает с одним из publicPaths или вложен в него
+func isPublicPath(path string) bool {
+	for _, public := range publicPaths {
+		if path == public || strings.HasPrefix(path, public+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// JWTAuth проверяет Bearer-токен из заголовка Authorization (HS256, подпись secret)
+// и кладет claim sub в контекст запроса как ID аутентифицированного игрока,
+// а claim role — как его роль.
+// Запросы без действительного токена отклоняются с 401.
+func JWTAuth(secret string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isPublicPath(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			raw, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || raw == "" {
+				writeUnauthorized(w, "Missing bearer token")
+				return
+			}
+
+			token, err := jwt.Parse(raw, func(t *jwt.Token) (interface{}, error) {
+				return []byte(secret), nil
+			}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+			if err != nil || !token.Valid {
+				writeUnauthorized(w, "Invalid token")
+				return
+			}
+
+			playerID, err := token.Claims.GetSubject()
+			if err != nil || playerID == "" {
+				writeUnauthorized(w, "Token has no subject")
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), playerIDKey, playerID)
+			if claims, ok := token.Claims.(jwt.MapClaims); ok {
+				if role, ok := claims["role"].(string); ok {
+					ctx = context.WithValue(ctx, roleKey, role)
+				}
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// PlayerIDFromContext возвращает ID аутентифицированного игрока.
+// Второе значение равно false, если запрос прошел без JWTAuth.
+func PlayerIDFromContext(ctx context.Context) (string, bool) {
+	playerID, ok := ctx.Value(playerIDKey).(string)
+	return playerID, ok
+}
+
+// writeUnauthorized отправляет 401 в формате ошибок API
+func writeUnauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	w.WriteHeader(http.StatusUnauthorized)
+	w.Write([]byte(`{"error":"` + message + `"}`))
+}
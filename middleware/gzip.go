@@ -0,0 +1,204 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// gzipWriters переиспользуемые gzip.Writer: каждый держит буферы на сотни килобайт
+var gzipWriters = sync.Pool{
+	New: func() interface{} {
+		return gzip.NewWriter(nil)
+	},
+}
+
+// Gzip сжимает ответ, если клиент прислал Accept-Encoding: gzip, а тело больше
+// minSizeBytes. Пока тело не набрало minSizeBytes, оно копится в памяти, поэтому
+// маленькие ответы отдаются как есть. При сжатии выставляется Content-Encoding: gzip
+// и убирается Content-Length. Потоки SSE, WebSocket и ответы, которые обработчик
+// сжал сам, не трогаются.
+func Gzip(minSizeBytes int) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead || r.Header.Get("Upgrade") != "" || !acceptsGzip(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Accept-Encoding")
+			gw := &gzipResponseWriter{ResponseWriter: w, minSize: minSizeBytes}
+			defer gw.finish()
+			next.ServeHTTP(gw, r)
+		})
+	}
+}
+
+// acceptsGzip проверяет, что клиент принимает gzip (и не запретил его через q=0)
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		q, ok := strings.CutPrefix(strings.TrimSpace(params), "q=")
+		if !ok {
+			return true
+		}
+		weight, err := strconv.ParseFloat(q, 64)
+		return err == nil && weight > 0
+	}
+	return false
+}
+
+// gzipMode как отдается тело ответа
+type gzipMode int
+
+const (
+	gzipUndecided  gzipMode = iota // Тело копится в буфере
+	gzipPlain                      // Тело пишется без сжатия
+	gzipCompressed                 // Тело сжимается
+)
+
+// gzipResponseWriter копит начало тела и по его размеру выбирает, сжимать ли ответ
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	minSize int
+	status  int
+	buf     []byte
+	mode    gzipMode
+	gz      *gzip.Writer
+}
+
+// WriteHeader запоминает код ответа: заголовки уходят клиенту, когда выбран режим
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	if w.mode != gzipUndecided || w.status != 0 {
+		return
+	}
+	w.status = status
+
+	// Ответы без тела, потоки событий и уже сжатые ответы отдаются как есть
+	if !bodyAllowed(status) || w.Header().Get("Content-Encoding") != "" ||
+		strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream") {
+		w.startPlain()
+	}
+}
+
+// Write пишет тело ответа
+func (w *gzipResponseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	switch w.mode {
+	case gzipPlain:
+		return w.ResponseWriter.Write(p)
+	case gzipCompressed:
+		return w.gz.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) > w.minSize {
+		if err := w.startGzip(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush отправляет клиенту уже сжатые данные. Пока режим не выбран, тело остается в
+// буфере: иначе поток вроде снимка очередей, который сбрасывается после каждой записи,
+// никогда не набрал бы minSize.
+func (w *gzipResponseWriter) Flush() {
+	switch w.mode {
+	case gzipUndecided:
+		return
+	case gzipCompressed:
+		w.gz.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack передает соединение обработчику, если ответ еще не начат
+func (w *gzipResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	if w.mode != gzipUndecided || len(w.buf) > 0 {
+		return nil, nil, errors.New("response already started")
+	}
+	w.mode = gzipPlain
+	return hijacker.Hijack()
+}
+
+// Unwrap отдает исходный ResponseWriter для http.ResponseController
+func (w *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// startPlain отправляет заголовки и накопленное тело без сжатия
+func (w *gzipResponseWriter) startPlain() error {
+	w.mode = gzipPlain
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if len(w.buf) == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(w.buf)
+	w.buf = nil
+	return err
+}
+
+// startGzip отправляет заголовки сжатого ответа и сжимает накопленное тело
+func (w *gzipResponseWriter) startGzip() error {
+	w.mode = gzipCompressed
+	header := w.Header()
+	header.Set("Content-Encoding", "gzip")
+	header.Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.status)
+
+	w.gz = gzipWriters.Get().(*gzip.Writer)
+	w.gz.Reset(w.ResponseWriter)
+	_, err := w.gz.Write(w.buf)
+	w.buf = nil
+	return err
+}
+
+// finish дописывает ответ после обработчика: короткое тело уходит без сжатия,
+// сжатое — закрывается
+func (w *gzipResponseWriter) finish() {
+	switch w.mode {
+	case gzipUndecided:
+		if w.status == 0 && len(w.buf) == 0 {
+			// Обработчик ничего не записал — net/http сам ответит 200
+			return
+		}
+		w.startPlain()
+	case gzipCompressed:
+		w.gz.Close()
+		gzipWriters.Put(w.gz)
+		w.gz = nil
+	}
+}
+
+// bodyAllowed проверяет, что у ответа с этим кодом может быть тело
+func bodyAllowed(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent, status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+const testGzipMinSize = 1024
+
+// snapshotHandler отвечает JSON-снимком из n записей, выставляя Content-Length,
+// как обработчик, который знает размер тела заранее
+func snapshotHandler(n int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		players := make([]map[string]interface{}, n)
+		for i := range players {
+			players[i] = map[string]interface{}{"player_id": fmt.Sprintf("player-%d", i), "rating": 1000 + i, "region": "EU"}
+		}
+		body, _ := json.Marshal(map[string]interface{}{"players": players})
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.Write(body)
+	})
+}
+
+// serveGzip выполняет GET через Gzip с заданным Accept-Encoding
+func serveGzip(handler http.Handler, acceptEncoding string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/queue/snapshot", nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	rec := httptest.NewRecorder()
+	Gzip(testGzipMinSize)(handler).ServeHTTP(rec, req)
+	return rec
+}
+
+// gunzip распаковывает сжатое тело ответа
+func gunzip(t *testing.T, body []byte) []byte {
+	t.Helper()
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+	defer zr.Close()
+	plain, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("read gzip body: %v", err)
+	}
+	return plain
+}
+
+// TestGzipMatchesUncompressed проверяет, что сжатый ответ распаковывается в тот же
+// JSON, что отдается клиенту без Accept-Encoding: gzip
+func TestGzipMatchesUncompressed(t *testing.T) {
+	handler := snapshotHandler(500)
+
+	plain := serveGzip(handler, "")
+	if plain.Code != http.StatusOK {
+		t.Fatalf("plain status = %d, want 200", plain.Code)
+	}
+	if enc := plain.Header().Get("Content-Encoding"); enc != "" {
+		t.Fatalf("Content-Encoding without Accept-Encoding = %q, want none", enc)
+	}
+	if plain.Body.Len() <= testGzipMinSize {
+		t.Fatalf("test body is %d bytes, want more than %d", plain.Body.Len(), testGzipMinSize)
+	}
+
+	compressed := serveGzip(handler, "gzip, deflate")
+	if compressed.Code != http.StatusOK {
+		t.Fatalf("compressed status = %d, want 200", compressed.Code)
+	}
+	if enc := compressed.Header().Get("Content-Encoding"); enc != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", enc)
+	}
+	if cl := compressed.Header().Get("Content-Length"); cl != "" {
+		t.Errorf("Content-Length = %q on a compressed response, want none", cl)
+	}
+	if vary := compressed.Header().Get("Vary"); vary != "Accept-Encoding" {
+		t.Errorf("Vary = %q, want Accept-Encoding", vary)
+	}
+	if compressed.Body.Len() >= plain.Body.Len() {
+		t.Errorf("compressed body is %d bytes, plain is %d", compressed.Body.Len(), plain.Body.Len())
+	}
+
+	body := gunzip(t, compressed.Body.Bytes())
+	if !json.Valid(body) {
+		t.Fatal("decompressed body is not valid JSON")
+	}
+	if !bytes.Equal(body, plain.Body.Bytes()) {
+		t.Error("decompressed body differs from the uncompressed response")
+	}
+}
+
+func TestGzipPassThrough(t *testing.T) {
+	tests := []struct {
+		name           string
+		handler        http.Handler
+		acceptEncoding string
+	}{
+		{"body below min size", snapshotHandler(1), "gzip"},
+		{"gzip refused", snapshotHandler(500), "gzip;q=0"},
+		{"other encoding", snapshotHandler(500), "br"},
+		{"already encoded", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Encoding", "br")
+			w.Write(bytes.Repeat([]byte("x"), 2*testGzipMinSize))
+		}), "gzip"},
+		{"event stream", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Write(bytes.Repeat([]byte("data: x\n\n"), testGzipMinSize))
+		}), "gzip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := httptest.NewRecorder()
+			tt.handler.ServeHTTP(want, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			rec := serveGzip(tt.handler, tt.acceptEncoding)
+			if enc, wantEnc := rec.Header().Get("Content-Encoding"), want.Header().Get("Content-Encoding"); enc != wantEnc {
+				t.Errorf("Content-Encoding = %q, want %q", enc, wantEnc)
+			}
+			if !bytes.Equal(rec.Body.Bytes(), want.Body.Bytes()) {
+				t.Error("body changed by the middleware")
+			}
+		})
+	}
+}
+
+func TestGzipNoBody(t *testing.T) {
+	rec := serveGzip(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}), "gzip")
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("status = %d, want 204", rec.Code)
+	}
+	if enc := rec.Header().Get("Content-Encoding"); enc != "" {
+		t.Errorf("Content-Encoding = %q, want none", enc)
+	}
+}
+
+// TestGzipFlushedStream проверяет, что поток, сбрасываемый после каждой записи,
+// все равно сжимается целиком
+func TestGzipFlushedStream(t *testing.T) {
+	const line = `{"player_id":"player","rating":1500}` + "\n"
+	rec := serveGzip(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		for i := 0; i < 100; i++ {
+			w.Write([]byte(line))
+			w.(http.Flusher).Flush()
+		}
+	}), "gzip")
+
+	if enc := rec.Header().Get("Content-Encoding"); enc != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", enc)
+	}
+	if body := gunzip(t, rec.Body.Bytes()); !bytes.Equal(body, bytes.Repeat([]byte(line), 100)) {
+		t.Error("decompressed stream differs from the written lines")
+	}
+}
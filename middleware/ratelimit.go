@@ -0,0 +1,46 @@
+// Package middleware содержит HTTP middleware сервиса
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"chrono-matchmaking/storage"
+	"github.com/gorilla/mux"
+)
+
+// NewRateLimitMiddleware ограничивает число запросов с одного IP до maxPerSecond в секунду.
+// Счетчик хранится в Redis (INCR + EXPIRE на ключ IP и секунды), поэтому лимит общий для
+// всех реплик. При исчерпании лимита возвращается 429 с заголовком Retry-After.
+// Если Redis недоступен, запросы пропускаются: ограничение не должно блокировать очередь.
+func NewRateLimitMiddleware(storage storage.Storage, maxPerSecond int) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if maxPerSecond <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			count, err := storage.IncrementRateLimit(r.Context(), clientIP(r), time.Now())
+			if err == nil && count > int64(maxPerSecond) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				w.Write([]byte(`{"error":"Too many requests"}`))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientIP возвращает IP клиента из адреса соединения
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+
+	"chrono-matchmaking/logging"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// RequestIDHeader заголовок с ID запроса
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength наибольшая длина ID запроса от клиента; более длинный заменяется новым
+const maxRequestIDLength = 128
+
+// RequestID берет ID запроса из заголовка X-Request-ID (или создает UUID, если заголовка
+// нет), кладет его в контекст вместе с логгером запроса (см. logging.FromContext) и
+// возвращает в том же заголовке ответа
+func RequestID() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(RequestIDHeader)
+			if requestID == "" || len(requestID) > maxRequestIDLength {
+				requestID = uuid.New().String()
+			}
+
+			w.Header().Set(RequestIDHeader, requestID)
+			next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), requestID)))
+		})
+	}
+}
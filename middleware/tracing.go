@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// Tracing открывает серверный спан OpenTelemetry на каждый запрос. Спан называется
+// по шаблону маршрута mux ("GET /api/v1/players/{player_id}/profile"), чтобы запросы
+// к разным игрокам попадали в одну операцию. Контекст запроса с этим спаном
+// передается в сервис и хранилище, их спаны становятся дочерними.
+func Tracing(serviceName string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return otelhttp.NewHandler(next, serviceName,
+			otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+				if route := mux.CurrentRoute(r); route != nil {
+					if tmpl, err := route.GetPathTemplate(); err == nil {
+						return r.Method + " " + tmpl
+					}
+				}
+				return r.Method + " " + r.URL.Path
+			}),
+		)
+	}
+}
@@ -0,0 +1,30 @@
+package models
+
+// Значения ReadinessResponse.QueueProcessor
+const (
+	QueueProcessorRunning = "running"
+	QueueProcessorStopped = "stopped"
+)
+
+// ReadinessResponse ответ готовности сервиса, когда Redis доступен
+type ReadinessResponse struct {
+	Redis          string `json:"redis"`
+	QueueProcessor string `json:"queue_processor"`
+	UptimeSeconds  int64  `json:"uptime_seconds"`
+	OffPeak        bool   `json:"off_peak"` // Действуют непиковые часы с расширенными критериями подбора
+
+	RedisPool *RedisPoolStats `json:"redis_pool,omitempty"` // Нет у хранилища в памяти
+}
+
+// RedisPoolStats состояние пула соединений с Redis
+type RedisPoolStats struct {
+	TotalConns uint32 `json:"total_conns"` // Открытых соединений
+	IdleConns  uint32 `json:"idle_conns"`  // Из них простаивающих
+	StaleConns uint32 `json:"stale_conns"` // Закрыто устаревших соединений с запуска
+}
+
+// ReadinessErrorResponse ответ готовности сервиса, когда Redis недоступен
+type ReadinessErrorResponse struct {
+	Redis string `json:"redis"`
+	Error string `json:"error"`
+}
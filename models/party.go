@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Party группа игроков, вошедших в очередь вместе. Игроки группы всегда
+// попадают в один матч и в одну команду.
+type Party struct {
+	PartyID   string    `json:"party_id"`
+	LeaderID  string    `json:"leader_id"` // Первый игрок из запроса
+	MemberIDs []string  `json:"member_ids"`
+	Region    string    `json:"region"`
+	GameMode  string    `json:"game_mode"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PartyRequest представляет запрос на вход группы в очередь
+type PartyRequest struct {
+	PlayerIDs []string          `json:"player_ids"`
+	Region    string            `json:"region"`
+	GameMode  string            `json:"game_mode"`
+	Ratings   map[string]int    `json:"ratings"`         // Рейтинг каждого игрока группы
+	Roles     map[string]string `json:"roles,omitempty"` // Роль каждого игрока группы (необязательно)
+}
+
+// NewPartyFromRequest создает группу и ее игроков по запросу на вход в очередь
+func NewPartyFromRequest(req *PartyRequest) (*Party, []*Player) {
+	party := &Party{
+		PartyID:   uuid.New().String(),
+		LeaderID:  req.PlayerIDs[0],
+		MemberIDs: req.PlayerIDs,
+		Region:    req.Region,
+		GameMode:  req.GameMode,
+		CreatedAt: time.Now(),
+	}
+
+	players := make([]*Player, 0, len(req.PlayerIDs))
+	for _, playerID := range req.PlayerIDs {
+		player := NewPlayer(req.Ratings[playerID], req.Region, req.GameMode, 0)
+		player.ID = playerID
+		player.JoinedAt = party.CreatedAt
+		player.PartyID = party.PartyID
+		player.PartySize = len(req.PlayerIDs)
+		player.Role = req.Roles[playerID]
+		players = append(players, player)
+	}
+
+	return party, players
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
@@ -8,12 +9,87 @@ import (
 
 // Player представляет игрока в системе матчмейкинга
 type Player struct {
-	ID         string    `json:"id"`           // Уникальный идентификатор игрока
-	Rating     int       `json:"rating"`       // Рейтинг игрока (MMR)
-	Region     string    `json:"region"`       // Регион игрока (например, "EU", "US", "ASIA")
-	GameMode   string    `json:"game_mode"`    // Режим игры (например, "ranked", "casual")
-	JoinedAt   time.Time `json:"joined_at"`   // Время входа в очередь
-	PlayerLevel int      `json:"player_level"` // Уровень игрока
+	ID          string    `json:"id"`           // Уникальный идентификатор игрока
+	Rating      int       `json:"rating"`       // Рейтинг игрока (MMR)
+	Region      string    `json:"region"`       // Регион игрока (например, "EU", "US", "ASIA")
+	GameMode    string    `json:"game_mode"`    // Режим игры (например, "ranked", "casual")
+	JoinedAt    time.Time `json:"joined_at"`    // Время входа в очередь
+	PlayerLevel int       `json:"player_level"` // Уровень игрока
+
+	VoicePreference string `json:"voice_preference,omitempty"` // Требование к голосовому чату: "required", "preferred", "none"
+	VoiceLanguage   string `json:"voice_language,omitempty"`   // Язык голосового чата (например, "en", "ru")
+
+	AccountCreatedAt time.Time     `json:"account_created_at"` // Время создания аккаунта
+	AccountAge       time.Duration `json:"account_age"`        // Возраст аккаунта на момент входа в очередь
+
+	RatingDeviation int     `json:"rating_deviation,omitempty"` // Отклонение рейтинга Glicko-2: чем больше, тем менее надежен рейтинг
+	Volatility      float64 `json:"volatility,omitempty"`       // Волатильность рейтинга Glicko-2
+
+	WinRate float64 `json:"win_rate"` // Доля побед за последние 20 игр (0–1)
+
+	Role string `json:"role,omitempty"` // Роль в команде: "tank", "healer", "dps"; пустая — любая роль
+
+	IsPremium bool `json:"is_premium,omitempty"` // Подписчик: ждет в приоритетной очереди
+
+	IsSuspicious bool `json:"is_suspicious,omitempty"` // Подозревается в смурфинге: ждет в отдельной очереди и подбирается только к таким же игрокам
+
+	IsCalibrating      bool `json:"is_calibrating,omitempty"`      // Проходит калибровку: ждет в отдельной очереди с расширенным диапазоном рейтинга
+	CalibratingMatches int  `json:"calibrating_matches,omitempty"` // Сколько калибровочных матчей сыграно к моменту входа в очередь
+
+	PartyID   string `json:"party_id,omitempty"`   // Группа, с которой игрок вошел в очередь
+	PartySize int    `json:"party_size,omitempty"` // Количество игроков в группе
+
+	PreferredMaps []string `json:"preferred_maps,omitempty"` // Карты, на которых игрок хочет играть; пустой — любая
+
+	PreferredLanguages []string `json:"preferred_languages,omitempty"` // Языки общения игрока (например, ["en", "ru"]); пустой — любой
+
+	// GameModes все режимы, в которых игрок ищет матч одновременно; задается, только если
+	// режимов больше одного. Игрок стоит в очереди каждого режима, а GameMode — режим
+	// очереди, из которой прочитана запись (при входе — первый из GameModes).
+	GameModes []string `json:"game_modes,omitempty"`
+
+	IsBotPlayer bool `json:"is_bot_player,omitempty"` // Бот, добавленный в матч вместо недостающего игрока; его поведением управляет игровой сервер
+}
+
+// QueueGameModes возвращает режимы всех очередей, в которых стоит игрок
+func (p *Player) QueueGameModes() []string {
+	if len(p.GameModes) > 1 {
+		return p.GameModes
+	}
+	return []string{p.GameMode}
+}
+
+// IsMultiQueue проверяет, что игрок ищет матч сразу в нескольких режимах
+func (p *Player) IsMultiQueue() bool {
+	return len(p.GameModes) > 1
+}
+
+// Значения Player.VoicePreference
+const (
+	VoicePreferenceRequired  = "required"  // Играть только с говорящими на том же языке
+	VoicePreferencePreferred = "preferred" // Тот же язык желателен, но не обязателен
+	VoicePreferenceNone      = "none"      // Голосовой чат не важен
+)
+
+// Значения Player.Role
+const (
+	RoleTank   = "tank"
+	RoleHealer = "healer"
+	RoleDPS    = "dps"
+)
+
+// IsValidRole проверяет, что роль пустая или одна из известных
+func IsValidRole(role string) bool {
+	switch role {
+	case "", RoleTank, RoleHealer, RoleDPS:
+		return true
+	}
+	return false
+}
+
+// CompositionRule требования к составу одной команды: минимальное число игроков каждой роли
+type CompositionRule struct {
+	Required map[string]int `json:"required"` // Роль -> количество игроков в команде
 }
 
 // NewPlayer создает нового игрока
@@ -28,19 +104,255 @@ func NewPlayer(rating int, region, gameMode string, playerLevel int) *Player {
 	}
 }
 
+// NewPlayerFromRequest создает нового игрока по запросу на вход в очередь
+func NewPlayerFromRequest(req *MatchRequest) *Player {
+	player := NewPlayer(req.Rating, req.Region, req.GameMode, req.PlayerLevel)
+	if req.PlayerID != "" {
+		player.ID = req.PlayerID // Стабильный ID зарегистрированного игрока
+	}
+	player.VoicePreference = req.VoicePreference
+	player.VoiceLanguage = req.VoiceLanguage
+	player.RatingDeviation = req.RatingDeviation
+	player.Volatility = req.Volatility
+	player.WinRate = req.WinRate
+	player.Role = req.Role
+	player.IsPremium = req.IsPremium
+	player.PreferredMaps = req.PreferredMaps
+	player.PreferredLanguages = req.PreferredLanguages
+	player.setGameModes(req.GameModes)
+	if !req.AccountCreatedAt.IsZero() {
+		player.AccountCreatedAt = req.AccountCreatedAt
+		player.AccountAge = player.JoinedAt.Sub(req.AccountCreatedAt)
+	}
+	return player
+}
+
+// setGameModes задает режимы одновременного поиска: GameMode идет первым, повторы
+// отбрасываются. Для одного режима GameModes остается пустым.
+func (p *Player) setGameModes(modes []string) {
+	all := make([]string, 0, len(modes)+1)
+	seen := make(map[string]bool, len(modes)+1)
+	for _, mode := range append([]string{p.GameMode}, modes...) {
+		if mode == "" || seen[mode] {
+			continue
+		}
+		seen[mode] = true
+		all = append(all, mode)
+	}
+	if len(all) > 0 {
+		p.GameMode = all[0]
+	}
+	if len(all) > 1 {
+		p.GameModes = all
+	} else {
+		p.GameModes = nil
+	}
+}
+
 // MatchRequest представляет запрос на поиск матча
 type MatchRequest struct {
-	PlayerID   string `json:"player_id"`
-	Rating     int    `json:"rating"`
-	Region     string `json:"region"`
-	GameMode   string `json:"game_mode"`
-	PlayerLevel int   `json:"player_level"`
+	PlayerID    string `json:"player_id"`
+	Rating      int    `json:"rating"` // Начальный рейтинг; после первого матча сервис берет сохраненный
+	Region      string `json:"region"`
+	GameMode    string `json:"game_mode"`
+	PlayerLevel int    `json:"player_level"`
+
+	VoicePreference string `json:"voice_preference,omitempty"`
+	VoiceLanguage   string `json:"voice_language,omitempty"`
+
+	AccountCreatedAt time.Time `json:"account_created_at"`
+
+	RatingDeviation int     `json:"rating_deviation,omitempty"`
+	Volatility      float64 `json:"volatility,omitempty"`
+
+	WinRate float64 `json:"win_rate"`
+
+	Role string `json:"role,omitempty"`
+
+	IsPremium bool `json:"is_premium,omitempty"`
+
+	PreferredMaps []string `json:"preferred_maps,omitempty"`
+
+	PreferredLanguages []string `json:"preferred_languages,omitempty"`
+
+	GameModes []string `json:"game_modes,omitempty"` // Дополнительные режимы одновременного поиска
+}
+
+// UpdateQueuedPlayerRequest представляет частичное изменение игрока в очереди:
+// незаданные поля не меняются
+type UpdateQueuedPlayerRequest struct {
+	Region   *string `json:"region,omitempty"`
+	GameMode *string `json:"game_mode,omitempty"`
+	Rating   *int    `json:"rating,omitempty"`
+}
+
+// BatchJoinRequest представляет запрос на вход в очередь сразу нескольких игроков
+type BatchJoinRequest struct {
+	Players []MatchRequest `json:"players"`
+}
+
+// BatchJoinFailure ошибка входа в очередь одного игрока из пакетного запроса
+type BatchJoinFailure struct {
+	Index int    `json:"index"` // Позиция игрока в BatchJoinRequest.Players
+	Error string `json:"error"`
+}
+
+// BatchJoinResponse результат пакетного входа в очередь
+type BatchJoinResponse struct {
+	Succeeded []string           `json:"succeeded"` // ID игроков, вставших в очередь
+	Failed    []BatchJoinFailure `json:"failed"`
 }
 
 // Match представляет найденный матч
 type Match struct {
-	MatchID   string   `json:"match_id"`
-	Players   []Player `json:"players"`
+	MatchID   string    `json:"match_id"`
+	Players   []Player  `json:"players"`
 	CreatedAt time.Time `json:"created_at"`
+	GameMode  string    `json:"game_mode,omitempty"` // Режим, в котором сыгран матч; у игроков с несколькими режимами поиска — один из них
+
+	ExpiresAt      time.Time  `json:"expires_at"`                // До этого времени матч выдается игрокам (CreatedAt + MatcherConfig.MatchTTL)
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"` // Когда игровой сервер подтвердил, что принял матч
+
+	TeamAVoiceCompatible bool `json:"team_a_voice_compatible"` // Все игроки команды A говорят на одном языке
+	TeamBVoiceCompatible bool `json:"team_b_voice_compatible"` // Все игроки команды B говорят на одном языке
+
+	ScheduledStartTime *time.Time `json:"scheduled_start_time,omitempty"` // Время начала заранее запланированного матча
+
+	Teams TeamAssignment `json:"teams"` // Состав команд A и B
+
+	BackfillCount int `json:"backfill_count,omitempty"` // Сколько игроков добрано взамен отключившихся
+
+	IsCrossRegion bool   `json:"is_cross_region"`         // В матче игроки из разных регионов
+	ServerRegion  string `json:"server_region,omitempty"` // Регион игрового сервера с наименьшей задержкой для участников
+	ServerID      string `json:"server_id,omitempty"`     // Назначенный игровой сервер
+	ServerAddr    string `json:"server_addr,omitempty"`   // Адрес назначенного игрового сервера
+
+	QualityScore    float64 `json:"quality_score"`               // Баланс рейтинга команд при создании матча с надбавкой VoiceGroupBonus (0–1, см. service.ComputeMatchQuality)
+	VoiceGroupBonus float64 `json:"voice_group_bonus,omitempty"` // Надбавка к качеству за команды с общим языком голосового чата
+
+	MapName string `json:"map_name,omitempty"` // Карта, выбранная по предпочтениям игроков из MatcherConfig.MapPool
+
+	Metadata map[string]interface{} `json:"metadata,omitempty"` // Вычисляемые после матча данные (например, satisfaction_score)
+}
+
+// HumanPlayers возвращает игроков матча без ботов — тех, кто пришел из очереди
+func (m *Match) HumanPlayers() []Player {
+	humans := make([]Player, 0, len(m.Players))
+	for _, p := range m.Players {
+		if !p.IsBotPlayer {
+			humans = append(humans, p)
+		}
+	}
+	return humans
 }
 
+// TeamAssignment состав двух команд матча: [0] — команда A, [1] — команда B
+type TeamAssignment [2][]Player
+
+// NewBalancedTeamAssignment делит игроков на две равные команды «змейкой» по убыванию
+// рейтинга (A, B, B, A, A, B, ...), чтобы средний рейтинг команд был близким
+func NewBalancedTeamAssignment(players []Player) TeamAssignment {
+	sorted := make([]Player, len(players))
+	copy(sorted, players)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Rating > sorted[j].Rating
+	})
+
+	var teams TeamAssignment
+	for i, p := range sorted {
+		// Номер игрока внутри пары выбора: в четных парах первым выбирает A, в нечетных — B
+		team := i % 2
+		if (i/2)%2 == 1 {
+			team = 1 - team
+		}
+		teams[team] = append(teams[team], p)
+	}
+	return teams
+}
+
+// PendingMatch собранный матч, ожидающий подтверждения всех игроков.
+// Игроки в порядке команд: первая половина — команда A, вторая — команда B.
+type PendingMatch struct {
+	PendingID string    `json:"pending_id"`
+	PlayerIDs []string  `json:"player_ids"`
+	Players   []Player  `json:"players"`
+	Region    string    `json:"region"`
+	GameMode  string    `json:"game_mode"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"` // Время, до которого все игроки должны подтвердить матч
+}
+
+// ConfirmMatchRequest представляет ответ игрока на предложенный матч
+type ConfirmMatchRequest struct {
+	PlayerID string `json:"player_id"`
+}
+
+// SatisfactionScore оценка удовлетворенности игроков матчем по повторному входу в очередь
+type SatisfactionScore struct {
+	MatchID    string    `json:"match_id"`
+	Score      float64   `json:"score"`    // Доля игроков, вернувшихся в очередь (0–1)
+	Requeued   int       `json:"requeued"` // Сколько игроков вернулись в очередь
+	Total      int       `json:"total"`    // Всего игроков в матче
+	ComputedAt time.Time `json:"computed_at"`
+}
+
+// ScheduleMatchRequest представляет запрос на создание запланированного матча
+type ScheduleMatchRequest struct {
+	PlayerIDs []string  `json:"player_ids"`
+	Region    string    `json:"region"`
+	GameMode  string    `json:"game_mode"`
+	StartTime time.Time `json:"start_time"`
+}
+
+// MatchResultRequest представляет результат завершенного матча
+type MatchResultRequest struct {
+	WinnerPlayerIDs []string `json:"winner_player_ids"` // Игроки победившей команды
+}
+
+// MatchResultResponse игроки матча с рейтингами, пересчитанными по результату
+type MatchResultResponse struct {
+	MatchID string   `json:"match_id"`
+	Players []Player `json:"players"`
+}
+
+// Значения ReconnectResponse.Status
+const (
+	ReconnectStatusCompleted  = "completed"    // Матч завершился, возвращен последний матч из истории
+	ReconnectStatusNotInMatch = "not_in_match" // У игрока нет ни активного, ни завершенного матча
+)
+
+// ReconnectResponse ответ на переподключение, когда активного матча у игрока нет
+type ReconnectResponse struct {
+	Status string `json:"status"`
+	Match  *Match `json:"match,omitempty"`
+}
+
+// BackfillRequest представляет запрос game-server на замену отключившихся игроков
+type BackfillRequest struct {
+	SlotCount    int    `json:"slot_count"`
+	Region       string `json:"region"`
+	GameMode     string `json:"game_mode"`
+	RatingAnchor int    `json:"rating_anchor"` // Рейтинг, вокруг которого ищутся замены
+}
+
+// RegionsConfig список активных регионов (запрос и ответ /admin/regions)
+type RegionsConfig struct {
+	Regions []string `json:"regions"`
+}
+
+// BanRequest представляет запрос на бан игрока
+type BanRequest struct {
+	PlayerID        string `json:"player_id"`
+	DurationSeconds int64  `json:"duration_seconds"`
+}
+
+// RatingAdvice персональные рекомендации игроку по рейтингу и выбору очереди
+type RatingAdvice struct {
+	PlayerID               string  `json:"player_id"`
+	CurrentRating          int     `json:"current_rating"`
+	CurrentPercentile      float64 `json:"current_percentile"`        // Доля игроков очереди с меньшим рейтингом (0–100)
+	Underserved            bool    `json:"underserved"`               // В рейтинговой группе игрока меньше игроков, чем нужно на матч
+	TargetRating           int     `json:"target_rating"`             // Центр самой заполненной рейтинговой группы
+	EstimatedGamesToTarget int     `json:"estimated_games_to_target"` // Оценка по K-фактору при 50% вероятности победы
+	SuggestedQueue         string  `json:"suggested_queue,omitempty"` // Другой режим с большим числом подходящих соперников
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// InviteMatch приватный матч по коду приглашения. Игроки собираются вне общей очереди
+// и без подбора по рейтингу; матч создается, когда присоединится PlayersNeeded игроков.
+type InviteMatch struct {
+	InviteCode    string    `json:"invite_code"`
+	HostPlayerID  string    `json:"host_player_id"`
+	Region        string    `json:"region"`
+	GameMode      string    `json:"game_mode"`
+	PlayersNeeded int       `json:"players_needed"`    // Количество игроков в матче режима
+	Players       []Player  `json:"players,omitempty"` // Присоединившиеся игроки в порядке входа
+	CreatedAt     time.Time `json:"created_at"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+// CreatePrivateMatchRequest представляет запрос на создание приватного матча
+type CreatePrivateMatchRequest struct {
+	GameMode     string `json:"game_mode"`
+	Region       string `json:"region"`
+	HostPlayerID string `json:"host_player_id"`
+}
+
+// JoinPrivateMatchRequest представляет запрос на вход в приватный матч. Поля игрока те же,
+// что при входе в очередь; регион и режим берутся из приглашения.
+type JoinPrivateMatchRequest struct {
+	InviteCode string `json:"invite_code"`
+	MatchRequest
+}
+
+// JoinPrivateMatchResponse результат входа в приватный матч
+type JoinPrivateMatchResponse struct {
+	InviteCode    string `json:"invite_code"`
+	PlayersJoined int    `json:"players_joined"`
+	PlayersNeeded int    `json:"players_needed"`
+	Match         *Match `json:"match,omitempty"` // Созданный матч, если вошел последний игрок
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PlayerProfile хранит долгосрочные данные игрока, не связанные с конкретной сессией в очереди
+type PlayerProfile struct {
+	PlayerID     string    `json:"player_id"`               // Стабильный идентификатор игрока
+	DisplayName  string    `json:"display_name"`            // Отображаемое имя
+	Email        string    `json:"email"`                   // Email, из которого выводится PlayerID
+	Platform     string    `json:"platform"`                // Платформа (например, "pc", "mobile")
+	TotalMatches int       `json:"total_matches"`           // Количество сыгранных матчей
+	WinStreak    int       `json:"win_streak"`              // Побед подряд в последних матчах
+	LossStreak   int       `json:"loss_streak"`             // Поражений подряд в последних матчах
+	CreatedAt    time.Time `json:"created_at"`              // Время регистрации
+	DeviceTokens []string  `json:"device_tokens,omitempty"` // Токены устройств для push-уведомлений
+}
+
+// RegisterRequest представляет запрос на регистрацию игрока
+type RegisterRequest struct {
+	DisplayName string `json:"display_name"`
+	Email       string `json:"email"`
+	Platform    string `json:"platform"`
+}
+
+// PushTokenRequest представляет запрос на регистрацию токена устройства
+type PushTokenRequest struct {
+	Token string `json:"token"`
+}
+
+// NormalizeEmail приводит email к каноническому виду
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// PlayerIDFromEmail возвращает стабильный идентификатор игрока (UUID v5) для email
+func PlayerIDFromEmail(email string) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte("mailto:"+NormalizeEmail(email))).String()
+}
+
+// NewPlayerProfile создает профиль игрока со стабильным идентификатором
+func NewPlayerProfile(displayName, email, platform string) *PlayerProfile {
+	return &PlayerProfile{
+		PlayerID:    PlayerIDFromEmail(email),
+		DisplayName: displayName,
+		Email:       NormalizeEmail(email),
+		Platform:    platform,
+		CreatedAt:   time.Now(),
+	}
+}
+
+// RatingPoint точка истории рейтинга игрока
+type RatingPoint struct {
+	Timestamp     time.Time `json:"timestamp"`
+	Rating        int       `json:"rating"`
+	MatchesPlayed int       `json:"matches_played"`
+	DeltaFromPrev int       `json:"delta_from_prev"` // Изменение относительно предыдущей точки (0 для первой)
+}
+
+// PlayerStats сводная статистика игрока
+type PlayerStats struct {
+	PlayerID       string  `json:"player_id"`
+	MatchCount     int     `json:"match_count"`
+	Wins           int     `json:"wins"`
+	Losses         int     `json:"losses"`
+	WinRate        float64 `json:"win_rate"`         // wins / (wins + losses); 0, если результатов еще нет
+	AvgWaitSeconds float64 `json:"avg_wait_seconds"` // Среднее ожидание по последним 100 матчам
+}
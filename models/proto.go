@@ -0,0 +1,99 @@
+package models
+
+import (
+	"time"
+
+	"chrono-matchmaking/proto/matchmakingpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// PlayerFromProto преобразует игрока gRPC API в модель
+func PlayerFromProto(p *matchmakingpb.Player) *Player {
+	if p == nil {
+		return nil
+	}
+	return &Player{
+		ID:               p.GetId(),
+		Rating:           int(p.GetRating()),
+		Region:           p.GetRegion(),
+		GameMode:         p.GetGameMode(),
+		JoinedAt:         timeFromProto(p.GetJoinedAt()),
+		PlayerLevel:      int(p.GetPlayerLevel()),
+		VoicePreference:  p.GetVoicePreference(),
+		VoiceLanguage:    p.GetVoiceLanguage(),
+		AccountCreatedAt: timeFromProto(p.GetAccountCreatedAt()),
+		RatingDeviation:  int(p.GetRatingDeviation()),
+		Volatility:       p.GetVolatility(),
+		WinRate:          p.GetWinRate(),
+		Role:             p.GetRole(),
+		IsPremium:        p.GetIsPremium(),
+		PartyID:          p.GetPartyId(),
+		PartySize:        int(p.GetPartySize()),
+	}
+}
+
+// PlayerToProto преобразует игрока в сообщение gRPC API
+func PlayerToProto(p *Player) *matchmakingpb.Player {
+	if p == nil {
+		return nil
+	}
+	return &matchmakingpb.Player{
+		Id:               p.ID,
+		Rating:           int32(p.Rating),
+		Region:           p.Region,
+		GameMode:         p.GameMode,
+		JoinedAt:         timeToProto(p.JoinedAt),
+		PlayerLevel:      int32(p.PlayerLevel),
+		VoicePreference:  p.VoicePreference,
+		VoiceLanguage:    p.VoiceLanguage,
+		RatingDeviation:  int32(p.RatingDeviation),
+		Volatility:       p.Volatility,
+		WinRate:          p.WinRate,
+		Role:             p.Role,
+		IsPremium:        p.IsPremium,
+		PartyId:          p.PartyID,
+		PartySize:        int32(p.PartySize),
+		AccountCreatedAt: timeToProto(p.AccountCreatedAt),
+	}
+}
+
+// MatchToProto преобразует матч в сообщение gRPC API
+func MatchToProto(m *Match) *matchmakingpb.Match {
+	if m == nil {
+		return nil
+	}
+	return &matchmakingpb.Match{
+		MatchId:       m.MatchID,
+		Players:       playersToProto(m.Players),
+		CreatedAt:     timeToProto(m.CreatedAt),
+		TeamA:         playersToProto(m.Teams[0]),
+		TeamB:         playersToProto(m.Teams[1]),
+		ServerRegion:  m.ServerRegion,
+		ServerId:      m.ServerID,
+		ServerAddr:    m.ServerAddr,
+		IsCrossRegion: m.IsCrossRegion,
+	}
+}
+
+func playersToProto(players []Player) []*matchmakingpb.Player {
+	result := make([]*matchmakingpb.Player, len(players))
+	for i := range players {
+		result[i] = PlayerToProto(&players[i])
+	}
+	return result
+}
+
+// timeToProto оставляет нулевое время пустым полем, а не 0001-01-01
+func timeToProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func timeFromProto(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
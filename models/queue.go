@@ -0,0 +1,176 @@
+package models
+
+import "time"
+
+// WaitingPlayerInfo описывает игрока в очереди для дашбордов состояния очереди
+type WaitingPlayerInfo struct {
+	PlayerID                      string  `json:"player_id"`
+	WaitSeconds                   float64 `json:"wait_seconds"`                     // Сколько игрок уже ждет
+	Rating                        int     `json:"rating"`                           // Рейтинг игрока
+	Position                      int64   `json:"position"`                         // Позиция по времени ожидания (1 — дольше всех)
+	EstimatedWaitSecondsRemaining float64 `json:"estimated_wait_seconds_remaining"` // Оценка оставшегося ожидания
+}
+
+// HourStats статистика очереди за один час суток (UTC)
+type HourStats struct {
+	Hour           int     `json:"hour"`
+	AvgWaitSeconds float64 `json:"avg_wait_seconds"` // Среднее время ожидания игроков, попавших в матч
+	MatchesFormed  int64   `json:"matches_formed"`   // Количество созданных матчей
+	QueueHealth    float64 `json:"queue_health"`     // MatchesFormed / AvgWaitSeconds
+}
+
+// PeakHoursReport отчет о загруженности очереди по часам суток
+type PeakHoursReport struct {
+	Region      string        `json:"region"`
+	GameMode    string        `json:"game_mode"`
+	HourlyStats [24]HourStats `json:"hourly_stats"`
+	BestHour    int           `json:"best_hour"`  // Час с наибольшим QueueHealth
+	WorstHour   int           `json:"worst_hour"` // Час с наименьшим QueueHealth
+}
+
+// SegmentStats статистика очереди в одном рейтинговом сегменте
+type SegmentStats struct {
+	Bracket         string  `json:"bracket"`           // Диапазон рейтинга, например "1000-1500"
+	PlayerCount     int64   `json:"player_count"`      // Игроков в очереди
+	AvgWaitSeconds  float64 `json:"avg_wait_seconds"`  // Среднее текущее ожидание
+	MatchesLastHour int64   `json:"matches_last_hour"` // Матчей сегмента за последний час
+	BottleneckScore float64 `json:"bottleneck_score"`  // PlayerCount / max(MatchesLastHour, 1), чем выше, тем дольше ожидание
+}
+
+// QueuePosition положение игрока в очереди и текущий диапазон поиска соперников
+type QueuePosition struct {
+	PlayerID     string  `json:"player_id"`
+	Position     int64   `json:"position"`       // Место по рейтингу среди ожидающих (1 — самый низкий рейтинг)
+	TotalInQueue int64   `json:"total_in_queue"` // Всего игроков в очереди региона и режима
+	Rating       int     `json:"rating"`
+	Region       string  `json:"region"`
+	GameMode     string  `json:"game_mode"`
+	RatingRange  int     `json:"rating_range"` // Допустимая разница рейтинга с учетом времени ожидания
+	MinRating    int     `json:"min_rating"`
+	MaxRating    int     `json:"max_rating"`
+	WaitSeconds  float64 `json:"wait_seconds"`
+}
+
+// RatingBracket диапазон рейтинга [Min, Max] включительно; Max 0 — без верхней границы
+type RatingBracket struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+// BracketDepth количество игроков очереди в одном диапазоне рейтинга
+type BracketDepth struct {
+	Min   int   `json:"min"`
+	Max   int   `json:"max"`
+	Count int64 `json:"count"`
+}
+
+// WaitEstimate оценка времени ожидания матча в очереди
+type WaitEstimate struct {
+	Region           string  `json:"region"`
+	GameMode         string  `json:"game_mode"`
+	EstimatedSeconds float64 `json:"estimated_seconds"`
+	SampleSize       int     `json:"sample_size"` // Матчей за последний час, по которым посчитана оценка
+	QueueDepth       int64   `json:"queue_depth"` // Игроков в очереди сейчас
+}
+
+// KeyPatternUsage оценка памяти Redis, занимаемой ключами одного шаблона
+type KeyPatternUsage struct {
+	Pattern          string  `json:"pattern"`
+	TotalKeys        int64   `json:"total_keys"`
+	SampledKeys      int     `json:"sampled_keys"`      // По скольким ключам посчитано среднее
+	AvgBytesPerKey   float64 `json:"avg_bytes_per_key"` // По данным MEMORY USAGE
+	EstimatedTotalMB float64 `json:"estimated_total_mb"`
+}
+
+// MemoryUsageReport оценка памяти Redis по шаблонам ключей сервиса
+type MemoryUsageReport struct {
+	Patterns         []KeyPatternUsage `json:"patterns"`
+	EstimatedTotalMB float64           `json:"estimated_total_mb"`
+	GeneratedAt      time.Time         `json:"generated_at"`
+}
+
+// DistributionComparison сравнение распределений рейтинга игроков в очереди
+// и игроков, попавших в матч за последние сутки
+type DistributionComparison struct {
+	Region           string    `json:"region"`
+	GameMode         string    `json:"game_mode"`
+	Buckets          []string  `json:"buckets"`           // Диапазоны рейтинга, например "1000-1500" и "3000+"
+	QueueHistogram   []float64 `json:"queue_histogram"`   // Доля игроков очереди в каждом диапазоне
+	MatchedHistogram []float64 `json:"matched_histogram"` // Доля сматченных игроков в каждом диапазоне
+	QueuePlayers     int       `json:"queue_players"`
+	MatchedPlayers   int       `json:"matched_players"`
+	KLDivergence     float64   `json:"kl_divergence"` // KL(matched || queue); больше 0.1 — признак перекоса алгоритма
+}
+
+// Типы событий очереди в потоке /events/queue
+const (
+	QueueEventJoin         = "queue_join"
+	QueueEventLeave        = "queue_leave"
+	QueueEventMatchCreated = "match_created"
+)
+
+// QueueEvent данные события очереди. Регион и режим есть в каждом событии,
+// чтобы клиенты могли фильтровать поток на своей стороне.
+type QueueEvent struct {
+	Region   string `json:"region"`
+	GameMode string `json:"game_mode"`
+	PlayerID string `json:"player_id,omitempty"` // queue_join, queue_leave
+	Rating   int    `json:"rating,omitempty"`    // queue_join
+	Match    *Match `json:"match,omitempty"`     // match_created
+}
+
+// QueueSnapshotEntry одна очередь в снимке /admin/queue/snapshot (строка NDJSON)
+type QueueSnapshotEntry struct {
+	Queue   string    `json:"queue"`   // Ключ очереди в Redis, например queue:premium:EU:5v5
+	Players []*Player `json:"players"` // Игроки по возрастанию рейтинга
+}
+
+// QueueRestoreResult итог восстановления очередей из снимка
+type QueueRestoreResult struct {
+	Queues        int `json:"queues"`         // Прочитано очередей снимка
+	Restored      int `json:"restored"`       // Игроков возвращено в очередь
+	AlreadyQueued int `json:"already_queued"` // Игроков, уже стоявших в очереди
+	Failed        int `json:"failed"`         // Игроков, которых не удалось добавить
+}
+
+// DLQEntry группа игроков, матч которой не удалось сохранить после нескольких попыток
+type DLQEntry struct {
+	ID       string    `json:"id"`
+	Region   string    `json:"region"`
+	GameMode string    `json:"game_mode"`
+	Players  []*Player `json:"players"` // Игроки в порядке команд: первая половина — команда A
+	Reason   string    `json:"reason"`  // Ошибка последней попытки
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// SimulateRequest необязательное тело /admin/simulate
+type SimulateRequest struct {
+	InjectPlayers []*Player `json:"inject_players,omitempty"` // Гипотетические игроки, добавляемые к очереди только для симуляции
+}
+
+// SimulatedMatch матч, который был бы создан при обработке очереди
+type SimulatedMatch struct {
+	Players      []Player `json:"players"`       // Игроки в порядке команд: первая половина — команда A
+	QualityScore float64  `json:"quality_score"` // См. Match.QualityScore
+
+	TeamAVoiceCompatible bool    `json:"team_a_voice_compatible"`     // См. Match.TeamAVoiceCompatible
+	TeamBVoiceCompatible bool    `json:"team_b_voice_compatible"`     // См. Match.TeamBVoiceCompatible
+	VoiceGroupBonus      float64 `json:"voice_group_bonus,omitempty"` // См. Match.VoiceGroupBonus
+	RatingSpread         int     `json:"rating_spread"`               // Разница между наибольшим и наименьшим рейтингом игроков
+}
+
+// LiveQueueMetrics показатели одной очереди в потоке /admin/ws/metrics
+type LiveQueueMetrics struct {
+	Region           string  `json:"region"`
+	GameMode         string  `json:"game_mode"`
+	QueueSize        int64   `json:"queue_size"`
+	MatchesPerMinute int     `json:"matches_per_minute"` // Матчей за последние 60 секунд
+	AvgQualityScore  float64 `json:"avg_quality_score"`  // Средний QualityScore этих матчей
+	AvgWaitSeconds   float64 `json:"avg_wait_seconds"`   // Среднее ожидание игроков этих матчей
+}
+
+// LiveMetricsSnapshot снимок показателей всех активных очередей
+type LiveMetricsSnapshot struct {
+	Timestamp time.Time          `json:"timestamp"`
+	Queues    []LiveQueueMetrics `json:"queues"`
+}
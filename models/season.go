@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Season граница рейтингового сезона
+type Season struct {
+	SeasonID          string    `json:"season_id"`
+	StartedAt         time.Time `json:"started_at"`         // Время мягкого сброса рейтингов
+	CompressionFactor float64   `json:"compression_factor"` // Доля отклонения от базового рейтинга, сохраненная при сбросе
+	PlayersAffected   int64     `json:"players_affected"`   // Сколько игроков получили новый рейтинг
+}
+
+// SeasonResetRequest представляет запрос на начало нового сезона
+type SeasonResetRequest struct {
+	SeasonID          string  `json:"season_id"`
+	CompressionFactor float64 `json:"compression_factor"`
+}
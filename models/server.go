@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Server игровой сервер, на котором проводятся матчи
+type Server struct {
+	ServerID     string    `json:"server_id"`
+	Addr         string    `json:"addr"`   // Адрес для подключения игроков (host:port)
+	Region       string    `json:"region"` // Регион датацентра
+	Capacity     int       `json:"capacity"`
+	Load         int       `json:"load"` // Количество идущих на сервере матчей
+	RegisteredAt time.Time `json:"registered_at"`
+}
+
+// RegisterServerRequest представляет запрос на регистрацию игрового сервера
+type RegisterServerRequest struct {
+	ServerID string `json:"server_id"`
+	Addr     string `json:"addr"`
+	Region   string `json:"region"`
+	Capacity int    `json:"capacity"`
+}
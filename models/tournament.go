@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Значения Tournament.Status
+const (
+	TournamentStatusInProgress = "in_progress" // Идут раунды
+	TournamentStatusCompleted  = "completed"   // Финал сыгран, победитель определен
+)
+
+// Tournament турнир на выбывание. Участник — одиночный игрок или команда из
+// половины игроков матча режима; в каждом матче сетки первая половина игроков —
+// первый участник, вторая — второй.
+type Tournament struct {
+	TournamentID string       `json:"tournament_id"`
+	Region       string       `json:"region"`
+	GameMode     string       `json:"game_mode"`
+	MaxPlayers   int          `json:"max_players"`
+	Status       string       `json:"status"`
+	Rounds       [][][]Player `json:"rounds"`            // Раунды → матчи → игроки
+	Winners      []Player     `json:"winners,omitempty"` // Игроки победившего участника после финала
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+// CreateTournamentRequest представляет запрос на создание турнира
+type CreateTournamentRequest struct {
+	Region     string `json:"region"`
+	GameMode   string `json:"game_mode"`
+	MaxPlayers int    `json:"max_players"`
+}
+
+// AdvanceTournamentRequest результаты текущего раунда турнира
+type AdvanceTournamentRequest struct {
+	WinnerPlayerIDs []string `json:"winner_player_ids"` // Игроки победивших участников всех матчей раунда
+}
@@ -0,0 +1,247 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	fcmScope           = "https://www.googleapis.com/auth/firebase.messaging"
+	fcmSendURL         = "https://fcm.googleapis.com/v1/projects/%s/messages:send"
+	fcmDefaultTokenURI = "https://oauth2.googleapis.com/token"
+	fcmTokenLifetime   = time.Hour
+	fcmTokenLeeway     = time.Minute // Обновляем токен доступа заранее
+)
+
+// fcmServiceAccount поля файла ключа сервисного аккаунта Google, нужные для FCM
+type fcmServiceAccount struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// FCMPushProvider отправляет уведомления через Firebase Cloud Messaging (HTTP v1 API).
+// Авторизация — OAuth2 токен, полученный по JWT сервисного аккаунта.
+type FCMPushProvider struct {
+	account    fcmServiceAccount
+	privateKey *rsa.PrivateKey
+	tokens     DeviceTokenStore
+	client     *http.Client
+	logger     *zap.Logger
+
+	mu          sync.Mutex // Защищает accessToken и tokenExpiry
+	accessToken string
+	tokenExpiry time.Time
+}
+
+// NewFCMPushProvider создает провайдер FCM по файлу ключа сервисного аккаунта
+func NewFCMPushProvider(credentialsFile string, tokens DeviceTokenStore, logger *zap.Logger) (*FCMPushProvider, error) {
+	raw, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read FCM credentials: %w", err)
+	}
+
+	var account fcmServiceAccount
+	if err := json.Unmarshal(raw, &account); err != nil {
+		return nil, fmt.Errorf("failed to parse FCM credentials: %w", err)
+	}
+	if account.ProjectID == "" || account.ClientEmail == "" {
+		return nil, fmt.Errorf("FCM credentials must contain project_id and client_email")
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = fcmDefaultTokenURI
+	}
+
+	privateKey, err := parseRSAPrivateKey(account.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FCMPushProvider{
+		account:    account,
+		privateKey: privateKey,
+		tokens:     tokens,
+		client:     &http.Client{Timeout: 5 * time.Second},
+		logger:     logger,
+	}, nil
+}
+
+// Send отправляет уведомление на все зарегистрированные устройства игрока.
+// Игроки без устройств пропускаются без ошибки.
+func (p *FCMPushProvider) Send(ctx context.Context, playerID, title, body string) error {
+	deviceTokens, err := p.tokens.GetDeviceTokens(ctx, playerID)
+	if err != nil {
+		return fmt.Errorf("failed to get device tokens: %w", err)
+	}
+	if len(deviceTokens) == 0 {
+		return nil
+	}
+
+	accessToken, err := p.getAccessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, deviceToken := range deviceTokens {
+		if err := p.sendToDevice(ctx, accessToken, deviceToken, title, body); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	p.logger.Info("Push notification sent",
+		zap.String("player_id", playerID),
+		zap.Int("devices", len(deviceTokens)),
+	)
+
+	return nil
+}
+
+// sendToDevice отправляет одно сообщение FCM
+func (p *FCMPushProvider) sendToDevice(ctx context.Context, accessToken, deviceToken, title, body string) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"message": map[string]interface{}{
+			"token": deviceToken,
+			"notification": map[string]string{
+				"title": title,
+				"body":  body,
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal FCM message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf(fcmSendURL, p.account.ProjectID), bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create FCM request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send FCM request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("FCM returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// getAccessToken возвращает закэшированный OAuth2 токен или получает новый
+func (p *FCMPushProvider) getAccessToken(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.accessToken != "" && time.Now().Before(p.tokenExpiry.Add(-fcmTokenLeeway)) {
+		return p.accessToken, nil
+	}
+
+	assertion, err := p.signJWT(time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to request access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned status %d", resp.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode access token: %w", err)
+	}
+
+	p.accessToken = token.AccessToken
+	p.tokenExpiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+
+	return p.accessToken, nil
+}
+
+// signJWT формирует подписанный RS256 JWT для обмена на токен доступа
+func (p *FCMPushProvider) signJWT(now time.Time) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   p.account.ClientEmail,
+		"scope": fcmScope,
+		"aud":   p.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(fcmTokenLifetime).Unix(),
+	})
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, p.privateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign JWT: %w", err)
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// parseRSAPrivateKey разбирает PEM-ключ сервисного аккаунта (PKCS#8 или PKCS#1)
+func parseRSAPrivateKey(pemKey string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, fmt.Errorf("FCM credentials contain no PEM private key")
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("FCM private key is not an RSA key")
+		}
+		return rsaKey, nil
+	}
+
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse FCM private key: %w", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,13 @@
+package notification
+
+import "context"
+
+// PushNotificationProvider отправляет push-уведомления на устройства игрока
+type PushNotificationProvider interface {
+	Send(ctx context.Context, playerID, title, body string) error
+}
+
+// DeviceTokenStore возвращает зарегистрированные токены устройств игрока
+type DeviceTokenStore interface {
+	GetDeviceTokens(ctx context.Context, playerID string) ([]string, error)
+}
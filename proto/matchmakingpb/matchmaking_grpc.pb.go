@@ -0,0 +1,302 @@
+// gRPC API матчмейкинга: те же операции, что и HTTP API /api/v1/queue.
+// Go-код генерируется в proto/matchmakingpb:
+//
+//   protoc --go_out=. --go_opt=module=chrono-matchmaking \
+//     --go-grpc_out=. --go-grpc_opt=module=chrono-matchmaking \
+//     proto/matchmaking.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: proto/matchmaking.proto
+
+package matchmakingpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	MatchmakingService_JoinQueue_FullMethodName                = "/chrono.matchmaking.v1.MatchmakingService/JoinQueue"
+	MatchmakingService_LeaveQueue_FullMethodName               = "/chrono.matchmaking.v1.MatchmakingService/LeaveQueue"
+	MatchmakingService_FindMatch_FullMethodName                = "/chrono.matchmaking.v1.MatchmakingService/FindMatch"
+	MatchmakingService_GetQueueStatus_FullMethodName           = "/chrono.matchmaking.v1.MatchmakingService/GetQueueStatus"
+	MatchmakingService_StreamMatchNotifications_FullMethodName = "/chrono.matchmaking.v1.MatchmakingService/StreamMatchNotifications"
+)
+
+// MatchmakingServiceClient is the client API for MatchmakingService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MatchmakingServiceClient interface {
+	// JoinQueue ставит зарегистрированного игрока в очередь
+	JoinQueue(ctx context.Context, in *JoinQueueRequest, opts ...grpc.CallOption) (*JoinQueueResponse, error)
+	// LeaveQueue убирает игрока (и его группу) из очереди
+	LeaveQueue(ctx context.Context, in *LeaveQueueRequest, opts ...grpc.CallOption) (*LeaveQueueResponse, error)
+	// FindMatch возвращает матч игрока или NOT_FOUND, если матч еще не собран
+	FindMatch(ctx context.Context, in *FindMatchRequest, opts ...grpc.CallOption) (*Match, error)
+	// GetQueueStatus возвращает размеры очереди региона и режима
+	GetQueueStatus(ctx context.Context, in *GetQueueStatusRequest, opts ...grpc.CallOption) (*QueueStatus, error)
+	// StreamMatchNotifications отправляет матч игрока, как только он будет создан, и завершает поток
+	StreamMatchNotifications(ctx context.Context, in *StreamMatchNotificationsRequest, opts ...grpc.CallOption) (MatchmakingService_StreamMatchNotificationsClient, error)
+}
+
+type matchmakingServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMatchmakingServiceClient(cc grpc.ClientConnInterface) MatchmakingServiceClient {
+	return &matchmakingServiceClient{cc}
+}
+
+func (c *matchmakingServiceClient) JoinQueue(ctx context.Context, in *JoinQueueRequest, opts ...grpc.CallOption) (*JoinQueueResponse, error) {
+	out := new(JoinQueueResponse)
+	err := c.cc.Invoke(ctx, MatchmakingService_JoinQueue_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *matchmakingServiceClient) LeaveQueue(ctx context.Context, in *LeaveQueueRequest, opts ...grpc.CallOption) (*LeaveQueueResponse, error) {
+	out := new(LeaveQueueResponse)
+	err := c.cc.Invoke(ctx, MatchmakingService_LeaveQueue_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *matchmakingServiceClient) FindMatch(ctx context.Context, in *FindMatchRequest, opts ...grpc.CallOption) (*Match, error) {
+	out := new(Match)
+	err := c.cc.Invoke(ctx, MatchmakingService_FindMatch_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *matchmakingServiceClient) GetQueueStatus(ctx context.Context, in *GetQueueStatusRequest, opts ...grpc.CallOption) (*QueueStatus, error) {
+	out := new(QueueStatus)
+	err := c.cc.Invoke(ctx, MatchmakingService_GetQueueStatus_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *matchmakingServiceClient) StreamMatchNotifications(ctx context.Context, in *StreamMatchNotificationsRequest, opts ...grpc.CallOption) (MatchmakingService_StreamMatchNotificationsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &MatchmakingService_ServiceDesc.Streams[0], MatchmakingService_StreamMatchNotifications_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &matchmakingServiceStreamMatchNotificationsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type MatchmakingService_StreamMatchNotificationsClient interface {
+	Recv() (*Match, error)
+	grpc.ClientStream
+}
+
+type matchmakingServiceStreamMatchNotificationsClient struct {
+	grpc.ClientStream
+}
+
+func (x *matchmakingServiceStreamMatchNotificationsClient) Recv() (*Match, error) {
+	m := new(Match)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// MatchmakingServiceServer is the server API for MatchmakingService service.
+// All implementations must embed UnimplementedMatchmakingServiceServer
+// for forward compatibility
+type MatchmakingServiceServer interface {
+	// JoinQueue ставит зарегистрированного игрока в очередь
+	JoinQueue(context.Context, *JoinQueueRequest) (*JoinQueueResponse, error)
+	// LeaveQueue убирает игрока (и его группу) из очереди
+	LeaveQueue(context.Context, *LeaveQueueRequest) (*LeaveQueueResponse, error)
+	// FindMatch возвращает матч игрока или NOT_FOUND, если матч еще не собран
+	FindMatch(context.Context, *FindMatchRequest) (*Match, error)
+	// GetQueueStatus возвращает размеры очереди региона и режима
+	GetQueueStatus(context.Context, *GetQueueStatusRequest) (*QueueStatus, error)
+	// StreamMatchNotifications отправляет матч игрока, как только он будет создан, и завершает поток
+	StreamMatchNotifications(*StreamMatchNotificationsRequest, MatchmakingService_StreamMatchNotificationsServer) error
+	mustEmbedUnimplementedMatchmakingServiceServer()
+}
+
+// UnimplementedMatchmakingServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedMatchmakingServiceServer struct {
+}
+
+func (UnimplementedMatchmakingServiceServer) JoinQueue(context.Context, *JoinQueueRequest) (*JoinQueueResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method JoinQueue not implemented")
+}
+func (UnimplementedMatchmakingServiceServer) LeaveQueue(context.Context, *LeaveQueueRequest) (*LeaveQueueResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LeaveQueue not implemented")
+}
+func (UnimplementedMatchmakingServiceServer) FindMatch(context.Context, *FindMatchRequest) (*Match, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindMatch not implemented")
+}
+func (UnimplementedMatchmakingServiceServer) GetQueueStatus(context.Context, *GetQueueStatusRequest) (*QueueStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetQueueStatus not implemented")
+}
+func (UnimplementedMatchmakingServiceServer) StreamMatchNotifications(*StreamMatchNotificationsRequest, MatchmakingService_StreamMatchNotificationsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamMatchNotifications not implemented")
+}
+func (UnimplementedMatchmakingServiceServer) mustEmbedUnimplementedMatchmakingServiceServer() {}
+
+// UnsafeMatchmakingServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MatchmakingServiceServer will
+// result in compilation errors.
+type UnsafeMatchmakingServiceServer interface {
+	mustEmbedUnimplementedMatchmakingServiceServer()
+}
+
+func RegisterMatchmakingServiceServer(s grpc.ServiceRegistrar, srv MatchmakingServiceServer) {
+	s.RegisterService(&MatchmakingService_ServiceDesc, srv)
+}
+
+func _MatchmakingService_JoinQueue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JoinQueueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MatchmakingServiceServer).JoinQueue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MatchmakingService_JoinQueue_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MatchmakingServiceServer).JoinQueue(ctx, req.(*JoinQueueRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MatchmakingService_LeaveQueue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LeaveQueueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MatchmakingServiceServer).LeaveQueue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MatchmakingService_LeaveQueue_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MatchmakingServiceServer).LeaveQueue(ctx, req.(*LeaveQueueRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MatchmakingService_FindMatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindMatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MatchmakingServiceServer).FindMatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MatchmakingService_FindMatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MatchmakingServiceServer).FindMatch(ctx, req.(*FindMatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MatchmakingService_GetQueueStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetQueueStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MatchmakingServiceServer).GetQueueStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MatchmakingService_GetQueueStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MatchmakingServiceServer).GetQueueStatus(ctx, req.(*GetQueueStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MatchmakingService_StreamMatchNotifications_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamMatchNotificationsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MatchmakingServiceServer).StreamMatchNotifications(m, &matchmakingServiceStreamMatchNotificationsServer{stream})
+}
+
+type MatchmakingService_StreamMatchNotificationsServer interface {
+	Send(*Match) error
+	grpc.ServerStream
+}
+
+type matchmakingServiceStreamMatchNotificationsServer struct {
+	grpc.ServerStream
+}
+
+func (x *matchmakingServiceStreamMatchNotificationsServer) Send(m *Match) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// MatchmakingService_ServiceDesc is the grpc.ServiceDesc for MatchmakingService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MatchmakingService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "chrono.matchmaking.v1.MatchmakingService",
+	HandlerType: (*MatchmakingServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "JoinQueue",
+			Handler:    _MatchmakingService_JoinQueue_Handler,
+		},
+		{
+			MethodName: "LeaveQueue",
+			Handler:    _MatchmakingService_LeaveQueue_Handler,
+		},
+		{
+			MethodName: "FindMatch",
+			Handler:    _MatchmakingService_FindMatch_Handler,
+		},
+		{
+			MethodName: "GetQueueStatus",
+			Handler:    _MatchmakingService_GetQueueStatus_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamMatchNotifications",
+			Handler:       _MatchmakingService_StreamMatchNotifications_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "proto/matchmaking.proto",
+}
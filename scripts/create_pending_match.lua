@@ -0,0 +1,47 @@
+-- Атомарное создание матча, ожидающего подтверждения игроков.
+--
+-- KEYS[1..n]          — ключи очередей игроков (sorted set)
+-- KEYS[n+1..2n]       — ключи игроков player:{id}
+-- KEYS[2n+1..3n]      — ключи ожидающего матча игроков pending:player:{id}
+-- KEYS[3n+1]          — ключ ожидающего матча pending:{pendingID}
+-- KEYS[3n+2]          — набор ожидающих матчей региона/режима (sorted set)
+-- KEYS[3n+3..3n+2+e]  — остальные очереди игроков, ищущих матч в нескольких режимах
+-- ARGV[1]             — JSON ожидающего матча
+-- ARGV[2]             — TTL ожидающего матча в секундах
+-- ARGV[3]             — время истечения (unix), score в наборе ожидающих матчей
+-- ARGV[4]             — e, количество остальных очередей (необязательный, по умолчанию 0)
+-- ARGV[4+j]           — номер игрока (1..n), которого нужно убрать из KEYS[3n+2+j]
+--
+-- Возвращает 1, если матч создан, и 0, если хотя бы один игрок уже покинул очередь.
+
+local e = tonumber(ARGV[4] or '0')
+local n = (#KEYS - 2 - e) / 3
+local members = {}
+
+-- Проверяем, что все игроки все еще в очереди (CAS)
+for i = 1, n do
+	local member = redis.call('GET', KEYS[n + i])
+	if not member then
+		return 0
+	end
+	if not redis.call('ZSCORE', KEYS[i], member) then
+		return 0
+	end
+	members[i] = member
+end
+
+-- Убираем игроков из очереди на время подтверждения
+for i = 1, n do
+	redis.call('ZREM', KEYS[i], members[i])
+	redis.call('DEL', KEYS[n + i])
+	redis.call('SET', KEYS[2 * n + i], ARGV[1], 'EX', ARGV[2])
+end
+redis.call('SET', KEYS[3 * n + 1], ARGV[1], 'EX', ARGV[2])
+redis.call('ZADD', KEYS[3 * n + 2], ARGV[3], ARGV[1])
+
+-- Элемент игрока одинаков во всех его очередях
+for j = 1, e do
+	redis.call('ZREM', KEYS[3 * n + 2 + j], members[tonumber(ARGV[4 + j])])
+end
+
+return 1
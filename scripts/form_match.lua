@@ -0,0 +1,47 @@
+-- Атомарное формирование матча.
+--
+-- KEYS[1..n]          — ключи очередей игроков (sorted set); у межрегионального
+--                       матча игроки стоят в очередях разных регионов
+-- KEYS[n+1..2n]       — ключи игроков player:{id}
+-- KEYS[2n+1..3n]      — ключи матча игроков match:{id}
+-- KEYS[3n+1]          — ключ матча по его ID match:id:{matchID}
+-- KEYS[3n+2..3n+1+e]  — остальные очереди игроков, ищущих матч в нескольких режимах
+-- ARGV[1]             — JSON матча
+-- ARGV[2]             — TTL матча игроков в секундах
+-- ARGV[3]             — TTL записи матча по ID в секундах
+-- ARGV[4]             — e, количество остальных очередей (необязательный, по умолчанию 0)
+-- ARGV[4+j]           — номер игрока (1..n), которого нужно убрать из KEYS[3n+1+j]
+--
+-- Возвращает 1, если матч создан, и 0, если хотя бы один игрок уже покинул
+-- очередь (например, попал в матч, сформированный параллельно).
+
+local e = tonumber(ARGV[4] or '0')
+local n = (#KEYS - 1 - e) / 3
+local members = {}
+
+-- Проверяем, что все игроки все еще в очереди (CAS)
+for i = 1, n do
+	local member = redis.call('GET', KEYS[n + i])
+	if not member then
+		return 0
+	end
+	if not redis.call('ZSCORE', KEYS[i], member) then
+		return 0
+	end
+	members[i] = member
+end
+
+-- Сохраняем матч и удаляем игроков из очереди
+for i = 1, n do
+	redis.call('SET', KEYS[2 * n + i], ARGV[1], 'EX', ARGV[2])
+	redis.call('ZREM', KEYS[i], members[i])
+	redis.call('DEL', KEYS[n + i])
+end
+redis.call('SET', KEYS[3 * n + 1], ARGV[1], 'EX', ARGV[3])
+
+-- Элемент игрока одинаков во всех его очередях
+for j = 1, e do
+	redis.call('ZREM', KEYS[3 * n + 1 + j], members[tonumber(ARGV[4 + j])])
+end
+
+return 1
@@ -0,0 +1,37 @@
+-- Атомарный вход игрока в приватный матч.
+--
+-- KEYS[1]  — ключ приглашения invite:{code}
+-- KEYS[2]  — список игроков приглашения invite:{code}:players
+-- KEYS[3]  — набор ID игроков приглашения invite:{code}:members
+-- ARGV[1]  — ID игрока
+-- ARGV[2]  — JSON игрока
+-- ARGV[3]  — количество игроков в матче
+--
+-- Возвращает {0}, если приглашения нет или срок его истек; {-1}, если игрок уже вошел;
+-- {1, число игроков}, если игрок добавлен, а матч еще не собран; {2, JSON игроков...},
+-- если вошел последний игрок: ключи приглашения при этом удаляются.
+
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl <= 0 then
+	return {0}
+end
+
+if redis.call('SADD', KEYS[3], ARGV[1]) == 0 then
+	return {-1}
+end
+local count = redis.call('RPUSH', KEYS[2], ARGV[2])
+redis.call('PEXPIRE', KEYS[2], ttl)
+redis.call('PEXPIRE', KEYS[3], ttl)
+
+if count < tonumber(ARGV[3]) then
+	return {1, count}
+end
+
+local players = redis.call('LRANGE', KEYS[2], 0, -1)
+redis.call('DEL', KEYS[1], KEYS[2], KEYS[3])
+
+local result = {2}
+for i = 1, #players do
+	result[i + 1] = players[i]
+end
+return result
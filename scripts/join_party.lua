@@ -0,0 +1,28 @@
+-- Атомарный вход группы в очередь.
+--
+-- KEYS[1]          — ключ очереди (sorted set)
+-- KEYS[2..n+1]     — ключи игроков player:{id}
+-- KEYS[n+2]        — ключ группы party:{partyID}
+-- ARGV[1]          — TTL ключей игроков и группы в секундах
+-- ARGV[2]          — JSON группы
+-- ARGV[3..]        — пары (рейтинг, JSON игрока) в порядке KEYS
+--
+-- Возвращает 1, если группа добавлена, и 0, если кто-то из игроков уже в очереди.
+
+local n = #KEYS - 2
+
+for i = 1, n do
+	if redis.call('EXISTS', KEYS[1 + i]) == 1 then
+		return 0
+	end
+end
+
+for i = 1, n do
+	local score = ARGV[1 + 2 * i]
+	local member = ARGV[2 + 2 * i]
+	redis.call('SET', KEYS[1 + i], member, 'EX', ARGV[1])
+	redis.call('ZADD', KEYS[1], score, member)
+end
+redis.call('SET', KEYS[#KEYS], ARGV[2], 'EX', ARGV[1])
+
+return 1
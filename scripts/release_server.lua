@@ -0,0 +1,19 @@
+-- Атомарное освобождение слота игрового сервера после окончания матча.
+--
+-- KEYS[1] — хеш серверов региона servers:{region}
+-- ARGV[1] — ID сервера
+--
+-- Возвращает 1, если загрузка уменьшена, и 0, если сервер уже снят с учета.
+
+local raw = redis.call('HGET', KEYS[1], ARGV[1])
+if not raw then
+	return 0
+end
+
+local server = cjson.decode(raw)
+if server.load > 0 then
+	server.load = server.load - 1
+end
+redis.call('HSET', KEYS[1], ARGV[1], cjson.encode(server))
+
+return 1
@@ -0,0 +1,23 @@
+-- Атомарное удаление игрока, оставшегося в очереди после остановки реплики.
+--
+-- KEYS[1] — ключ игрока player:{id}
+-- KEYS[2..] — очереди региона/режима (sorted set)
+-- ARGV[1] — элемент очереди (JSON игрока) на момент остановки
+--
+-- Ключ игрока удаляется, только если он не изменился: игрок, который с тех пор
+-- перезашел в очередь или получил новый рейтинг, остается в очереди.
+-- Возвращает 1, если элемент удален хотя бы из одной очереди, иначе 0.
+
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	redis.call('DEL', KEYS[1])
+end
+
+local removed = 0
+for i = 2, #KEYS do
+	removed = removed + redis.call('ZREM', KEYS[i], ARGV[1])
+end
+
+if removed > 0 then
+	return 1
+end
+return 0
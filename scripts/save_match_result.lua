@@ -0,0 +1,20 @@
+-- Атомарное закрепление результата матча вместе с новыми рейтингами игроков.
+--
+-- KEYS[1] — отметка о примененном результате match:result:{match_id}
+-- KEYS[2..] — ключи рейтингов rating:current:{player_id}
+-- ARGV[1] — TTL отметки в секундах
+-- ARGV[2..] — новые рейтинги в порядке KEYS[2..]
+--
+-- Рейтинги записываются, только если результат еще не применялся, поэтому повторный
+-- запрос не меняет их, а частичной записи не бывает.
+-- Возвращает 1, если результат применен этим вызовом, и 0, если он уже был применен.
+
+if not redis.call('SET', KEYS[1], 1, 'NX', 'EX', ARGV[1]) then
+	return 0
+end
+
+for i = 2, #KEYS do
+	redis.call('SET', KEYS[i], ARGV[i])
+end
+
+return 1
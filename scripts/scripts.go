@@ -0,0 +1,49 @@
+// Package scripts содержит Lua-скрипты Redis, встроенные в бинарный файл
+package scripts
+
+import _ "embed"
+
+// FormMatch атомарно сохраняет матч и удаляет его игроков из очереди
+//
+//go:embed form_match.lua
+var FormMatch string
+
+// JoinParty атомарно добавляет в очередь всех игроков группы
+//
+//go:embed join_party.lua
+var JoinParty string
+
+// CreatePendingMatch атомарно переводит игроков из очереди в матч, ожидающий подтверждения
+//
+//go:embed create_pending_match.lua
+var CreatePendingMatch string
+
+// UpdatePlayerRating атомарно заменяет рейтинг игрока в его ключе и элементе очереди
+//
+//go:embed update_player_rating.lua
+var UpdatePlayerRating string
+
+// RemoveDrainedPlayer атомарно удаляет из очереди игрока, оставшегося после остановки реплики
+//
+//go:embed remove_drained_player.lua
+var RemoveDrainedPlayer string
+
+// SaveMatchResult атомарно закрепляет результат матча и сохраняет новые рейтинги игроков
+//
+//go:embed save_match_result.lua
+var SaveMatchResult string
+
+// SelectServer атомарно выбирает наименее загруженный игровой сервер региона
+//
+//go:embed select_server.lua
+var SelectServer string
+
+// ReleaseServer атомарно освобождает слот игрового сервера
+//
+//go:embed release_server.lua
+var ReleaseServer string
+
+// JoinInvite атомарно добавляет игрока в приватный матч и забирает состав, когда матч собран
+//
+//go:embed join_invite.lua
+var JoinInvite string
@@ -0,0 +1,32 @@
+-- Атомарный выбор наименее загруженного игрового сервера региона.
+--
+-- KEYS[1] — хеш серверов региона servers:{region} (ID сервера -> JSON)
+--
+-- Выбирается сервер с наименьшей долей занятых слотов (load / capacity),
+-- его загрузка увеличивается на 1. Возвращает JSON выбранного сервера
+-- или false, если свободных серверов нет.
+
+local entries = redis.call('HGETALL', KEYS[1])
+
+local best
+local bestRatio
+for i = 1, #entries, 2 do
+	local server = cjson.decode(entries[i + 1])
+	if server.capacity > 0 and server.load < server.capacity then
+		local ratio = server.load / server.capacity
+		if best == nil or ratio < bestRatio then
+			best = server
+			bestRatio = ratio
+		end
+	end
+end
+
+if best == nil then
+	return false
+end
+
+best.load = best.load + 1
+local encoded = cjson.encode(best)
+redis.call('HSET', KEYS[1], best.server_id, encoded)
+
+return encoded
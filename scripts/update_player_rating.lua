@@ -0,0 +1,24 @@
+-- Атомарная замена данных игрока с новым рейтингом.
+--
+-- KEYS[1] — ключ игрока player:{id}
+-- KEYS[2..] — ключи очередей игрока (sorted set); у игрока с несколькими режимами
+--             поиска — очередь каждого режима
+-- ARGV[1] — прежний JSON игрока
+-- ARGV[2] — новый JSON игрока
+-- ARGV[3] — новый рейтинг (score в очереди)
+--
+-- Элемент очереди совпадает с JSON ключа игрока, поэтому он заменяется вместе с ключом.
+-- Возвращает 1, если игрок обновлен, и 0, если его данные изменились или ключ истек.
+
+if redis.call('GET', KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+
+redis.call('SET', KEYS[1], ARGV[2], 'KEEPTTL')
+for i = 2, #KEYS do
+	if redis.call('ZREM', KEYS[i], ARGV[1]) == 1 then
+		redis.call('ZADD', KEYS[i], ARGV[3], ARGV[2])
+	end
+end
+
+return 1
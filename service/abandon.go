@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"chrono-matchmaking/logging"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// ErrPlayerFlagged возвращается при входе в очередь игроку, отмеченному для проверки
+// поведения из-за частых выходов из очереди
+var ErrPlayerFlagged = errors.New("player is flagged for behavioral review")
+
+// ErrPlayerNotFlagged возвращается при снятии отметки с игрока, у которого ее нет
+var ErrPlayerNotFlagged = errors.New("player is not flagged")
+
+// recordAbandon учитывает выход игрока из очереди без матча. Если за сутки с первого
+// выхода их стало больше MaxAbandonsBeforeFlag, игрок попадает в набор flagged:abandon
+// и AbandonFlagDuration не может встать в очередь. Ошибки хранилища только пишутся
+// в лог: выход из очереди из-за них не должен падать.
+func (s *MatcherService) recordAbandon(ctx context.Context, playerID string) {
+	config := s.currentConfig()
+	maxAbandons := config.MaxAbandonsBeforeFlag
+	if maxAbandons <= 0 {
+		return
+	}
+
+	count, err := s.storage.IncrementAbandonCount(ctx, playerID)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to record queue abandon",
+			zap.String("player_id", playerID),
+			zap.Error(err),
+		)
+		return
+	}
+	if count <= int64(maxAbandons) {
+		return
+	}
+
+	if err := s.storage.FlagPlayer(ctx, playerID, config.AbandonFlagDuration); err != nil {
+		logging.FromContext(ctx).Warn("Failed to flag player",
+			zap.String("player_id", playerID),
+			zap.Int64("abandons", count),
+			zap.Error(err),
+		)
+	}
+}
+
+// checkFlagged возвращает ErrPlayerFlagged, если игрок отмечен для проверки
+func (s *MatcherService) checkFlagged(ctx context.Context, playerID string) error {
+	flagged, err := s.storage.IsPlayerFlagged(ctx, playerID)
+	if err != nil {
+		return err
+	}
+	if flagged {
+		return ErrPlayerFlagged
+	}
+	return nil
+}
+
+// GetFlaggedPlayers возвращает ID игроков, отмеченных для проверки из-за частых
+// выходов из очереди
+func (s *MatcherService) GetFlaggedPlayers(ctx context.Context) ([]string, error) {
+	ctx, span := startSpan(ctx, "GetFlaggedPlayers")
+	defer span.End()
+
+	return s.storage.GetFlaggedPlayers(ctx)
+}
+
+// UnflagPlayer снимает с игрока отметку за частые выходы из очереди и сбрасывает
+// счетчик выходов. Если отметки нет, возвращает ErrPlayerNotFlagged.
+func (s *MatcherService) UnflagPlayer(ctx context.Context, playerID string) error {
+	ctx, span := startSpan(ctx, "UnflagPlayer", attribute.String("player_id", playerID))
+	defer span.End()
+
+	flagged, err := s.storage.UnflagPlayer(ctx, playerID)
+	if err != nil {
+		return err
+	}
+	if !flagged {
+		return ErrPlayerNotFlagged
+	}
+
+	logging.FromContext(ctx).Info("Player unflagged", zap.String("player_id", playerID))
+	return nil
+}
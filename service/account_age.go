@@ -0,0 +1,55 @@
+package service
+
+import (
+	"time"
+
+	"chrono-matchmaking/models"
+)
+
+const (
+	newAccountAge     = 7 * 24 * time.Hour   // Аккаунт младше недели считается новым
+	veteranAccountAge = 365 * 24 * time.Hour // Аккаунт старше года считается ветеранским
+)
+
+// AccountAgeQualityAdjustment возвращает поправку к качеству матча по возрасту аккаунтов.
+// Каждая пара новый аккаунт/ветеран снижает качество на AccountAgeMismatchPenalty;
+// если возраст всех аккаунтов отличается не больше чем на MaxAccountAgeDiff,
+// качество повышается на AccountAgeMatchBonus. Игроки без даты создания аккаунта не учитываются.
+func (s *MatcherService) AccountAgeQualityAdjustment(players []models.Player) float64 {
+	config := s.currentConfig()
+	if len(players) > 0 {
+		config = s.configForContext(players[0].Region, players[0].GameMode)
+	}
+
+	known := make([]time.Duration, 0, len(players))
+	for _, p := range players {
+		if !p.AccountCreatedAt.IsZero() {
+			known = append(known, p.AccountAge)
+		}
+	}
+	if len(known) < 2 {
+		return 0
+	}
+
+	var adjustment float64
+	minAge, maxAge := known[0], known[0]
+	for i, a := range known {
+		if a < minAge {
+			minAge = a
+		}
+		if a > maxAge {
+			maxAge = a
+		}
+		for _, b := range known[i+1:] {
+			if (a < newAccountAge && b > veteranAccountAge) || (b < newAccountAge && a > veteranAccountAge) {
+				adjustment -= config.AccountAgeMismatchPenalty
+			}
+		}
+	}
+
+	if maxAge-minAge <= config.MaxAccountAgeDiff {
+		adjustment += config.AccountAgeMatchBonus
+	}
+
+	return adjustment
+}
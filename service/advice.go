@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"chrono-matchmaking/models"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ratingAdviceBracketWidth ширина рейтинговой группы для анализа распределения очереди
+const ratingAdviceBracketWidth = 100
+
+// SuggestRatingImprovement сравнивает рейтинг игрока с распределением рейтингов в его очереди
+// и возвращает рекомендации: перцентиль, самую заполненную рейтинговую группу, оценку числа
+// игр до нее и режим, в котором сейчас больше подходящих соперников
+func (s *MatcherService) SuggestRatingImprovement(ctx context.Context, playerID string) (*models.RatingAdvice, error) {
+	ctx, span := startSpan(ctx, "SuggestRatingImprovement", attribute.String("player_id", playerID))
+	defer span.End()
+
+	player, err := s.storage.GetPlayerByID(ctx, playerID)
+	if err != nil {
+		return nil, fmt.Errorf("player not found in queue: %w", err)
+	}
+
+	players, err := s.storage.GetQueuePlayers(ctx, player.Region, player.GameMode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get queue players: %w", err)
+	}
+
+	advice := &models.RatingAdvice{
+		PlayerID:      player.ID,
+		CurrentRating: player.Rating,
+		TargetRating:  player.Rating,
+	}
+
+	// Распределение рейтингов по группам
+	var below int
+	brackets := make(map[int]int)
+	for _, p := range players {
+		if p.Rating < player.Rating {
+			below++
+		}
+		brackets[ratingBracket(p.Rating)]++
+	}
+	if len(players) > 0 {
+		advice.CurrentPercentile = float64(below) / float64(len(players)) * 100
+	}
+
+	playersPerMatch, err := s.GetPlayersPerMatch(ctx, player.GameMode)
+	if err != nil {
+		return nil, err
+	}
+	ownBracket := ratingBracket(player.Rating)
+	advice.Underserved = brackets[ownBracket] < playersPerMatch
+
+	// Самая заполненная группа; при равенстве выбираем ближайшую к игроку
+	bestBracket, bestCount := ownBracket, brackets[ownBracket]
+	for bracket, count := range brackets {
+		closer := abs(bracket-ownBracket) < abs(bestBracket-ownBracket)
+		if count > bestCount || (count == bestCount && closer) {
+			bestBracket, bestCount = bracket, count
+		}
+	}
+	if bestBracket != ownBracket {
+		advice.TargetRating = bestBracket + ratingAdviceBracketWidth/2
+	}
+
+	// При вероятности победы 50% одна победа дает K/2 рейтинга
+	perGame := s.kFactorFor(nil) / 2
+	advice.EstimatedGamesToTarget = int(math.Ceil(math.Abs(float64(advice.TargetRating-player.Rating)) / perGame))
+
+	suggested, err := s.suggestQueue(ctx, player)
+	if err != nil {
+		return nil, err
+	}
+	advice.SuggestedQueue = suggested
+
+	return advice, nil
+}
+
+// suggestQueue ищет режим того же региона, где в текущем диапазоне рейтинга игрока
+// набирается больше матчей, чем в его собственном режиме
+func (s *MatcherService) suggestQueue(ctx context.Context, player *models.Player) (string, error) {
+	modes, err := s.GetGameModes(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	matchesAvailable := func(gameMode string) (float64, error) {
+		ratingRange := s.calculateRatingRange(player.Region, gameMode, 0)
+		candidates, err := s.storage.GetPlayersInRange(ctx, player.Region, gameMode,
+			player.Rating-ratingRange, player.Rating+ratingRange, 0)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get players in range: %w", err)
+		}
+		playersPerMatch, ok := modes[gameMode]
+		if !ok {
+			playersPerMatch = defaultPlayersPerMatch
+		}
+		return float64(len(candidates)) / float64(playersPerMatch), nil
+	}
+
+	best, err := matchesAvailable(player.GameMode)
+	if err != nil {
+		return "", err
+	}
+
+	suggested := ""
+	for _, gameMode := range GameModeNames(modes) {
+		if gameMode == player.GameMode {
+			continue
+		}
+		available, err := matchesAvailable(gameMode)
+		if err != nil {
+			return "", err
+		}
+		if available > best {
+			best, suggested = available, gameMode
+		}
+	}
+
+	return suggested, nil
+}
+
+// ratingBracket возвращает нижнюю границу рейтинговой группы
+func ratingBracket(rating int) int {
+	bracket := rating / ratingAdviceBracketWidth * ratingAdviceBracketWidth
+	if rating < 0 && rating%ratingAdviceBracketWidth != 0 {
+		bracket -= ratingAdviceBracketWidth
+	}
+	return bracket
+}
+
+// abs возвращает модуль целого числа
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"chrono-matchmaking/models"
+)
+
+// Значения MatcherConfig.Algorithm
+const (
+	AlgorithmGreedy    = "greedy"    // Жадный подбор от каждого игрока по очереди
+	AlgorithmStable    = "stable"    // Устойчивое распределение по Гейлу — Шепли
+	AlgorithmHungarian = "hungarian" // Оптимальное назначение венгерским алгоритмом
+)
+
+// MatchingAlgorithm разбивает игроков очереди на группы для матчей.
+// Каждая возвращенная группа содержит ровно groupSize игроков, группы не пересекаются,
+// участники одной party всегда попадают в одну группу.
+type MatchingAlgorithm interface {
+	FormGroups(players []*models.Player, groupSize int) [][]*models.Player
+}
+
+// GroupConstraints правила, которые MatcherService накладывает на группы
+type GroupConstraints struct {
+	CanJoin  func(group, unit []*models.Player) bool // Единица подбора может войти в группу
+	Complete func(group []*models.Player) bool       // Собранную группу можно превратить в матч
+}
+
+// constrainedAlgorithm реализуют алгоритмы, которым нужны правила совместимости сервиса
+type constrainedAlgorithm interface {
+	setConstraints(c GroupConstraints)
+}
+
+// NewMatchingAlgorithm возвращает встроенный алгоритм по имени из MatcherConfig.Algorithm.
+// Пустое имя означает жадный алгоритм.
+func NewMatchingAlgorithm(name string) (MatchingAlgorithm, error) {
+	switch name {
+	case "", AlgorithmGreedy:
+		return &GreedyAlgorithm{}, nil
+	case AlgorithmStable:
+		return &StableMatchingAlgorithm{}, nil
+	case AlgorithmHungarian:
+		return &HungarianAlgorithm{}, nil
+	default:
+		return nil, fmt.Errorf("unknown matching algorithm %q", name)
+	}
+}
+
+// groupConstraints возвращает правила сервиса для алгоритмов подбора
+func (s *MatcherService) groupConstraints() GroupConstraints {
+	return GroupConstraints{
+		CanJoin:  s.canJoinGroup,
+		Complete: s.isGroupComplete,
+	}
+}
+
+// canJoinGroup проверяет совместимость единицы с первым игроком группы, что группы
+// по-прежнему можно рассадить по командам, а роли — добрать до требований
+func (s *MatcherService) canJoinGroup(group, unit []*models.Player) bool {
+	if len(group) == 0 {
+		return true
+	}
+	gameMode := group[0].GameMode
+	teamSize := s.playersPerMatch(context.Background(), gameMode) / 2
+
+	if !s.unitCompatibleWithGroup(group, unit) {
+		return false
+	}
+
+	units := groupPartyUnits(append(append([]*models.Player{}, group...), unit...))
+	unitSizes := make([]int, len(units))
+	for i, u := range units {
+		unitSizes[i] = len(u)
+	}
+	if !canSplitTeams(unitSizes, teamSize) {
+		return false
+	}
+
+	if rule, ok := s.currentConfig().CompositionRules[gameMode]; ok {
+		return compositionFeasible(append(append([]*models.Player{}, group...), unit...), rule, teamSize)
+	}
+	return true
+}
+
+// isGroupComplete проверяет, что команды собранной группы можно расставить по требованиям к ролям
+func (s *MatcherService) isGroupComplete(group []*models.Player) bool {
+	gameMode := group[0].GameMode
+	rule, ok := s.currentConfig().CompositionRules[gameMode]
+	if !ok {
+		return true
+	}
+	_, ok = arrangeComposedTeams(groupPartyUnits(group), s.playersPerMatch(context.Background(), gameMode)/2, rule)
+	return ok
+}
+
+// GreedyAlgorithm жадно собирает группу вокруг каждой еще не распределенной единицы
+// подбора: сначала из игроков с тем же языком голосового чата, затем из остальных
+type GreedyAlgorithm struct {
+	constraints GroupConstraints
+}
+
+// setConstraints реализует constrainedAlgorithm
+func (a *GreedyAlgorithm) setConstraints(c GroupConstraints) {
+	a.constraints = c
+}
+
+// FormGroups реализует MatchingAlgorithm
+func (a *GreedyAlgorithm) FormGroups(players []*models.Player, groupSize int) [][]*models.Player {
+	// Группы игроков подбираются целиком, поэтому работаем с единицами подбора
+	units := groupPartyUnits(players)
+
+	used := make(map[string]bool) // Отслеживаем использованных игроков
+	var groups [][]*models.Player
+
+	for i := 0; i < len(units); i++ {
+		if used[units[i][0].ID] {
+			continue
+		}
+
+		// Начинаем формировать группу с текущей единицы
+		group := append([]*models.Player{}, units[i]...)
+		for _, p := range units[i] {
+			used[p.ID] = true
+		}
+
+		// Ищем совместимых игроков для группы: сначала с тем же языком
+		// голосового чата, затем остальных
+		for pass := 0; pass < 2 && len(group) < groupSize; pass++ {
+			for j := 0; j < len(units) && len(group) < groupSize; j++ {
+				unit := units[j]
+				if used[unit[0].ID] || len(group)+len(unit) > groupSize {
+					continue
+				}
+				if pass == 0 && !sharesVoiceLanguage(group[0], unit[0]) {
+					continue
+				}
+				if a.constraints.CanJoin != nil && !a.constraints.CanJoin(group, unit) {
+					continue
+				}
+				group = append(group, unit...)
+				for _, p := range unit {
+					used[p.ID] = true
+				}
+			}
+		}
+
+		if len(group) == groupSize && (a.constraints.Complete == nil || a.constraints.Complete(group)) {
+			groups = append(groups, group)
+			continue
+		}
+
+		// Если не собрали группу, освобождаем всех игроков, чтобы попробовать другие комбинации
+		for _, p := range group {
+			delete(used, p.ID)
+		}
+	}
+
+	return groups
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/metrics"
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/storage"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// recordMatchStats обновляет почасовую статистику режима, метрики Prometheus, журнал встреч
+// соперников и время последней активности игроков после создания матча.
+// Ошибки только логируются: статистика не должна мешать созданию матча.
+func (s *MatcherService) recordMatchStats(ctx context.Context, region, gameMode string, match *models.Match) {
+	hour := match.CreatedAt.UTC().Hour()
+
+	metrics.MatchCreationTotal.WithLabelValues(region, gameMode).Inc()
+	// Боты не ждали в очереди и не попадают в статистику ожидания
+	humans := match.HumanPlayers()
+	for _, player := range humans {
+		wait := match.CreatedAt.Sub(player.JoinedAt)
+		metrics.MatchWaitSeconds.WithLabelValues(region, gameMode).Observe(wait.Seconds())
+
+		if err := s.storage.RecordMatchCreationTime(ctx, region, gameMode, wait); err != nil {
+			logging.FromContext(ctx).Warn("Failed to record match creation time",
+				zap.String("match_id", match.MatchID),
+				zap.String("player_id", player.ID),
+				zap.Error(err),
+			)
+		}
+		if err := s.storage.RecordPlayerWaitTime(ctx, player.ID, wait); err != nil {
+			logging.FromContext(ctx).Warn("Failed to record player wait time",
+				zap.String("match_id", match.MatchID),
+				zap.String("player_id", player.ID),
+				zap.Error(err),
+			)
+		}
+	}
+
+	s.recordSegmentMatch(ctx, region, gameMode, match)
+	s.recordEncounters(ctx, match)
+	s.recordActivity(ctx, match)
+
+	if err := s.storage.RecordMatchedRatings(ctx, region, gameMode, match); err != nil {
+		logging.FromContext(ctx).Warn("Failed to record matched ratings",
+			zap.String("match_id", match.MatchID),
+			zap.Error(err),
+		)
+	}
+
+	if err := s.storage.IncrementModePopularity(ctx, region, gameMode, hour); err != nil {
+		logging.FromContext(ctx).Warn("Failed to record match stats",
+			zap.String("match_id", match.MatchID),
+			zap.Error(err),
+		)
+		return
+	}
+
+	for _, player := range humans {
+		wait := match.CreatedAt.Sub(player.JoinedAt).Seconds()
+		if err := s.storage.RecordWaitTime(ctx, region, gameMode, hour, wait); err != nil {
+			logging.FromContext(ctx).Warn("Failed to record wait time",
+				zap.String("match_id", match.MatchID),
+				zap.String("player_id", player.ID),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// GetPeakHoursReport строит отчет о загруженности очереди по часам суток (UTC).
+// Для каждого часа QueueHealth = matchesPerHour / avgWaitSeconds: чем больше матчей
+// и чем короче ожидание, тем лучше время для поиска игры.
+func (s *MatcherService) GetPeakHoursReport(ctx context.Context, region, gameMode string) (*models.PeakHoursReport, error) {
+	ctx, span := startSpan(ctx, "GetPeakHoursReport",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	avgWait, err := s.storage.GetHourlyAvgWait(ctx, region, gameMode)
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := s.storage.GetHourlyMatchCounts(ctx, region, gameMode)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.PeakHoursReport{
+		Region:   region,
+		GameMode: gameMode,
+	}
+
+	for hour := 0; hour < storage.HoursPerDay; hour++ {
+		stats := models.HourStats{
+			Hour:           hour,
+			AvgWaitSeconds: avgWait[hour],
+			MatchesFormed:  matches[hour],
+		}
+		if stats.AvgWaitSeconds > 0 {
+			stats.QueueHealth = float64(stats.MatchesFormed) / stats.AvgWaitSeconds
+		} else if stats.MatchesFormed > 0 {
+			// Матчи создавались мгновенно — считаем ожидание равным секунде
+			stats.QueueHealth = float64(stats.MatchesFormed)
+		}
+		report.HourlyStats[hour] = stats
+
+		if stats.QueueHealth > report.HourlyStats[report.BestHour].QueueHealth {
+			report.BestHour = hour
+		}
+		if stats.QueueHealth < report.HourlyStats[report.WorstHour].QueueHealth {
+			report.WorstHour = hour
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/metrics"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	autoPurgeGrowthFactor = 1.5             // Приток должен превышать отток в 1.5 раза
+	autoPurgeGrowthWindow = 3               // Столько замеров подряд очередь должна расти
+	autoPurgeCooldown     = 5 * time.Minute // Минимальный интервал между очистками очереди
+	autoPurgeLogThreshold = 0.1             // Доля удаленных игроков, при которой пишем итог в лог
+)
+
+// queueGrowthSample последний замер притока и оттока очереди
+type queueGrowthSample struct {
+	joins, leaves int64     // Значения счетчиков на момент замера
+	at            time.Time // Время замера
+	joinRate      float64   // Входов в минуту с предыдущего замера
+	leaveRate     float64   // Выходов в минуту с предыдущего замера
+	growing       int       // Сколько замеров подряд очередь растет
+}
+
+// GetQueueGrowthRate возвращает скорость входа в очередь и выхода из нее (игроков в минуту)
+// по последнему замеру ProcessQueue. До второго замера обе скорости равны нулю.
+func (s *MatcherService) GetQueueGrowthRate(region, gameMode string) (joinRate, leaveRate float64) {
+	s.growthMu.Lock()
+	defer s.growthMu.Unlock()
+
+	sample, ok := s.queueGrowth[region+":"+gameMode]
+	if !ok {
+		return 0, 0
+	}
+	return sample.joinRate, sample.leaveRate
+}
+
+// sampleQueueGrowth делает очередной замер притока/оттока и возвращает, сколько замеров
+// подряд приток превышает отток в autoPurgeGrowthFactor раз
+func (s *MatcherService) sampleQueueGrowth(ctx context.Context, region, gameMode string) (int, error) {
+	joins, leaves, err := s.storage.GetQueueFlowCounters(ctx, region, gameMode)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+
+	s.growthMu.Lock()
+	defer s.growthMu.Unlock()
+
+	key := region + ":" + gameMode
+	prev, ok := s.queueGrowth[key]
+	sample := &queueGrowthSample{joins: joins, leaves: leaves, at: now}
+	if ok {
+		minutes := now.Sub(prev.at).Minutes()
+		if minutes > 0 {
+			sample.joinRate = float64(joins-prev.joins) / minutes
+			sample.leaveRate = float64(leaves-prev.leaves) / minutes
+		}
+		if sample.joinRate > sample.leaveRate*autoPurgeGrowthFactor {
+			sample.growing = prev.growing + 1
+		}
+	}
+	s.queueGrowth[key] = sample
+
+	return sample.growing, nil
+}
+
+// resetQueueGrowth сбрасывает счетчик замеров роста после очистки очереди
+func (s *MatcherService) resetQueueGrowth(region, gameMode string) {
+	s.growthMu.Lock()
+	defer s.growthMu.Unlock()
+
+	if sample, ok := s.queueGrowth[region+":"+gameMode]; ok {
+		sample.growing = 0
+	}
+}
+
+// AutoPurgeStalePlayers запускает PurgeInactivePlayers, если очередь растет
+// (приток > отток × 1.5) три замера подряд, а предыдущая очистка была больше
+// 5 минут назад. Работает только при включенном AutoPurgeEnabled. Возвращает true,
+// если очистка была запущена.
+func (s *MatcherService) AutoPurgeStalePlayers(ctx context.Context, region, gameMode string) (bool, error) {
+	ctx, span := startSpan(ctx, "AutoPurgeStalePlayers",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	if !s.configForContext(region, gameMode).AutoPurgeEnabled {
+		return false, nil
+	}
+
+	growing, err := s.sampleQueueGrowth(ctx, region, gameMode)
+	if err != nil {
+		return false, err
+	}
+	if growing < autoPurgeGrowthWindow {
+		return false, nil
+	}
+
+	started, err := s.storage.TryStartQueuePurge(ctx, region, gameMode, autoPurgeCooldown)
+	if err != nil {
+		return false, err
+	}
+	if !started {
+		return false, nil
+	}
+
+	metrics.AutoPurgeTriggeredTotal.WithLabelValues(region, gameMode).Inc()
+	s.resetQueueGrowth(region, gameMode)
+
+	before, err := s.GetQueueSize(ctx, region, gameMode)
+	if err != nil {
+		return true, err
+	}
+
+	if _, err := s.PurgeInactivePlayers(ctx, region, gameMode); err != nil {
+		return true, err
+	}
+
+	after, err := s.GetQueueSize(ctx, region, gameMode)
+	if err != nil {
+		return true, err
+	}
+
+	if before > 0 && float64(before-after)/float64(before) > autoPurgeLogThreshold {
+		logging.FromContext(ctx).Info("Auto-purge shrank queue",
+			zap.String("region", region),
+			zap.String("game_mode", gameMode),
+			zap.Int64("before", before),
+			zap.Int64("after", after),
+		)
+	}
+
+	return true, nil
+}
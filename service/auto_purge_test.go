@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/storage"
+	"go.uber.org/zap"
+)
+
+// growQueue ставит в очередь count новых игроков в обход сервиса, чтобы сохранить JoinedAt
+func growQueue(t *testing.T, store storage.Storage, prefix string, count int, joinedAt time.Time) {
+	t.Helper()
+	for i := 0; i < count; i++ {
+		player := &models.Player{ID: fmt.Sprintf("%s-%d", prefix, i), Rating: 1500, Region: "EU", GameMode: "3v3", JoinedAt: joinedAt}
+		if err := store.AddPlayerToQueue(context.Background(), player); err != nil {
+			t.Fatalf("AddPlayerToQueue: %v", err)
+		}
+	}
+}
+
+func TestAutoPurgeOnGrowingQueue(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewInMemoryStorage()
+	config := DefaultMatcherConfig()
+	config.AutoPurgeEnabled = true
+	config.MaxSearchTime = 10 * time.Minute
+	matcher := NewMatcherService(store, zap.NewNop(), config)
+
+	growQueue(t, store, "stale", 4, time.Now().Add(-time.Hour))
+
+	// Первый замер только запоминает счетчики; затем очередь растет три замера подряд
+	for sample := 0; sample <= autoPurgeGrowthWindow; sample++ {
+		if sample > 0 {
+			growQueue(t, store, fmt.Sprintf("fresh%d", sample), 2, time.Now())
+		}
+		triggered, err := matcher.AutoPurgeStalePlayers(ctx, "EU", "3v3")
+		if err != nil {
+			t.Fatalf("AutoPurgeStalePlayers: %v", err)
+		}
+		if want := sample == autoPurgeGrowthWindow; triggered != want {
+			t.Fatalf("sample %d: triggered = %v, want %v", sample, triggered, want)
+		}
+	}
+
+	if joinRate, leaveRate := matcher.GetQueueGrowthRate("EU", "3v3"); joinRate <= leaveRate*autoPurgeGrowthFactor {
+		t.Errorf("growth rate = %v joins, %v leaves per minute; want a growing queue", joinRate, leaveRate)
+	}
+	players, _ := store.GetQueuePlayers(ctx, "EU", "3v3")
+	if len(players) != 2*autoPurgeGrowthWindow {
+		t.Errorf("queue has %d players, want %d fresh ones", len(players), 2*autoPurgeGrowthWindow)
+	}
+	for _, p := range players {
+		if time.Since(p.JoinedAt) > config.MaxSearchTime {
+			t.Errorf("stale player %s survived the purge", p.ID)
+		}
+	}
+
+	// Счетчик роста сброшен, а повторная очистка раньше чем через 5 минут не запускается
+	growQueue(t, store, "stale-again", 2, time.Now().Add(-time.Hour))
+	for sample := 1; sample <= autoPurgeGrowthWindow; sample++ {
+		growQueue(t, store, fmt.Sprintf("late%d", sample), 2, time.Now())
+		triggered, err := matcher.AutoPurgeStalePlayers(ctx, "EU", "3v3")
+		if err != nil {
+			t.Fatalf("AutoPurgeStalePlayers: %v", err)
+		}
+		if triggered {
+			t.Fatalf("sample %d: auto-purge triggered again within the cooldown", sample)
+		}
+	}
+	if _, stale, _, _ := store.GetQueueMemberCount(ctx, "EU", "3v3", config.MaxSearchTime); stale != 2 {
+		t.Errorf("stale players = %d, want 2 kept until the cooldown ends", stale)
+	}
+}
+
+func TestAutoPurgeDisabled(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewInMemoryStorage()
+	matcher := NewMatcherService(store, zap.NewNop(), DefaultMatcherConfig())
+
+	growQueue(t, store, "stale", 4, time.Now().Add(-time.Hour))
+	for sample := 0; sample <= 2*autoPurgeGrowthWindow; sample++ {
+		growQueue(t, store, fmt.Sprintf("fresh%d", sample), 2, time.Now())
+		if triggered, _ := matcher.AutoPurgeStalePlayers(ctx, "EU", "3v3"); triggered {
+			t.Fatalf("sample %d: auto-purge triggered with AutoPurgeEnabled off", sample)
+		}
+	}
+	if size, _ := store.GetQueueSize(ctx, "EU", "3v3"); size != 4+2*(2*autoPurgeGrowthWindow+1) {
+		t.Errorf("queue size = %d, want nothing purged", size)
+	}
+}
+
+func TestAutoPurgeNeedsConsecutiveGrowth(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewInMemoryStorage()
+	config := DefaultMatcherConfig()
+	config.AutoPurgeEnabled = true
+	matcher := NewMatcherService(store, zap.NewNop(), config)
+
+	// Замер без притока прерывает серию роста
+	pattern := []int{0, 2, 2, 0, 2, 2}
+	for sample, joins := range pattern {
+		growQueue(t, store, fmt.Sprintf("p%d", sample), joins, time.Now())
+		if triggered, _ := matcher.AutoPurgeStalePlayers(ctx, "EU", "3v3"); triggered {
+			t.Fatalf("sample %d: auto-purge triggered without %d growing samples in a row", sample, autoPurgeGrowthWindow)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/storage"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// ErrMatchConflict возвращается, если кто-то из выбранных игроков уже покинул очередь
+var ErrMatchConflict = storage.ErrMatchConflict
+
+// ErrNoBackfillCandidates возвращается, если в очереди не хватает подходящих игроков на замену
+var ErrNoBackfillCandidates = errors.New("not enough backfill candidates in queue")
+
+// BackfillMatch добирает в уже созданный матч slot_count игроков из очереди взамен
+// отключившихся. Замены ищутся в диапазоне MaxRatingDiff вокруг rating_anchor, убираются
+// из очереди и добавляются в матч; каждая попадает в команду, где сейчас меньше игроков.
+func (s *MatcherService) BackfillMatch(ctx context.Context, matchID string, req *models.BackfillRequest) (*models.Match, error) {
+	ctx, span := startSpan(ctx, "BackfillMatch", attribute.String("match_id", matchID))
+	defer span.End()
+
+	playersPerMatch, err := s.GetPlayersPerMatch(ctx, req.GameMode)
+	if err != nil {
+		return nil, err
+	}
+	if req.SlotCount <= 0 || req.SlotCount > playersPerMatch {
+		return nil, fmt.Errorf("slot_count must be between 1 and %d", playersPerMatch)
+	}
+
+	match, err := s.storage.GetMatchByID(ctx, matchID)
+	if err != nil {
+		return nil, err
+	}
+
+	ratingRange := s.calculateRatingRange(req.Region, req.GameMode, 0)
+	candidates, err := s.storage.GetPlayersInRange(ctx, req.Region, req.GameMode,
+		req.RatingAnchor-ratingRange, req.RatingAnchor+ratingRange, int64(req.SlotCount*3))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get backfill candidates: %w", err)
+	}
+
+	inMatch := make(map[string]bool, len(match.Players))
+	for _, p := range match.Players {
+		inMatch[p.ID] = true
+	}
+
+	replacements := make([]models.Player, 0, req.SlotCount)
+	for _, candidate := range candidates {
+		if len(replacements) >= req.SlotCount {
+			break
+		}
+		// Группы добираются только целиком при обычном подборе
+		if candidate.PartyID != "" || inMatch[candidate.ID] {
+			continue
+		}
+		replacements = append(replacements, *candidate)
+	}
+	if len(replacements) < req.SlotCount {
+		return nil, fmt.Errorf("%w: found %d of %d", ErrNoBackfillCandidates, len(replacements), req.SlotCount)
+	}
+
+	match.Players = append(match.Players, replacements...)
+	for _, p := range replacements {
+		team := 0
+		if len(match.Teams[1]) < len(match.Teams[0]) {
+			team = 1
+		}
+		match.Teams[team] = append(match.Teams[team], p)
+	}
+	match.BackfillCount += len(replacements)
+
+	unlock, err := s.lockMultiQueuePlayers(ctx, replacements)
+	if err != nil {
+		return nil, err
+	}
+	err = s.storage.ClaimBackfillPlayers(ctx, match, replacements)
+	unlock()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.storage.UpdateMatch(ctx, match); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).Info("Match backfilled",
+		zap.String("match_id", match.MatchID),
+		zap.Int("slot_count", req.SlotCount),
+		zap.Int("backfill_count", match.BackfillCount),
+	)
+
+	s.publishMatchFormed(ctx, req.Region, req.GameMode, match)
+
+	return match, nil
+}
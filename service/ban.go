@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// ErrPlayerBanned возвращается, если игроку запрещен вход в очередь
+var ErrPlayerBanned = errors.New("player is banned")
+
+// PlayerBannedError сообщает, до какого времени действует бан игрока.
+// Проверяется через errors.Is(err, ErrPlayerBanned).
+type PlayerBannedError struct {
+	PlayerID    string
+	BannedUntil time.Time
+}
+
+// Error реализует интерфейс error
+func (e *PlayerBannedError) Error() string {
+	return fmt.Sprintf("player %s is banned until %s", e.PlayerID, e.BannedUntil.UTC().Format(time.RFC3339))
+}
+
+// Unwrap позволяет сравнивать ошибку с ErrPlayerBanned
+func (e *PlayerBannedError) Unwrap() error {
+	return ErrPlayerBanned
+}
+
+// BanPlayer запрещает игроку вход в очередь на время duration.
+// Если игрок сейчас в очереди, он из нее удаляется.
+func (s *MatcherService) BanPlayer(ctx context.Context, playerID string, duration time.Duration) (time.Time, error) {
+	ctx, span := startSpan(ctx, "BanPlayer", attribute.String("player_id", playerID))
+	defer span.End()
+
+	if duration <= 0 {
+		return time.Time{}, fmt.Errorf("ban duration must be positive")
+	}
+
+	if err := s.storage.BanPlayer(ctx, playerID, duration); err != nil {
+		return time.Time{}, err
+	}
+
+	if _, err := s.storage.GetPlayerByID(ctx, playerID); err == nil {
+		if err := s.removePlayerFromQueue(ctx, playerID); err != nil {
+			logging.FromContext(ctx).Warn("Failed to remove banned player from queue",
+				zap.String("player_id", playerID),
+				zap.Error(err),
+			)
+		}
+	}
+
+	return s.storage.GetBanExpiry(ctx, playerID)
+}
+
+// checkBan возвращает *PlayerBannedError, если у игрока действует бан
+func (s *MatcherService) checkBan(ctx context.Context, playerID string) error {
+	banned, err := s.storage.IsBanned(ctx, playerID)
+	if err != nil {
+		return err
+	}
+	if !banned {
+		return nil
+	}
+
+	bannedUntil, err := s.storage.GetBanExpiry(ctx, playerID)
+	if err != nil {
+		return err
+	}
+	return &PlayerBannedError{PlayerID: playerID, BannedUntil: bannedUntil}
+}
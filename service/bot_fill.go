@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// botIDPrefix префикс ID ботов, чтобы их было видно в логах и ответах без разбора JSON
+const botIDPrefix = "bot_"
+
+// BotFactory создает ботов, которыми добираются матчи малолюдных очередей. Поведением
+// бота в игре управляет игровой сервер; матчмейкеру нужен только его рейтинг.
+type BotFactory struct {
+	matcher *MatcherService // Конфигурация очередей
+}
+
+// NewBotFactory создает фабрику ботов для очередей матчмейкера
+func NewBotFactory(matcher *MatcherService) *BotFactory {
+	return &BotFactory{matcher: matcher}
+}
+
+// GenerateBot создает бота для очереди региона и режима со случайным рейтингом, который
+// отличается от anchorRating не больше чем на MaxRatingDiff очереди. Берется
+// настроенное значение, а не расширенное для малолюдной очереди: бот нужен как раз
+// тогда, когда очередь малолюдна, и с расширенным диапазоном был бы заметно слабее
+// или сильнее игроков.
+func (f *BotFactory) GenerateBot(region, gameMode string, anchorRating int) *models.Player {
+	diff := f.matcher.queueConfig(region, gameMode).MaxRatingDiff
+	rating := anchorRating
+	if diff > 0 {
+		rating += rand.Intn(2*diff+1) - diff
+	}
+
+	bot := models.NewPlayer(rating, region, gameMode, 0)
+	bot.ID = botIDPrefix + uuid.New().String()
+	bot.IsBotPlayer = true
+	bot.WinRate = 0.5
+	return bot
+}
+
+// tryBotFillMatch собирает матч с ботом, если среди игроков очереди нашлась группа
+// на одного игрока меньше матча, а самый старый из них ждет дольше BotFillMinWait.
+// Группы (party) ботом не добираются. Матч с ботом создается сразу, даже при
+// MatchConfirmationEnabled: бот не может его подтвердить. Возвращает nil, если матч
+// не собран.
+func (s *MatcherService) tryBotFillMatch(ctx context.Context, region, gameMode string, players []*models.Player) (*models.Match, error) {
+	config := s.configForContext(region, gameMode)
+	if !config.BotFillEnabled {
+		return nil, nil
+	}
+
+	solo := make([]*models.Player, 0, len(players))
+	for _, p := range players {
+		if p.PartyID == "" && !p.IsBotPlayer {
+			solo = append(solo, p)
+		}
+	}
+	if len(solo) == 0 {
+		return nil, nil
+	}
+	sort.Slice(solo, func(i, j int) bool {
+		return solo[i].JoinedAt.Before(solo[j].JoinedAt)
+	})
+
+	anchor := solo[0]
+	if time.Since(anchor.JoinedAt) < config.BotFillMinWait {
+		return nil, nil
+	}
+
+	playersPerMatch, err := s.GetPlayersPerMatch(ctx, gameMode)
+	if err != nil {
+		return nil, err
+	}
+
+	group := []*models.Player{anchor}
+	for _, candidate := range solo[1:] {
+		if len(group) >= playersPerMatch-1 {
+			break
+		}
+		if s.isSkillCompatible(anchor, candidate) && voiceCompatibleWithGroup(group, candidate) {
+			group = append(group, candidate)
+		}
+	}
+	if len(group) != playersPerMatch-1 {
+		return nil, nil
+	}
+
+	bot := s.bots.GenerateBot(region, gameMode, anchor.Rating)
+	matchPlayers := make([]models.Player, 0, playersPerMatch)
+	for _, p := range group {
+		matchPlayers = append(matchPlayers, *p)
+	}
+	matchPlayers = append(matchPlayers, *bot)
+
+	match := s.buildMatch(matchPlayers)
+	s.assignServer(ctx, match)
+	if err := s.formMatch(ctx, match); err != nil {
+		s.releaseServer(ctx, match)
+		return nil, err
+	}
+
+	logging.FromContext(ctx).Info("Match filled with bot",
+		zap.String("match_id", match.MatchID),
+		zap.String("region", region),
+		zap.String("game_mode", gameMode),
+		zap.String("bot_id", bot.ID),
+		zap.Int("bot_rating", bot.Rating),
+	)
+
+	if err := s.createLobbyInGameService(ctx, match); err != nil {
+		logging.FromContext(ctx).Warn("Failed to create lobby in game-service",
+			zap.String("match_id", match.MatchID),
+			zap.Error(err),
+		)
+	}
+
+	s.recordMatchStats(ctx, region, gameMode, match)
+	s.publishMatchFormed(ctx, region, gameMode, match)
+
+	return match, nil
+}
+
+// fillWithBot вызывает tryBotFillMatch и пишет ошибку в лог: проход ProcessQueue из-за
+// нее не прерывается
+func (s *MatcherService) fillWithBot(ctx context.Context, region, gameMode string, players []*models.Player) {
+	if _, err := s.tryBotFillMatch(ctx, region, gameMode, players); err != nil {
+		logging.FromContext(ctx).Warn("Failed to create match with bot",
+			zap.String("region", region),
+			zap.String("game_mode", gameMode),
+			zap.Error(err),
+		)
+	}
+}
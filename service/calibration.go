@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/storage"
+	"go.uber.org/zap"
+)
+
+// calibrationMaxRatingDiff допустимая разница рейтинга калибрующихся игроков: их
+// рейтинг еще ничего не говорит об уровне игры
+const calibrationMaxRatingDiff = 500
+
+// maxRatingDiff возвращает допустимую разницу рейтинга для игрока: MaxRatingDiff его
+// очереди, для калибрующегося игрока — не меньше calibrationMaxRatingDiff
+func (s *MatcherService) maxRatingDiff(player *models.Player) int {
+	diff := s.configForContext(player.Region, player.GameMode).MaxRatingDiff
+	if player.IsCalibrating && diff < calibrationMaxRatingDiff {
+		return calibrationMaxRatingDiff
+	}
+	return diff
+}
+
+// playedMatches возвращает количество матчей игрока с отправленным результатом
+func playedMatches(stats map[string]float64) int {
+	return int(stats[storage.StatWins] + stats[storage.StatLosses])
+}
+
+// markCalibrating отмечает игрока, сыгравшего меньше CalibrationGames матчей: такой
+// игрок ждет в очереди queue:calibration:{region}:{game_mode} и подбирается только к
+// таким же игрокам с разницей рейтинга до 500. Сыгранными считаются матчи с
+// отправленным результатом. При ошибке хранилища игрок считается откалиброванным.
+func (s *MatcherService) markCalibrating(ctx context.Context, player *models.Player) {
+	calibrationGames := s.currentConfig().CalibrationGames
+	if calibrationGames <= 0 || player.IsSuspicious {
+		return
+	}
+
+	stats, err := s.storage.GetPlayerStats(ctx, player.ID)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to check calibration progress",
+			zap.String("player_id", player.ID),
+			zap.Error(err),
+		)
+		return
+	}
+
+	played := playedMatches(stats)
+	if played >= calibrationGames {
+		return
+	}
+	player.IsCalibrating = true
+	player.CalibratingMatches = played
+}
+
+// completeCalibrations учитывает результат матча в калибровке его калибрующихся
+// игроков. Игроку, для которого матч стал последним калибровочным, в players
+// записывается рейтинг ELOCalculator.ProvisionalRating по его победам и поражениям
+// за калибровку. Хранилище не меняется: статистику калибровки после записи
+// рейтингов сохраняет recordCalibrationMatches. Вызывается до записи результата в
+// статистику. Возвращает ID игроков, завершивших калибровку, и средний рейтинг
+// соперников каждого калибрующегося игрока в этом матче.
+func (s *MatcherService) completeCalibrations(ctx context.Context, match *models.Match, winnerIDs []string, players []models.Player) (map[string]bool, map[string]float64) {
+	calibrationGames := s.currentConfig().CalibrationGames
+	if calibrationGames <= 0 {
+		return nil, nil
+	}
+
+	winners := make(map[string]bool, len(winnerIDs))
+	for _, id := range winnerIDs {
+		winners[id] = true
+	}
+	teams := matchTeams(match)
+
+	completed := make(map[string]bool)
+	opponentRatings := make(map[string]float64)
+	for i, p := range match.Players {
+		if !p.IsCalibrating {
+			continue
+		}
+
+		// Средний рейтинг соперников до матча
+		opponentRating := 0.0
+		for t, team := range teams {
+			if !teamHasPlayer(team, p.ID) || len(teams[1-t]) == 0 {
+				continue
+			}
+			for _, opponent := range teams[1-t] {
+				opponentRating += float64(opponent.Rating)
+			}
+			opponentRating /= float64(len(teams[1-t]))
+		}
+		opponentRatings[p.ID] = opponentRating
+
+		stats, err := s.storage.GetPlayerStats(ctx, p.ID)
+		if err != nil {
+			logging.FromContext(ctx).Warn("Failed to get calibration stats",
+				zap.String("match_id", match.MatchID),
+				zap.String("player_id", p.ID),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		wins, losses := int(stats[storage.StatWins]), int(stats[storage.StatLosses])
+		if winners[p.ID] {
+			wins++
+		} else {
+			losses++
+		}
+		games := wins + losses
+		if games < calibrationGames {
+			continue
+		}
+
+		averageOpponent := int(stats[storage.StatCalibrationOpponentRating]+opponentRating) / games
+		players[i].Rating = NewELOCalculator(nil).ProvisionalRating(averageOpponent, wins, losses)
+		completed[p.ID] = true
+
+		logging.FromContext(ctx).Info("Player calibration completed",
+			zap.String("player_id", p.ID),
+			zap.Int("wins", wins),
+			zap.Int("losses", losses),
+			zap.Int("rating", players[i].Rating),
+		)
+	}
+	return completed, opponentRatings
+}
+
+// recordCalibrationMatches добавляет средний рейтинг соперников из completeCalibrations
+// в статистику калибровки игроков
+func (s *MatcherService) recordCalibrationMatches(ctx context.Context, matchID string, opponentRatings map[string]float64) {
+	for playerID, opponentRating := range opponentRatings {
+		if err := s.storage.IncrementStat(ctx, playerID, storage.StatCalibrationOpponentRating, opponentRating); err != nil {
+			logging.FromContext(ctx).Warn("Failed to record calibration match",
+				zap.String("match_id", matchID),
+				zap.String("player_id", playerID),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// teamHasPlayer проверяет, что игрок входит в команду
+func teamHasPlayer(team []models.Player, playerID string) bool {
+	for _, p := range team {
+		if p.ID == playerID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/storage"
+	"go.uber.org/zap"
+)
+
+// TestCalibrationRatingKeptOnNextJoin проверяет, что рейтинг по итогам калибровки
+// действует при следующем входе в очередь, а не заменяется рейтингом клиента
+func TestCalibrationRatingKeptOnNextJoin(t *testing.T) {
+	ctx := context.Background()
+	config := DefaultMatcherConfig()
+	config.CalibrationGames = 1
+	matcher := NewMatcherService(storage.NewInMemoryStorage(), zap.NewNop(), config)
+
+	join := func(id string, rating int) {
+		t.Helper()
+		player := &models.Player{ID: id, Rating: rating, Region: "EU", GameMode: "1v1"}
+		if err := matcher.AddPlayerToQueue(ctx, player); err != nil {
+			t.Fatalf("AddPlayerToQueue(%s): %v", id, err)
+		}
+	}
+	join("winner", 1500)
+	join("loser", 1500)
+
+	if err := matcher.ProcessQueue(ctx, "EU", "1v1"); err != nil {
+		t.Fatalf("ProcessQueue: %v", err)
+	}
+	match, err := matcher.GetPlayerMatch(ctx, "winner")
+	if err != nil {
+		t.Fatalf("GetPlayerMatch: %v", err)
+	}
+	for _, p := range match.Players {
+		if !p.IsCalibrating {
+			t.Fatalf("player %s is not calibrating", p.ID)
+		}
+	}
+
+	players, err := matcher.ReportMatchResult(ctx, match.MatchID, []string{"winner"})
+	if err != nil {
+		t.Fatalf("ReportMatchResult: %v", err)
+	}
+	provisional := 0
+	for _, p := range players {
+		if p.ID == "winner" {
+			provisional = p.Rating
+		}
+	}
+	if want := NewELOCalculator(nil).ProvisionalRating(1500, 1, 0); provisional != want {
+		t.Fatalf("provisional rating = %d, want %d", provisional, want)
+	}
+
+	join("winner", 1000)
+	queued, err := matcher.storage.GetPlayerByID(ctx, "winner")
+	if err != nil {
+		t.Fatalf("GetPlayerByID: %v", err)
+	}
+	if queued.Rating != provisional {
+		t.Errorf("rating on next join = %d, want provisional %d", queued.Rating, provisional)
+	}
+	if queued.IsCalibrating {
+		t.Error("player is still calibrating after the last calibration match")
+	}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"math"
+
+	"chrono-matchmaking/models"
+)
+
+// DefaultCompositionRules возвращает требования к ролям по умолчанию:
+// в 3v3 у каждой команды есть танк, лекарь и боец
+func DefaultCompositionRules() map[string]models.CompositionRule {
+	return map[string]models.CompositionRule{
+		"3v3": {Required: map[string]int{
+			models.RoleTank:   1,
+			models.RoleHealer: 1,
+			models.RoleDPS:    1,
+		}},
+	}
+}
+
+// hasCompositionRule проверяет, заданы ли требования к ролям для режима
+func (s *MatcherService) hasCompositionRule(gameMode string) bool {
+	_, ok := s.currentConfig().CompositionRules[gameMode]
+	return ok
+}
+
+// teamSatisfiesRule проверяет, что в команде хватает игроков каждой роли.
+// Игроки без роли могут занять любую недостающую.
+func teamSatisfiesRule(team []*models.Player, rule models.CompositionRule) bool {
+	counts := make(map[string]int, len(rule.Required))
+	flex := 0
+	for _, p := range team {
+		if p.Role == "" {
+			flex++
+			continue
+		}
+		counts[p.Role]++
+	}
+
+	deficit := 0
+	for role, required := range rule.Required {
+		if counts[role] < required {
+			deficit += required - counts[role]
+		}
+	}
+	return deficit <= flex
+}
+
+// validateComposition проверяет, что обе команды группы (первая и вторая половины)
+// удовлетворяют требованиям к ролям
+func validateComposition(group []*models.Player, rule models.CompositionRule) bool {
+	half := len(group) / 2
+	return teamSatisfiesRule(group[:half], rule) && teamSatisfiesRule(group[half:], rule)
+}
+
+// compositionFeasible проверяет, что недостающие роли еще можно добрать:
+// каждая роль нужна в обеих командах, а оставшиеся места и игроки без роли
+// должны покрыть нехватку
+func compositionFeasible(group []*models.Player, rule models.CompositionRule, teamSize int) bool {
+	counts := make(map[string]int, len(rule.Required))
+	flex := 0
+	for _, p := range group {
+		if p.Role == "" {
+			flex++
+			continue
+		}
+		counts[p.Role]++
+	}
+
+	deficit := 0
+	for role, required := range rule.Required {
+		if need := 2*required - counts[role]; need > 0 {
+			deficit += need
+		}
+	}
+	return deficit <= flex+2*teamSize-len(group)
+}
+
+// arrangeComposedTeams перебирает расстановки единиц подбора по командам и выбирает
+// ту, где обе команды удовлетворяют требованиям к ролям, а разница суммарного рейтинга
+// минимальна. Группы не разделяются. Возвращает игроков в порядке команд A, B.
+func arrangeComposedTeams(units [][]*models.Player, teamSize int, rule models.CompositionRule) ([]models.Player, bool) {
+	var best []*models.Player
+	bestDiff := math.MaxInt
+
+	// Единиц подбора не больше числа игроков в матче, поэтому полный перебор дешев
+	for mask := 0; mask < 1<<len(units); mask++ {
+		teamA := make([]*models.Player, 0, teamSize)
+		teamB := make([]*models.Player, 0, teamSize)
+		ratingDiff := 0
+		for i, unit := range units {
+			for _, p := range unit {
+				if mask&(1<<i) != 0 {
+					teamA = append(teamA, p)
+					ratingDiff += p.Rating
+				} else {
+					teamB = append(teamB, p)
+					ratingDiff -= p.Rating
+				}
+			}
+		}
+		if len(teamA) != teamSize || len(teamB) != teamSize {
+			continue
+		}
+
+		candidate := append(teamA, teamB...)
+		if !validateComposition(candidate, rule) {
+			continue
+		}
+		if ratingDiff < 0 {
+			ratingDiff = -ratingDiff
+		}
+		if ratingDiff < bestDiff {
+			best, bestDiff = candidate, ratingDiff
+		}
+	}
+
+	if best == nil {
+		return nil, false
+	}
+
+	players := make([]models.Player, len(best))
+	for i, p := range best {
+		players[i] = *p
+	}
+	return players, true
+}
@@ -0,0 +1,549 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"go.uber.org/zap"
+)
+
+// ConfigValidationError содержит ошибки валидации по отдельным полям конфигурации
+type ConfigValidationError struct {
+	Fields map[string]string // Имя поля -> описание ошибки
+}
+
+// Error реализует интерфейс error
+func (e *ConfigValidationError) Error() string {
+	names := make([]string, 0, len(e.Fields))
+	for name := range e.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s: %s", name, e.Fields[name]))
+	}
+	return "invalid matcher config: " + strings.Join(parts, "; ")
+}
+
+// configFieldValidators проверяют значения отдельных полей MatcherConfig
+var configFieldValidators = map[string]func(cfg *MatcherConfig) string{
+	"MaxRatingDiff": func(cfg *MatcherConfig) string {
+		if cfg.MaxRatingDiff <= 0 {
+			return "must be positive"
+		}
+		return ""
+	},
+	"MaxSearchTime": func(cfg *MatcherConfig) string {
+		if cfg.MaxSearchTime <= 0 {
+			return "must be positive"
+		}
+		return ""
+	},
+	"RatingExpansionRate": func(cfg *MatcherConfig) string {
+		if cfg.RatingExpansionRate < 0 {
+			return "must not be negative"
+		}
+		return ""
+	},
+	"PlayersPerMatch": func(cfg *MatcherConfig) string {
+		if cfg.PlayersPerMatch <= 0 || cfg.PlayersPerMatch%2 != 0 {
+			return "must be a positive even number"
+		}
+		return ""
+	},
+	"AccountAgeMismatchPenalty": func(cfg *MatcherConfig) string {
+		if cfg.AccountAgeMismatchPenalty < 0 || cfg.AccountAgeMismatchPenalty > 1 {
+			return "must be between 0 and 1"
+		}
+		return ""
+	},
+	"VoiceGroupBonus": func(cfg *MatcherConfig) string {
+		if cfg.VoiceGroupBonus < 0 || cfg.VoiceGroupBonus > 1 {
+			return "must be between 0 and 1"
+		}
+		return ""
+	},
+	"AccountAgeMatchBonus": func(cfg *MatcherConfig) string {
+		if cfg.AccountAgeMatchBonus < 0 || cfg.AccountAgeMatchBonus > 1 {
+			return "must be between 0 and 1"
+		}
+		return ""
+	},
+	"MaxAccountAgeDiff": func(cfg *MatcherConfig) string {
+		if cfg.MaxAccountAgeDiff < 0 {
+			return "must not be negative"
+		}
+		return ""
+	},
+	"MaxWinRateDiff": func(cfg *MatcherConfig) string {
+		if cfg.MaxWinRateDiff < 0 || cfg.MaxWinRateDiff > 1 {
+			return "must be between 0 and 1"
+		}
+		return ""
+	},
+	"RateLimit": func(cfg *MatcherConfig) string {
+		if cfg.RateLimit < 0 {
+			return "must not be negative"
+		}
+		return ""
+	},
+	"Algorithm": func(cfg *MatcherConfig) string {
+		if _, err := NewMatchingAlgorithm(cfg.Algorithm); err != nil {
+			return "must be one of: greedy, stable, hungarian"
+		}
+		return ""
+	},
+	"LevelBrackets": func(cfg *MatcherConfig) string {
+		for i, b := range cfg.LevelBrackets {
+			if b.Min < 0 || (b.Max != 0 && b.Max < b.Min) {
+				return fmt.Sprintf("bracket %d: min must not be negative and max must be 0 or at least min", i)
+			}
+			if i > 0 {
+				prev := cfg.LevelBrackets[i-1]
+				if prev.Max == 0 || b.Min <= prev.Max {
+					return fmt.Sprintf("bracket %d: brackets must be ascending and must not overlap", i)
+				}
+			}
+		}
+		return ""
+	},
+	"CompositionRules": func(cfg *MatcherConfig) string {
+		for gameMode, rule := range cfg.CompositionRules {
+			total := 0
+			for role, count := range rule.Required {
+				if role == "" || !models.IsValidRole(role) {
+					return fmt.Sprintf("%s: unknown role %q", gameMode, role)
+				}
+				if count < 0 {
+					return fmt.Sprintf("%s: role counts must not be negative", gameMode)
+				}
+				total += count
+			}
+			// Размер команд режимов, добавленных через /admin/game_modes, здесь неизвестен
+			players, ok := DefaultGameModes()[gameMode]
+			if ok && total > players/2 {
+				return fmt.Sprintf("%s: required roles exceed team size", gameMode)
+			}
+		}
+		return ""
+	},
+	"AvoidRecentOpponentsDuration": func(cfg *MatcherConfig) string {
+		if cfg.AvoidRecentOpponentsDuration < 0 {
+			return "must not be negative"
+		}
+		return ""
+	},
+	"InactivityDecayThreshold": func(cfg *MatcherConfig) string {
+		if cfg.InactivityDecayThreshold < 0 {
+			return "must not be negative"
+		}
+		return ""
+	},
+	"DecayPercentage": func(cfg *MatcherConfig) string {
+		if cfg.DecayPercentage < 0 || cfg.DecayPercentage > 100 {
+			return "must be between 0 and 100"
+		}
+		return ""
+	},
+	"RatingFloor": func(cfg *MatcherConfig) string {
+		if cfg.RatingFloor < 0 {
+			return "must not be negative"
+		}
+		return ""
+	},
+	"MapPool": func(cfg *MatcherConfig) string {
+		seen := make(map[string]bool, len(cfg.MapPool))
+		for _, name := range cfg.MapPool {
+			if name == "" || seen[name] {
+				return "map names must be non-empty and unique"
+			}
+			seen[name] = true
+		}
+		return ""
+	},
+	"MapCompatibilityWeight": func(cfg *MatcherConfig) string {
+		if cfg.MapCompatibilityWeight < 0 || cfg.MapCompatibilityWeight > 1 {
+			return "must be between 0 and 1"
+		}
+		return ""
+	},
+	"SmurfWindowGames": func(cfg *MatcherConfig) string {
+		if cfg.SmurfWindowGames < 0 {
+			return "must not be negative"
+		}
+		return ""
+	},
+	"SmurfRatingThreshold": func(cfg *MatcherConfig) string {
+		if cfg.SmurfRatingThreshold <= 0 {
+			return "must be positive"
+		}
+		return ""
+	},
+	"MaxQueueSize": func(cfg *MatcherConfig) string {
+		if cfg.MaxQueueSize < 0 {
+			return "must not be negative"
+		}
+		return ""
+	},
+	"MinLanguageOverlap": func(cfg *MatcherConfig) string {
+		if cfg.MinLanguageOverlap <= 0 {
+			return "must be positive"
+		}
+		return ""
+	},
+	"MatchTTL": func(cfg *MatcherConfig) string {
+		if cfg.MatchTTL <= 0 {
+			return "must be positive"
+		}
+		return ""
+	},
+	"OffPeakHoursUTC": func(cfg *MatcherConfig) string {
+		for i, w := range cfg.OffPeakHoursUTC {
+			if w[0] < 0 || w[0] > 23 || w[1] < 0 || w[1] > 24 || w[0] == w[1] {
+				return fmt.Sprintf("window %d: start must be 0-23, end 0-24 and differ from start", i)
+			}
+		}
+		return ""
+	},
+	"OffPeakRatingMultiplier": func(cfg *MatcherConfig) string {
+		if cfg.OffPeakRatingMultiplier < 1 {
+			return "must be at least 1"
+		}
+		return ""
+	},
+	"MaxAbandonsBeforeFlag": func(cfg *MatcherConfig) string {
+		if cfg.MaxAbandonsBeforeFlag < 0 {
+			return "must not be negative"
+		}
+		return ""
+	},
+	"AbandonFlagDuration": func(cfg *MatcherConfig) string {
+		if cfg.AbandonFlagDuration <= 0 {
+			return "must be positive"
+		}
+		return ""
+	},
+	"CalibrationGames": func(cfg *MatcherConfig) string {
+		if cfg.CalibrationGames < 0 {
+			return "must not be negative"
+		}
+		return ""
+	},
+	"BotFillMinWait": func(cfg *MatcherConfig) string {
+		if cfg.BotFillMinWait < 0 {
+			return "must not be negative"
+		}
+		return ""
+	},
+	"MomentumBoost": func(cfg *MatcherConfig) string {
+		if cfg.MomentumBoost < 0 {
+			return "must not be negative"
+		}
+		return ""
+	},
+	"WorkerPoolSize": func(cfg *MatcherConfig) string {
+		if cfg.WorkerPoolSize <= 0 {
+			return "must be positive"
+		}
+		return ""
+	},
+	"MaxWorkers": func(cfg *MatcherConfig) string {
+		if cfg.MaxWorkers <= 0 {
+			return "must be positive"
+		}
+		return ""
+	},
+	"RegionLatencyMatrix": func(cfg *MatcherConfig) string {
+		for _, row := range cfg.RegionLatencyMatrix {
+			for _, latency := range row {
+				if latency < 0 {
+					return "latencies must not be negative"
+				}
+			}
+		}
+		return ""
+	},
+}
+
+// GetMatcherConfig возвращает копию текущей конфигурации матчмейкера
+func (s *MatcherService) GetMatcherConfig() MatcherConfig {
+	return *s.currentConfig()
+}
+
+// configUpdatesChannel канал pub/sub, по которому PATCH конфигурации рассылается репликам
+const configUpdatesChannel = "config:matcher:updates"
+
+// startupOnlyConfigFields поля, которые читаются только при запуске сервиса: алгоритм
+// подбора, лимит запросов и пул обработчиков очередей создаются один раз. Частичное
+// обновление их отклоняет, чтобы ответ не сообщал об изменении, которое не действует.
+var startupOnlyConfigFields = map[string]bool{
+	"Algorithm":      true,
+	"RateLimit":      true,
+	"WorkerPoolSize": true,
+}
+
+// ConfigUpdateEvent частичное обновление конфигурации, разосланное репликам
+type ConfigUpdateEvent struct {
+	InstanceID string                 `json:"instance_id"` // Реплика, принявшая PATCH
+	Patch      map[string]interface{} `json:"patch"`
+}
+
+// UpdateMatcherConfigPartial применяет к текущей конфигурации только переданные поля
+// и рассылает патч остальным репликам (см. WatchConfigUpdates).
+// Ключи patch могут быть как именами полей (MaxRatingDiff), так и JSON-именами
+// (max_rating_diff). Длительности принимаются строкой ("5m") или числом наносекунд.
+// Поля, которые действуют только после перезапуска (Algorithm, RateLimit,
+// WorkerPoolSize), не принимаются. При ошибке валидации возвращается
+// *ConfigValidationError, и конфигурация не меняется.
+func (s *MatcherService) UpdateMatcherConfigPartial(ctx context.Context, patch map[string]interface{}) error {
+	ctx, span := startSpan(ctx, "UpdateMatcherConfigPartial")
+	defer span.End()
+
+	errs := make(map[string]string)
+	for key := range patch {
+		if field, ok := lookupConfigField(reflect.TypeOf(MatcherConfig{}), key); ok && startupOnlyConfigFields[field.Name] {
+			errs[field.Name] = "requires restart, change it in the config file"
+		}
+	}
+	if len(errs) > 0 {
+		return &ConfigValidationError{Fields: errs}
+	}
+
+	current := s.currentConfig()
+	updated := *current
+
+	applied, errs := applyConfigPatch(&updated, patch)
+	if len(errs) > 0 {
+		return &ConfigValidationError{Fields: errs}
+	}
+
+	// CompareAndSwap защищает от потери параллельного обновления
+	if !s.config.CompareAndSwap(current, &updated) {
+		return fmt.Errorf("matcher config was modified concurrently, retry the update")
+	}
+	s.storage.SetMaxQueueSize(updated.MaxQueueSize)
+
+	sort.Strings(applied)
+	logging.FromContext(ctx).Info("Matcher config updated",
+		zap.Strings("fields", applied),
+	)
+
+	s.publishConfigUpdate(ctx, patch)
+	return nil
+}
+
+// publishConfigUpdate рассылает примененный патч конфигурации остальным репликам.
+// Без координатора сервис работает в одном экземпляре, и рассылать некому.
+func (s *MatcherService) publishConfigUpdate(ctx context.Context, patch map[string]interface{}) {
+	if s.coordinator == nil {
+		return
+	}
+
+	payload, err := json.Marshal(ConfigUpdateEvent{InstanceID: s.coordinator.InstanceID(), Patch: patch})
+	if err == nil {
+		err = s.storage.Publish(ctx, configUpdatesChannel, payload)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to publish matcher config update",
+			zap.Error(err),
+		)
+	}
+}
+
+// WatchConfigUpdates применяет патчи конфигурации, принятые другими репликами
+// (UpdateMatcherConfigPartial), к конфигурации этой реплики. Блокируется до отмены ctx.
+func (s *MatcherService) WatchConfigUpdates(ctx context.Context) error {
+	if s.coordinator == nil {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	messages, err := s.storage.Subscribe(ctx, configUpdatesChannel)
+	if err != nil {
+		return err
+	}
+
+	for payload := range messages {
+		var event ConfigUpdateEvent
+		if err := json.Unmarshal(payload, &event); err != nil {
+			s.logger.Warn("Failed to unmarshal matcher config update", zap.Error(err))
+			continue
+		}
+		if event.InstanceID == s.coordinator.InstanceID() {
+			continue // Собственный патч уже применен
+		}
+		s.applyConfigUpdate(event)
+	}
+
+	return ctx.Err()
+}
+
+// applyConfigUpdate применяет патч другой реплики. Патч уже проверен на ней, поэтому
+// ошибка здесь означает расхождение версий реплик и только пишется в лог.
+func (s *MatcherService) applyConfigUpdate(event ConfigUpdateEvent) {
+	for {
+		current := s.currentConfig()
+		updated := *current
+
+		applied, errs := applyConfigPatch(&updated, event.Patch)
+		if len(errs) > 0 {
+			s.logger.Warn("Rejected matcher config update from another replica",
+				zap.String("instance_id", event.InstanceID),
+				zap.Error(&ConfigValidationError{Fields: errs}),
+			)
+			return
+		}
+
+		// Параллельное локальное изменение: применяем патч поверх него
+		if !s.config.CompareAndSwap(current, &updated) {
+			continue
+		}
+		s.storage.SetMaxQueueSize(updated.MaxQueueSize)
+
+		sort.Strings(applied)
+		s.logger.Info("Matcher config updated by another replica",
+			zap.String("instance_id", event.InstanceID),
+			zap.Strings("fields", applied),
+		)
+		return
+	}
+}
+
+// UpdateConfig целиком заменяет конфигурацию матчмейкера. Новые значения
+// начинают действовать со следующего цикла обработки очереди.
+// При ошибке валидации возвращается *ConfigValidationError, и конфигурация не меняется.
+func (s *MatcherService) UpdateConfig(cfg *MatcherConfig) error {
+	if errs := validateMatcherConfig(cfg); len(errs) > 0 {
+		return &ConfigValidationError{Fields: errs}
+	}
+
+	updated := *cfg
+	s.config.Store(&updated)
+	s.storage.SetMaxQueueSize(updated.MaxQueueSize)
+
+	s.logger.Info("Matcher config replaced",
+		zap.Int("max_rating_diff", updated.MaxRatingDiff),
+		zap.Int("rating_expansion_rate", updated.RatingExpansionRate),
+		zap.Duration("max_search_time", updated.MaxSearchTime),
+	)
+
+	return nil
+}
+
+// ParseMatcherConfig разбирает JSON конфигурации матчмейкера. Отсутствующие поля
+// берутся из DefaultMatcherConfig, ключи и значения — как в UpdateMatcherConfigPartial.
+func ParseMatcherConfig(data []byte) (*MatcherConfig, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid matcher config json: %w", err)
+	}
+
+	cfg := DefaultMatcherConfig()
+	if _, errs := applyConfigPatch(cfg, raw); len(errs) > 0 {
+		return nil, &ConfigValidationError{Fields: errs}
+	}
+	return cfg, nil
+}
+
+// configStatusFields поля состояния из ответа GET /admin/config. В патче они
+// пропускаются, чтобы ответ можно было без изменений отправить в PUT.
+var configStatusFields = map[string]bool{
+	"off_peak_active": true,
+}
+
+// applyConfigPatch записывает значения patch в cfg и проверяет измененные поля.
+// Возвращает имена примененных полей и ошибки по остальным.
+func applyConfigPatch(cfg *MatcherConfig, patch map[string]interface{}) ([]string, map[string]string) {
+	errs := make(map[string]string)
+	applied := make([]string, 0, len(patch))
+
+	target := reflect.ValueOf(cfg).Elem()
+	for key, value := range patch {
+		if configStatusFields[key] {
+			continue
+		}
+		field, ok := lookupConfigField(target.Type(), key)
+		if !ok {
+			errs[key] = "unknown field"
+			continue
+		}
+
+		if err := setConfigField(target.FieldByIndex(field.Index), value); err != nil {
+			errs[field.Name] = err.Error()
+			continue
+		}
+
+		if validate, ok := configFieldValidators[field.Name]; ok {
+			if msg := validate(cfg); msg != "" {
+				errs[field.Name] = msg
+				continue
+			}
+		}
+		applied = append(applied, field.Name)
+	}
+
+	return applied, errs
+}
+
+// validateMatcherConfig проверяет все поля конфигурации
+func validateMatcherConfig(cfg *MatcherConfig) map[string]string {
+	errs := make(map[string]string)
+	for name, validate := range configFieldValidators {
+		if msg := validate(cfg); msg != "" {
+			errs[name] = msg
+		}
+	}
+	return errs
+}
+
+// lookupConfigField ищет поле MatcherConfig по имени или JSON-тегу
+func lookupConfigField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
+		if strings.EqualFold(field.Name, key) || (jsonName != "" && jsonName == key) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// setConfigField записывает значение из JSON-патча в поле конфигурации
+func setConfigField(field reflect.Value, value interface{}) error {
+	// Длительности удобнее задавать строкой вида "30s"
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		if str, ok := value.(string); ok {
+			d, err := time.ParseDuration(str)
+			if err != nil {
+				return fmt.Errorf("invalid duration: %w", err)
+			}
+			field.SetInt(int64(d))
+			return nil
+		}
+	}
+
+	// Остальные типы приводим через JSON, чтобы поддержать числа, строки, срезы и карты
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("invalid value: %w", err)
+	}
+
+	ptr := reflect.New(field.Type())
+	if err := json.Unmarshal(raw, ptr.Interface()); err != nil {
+		return fmt.Errorf("invalid value for type %s", field.Type())
+	}
+	field.Set(ptr.Elem())
+	return nil
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MatcherConfigOverride значения MatcherConfig для отдельной очереди. Заданы только
+// непустые поля, остальные берутся из базовой конфигурации. Поля называются так же,
+// как в MatcherConfig; глобальные настройки (RateLimit, Algorithm, RegionLatencyMatrix,
+// снижение рейтинга и т.п.) для очереди не переопределяются.
+type MatcherConfigOverride struct {
+	MaxRatingDiff       *int           `json:"max_rating_diff,omitempty"`
+	MaxSearchTime       *time.Duration `json:"max_search_time,omitempty"`
+	RatingExpansionRate *int           `json:"rating_expansion_rate,omitempty"`
+
+	AccountAgeMismatchPenalty *float64       `json:"account_age_mismatch_penalty,omitempty"`
+	AccountAgeMatchBonus      *float64       `json:"account_age_match_bonus,omitempty"`
+	MaxAccountAgeDiff         *time.Duration `json:"max_account_age_diff,omitempty"`
+
+	AutoPurgeEnabled         *bool `json:"auto_purge_enabled,omitempty"`
+	MatchConfirmationEnabled *bool `json:"match_confirmation_enabled,omitempty"`
+
+	MaxWinRateDiff *float64 `json:"max_win_rate_diff,omitempty"`
+
+	CrossRegionFallback *bool     `json:"cross_region_fallback,omitempty"`
+	FallbackRegions     *[]string `json:"fallback_regions,omitempty"`
+
+	LevelBrackets *[]LevelBracket `json:"level_brackets,omitempty"`
+
+	AvoidRecentOpponentsDuration *time.Duration `json:"avoid_recent_opponents_duration,omitempty"`
+
+	MapPool                *[]string `json:"map_pool,omitempty"`
+	MapCompatibilityWeight *float64  `json:"map_compatibility_weight,omitempty"`
+
+	SmurfWindowGames     *int `json:"smurf_window_games,omitempty"`
+	SmurfRatingThreshold *int `json:"smurf_rating_threshold,omitempty"`
+
+	LanguageMatchingEnabled *bool `json:"language_matching_enabled,omitempty"`
+	MinLanguageOverlap      *int  `json:"min_language_overlap,omitempty"`
+
+	BotFillEnabled *bool          `json:"bot_fill_enabled,omitempty"`
+	BotFillMinWait *time.Duration `json:"bot_fill_min_wait,omitempty"`
+}
+
+// UnmarshalJSON разбирает переопределение с теми же правилами, что и патч конфигурации:
+// ключи — имена полей или JSON-имена, длительности — строкой ("30s") или числом наносекунд
+func (o *MatcherConfigOverride) UnmarshalJSON(data []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	target := reflect.ValueOf(o).Elem()
+	for key, value := range raw {
+		field, ok := lookupConfigField(target.Type(), key)
+		if !ok {
+			return fmt.Errorf("unknown override field %q", key)
+		}
+		ptr := reflect.New(field.Type.Elem())
+		if err := setConfigField(ptr.Elem(), value); err != nil {
+			return fmt.Errorf("%s: %w", field.Name, err)
+		}
+		target.FieldByIndex(field.Index).Set(ptr)
+	}
+	return nil
+}
+
+// apply записывает заданные поля переопределения в cfg. Возвращает имена записанных полей.
+func (o *MatcherConfigOverride) apply(cfg *MatcherConfig) []string {
+	source := reflect.ValueOf(o).Elem()
+	target := reflect.ValueOf(cfg).Elem()
+
+	fields := make([]string, 0, source.NumField())
+	for i := 0; i < source.NumField(); i++ {
+		value := source.Field(i)
+		if value.IsNil() {
+			continue
+		}
+		name := source.Type().Field(i).Name
+		target.FieldByName(name).Set(value.Elem())
+		fields = append(fields, name)
+	}
+	return fields
+}
+
+// overrideKey возвращает ключ MatcherConfig.Overrides для очереди
+func overrideKey(region, gameMode string) string {
+	return region + ":" + gameMode
+}
+
+// validateConfigOverrides проверяет формат ключей переопределений и значения каждого
+// переопределения теми же правилами, что и поля базовой конфигурации
+func validateConfigOverrides(cfg *MatcherConfig) string {
+	keys := make([]string, 0, len(cfg.Overrides))
+	for key := range cfg.Overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		region, gameMode, ok := strings.Cut(key, ":")
+		if !ok || region == "" || gameMode == "" {
+			return fmt.Sprintf("key %q must have the form region:game_mode", key)
+		}
+		override := cfg.Overrides[key]
+		if override == nil {
+			continue
+		}
+
+		merged := *cfg
+		for _, name := range override.apply(&merged) {
+			validate, ok := configFieldValidators[name]
+			if !ok {
+				continue
+			}
+			if msg := validate(&merged); msg != "" {
+				return fmt.Sprintf("%s: %s %s", key, name, msg)
+			}
+		}
+	}
+	return ""
+}
+
+func init() {
+	// Регистрируется здесь: валидатор сам обращается к configFieldValidators
+	configFieldValidators["Overrides"] = validateConfigOverrides
+}
+
+// resolvedConfigs базовая конфигурация и собранные из нее конфигурации очередей
+type resolvedConfigs struct {
+	base    *MatcherConfig
+	byQueue map[string]*MatcherConfig
+}
+
+// configForContext возвращает конфигурацию для очереди региона и режима: копию текущей
+// конфигурации с примененным переопределением "{region}:{gameMode}", если оно задано.
+// Пока по очереди идет ProcessQueue, возвращается подстроенная под число игроков
+// конфигурация этого прохода. Возвращаемое значение нельзя изменять.
+func (s *MatcherService) configForContext(region, gameMode string) *MatcherConfig {
+	if cfg, ok := s.runConfigs.Load(overrideKey(region, gameMode)); ok {
+		return cfg.(*MatcherConfig)
+	}
+	return s.queueConfig(region, gameMode)
+}
+
+// queueConfig возвращает конфигурацию очереди с переопределением, но без подстройки
+// прохода ProcessQueue
+func (s *MatcherService) queueConfig(region, gameMode string) *MatcherConfig {
+	base := s.currentConfig()
+
+	resolved := s.queueConfigs.Load()
+	if resolved == nil || resolved.base != base {
+		resolved = &resolvedConfigs{
+			base:    base,
+			byQueue: make(map[string]*MatcherConfig, len(base.Overrides)),
+		}
+		for key, override := range base.Overrides {
+			if override == nil {
+				continue
+			}
+			merged := *base
+			override.apply(&merged)
+			resolved.byQueue[key] = &merged
+		}
+		s.queueConfigs.Store(resolved)
+	}
+
+	if cfg, ok := resolved.byQueue[overrideKey(region, gameMode)]; ok {
+		return cfg
+	}
+	return base
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"chrono-matchmaking/coordinator"
+	"chrono-matchmaking/storage"
+	"go.uber.org/zap"
+)
+
+func TestUpdateMatcherConfigPartialKeepsOtherFields(t *testing.T) {
+	config := DefaultMatcherConfig()
+	config.MaxSearchTime = 7 * time.Minute
+	matcher := NewMatcherService(storage.NewInMemoryStorage(), zap.NewNop(), config)
+	before := matcher.GetMatcherConfig()
+
+	if err := matcher.UpdateMatcherConfigPartial(context.Background(), map[string]interface{}{"max_rating_diff": float64(250)}); err != nil {
+		t.Fatalf("UpdateMatcherConfigPartial: %v", err)
+	}
+
+	after := matcher.GetMatcherConfig()
+	if after.MaxRatingDiff != 250 {
+		t.Errorf("MaxRatingDiff = %d, want 250", after.MaxRatingDiff)
+	}
+	if after.MaxSearchTime != 7*time.Minute {
+		t.Errorf("MaxSearchTime = %v, want 7m kept", after.MaxSearchTime)
+	}
+	after.MaxRatingDiff = before.MaxRatingDiff
+	if !reflect.DeepEqual(after, before) {
+		t.Errorf("fields other than MaxRatingDiff changed:\nbefore %+v\nafter  %+v", before, after)
+	}
+}
+
+func TestUpdateMatcherConfigPartialKeys(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch map[string]interface{}
+		want  time.Duration
+	}{
+		{"field name with duration string", map[string]interface{}{"MaxSearchTime": "90s"}, 90 * time.Second},
+		{"json name with nanoseconds", map[string]interface{}{"max_search_time": float64(time.Minute)}, time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher := NewMatcherService(storage.NewInMemoryStorage(), zap.NewNop(), DefaultMatcherConfig())
+			if err := matcher.UpdateMatcherConfigPartial(context.Background(), tt.patch); err != nil {
+				t.Fatalf("UpdateMatcherConfigPartial: %v", err)
+			}
+			if got := matcher.GetMatcherConfig().MaxSearchTime; got != tt.want {
+				t.Errorf("MaxSearchTime = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateMatcherConfigPartialRejectsInvalidFields(t *testing.T) {
+	matcher := NewMatcherService(storage.NewInMemoryStorage(), zap.NewNop(), DefaultMatcherConfig())
+	before := matcher.GetMatcherConfig()
+
+	err := matcher.UpdateMatcherConfigPartial(context.Background(), map[string]interface{}{
+		"max_rating_diff":   float64(-1),
+		"players_per_match": float64(3),
+		"max_search_time":   "2m",
+		"no_such_field":     true,
+	})
+	var validationErr *ConfigValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("UpdateMatcherConfigPartial = %v, want *ConfigValidationError", err)
+	}
+	want := map[string]string{
+		"MaxRatingDiff":   "must be positive",
+		"PlayersPerMatch": "must be a positive even number",
+		"no_such_field":   "unknown field",
+	}
+	if !reflect.DeepEqual(validationErr.Fields, want) {
+		t.Errorf("field errors = %v, want %v", validationErr.Fields, want)
+	}
+
+	// Валидные поля отклоненного патча тоже не применяются
+	if after := matcher.GetMatcherConfig(); !reflect.DeepEqual(after, before) {
+		t.Errorf("config changed by a rejected patch:\nbefore %+v\nafter  %+v", before, after)
+	}
+}
+
+func TestUpdateMatcherConfigPartialRejectsStartupOnlyFields(t *testing.T) {
+	matcher := NewMatcherService(storage.NewInMemoryStorage(), zap.NewNop(), DefaultMatcherConfig())
+	before := matcher.GetMatcherConfig()
+
+	err := matcher.UpdateMatcherConfigPartial(context.Background(), map[string]interface{}{
+		"algorithm":        AlgorithmStable,
+		"RateLimit":        float64(10),
+		"worker_pool_size": float64(8),
+		"max_rating_diff":  float64(300),
+	})
+	var validationErr *ConfigValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("UpdateMatcherConfigPartial = %v, want *ConfigValidationError", err)
+	}
+	for _, field := range []string{"Algorithm", "RateLimit", "WorkerPoolSize"} {
+		if _, ok := validationErr.Fields[field]; !ok {
+			t.Errorf("no error for startup-only field %s: %v", field, validationErr.Fields)
+		}
+	}
+	if after := matcher.GetMatcherConfig(); !reflect.DeepEqual(after, before) {
+		t.Errorf("config changed by a rejected patch:\nbefore %+v\nafter  %+v", before, after)
+	}
+}
+
+// TestUpdateMatcherConfigPartialReachesOtherReplicas проверяет, что патч, принятый
+// одной репликой, применяется и на другой через pub/sub
+func TestUpdateMatcherConfigPartialReachesOtherReplicas(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := storage.NewInMemoryStorage()
+	replicas := make([]*MatcherService, 2)
+	for i := range replicas {
+		replicas[i] = NewMatcherService(store, zap.NewNop(), DefaultMatcherConfig())
+		replicas[i].SetCoordinator(coordinator.NewCoordinator(store, zap.NewNop()))
+	}
+	done := make(chan error, 1)
+	go func() { done <- replicas[1].WatchConfigUpdates(ctx) }()
+
+	// Подписка оформляется в фоне: повторяем патч, пока он не дойдет
+	deadline := time.Now().Add(2 * time.Second)
+	for replicas[1].GetMatcherConfig().MaxRatingDiff != 275 {
+		if time.Now().After(deadline) {
+			t.Fatalf("MaxRatingDiff on the other replica = %d, want 275", replicas[1].GetMatcherConfig().MaxRatingDiff)
+		}
+		if err := replicas[0].UpdateMatcherConfigPartial(ctx, map[string]interface{}{"max_rating_diff": float64(275)}); err != nil {
+			t.Fatalf("UpdateMatcherConfigPartial: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if got := replicas[1].GetMatcherConfig().MaxSearchTime; got != DefaultMatcherConfig().MaxSearchTime {
+		t.Errorf("MaxSearchTime on the other replica = %v, want the default kept", got)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("WatchConfigUpdates = %v, want context.Canceled", err)
+	}
+}
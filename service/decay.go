@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"math"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"go.uber.org/zap"
+)
+
+// RatingDecayJob снижает рейтинг игроков, вернувшихся в очередь после долгого перерыва,
+// чтобы они не растягивали ожидание игроков среднего рейтинга. Перерыв — время между
+// последним сыгранным матчем (last_active:{id}) и входом в очередь.
+type RatingDecayJob struct {
+	matcher *MatcherService
+	logger  *zap.Logger
+}
+
+// NewRatingDecayJob создает задачу снижения рейтинга с параметрами из конфигурации матчмейкера
+func NewRatingDecayJob(matcher *MatcherService, logger *zap.Logger) *RatingDecayJob {
+	return &RatingDecayJob{
+		matcher: matcher,
+		logger:  logger,
+	}
+}
+
+// Run обходит всех игроков в очереди и снижает рейтинг тем, чей перерыв длиннее
+// InactivityDecayThreshold, на DecayPercentage, но не ниже RatingFloor. После снижения
+// время последней активности сдвигается на вход в очередь, чтобы рейтинг за один
+// перерыв снижался только один раз.
+func (j *RatingDecayJob) Run(ctx context.Context) error {
+	config := j.matcher.currentConfig()
+	if config.InactivityDecayThreshold <= 0 || config.DecayPercentage <= 0 {
+		return nil
+	}
+
+	players, err := j.matcher.storage.GetAllPlayers(ctx)
+	if err != nil {
+		return err
+	}
+
+	decayed := 0
+	for _, player := range players {
+		ok, err := j.decayPlayer(ctx, player, config)
+		if err != nil {
+			logging.FromContext(ctx).Warn("Failed to decay player rating",
+				zap.String("player_id", player.ID),
+				zap.Error(err),
+			)
+			continue
+		}
+		if ok {
+			decayed++
+		}
+	}
+
+	logging.FromContext(ctx).Info("Rating decay finished",
+		zap.Int("players_checked", len(players)),
+		zap.Int("players_decayed", decayed),
+	)
+
+	return nil
+}
+
+// decayPlayer снижает рейтинг одного игрока, если его перерыв превысил порог
+func (j *RatingDecayJob) decayPlayer(ctx context.Context, player *models.Player, config *MatcherConfig) (bool, error) {
+	if player.Rating <= config.RatingFloor {
+		return false, nil
+	}
+
+	lastActive, err := j.matcher.storage.GetLastActive(ctx, player.ID)
+	if err != nil {
+		return false, err
+	}
+	if lastActive.IsZero() || player.JoinedAt.Sub(lastActive) <= config.InactivityDecayThreshold {
+		return false, nil
+	}
+
+	rating := int(math.Round(float64(player.Rating) * (1 - config.DecayPercentage/100)))
+	if rating < config.RatingFloor {
+		rating = config.RatingFloor
+	}
+
+	if err := j.matcher.storage.UpdatePlayerRating(ctx, player.ID, rating); err != nil {
+		return false, err
+	}
+	if err := j.matcher.storage.SetLastActive(ctx, player.ID, player.JoinedAt); err != nil {
+		return false, err
+	}
+
+	logging.FromContext(ctx).Info("Player rating decayed after inactivity",
+		zap.String("player_id", player.ID),
+		zap.Int("old_rating", player.Rating),
+		zap.Int("new_rating", rating),
+		zap.Duration("inactive_for", player.JoinedAt.Sub(lastActive)),
+	)
+
+	return true, nil
+}
+
+// recordActivity запоминает время матча как последнюю активность его участников.
+// Ошибки только логируются: активность не должна мешать созданию матча.
+func (s *MatcherService) recordActivity(ctx context.Context, match *models.Match) {
+	for _, player := range match.HumanPlayers() {
+		if err := s.storage.SetLastActive(ctx, player.ID, match.CreatedAt); err != nil {
+			logging.FromContext(ctx).Warn("Failed to record player activity",
+				zap.String("match_id", match.MatchID),
+				zap.String("player_id", player.ID),
+				zap.Error(err),
+			)
+		}
+	}
+}
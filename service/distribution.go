@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/metrics"
+	"chrono-matchmaking/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	distributionKLAlertThreshold = 0.1  // Расхождение, при котором алгоритм подозревается в перекосе
+	distributionSmoothing        = 1e-6 // Сглаживание пустых диапазонов, чтобы KL оставалась конечной
+)
+
+// DefaultDistributionBuckets границы диапазонов рейтинга по умолчанию
+func DefaultDistributionBuckets() []int {
+	return []int{0, 500, 1000, 1500, 2000, 2500, 3000}
+}
+
+// GetRatingDistributionComparison сравнивает распределение рейтинга игроков в очереди
+// с распределением игроков, попавших в матч за последние сутки. buckets — возрастающие
+// нижние границы диапазонов: рейтинг ниже первой границы попадает в первый диапазон,
+// последний диапазон не ограничен сверху. Высокая KL-дивергенция означает, что алгоритм
+// систематически пропускает часть рейтингов.
+func (s *MatcherService) GetRatingDistributionComparison(ctx context.Context, region, gameMode string, buckets []int) (*models.DistributionComparison, error) {
+	ctx, span := startSpan(ctx, "GetRatingDistributionComparison",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	if len(buckets) == 0 {
+		buckets = DefaultDistributionBuckets()
+	}
+	if !sort.IntsAreSorted(buckets) {
+		return nil, fmt.Errorf("buckets must be in ascending order")
+	}
+
+	queuePlayers, err := s.storage.GetQueuePlayers(ctx, region, gameMode)
+	if err != nil {
+		return nil, err
+	}
+	queueRatings := make([]int, 0, len(queuePlayers))
+	for _, p := range queuePlayers {
+		queueRatings = append(queueRatings, p.Rating)
+	}
+
+	matchedRatings, err := s.storage.GetMatchedRatings(ctx, region, gameMode)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := &models.DistributionComparison{
+		Region:           region,
+		GameMode:         gameMode,
+		Buckets:          distributionBucketLabels(buckets),
+		QueueHistogram:   ratingHistogram(queueRatings, buckets),
+		MatchedHistogram: ratingHistogram(matchedRatings, buckets),
+		QueuePlayers:     len(queueRatings),
+		MatchedPlayers:   len(matchedRatings),
+	}
+
+	// Без данных с одной из сторон сравнивать нечего
+	if len(queueRatings) > 0 && len(matchedRatings) > 0 {
+		comparison.KLDivergence = klDivergence(comparison.MatchedHistogram, comparison.QueueHistogram)
+	}
+
+	metrics.RatingDistributionKLDivergence.WithLabelValues(region, gameMode).Set(comparison.KLDivergence)
+	if comparison.KLDivergence > distributionKLAlertThreshold {
+		logging.FromContext(ctx).Warn("Matched rating distribution diverges from queue",
+			zap.String("region", region),
+			zap.String("game_mode", gameMode),
+			zap.Float64("kl_divergence", comparison.KLDivergence),
+		)
+	}
+
+	return comparison, nil
+}
+
+// ratingHistogram возвращает долю рейтингов в каждом диапазоне
+func ratingHistogram(ratings []int, buckets []int) []float64 {
+	histogram := make([]float64, len(buckets))
+	if len(ratings) == 0 {
+		return histogram
+	}
+
+	for _, rating := range ratings {
+		// Индекс последней границы, не превышающей рейтинг
+		i := sort.Search(len(buckets), func(i int) bool { return buckets[i] > rating }) - 1
+		if i < 0 {
+			i = 0
+		}
+		histogram[i]++
+	}
+
+	for i := range histogram {
+		histogram[i] /= float64(len(ratings))
+	}
+	return histogram
+}
+
+// klDivergence вычисляет KL(p || q) со сглаживанием пустых диапазонов
+func klDivergence(p, q []float64) float64 {
+	var divergence float64
+	for i := range p {
+		pi := (p[i] + distributionSmoothing) / (1 + distributionSmoothing*float64(len(p)))
+		qi := (q[i] + distributionSmoothing) / (1 + distributionSmoothing*float64(len(q)))
+		divergence += pi * math.Log(pi/qi)
+	}
+	return divergence
+}
+
+// distributionBucketLabels возвращает названия диапазонов вида "1000-1500" и "3000+"
+func distributionBucketLabels(buckets []int) []string {
+	labels := make([]string, len(buckets))
+	for i, lower := range buckets {
+		if i == len(buckets)-1 {
+			labels[i] = fmt.Sprintf("%d+", lower)
+			continue
+		}
+		labels[i] = fmt.Sprintf("%d-%d", lower, buckets[i+1])
+	}
+	return labels
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/metrics"
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/storage"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// matchFormationAttempts сколько раз подряд сохраняется матч группы, прежде чем она попадет в DLQ
+const matchFormationAttempts = 3
+
+// ErrDLQEntryNotFound возвращается, если записи DLQ с таким индексом нет
+var ErrDLQEntryNotFound = storage.ErrDLQEntryNotFound
+
+// formMatchWithRetry атомарно сохраняет матч группы из очереди. При ошибке хранилища
+// сохранение повторяется до трех раз, после чего группа записывается в DLQ.
+// ErrMatchConflict (кто-то из игроков уже покинул очередь) не повторяется.
+func (s *MatcherService) formMatchWithRetry(ctx context.Context, region, gameMode string, match *models.Match) error {
+	var err error
+	for attempt := 1; attempt <= matchFormationAttempts; attempt++ {
+		err = s.formMatch(ctx, match)
+		if err == nil || errors.Is(err, ErrMatchConflict) || ctx.Err() != nil {
+			return err
+		}
+	}
+
+	s.deadLetter(ctx, region, gameMode, match.Players, err)
+	return err
+}
+
+// deadLetter записывает в DLQ группу, матч которой не удалось сохранить
+func (s *MatcherService) deadLetter(ctx context.Context, region, gameMode string, players []models.Player, cause error) {
+	group := make([]*models.Player, len(players))
+	for i := range players {
+		group[i] = &players[i]
+	}
+
+	if err := s.storage.AddToDLQ(ctx, region, gameMode, group, cause.Error()); err != nil {
+		logging.FromContext(ctx).Error("Failed to add group to dlq",
+			zap.String("region", region),
+			zap.String("game_mode", gameMode),
+			zap.Error(err),
+		)
+		return
+	}
+
+	metrics.DLQEnqueuedTotal.WithLabelValues(region, gameMode).Inc()
+	logging.FromContext(ctx).Warn("Group moved to dlq after failed match formation",
+		zap.String("region", region),
+		zap.String("game_mode", gameMode),
+		zap.Int("players_count", len(group)),
+		zap.Int("attempts", matchFormationAttempts),
+		zap.Error(cause),
+	)
+}
+
+// GetDLQ возвращает группы в DLQ от старых к новым
+func (s *MatcherService) GetDLQ(ctx context.Context) ([]*models.DLQEntry, error) {
+	ctx, span := startSpan(ctx, "GetDLQ")
+	defer span.End()
+
+	return s.storage.GetDLQ(ctx)
+}
+
+// RetryDLQEntry заново собирает матч группы из DLQ и сохраняет его так же, как
+// при обработке очереди: только если все игроки группы еще в очереди. После
+// успешного сохранения запись удаляется из DLQ. Если кто-то из игроков уже
+// покинул очередь, возвращается ErrMatchConflict, и запись остается.
+func (s *MatcherService) RetryDLQEntry(ctx context.Context, index int64) (*models.Match, error) {
+	ctx, span := startSpan(ctx, "RetryDLQEntry", attribute.Int64("index", index))
+	defer span.End()
+
+	entry, err := s.storage.GetDLQEntry(ctx, index)
+	if err != nil {
+		return nil, err
+	}
+	if len(entry.Players) == 0 {
+		return nil, fmt.Errorf("dlq entry %s has no players", entry.ID)
+	}
+
+	players := make([]models.Player, len(entry.Players))
+	for i, p := range entry.Players {
+		players[i] = *p
+	}
+	match := s.buildMatch(players)
+	s.assignServer(ctx, match)
+
+	if err := s.formMatch(ctx, match); err != nil {
+		s.releaseServer(ctx, match)
+		return nil, err
+	}
+
+	if err := s.storage.RemoveDLQEntry(ctx, index, entry.ID); err != nil {
+		logging.FromContext(ctx).Warn("Failed to remove retried dlq entry",
+			zap.String("entry_id", entry.ID),
+			zap.Error(err),
+		)
+	}
+
+	logging.FromContext(ctx).Info("Match created from dlq entry",
+		zap.String("entry_id", entry.ID),
+		zap.String("match_id", match.MatchID),
+		zap.String("region", entry.Region),
+		zap.String("game_mode", entry.GameMode),
+	)
+
+	s.completeFormedMatch(ctx, entry.Region, entry.GameMode, match)
+	return match, nil
+}
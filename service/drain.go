@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"go.uber.org/zap"
+)
+
+// drainTimeout время на последний проход обработки очередей при остановке сервиса
+const drainTimeout = 10 * time.Second
+
+// DrainQueues при остановке сервиса последний раз обрабатывает очереди, которые ведет
+// эта реплика, чтобы ожидающие игроки успели попасть в матчи. Очереди других реплик
+// не трогаются. На весь проход отводится 10 секунд; число оставшихся без матча
+// игроков пишется в лог.
+func (s *MatcherService) DrainQueues(ctx context.Context, regions, gameModes []string) error {
+	ctx, cancel := context.WithTimeout(ctx, drainTimeout)
+	defer cancel()
+
+	var errs []error
+	var remaining int64
+	for _, region := range regions {
+		for _, gameMode := range gameModes {
+			leader, err := s.BecomeLeader(ctx, region, gameMode)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to acquire leadership of %s/%s: %w", region, gameMode, err))
+				continue
+			}
+			if !leader {
+				continue
+			}
+
+			if err := s.ProcessQueue(ctx, region, gameMode); err != nil {
+				errs = append(errs, fmt.Errorf("failed to drain %s/%s: %w", region, gameMode, err))
+			}
+
+			size, err := s.storage.GetQueueSize(ctx, region, gameMode)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to get size of %s/%s: %w", region, gameMode, err))
+				continue
+			}
+			if size > 0 {
+				logging.FromContext(ctx).Info("Players left unmatched after queue drain",
+					zap.String("region", region),
+					zap.String("game_mode", gameMode),
+					zap.Int64("players", size),
+				)
+			}
+			remaining += size
+		}
+	}
+
+	logging.FromContext(ctx).Info("Queues drained", zap.Int64("unmatched_players", remaining))
+	return errors.Join(errs...)
+}
+
+// ExpireLedQueues отмечает игроков, оставшихся в очередях, которые ведет эта реплика,
+// для удаления через ttl, чтобы они не висели в очереди бессрочно. Сами очереди общие
+// для всех реплик и срока жизни не получают: после остановки их подхватывает другая
+// реплика, и вставшие в них позже игроки не затрагиваются. Отмеченных игроков удаляет
+// лидер очереди в ProcessQueue (см. removeDrainedPlayers).
+func (s *MatcherService) ExpireLedQueues(ctx context.Context, regions, gameModes []string, ttl time.Duration) error {
+	var errs []error
+	for _, region := range regions {
+		for _, gameMode := range gameModes {
+			leader, err := s.BecomeLeader(ctx, region, gameMode)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to acquire leadership of %s/%s: %w", region, gameMode, err))
+				continue
+			}
+			if !leader {
+				continue
+			}
+			scheduled, err := s.storage.ScheduleQueueRemoval(ctx, region, gameMode, ttl)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if scheduled > 0 {
+				logging.FromContext(ctx).Info("Players left in queue scheduled for removal",
+					zap.String("region", region),
+					zap.String("game_mode", gameMode),
+					zap.Int64("players", scheduled),
+					zap.Duration("ttl", ttl),
+				)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// removeDrainedPlayers удаляет из очереди игроков, срок которых, назначенный при
+// остановке реплики (ExpireLedQueues), прошел
+func (s *MatcherService) removeDrainedPlayers(ctx context.Context, region, gameMode string) {
+	if _, err := s.storage.RemoveDrainedPlayers(ctx, region, gameMode, time.Now()); err != nil {
+		logging.FromContext(ctx).Warn("Failed to remove players left after shutdown",
+			zap.String("region", region),
+			zap.String("game_mode", gameMode),
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"chrono-matchmaking/models"
+)
+
+// K-факторы Elo без UseProvisionalPeriod
+const (
+	eloProvisionalGames   = 100  // До стольких сыгранных матчей рейтинг игрока считается неустоявшимся
+	eloProvisionalKFactor = 32.0 // K-фактор игроков, сыгравших меньше eloProvisionalGames матчей
+	eloEstablishedKFactor = 16.0 // K-фактор остальных игроков
+)
+
+// CalculateDynamicKFactor возвращает K-фактор Elo в зависимости от количества сыгранных матчей:
+// рейтинг новых игроков меняется сильнее, чем рейтинг ветеранов
+func (s *MatcherService) CalculateDynamicKFactor(profile *models.PlayerProfile) float64 {
+	switch {
+	case profile.TotalMatches < 10:
+		return 40
+	case profile.TotalMatches < 50:
+		return 32
+	case profile.TotalMatches < 200:
+		return 20
+	default:
+		return 15
+	}
+}
+
+// kFactorFor возвращает K-фактор для игрока: при включенном UseProvisionalPeriod —
+// CalculateDynamicKFactor, иначе 32 до eloProvisionalGames сыгранных матчей и 16 после.
+// Игрок без профиля (nil) считается новичком.
+func (s *MatcherService) kFactorFor(profile *models.PlayerProfile) float64 {
+	if profile == nil {
+		profile = &models.PlayerProfile{}
+	}
+	if s.currentConfig().UseProvisionalPeriod {
+		return s.CalculateDynamicKFactor(profile)
+	}
+	if profile.TotalMatches < eloProvisionalGames {
+		return eloProvisionalKFactor
+	}
+	return eloEstablishedKFactor
+}
+
+// eloExpectedScore возвращает ожидаемый по Elo результат игрока против соперника (0–1)
+func eloExpectedScore(rating, opponentRating int) float64 {
+	return 1 / (1 + math.Pow(10, float64(opponentRating-rating)/400))
+}
+
+// ErrInvalidMatchResult возвращается, если победители не совпадают с одной из команд матча
+var ErrInvalidMatchResult = errors.New("invalid match result")
+
+// ELOCalculator пересчитывает рейтинги участников матча по его результату.
+// Изменение рейтинга игрока — сумма изменений Elo против каждого соперника из другой команды,
+// посчитанных по рейтингам до матча.
+type ELOCalculator struct {
+	kFactors map[string]float64 // ID игрока -> K-фактор; отсутствующие считаются новичками
+}
+
+// NewELOCalculator создает калькулятор с K-факторами игроков (см. MatcherService.kFactorFor)
+func NewELOCalculator(kFactors map[string]float64) *ELOCalculator {
+	return &ELOCalculator{kFactors: kFactors}
+}
+
+// UpdateRatings возвращает игроков матча с новыми рейтингами в порядке match.Players.
+// winnerIDs должны совпадать с составом одной из команд, иначе возвращается ErrInvalidMatchResult.
+func (c *ELOCalculator) UpdateRatings(match *models.Match, winnerIDs []string) ([]models.Player, error) {
+	teams := matchTeams(match)
+	winningTeam, err := winningTeamIndex(teams, winnerIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	newRatings := make(map[string]int, len(match.Players))
+	for t, team := range teams {
+		score := 0.0
+		if t == winningTeam {
+			score = 1
+		}
+		for _, p := range team {
+			k := c.kFactor(p.ID)
+			delta := 0.0
+			for _, opponent := range teams[1-t] {
+				delta += k * (score - eloExpectedScore(p.Rating, opponent.Rating))
+			}
+			newRatings[p.ID] = p.Rating + int(math.Round(delta))
+		}
+	}
+
+	updated := make([]models.Player, 0, len(match.Players))
+	for _, p := range match.Players {
+		if rating, ok := newRatings[p.ID]; ok {
+			p.Rating = rating
+		}
+		updated = append(updated, p)
+	}
+	return updated, nil
+}
+
+// ProvisionalRating возвращает рейтинг по итогам калибровки: рейтинг, при котором
+// ожидаемый по Elo результат против соперников со средним рейтингом opponentRating
+// равен доле побед. Доля сглаживается на полпобеды (wins+0.5)/(games+1), чтобы серия
+// из одних побед или поражений давала конечный рейтинг.
+func (c *ELOCalculator) ProvisionalRating(opponentRating, wins, losses int) int {
+	score := (float64(wins) + 0.5) / float64(wins+losses+1)
+	return opponentRating + int(math.Round(400*math.Log10(score/(1-score))))
+}
+
+// matchTeams возвращает состав команд матча. У матчей без состава команд первая
+// половина игроков — команда A.
+func matchTeams(match *models.Match) models.TeamAssignment {
+	teams := match.Teams
+	if len(teams[0]) == 0 && len(teams[1]) == 0 {
+		half := len(match.Players) / 2
+		teams = models.TeamAssignment{match.Players[:half], match.Players[half:]}
+	}
+	return teams
+}
+
+// kFactor возвращает K-фактор игрока
+func (c *ELOCalculator) kFactor(playerID string) float64 {
+	if k, ok := c.kFactors[playerID]; ok {
+		return k
+	}
+	return eloProvisionalKFactor
+}
+
+// winningTeamIndex возвращает номер команды, состав которой совпадает с winnerIDs
+func winningTeamIndex(teams models.TeamAssignment, winnerIDs []string) (int, error) {
+	winners := make(map[string]bool, len(winnerIDs))
+	for _, id := range winnerIDs {
+		winners[id] = true
+	}
+
+	for t, team := range teams {
+		if len(team) == 0 || len(team) != len(winners) {
+			continue
+		}
+		matched := true
+		for _, p := range team {
+			if !winners[p.ID] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: winners must be exactly the players of one team", ErrInvalidMatchResult)
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"testing"
+
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/storage"
+	"go.uber.org/zap"
+)
+
+func TestCalculateDynamicKFactor(t *testing.T) {
+	matcher := NewMatcherService(storage.NewInMemoryStorage(), zap.NewNop(), DefaultMatcherConfig())
+
+	tests := []struct {
+		name         string
+		totalMatches int
+		want         float64
+	}{
+		{"new player", 0, 40},
+		{"last game of first tier", 9, 40},
+		{"second tier start", 10, 32},
+		{"last game of second tier", 49, 32},
+		{"third tier start", 50, 20},
+		{"last game of third tier", 199, 20},
+		{"veteran", 200, 15},
+		{"long-time veteran", 5000, 15},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := matcher.CalculateDynamicKFactor(&models.PlayerProfile{TotalMatches: tt.totalMatches})
+			if got != tt.want {
+				t.Errorf("CalculateDynamicKFactor(%d) = %v, want %v", tt.totalMatches, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestELOCalculatorEvenMatch проверяет изменение рейтинга в матче равных соперников
+// (вероятность победы 50%): победитель получает K/2, проигравший теряет K/2
+func TestELOCalculatorEvenMatch(t *testing.T) {
+	tests := []struct {
+		name                 string
+		useProvisionalPeriod bool
+		totalMatches         int
+		wantDelta            int
+	}{
+		{"default K new player", false, 0, 16},
+		{"default K last provisional game", false, 99, 16},
+		{"default K established player", false, 100, 8},
+		{"default K veteran", false, 500, 8},
+		{"first tier", true, 0, 20},
+		{"second tier", true, 10, 16},
+		{"third tier", true, 50, 10},
+		{"fourth tier", true, 200, 8}, // 7.5 округляется от нуля
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultMatcherConfig()
+			config.UseProvisionalPeriod = tt.useProvisionalPeriod
+			matcher := NewMatcherService(storage.NewInMemoryStorage(), zap.NewNop(), config)
+
+			profile := &models.PlayerProfile{TotalMatches: tt.totalMatches}
+			kFactors := map[string]float64{
+				"winner": matcher.kFactorFor(profile),
+				"loser":  matcher.kFactorFor(profile),
+			}
+			match := &models.Match{Players: []models.Player{
+				{ID: "winner", Rating: 1500},
+				{ID: "loser", Rating: 1500},
+			}}
+
+			players, err := NewELOCalculator(kFactors).UpdateRatings(match, []string{"winner"})
+			if err != nil {
+				t.Fatalf("UpdateRatings: %v", err)
+			}
+			if got := players[0].Rating - 1500; got != tt.wantDelta {
+				t.Errorf("winner delta = %d, want %d", got, tt.wantDelta)
+			}
+			if got := players[1].Rating - 1500; got != -tt.wantDelta {
+				t.Errorf("loser delta = %d, want %d", got, -tt.wantDelta)
+			}
+		})
+	}
+}
+
+func TestKFactorForPlayerWithoutProfile(t *testing.T) {
+	config := DefaultMatcherConfig()
+	config.UseProvisionalPeriod = true
+	matcher := NewMatcherService(storage.NewInMemoryStorage(), zap.NewNop(), config)
+
+	if got := matcher.kFactorFor(nil); got != 40 {
+		t.Errorf("kFactorFor(nil) = %v, want 40", got)
+	}
+}
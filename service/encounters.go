@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"go.uber.org/zap"
+)
+
+// recentEncounterBypass доля MaxSearchTime, после которой недавние соперники снова
+// считаются совместимыми, чтобы долго ждущий игрок не остался без матча
+const recentEncounterBypass = 0.8
+
+// recordEncounters запоминает пары соперников из разных команд матча.
+// Ошибки только логируются: журнал встреч не должен мешать созданию матча.
+func (s *MatcherService) recordEncounters(ctx context.Context, match *models.Match) {
+	if len(match.Players) == 0 {
+		return
+	}
+	ttl := s.configForContext(match.Players[0].Region, match.Players[0].GameMode).AvoidRecentOpponentsDuration
+	if ttl <= 0 {
+		return
+	}
+
+	for _, p1 := range match.Teams[0] {
+		for _, p2 := range match.Teams[1] {
+			if err := s.storage.RecordEncounter(ctx, p1.ID, p2.ID, ttl); err != nil {
+				logging.FromContext(ctx).Warn("Failed to record encounter",
+					zap.String("match_id", match.MatchID),
+					zap.String("player_id", p1.ID),
+					zap.String("opponent_id", p2.ID),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+}
+
+// haveRecentlyMet проверяет, что игроки недавно играли друг против друга и их не стоит
+// сводить снова. Проверка отключается, если игрок, который ждет дольше, ждет больше
+// 80% MaxSearchTime. При ошибке хранилища игроки считаются не встречавшимися.
+func (s *MatcherService) haveRecentlyMet(p1, p2 *models.Player) bool {
+	config := s.configForContext(p1.Region, p1.GameMode)
+	if config.AvoidRecentOpponentsDuration <= 0 {
+		return false
+	}
+
+	if longestWait(p1, p2).Seconds() >= config.MaxSearchTime.Seconds()*recentEncounterBypass {
+		return false
+	}
+
+	met, err := s.storage.HaveRecentlyMet(context.Background(), p1.ID, p2.ID)
+	if err != nil {
+		s.logger.Warn("Failed to check recent encounter",
+			zap.String("player_id", p1.ID),
+			zap.String("opponent_id", p2.ID),
+			zap.Error(err),
+		)
+		return false
+	}
+	return met
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"chrono-matchmaking/broadcast"
+	"chrono-matchmaking/models"
+)
+
+// SetEventHub включает рассылку событий очереди (вход, выход, создание матча)
+func (s *MatcherService) SetEventHub(h *broadcast.Hub) {
+	s.eventHub = h
+}
+
+// publishEvent рассылает событие очереди подписчикам хаба
+func (s *MatcherService) publishEvent(eventType string, event models.QueueEvent) {
+	if s.eventHub == nil {
+		return
+	}
+	s.eventHub.Publish(broadcast.Event{Type: eventType, Data: event})
+}
+
+// publishQueueJoin рассылает событие о входе игрока в очередь
+func (s *MatcherService) publishQueueJoin(player *models.Player) {
+	s.publishEvent(models.QueueEventJoin, models.QueueEvent{
+		Region:   player.Region,
+		GameMode: player.GameMode,
+		PlayerID: player.ID,
+		Rating:   player.Rating,
+	})
+}
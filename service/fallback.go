@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"go.uber.org/zap"
+)
+
+// tryFallbackMatch собирает межрегиональный матч, если в очереди региона не хватает
+// игроков, а самый старый из них ждет дольше MaxSearchTime/2. Недостающие игроки
+// добираются из очередей регионов FallbackRegions. Возвращает nil, если матч не собран.
+func (s *MatcherService) tryFallbackMatch(ctx context.Context, region, gameMode string, players []*models.Player) (*models.Match, error) {
+	config := s.configForContext(region, gameMode)
+	if !config.CrossRegionFallback || len(config.FallbackRegions) == 0 {
+		return nil, nil
+	}
+
+	// Группы подбираются только в своем регионе
+	local := make([]*models.Player, 0, len(players))
+	for _, p := range players {
+		if p.PartyID == "" {
+			local = append(local, p)
+		}
+	}
+	if len(local) == 0 {
+		return nil, nil
+	}
+	sort.Slice(local, func(i, j int) bool {
+		return local[i].JoinedAt.Before(local[j].JoinedAt)
+	})
+
+	anchor := local[0]
+	waitTime := time.Since(anchor.JoinedAt)
+	if waitTime < config.MaxSearchTime/2 {
+		return nil, nil
+	}
+
+	playersPerMatch, err := s.GetPlayersPerMatch(ctx, gameMode)
+	if err != nil {
+		return nil, err
+	}
+	ratingRange := s.calculateRatingRange(region, gameMode, waitTime)
+
+	// Сначала свои игроки, затем игроки соседних регионов в порядке FallbackRegions
+	candidates := append([]*models.Player{}, local[1:]...)
+	for _, fallbackRegion := range config.FallbackRegions {
+		if fallbackRegion == region {
+			continue
+		}
+		regionPlayers, err := s.storage.GetPlayersInRange(ctx, fallbackRegion, gameMode,
+			anchor.Rating-ratingRange, anchor.Rating+ratingRange, int64(playersPerMatch*2))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get players from %s: %w", fallbackRegion, err)
+		}
+		candidates = append(candidates, regionPlayers...)
+	}
+
+	group := []*models.Player{anchor}
+	for _, candidate := range candidates {
+		if len(group) >= playersPerMatch {
+			break
+		}
+		if candidate.PartyID != "" {
+			continue
+		}
+		if s.isSkillCompatible(anchor, candidate) && voiceCompatibleWithGroup(group, candidate) {
+			group = append(group, candidate)
+		}
+	}
+	if len(group) < playersPerMatch {
+		return nil, nil
+	}
+
+	matchPlayers := make([]models.Player, 0, len(group))
+	for _, p := range group {
+		matchPlayers = append(matchPlayers, *p)
+	}
+
+	match := s.buildMatch(matchPlayers)
+	s.assignServer(ctx, match)
+	if err := s.formMatch(ctx, match); err != nil {
+		s.releaseServer(ctx, match)
+		return nil, fmt.Errorf("failed to form cross-region match: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("Cross-region match created",
+		zap.String("match_id", match.MatchID),
+		zap.String("region", region),
+		zap.String("server_region", match.ServerRegion),
+		zap.Int("players_count", len(matchPlayers)),
+	)
+
+	if err := s.createLobbyInGameService(ctx, match); err != nil {
+		logging.FromContext(ctx).Warn("Failed to create lobby in game-service",
+			zap.String("match_id", match.MatchID),
+			zap.Error(err),
+		)
+	}
+
+	s.recordMatchStats(ctx, region, gameMode, match)
+	s.publishMatchFormed(ctx, region, gameMode, match)
+
+	return match, nil
+}
+
+// selectServerRegion выбирает регион сервера матча: среди регионов игроков тот, у которого
+// максимальная задержка до остальных игроков наименьшая. При равенстве выбирается регион
+// с большим числом игроков. Неизвестные пары регионов считаются самыми медленными.
+func (s *MatcherService) selectServerRegion(players []models.Player) string {
+	counts := make(map[string]int)
+	regions := make([]string, 0, 1)
+	for _, p := range players {
+		if counts[p.Region] == 0 {
+			regions = append(regions, p.Region)
+		}
+		counts[p.Region]++
+	}
+	if len(regions) <= 1 {
+		if len(regions) == 0 {
+			return ""
+		}
+		return regions[0]
+	}
+
+	best, bestLatency := "", math.MaxInt
+	for _, server := range regions {
+		worst := 0
+		for _, region := range regions {
+			latency, ok := s.GetRegionLatency(server, region)
+			if !ok {
+				latency = math.MaxInt
+			}
+			if latency > worst {
+				worst = latency
+			}
+		}
+		if worst < bestLatency || (worst == bestLatency && counts[server] > counts[best]) {
+			best, bestLatency = server, worst
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	// gameModesCacheTTL сколько реплика использует размер матча режима без повторного чтения из Redis
+	gameModesCacheTTL = 60 * time.Second
+
+	// defaultPlayersPerMatch размер матча режима, которого нет в списке режимов (3x3)
+	defaultPlayersPerMatch = 6
+)
+
+// ErrInvalidGameModes возвращается при попытке сохранить пустой или некорректный список режимов
+var ErrInvalidGameModes = errors.New("invalid game modes")
+
+// gameModeCacheEntry размер матча режима в локальном кэше
+type gameModeCacheEntry struct {
+	playersPerMatch int
+	expiresAt       time.Time
+}
+
+// DefaultGameModes возвращает режимы игры и количество игроков в их матчах,
+// используемые, пока список не задан через API
+func DefaultGameModes() map[string]int {
+	return map[string]int{
+		"1v1": 2,  // 1 игрок против 1 игрока
+		"3v3": 6,  // 3 игрока против 3 игроков
+		"5v5": 10, // 5 игроков против 5 игроков
+	}
+}
+
+// GameModeNames возвращает названия режимов в алфавитном порядке
+func GameModeNames(modes map[string]int) []string {
+	names := make([]string, 0, len(modes))
+	for name := range modes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetGameModes возвращает режимы игры с количеством игроков в матче. Если режимы не
+// сохранены в хранилище, возвращаются режимы по умолчанию. Прочитанные значения
+// обновляют локальный кэш GetPlayersPerMatch.
+func (s *MatcherService) GetGameModes(ctx context.Context) (map[string]int, error) {
+	ctx, span := startSpan(ctx, "GetGameModes")
+	defer span.End()
+
+	modes, err := s.storage.GetGameModes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(modes) == 0 {
+		modes = DefaultGameModes()
+	}
+
+	expiresAt := time.Now().Add(gameModesCacheTTL)
+	for mode, players := range modes {
+		s.gameModeCache.Store(mode, gameModeCacheEntry{playersPerMatch: players, expiresAt: expiresAt})
+	}
+	return modes, nil
+}
+
+// SetGameModes заменяет список режимов игры. Режим должен собирать матч из двух
+// равных команд, поэтому количество игроков — положительное четное число. Остальные
+// реплики увидят изменения в течение минуты (срок локального кэша).
+func (s *MatcherService) SetGameModes(ctx context.Context, modes map[string]int) error {
+	ctx, span := startSpan(ctx, "SetGameModes", attribute.Int("game_modes", len(modes)))
+	defer span.End()
+
+	if len(modes) == 0 {
+		return fmt.Errorf("%w: at least one game mode is required", ErrInvalidGameModes)
+	}
+	for mode, players := range modes {
+		if strings.TrimSpace(mode) == "" || strings.Contains(mode, ":") {
+			return fmt.Errorf("%w: game mode %q must be non-empty and must not contain ':'", ErrInvalidGameModes, mode)
+		}
+		if players <= 0 || players%2 != 0 {
+			return fmt.Errorf("%w: game mode %s must have a positive even number of players, got %d", ErrInvalidGameModes, mode, players)
+		}
+	}
+
+	if err := s.storage.SetGameModes(ctx, modes); err != nil {
+		return err
+	}
+
+	s.gameModeCache.Range(func(key, _ interface{}) bool {
+		s.gameModeCache.Delete(key)
+		return true
+	})
+
+	logging.FromContext(ctx).Info("Game modes updated", zap.Strings("game_modes", GameModeNames(modes)))
+	return nil
+}
+
+// GetPlayersPerMatch возвращает количество игроков в матче режима. Значение кэшируется
+// на реплике на 60 секунд. Для режима, которого нет в списке, возвращается 6 (3x3).
+func (s *MatcherService) GetPlayersPerMatch(ctx context.Context, gameMode string) (int, error) {
+	if cached, ok := s.gameModeCache.Load(gameMode); ok {
+		if entry := cached.(gameModeCacheEntry); time.Now().Before(entry.expiresAt) {
+			return entry.playersPerMatch, nil
+		}
+	}
+
+	modes, err := s.GetGameModes(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get players per match for %s: %w", gameMode, err)
+	}
+	if players, ok := modes[gameMode]; ok {
+		return players, nil
+	}
+
+	s.gameModeCache.Store(gameMode, gameModeCacheEntry{
+		playersPerMatch: defaultPlayersPerMatch,
+		expiresAt:       time.Now().Add(gameModesCacheTTL),
+	})
+	return defaultPlayersPerMatch, nil
+}
+
+// playersPerMatch возвращает количество игроков в матче режима там, где ошибку
+// некуда вернуть: при недоступном хранилище используется значение по умолчанию
+func (s *MatcherService) playersPerMatch(ctx context.Context, gameMode string) int {
+	players, err := s.GetPlayersPerMatch(ctx, gameMode)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Using default players per match",
+			zap.String("game_mode", gameMode),
+			zap.Error(err),
+		)
+		if players, ok := DefaultGameModes()[gameMode]; ok {
+			return players
+		}
+		return defaultPlayersPerMatch
+	}
+	return players
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"math"
+
+	"chrono-matchmaking/models"
+)
+
+// Параметры Glicko-2 (Glickman, "Example of the Glicko-2 system")
+const (
+	glickoDefaultRating     = 1500.0   // Рейтинг, относительно которого ведется расчет
+	glickoDefaultDeviation  = 350.0    // Отклонение рейтинга нового игрока
+	glickoDefaultVolatility = 0.06     // Волатильность нового игрока
+	glickoTau               = 0.5      // Ограничение изменения волатильности за период
+	glickoScale             = 173.7178 // Перевод между шкалой Glicko и Glicko-2
+	glickoEpsilon           = 0.000001 // Точность итерационного расчета волатильности
+)
+
+// CalculateNewRating пересчитывает рейтинг, отклонение и волатильность игрока по результатам
+// игр рейтингового периода. scores[i] — результат против opponents[i]: 1 победа, 0.5 ничья,
+// 0 поражение. Игрокам без RatingDeviation/Volatility назначаются значения нового игрока.
+// Без игр растет только отклонение рейтинга.
+func CalculateNewRating(player models.Player, opponents []models.Player, scores []float64) models.Player {
+	phi, sigma := glickoDeviation(player), glickoVolatility(player)
+	mu := (float64(player.Rating) - glickoDefaultRating) / glickoScale
+
+	n := len(opponents)
+	if len(scores) < n {
+		n = len(scores)
+	}
+	if n == 0 {
+		player.RatingDeviation = int(math.Round(math.Min(math.Sqrt(phi*phi+sigma*sigma), glickoDefaultDeviation/glickoScale) * glickoScale))
+		player.Volatility = sigma
+		return player
+	}
+
+	// Оценочная дисперсия v и улучшение рейтинга delta
+	var invV, sum float64
+	for i := 0; i < n; i++ {
+		muJ := (float64(opponents[i].Rating) - glickoDefaultRating) / glickoScale
+		g := glickoG(glickoDeviation(opponents[i]))
+		e := 1 / (1 + math.Exp(-g*(mu-muJ)))
+		invV += g * g * e * (1 - e)
+		sum += g * (scores[i] - e)
+	}
+	v := 1 / invV
+	delta := v * sum
+
+	sigma = glickoNewVolatility(phi, sigma, v, delta)
+
+	phiStar := math.Sqrt(phi*phi + sigma*sigma)
+	newPhi := 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+	newMu := mu + newPhi*newPhi*sum
+
+	player.Rating = int(math.Round(newMu*glickoScale + glickoDefaultRating))
+	player.RatingDeviation = int(math.Round(newPhi * glickoScale))
+	player.Volatility = sigma
+	return player
+}
+
+// glickoDeviation возвращает отклонение рейтинга игрока в шкале Glicko-2
+func glickoDeviation(p models.Player) float64 {
+	if p.RatingDeviation <= 0 {
+		return glickoDefaultDeviation / glickoScale
+	}
+	return float64(p.RatingDeviation) / glickoScale
+}
+
+// glickoVolatility возвращает волатильность игрока
+func glickoVolatility(p models.Player) float64 {
+	if p.Volatility <= 0 {
+		return glickoDefaultVolatility
+	}
+	return p.Volatility
+}
+
+// glickoG снижает вес результата против соперника с неопределенным рейтингом
+func glickoG(phi float64) float64 {
+	return 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+}
+
+// glickoNewVolatility находит новую волатильность методом Illinois (шаг 5 алгоритма)
+func glickoNewVolatility(phi, sigma, v, delta float64) float64 {
+	a := math.Log(sigma * sigma)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		d := phi*phi + v + ex
+		return ex*(delta*delta-d)/(2*d*d) - (x-a)/(glickoTau*glickoTau)
+	}
+
+	A := a
+	var B float64
+	if delta*delta > phi*phi+v {
+		B = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := 1.0
+		for f(a-k*glickoTau) < 0 {
+			k++
+		}
+		B = a - k*glickoTau
+	}
+
+	fA, fB := f(A), f(B)
+	for math.Abs(B-A) > glickoEpsilon {
+		C := A + (A-B)*fA/(fB-fA)
+		fC := f(C)
+		if fC*fB <= 0 {
+			A, fA = B, fB
+		} else {
+			fA /= 2
+		}
+		B, fB = C, fC
+	}
+
+	return math.Exp(A / 2)
+}
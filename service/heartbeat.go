@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"chrono-matchmaking/storage"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Heartbeat продлевает ожидание игрока в очереди и возвращает новый срок жизни его ключа.
+// Если ключ игрока уже истек, возвращает ErrPlayerNotInQueue: игроку нужно войти заново
+func (s *MatcherService) Heartbeat(ctx context.Context, playerID string) (time.Duration, error) {
+	ctx, span := startSpan(ctx, "Heartbeat", attribute.String("player_id", playerID))
+	defer span.End()
+
+	if err := s.storage.RefreshPlayerTTL(ctx, playerID, storage.PlayerTTL); err != nil {
+		return 0, err
+	}
+	return storage.PlayerTTL, nil
+}
+
+// GetExpiringPlayers возвращает игроков, чей ключ истечет раньше чем через threshold,
+// и оставшееся им время
+func (s *MatcherService) GetExpiringPlayers(ctx context.Context, threshold time.Duration) (map[string]time.Duration, error) {
+	ctx, span := startSpan(ctx, "GetExpiringPlayers")
+	defer span.End()
+
+	return s.storage.GetExpiringPlayers(ctx, threshold)
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"sort"
+
+	"chrono-matchmaking/models"
+)
+
+// hungarianForbidden стоимость назначения игрока в группу несовместимого с ним лидера;
+// много больше любой разницы рейтинга, поэтому такие назначения выбираются, только
+// если иначе игрока некуда поставить, и потом отбрасываются
+const hungarianForbidden = 1 << 30
+
+// HungarianAlgorithm распределяет игроков по группам венгерским алгоритмом так, чтобы
+// суммарная разница рейтинга была минимальной по всем группам прохода сразу, а не
+// по каждой группе отдельно, как в жадном подборе. Дольше всех ожидающие единицы
+// подбора становятся лидерами групп; каждое свободное место группы — отдельная вершина
+// двудольного графа, и остальные игроки назначаются на места со стоимостью
+// |рейтинг игрока − рейтинг лидера|. Группы (party), не ставшие лидерами, в этом
+// проходе не распределяются: с ростом ожидания они сами становятся лидерами.
+type HungarianAlgorithm struct {
+	constraints GroupConstraints
+}
+
+// setConstraints реализует constrainedAlgorithm
+func (a *HungarianAlgorithm) setConstraints(c GroupConstraints) {
+	a.constraints = c
+}
+
+// FormGroups реализует MatchingAlgorithm
+func (a *HungarianAlgorithm) FormGroups(players []*models.Player, groupSize int) [][]*models.Player {
+	units := groupPartyUnits(players)
+	groupCount := len(players) / groupSize
+	if groupCount == 0 {
+		return nil
+	}
+
+	// Лидеры — дольше всех ожидающие единицы подбора
+	sort.SliceStable(units, func(i, j int) bool {
+		return units[i][0].JoinedAt.Before(units[j][0].JoinedAt)
+	})
+	if groupCount > len(units) {
+		groupCount = len(units)
+	}
+	leaders := units[:groupCount]
+
+	residents := make([]*models.Player, 0, len(players))
+	for _, unit := range units[groupCount:] {
+		if len(unit) == 1 {
+			residents = append(residents, unit[0])
+		}
+	}
+
+	// Места групп: индекс лидера для каждого свободного места
+	var slots []int
+	for l, leader := range leaders {
+		for i := len(leader); i < groupSize; i++ {
+			slots = append(slots, l)
+		}
+	}
+
+	// Стоимость назначения игрока в группу лидера; у всех мест группы она одинакова
+	leaderCost := make([][]int, len(residents))
+	for r := range residents {
+		leaderCost[r] = make([]int, len(leaders))
+		for l, leader := range leaders {
+			if a.constraints.CanJoin != nil && !a.constraints.CanJoin(leader, residents[r:r+1]) {
+				leaderCost[r][l] = hungarianForbidden
+				continue
+			}
+			leaderCost[r][l] = unitRatingDistance(residents[r:r+1], leader)
+		}
+	}
+	cost := func(r, s int) int {
+		return leaderCost[r][slots[s]]
+	}
+
+	// Строк в матрице назначений не больше, чем столбцов
+	assigned := make([][]int, len(leaders)) // Назначенные игроки по лидерам
+	if len(residents) <= len(slots) {
+		matrix := make([][]int, len(residents))
+		for r := range matrix {
+			matrix[r] = make([]int, len(slots))
+			for s := range slots {
+				matrix[r][s] = cost(r, s)
+			}
+		}
+		for r, s := range hungarianAssign(matrix) {
+			if matrix[r][s] < hungarianForbidden {
+				assigned[slots[s]] = append(assigned[slots[s]], r)
+			}
+		}
+	} else {
+		matrix := make([][]int, len(slots))
+		for s := range matrix {
+			matrix[s] = make([]int, len(residents))
+			for r := range residents {
+				matrix[s][r] = cost(r, s)
+			}
+		}
+		for s, r := range hungarianAssign(matrix) {
+			if matrix[s][r] < hungarianForbidden {
+				assigned[slots[s]] = append(assigned[slots[s]], r)
+			}
+		}
+	}
+
+	var groups [][]*models.Player
+	for l, leader := range leaders {
+		// Совместимость с лидером проверена в матрице; остальные правила группы
+		// проверяются при добавлении, начиная с самых близких по рейтингу
+		members := assigned[l]
+		sort.SliceStable(members, func(i, j int) bool {
+			return unitRatingDistance(residents[members[i]:members[i]+1], leader) <
+				unitRatingDistance(residents[members[j]:members[j]+1], leader)
+		})
+
+		group := append([]*models.Player{}, leader...)
+		for _, r := range members {
+			unit := residents[r : r+1]
+			if a.constraints.CanJoin == nil || a.constraints.CanJoin(group, unit) {
+				group = append(group, unit...)
+			}
+		}
+		if len(group) != groupSize {
+			continue
+		}
+		if a.constraints.Complete != nil && !a.constraints.Complete(group) {
+			continue
+		}
+		groups = append(groups, group)
+	}
+
+	return groups
+}
+
+// hungarianAssign решает задачу о назначениях для матрицы n×m (n <= m) венгерским
+// алгоритмом с потенциалами за O(n²·m) и возвращает столбец для каждой строки так,
+// что сумма стоимостей минимальна
+func hungarianAssign(cost [][]int) []int {
+	n := len(cost)
+	if n == 0 {
+		return nil
+	}
+	m := len(cost[0])
+
+	const inf = int(^uint(0) >> 2)
+	u := make([]int, n+1)   // Потенциалы строк
+	v := make([]int, m+1)   // Потенциалы столбцов
+	p := make([]int, m+1)   // Строка (с 1), занимающая столбец; p[0] — добавляемая строка
+	way := make([]int, m+1) // Предыдущий столбец на увеличивающем пути
+
+	for i := 1; i <= n; i++ {
+		p[0] = i
+		j0 := 0
+		minv := make([]int, m+1)
+		used := make([]bool, m+1)
+		for j := range minv {
+			minv[j] = inf
+		}
+
+		// Ищем увеличивающий путь от новой строки до свободного столбца
+		for {
+			used[j0] = true
+			i0 := p[j0]
+			delta := inf
+			j1 := 0
+			for j := 1; j <= m; j++ {
+				if used[j] {
+					continue
+				}
+				if cur := cost[i0-1][j-1] - u[i0] - v[j]; cur < minv[j] {
+					minv[j] = cur
+					way[j] = j0
+				}
+				if minv[j] < delta {
+					delta = minv[j]
+					j1 = j
+				}
+			}
+			for j := 0; j <= m; j++ {
+				if used[j] {
+					u[p[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+			if p[j0] == 0 {
+				break
+			}
+		}
+
+		// Перекладываем назначения вдоль найденного пути
+		for j0 != 0 {
+			j1 := way[j0]
+			p[j0] = p[j1]
+			j0 = j1
+		}
+	}
+
+	result := make([]int, n)
+	for j := 1; j <= m; j++ {
+		if p[j] != 0 {
+			result[p[j]-1] = j - 1
+		}
+	}
+	return result
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"chrono-matchmaking/models"
+)
+
+// bruteForceAssign перебирает все назначения строк матрицы n×m (n <= m) столбцам
+// и возвращает минимальную суммарную стоимость
+func bruteForceAssign(cost [][]int) int {
+	best := -1
+	used := make([]bool, len(cost[0]))
+	var walk func(row, total int)
+	walk = func(row, total int) {
+		if row == len(cost) {
+			if best < 0 || total < best {
+				best = total
+			}
+			return
+		}
+		for col := range used {
+			if !used[col] {
+				used[col] = true
+				walk(row+1, total+cost[row][col])
+				used[col] = false
+			}
+		}
+	}
+	walk(0, 0)
+	return best
+}
+
+func TestHungarianAssignIsOptimal(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	for round := 0; round < 200; round++ {
+		n := 1 + rng.Intn(6)
+		m := n + rng.Intn(3)
+		cost := make([][]int, n)
+		for i := range cost {
+			cost[i] = make([]int, m)
+			for j := range cost[i] {
+				cost[i][j] = rng.Intn(100)
+			}
+		}
+
+		assignment := hungarianAssign(cost)
+		total := 0
+		cols := make(map[int]bool)
+		for row, col := range assignment {
+			if cols[col] {
+				t.Fatalf("round %d: column %d assigned twice in %v", round, col, assignment)
+			}
+			cols[col] = true
+			total += cost[row][col]
+		}
+		if want := bruteForceAssign(cost); total != want {
+			t.Fatalf("round %d: assignment cost %d, optimum %d for %v", round, total, want, cost)
+		}
+	}
+}
+
+// TestHungarianMinimizesTotalDistance проверяет, что суммарная разница рейтинга игроков
+// и лидеров их групп минимальна по всем группам сразу. Жадный подбор собирает первую
+// группу из игроков в порядке очереди и забирает в нее 1560, ближайшего ко второму лидеру.
+func TestHungarianMinimizesTotalDistance(t *testing.T) {
+	players := stableTestPlayers(1500, 1560, 1520, 1530, 1540, 1550)
+
+	total := func(groups [][]*models.Player) int {
+		sum := 0
+		for _, group := range groups {
+			for _, p := range group[1:] {
+				sum += ratingDistance(group[0], p)
+			}
+		}
+		return sum
+	}
+
+	hungarian := (&HungarianAlgorithm{}).FormGroups(players, 3)
+	if len(hungarian) != 2 {
+		t.Fatalf("got %d groups, want 2", len(hungarian))
+	}
+	// Оптимум: лидеру 1500 — 1520 и 1530, лидеру 1560 — 1540 и 1550
+	if got := total(hungarian); got != 80 {
+		t.Errorf("total distance = %d, want the optimum 80 (groups %v)", got, groupRatings(hungarian))
+	}
+
+	greedy := (&GreedyAlgorithm{}).FormGroups(players, 3)
+	if got := total(greedy); got < total(hungarian) {
+		t.Errorf("greedy total distance %d beats the optimal %d", got, total(hungarian))
+	}
+}
+
+func TestHungarianRespectsConstraints(t *testing.T) {
+	players := stableTestPlayers(1000, 1100, 1010, 1050)
+	algorithm := &HungarianAlgorithm{}
+	algorithm.setConstraints(GroupConstraints{
+		CanJoin: func(group, unit []*models.Player) bool {
+			return !(group[0].ID == "p0" && unit[0].ID == "p2")
+		},
+	})
+
+	groups := algorithm.FormGroups(players, 2)
+	for _, group := range groups {
+		if group[0].ID == "p0" && group[1].ID == "p2" {
+			t.Errorf("forbidden pair grouped: %v", groupRatings(groups))
+		}
+	}
+	if len(groups) != 2 {
+		t.Errorf("got %d groups, want 2", len(groups))
+	}
+}
+
+// benchmarkQueue возвращает воспроизводимую очередь: рейтинги с нормальным распределением
+// вокруг 1500 и случайный порядок входа
+func benchmarkQueue(size int) []*models.Player {
+	rng := rand.New(rand.NewSource(1))
+	start := time.Now().Add(-time.Hour)
+	players := make([]*models.Player, size)
+	for i := range players {
+		players[i] = &models.Player{
+			ID:       fmt.Sprintf("p%d", i),
+			Rating:   1500 + int(rng.NormFloat64()*300),
+			Region:   "EU",
+			GameMode: "3v3",
+			JoinedAt: start.Add(time.Duration(rng.Intn(3600)) * time.Second),
+		}
+	}
+	return players
+}
+
+// averageMatchQuality возвращает средний ComputeMatchQuality групп после балансировки команд
+func averageMatchQuality(groups [][]*models.Player) float64 {
+	if len(groups) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, group := range groups {
+		players := make([]models.Player, len(group))
+		for i, p := range group {
+			players[i] = *p
+		}
+		teams := models.NewBalancedTeamAssignment(players)
+		total += ComputeMatchQuality(append(teams[0], teams[1]...))
+	}
+	return total / float64(len(groups))
+}
+
+// BenchmarkMatchingQuality сравнивает алгоритмы на одной и той же очереди: кроме времени
+// сообщает средний баланс матча (quality/match) и число собранных матчей (matches/op)
+func BenchmarkMatchingQuality(b *testing.B) {
+	algorithms := []struct {
+		name      string
+		algorithm func() MatchingAlgorithm
+	}{
+		{AlgorithmGreedy, func() MatchingAlgorithm { return &GreedyAlgorithm{} }},
+		{AlgorithmStable, func() MatchingAlgorithm { return &StableMatchingAlgorithm{} }},
+		{AlgorithmHungarian, func() MatchingAlgorithm { return &HungarianAlgorithm{} }},
+	}
+	for _, size := range []int{60, 300} {
+		players := benchmarkQueue(size)
+		for _, alg := range algorithms {
+			b.Run(fmt.Sprintf("%s/%d", alg.name, size), func(b *testing.B) {
+				var groups [][]*models.Player
+				for i := 0; i < b.N; i++ {
+					groups = alg.algorithm().FormGroups(players, 6)
+				}
+				b.ReportMetric(averageMatchQuality(groups), "quality/match")
+				b.ReportMetric(float64(len(groups)), "matches/op")
+			})
+		}
+	}
+}
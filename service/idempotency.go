@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+
+	"chrono-matchmaking/storage"
+)
+
+// ErrIdempotencyKeyNotFound возвращается, если ключ идемпотентности не использовался или истек
+var ErrIdempotencyKeyNotFound = storage.ErrIdempotencyKeyNotFound
+
+// GetIdempotentJoin возвращает ID игрока, вставшего в очередь по ключу идемпотентности,
+// или ErrIdempotencyKeyNotFound
+func (s *MatcherService) GetIdempotentJoin(ctx context.Context, key string) (string, error) {
+	ctx, span := startSpan(ctx, "GetIdempotentJoin")
+	defer span.End()
+
+	return s.storage.GetIdempotencyKey(ctx, key)
+}
+
+// SaveIdempotentJoin запоминает успешный вход игрока в очередь по ключу идемпотентности.
+// Если ключ уже сохранен параллельным запросом, исходное значение не меняется.
+func (s *MatcherService) SaveIdempotentJoin(ctx context.Context, key, playerID string) error {
+	ctx, span := startSpan(ctx, "SaveIdempotentJoin")
+	defer span.End()
+
+	_, err := s.storage.SaveIdempotencyKey(ctx, key, playerID)
+	return err
+}
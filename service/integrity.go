@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"chrono-matchmaking/storage"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrMatchNotFound возвращается, если матч не найден или его срок хранения истек
+var ErrMatchNotFound = storage.ErrMatchNotFound
+
+// ValidateMatchIntegrity проверяет согласованность сохраненного матча и возвращает
+// список найденных нарушений. Пустой список означает, что матч корректен.
+func (s *MatcherService) ValidateMatchIntegrity(ctx context.Context, matchID string) ([]string, error) {
+	ctx, span := startSpan(ctx, "ValidateMatchIntegrity", attribute.String("match_id", matchID))
+	defer span.End()
+
+	match, err := s.storage.GetMatchByID(ctx, matchID)
+	if err != nil {
+		return nil, err
+	}
+
+	violations := make([]string, 0)
+
+	if match.MatchID != matchID {
+		violations = append(violations, fmt.Sprintf("stored match has id %q", match.MatchID))
+	}
+
+	if match.CreatedAt.After(time.Now()) {
+		violations = append(violations, fmt.Sprintf("created_at %s is in the future", match.CreatedAt.Format(time.RFC3339)))
+	}
+
+	if match.QualityScore < 0 || match.QualityScore > 1 {
+		violations = append(violations, fmt.Sprintf("quality_score %v is outside [0, 1]", match.QualityScore))
+	}
+
+	if match.ServerAddr != "" && !isHostPort(match.ServerAddr) {
+		violations = append(violations, fmt.Sprintf("server_addr %q is not a host:port address", match.ServerAddr))
+	}
+
+	if len(match.Players) == 0 {
+		return append(violations, "match has no players"), nil
+	}
+
+	// Размер матча и команд (первая и вторая половины игроков) должен соответствовать режиму.
+	// Добранные взамен отключившихся игроки дописываются в конец и в размер не входят
+	gameMode := match.Players[0].GameMode
+	expected, err := s.GetPlayersPerMatch(ctx, gameMode)
+	if err != nil {
+		return nil, err
+	}
+	original := len(match.Players) - match.BackfillCount
+	if original != expected {
+		violations = append(violations, fmt.Sprintf("match has %d players, game mode %s requires %d", original, gameMode, expected))
+	}
+	if original%2 != 0 {
+		violations = append(violations, fmt.Sprintf("teams are uneven: %d players", original))
+	}
+	if bots := len(match.Players) - len(match.HumanPlayers()); bots > expected/2 {
+		violations = append(violations, fmt.Sprintf("match has %d bots, at most %d allowed", bots, expected/2))
+	}
+
+	// Ключи match:{id} игроков живут storage.MatchTTL, запись матча по ID — дольше
+	activatedAt := match.CreatedAt
+	if match.ScheduledStartTime != nil {
+		activatedAt = *match.ScheduledStartTime
+	}
+	checkPlayerKeys := time.Since(activatedAt) < storage.MatchTTL
+
+	seen := make(map[string]bool, len(match.Players))
+	for _, p := range match.Players {
+		if seen[p.ID] {
+			violations = append(violations, fmt.Sprintf("player %s appears more than once", p.ID))
+			continue
+		}
+		seen[p.ID] = true
+
+		if p.GameMode != gameMode || p.Region != match.Players[0].Region {
+			violations = append(violations, fmt.Sprintf("player %s is from queue %s/%s", p.ID, p.Region, p.GameMode))
+		}
+
+		// Боту ключ match:{id} не создается
+		if !checkPlayerKeys || p.IsBotPlayer {
+			continue
+		}
+
+		// У каждого игрока должна быть ссылка match:{id} на этот матч
+		playerMatch, err := s.storage.GetMatchByPlayerID(ctx, p.ID)
+		if errors.Is(err, storage.ErrMatchNotFound) {
+			violations = append(violations, fmt.Sprintf("player %s has no match key", p.ID))
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if playerMatch.MatchID != matchID {
+			violations = append(violations, fmt.Sprintf("player %s match key points to %s", p.ID, playerMatch.MatchID))
+		}
+	}
+
+	return violations, nil
+}
+
+// isHostPort проверяет, что addr — адрес вида host:port с номером порта 1–65535
+func isHostPort(addr string) bool {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || host == "" {
+		return false
+	}
+	n, err := strconv.Atoi(port)
+	return err == nil && n >= 1 && n <= 65535
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/storage"
+	"go.uber.org/zap"
+)
+
+// integrityTestMatch возвращает корректный матч 3v3 из шести игроков
+func integrityTestMatch() *models.Match {
+	match := &models.Match{
+		MatchID:      "match",
+		CreatedAt:    time.Now().Add(-time.Minute),
+		GameMode:     "3v3",
+		QualityScore: 0.8,
+		ServerAddr:   "10.0.0.1:7777",
+	}
+	for i := 1; i <= 6; i++ {
+		match.Players = append(match.Players, models.Player{ID: fmt.Sprintf("p%d", i), Rating: 1500, Region: "EU", GameMode: "3v3"})
+	}
+	match.ExpiresAt = match.CreatedAt.Add(storage.MatchTTL)
+	return match
+}
+
+func TestValidateMatchIntegrity(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(match *models.Match)
+		after  func(ctx context.Context, store storage.Storage) error // Вызывается после сохранения матча
+		want   []string
+	}{
+		{
+			name: "valid match",
+			want: []string{},
+		},
+		{
+			name: "missing player match key",
+			after: func(ctx context.Context, store storage.Storage) error {
+				return store.RemoveMatch(ctx, "p2")
+			},
+			want: []string{"player p2 has no match key"},
+		},
+		{
+			name: "player match key points to another match",
+			after: func(ctx context.Context, store storage.Storage) error {
+				other := integrityTestMatch()
+				other.MatchID = "other"
+				other.Players = other.Players[2:3]
+				return store.SaveMatch(ctx, other)
+			},
+			want: []string{"player p3 match key points to other"},
+		},
+		{
+			name: "player count differs from game mode",
+			modify: func(match *models.Match) {
+				match.Players = match.Players[:4]
+			},
+			want: []string{"match has 4 players, game mode 3v3 requires 6"},
+		},
+		{
+			name: "uneven teams",
+			modify: func(match *models.Match) {
+				match.Players = match.Players[:5]
+			},
+			want: []string{"match has 5 players, game mode 3v3 requires 6", "teams are uneven: 5 players"},
+		},
+		{
+			name: "backfilled players not counted",
+			modify: func(match *models.Match) {
+				match.Players = append(match.Players, models.Player{ID: "p7", Region: "EU", GameMode: "3v3"})
+				match.BackfillCount = 1
+			},
+			want: []string{},
+		},
+		{
+			name: "too many bots",
+			modify: func(match *models.Match) {
+				for i := 0; i < 4; i++ {
+					match.Players[i].IsBotPlayer = true
+				}
+			},
+			want: []string{"match has 4 bots, at most 3 allowed"},
+		},
+		{
+			name: "quality score above one",
+			modify: func(match *models.Match) {
+				match.QualityScore = 1.5
+			},
+			want: []string{"quality_score 1.5 is outside [0, 1]"},
+		},
+		{
+			name: "negative quality score",
+			modify: func(match *models.Match) {
+				match.QualityScore = -0.1
+			},
+			want: []string{"quality_score -0.1 is outside [0, 1]"},
+		},
+		{
+			name: "invalid server address",
+			modify: func(match *models.Match) {
+				match.ServerAddr = "http://10.0.0.1"
+			},
+			want: []string{`server_addr "http://10.0.0.1" is not a host:port address`},
+		},
+		{
+			name: "created in the future",
+			modify: func(match *models.Match) {
+				match.CreatedAt = time.Date(2999, 1, 1, 0, 0, 0, 0, time.UTC)
+			},
+			want: []string{"created_at 2999-01-01T00:00:00Z is in the future"},
+		},
+		{
+			name: "duplicate player",
+			modify: func(match *models.Match) {
+				match.Players[5] = match.Players[0]
+			},
+			want: []string{"player p1 appears more than once"},
+		},
+		{
+			name: "player from another queue",
+			modify: func(match *models.Match) {
+				match.Players[3].Region = "US"
+			},
+			want: []string{"player p4 is from queue US/3v3"},
+		},
+		{
+			name: "no players",
+			modify: func(match *models.Match) {
+				match.Players = nil
+			},
+			want: []string{"match has no players"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := storage.NewInMemoryStorage()
+			matcher := NewMatcherService(store, zap.NewNop(), DefaultMatcherConfig())
+
+			match := integrityTestMatch()
+			if tt.modify != nil {
+				tt.modify(match)
+			}
+			if err := store.SaveMatch(ctx, match); err != nil {
+				t.Fatalf("SaveMatch: %v", err)
+			}
+			if tt.after != nil {
+				if err := tt.after(ctx, store); err != nil {
+					t.Fatalf("setup: %v", err)
+				}
+			}
+
+			violations, err := matcher.ValidateMatchIntegrity(ctx, "match")
+			if err != nil {
+				t.Fatalf("ValidateMatchIntegrity: %v", err)
+			}
+			if !reflect.DeepEqual(violations, tt.want) {
+				t.Errorf("violations = %q, want %q", violations, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateMatchIntegrityUnknownMatch(t *testing.T) {
+	matcher := NewMatcherService(storage.NewInMemoryStorage(), zap.NewNop(), DefaultMatcherConfig())
+
+	if _, err := matcher.ValidateMatchIntegrity(context.Background(), "missing"); !errors.Is(err, ErrMatchNotFound) {
+		t.Errorf("ValidateMatchIntegrity(missing) = %v, want ErrMatchNotFound", err)
+	}
+}
@@ -0,0 +1,43 @@
+package service
+
+import "chrono-matchmaking/models"
+
+// languageRelaxation доля MaxSearchTime, после которой требование общих языков снимается,
+// чтобы игроки с редким языком не ждали бесконечно
+const languageRelaxation = 0.6
+
+// isLanguageCompatible проверяет, что при LanguageMatchingEnabled у игроков не меньше
+// MinLanguageOverlap общих языков. Игроки без PreferredLanguages не ограничиваются.
+// После MaxSearchTime * 0.6 ожидания (по тому, кто ждет дольше) требование снимается.
+func (s *MatcherService) isLanguageCompatible(p1, p2 *models.Player) bool {
+	config := s.configForContext(p1.Region, p1.GameMode)
+	if !config.LanguageMatchingEnabled {
+		return true
+	}
+	if len(p1.PreferredLanguages) == 0 || len(p2.PreferredLanguages) == 0 {
+		return true
+	}
+
+	if longestWait(p1, p2).Seconds() >= config.MaxSearchTime.Seconds()*languageRelaxation {
+		return true
+	}
+
+	return languageOverlap(p1.PreferredLanguages, p2.PreferredLanguages) >= config.MinLanguageOverlap
+}
+
+// languageOverlap возвращает количество общих языков двух списков
+func languageOverlap(a, b []string) int {
+	known := make(map[string]bool, len(a))
+	for _, lang := range a {
+		known[lang] = true
+	}
+
+	overlap := 0
+	for _, lang := range b {
+		if known[lang] {
+			overlap++
+			delete(known, lang) // Повторы в списке не считаются дважды
+		}
+	}
+	return overlap
+}
@@ -0,0 +1,46 @@
+package service
+
+// DefaultRegionLatencyMatrix возвращает ожидаемые задержки между регионами (мс) по умолчанию
+func DefaultRegionLatencyMatrix() map[string]map[string]int {
+	return map[string]map[string]int{
+		"EU": {"US": 80, "ASIA": 200},
+		"US": {"ASIA": 150},
+	}
+}
+
+// GetRegionLatencyMap возвращает симметричную матрицу задержек между регионами.
+// В конфигурации достаточно указать задержку в одном направлении.
+func (s *MatcherService) GetRegionLatencyMap() map[string]map[string]int {
+	matrix := s.currentConfig().RegionLatencyMatrix
+
+	result := make(map[string]map[string]int)
+	set := func(from, to string, latency int) {
+		if result[from] == nil {
+			result[from] = make(map[string]int)
+		}
+		result[from][to] = latency
+	}
+
+	for from, row := range matrix {
+		for to, latency := range row {
+			if from == to {
+				continue
+			}
+			set(from, to, latency)
+			set(to, from, latency)
+		}
+	}
+
+	return result
+}
+
+// GetRegionLatency возвращает ожидаемую задержку между двумя регионами.
+// Второе значение равно false, если пара регионов не настроена.
+func (s *MatcherService) GetRegionLatency(region1, region2 string) (int, bool) {
+	if region1 == region2 {
+		return 0, true
+	}
+
+	latency, ok := s.GetRegionLatencyMap()[region1][region2]
+	return latency, ok
+}
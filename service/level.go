@@ -0,0 +1,60 @@
+package service
+
+import "chrono-matchmaking/models"
+
+// LevelBracket диапазон уровней игроков, внутри которого они подбираются друг с другом.
+// Max == 0 означает диапазон без верхней границы.
+type LevelBracket struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+// DefaultLevelBrackets возвращает диапазоны уровней по умолчанию: 1–10, 11–30, 31–50, 51+
+func DefaultLevelBrackets() []LevelBracket {
+	return []LevelBracket{
+		{Min: 1, Max: 10},
+		{Min: 11, Max: 30},
+		{Min: 31, Max: 50},
+		{Min: 51},
+	}
+}
+
+// calculateLevelBracket возвращает индекс диапазона, в который попадает уровень,
+// или -1, если уровень не попадает ни в один диапазон
+func calculateLevelBracket(level int, brackets []LevelBracket) int {
+	for i, b := range brackets {
+		if level >= b.Min && (b.Max == 0 || level <= b.Max) {
+			return i
+		}
+	}
+	return -1
+}
+
+// isLevelCompatible проверяет, что уровни игроков попадают в один диапазон.
+// Как и диапазон рейтинга, требование ослабевает с ожиданием: после MaxSearchTime/2
+// допускаются соседние диапазоны, после MaxSearchTime — любые.
+// Игроки с уровнем вне всех диапазонов (например, не переданным) не ограничиваются.
+func (s *MatcherService) isLevelCompatible(p1, p2 *models.Player) bool {
+	config := s.configForContext(p1.Region, p1.GameMode)
+	if len(config.LevelBrackets) == 0 {
+		return true
+	}
+
+	b1 := calculateLevelBracket(p1.PlayerLevel, config.LevelBrackets)
+	b2 := calculateLevelBracket(p2.PlayerLevel, config.LevelBrackets)
+	if b1 < 0 || b2 < 0 || b1 == b2 {
+		return true
+	}
+
+	// Допуск считается по игроку, который ждет дольше
+	waitTime := s.pairWaitTime(p1, p2)
+
+	switch {
+	case waitTime >= config.MaxSearchTime:
+		return true
+	case waitTime >= config.MaxSearchTime/2:
+		return b1-b2 <= 1 && b2-b1 <= 1
+	default:
+		return false
+	}
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"chrono-matchmaking/models"
+)
+
+// SelectMap выбирает карту матча из pool по предпочтениям игроков группы: побеждает
+// карта с наибольшим числом голосов, при равенстве — первая по алфавиту. Карты вне
+// pool не учитываются, повторы в предпочтениях одного игрока считаются одним голосом.
+// Пустой pool означает, что карта не выбирается.
+func SelectMap(group []*models.Player, pool []string) string {
+	if len(pool) == 0 {
+		return ""
+	}
+
+	votes := make(map[string]int, len(pool))
+	for _, name := range pool {
+		votes[name] = 0
+	}
+	for _, p := range group {
+		voted := make(map[string]bool, len(p.PreferredMaps))
+		for _, name := range p.PreferredMaps {
+			if _, ok := votes[name]; !ok || voted[name] {
+				continue
+			}
+			voted[name] = true
+			votes[name]++
+		}
+	}
+
+	names := make([]string, 0, len(votes))
+	for name := range votes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if votes[names[i]] != votes[names[j]] {
+			return votes[names[i]] > votes[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	return names[0]
+}
+
+// hasCommonMap проверяет, что у игроков есть общая предпочитаемая карта.
+// Игрок без предпочтений согласен на любую карту.
+func hasCommonMap(p1, p2 *models.Player) bool {
+	if len(p1.PreferredMaps) == 0 || len(p2.PreferredMaps) == 0 {
+		return true
+	}
+	for _, m1 := range p1.PreferredMaps {
+		for _, m2 := range p2.PreferredMaps {
+			if m1 == m2 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// pairWaitTime возвращает ожидание, по которому расширяются допуски совместимости пары:
+// ожидание того, кто ждет дольше. Если у игроков нет ни одной общей карты, ожидание
+// уменьшается на долю MapCompatibilityWeight, и допуски для них расширяются медленнее.
+func (s *MatcherService) pairWaitTime(p1, p2 *models.Player) time.Duration {
+	waitTime := longestWait(p1, p2)
+	if weight := s.configForContext(p1.Region, p1.GameMode).MapCompatibilityWeight; weight > 0 && !hasCommonMap(p1, p2) {
+		waitTime = time.Duration(float64(waitTime) * (1 - weight))
+	}
+	return waitTime
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/storage"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	// expiredMatchesLockKey блокировка, чтобы брошенные матчи обрабатывала одна реплика
+	expiredMatchesLockKey = "lock:expired-matches"
+	expiredMatchesLockTTL = 10 * time.Minute
+
+	// orphanRequeuedMetadataKey отмечает брошенный матч, игроки которого уже возвращены в очередь
+	orphanRequeuedMetadataKey = "orphan_requeued"
+)
+
+// AcknowledgeMatch отмечает, что игровой сервер принял матч. Подтвержденный матч после
+// истечения не считается брошенным, и его игроки не возвращаются в очередь.
+// Повторное подтверждение не меняет время первого.
+func (s *MatcherService) AcknowledgeMatch(ctx context.Context, matchID string) (*models.Match, error) {
+	ctx, span := startSpan(ctx, "AcknowledgeMatch", attribute.String("match_id", matchID))
+	defer span.End()
+
+	match, err := s.storage.GetMatchByID(ctx, matchID)
+	if err != nil {
+		return nil, err
+	}
+	if match.AcknowledgedAt != nil {
+		return match, nil
+	}
+
+	now := time.Now().UTC()
+	match.AcknowledgedAt = &now
+	if err := s.storage.UpdateMatch(ctx, match); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).Info("Match acknowledged by game server", zap.String("match_id", matchID))
+	return match, nil
+}
+
+// isMatchAcknowledged проверяет, что матч подтвердил игровой сервер или по нему уже
+// пришел результат
+func isMatchAcknowledged(match *models.Match) bool {
+	if match.AcknowledgedAt != nil {
+		return true
+	}
+	_, reported := match.Metadata["winner_player_ids"]
+	return reported
+}
+
+// CleanupExpiredMatches пишет в лог игроков каждого истекшего матча и возвращает в очередь
+// игроков брошенных матчей — истекших, но так и не подтвержденных игровым сервером.
+// Игрок не возвращается, если он уже стоит в очереди или попал в более новый матч;
+// запланированные матчи не возвращаются: их игроки не приходили из очереди.
+// Обработанный матч отмечается в Metadata и при следующем проходе пропускается.
+// Возвращает количество возвращенных игроков.
+func (s *MatcherService) CleanupExpiredMatches(ctx context.Context) (int, error) {
+	ctx, span := startSpan(ctx, "CleanupExpiredMatches")
+	defer span.End()
+
+	owner := uuid.New().String()
+	acquired, err := s.storage.AcquireLock(ctx, expiredMatchesLockKey, owner, expiredMatchesLockTTL)
+	if err != nil {
+		return 0, err
+	}
+	if !acquired {
+		return 0, nil // Истекшие матчи обрабатывает другая реплика
+	}
+	defer func() {
+		if err := s.storage.ReleaseLock(ctx, expiredMatchesLockKey, owner); err != nil {
+			logging.FromContext(ctx).Warn("Failed to release expired matches lock", zap.Error(err))
+		}
+	}()
+
+	matches, err := s.storage.ScanExpiredMatches(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	requeued := 0
+	for _, match := range matches {
+		requeued += s.handleExpiredMatch(ctx, match)
+	}
+
+	return requeued, nil
+}
+
+// HandleMatchExpiry обрабатывает запись потока истечения (storage.WatchForMatchExpiry).
+// Для ожидающего матча отменяются не подтвержденные вовремя матчи его очереди. Для
+// созданного матча освобождается игровой сервер, а если матч брошен, его игроки
+// возвращаются в очередь, как в CleanupExpiredMatches. Ошибка означает, что запись
+// нужно обработать повторно.
+func (s *MatcherService) HandleMatchExpiry(ctx context.Context, expiry storage.MatchExpiry) error {
+	if expiry.Pending {
+		return s.ExpirePendingMatches(ctx, expiry.Region, expiry.GameMode)
+	}
+
+	ctx, span := startSpan(ctx, "HandleMatchExpiry", attribute.String("match_id", expiry.MatchID))
+	defer span.End()
+
+	match, err := s.storage.GetMatchByID(ctx, expiry.MatchID)
+	if errors.Is(err, ErrMatchNotFound) {
+		return nil // Матч уже удален
+	}
+	if err != nil {
+		return err
+	}
+
+	s.releaseServer(ctx, match)
+	s.handleExpiredMatch(ctx, match)
+	return nil
+}
+
+// handleExpiredMatch пишет в лог игроков истекшего матча и, если матч брошен, возвращает
+// их в очередь и отмечает матч обработанным. Возвращает количество возвращенных игроков.
+func (s *MatcherService) handleExpiredMatch(ctx context.Context, match *models.Match) int {
+	if _, done := match.Metadata[orphanRequeuedMetadataKey]; done {
+		return 0
+	}
+
+	playerIDs := make([]string, 0, len(match.Players))
+	for _, p := range match.Players {
+		playerIDs = append(playerIDs, p.ID)
+	}
+	acknowledged := isMatchAcknowledged(match)
+	logging.FromContext(ctx).Info("Match expired",
+		zap.String("match_id", match.MatchID),
+		zap.Strings("player_ids", playerIDs),
+		zap.Bool("acknowledged", acknowledged),
+	)
+	if acknowledged || match.ScheduledStartTime != nil {
+		return 0
+	}
+
+	requeued := s.requeueOrphanedPlayers(ctx, match)
+	if _, err := s.storage.UpdateMatchMetadata(ctx, match.MatchID, map[string]interface{}{
+		orphanRequeuedMetadataKey: true,
+	}); err != nil && !errors.Is(err, ErrMatchNotFound) {
+		logging.FromContext(ctx).Warn("Failed to mark orphaned match as requeued",
+			zap.String("match_id", match.MatchID),
+			zap.Error(err),
+		)
+	}
+	return requeued
+}
+
+// requeueOrphanedPlayers возвращает в очередь игроков брошенного матча со свежим
+// временем входа. Возвращает количество возвращенных игроков.
+func (s *MatcherService) requeueOrphanedPlayers(ctx context.Context, match *models.Match) int {
+	requeued := 0
+	now := time.Now()
+	for _, p := range match.HumanPlayers() {
+		current, err := s.storage.GetMatchByPlayerID(ctx, p.ID)
+		if err == nil && current.MatchID != match.MatchID {
+			continue // Игрок уже в другом матче
+		}
+
+		player := p
+		player.JoinedAt = now
+		if err := s.storage.AddPlayerToQueue(ctx, &player); err != nil {
+			if !errors.Is(err, ErrPlayerAlreadyQueued) {
+				logging.FromContext(ctx).Warn("Failed to requeue player of orphaned match",
+					zap.String("match_id", match.MatchID),
+					zap.String("player_id", p.ID),
+					zap.Error(err),
+				)
+			}
+			continue
+		}
+		requeued++
+	}
+
+	logging.FromContext(ctx).Warn("Orphaned match players requeued",
+		zap.String("match_id", match.MatchID),
+		zap.Int("requeued", requeued),
+	)
+	return requeued
+}
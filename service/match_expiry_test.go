@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/storage"
+	"go.uber.org/zap"
+)
+
+// expiredTestMatch возвращает матч двух игроков, срок которого уже истек
+func expiredTestMatch() *models.Match {
+	now := time.Now()
+	return &models.Match{
+		MatchID:   "expired",
+		CreatedAt: now.Add(-storage.MatchTTL),
+		ExpiresAt: now.Add(-time.Second),
+		GameMode:  "1v1",
+		Players: []models.Player{
+			{ID: "a", Rating: 1500, Region: "EU", GameMode: "1v1"},
+			{ID: "b", Rating: 1500, Region: "EU", GameMode: "1v1"},
+		},
+	}
+}
+
+// isQueued проверяет, что игрок стоит в очереди
+func isQueued(ctx context.Context, store storage.Storage, playerID string) bool {
+	_, err := store.GetPlayerQueuePosition(ctx, playerID)
+	return err == nil
+}
+
+func TestHandleMatchExpiryRequeuesOrphanedMatch(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewInMemoryStorage()
+	matcher := NewMatcherService(store, zap.NewNop(), DefaultMatcherConfig())
+
+	if err := store.SaveMatch(ctx, expiredTestMatch()); err != nil {
+		t.Fatalf("SaveMatch: %v", err)
+	}
+	if err := matcher.HandleMatchExpiry(ctx, storage.MatchExpiry{MatchID: "expired"}); err != nil {
+		t.Fatalf("HandleMatchExpiry: %v", err)
+	}
+	for _, id := range []string{"a", "b"} {
+		if !isQueued(ctx, store, id) {
+			t.Errorf("player %s of the orphaned match was not requeued", id)
+		}
+	}
+
+	// Повторная доставка записи не возвращает игроков второй раз
+	if err := store.RemovePlayerFromQueue(ctx, "a"); err != nil {
+		t.Fatalf("RemovePlayerFromQueue: %v", err)
+	}
+	if err := matcher.HandleMatchExpiry(ctx, storage.MatchExpiry{MatchID: "expired"}); err != nil {
+		t.Fatalf("HandleMatchExpiry: %v", err)
+	}
+	if isQueued(ctx, store, "a") {
+		t.Error("player requeued again on a repeated delivery")
+	}
+}
+
+func TestHandleMatchExpirySkipsAcknowledgedMatch(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewInMemoryStorage()
+	matcher := NewMatcherService(store, zap.NewNop(), DefaultMatcherConfig())
+
+	match := expiredTestMatch()
+	acknowledged := time.Now()
+	match.AcknowledgedAt = &acknowledged
+	if err := store.SaveMatch(ctx, match); err != nil {
+		t.Fatalf("SaveMatch: %v", err)
+	}
+	if err := matcher.HandleMatchExpiry(ctx, storage.MatchExpiry{MatchID: "expired"}); err != nil {
+		t.Fatalf("HandleMatchExpiry: %v", err)
+	}
+	if isQueued(ctx, store, "a") {
+		t.Error("player of an acknowledged match was requeued")
+	}
+}
+
+func TestHandleMatchExpiryIgnoresMissingMatch(t *testing.T) {
+	matcher := NewMatcherService(storage.NewInMemoryStorage(), zap.NewNop(), DefaultMatcherConfig())
+	if err := matcher.HandleMatchExpiry(context.Background(), storage.MatchExpiry{MatchID: "missing"}); err != nil {
+		t.Errorf("HandleMatchExpiry for a deleted match = %v, want nil", err)
+	}
+}
+
+func TestHandleMatchExpiryExpiresPendingMatch(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewInMemoryStorage()
+	matcher := NewMatcherService(store, zap.NewNop(), DefaultMatcherConfig())
+
+	players := expiredTestMatch().Players
+	for i := range players {
+		if err := store.AddPlayerToQueue(ctx, &players[i]); err != nil {
+			t.Fatalf("AddPlayerToQueue: %v", err)
+		}
+	}
+	pending := &models.PendingMatch{
+		PendingID: "pending",
+		PlayerIDs: []string{"a", "b"},
+		Players:   players,
+		Region:    "EU",
+		GameMode:  "1v1",
+		CreatedAt: time.Now().Add(-time.Minute),
+		ExpiresAt: time.Now().Add(-time.Second),
+	}
+	if err := store.CreatePendingMatch(ctx, pending); err != nil {
+		t.Fatalf("CreatePendingMatch: %v", err)
+	}
+	if _, err := store.AcceptPendingMatch(ctx, pending, "a"); err != nil {
+		t.Fatalf("AcceptPendingMatch: %v", err)
+	}
+
+	expiry := storage.MatchExpiry{MatchID: "pending", Pending: true, Region: "EU", GameMode: "1v1"}
+	if err := matcher.HandleMatchExpiry(ctx, expiry); err != nil {
+		t.Fatalf("HandleMatchExpiry: %v", err)
+	}
+	if !isQueued(ctx, store, "a") {
+		t.Error("player who accepted the pending match was not requeued")
+	}
+	if isQueued(ctx, store, "b") {
+		t.Error("player who did not accept the pending match was requeued")
+	}
+}
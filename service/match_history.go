@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"chrono-matchmaking/models"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// GetMatchHistory возвращает до limit последних матчей игрока, начиная с самого нового
+func (s *MatcherService) GetMatchHistory(ctx context.Context, playerID string, limit int) ([]*models.Match, error) {
+	ctx, span := startSpan(ctx, "GetMatchHistory", attribute.String("player_id", playerID))
+	defer span.End()
+
+	return s.storage.GetMatchHistory(ctx, playerID, limit)
+}
+
+// ReconnectToMatch возвращает матч, к которому игрок может вернуться после отключения.
+// completed — true, если активного матча уже нет (истек срок хранения) и возвращен
+// последний матч из истории игрока. Если нет ни того ни другого — ErrMatchNotFound.
+// В отличие от FindMatch новый поиск не начинается.
+func (s *MatcherService) ReconnectToMatch(ctx context.Context, playerID string) (match *models.Match, completed bool, err error) {
+	ctx, span := startSpan(ctx, "ReconnectToMatch", attribute.String("player_id", playerID))
+	defer span.End()
+
+	match, err = s.GetPlayerMatch(ctx, playerID)
+	if err == nil {
+		return match, false, nil
+	}
+	if !errors.Is(err, ErrMatchNotFound) {
+		return nil, false, err
+	}
+
+	history, err := s.storage.GetMatchHistory(ctx, playerID, 1)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(history) == 0 {
+		return nil, false, ErrMatchNotFound
+	}
+	return history[0], true, nil
+}
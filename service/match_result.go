@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/storage"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// ErrMatchResultReported возвращается при повторной отправке результата матча
+var ErrMatchResultReported = errors.New("match result already reported")
+
+// ReportMatchResult пересчитывает рейтинги участников матча по Elo, сохраняет их
+// и возвращает игроков с новыми рейтингами. K-фактор зависит от количества сыгранных
+// матчей из профиля (игроки без профиля считаются новичками), см. kFactorFor.
+// Результат применяется один раз: SaveMatchResultRatings атомарно закрепляет его
+// вместе с рейтингами всех игроков, и параллельный повторный запрос получает
+// ErrMatchResultReported. Если запись не удалась, не меняется ничего и запрос можно
+// повторить.
+func (s *MatcherService) ReportMatchResult(ctx context.Context, matchID string, winnerIDs []string) ([]models.Player, error) {
+	ctx, span := startSpan(ctx, "ReportMatchResult", attribute.String("match_id", matchID))
+	defer span.End()
+
+	match, err := s.storage.GetMatchByID(ctx, matchID)
+	if err != nil {
+		return nil, err
+	}
+	if _, reported := match.Metadata["winner_player_ids"]; reported {
+		return nil, ErrMatchResultReported
+	}
+
+	gamesPlayed := make(map[string]int, len(match.Players))
+	kFactors := make(map[string]float64, len(match.Players))
+	for _, p := range match.Players {
+		profile, err := s.storage.GetProfile(ctx, p.ID)
+		if errors.Is(err, ErrProfileNotFound) {
+			kFactors[p.ID] = s.kFactorFor(nil)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		gamesPlayed[p.ID] = profile.TotalMatches
+		kFactors[p.ID] = s.kFactorFor(profile)
+	}
+
+	players, err := NewELOCalculator(kFactors).UpdateRatings(match, winnerIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	calibrated, opponentRatings := s.completeCalibrations(ctx, match, winnerIDs, players)
+
+	// Результат проверен; рейтинги всех игроков записываются одним действием вместе с
+	// отметкой о результате, и дальше только этот запрос меняет статистику
+	ratings := make(map[string]int, len(players))
+	for i, p := range players {
+		if !match.Players[i].IsBotPlayer { // Рейтинг бота нигде не хранится
+			ratings[p.ID] = p.Rating
+		}
+	}
+	saved, err := s.storage.SaveMatchResultRatings(ctx, matchID, ratings)
+	if err != nil {
+		return nil, err
+	}
+	if !saved {
+		return nil, ErrMatchResultReported
+	}
+
+	s.recordCalibrationMatches(ctx, matchID, opponentRatings)
+
+	now := time.Now().UTC()
+	for i, p := range players {
+		if match.Players[i].IsBotPlayer {
+			continue
+		}
+		// Скорость роста рейтинга нужна для поиска смурфов; скачок рейтинга по итогам
+		// калибровки на смурфинг не указывает
+		if !calibrated[p.ID] {
+			if err := s.storage.RecordRatingChange(ctx, p.ID, p.Rating-match.Players[i].Rating, now); err != nil {
+				logging.FromContext(ctx).Warn("Failed to record rating change",
+					zap.String("match_id", matchID),
+					zap.String("player_id", p.ID),
+					zap.Error(err),
+				)
+			}
+		}
+		if err := s.RecordRatingSnapshot(ctx, p.ID, p.Rating, gamesPlayed[p.ID]+1); err != nil {
+			logging.FromContext(ctx).Warn("Failed to record rating snapshot",
+				zap.String("match_id", matchID),
+				zap.String("player_id", p.ID),
+				zap.Error(err),
+			)
+		}
+		if err := s.storage.IncrementTotalMatches(ctx, p.ID); err != nil {
+			logging.FromContext(ctx).Warn("Failed to increment total matches",
+				zap.String("match_id", matchID),
+				zap.String("player_id", p.ID),
+				zap.Error(err),
+			)
+		}
+	}
+
+	s.recordWinsAndLosses(ctx, match, winnerIDs)
+
+	if _, err := s.storage.UpdateMatchMetadata(ctx, matchID, map[string]interface{}{
+		"winner_player_ids": winnerIDs,
+	}); err != nil {
+		logging.FromContext(ctx).Warn("Failed to save match result",
+			zap.String("match_id", matchID),
+			zap.Error(err),
+		)
+	}
+
+	logging.FromContext(ctx).Info("Match result reported",
+		zap.String("match_id", matchID),
+		zap.Strings("winner_player_ids", winnerIDs),
+	)
+
+	return players, nil
+}
+
+// storedRating возвращает рейтинг игрока, сохраненный после его последнего матча.
+// Игрок, который еще не сыграл ни одного матча с результатом, встает в очередь с
+// рейтингом клиента clientRating; дальше рейтинг меняется только по результатам матчей.
+func (s *MatcherService) storedRating(ctx context.Context, playerID string, clientRating int) (int, error) {
+	rating, found, err := s.storage.GetPlayerRating(ctx, playerID)
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		return clientRating, nil
+	}
+	return rating, nil
+}
+
+// applyStoredRating заменяет рейтинг из запроса игрока сохраненным (см. storedRating)
+func (s *MatcherService) applyStoredRating(ctx context.Context, player *models.Player) error {
+	rating, err := s.storedRating(ctx, player.ID, player.Rating)
+	if err != nil {
+		return err
+	}
+	player.Rating = rating
+	return nil
+}
+
+// recordWinsAndLosses увеличивает счетчики побед и поражений участников матча,
+// продлевает их серии и пересчитывает импульс рейтинга (см. GetRatingMomentum)
+func (s *MatcherService) recordWinsAndLosses(ctx context.Context, match *models.Match, winnerIDs []string) {
+	winners := make(map[string]bool, len(winnerIDs))
+	for _, id := range winnerIDs {
+		winners[id] = true
+	}
+
+	for _, p := range match.HumanPlayers() {
+		stat := storage.StatLosses
+		if winners[p.ID] {
+			stat = storage.StatWins
+		}
+		if err := s.storage.IncrementStat(ctx, p.ID, stat, 1); err != nil {
+			logging.FromContext(ctx).Warn("Failed to record match outcome",
+				zap.String("match_id", match.MatchID),
+				zap.String("player_id", p.ID),
+				zap.Error(err),
+			)
+		}
+		if err := s.storage.RecordMatchOutcome(ctx, p.ID, winners[p.ID]); err != nil {
+			logging.FromContext(ctx).Warn("Failed to record win streak",
+				zap.String("match_id", match.MatchID),
+				zap.String("player_id", p.ID),
+				zap.Error(err),
+			)
+		}
+		// Импульс пересчитывается после каждой игры, и его TTL начинается заново
+		if _, err := s.refreshMomentum(ctx, p.ID); err != nil {
+			logging.FromContext(ctx).Warn("Failed to refresh rating momentum",
+				zap.String("match_id", match.MatchID),
+				zap.String("player_id", p.ID),
+				zap.Error(err),
+			)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/storage"
+	"go.uber.org/zap"
+)
+
+// failingResultStorage хранилище, в котором первая запись результата матча не удается
+type failingResultStorage struct {
+	storage.Storage
+	failures int
+}
+
+func (s *failingResultStorage) SaveMatchResultRatings(ctx context.Context, matchID string, ratings map[string]int) (bool, error) {
+	if s.failures > 0 {
+		s.failures--
+		return false, errors.New("connection reset")
+	}
+	return s.Storage.SaveMatchResultRatings(ctx, matchID, ratings)
+}
+
+// TestReportMatchResultRetryAfterFailure проверяет, что неудачная запись рейтингов
+// не закрепляет результат: рейтинги не меняются, а повторный запрос применяет его
+func TestReportMatchResultRetryAfterFailure(t *testing.T) {
+	ctx := context.Background()
+	store := &failingResultStorage{Storage: storage.NewInMemoryStorage(), failures: 1}
+	matcher := NewMatcherService(store, zap.NewNop(), DefaultMatcherConfig())
+
+	match := &models.Match{MatchID: "match", GameMode: "1v1", Players: []models.Player{
+		{ID: "winner", Rating: 1500, Region: "EU", GameMode: "1v1"},
+		{ID: "loser", Rating: 1500, Region: "EU", GameMode: "1v1"},
+	}}
+	if err := store.SaveMatch(ctx, match); err != nil {
+		t.Fatalf("SaveMatch: %v", err)
+	}
+
+	if _, err := matcher.ReportMatchResult(ctx, "match", []string{"winner"}); err == nil {
+		t.Fatal("ReportMatchResult succeeded despite the storage failure")
+	}
+	for _, id := range []string{"winner", "loser"} {
+		if _, found, _ := store.GetPlayerRating(ctx, id); found {
+			t.Errorf("rating of %s saved by the failed request", id)
+		}
+	}
+
+	if _, err := matcher.ReportMatchResult(ctx, "match", []string{"winner"}); err != nil {
+		t.Fatalf("retried ReportMatchResult: %v", err)
+	}
+	// Новичок с K=32 против равного соперника получает K/2
+	if rating, _, _ := store.GetPlayerRating(ctx, "winner"); rating != 1516 {
+		t.Errorf("winner rating = %d, want 1516", rating)
+	}
+	if rating, _, _ := store.GetPlayerRating(ctx, "loser"); rating != 1484 {
+		t.Errorf("loser rating = %d, want 1484", rating)
+	}
+
+	if _, err := matcher.ReportMatchResult(ctx, "match", []string{"winner"}); !errors.Is(err, ErrMatchResultReported) {
+		t.Errorf("third ReportMatchResult = %v, want ErrMatchResultReported", err)
+	}
+}
@@ -4,65 +4,232 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"chrono-matchmaking/broadcast"
+	"chrono-matchmaking/coordinator"
+	"chrono-matchmaking/logging"
 	"chrono-matchmaking/models"
+	"chrono-matchmaking/notification"
 	"chrono-matchmaking/storage"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
 // MatcherService управляет логикой поиска матчей
 type MatcherService struct {
-	storage      *storage.RedisStorage
-	logger       *zap.Logger
-	config       *MatcherConfig
-	gameServiceURL string // URL game-service для создания лобби
+	storage        storage.Storage
+	logger         *zap.Logger
+	config         atomic.Pointer[MatcherConfig]         // Текущая конфигурация, заменяется целиком
+	queueConfigs   atomic.Pointer[resolvedConfigs]       // Конфигурации очередей с переопределениями, см. configForContext
+	gameServiceURL string                                // URL game-service для создания лобби
+	coordinator    *coordinator.Coordinator              // Координация реплик; nil — единственный экземпляр
+	pushProvider   notification.PushNotificationProvider // Push-уведомления; nil — отключены
+	matchNotifier  *MatchNotifier                        // Уведомления подписанных игроков о матче; nil — отключены
+	algorithm      MatchingAlgorithm                     // Разбиение очереди на группы для матчей
+	serverRegistry *ServerRegistry                       // Назначение игровых серверов; nil — отключено
+	eventHub       *broadcast.Hub                        // События очереди для SSE-потока; nil — отключены
+	smurfDetector  *SmurfDetector                        // Поиск подозреваемых в смурфинге при входе в очередь
+	gameModeCache  sync.Map                              // Режим -> gameModeCacheEntry, см. GetPlayersPerMatch
+	population     *PopulationAdvisor                    // Подстройка конфигурации под число игроков в очереди
+	runConfigs     sync.Map                              // "регион:режим" -> *MatcherConfig текущего прохода ProcessQueue
+	bots           *BotFactory                           // Боты для матчей, которым не хватает одного игрока
+
+	topWaitingMu    sync.Mutex                      // Защищает topWaitingCache
+	topWaitingCache map[string]topWaitingCacheEntry // Кэш GetTopWaitingPlayers по "регион:режим"
+
+	growthMu    sync.Mutex                    // Защищает queueGrowth
+	queueGrowth map[string]*queueGrowthSample // Последний замер притока/оттока очереди по "регион:режим"
 }
 
 // MatcherConfig конфигурация матчмейкера
 type MatcherConfig struct {
-	MaxRatingDiff      int           // Максимальная разница рейтинга
-	MaxSearchTime      time.Duration // Максимальное время поиска матча
-	RatingExpansionRate int          // Скорость расширения диапазона рейтинга (в секундах)
-	PlayersPerMatch    int           // Количество игроков в матче (6 для 3x3)
+	MaxRatingDiff       int           `json:"max_rating_diff"`       // Максимальная разница рейтинга
+	MaxSearchTime       time.Duration `json:"max_search_time"`       // Максимальное время поиска матча
+	RatingExpansionRate int           `json:"rating_expansion_rate"` // Скорость расширения диапазона рейтинга (в секундах)
+	PlayersPerMatch     int           `json:"players_per_match"`     // Количество игроков в матче (6 для 3x3)
+
+	UseProvisionalPeriod bool                      `json:"use_provisional_period"` // Динамический K-фактор Elo в зависимости от числа матчей
+	RegionLatencyMatrix  map[string]map[string]int `json:"region_latency_matrix"`  // Ожидаемые задержки между регионами (мс)
+
+	AccountAgeMismatchPenalty float64       `json:"account_age_mismatch_penalty"` // Снижение качества матча, если новый аккаунт играет с ветераном
+	AccountAgeMatchBonus      float64       `json:"account_age_match_bonus"`      // Повышение качества матча для аккаунтов близкого возраста
+	MaxAccountAgeDiff         time.Duration `json:"max_account_age_diff"`         // Максимальная разница возраста аккаунтов для бонуса
+
+	AutoPurgeEnabled bool `json:"auto_purge_enabled"` // Очищать устаревших игроков при устойчивом росте очереди
+
+	MatchConfirmationEnabled bool `json:"match_confirmation_enabled"` // Создавать матч только после подтверждения всеми игроками
+
+	MaxWinRateDiff float64 `json:"max_win_rate_diff"` // Максимальная разница доли побед (0 — не учитывать)
+
+	RateLimit int `json:"rate_limit"` // Запросов на вход в очередь с одного IP в секунду (0 — без ограничения), применяется при запуске
+
+	CrossRegionFallback bool     `json:"cross_region_fallback"` // Добирать игроков из соседних регионов при малой очереди
+	FallbackRegions     []string `json:"fallback_regions"`      // Регионы, из которых добираются игроки, в порядке приоритета
+
+	CompositionRules map[string]models.CompositionRule `json:"composition_rules"` // Требования к ролям в команде по режиму игры
+
+	Algorithm string `json:"algorithm"` // Алгоритм подбора групп: "greedy" или "stable", применяется при запуске
+
+	LevelBrackets []LevelBracket `json:"level_brackets"` // Диапазоны уровней игроков; игроки из разных диапазонов не подбираются
+
+	AvoidRecentOpponentsDuration time.Duration `json:"avoid_recent_opponents_duration"` // Сколько не сводить недавних соперников (0 — не учитывать)
+
+	InactivityDecayThreshold time.Duration `json:"inactivity_decay_threshold"` // Перерыв в игре, после которого рейтинг снижается (0 — без снижения)
+	DecayPercentage          float64       `json:"decay_percentage"`           // На сколько процентов снижается рейтинг после перерыва
+	RatingFloor              int           `json:"rating_floor"`               // Ниже этого рейтинга снижение не опускает
+
+	MapPool                []string `json:"map_pool"`                 // Карты, из которых выбирается карта матча; пустой — карта не выбирается
+	MapCompatibilityWeight float64  `json:"map_compatibility_weight"` // Насколько медленнее расширяются допуски игроков без общих карт (0 — не учитывать, 0.5 — вдвое)
+
+	SmurfWindowGames     int `json:"smurf_window_games"`     // Сколько последних матчей учитывается при поиске смурфов (0 — не искать)
+	SmurfRatingThreshold int `json:"smurf_rating_threshold"` // Прирост рейтинга за эти матчи, при котором игрок считается подозрительным
+
+	MomentumBoost int `json:"momentum_boost"` // Сдвиг рейтинга поиска FindMatch за каждую победу серии длиннее 3 (0 — не сдвигать)
+
+	WorkerPoolSize int `json:"worker_pool_size"` // Сколько очередей обрабатывается одновременно (применяется при запуске)
+	MaxWorkers     int `json:"max_workers"`      // Верхняя граница пула воркеров при автомасштабировании
+
+	MaxQueueSize int `json:"max_queue_size"` // Максимум игроков в очереди региона и режима (0 — без ограничения)
+
+	LanguageMatchingEnabled bool `json:"language_matching_enabled"` // Подбирать только игроков с общими языками (PreferredLanguages)
+	MinLanguageOverlap      int  `json:"min_language_overlap"`      // Сколько общих языков должно быть у пары игроков
+
+	VoiceGroupBonus float64 `json:"voice_group_bonus"` // Повышение качества матча за каждую команду с общим языком голосового чата
+
+	MatchTTL time.Duration `json:"match_ttl"` // Сколько матч выдается игрокам; неподтвержденный сервером за это время считается брошенным
+
+	CalibrationGames int `json:"calibration_games"` // Сколько матчей новый игрок проводит в калибровочной очереди (0 — без калибровки)
+
+	OffPeakHoursUTC         [][2]int `json:"off_peak_hours_utc"`         // Окна непиковых часов [начало, конец) по UTC, например [[2, 6]]
+	OffPeakRatingMultiplier float64  `json:"off_peak_rating_multiplier"` // Во сколько раз в непиковые часы расширяется MaxRatingDiff
+
+	MaxAbandonsBeforeFlag int           `json:"max_abandons_before_flag"` // После скольких выходов из очереди за сутки игрок отмечается для проверки (0 — не отмечать)
+	AbandonFlagDuration   time.Duration `json:"abandon_flag_duration"`    // Сколько действует отметка за частые выходы из очереди
+
+	BotFillEnabled bool          `json:"bot_fill_enabled"`  // Добавлять бота в матч, которому не хватает одного игрока
+	BotFillMinWait time.Duration `json:"bot_fill_min_wait"` // Сколько должен прождать хотя бы один игрок группы, чтобы к ней добавили бота
+
+	Overrides map[string]*MatcherConfigOverride `json:"overrides,omitempty"` // Значения для отдельных очередей по ключу "{region}:{game_mode}"
 }
 
 // DefaultMatcherConfig возвращает конфигурацию по умолчанию
 func DefaultMatcherConfig() *MatcherConfig {
 	return &MatcherConfig{
-		MaxRatingDiff:      200,           // Начальная разница рейтинга
-		MaxSearchTime:      5 * time.Minute, // Максимальное время поиска
-		RatingExpansionRate: 50,           // +50 рейтинга каждые 30 секунд
-		PlayersPerMatch:    6,             // 3x3 матч (6 игроков) - используется как значение по умолчанию
+		MaxRatingDiff:        200,             // Начальная разница рейтинга
+		MaxSearchTime:        5 * time.Minute, // Максимальное время поиска
+		RatingExpansionRate:  50,              // +50 рейтинга каждые 30 секунд
+		PlayersPerMatch:      6,               // 3x3 матч (6 игроков) - используется как значение по умолчанию
+		UseProvisionalPeriod: false,           // По умолчанию K-фактор 32 до 100 матчей и 16 после
+		RegionLatencyMatrix:  DefaultRegionLatencyMatrix(),
+
+		AccountAgeMismatchPenalty: 0.05,                // -0.05 к качеству за пару новичок/ветеран
+		AccountAgeMatchBonus:      0.02,                // +0.02 к качеству для аккаунтов одного возраста
+		MaxAccountAgeDiff:         30 * 24 * time.Hour, // Аккаунты моложе месяца друг относительно друга
+
+		AutoPurgeEnabled: false, // По умолчанию очередь очищается только ежедневно
+
+		MatchConfirmationEnabled: false, // По умолчанию матч создается сразу
+
+		MaxWinRateDiff: 0.25, // Разница доли побед до 25 п.п., с ожиданием растет до 0.5
+
+		RateLimit: 5, // 5 попыток входа в очередь в секунду с одного IP
+
+		CrossRegionFallback: false, // По умолчанию матчи только внутри региона
+
+		CompositionRules: DefaultCompositionRules(),
+
+		Algorithm: AlgorithmGreedy,
+
+		LevelBrackets: DefaultLevelBrackets(),
+
+		AvoidRecentOpponentsDuration: 2 * time.Hour, // Соперники не встречаются повторно в течение 2 часов
+
+		InactivityDecayThreshold: 30 * 24 * time.Hour, // Рейтинг снижается после месяца без матчей
+		DecayPercentage:          5,                   // -5% рейтинга
+		RatingFloor:              seasonBaseRating,    // Не ниже базового рейтинга
+
+		MapCompatibilityWeight: 0, // По умолчанию предпочтения карт не влияют на подбор
+
+		SmurfWindowGames:     10,  // Последние 10 матчей
+		SmurfRatingThreshold: 400, // +400 рейтинга
+
+		MomentumBoost: 50, // Серия из 5 побед ищет соперников на 250 рейтинга выше
+
+		WorkerPoolSize: 4,  // До 4 очередей одновременно
+		MaxWorkers:     50, // Автомасштабирование не запускает больше 50 воркеров
+
+		MaxQueueSize: 10000, // Защита Redis от заполнения очереди ботами
+
+		LanguageMatchingEnabled: false, // По умолчанию языки на подбор не влияют
+		MinLanguageOverlap:      1,     // Достаточно одного общего языка
+
+		VoiceGroupBonus: 0.1, // +0.1 к качеству за каждую команду с общим голосовым чатом
+
+		MatchTTL: storage.MatchTTL, // 10 минут
+
+		CalibrationGames: 10, // Первые 10 матчей — калибровка
+
+		OffPeakHoursUTC:         nil, // Непиковые часы не заданы
+		OffPeakRatingMultiplier: 2.0, // Ночью диапазон рейтинга вдвое шире
+
+		MaxAbandonsBeforeFlag: 5,                  // Шестой выход за сутки отмечает игрока
+		AbandonFlagDuration:   7 * 24 * time.Hour, // Отметка снимается через неделю
+
+		BotFillEnabled: false,           // По умолчанию матчи только из живых игроков
+		BotFillMinWait: 2 * time.Minute, // Бот добавляется после 2 минут ожидания
 	}
 }
 
-// GetPlayersPerMatch возвращает количество игроков для режима игры
-func GetPlayersPerMatch(gameMode string) int {
-	switch gameMode {
-	case "1v1":
-		return 2 // 1 игрок против 1 игрока
-	case "3v3":
-		return 6 // 3 игрока против 3 игроков
-	default:
-		return 6 // По умолчанию 3v3
+// NewMatcherService создает новый сервис матчмейкинга с алгоритмом подбора из config.Algorithm
+// (жадным, если имя алгоритма неизвестно)
+func NewMatcherService(storage storage.Storage, logger *zap.Logger, config *MatcherConfig) *MatcherService {
+	if config == nil {
+		config = DefaultMatcherConfig()
 	}
+	algorithm, err := NewMatchingAlgorithm(config.Algorithm)
+	if err != nil {
+		logger.Warn("Unknown matching algorithm, using greedy", zap.String("algorithm", config.Algorithm))
+		algorithm = &GreedyAlgorithm{}
+	}
+	return NewMatcherServiceWithAlgorithm(storage, logger, config, algorithm)
 }
 
-// NewMatcherService создает новый сервис матчмейкинга
-func NewMatcherService(storage *storage.RedisStorage, logger *zap.Logger, config *MatcherConfig) *MatcherService {
+// NewMatcherServiceWithAlgorithm создает сервис матчмейкинга с заданным алгоритмом подбора групп.
+// Встроенным алгоритмам передаются правила совместимости игроков сервиса.
+func NewMatcherServiceWithAlgorithm(storage storage.Storage, logger *zap.Logger, config *MatcherConfig, algorithm MatchingAlgorithm) *MatcherService {
 	if config == nil {
 		config = DefaultMatcherConfig()
 	}
-	return &MatcherService{
-		storage:        storage,
-		logger:         logger,
-		config:         config,
-		gameServiceURL: "http://localhost:8081", // По умолчанию, можно изменить через SetGameServiceURL
+	s := &MatcherService{
+		storage:         storage,
+		logger:          logger,
+		gameServiceURL:  "http://localhost:8081", // По умолчанию, можно изменить через SetGameServiceURL
+		topWaitingCache: make(map[string]topWaitingCacheEntry),
+		queueGrowth:     make(map[string]*queueGrowthSample),
+		algorithm:       algorithm,
+		smurfDetector:   NewSmurfDetector(storage),
+	}
+	s.population = NewPopulationAdvisor(s)
+	s.bots = NewBotFactory(s)
+	s.config.Store(config)
+	storage.SetMaxQueueSize(config.MaxQueueSize)
+	if c, ok := algorithm.(constrainedAlgorithm); ok {
+		c.setConstraints(s.groupConstraints())
 	}
+	return s
+}
+
+// currentConfig возвращает текущую конфигурацию матчмейкера.
+// Возвращаемое значение нельзя изменять: обновления выполняются заменой указателя.
+func (s *MatcherService) currentConfig() *MatcherConfig {
+	return s.config.Load()
 }
 
 // SetGameServiceURL устанавливает URL game-service
@@ -70,13 +237,107 @@ func (s *MatcherService) SetGameServiceURL(url string) {
 	s.gameServiceURL = url
 }
 
+// SetCoordinator включает координацию нескольких реплик сервиса
+func (s *MatcherService) SetCoordinator(c *coordinator.Coordinator) {
+	s.coordinator = c
+}
+
+// SetPushProvider включает push-уведомления об изменении позиции в очереди
+func (s *MatcherService) SetPushProvider(p notification.PushNotificationProvider) {
+	s.pushProvider = p
+}
+
+// SetMatchNotifier включает уведомления подписанных игроков о созданных матчах
+func (s *MatcherService) SetMatchNotifier(n *MatchNotifier) {
+	s.matchNotifier = n
+}
+
+// BecomeLeader пытается сделать эту реплику лидером очереди региона/режима.
+// Без координатора реплика всегда считается лидером.
+func (s *MatcherService) BecomeLeader(ctx context.Context, region, gameMode string) (bool, error) {
+	ctx, span := startSpan(ctx, "BecomeLeader",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	if s.coordinator == nil {
+		return true, nil
+	}
+	return s.coordinator.BecomeLeader(ctx, region, gameMode)
+}
+
+// ResignLeadership снимает лидерство этой реплики для очереди региона/режима
+func (s *MatcherService) ResignLeadership(ctx context.Context, region, gameMode string) error {
+	ctx, span := startSpan(ctx, "ResignLeadership",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	if s.coordinator == nil {
+		return nil
+	}
+	return s.coordinator.ResignLeadership(ctx, region, gameMode)
+}
+
+// publishMatchFormed уведомляет подписанных игроков, публикует матч для внешних
+// сервисов и рассылает событие о созданном матче остальным репликам
+func (s *MatcherService) publishMatchFormed(ctx context.Context, region, gameMode string, match *models.Match) {
+	if s.matchNotifier != nil {
+		s.matchNotifier.Notify(match)
+	}
+	s.publishEvent(models.QueueEventMatchCreated, models.QueueEvent{
+		Region:   region,
+		GameMode: gameMode,
+		Match:    match,
+	})
+	if err := s.storage.PublishMatch(ctx, region, gameMode, match); err != nil {
+		logging.FromContext(ctx).Warn("Failed to publish match event",
+			zap.String("match_id", match.MatchID),
+			zap.Error(err),
+		)
+	}
+	if s.coordinator == nil {
+		return
+	}
+	if err := s.coordinator.PublishMatchFormed(ctx, region, gameMode, match); err != nil {
+		logging.FromContext(ctx).Warn("Failed to publish match formed event",
+			zap.String("match_id", match.MatchID),
+			zap.Error(err),
+		)
+	}
+}
+
+// GetPlayerMatch возвращает уже созданный матч игрока или ErrMatchNotFound.
+// В отличие от FindMatch не пытается собрать новый матч.
+func (s *MatcherService) GetPlayerMatch(ctx context.Context, playerID string) (*models.Match, error) {
+	ctx, span := startSpan(ctx, "GetPlayerMatch", attribute.String("player_id", playerID))
+	defer span.End()
+
+	match, err := s.storage.GetMatchByPlayerID(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+	if isScheduledForLater(match) {
+		return nil, ErrMatchNotFound
+	}
+	return match, nil
+}
+
 // FindMatch пытается найти матч для игрока
 func (s *MatcherService) FindMatch(ctx context.Context, playerID string) (*models.Match, error) {
+	ctx, span := startSpan(ctx, "FindMatch", attribute.String("player_id", playerID))
+	defer span.End()
+
 	// Сначала проверяем, есть ли уже сохраненный матч для этого игрока
 	savedMatch, err := s.storage.GetMatchByPlayerID(ctx, playerID)
+	if err == nil && savedMatch != nil && isScheduledForLater(savedMatch) {
+		return nil, fmt.Errorf("match %s starts at %s", savedMatch.MatchID, savedMatch.ScheduledStartTime.Format(time.RFC3339))
+	}
 	if err == nil && savedMatch != nil {
 		// Матч уже найден и сохранен
-		s.logger.Info("Returning saved match",
+		logging.FromContext(ctx).Info("Returning saved match",
 			zap.String("match_id", savedMatch.MatchID),
 			zap.String("player_id", playerID),
 		)
@@ -89,20 +350,50 @@ func (s *MatcherService) FindMatch(ctx context.Context, playerID string) (*model
 		return nil, fmt.Errorf("player not found in queue: %w", err)
 	}
 
+	// Матчи для групп собирает ProcessQueue, который рассаживает группы по командам
+	if currentPlayer.PartyID != "" {
+		return nil, fmt.Errorf("no suitable match found")
+	}
+
+	// Игрок с несколькими режимами поиска получает матч в первом режиме, где он нашелся
+	for _, mode := range currentPlayer.QueueGameModes() {
+		player := *currentPlayer
+		player.GameMode = mode
+		match, findErr := s.findMatchInMode(ctx, &player)
+		if findErr == nil || errors.Is(findErr, ErrMatchAwaitingConfirmation) {
+			return match, findErr
+		}
+		err = findErr
+	}
+	return nil, err
+}
+
+// findMatchInMode ищет матч для игрока в очереди режима currentPlayer.GameMode
+func (s *MatcherService) findMatchInMode(ctx context.Context, currentPlayer *models.Player) (*models.Match, error) {
+	playerID := currentPlayer.ID
+
 	// Определяем количество игроков для данного режима
-	playersPerMatch := GetPlayersPerMatch(currentPlayer.GameMode)
+	playersPerMatch, err := s.GetPlayersPerMatch(ctx, currentPlayer.GameMode)
+	if err != nil {
+		return nil, err
+	}
 
 	// Вычисляем динамический диапазон рейтинга на основе времени ожидания
 	waitTime := time.Since(currentPlayer.JoinedAt)
-	ratingRange := s.calculateRatingRange(waitTime)
+	ratingRange := s.calculateRatingRange(currentPlayer.Region, currentPlayer.GameMode, waitTime)
+	if currentPlayer.IsCalibrating && ratingRange < calibrationMaxRatingDiff {
+		ratingRange = calibrationMaxRatingDiff
+	}
 
-	// Ищем подходящих игроков (нужно больше кандидатов, так как будем фильтровать)
+	// Ищем подходящих игроков (нужно больше кандидатов, так как будем фильтровать).
+	// Игрок на серии побед ищет соперников выше своего рейтинга, см. searchRating.
+	rating := s.searchRating(ctx, currentPlayer)
 	candidates, err := s.storage.GetPlayersInRange(
 		ctx,
 		currentPlayer.Region,
 		currentPlayer.GameMode,
-		currentPlayer.Rating-ratingRange,
-		currentPlayer.Rating+ratingRange,
+		rating-ratingRange,
+		rating+ratingRange,
 		int64(playersPerMatch*2), // Берем больше кандидатов для фильтрации
 	)
 
@@ -110,210 +401,628 @@ func (s *MatcherService) FindMatch(ctx context.Context, playerID string) (*model
 		return nil, fmt.Errorf("failed to get candidates: %w", err)
 	}
 
-	// Фильтруем кандидатов (исключаем самого игрока и проверяем совместимость)
-	matchPlayers := make([]models.Player, 0, playersPerMatch)
-	matchPlayers = append(matchPlayers, *currentPlayer)
-
-	for _, candidate := range candidates {
-		if candidate.ID == playerID {
-			continue // Пропускаем самого игрока
-		}
+	// Фильтруем кандидатов (исключаем самого игрока и проверяем совместимость).
+	// Сначала добираем игроков с тем же языком голосового чата, затем остальных.
+	// Разница рейтинга проверяется от рейтинга поиска, в матч игрок попадает со своим.
+	searcher := *currentPlayer
+	searcher.Rating = rating
+	group := []*models.Player{currentPlayer}
+	picked := map[string]bool{playerID: true}
 
-		if s.isCompatible(currentPlayer, candidate) {
-			matchPlayers = append(matchPlayers, *candidate)
-			if len(matchPlayers) >= playersPerMatch {
+	for pass := 0; pass < 2 && len(group) < playersPerMatch; pass++ {
+		for _, candidate := range candidates {
+			if len(group) >= playersPerMatch {
 				break
 			}
+			if picked[candidate.ID] {
+				continue // Пропускаем самого игрока и уже выбранных
+			}
+			if candidate.PartyID != "" {
+				continue // Группы подбираются целиком в ProcessQueue
+			}
+			if pass == 0 && !sharesVoiceLanguage(currentPlayer, candidate) {
+				continue
+			}
+
+			if s.isCompatible(&searcher, candidate) && voiceCompatibleWithGroup(group, candidate) {
+				group = append(group, candidate)
+				picked[candidate.ID] = true
+			}
 		}
 	}
 
+	matchPlayers := make([]models.Player, 0, len(group))
+	for _, p := range group {
+		matchPlayers = append(matchPlayers, *p)
+	}
+
 	// Если нашли достаточно игроков, создаем матч
 	if len(matchPlayers) >= playersPerMatch {
-		match := &models.Match{
-			MatchID:   fmt.Sprintf("match_%d", time.Now().UnixNano()),
-			Players:   matchPlayers,
-			CreatedAt: time.Now(),
+		// Матч станет настоящим, только когда его подтвердят все игроки
+		if s.configForContext(currentPlayer.Region, currentPlayer.GameMode).MatchConfirmationEnabled {
+			if _, err := s.createPendingMatch(ctx, currentPlayer.Region, currentPlayer.GameMode, matchPlayers); err != nil {
+				return nil, fmt.Errorf("failed to create pending match: %w", err)
+			}
+			return nil, ErrMatchAwaitingConfirmation
 		}
 
-		// Сохраняем матч для всех игроков ПЕРЕД удалением из очереди
-		if err := s.storage.SaveMatch(ctx, match); err != nil {
-			s.logger.Warn("Failed to save match",
-				zap.String("match_id", match.MatchID),
-				zap.Error(err),
-			)
-		}
+		match := s.buildMatch(matchPlayers)
+		s.assignServer(ctx, match)
 
-		// Удаляем игроков из очереди
-		for _, p := range matchPlayers {
-			if err := s.storage.RemovePlayerFromQueue(ctx, p.ID); err != nil {
-				s.logger.Warn("Failed to remove player from queue",
-					zap.String("player_id", p.ID),
-					zap.Error(err),
-				)
-			}
+		// Атомарно сохраняем матч и удаляем игроков из очереди
+		if err := s.formMatch(ctx, match); err != nil {
+			s.releaseServer(ctx, match)
+			return nil, fmt.Errorf("failed to form match: %w", err)
 		}
 
-		s.logger.Info("Match found",
+		logging.FromContext(ctx).Info("Match found",
 			zap.String("match_id", match.MatchID),
 			zap.Int("players_count", len(matchPlayers)),
 		)
 
 		// Создаем лобби в game-service
 		if err := s.createLobbyInGameService(ctx, match); err != nil {
-			s.logger.Warn("Failed to create lobby in game-service",
+			logging.FromContext(ctx).Warn("Failed to create lobby in game-service",
 				zap.String("match_id", match.MatchID),
 				zap.Error(err),
 			)
 			// Не возвращаем ошибку, так как матч уже создан
 		}
 
+		s.recordMatchStats(ctx, currentPlayer.Region, currentPlayer.GameMode, match)
+		s.publishMatchFormed(ctx, currentPlayer.Region, currentPlayer.GameMode, match)
+
 		return match, nil
 	}
 
 	return nil, fmt.Errorf("no suitable match found")
 }
 
-// calculateRatingRange вычисляет динамический диапазон рейтинга на основе времени ожидания
-func (s *MatcherService) calculateRatingRange(waitTime time.Duration) int {
-	if waitTime > s.config.MaxSearchTime {
+// buildMatch создает матч из подобранных игроков.
+// Первая половина игроков образует команду A, вторая — команду B. Без групп игроки
+// перераспределяются по командам для баланса рейтинга; с группами или требованиями
+// к ролям сохраняется переданная расстановка, чтобы группа не разделилась, а роли не смешались.
+func (s *MatcherService) buildMatch(players []models.Player) *models.Match {
+	half := len(players) / 2
+
+	var teams models.TeamAssignment
+	if hasParty(players) || s.hasCompositionRule(players[0].GameMode) {
+		teams = models.TeamAssignment{players[:half], players[half:]}
+	} else {
+		teams = models.NewBalancedTeamAssignment(players)
+		players = append(append(make([]models.Player, 0, len(players)), teams[0]...), teams[1]...)
+	}
+
+	serverRegion := s.selectServerRegion(players)
+
+	isCrossRegion := false
+	for _, p := range players {
+		if p.Region != serverRegion {
+			isCrossRegion = true
+			break
+		}
+	}
+
+	group := make([]*models.Player, len(players))
+	for i := range players {
+		group[i] = &players[i]
+	}
+
+	config := s.configForContext(players[0].Region, players[0].GameMode)
+	now := time.Now()
+
+	teamAVoice := teamVoiceCompatible(players[:half])
+	teamBVoice := teamVoiceCompatible(players[half:])
+	voiceBonus := VoiceGroupBonus(config.VoiceGroupBonus, teamAVoice, teamBVoice)
+
+	return &models.Match{
+		MatchID:              fmt.Sprintf("match_%d", now.UnixNano()),
+		Players:              players,
+		GameMode:             players[0].GameMode,
+		CreatedAt:            now,
+		ExpiresAt:            now.Add(config.MatchTTL),
+		TeamAVoiceCompatible: teamAVoice,
+		TeamBVoiceCompatible: teamBVoice,
+		Teams:                teams,
+		IsCrossRegion:        isCrossRegion,
+		ServerRegion:         serverRegion,
+		QualityScore:         math.Min(1, ComputeMatchQuality(players)+voiceBonus),
+		VoiceGroupBonus:      voiceBonus,
+		MapName:              SelectMap(group, config.MapPool),
+	}
+}
+
+// calculateRatingRange вычисляет динамический диапазон рейтинга очереди на основе времени ожидания
+func (s *MatcherService) calculateRatingRange(region, gameMode string, waitTime time.Duration) int {
+	config := s.configForContext(region, gameMode)
+	if waitTime > config.MaxSearchTime {
 		return 1000 // Максимальный диапазон после максимального времени ожидания
 	}
 
 	// Расширяем диапазон каждые 30 секунд
 	expansionCount := int(waitTime.Seconds()) / 30
-	return s.config.MaxRatingDiff + (expansionCount * s.config.RatingExpansionRate)
+	return config.MaxRatingDiff + (expansionCount * config.RatingExpansionRate)
+}
+
+// calculateWinRateRange вычисляет допустимую разницу доли побед: она линейно растет
+// от MaxWinRateDiff до 0.5 за MaxSearchTime, как диапазон рейтинга в calculateRatingRange
+func (s *MatcherService) calculateWinRateRange(region, gameMode string, waitTime time.Duration) float64 {
+	config := s.configForContext(region, gameMode)
+	if config.MaxWinRateDiff <= 0 || config.MaxWinRateDiff >= maxRelaxedWinRateDiff {
+		return config.MaxWinRateDiff
+	}
+	if waitTime >= config.MaxSearchTime {
+		return maxRelaxedWinRateDiff
+	}
+
+	progress := waitTime.Seconds() / config.MaxSearchTime.Seconds()
+	return config.MaxWinRateDiff + (maxRelaxedWinRateDiff-config.MaxWinRateDiff)*progress
 }
 
+// maxRelaxedWinRateDiff разница доли побед, до которой допуск расширяется при долгом ожидании
+const maxRelaxedWinRateDiff = 0.5
+
 // isCompatible проверяет совместимость двух игроков
 func (s *MatcherService) isCompatible(p1, p2 *models.Player) bool {
+	// Игроки одной группы уже собраны вместе
+	if p1.PartyID != "" && p1.PartyID == p2.PartyID {
+		return true
+	}
+
 	// Проверяем регион
 	if p1.Region != p2.Region {
 		return false
 	}
 
+	if !s.isSkillCompatible(p1, p2) {
+		return false
+	}
+
+	if !s.isLanguageCompatible(p1, p2) {
+		return false
+	}
+
+	// Недавних соперников не сводим снова, пока ожидание не затянулось
+	return !s.haveRecentlyMet(p1, p2)
+}
+
+// longestWait возвращает ожидание того из двух игроков, кто ждет дольше
+func longestWait(p1, p2 *models.Player) time.Duration {
+	if p2.JoinedAt.Before(p1.JoinedAt) {
+		return time.Since(p2.JoinedAt)
+	}
+	return time.Since(p1.JoinedAt)
+}
+
+// isSkillCompatible проверяет совместимость двух игроков без учета региона
+func (s *MatcherService) isSkillCompatible(p1, p2 *models.Player) bool {
 	// Проверяем режим игры
 	if p1.GameMode != p2.GameMode {
 		return false
 	}
 
-	// Проверяем разницу рейтинга
+	// Подозреваемые в смурфинге и калибрующиеся игроки играют только друг с другом
+	if p1.IsSuspicious != p2.IsSuspicious || p1.IsCalibrating != p2.IsCalibrating {
+		return false
+	}
+
+	// Проверяем разницу рейтинга: чем менее надежен рейтинг игроков (Glicko-2),
+	// тем шире допустимое окно
 	ratingDiff := int(math.Abs(float64(p1.Rating - p2.Rating)))
-	return ratingDiff <= s.config.MaxRatingDiff
+	if ratingDiff > s.maxRatingDiff(p1)+2*(p1.RatingDeviation+p2.RatingDeviation) {
+		return false
+	}
+
+	// Новые аккаунты не должны попадать к игрокам намного выше уровнем
+	if !s.isLevelCompatible(p1, p2) {
+		return false
+	}
+
+	// Проверяем разницу доли побед, чтобы игроки на серии поражений не ломали баланс.
+	// Допуск считается по игроку, который ждет дольше
+	waitTime := s.pairWaitTime(p1, p2)
+	maxWinRateDiff := s.calculateWinRateRange(p1.Region, p1.GameMode, waitTime)
+	return maxWinRateDiff <= 0 || math.Abs(p1.WinRate-p2.WinRate) <= maxWinRateDiff
 }
 
-// AddPlayerToQueue добавляет игрока в очередь
+// ErrPlayerAlreadyQueued возвращается, если игрок уже находится в очереди
+var ErrPlayerAlreadyQueued = storage.ErrPlayerAlreadyQueued
+
+// ErrQueueFull возвращается, если очередь региона и режима достигла MaxQueueSize
+var ErrQueueFull = storage.ErrQueueFull
+
+// AddPlayerToQueue добавляет игрока в очередь. Забаненному игроку возвращается
+// *PlayerBannedError (ErrPlayerBanned), недавно отказавшемуся от матча — ErrPlayerOnCooldown,
+// отмеченному за частые выходы из очереди — ErrPlayerFlagged. Рейтинг из запроса
+// заменяется сохраненным после последнего матча игрока, если он есть.
 func (s *MatcherService) AddPlayerToQueue(ctx context.Context, player *models.Player) error {
-	return s.storage.AddPlayerToQueue(ctx, player)
+	ctx, span := startSpan(ctx, "AddPlayerToQueue",
+		attribute.String("player_id", player.ID),
+		attribute.String("region", player.Region),
+		attribute.String("game_mode", player.GameMode),
+	)
+	defer span.End()
+
+	if err := s.checkBan(ctx, player.ID); err != nil {
+		return err
+	}
+	if err := s.checkFlagged(ctx, player.ID); err != nil {
+		return err
+	}
+
+	cooldown, err := s.storage.GetQueueCooldown(ctx, player.ID)
+	if err != nil {
+		return err
+	}
+	if cooldown > 0 {
+		return fmt.Errorf("%w for %s", ErrPlayerOnCooldown, cooldown.Round(time.Second))
+	}
+	if err := s.applyStoredRating(ctx, player); err != nil {
+		return err
+	}
+
+	s.markSuspicious(ctx, player)
+	s.markCalibrating(ctx, player)
+	if err := s.storage.AddPlayerToQueue(ctx, player); err != nil {
+		if errors.Is(err, ErrQueueFull) {
+			s.recordQueueFull(player)
+		}
+		return err
+	}
+
+	s.updateQueueDepth(ctx, player.Region, player.GameMode)
+	s.publishQueueJoin(player)
+	return nil
 }
 
-// RemovePlayerFromQueue удаляет игрока из очереди
+// AddPlayersToQueue добавляет в очередь сразу нескольких игроков с теми же проверками,
+// что и AddPlayerToQueue. Возвращает ошибку для каждого игрока в порядке players (nil — добавлен).
+func (s *MatcherService) AddPlayersToQueue(ctx context.Context, players []*models.Player) []error {
+	ctx, span := startSpan(ctx, "AddPlayersToQueue")
+	defer span.End()
+
+	errs := make([]error, len(players))
+	eligible := make([]*models.Player, 0, len(players))
+	indexes := make([]int, 0, len(players))
+	for i, player := range players {
+		if err := s.checkBan(ctx, player.ID); err != nil {
+			errs[i] = err
+			continue
+		}
+		if err := s.checkFlagged(ctx, player.ID); err != nil {
+			errs[i] = err
+			continue
+		}
+		cooldown, err := s.storage.GetQueueCooldown(ctx, player.ID)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		if cooldown > 0 {
+			errs[i] = fmt.Errorf("%w for %s", ErrPlayerOnCooldown, cooldown.Round(time.Second))
+			continue
+		}
+		if err := s.applyStoredRating(ctx, player); err != nil {
+			errs[i] = err
+			continue
+		}
+		s.markSuspicious(ctx, player)
+		s.markCalibrating(ctx, player)
+		eligible = append(eligible, player)
+		indexes = append(indexes, i)
+	}
+	if len(eligible) == 0 {
+		return errs
+	}
+
+	type queueRef struct{ region, gameMode string }
+	updated := make(map[queueRef]bool)
+	for j, err := range s.storage.AddPlayersToQueue(ctx, eligible) {
+		errs[indexes[j]] = err
+		if errors.Is(err, ErrQueueFull) {
+			s.recordQueueFull(eligible[j])
+		}
+		if err == nil {
+			updated[queueRef{eligible[j].Region, eligible[j].GameMode}] = true
+			s.publishQueueJoin(eligible[j])
+		}
+	}
+	for q := range updated {
+		s.updateQueueDepth(ctx, q.region, q.gameMode)
+	}
+
+	return errs
+}
+
+// RemovePlayerFromQueue удаляет игрока из очереди по его запросу. Выход игрока группы
+// убирает из очереди всю группу; выход засчитывается только самому игроку (recordAbandon).
 func (s *MatcherService) RemovePlayerFromQueue(ctx context.Context, playerID string) error {
-	return s.storage.RemovePlayerFromQueue(ctx, playerID)
+	ctx, span := startSpan(ctx, "RemovePlayerFromQueue", attribute.String("player_id", playerID))
+	defer span.End()
+
+	if err := s.removePlayerFromQueue(ctx, playerID); err != nil {
+		return err
+	}
+	s.recordAbandon(ctx, playerID)
+	return nil
 }
 
-// GetQueueSize возвращает размер очереди
+// removePlayerFromQueue удаляет игрока из очереди, не засчитывая выход
+func (s *MatcherService) removePlayerFromQueue(ctx context.Context, playerID string) error {
+	player, err := s.storage.GetPlayerByID(ctx, playerID)
+	if err != nil {
+		// Ключа игрока нет — storage вернет ошибку "player not found"
+		return s.storage.RemovePlayerFromQueue(ctx, playerID)
+	}
+
+	if player.PartyID != "" {
+		err = s.removePartyFromQueue(ctx, player.PartyID)
+	} else {
+		err = s.storage.RemovePlayerFromQueue(ctx, playerID)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.updateQueueDepth(ctx, player.Region, player.GameMode)
+	s.publishEvent(models.QueueEventLeave, models.QueueEvent{
+		Region:   player.Region,
+		GameMode: player.GameMode,
+		PlayerID: player.ID,
+	})
+	return nil
+}
+
+// GetQueueSize возвращает количество игроков в обычной и приоритетной очередях
 func (s *MatcherService) GetQueueSize(ctx context.Context, region, gameMode string) (int64, error) {
+	ctx, span := startSpan(ctx, "GetQueueSize",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
 	return s.storage.GetQueueSize(ctx, region, gameMode)
 }
 
+// GetQueueSizes возвращает размеры обычной и приоритетной очередей
+func (s *MatcherService) GetQueueSizes(ctx context.Context, region, gameMode string) (standard, premium int64, err error) {
+	ctx, span := startSpan(ctx, "GetQueueSizes",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	return s.storage.GetQueueSizes(ctx, region, gameMode)
+}
+
+// GetMatchByID возвращает сохраненный матч по его ID
+func (s *MatcherService) GetMatchByID(ctx context.Context, matchID string) (*models.Match, error) {
+	ctx, span := startSpan(ctx, "GetMatchByID", attribute.String("match_id", matchID))
+	defer span.End()
+
+	return s.storage.GetMatchByID(ctx, matchID)
+}
+
 // ProcessQueue обрабатывает очередь и пытается найти матчи
 func (s *MatcherService) ProcessQueue(ctx context.Context, region, gameMode string) error {
+	ctx, span := startSpan(ctx, "ProcessQueue",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	// Обновляем метрики состояния очереди
+	if err := s.updateQueueHealthMetrics(ctx, region, gameMode); err != nil {
+		logging.FromContext(ctx).Warn("Failed to update queue health metrics",
+			zap.String("region", region),
+			zap.String("game_mode", gameMode),
+			zap.Error(err),
+		)
+	}
+
+	// Удаляем игроков, оставшихся в очереди после остановки предыдущего лидера
+	s.removeDrainedPlayers(ctx, region, gameMode)
+
+	// При устойчивом росте очереди очищаем ее, не дожидаясь ежедневного запуска
+	if _, err := s.AutoPurgeStalePlayers(ctx, region, gameMode); err != nil {
+		logging.FromContext(ctx).Warn("Failed to auto-purge queue",
+			zap.String("region", region),
+			zap.String("game_mode", gameMode),
+			zap.Error(err),
+		)
+	}
+
+	// В непиковые часы и в малолюдной очереди проход идет с расширенным диапазоном рейтинга
+	runConfig := s.queueConfig(region, gameMode)
+	if IsOffPeak(time.Now(), runConfig.OffPeakHoursUTC) {
+		runConfig = offPeakConfig(runConfig)
+	}
+	adjusted, err := s.population.AdjustConfig(ctx, region, gameMode, runConfig)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to adjust config to queue population",
+			zap.String("region", region),
+			zap.String("game_mode", gameMode),
+			zap.Error(err),
+		)
+	} else {
+		runConfig = adjusted
+	}
+	key := overrideKey(region, gameMode)
+	s.runConfigs.Store(key, runConfig)
+	defer s.runConfigs.CompareAndDelete(key, runConfig)
+
 	// Определяем количество игроков для данного режима
-	playersPerMatch := GetPlayersPerMatch(gameMode)
+	playersPerMatch, err := s.GetPlayersPerMatch(ctx, gameMode)
+	if err != nil {
+		return err
+	}
 
-	// Получаем всех игроков в очереди для данного региона и режима
-	players, err := s.storage.GetPlayersInRange(ctx, region, gameMode, 0, math.MaxInt, 100)
+	// Получаем до 100 игроков очереди, начиная с самых загруженных рейтинговых сегментов
+	players, err := s.queueCandidates(ctx, region, gameMode)
 	if err != nil {
 		return fmt.Errorf("failed to get players: %w", err)
 	}
 
 	if len(players) < playersPerMatch {
-		return nil // Недостаточно игроков для создания матча
+		// Недостаточно игроков для создания матча: пробуем добрать их из соседних регионов,
+		// а если не вышло — ботом
+		match, err := s.tryFallbackMatch(ctx, region, gameMode, players)
+		if err != nil {
+			logging.FromContext(ctx).Warn("Failed to create cross-region match",
+				zap.String("region", region),
+				zap.String("game_mode", gameMode),
+				zap.Error(err),
+			)
+		}
+		if match == nil {
+			s.fillWithBot(ctx, region, gameMode, players)
+		}
+		return nil
 	}
 
-	// Используем алгоритм жадного поиска для формирования групп
-	used := make(map[string]bool) // Отслеживаем использованных игроков
-
-	for i := 0; i < len(players); i++ {
-		if used[players[i].ID] {
-			continue
+	// Подписчики ждут в приоритетной очереди: сначала матчи собираются только из них,
+	// затем оставшиеся подписчики подбираются вместе с обычной очередью.
+	// Совместимость игроков проверяется в обоих проходах одинаково.
+	premium := make([]*models.Player, 0)
+	for _, p := range players {
+		if p.IsPremium {
+			premium = append(premium, p)
 		}
+	}
+	var matched map[string]bool
+	if len(premium) >= playersPerMatch {
+		matched = s.formMatches(ctx, region, gameMode, premium)
+	}
 
-		// Начинаем формировать группу с текущего игрока
-		group := []*models.Player{players[i]}
-		used[players[i].ID] = true
-
-		// Ищем совместимых игроков для группы
-		for j := 0; j < len(players) && len(group) < playersPerMatch; j++ {
-			if used[players[j].ID] {
-				continue
-			}
+	remaining := make([]*models.Player, 0, len(players))
+	for _, p := range players {
+		if !matched[p.ID] {
+			remaining = append(remaining, p)
+		}
+	}
+	matched = s.formMatches(ctx, region, gameMode, remaining)
 
-			// Проверяем совместимость с первым игроком группы
-			if s.isCompatible(group[0], players[j]) {
-				group = append(group, players[j])
-				used[players[j].ID] = true
-			}
+	// Игрокам, для которых не нашлось полного матча, может достаться матч с ботом
+	leftover := make([]*models.Player, 0, len(remaining))
+	for _, p := range remaining {
+		if !matched[p.ID] {
+			leftover = append(leftover, p)
 		}
+	}
+	s.fillWithBot(ctx, region, gameMode, leftover)
 
-		// Если собрали группу из нужного количества игроков, создаем матч
-		if len(group) >= playersPerMatch {
-			matchPlayers := make([]models.Player, 0, len(group))
-			for _, p := range group {
-				matchPlayers = append(matchPlayers, *p)
-			}
+	s.NotifyQueuePositionChange(ctx, region, gameMode)
 
-			match := &models.Match{
-				MatchID:   fmt.Sprintf("match_%d", time.Now().UnixNano()),
-				Players:   matchPlayers,
-				CreatedAt: time.Now(),
-			}
+	return nil
+}
 
-			// Сохраняем матч для всех игроков ПЕРЕД удалением из очереди
-			if err := s.storage.SaveMatch(ctx, match); err != nil {
-				s.logger.Warn("Failed to save match",
-					zap.String("match_id", match.MatchID),
+// formMatches разбивает игроков на группы алгоритмом подбора и создает из них матчи
+// (или матчи, ожидающие подтверждения). Возвращает ID игроков, попавших в матчи.
+func (s *MatcherService) formMatches(ctx context.Context, region, gameMode string, players []*models.Player) map[string]bool {
+	matched := make(map[string]bool)
+	config := s.configForContext(region, gameMode)
+
+	for _, matchPlayers := range s.arrangeMatchGroups(ctx, region, gameMode, players) {
+		// Матч станет настоящим, только когда его подтвердят все игроки
+		if config.MatchConfirmationEnabled {
+			pending, err := s.createPendingMatch(ctx, region, gameMode, matchPlayers)
+			if err != nil {
+				logging.FromContext(ctx).Warn("Failed to create pending match",
+					zap.String("region", region),
+					zap.String("game_mode", gameMode),
 					zap.Error(err),
 				)
+				continue
 			}
-
-			// Удаляем игроков из очереди
-			for _, p := range group {
-				if err := s.storage.RemovePlayerFromQueue(ctx, p.ID); err != nil {
-					s.logger.Warn("Failed to remove player from queue",
-						zap.String("player_id", p.ID),
-						zap.Error(err),
-					)
-				}
+			for _, p := range matchPlayers {
+				matched[p.ID] = true
 			}
-
-			s.logger.Info("Match created from queue processing",
-				zap.String("match_id", match.MatchID),
+			logging.FromContext(ctx).Info("Match awaiting confirmation",
+				zap.String("pending_id", pending.PendingID),
 				zap.Int("players_count", len(matchPlayers)),
 				zap.String("region", region),
 				zap.String("game_mode", gameMode),
 			)
+			continue
+		}
 
-			// Создаем лобби в game-service
-			if err := s.createLobbyInGameService(ctx, match); err != nil {
-				s.logger.Warn("Failed to create lobby in game-service",
-					zap.String("match_id", match.MatchID),
-					zap.Error(err),
-				)
-			}
+		match := s.buildMatch(matchPlayers)
+		s.assignServer(ctx, match)
 
-			// Продолжаем поиск для остальных игроков
+		// Атомарно сохраняем матч и удаляем игроков из очереди. Если кто-то из группы
+		// уже попал в другой матч, пропускаем группу: оставшиеся игроки будут
+		// обработаны на следующем проходе. Группа, матч которой не удалось сохранить
+		// несколько раз подряд, попадает в DLQ
+		if err := s.formMatchWithRetry(ctx, region, gameMode, match); err != nil {
+			s.releaseServer(ctx, match)
+			logging.FromContext(ctx).Warn("Failed to form match",
+				zap.String("match_id", match.MatchID),
+				zap.String("region", region),
+				zap.String("game_mode", gameMode),
+				zap.Error(err),
+			)
 			continue
 		}
 
-		// Если не собрали группу, освобождаем игроков (кроме первого)
-		for k := 1; k < len(group); k++ {
-			delete(used, group[k].ID)
+		for _, p := range matchPlayers {
+			matched[p.ID] = true
 		}
-		delete(used, group[0].ID) // Освобождаем и первого, чтобы попробовать другие комбинации
+
+		logging.FromContext(ctx).Info("Match created from queue processing",
+			zap.String("match_id", match.MatchID),
+			zap.Int("players_count", len(matchPlayers)),
+			zap.String("region", region),
+			zap.String("game_mode", gameMode),
+			zap.Float64("quality_score", match.QualityScore),
+			zap.Bool("team_a_voice_compatible", match.TeamAVoiceCompatible),
+			zap.Bool("team_b_voice_compatible", match.TeamBVoiceCompatible),
+		)
+
+		s.completeFormedMatch(ctx, region, gameMode, match)
 	}
 
-	return nil
+	return matched
+}
+
+// arrangeMatchGroups разбивает игроков на группы алгоритмом подбора и расставляет
+// каждую группу по командам: первая половина — команда A, вторая — команда B.
+// Группы, для которых не удалось выполнить требования к ролям, отбрасываются.
+// Состояние очереди не меняется.
+func (s *MatcherService) arrangeMatchGroups(ctx context.Context, region, gameMode string, players []*models.Player) [][]models.Player {
+	playersPerMatch := s.playersPerMatch(ctx, gameMode)
+	teamSize := playersPerMatch / 2
+	rule, hasRule := s.configForContext(region, gameMode).CompositionRules[gameMode]
+
+	// Алгоритм подбора возвращает непересекающиеся группы нужного размера
+	groups := make([][]models.Player, 0)
+	for _, group := range s.algorithm.FormGroups(players, playersPerMatch) {
+		// Группа не разделяется между командами, а роли распределяются по требованиям
+		groupUnits := groupPartyUnits(group)
+		matchPlayers := arrangeTeams(groupUnits, teamSize)
+		if hasRule {
+			composed, ok := arrangeComposedTeams(groupUnits, teamSize, rule)
+			if !ok {
+				continue
+			}
+			matchPlayers = composed
+		}
+		groups = append(groups, matchPlayers)
+	}
+	return groups
+}
+
+// completeFormedMatch выполняет действия после сохранения матча из очереди: создает
+// лобби в game-service, обновляет статистику и уведомляет игроков и реплики
+func (s *MatcherService) completeFormedMatch(ctx context.Context, region, gameMode string, match *models.Match) {
+	if err := s.createLobbyInGameService(ctx, match); err != nil {
+		logging.FromContext(ctx).Warn("Failed to create lobby in game-service",
+			zap.String("match_id", match.MatchID),
+			zap.Error(err),
+		)
+	}
+
+	s.recordMatchStats(ctx, region, gameMode, match)
+	s.publishMatchFormed(ctx, region, gameMode, match)
 }
 
 // createLobbyInGameService создает лобби в game-service для найденного матча
@@ -338,6 +1047,12 @@ func (s *MatcherService) createLobbyInGameService(ctx context.Context, match *mo
 		"player_ids":  playerIDs,
 		"unity_scene": unityScene,
 	}
+	if match.ServerRegion != "" {
+		requestBody["server_region"] = match.ServerRegion
+	}
+	if match.ServerAddr != "" {
+		requestBody["server_addr"] = match.ServerAddr
+	}
 
 	jsonData, err := json.Marshal(requestBody)
 	if err != nil {
@@ -352,6 +1067,9 @@ func (s *MatcherService) createLobbyInGameService(ctx context.Context, match *mo
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	if requestID := logging.RequestIDFromContext(ctx); requestID != "" {
+		req.Header.Set("X-Request-ID", requestID) // Связывает логи game-service с запросом, создавшим матч
+	}
 
 	client := &http.Client{
 		Timeout: 5 * time.Second,
@@ -367,11 +1085,10 @@ func (s *MatcherService) createLobbyInGameService(ctx context.Context, match *mo
 		return fmt.Errorf("game-service returned status %d", resp.StatusCode)
 	}
 
-	s.logger.Info("Lobby created in game-service",
+	logging.FromContext(ctx).Info("Lobby created in game-service",
 		zap.String("match_id", match.MatchID),
 		zap.String("unity_scene", unityScene),
 	)
 
 	return nil
 }
-
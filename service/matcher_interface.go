@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"chrono-matchmaking/models"
+)
+
+// Matcher операции матчмейкера, которые нужны обработчикам очереди. Реализуется
+// MatcherService; в тестах обработчиков вместо него подставляется MockMatcher,
+// и Redis не нужен.
+type Matcher interface {
+	// Очередь
+	AddPlayerToQueue(ctx context.Context, player *models.Player) error
+	AddPlayersToQueue(ctx context.Context, players []*models.Player) []error
+	RemovePlayerFromQueue(ctx context.Context, playerID string) error
+	UpdateQueuedPlayer(ctx context.Context, playerID, newRegion, newGameMode string, newRating int) error
+	JoinPartyQueue(ctx context.Context, req *models.PartyRequest) (*models.Party, error)
+	Heartbeat(ctx context.Context, playerID string) (time.Duration, error)
+	ProcessQueue(ctx context.Context, region, gameMode string) error
+
+	// Матчи
+	FindMatch(ctx context.Context, playerID string) (*models.Match, error)
+	ReconnectToMatch(ctx context.Context, playerID string) (match *models.Match, completed bool, err error)
+	GetPlayerPendingMatch(ctx context.Context, playerID string) (*models.PendingMatch, error)
+	AcceptPendingMatch(ctx context.Context, pendingID, playerID string) (*models.Match, error)
+	DeclinePendingMatch(ctx context.Context, pendingID, playerID string) error
+	CreatePrivateMatch(ctx context.Context, req *models.CreatePrivateMatchRequest) (*models.InviteMatch, error)
+	JoinPrivateMatch(ctx context.Context, code string, player *models.Player) (*models.JoinPrivateMatchResponse, error)
+
+	// Состояние очереди
+	GetQueueSize(ctx context.Context, region, gameMode string) (int64, error)
+	GetQueueSizes(ctx context.Context, region, gameMode string) (standard, premium int64, err error)
+	GetQueueMemberCount(ctx context.Context, region, gameMode string) (active, stale, total int64, err error)
+	GetQueuePosition(ctx context.Context, playerID string) (*models.QueuePosition, error)
+	GetQueueSegmentStats(ctx context.Context, region, gameMode string) ([]models.SegmentStats, error)
+	GetQueueDepthByBracket(ctx context.Context, region, gameMode string) ([]models.BracketDepth, error)
+	EstimateWaitTime(ctx context.Context, region, gameMode string) (*models.WaitEstimate, error)
+	GetRegionLatency(region1, region2 string) (int, bool)
+	GetRegionLatencyMap() map[string]map[string]int
+
+	// Игроки и идемпотентность входа
+	GetPlayerProfile(ctx context.Context, playerID string) (*models.PlayerProfile, error)
+	GetIdempotentJoin(ctx context.Context, key string) (string, error)
+	SaveIdempotentJoin(ctx context.Context, key, playerID string) error
+}
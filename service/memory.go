@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+
+	"chrono-matchmaking/metrics"
+	"chrono-matchmaking/models"
+)
+
+// InspectMemoryUsage оценивает память Redis по шаблонам ключей и обновляет
+// метрику redis_estimated_memory_mb
+func (s *MatcherService) InspectMemoryUsage(ctx context.Context) (*models.MemoryUsageReport, error) {
+	ctx, span := startSpan(ctx, "InspectMemoryUsage")
+	defer span.End()
+
+	report, err := s.storage.InspectMemoryUsage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, usage := range report.Patterns {
+		metrics.RedisEstimatedMemoryMB.WithLabelValues(usage.Pattern).Set(usage.EstimatedTotalMB)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"chrono-matchmaking/broadcast"
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"go.uber.org/zap"
+)
+
+const (
+	liveMetricsWindow   = 60              // Секунд истории созданных матчей в кольцевом буфере
+	liveMetricsInterval = 5 * time.Second // Период рассылки снимков подписчикам
+)
+
+// liveMatchStats сумма показателей матчей одной очереди за одну секунду
+type liveMatchStats struct {
+	matches    int
+	qualitySum float64
+	waitSum    float64 // Секунды ожидания всех игроков матчей
+	waitCount  int
+}
+
+// liveMetricsBucket одна секунда кольцевого буфера
+type liveMetricsBucket struct {
+	second int64                      // Unix-время секунды; устаревшие ячейки перезаписываются
+	queues map[string]*liveMatchStats // По "регион:режим"
+}
+
+// MetricsCollector собирает живые показатели очередей для админ-консоли: созданные
+// матчи берутся из событий match_created хаба (включая матчи, созданные лидерами на
+// других репликах) и хранятся в кольцевом буфере за последние 60 секунд, размер очередей
+// читается из хранилища при построении снимка. Снимок рассылается всем подписчикам
+// каждые 5 секунд; пока подписчиков нет, снимки не строятся.
+type MetricsCollector struct {
+	matcher *MatcherService
+	hub     *broadcast.Hub
+	logger  *zap.Logger
+
+	mu      sync.Mutex // Защищает buckets
+	buckets [liveMetricsWindow]liveMetricsBucket
+
+	subs sync.Map // <-chan *models.LiveMetricsSnapshot -> chan *models.LiveMetricsSnapshot
+}
+
+// NewMetricsCollector создает сборщик живых показателей. Сбор начинается после Run.
+func NewMetricsCollector(matcher *MatcherService, hub *broadcast.Hub, logger *zap.Logger) *MetricsCollector {
+	return &MetricsCollector{
+		matcher: matcher,
+		hub:     hub,
+		logger:  logger,
+	}
+}
+
+// Run принимает события матчей и рассылает снимки, пока не отменен ctx
+func (c *MetricsCollector) Run(ctx context.Context) {
+	events := c.hub.Subscribe()
+	defer c.hub.Unsubscribe(events)
+
+	ticker := time.NewTicker(liveMetricsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if event.Type != models.QueueEventMatchCreated {
+				continue
+			}
+			if data, ok := event.Data.(models.QueueEvent); ok && data.Match != nil {
+				c.RecordMatch(data.Region, data.GameMode, data.Match, time.Now())
+			}
+		case <-ticker.C:
+			if !c.hasSubscribers() {
+				continue
+			}
+			snapshot, err := c.Snapshot(ctx)
+			if err != nil {
+				logging.FromContext(ctx).Warn("Failed to build live metrics snapshot", zap.Error(err))
+				continue
+			}
+			c.broadcast(snapshot)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// RecordMatch добавляет созданный матч в ячейку секунды now
+func (c *MetricsCollector) RecordMatch(region, gameMode string, match *models.Match, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	second := now.Unix()
+	bucket := &c.buckets[second%liveMetricsWindow]
+	if bucket.second != second || bucket.queues == nil {
+		bucket.second = second
+		bucket.queues = make(map[string]*liveMatchStats)
+	}
+
+	key := overrideKey(region, gameMode)
+	stats, ok := bucket.queues[key]
+	if !ok {
+		stats = &liveMatchStats{}
+		bucket.queues[key] = stats
+	}
+	stats.matches++
+	stats.qualitySum += match.QualityScore
+	for _, player := range match.Players {
+		stats.waitSum += match.CreatedAt.Sub(player.JoinedAt).Seconds()
+		stats.waitCount++
+	}
+}
+
+// windowStats суммирует ячейки за последние 60 секунд по очередям
+func (c *MetricsCollector) windowStats(now time.Time) map[string]liveMatchStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	oldest := now.Unix() - liveMetricsWindow
+	totals := make(map[string]liveMatchStats)
+	for _, bucket := range c.buckets {
+		if bucket.second <= oldest {
+			continue
+		}
+		for key, stats := range bucket.queues {
+			total := totals[key]
+			total.matches += stats.matches
+			total.qualitySum += stats.qualitySum
+			total.waitSum += stats.waitSum
+			total.waitCount += stats.waitCount
+			totals[key] = total
+		}
+	}
+	return totals
+}
+
+// Snapshot строит снимок показателей для каждой пары активного региона и режима
+func (c *MetricsCollector) Snapshot(ctx context.Context) (*models.LiveMetricsSnapshot, error) {
+	regions, err := c.matcher.GetRegions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	modes, err := c.matcher.GetGameModes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	gameModes := GameModeNames(modes)
+
+	now := time.Now().UTC()
+	totals := c.windowStats(now)
+
+	snapshot := &models.LiveMetricsSnapshot{
+		Timestamp: now,
+		Queues:    make([]models.LiveQueueMetrics, 0, len(regions)*len(gameModes)),
+	}
+	for _, region := range regions {
+		for _, gameMode := range gameModes {
+			size, err := c.matcher.GetQueueSize(ctx, region, gameMode)
+			if err != nil {
+				return nil, err
+			}
+
+			entry := models.LiveQueueMetrics{
+				Region:    region,
+				GameMode:  gameMode,
+				QueueSize: size,
+			}
+			if total := totals[overrideKey(region, gameMode)]; total.matches > 0 {
+				entry.MatchesPerMinute = total.matches
+				entry.AvgQualityScore = total.qualitySum / float64(total.matches)
+				if total.waitCount > 0 {
+					entry.AvgWaitSeconds = total.waitSum / float64(total.waitCount)
+				}
+			}
+			snapshot.Queues = append(snapshot.Queues, entry)
+		}
+	}
+	return snapshot, nil
+}
+
+// Subscribe возвращает канал, в который будут приходить снимки. Канал хранит только
+// последний непрочитанный снимок.
+func (c *MetricsCollector) Subscribe() <-chan *models.LiveMetricsSnapshot {
+	ch := make(chan *models.LiveMetricsSnapshot, 1)
+	c.subs.Store((<-chan *models.LiveMetricsSnapshot)(ch), ch)
+	return ch
+}
+
+// Unsubscribe удаляет подписку. Канал не закрывается: рассылка может идти
+// одновременно, а отправка в закрытый канал вызвала бы панику.
+func (c *MetricsCollector) Unsubscribe(ch <-chan *models.LiveMetricsSnapshot) {
+	c.subs.Delete(ch)
+}
+
+// hasSubscribers проверяет, есть ли хотя бы один подписчик
+func (c *MetricsCollector) hasSubscribers() bool {
+	found := false
+	c.subs.Range(func(_, _ interface{}) bool {
+		found = true
+		return false
+	})
+	return found
+}
+
+// broadcast отправляет снимок всем подписчикам, не дожидаясь медленных
+func (c *MetricsCollector) broadcast(snapshot *models.LiveMetricsSnapshot) {
+	c.subs.Range(func(_, value interface{}) bool {
+		ch := value.(chan *models.LiveMetricsSnapshot)
+		select {
+		case ch <- snapshot:
+		default:
+			// Предыдущий снимок еще не отправлен — этот пропускается
+		}
+		return true
+	})
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"chrono-matchmaking/models"
+)
+
+var _ Matcher = (*MockMatcher)(nil)
+
+// MockMatcher реализация Matcher для тестов: каждый метод вызывает одноименное поле
+// с суффиксом Fn. Если поле не задано, метод возвращает нулевые значения и nil-ошибку
+// (AddPlayersToQueue — по nil-ошибке на каждого игрока).
+type MockMatcher struct {
+	AddPlayerToQueueFn       func(ctx context.Context, player *models.Player) error
+	AddPlayersToQueueFn      func(ctx context.Context, players []*models.Player) []error
+	RemovePlayerFromQueueFn  func(ctx context.Context, playerID string) error
+	UpdateQueuedPlayerFn     func(ctx context.Context, playerID, newRegion, newGameMode string, newRating int) error
+	JoinPartyQueueFn         func(ctx context.Context, req *models.PartyRequest) (*models.Party, error)
+	HeartbeatFn              func(ctx context.Context, playerID string) (time.Duration, error)
+	ProcessQueueFn           func(ctx context.Context, region, gameMode string) error
+	FindMatchFn              func(ctx context.Context, playerID string) (*models.Match, error)
+	ReconnectToMatchFn       func(ctx context.Context, playerID string) (match *models.Match, completed bool, err error)
+	GetPlayerPendingMatchFn  func(ctx context.Context, playerID string) (*models.PendingMatch, error)
+	AcceptPendingMatchFn     func(ctx context.Context, pendingID, playerID string) (*models.Match, error)
+	DeclinePendingMatchFn    func(ctx context.Context, pendingID, playerID string) error
+	CreatePrivateMatchFn     func(ctx context.Context, req *models.CreatePrivateMatchRequest) (*models.InviteMatch, error)
+	JoinPrivateMatchFn       func(ctx context.Context, code string, player *models.Player) (*models.JoinPrivateMatchResponse, error)
+	GetQueueSizeFn           func(ctx context.Context, region, gameMode string) (int64, error)
+	GetQueueSizesFn          func(ctx context.Context, region, gameMode string) (standard, premium int64, err error)
+	GetQueueMemberCountFn    func(ctx context.Context, region, gameMode string) (active, stale, total int64, err error)
+	GetQueuePositionFn       func(ctx context.Context, playerID string) (*models.QueuePosition, error)
+	GetQueueSegmentStatsFn   func(ctx context.Context, region, gameMode string) ([]models.SegmentStats, error)
+	GetQueueDepthByBracketFn func(ctx context.Context, region, gameMode string) ([]models.BracketDepth, error)
+	EstimateWaitTimeFn       func(ctx context.Context, region, gameMode string) (*models.WaitEstimate, error)
+	GetRegionLatencyFn       func(region1, region2 string) (int, bool)
+	GetRegionLatencyMapFn    func() map[string]map[string]int
+	GetPlayerProfileFn       func(ctx context.Context, playerID string) (*models.PlayerProfile, error)
+	GetIdempotentJoinFn      func(ctx context.Context, key string) (string, error)
+	SaveIdempotentJoinFn     func(ctx context.Context, key, playerID string) error
+}
+
+// AddPlayerToQueue реализует Matcher
+func (m *MockMatcher) AddPlayerToQueue(ctx context.Context, player *models.Player) error {
+	if m.AddPlayerToQueueFn != nil {
+		return m.AddPlayerToQueueFn(ctx, player)
+	}
+	return nil
+}
+
+// AddPlayersToQueue реализует Matcher
+func (m *MockMatcher) AddPlayersToQueue(ctx context.Context, players []*models.Player) []error {
+	if m.AddPlayersToQueueFn != nil {
+		return m.AddPlayersToQueueFn(ctx, players)
+	}
+	return make([]error, len(players))
+}
+
+// RemovePlayerFromQueue реализует Matcher
+func (m *MockMatcher) RemovePlayerFromQueue(ctx context.Context, playerID string) error {
+	if m.RemovePlayerFromQueueFn != nil {
+		return m.RemovePlayerFromQueueFn(ctx, playerID)
+	}
+	return nil
+}
+
+// UpdateQueuedPlayer реализует Matcher
+func (m *MockMatcher) UpdateQueuedPlayer(ctx context.Context, playerID, newRegion, newGameMode string, newRating int) error {
+	if m.UpdateQueuedPlayerFn != nil {
+		return m.UpdateQueuedPlayerFn(ctx, playerID, newRegion, newGameMode, newRating)
+	}
+	return nil
+}
+
+// JoinPartyQueue реализует Matcher
+func (m *MockMatcher) JoinPartyQueue(ctx context.Context, req *models.PartyRequest) (*models.Party, error) {
+	if m.JoinPartyQueueFn != nil {
+		return m.JoinPartyQueueFn(ctx, req)
+	}
+	return nil, nil
+}
+
+// Heartbeat реализует Matcher
+func (m *MockMatcher) Heartbeat(ctx context.Context, playerID string) (time.Duration, error) {
+	if m.HeartbeatFn != nil {
+		return m.HeartbeatFn(ctx, playerID)
+	}
+	return 0, nil
+}
+
+// ProcessQueue реализует Matcher
+func (m *MockMatcher) ProcessQueue(ctx context.Context, region, gameMode string) error {
+	if m.ProcessQueueFn != nil {
+		return m.ProcessQueueFn(ctx, region, gameMode)
+	}
+	return nil
+}
+
+// FindMatch реализует Matcher
+func (m *MockMatcher) FindMatch(ctx context.Context, playerID string) (*models.Match, error) {
+	if m.FindMatchFn != nil {
+		return m.FindMatchFn(ctx, playerID)
+	}
+	return nil, nil
+}
+
+// ReconnectToMatch реализует Matcher
+func (m *MockMatcher) ReconnectToMatch(ctx context.Context, playerID string) (match *models.Match, completed bool, err error) {
+	if m.ReconnectToMatchFn != nil {
+		return m.ReconnectToMatchFn(ctx, playerID)
+	}
+	return nil, false, nil
+}
+
+// GetPlayerPendingMatch реализует Matcher
+func (m *MockMatcher) GetPlayerPendingMatch(ctx context.Context, playerID string) (*models.PendingMatch, error) {
+	if m.GetPlayerPendingMatchFn != nil {
+		return m.GetPlayerPendingMatchFn(ctx, playerID)
+	}
+	return nil, nil
+}
+
+// AcceptPendingMatch реализует Matcher
+func (m *MockMatcher) AcceptPendingMatch(ctx context.Context, pendingID, playerID string) (*models.Match, error) {
+	if m.AcceptPendingMatchFn != nil {
+		return m.AcceptPendingMatchFn(ctx, pendingID, playerID)
+	}
+	return nil, nil
+}
+
+// DeclinePendingMatch реализует Matcher
+func (m *MockMatcher) DeclinePendingMatch(ctx context.Context, pendingID, playerID string) error {
+	if m.DeclinePendingMatchFn != nil {
+		return m.DeclinePendingMatchFn(ctx, pendingID, playerID)
+	}
+	return nil
+}
+
+// CreatePrivateMatch реализует Matcher
+func (m *MockMatcher) CreatePrivateMatch(ctx context.Context, req *models.CreatePrivateMatchRequest) (*models.InviteMatch, error) {
+	if m.CreatePrivateMatchFn != nil {
+		return m.CreatePrivateMatchFn(ctx, req)
+	}
+	return nil, nil
+}
+
+// JoinPrivateMatch реализует Matcher
+func (m *MockMatcher) JoinPrivateMatch(ctx context.Context, code string, player *models.Player) (*models.JoinPrivateMatchResponse, error) {
+	if m.JoinPrivateMatchFn != nil {
+		return m.JoinPrivateMatchFn(ctx, code, player)
+	}
+	return nil, nil
+}
+
+// GetQueueSize реализует Matcher
+func (m *MockMatcher) GetQueueSize(ctx context.Context, region, gameMode string) (int64, error) {
+	if m.GetQueueSizeFn != nil {
+		return m.GetQueueSizeFn(ctx, region, gameMode)
+	}
+	return 0, nil
+}
+
+// GetQueueSizes реализует Matcher
+func (m *MockMatcher) GetQueueSizes(ctx context.Context, region, gameMode string) (standard, premium int64, err error) {
+	if m.GetQueueSizesFn != nil {
+		return m.GetQueueSizesFn(ctx, region, gameMode)
+	}
+	return 0, 0, nil
+}
+
+// GetQueueMemberCount реализует Matcher
+func (m *MockMatcher) GetQueueMemberCount(ctx context.Context, region, gameMode string) (active, stale, total int64, err error) {
+	if m.GetQueueMemberCountFn != nil {
+		return m.GetQueueMemberCountFn(ctx, region, gameMode)
+	}
+	return 0, 0, 0, nil
+}
+
+// GetQueuePosition реализует Matcher
+func (m *MockMatcher) GetQueuePosition(ctx context.Context, playerID string) (*models.QueuePosition, error) {
+	if m.GetQueuePositionFn != nil {
+		return m.GetQueuePositionFn(ctx, playerID)
+	}
+	return nil, nil
+}
+
+// GetQueueSegmentStats реализует Matcher
+func (m *MockMatcher) GetQueueSegmentStats(ctx context.Context, region, gameMode string) ([]models.SegmentStats, error) {
+	if m.GetQueueSegmentStatsFn != nil {
+		return m.GetQueueSegmentStatsFn(ctx, region, gameMode)
+	}
+	return nil, nil
+}
+
+// GetQueueDepthByBracket реализует Matcher
+func (m *MockMatcher) GetQueueDepthByBracket(ctx context.Context, region, gameMode string) ([]models.BracketDepth, error) {
+	if m.GetQueueDepthByBracketFn != nil {
+		return m.GetQueueDepthByBracketFn(ctx, region, gameMode)
+	}
+	return nil, nil
+}
+
+// EstimateWaitTime реализует Matcher
+func (m *MockMatcher) EstimateWaitTime(ctx context.Context, region, gameMode string) (*models.WaitEstimate, error) {
+	if m.EstimateWaitTimeFn != nil {
+		return m.EstimateWaitTimeFn(ctx, region, gameMode)
+	}
+	return nil, nil
+}
+
+// GetRegionLatency реализует Matcher
+func (m *MockMatcher) GetRegionLatency(region1, region2 string) (int, bool) {
+	if m.GetRegionLatencyFn != nil {
+		return m.GetRegionLatencyFn(region1, region2)
+	}
+	return 0, false
+}
+
+// GetRegionLatencyMap реализует Matcher
+func (m *MockMatcher) GetRegionLatencyMap() map[string]map[string]int {
+	if m.GetRegionLatencyMapFn != nil {
+		return m.GetRegionLatencyMapFn()
+	}
+	return nil
+}
+
+// GetPlayerProfile реализует Matcher
+func (m *MockMatcher) GetPlayerProfile(ctx context.Context, playerID string) (*models.PlayerProfile, error) {
+	if m.GetPlayerProfileFn != nil {
+		return m.GetPlayerProfileFn(ctx, playerID)
+	}
+	return nil, nil
+}
+
+// GetIdempotentJoin реализует Matcher
+func (m *MockMatcher) GetIdempotentJoin(ctx context.Context, key string) (string, error) {
+	if m.GetIdempotentJoinFn != nil {
+		return m.GetIdempotentJoinFn(ctx, key)
+	}
+	return "", nil
+}
+
+// SaveIdempotentJoin реализует Matcher
+func (m *MockMatcher) SaveIdempotentJoin(ctx context.Context, key, playerID string) error {
+	if m.SaveIdempotentJoinFn != nil {
+		return m.SaveIdempotentJoinFn(ctx, key, playerID)
+	}
+	return nil
+}
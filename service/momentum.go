@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	momentumWindowGames  = 10              // Сколько последних изменений рейтинга учитывает GetRatingMomentum
+	momentumMinMagnitude = 3               // Серия длиннее этой сдвигает рейтинг поиска
+	momentumTTL          = 5 * time.Minute // Сколько хранится momentum:{playerID}
+)
+
+// GetRatingMomentum оценивает, в какую сторону движется рейтинг игрока. Направление
+// и величина — текущая серия побед (upward) или поражений из профиля; у игрока без
+// профиля серия считается по последним изменениям рейтинга. Серия не учитывается,
+// если сумма последних 10 изменений рейтинга ей противоречит: игрок, отыгрывающий
+// недавние потери, не недооценен.
+func (s *MatcherService) GetRatingMomentum(ctx context.Context, playerID string) (upward bool, magnitude int, err error) {
+	ctx, span := startSpan(ctx, "GetRatingMomentum", attribute.String("player_id", playerID))
+	defer span.End()
+
+	changes, err := s.storage.GetRecentRatingChanges(ctx, playerID, momentumWindowGames)
+	if err != nil {
+		return false, 0, err
+	}
+
+	var winStreak, lossStreak int
+	profile, err := s.storage.GetProfile(ctx, playerID)
+	switch {
+	case errors.Is(err, ErrProfileNotFound):
+		winStreak, lossStreak = streaksFromChanges(changes)
+	case err != nil:
+		return false, 0, err
+	default:
+		winStreak, lossStreak = profile.WinStreak, profile.LossStreak
+	}
+
+	net := 0
+	for _, delta := range changes {
+		net += delta
+	}
+
+	switch {
+	case winStreak > 0 && net >= 0:
+		return true, winStreak, nil
+	case lossStreak > 0 && net <= 0:
+		return false, lossStreak, nil
+	}
+	return false, 0, nil
+}
+
+// streaksFromChanges возвращает текущие серии побед и поражений по изменениям
+// рейтинга, начиная с самого нового
+func streaksFromChanges(changes []int) (winStreak, lossStreak int) {
+	for _, delta := range changes {
+		switch {
+		case delta > 0 && lossStreak == 0:
+			winStreak++
+		case delta < 0 && winStreak == 0:
+			lossStreak++
+		default:
+			return winStreak, lossStreak
+		}
+	}
+	return winStreak, lossStreak
+}
+
+// refreshMomentum пересчитывает импульс игрока и сохраняет его в momentum:{playerID}
+// на 5 минут. Возвращает импульс со знаком: положительный — серия побед.
+func (s *MatcherService) refreshMomentum(ctx context.Context, playerID string) (int, error) {
+	upward, magnitude, err := s.GetRatingMomentum(ctx, playerID)
+	if err != nil {
+		return 0, err
+	}
+
+	momentum := magnitude
+	if !upward {
+		momentum = -magnitude
+	}
+	if err := s.storage.SetMomentum(ctx, playerID, momentum, momentumTTL); err != nil {
+		return 0, err
+	}
+	return momentum, nil
+}
+
+// searchRating возвращает рейтинг, вокруг которого FindMatch ищет соперников. Игрок
+// с серией побед длиннее 3 ищет соперников выше своего рейтинга на MomentumBoost за
+// каждую победу серии; сохраненный рейтинг игрока не меняется. Импульс берется из
+// momentum:{playerID}, а если его нет — вычисляется и сохраняется. При ошибке
+// хранилища используется рейтинг игрока.
+func (s *MatcherService) searchRating(ctx context.Context, player *models.Player) int {
+	boost := s.configForContext(player.Region, player.GameMode).MomentumBoost
+	if boost <= 0 || player.IsCalibrating {
+		return player.Rating
+	}
+
+	momentum, found, err := s.storage.GetMomentum(ctx, player.ID)
+	if err == nil && !found {
+		momentum, err = s.refreshMomentum(ctx, player.ID)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to get rating momentum",
+			zap.String("player_id", player.ID),
+			zap.Error(err),
+		)
+		return player.Rating
+	}
+
+	if momentum <= momentumMinMagnitude {
+		return player.Rating
+	}
+	return player.Rating + momentum*boost
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/storage"
+	"go.uber.org/zap"
+)
+
+// queueMomentumPlayers ставит в очередь 1v1 игрока с серией из streak побед и
+// соперника, рейтинг которого на 300 выше
+func queueMomentumPlayers(t *testing.T, store storage.Storage, streak int) {
+	t.Helper()
+	ctx := context.Background()
+
+	if _, err := store.CreateProfile(ctx, &models.PlayerProfile{PlayerID: "streaker"}); err != nil {
+		t.Fatalf("CreateProfile: %v", err)
+	}
+	for i := 0; i < streak; i++ {
+		if err := store.RecordMatchOutcome(ctx, "streaker", true); err != nil {
+			t.Fatalf("RecordMatchOutcome: %v", err)
+		}
+	}
+
+	now := time.Now()
+	for _, p := range []*models.Player{
+		{ID: "streaker", Rating: 1500, Region: "EU", GameMode: "1v1", JoinedAt: now},
+		{ID: "stronger", Rating: 1800, Region: "EU", GameMode: "1v1", JoinedAt: now},
+	} {
+		if err := store.AddPlayerToQueue(ctx, p); err != nil {
+			t.Fatalf("AddPlayerToQueue(%s): %v", p.ID, err)
+		}
+	}
+}
+
+func TestFindMatchAppliesMomentumBoost(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewInMemoryStorage()
+	matcher := NewMatcherService(store, zap.NewNop(), DefaultMatcherConfig())
+	queueMomentumPlayers(t, store, 5)
+
+	// 1500 + 5 * 50 = 1750: соперник с 1800 попадает в начальный диапазон ±200
+	match, err := matcher.FindMatch(ctx, "streaker")
+	if err != nil {
+		t.Fatalf("FindMatch: %v", err)
+	}
+	if len(match.Players) != 2 {
+		t.Fatalf("match has %d players, want 2", len(match.Players))
+	}
+	for _, p := range match.Players {
+		if p.ID == "streaker" && p.Rating != 1500 {
+			t.Errorf("streaker rating in match = %d, want stored 1500", p.Rating)
+		}
+	}
+
+	momentum, found, err := store.GetMomentum(ctx, "streaker")
+	if err != nil || !found || momentum != 5 {
+		t.Errorf("GetMomentum = %d, %v, %v; want 5, true, nil", momentum, found, err)
+	}
+}
+
+func TestFindMatchIgnoresShortStreak(t *testing.T) {
+	store := storage.NewInMemoryStorage()
+	matcher := NewMatcherService(store, zap.NewNop(), DefaultMatcherConfig())
+	queueMomentumPlayers(t, store, 3)
+
+	if match, err := matcher.FindMatch(context.Background(), "streaker"); err == nil {
+		t.Errorf("FindMatch matched %v without momentum boost", match.Players)
+	}
+}
+
+func TestGetRatingMomentum(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewInMemoryStorage()
+	matcher := NewMatcherService(store, zap.NewNop(), DefaultMatcherConfig())
+	now := time.Now()
+
+	// Без профиля серия считается по изменениям рейтинга, начиная с последнего
+	for i, delta := range []int{-20, 15, 16, 14, 15} {
+		if err := store.RecordRatingChange(ctx, "anonymous", delta, now.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatalf("RecordRatingChange: %v", err)
+		}
+	}
+	if upward, magnitude, err := matcher.GetRatingMomentum(ctx, "anonymous"); err != nil || !upward || magnitude != 4 {
+		t.Errorf("GetRatingMomentum(anonymous) = %v, %d, %v; want true, 4, nil", upward, magnitude, err)
+	}
+
+	// Серия побед после крупных потерь не считается импульсом
+	if _, err := store.CreateProfile(ctx, &models.PlayerProfile{PlayerID: "recovering", WinStreak: 2}); err != nil {
+		t.Fatalf("CreateProfile: %v", err)
+	}
+	for i, delta := range []int{-30, -30, -30, 10, 10} {
+		if err := store.RecordRatingChange(ctx, "recovering", delta, now.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatalf("RecordRatingChange: %v", err)
+		}
+	}
+	if upward, magnitude, err := matcher.GetRatingMomentum(ctx, "recovering"); err != nil || upward || magnitude != 0 {
+		t.Errorf("GetRatingMomentum(recovering) = %v, %d, %v; want false, 0, nil", upward, magnitude, err)
+	}
+}
+
+func TestReportMatchResultRecordsStreaks(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewInMemoryStorage()
+	matcher := NewMatcherService(store, zap.NewNop(), DefaultMatcherConfig())
+
+	for _, id := range []string{"winner", "loser"} {
+		if _, err := store.CreateProfile(ctx, &models.PlayerProfile{PlayerID: id, LossStreak: 2}); err != nil {
+			t.Fatalf("CreateProfile: %v", err)
+		}
+	}
+	match := &models.Match{MatchID: "match", GameMode: "1v1", Players: []models.Player{
+		{ID: "winner", Rating: 1500, Region: "EU", GameMode: "1v1"},
+		{ID: "loser", Rating: 1500, Region: "EU", GameMode: "1v1"},
+	}}
+	if err := store.SaveMatch(ctx, match); err != nil {
+		t.Fatalf("SaveMatch: %v", err)
+	}
+	if _, err := matcher.ReportMatchResult(ctx, "match", []string{"winner"}); err != nil {
+		t.Fatalf("ReportMatchResult: %v", err)
+	}
+
+	winner, _ := store.GetProfile(ctx, "winner")
+	if winner.WinStreak != 1 || winner.LossStreak != 0 {
+		t.Errorf("winner streaks = %d/%d, want 1/0", winner.WinStreak, winner.LossStreak)
+	}
+	loser, _ := store.GetProfile(ctx, "loser")
+	if loser.WinStreak != 0 || loser.LossStreak != 3 {
+		t.Errorf("loser streaks = %d/%d, want 0/3", loser.WinStreak, loser.LossStreak)
+	}
+	if momentum, found, _ := store.GetMomentum(ctx, "loser"); !found || momentum != -3 {
+		t.Errorf("loser momentum = %d (found %v), want -3", momentum, found)
+	}
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// playerLockTTL время блокировки игрока на формирование матча
+const playerLockTTL = 5 * time.Second
+
+// playerLockKey возвращает ключ блокировки игрока на формирование матча
+func playerLockKey(playerID string) string {
+	return "lock:player:" + playerID
+}
+
+// lockMultiQueuePlayers захватывает lock:player:{id} игроков, ищущих матч в нескольких
+// режимах: такой игрок виден в нескольких очередях, и без блокировки их могли бы
+// одновременно разбирать разные реплики. Игроки одного режима не блокируются — их
+// защищает проверка в скрипте формирования матча. Если кого-то из игроков уже
+// заблокировал другой матч, захваченные блокировки освобождаются и возвращается
+// ErrMatchConflict. Возвращенная функция освобождает блокировки.
+func (s *MatcherService) lockMultiQueuePlayers(ctx context.Context, players []models.Player) (func(), error) {
+	owner := uuid.New().String()
+	locked := make([]string, 0)
+	unlock := func() {
+		for _, key := range locked {
+			if err := s.storage.ReleaseLock(ctx, key, owner); err != nil {
+				logging.FromContext(ctx).Warn("Failed to release player lock",
+					zap.String("key", key),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+
+	for i := range players {
+		if !players[i].IsMultiQueue() {
+			continue
+		}
+		key := playerLockKey(players[i].ID)
+		acquired, err := s.storage.AcquireLock(ctx, key, owner, playerLockTTL)
+		if err != nil {
+			unlock()
+			return nil, err
+		}
+		if !acquired {
+			unlock()
+			return nil, ErrMatchConflict
+		}
+		locked = append(locked, key)
+	}
+	return unlock, nil
+}
+
+// formMatch атомарно сохраняет матч и удаляет его игроков из очередей, заблокировав
+// на это время игроков нескольких очередей
+func (s *MatcherService) formMatch(ctx context.Context, match *models.Match) error {
+	unlock, err := s.lockMultiQueuePlayers(ctx, match.Players)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return s.storage.RunAtomicMatchFormation(ctx, match)
+}
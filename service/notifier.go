@@ -0,0 +1,70 @@
+package service
+
+import (
+	"errors"
+	"sync"
+
+	"chrono-matchmaking/models"
+)
+
+// ErrAlreadySubscribed возвращается, если у игрока уже есть активная подписка на матч
+var ErrAlreadySubscribed = errors.New("player is already subscribed to match notifications")
+
+// MatchNotifier доставляет созданные матчи подписанным игрокам в пределах реплики.
+// Матчи, созданные другими репликами, передаются через координатор (см. main.go).
+type MatchNotifier struct {
+	mu   sync.Mutex
+	subs map[string]chan *models.Match // Подписки по ID игрока
+}
+
+// NewMatchNotifier создает новый диспетчер уведомлений о матчах
+func NewMatchNotifier() *MatchNotifier {
+	return &MatchNotifier{
+		subs: make(map[string]chan *models.Match),
+	}
+}
+
+// Subscribe подписывает игрока на уведомление о матче.
+// У игрока может быть только одна подписка одновременно.
+func (n *MatchNotifier) Subscribe(playerID string) (<-chan *models.Match, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if _, ok := n.subs[playerID]; ok {
+		return nil, ErrAlreadySubscribed
+	}
+
+	// Буфер на одно сообщение, чтобы Notify не блокировался
+	ch := make(chan *models.Match, 1)
+	n.subs[playerID] = ch
+	return ch, nil
+}
+
+// Unsubscribe удаляет подписку игрока и закрывает ее канал
+func (n *MatchNotifier) Unsubscribe(playerID string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if ch, ok := n.subs[playerID]; ok {
+		close(ch)
+		delete(n.subs, playerID)
+	}
+}
+
+// Notify отправляет матч всем подписанным игрокам этого матча
+func (n *MatchNotifier) Notify(match *models.Match) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for _, p := range match.Players {
+		ch, ok := n.subs[p.ID]
+		if !ok {
+			continue
+		}
+		select {
+		case ch <- match:
+		default:
+			// Предыдущее уведомление еще не прочитано — игрок его получит
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"time"
+)
+
+// offPeakExpansionFactor во сколько раз в непиковые часы ускоряется расширение
+// диапазона рейтинга
+const offPeakExpansionFactor = 2
+
+// IsOffPeak проверяет, попадает ли t в одно из окон непиковых часов. Окно [start, end)
+// задается часами UTC от 0 до 24; окно с start > end переходит через полночь
+// (например, [22, 4]).
+func IsOffPeak(t time.Time, windows [][2]int) bool {
+	hour := t.UTC().Hour()
+	for _, w := range windows {
+		start, end := w[0], w[1]
+		if start <= end {
+			if hour >= start && hour < end {
+				return true
+			}
+		} else if hour >= start || hour < end {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOffPeakNow проверяет, действуют ли сейчас непиковые часы текущей конфигурации
+func (s *MatcherService) IsOffPeakNow() bool {
+	return IsOffPeak(time.Now(), s.currentConfig().OffPeakHoursUTC)
+}
+
+// offPeakConfig возвращает копию cfg с ослабленными на непиковые часы требованиями:
+// MaxRatingDiff умножается на OffPeakRatingMultiplier, RatingExpansionRate удваивается,
+// а соседние диапазоны уровней попарно объединяются. cfg не изменяется.
+func offPeakConfig(cfg *MatcherConfig) *MatcherConfig {
+	relaxed := *cfg
+	relaxed.MaxRatingDiff = int(float64(cfg.MaxRatingDiff) * cfg.OffPeakRatingMultiplier)
+	relaxed.RatingExpansionRate = cfg.RatingExpansionRate * offPeakExpansionFactor
+	relaxed.LevelBrackets = mergeLevelBrackets(cfg.LevelBrackets)
+	return &relaxed
+}
+
+// mergeLevelBrackets объединяет диапазоны уровней попарно: 1–10 и 11–30 становятся
+// 1–30, 31–50 и 51+ — 31+. Нечетный последний диапазон остается как есть.
+func mergeLevelBrackets(brackets []LevelBracket) []LevelBracket {
+	if len(brackets) == 0 {
+		return nil
+	}
+
+	merged := make([]LevelBracket, 0, (len(brackets)+1)/2)
+	for i := 0; i < len(brackets); i += 2 {
+		b := brackets[i]
+		if i+1 < len(brackets) {
+			b.Max = brackets[i+1].Max
+		}
+		merged = append(merged, b)
+	}
+	return merged
+}
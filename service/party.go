@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/storage"
+)
+
+// ErrPartyNotFound возвращается, если группа не найдена
+var ErrPartyNotFound = storage.ErrPartyNotFound
+
+// JoinPartyQueue атомарно ставит в очередь группу игроков. Все игроки группы должны
+// быть зарегистрированы, а сама группа — помещаться в одну команду режима.
+func (s *MatcherService) JoinPartyQueue(ctx context.Context, req *models.PartyRequest) (*models.Party, error) {
+	ctx, span := startSpan(ctx, "JoinPartyQueue")
+	defer span.End()
+
+	playersPerMatch, err := s.GetPlayersPerMatch(ctx, req.GameMode)
+	if err != nil {
+		return nil, err
+	}
+	maxSize := playersPerMatch / 2
+	if len(req.PlayerIDs) < 2 || len(req.PlayerIDs) > maxSize {
+		return nil, fmt.Errorf("game mode %s allows parties of 2 to %d players, got %d", req.GameMode, maxSize, len(req.PlayerIDs))
+	}
+
+	seen := make(map[string]bool, len(req.PlayerIDs))
+	for _, playerID := range req.PlayerIDs {
+		if seen[playerID] {
+			return nil, fmt.Errorf("player %s is listed more than once", playerID)
+		}
+		seen[playerID] = true
+
+		if _, ok := req.Ratings[playerID]; !ok {
+			return nil, fmt.Errorf("rating for player %s is required", playerID)
+		}
+		if !models.IsValidRole(req.Roles[playerID]) {
+			return nil, fmt.Errorf("role of player %s must be one of: tank, healer, dps", playerID)
+		}
+		if _, err := s.storage.GetProfile(ctx, playerID); err != nil {
+			return nil, fmt.Errorf("player %s: %w", playerID, err)
+		}
+		if err := s.checkBan(ctx, playerID); err != nil {
+			return nil, err
+		}
+		if err := s.checkFlagged(ctx, playerID); err != nil {
+			return nil, fmt.Errorf("player %s: %w", playerID, err)
+		}
+		cooldown, err := s.storage.GetQueueCooldown(ctx, playerID)
+		if err != nil {
+			return nil, err
+		}
+		if cooldown > 0 {
+			return nil, fmt.Errorf("player %s: %w for %s", playerID, ErrPlayerOnCooldown, cooldown.Round(time.Second))
+		}
+		rating, err := s.storedRating(ctx, playerID, req.Ratings[playerID])
+		if err != nil {
+			return nil, err
+		}
+		req.Ratings[playerID] = rating
+	}
+
+	party, players := models.NewPartyFromRequest(req)
+	if err := s.storage.AddPartyToQueue(ctx, party, players); err != nil {
+		return nil, err
+	}
+	s.updateQueueDepth(ctx, party.Region, party.GameMode)
+
+	return party, nil
+}
+
+// removePartyFromQueue удаляет из очереди всех игроков группы
+func (s *MatcherService) removePartyFromQueue(ctx context.Context, partyID string) error {
+	party, err := s.storage.GetParty(ctx, partyID)
+	if err != nil {
+		return err
+	}
+
+	for _, memberID := range party.MemberIDs {
+		if err := s.storage.RemovePlayerFromQueue(ctx, memberID); err != nil {
+			return fmt.Errorf("failed to remove party member %s: %w", memberID, err)
+		}
+	}
+
+	return nil
+}
+
+// groupPartyUnits разбивает игроков очереди на неделимые единицы подбора: одиночных
+// игроков и группы целиком. Порядок единиц соответствует порядку первого игрока.
+// Группы, не все игроки которых попали в выборку, пропускаются до следующего цикла.
+func groupPartyUnits(players []*models.Player) [][]*models.Player {
+	parties := make(map[string][]*models.Player)
+	for _, p := range players {
+		if p.PartyID != "" {
+			parties[p.PartyID] = append(parties[p.PartyID], p)
+		}
+	}
+
+	units := make([][]*models.Player, 0, len(players))
+	for _, p := range players {
+		if p.PartyID == "" {
+			units = append(units, []*models.Player{p})
+			continue
+		}
+		members, ok := parties[p.PartyID]
+		if !ok {
+			continue // Группа уже добавлена
+		}
+		delete(parties, p.PartyID)
+		if len(members) == p.PartySize {
+			units = append(units, members)
+		}
+	}
+
+	return units
+}
+
+// unitCompatibleWithGroup проверяет, что все игроки единицы подходят группе
+func (s *MatcherService) unitCompatibleWithGroup(group []*models.Player, unit []*models.Player) bool {
+	for _, p := range unit {
+		if !s.isCompatible(group[0], p) || !voiceCompatibleWithGroup(group, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// canSplitTeams проверяет, что единицы можно разложить по двум командам
+// не больше чем по teamSize игроков, не разделяя группы
+func canSplitTeams(unitSizes []int, teamSize int) bool {
+	total := 0
+	for _, size := range unitSizes {
+		total += size
+	}
+	_, ok := splitTeams(unitSizes, teamSize, total)
+	return ok
+}
+
+// splitTeams подбирает подмножество единиц для команды A так, чтобы в ней было
+// от total-teamSize до teamSize игроков. Возвращает признак попадания единицы в команду A.
+func splitTeams(unitSizes []int, teamSize, total int) ([]bool, bool) {
+	inTeamA := make([]bool, len(unitSizes))
+	var search func(i, sum int) bool
+	search = func(i, sum int) bool {
+		if sum > teamSize {
+			return false
+		}
+		if total-sum <= teamSize {
+			return true
+		}
+		for ; i < len(unitSizes); i++ {
+			inTeamA[i] = true
+			if search(i+1, sum+unitSizes[i]) {
+				return true
+			}
+			inTeamA[i] = false
+		}
+		return false
+	}
+	return inTeamA, search(0, 0)
+}
+
+// hasParty проверяет, есть ли среди игроков матча участники группы
+func hasParty(players []models.Player) bool {
+	for _, p := range players {
+		if p.PartyID != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// arrangeTeams упорядочивает игроков матча так, чтобы каждая группа целиком попала
+// в одну половину: buildMatch отдает первую половину команде A, вторую — команде B
+func arrangeTeams(units [][]*models.Player, teamSize int) []models.Player {
+	sizes := make([]int, len(units))
+	total := 0
+	for i, unit := range units {
+		sizes[i] = len(unit)
+		total += len(unit)
+	}
+	inTeamA, _ := splitTeams(sizes, teamSize, total)
+
+	teamA := make([]models.Player, 0, teamSize)
+	teamB := make([]models.Player, 0, teamSize)
+	for i, unit := range units {
+		for _, p := range unit {
+			if inTeamA[i] {
+				teamA = append(teamA, *p)
+			} else {
+				teamB = append(teamB, *p)
+			}
+		}
+	}
+
+	return append(teamA, teamB...)
+}
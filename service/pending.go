@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/storage"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	pendingMatchTimeout = 30 * time.Second // Время на подтверждение матча всеми игроками
+	declineCooldown     = 2 * time.Minute  // Запрет на вход в очередь после отказа от матча
+)
+
+var (
+	// ErrPendingMatchNotFound возвращается, если матч не ожидает подтверждения
+	ErrPendingMatchNotFound = storage.ErrPendingMatchNotFound
+
+	// ErrMatchAwaitingConfirmation возвращается FindMatch, если матч собран и ждет подтверждения игроков
+	ErrMatchAwaitingConfirmation = errors.New("match is awaiting confirmation")
+
+	// ErrPlayerNotInPendingMatch возвращается, если игрок не участвует в ожидающем матче
+	ErrPlayerNotInPendingMatch = errors.New("player is not in pending match")
+
+	// ErrPlayerOnCooldown возвращается, если игроку временно запрещен вход в очередь
+	ErrPlayerOnCooldown = errors.New("player is on queue cooldown")
+)
+
+// createPendingMatch убирает игроков из очереди и предлагает им матч. Игроки переданы
+// в порядке команд, как для buildMatch.
+func (s *MatcherService) createPendingMatch(ctx context.Context, region, gameMode string, players []models.Player) (*models.PendingMatch, error) {
+	now := time.Now()
+	pending := &models.PendingMatch{
+		PendingID: fmt.Sprintf("pending_%d", now.UnixNano()),
+		PlayerIDs: make([]string, 0, len(players)),
+		Players:   players,
+		Region:    region,
+		GameMode:  gameMode,
+		CreatedAt: now,
+		ExpiresAt: now.Add(pendingMatchTimeout),
+	}
+	for _, p := range players {
+		pending.PlayerIDs = append(pending.PlayerIDs, p.ID)
+	}
+
+	unlock, err := s.lockMultiQueuePlayers(ctx, players)
+	if err != nil {
+		return nil, err
+	}
+	err = s.storage.CreatePendingMatch(ctx, pending)
+	unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if s.pushProvider != nil {
+		body := fmt.Sprintf("Match found in %s %s, accept within %d seconds", region, gameMode, int(pendingMatchTimeout.Seconds()))
+		for _, playerID := range pending.PlayerIDs {
+			if err := s.pushProvider.Send(ctx, playerID, "Match found", body); err != nil {
+				logging.FromContext(ctx).Warn("Failed to send match confirmation notification",
+					zap.String("player_id", playerID),
+					zap.String("pending_id", pending.PendingID),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+
+	return pending, nil
+}
+
+// GetPlayerPendingMatch возвращает матч игрока, ожидающий подтверждения
+func (s *MatcherService) GetPlayerPendingMatch(ctx context.Context, playerID string) (*models.PendingMatch, error) {
+	ctx, span := startSpan(ctx, "GetPlayerPendingMatch", attribute.String("player_id", playerID))
+	defer span.End()
+
+	return s.storage.GetPlayerPendingMatch(ctx, playerID)
+}
+
+// AcceptPendingMatch подтверждает участие игрока в матче. Когда матч подтверждают все
+// игроки, он становится обычным матчем и возвращается; до этого возвращается nil.
+func (s *MatcherService) AcceptPendingMatch(ctx context.Context, pendingID, playerID string) (*models.Match, error) {
+	ctx, span := startSpan(ctx, "AcceptPendingMatch", attribute.String("player_id", playerID))
+	defer span.End()
+
+	pending, err := s.loadPendingMatchForPlayer(ctx, pendingID, playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	last, err := s.storage.AcceptPendingMatch(ctx, pending, playerID)
+	if err != nil {
+		return nil, err
+	}
+	if !last {
+		return nil, nil
+	}
+
+	claimed, err := s.storage.ClaimPendingMatch(ctx, pending)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		// Матч успел истечь или его отклонили
+		return nil, ErrPendingMatchNotFound
+	}
+
+	match := s.buildMatch(pending.Players)
+	s.assignServer(ctx, match)
+	if err := s.storage.SaveMatch(ctx, match); err != nil {
+		s.releaseServer(ctx, match)
+		return nil, fmt.Errorf("failed to save confirmed match: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("Pending match confirmed",
+		zap.String("pending_id", pending.PendingID),
+		zap.String("match_id", match.MatchID),
+		zap.Int("players_count", len(match.Players)),
+	)
+
+	if err := s.createLobbyInGameService(ctx, match); err != nil {
+		logging.FromContext(ctx).Warn("Failed to create lobby in game-service",
+			zap.String("match_id", match.MatchID),
+			zap.Error(err),
+		)
+	}
+
+	s.recordMatchStats(ctx, pending.Region, pending.GameMode, match)
+	s.publishMatchFormed(ctx, pending.Region, pending.GameMode, match)
+
+	return match, nil
+}
+
+// DeclinePendingMatch отменяет матч по отказу игрока: остальные игроки возвращаются
+// в очередь с прежним временем входа, а отказавшийся получает запрет на вход в очередь.
+func (s *MatcherService) DeclinePendingMatch(ctx context.Context, pendingID, playerID string) error {
+	ctx, span := startSpan(ctx, "DeclinePendingMatch", attribute.String("player_id", playerID))
+	defer span.End()
+
+	pending, err := s.loadPendingMatchForPlayer(ctx, pendingID, playerID)
+	if err != nil {
+		return err
+	}
+
+	claimed, err := s.storage.ClaimPendingMatch(ctx, pending)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return ErrPendingMatchNotFound
+	}
+
+	if err := s.storage.SetQueueCooldown(ctx, playerID, declineCooldown); err != nil {
+		logging.FromContext(ctx).Warn("Failed to set decline cooldown",
+			zap.String("player_id", playerID),
+			zap.Error(err),
+		)
+	}
+
+	s.requeuePendingPlayers(ctx, pending, func(p models.Player) bool {
+		return p.ID != playerID
+	})
+
+	logging.FromContext(ctx).Info("Pending match declined",
+		zap.String("pending_id", pending.PendingID),
+		zap.String("player_id", playerID),
+	)
+
+	return nil
+}
+
+// ExpirePendingMatches отменяет матчи региона/режима, не подтвержденные вовремя.
+// В очередь возвращаются только подтвердившие игроки: не ответившие считаются отошедшими.
+func (s *MatcherService) ExpirePendingMatches(ctx context.Context, region, gameMode string) error {
+	ctx, span := startSpan(ctx, "ExpirePendingMatches",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	expired, err := s.storage.GetExpiredPendingMatches(ctx, region, gameMode, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, pending := range expired {
+		// Читаем подтверждения до ClaimPendingMatch, который их удаляет
+		acceptedIDs, err := s.storage.GetAcceptedPlayers(ctx, pending.PendingID)
+		if err != nil {
+			return err
+		}
+
+		claimed, err := s.storage.ClaimPendingMatch(ctx, pending)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue // Матч завершила другая реплика или последний игрок
+		}
+
+		accepted := make(map[string]bool, len(acceptedIDs))
+		for _, id := range acceptedIDs {
+			accepted[id] = true
+		}
+		s.requeuePendingPlayers(ctx, pending, func(p models.Player) bool {
+			return accepted[p.ID]
+		})
+
+		logging.FromContext(ctx).Info("Pending match expired",
+			zap.String("pending_id", pending.PendingID),
+			zap.Int("accepted", len(acceptedIDs)),
+			zap.Int("players_count", len(pending.PlayerIDs)),
+		)
+	}
+
+	return nil
+}
+
+// loadPendingMatchForPlayer возвращает ожидающий матч, если игрок в нем участвует
+// и срок подтверждения еще не истек
+func (s *MatcherService) loadPendingMatchForPlayer(ctx context.Context, pendingID, playerID string) (*models.PendingMatch, error) {
+	pending, err := s.storage.GetPendingMatch(ctx, pendingID)
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(pending.ExpiresAt) {
+		return nil, ErrPendingMatchNotFound
+	}
+
+	for _, id := range pending.PlayerIDs {
+		if id == playerID {
+			return pending, nil
+		}
+	}
+	return nil, ErrPlayerNotInPendingMatch
+}
+
+// requeuePendingPlayers возвращает в очередь игроков отмененного матча, для которых keep
+// возвращает true. Время входа сохраняется, поэтому игроки не теряют приоритет.
+// Если группа возвращается не целиком, ее игроки встают в очередь поодиночке.
+func (s *MatcherService) requeuePendingPlayers(ctx context.Context, pending *models.PendingMatch, keep func(models.Player) bool) {
+	kept := make(map[string]int)
+	for _, p := range pending.Players {
+		if p.PartyID != "" && keep(p) {
+			kept[p.PartyID]++
+		}
+	}
+
+	for _, p := range pending.Players {
+		if !keep(p) {
+			continue
+		}
+		player := p
+		if player.PartyID != "" && kept[player.PartyID] != player.PartySize {
+			player.PartyID = ""
+			player.PartySize = 0
+		}
+		if err := s.storage.AddPlayerToQueue(ctx, &player); err != nil && !errors.Is(err, ErrPlayerAlreadyQueued) {
+			logging.FromContext(ctx).Warn("Failed to requeue player after cancelled match",
+				zap.String("player_id", p.ID),
+				zap.String("pending_id", pending.PendingID),
+				zap.Error(err),
+			)
+		}
+	}
+}
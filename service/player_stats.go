@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/storage"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// GetPlayerStats возвращает сводную статистику игрока: число матчей, победы и поражения
+// по отправленным результатам и среднее ожидание матча в очереди. Для игрока без
+// матчей возвращаются нули.
+func (s *MatcherService) GetPlayerStats(ctx context.Context, playerID string) (*models.PlayerStats, error) {
+	ctx, span := startSpan(ctx, "GetPlayerStats", attribute.String("player_id", playerID))
+	defer span.End()
+
+	counters, err := s.storage.GetPlayerStats(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+	waits, err := s.storage.GetPlayerWaitTimes(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &models.PlayerStats{
+		PlayerID:   playerID,
+		MatchCount: int(counters[storage.StatMatchCount]),
+		Wins:       int(counters[storage.StatWins]),
+		Losses:     int(counters[storage.StatLosses]),
+	}
+	if decided := stats.Wins + stats.Losses; decided > 0 {
+		stats.WinRate = float64(stats.Wins) / float64(decided)
+	}
+	if len(waits) > 0 {
+		var total float64
+		for _, wait := range waits {
+			total += wait.Seconds()
+		}
+		stats.AvgWaitSeconds = total / float64(len(waits))
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+
+	"chrono-matchmaking/logging"
+	"go.uber.org/zap"
+)
+
+// populationMatchesFactor сколько матчей должно набираться из очереди, чтобы она не
+// считалась малолюдной
+const populationMatchesFactor = 3
+
+// PopulationAdvisor подстраивает конфигурацию очереди под число игроков в ней: в
+// малолюдной очереди (например, ночью) MaxRatingDiff расширяется, чтобы игроки не
+// ждали до MaxSearchTime
+type PopulationAdvisor struct {
+	matcher *MatcherService // Размер очереди и размеры матчей режимов
+}
+
+// NewPopulationAdvisor создает советника для очередей матчмейкера
+func NewPopulationAdvisor(matcher *MatcherService) *PopulationAdvisor {
+	return &PopulationAdvisor{matcher: matcher}
+}
+
+// AdjustConfig возвращает копию base с MaxRatingDiff, обратно пропорциональным числу
+// игроков в очереди: diff * minPop / max(pop, PlayersPerMatch), где minPop —
+// PlayersPerMatch * 3. Очередь из minPop игроков и больше получает base без изменений;
+// меньшая очередь — диапазон шире, но не больше чем втрое (на одну группу игроков).
+// base не изменяется.
+func (a *PopulationAdvisor) AdjustConfig(ctx context.Context, region, gameMode string, base *MatcherConfig) (*MatcherConfig, error) {
+	playersPerMatch, err := a.matcher.GetPlayersPerMatch(ctx, gameMode)
+	if err != nil {
+		return nil, err
+	}
+	size, err := a.matcher.GetQueueSize(ctx, region, gameMode)
+	if err != nil {
+		return nil, err
+	}
+
+	adjusted := *base
+	minPop := int64(playersPerMatch * populationMatchesFactor)
+	if size >= minPop {
+		return &adjusted, nil
+	}
+
+	population := size
+	if population < int64(playersPerMatch) {
+		population = int64(playersPerMatch)
+	}
+	adjusted.MaxRatingDiff = int(int64(base.MaxRatingDiff) * minPop / population)
+
+	logging.FromContext(ctx).Debug("Rating range relaxed for sparse queue",
+		zap.String("region", region),
+		zap.String("game_mode", gameMode),
+		zap.Int64("queue_size", size),
+		zap.Int("max_rating_diff", adjusted.MaxRatingDiff),
+	)
+	return &adjusted, nil
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/storage"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	inviteLifetime     = 10 * time.Minute // Срок действия кода приглашения
+	inviteCodeLength   = 6
+	inviteCodeAttempts = 5 // Сколько раз генерируется новый код, если случайный уже занят
+
+	// inviteCodeAlphabet символы кода приглашения без похожих друг на друга (0/O, 1/I)
+	inviteCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+var (
+	// ErrInviteNotFound возвращается, если приглашения нет, срок его истек или матч уже собран
+	ErrInviteNotFound = storage.ErrInviteNotFound
+
+	// ErrAlreadyInInvite возвращается, если игрок уже вошел в приватный матч
+	ErrAlreadyInInvite = storage.ErrAlreadyInInvite
+)
+
+// CreatePrivateMatch создает приватный матч и возвращает его код приглашения. Код
+// действует 10 минут; хост входит в матч по нему так же, как остальные игроки.
+func (s *MatcherService) CreatePrivateMatch(ctx context.Context, req *models.CreatePrivateMatchRequest) (*models.InviteMatch, error) {
+	ctx, span := startSpan(ctx, "CreatePrivateMatch",
+		attribute.String("host_player_id", req.HostPlayerID),
+		attribute.String("region", req.Region),
+		attribute.String("game_mode", req.GameMode),
+	)
+	defer span.End()
+
+	playersPerMatch, err := s.GetPlayersPerMatch(ctx, req.GameMode)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	invite := &models.InviteMatch{
+		HostPlayerID:  req.HostPlayerID,
+		Region:        req.Region,
+		GameMode:      req.GameMode,
+		PlayersNeeded: playersPerMatch,
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(inviteLifetime),
+	}
+
+	for attempt := 0; attempt < inviteCodeAttempts; attempt++ {
+		if invite.InviteCode, err = generateInviteCode(); err != nil {
+			return nil, err
+		}
+		err = s.storage.CreateInvite(ctx, invite)
+		if !errors.Is(err, storage.ErrInviteCodeTaken) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).Info("Private match created",
+		zap.String("invite_code", invite.InviteCode),
+		zap.String("host_player_id", invite.HostPlayerID),
+		zap.String("region", invite.Region),
+		zap.String("game_mode", invite.GameMode),
+	)
+
+	return invite, nil
+}
+
+// JoinPrivateMatch добавляет игрока в приватный матч по коду приглашения. Приватный матч
+// не использует общую очередь и подбор: когда входит последний нужный игрок, из всех
+// вошедших сразу создается матч (команды балансируются по рейтингу), и игроки получают
+// уведомление так же, как о матче из очереди. Игрок, стоящий в общей очереди, войти не может.
+func (s *MatcherService) JoinPrivateMatch(ctx context.Context, code string, player *models.Player) (*models.JoinPrivateMatchResponse, error) {
+	ctx, span := startSpan(ctx, "JoinPrivateMatch",
+		attribute.String("invite_code", code),
+		attribute.String("player_id", player.ID),
+	)
+	defer span.End()
+
+	if err := s.checkBan(ctx, player.ID); err != nil {
+		return nil, err
+	}
+	if _, err := s.storage.GetPlayerByID(ctx, player.ID); err == nil {
+		return nil, ErrPlayerAlreadyQueued
+	}
+	if err := s.applyStoredRating(ctx, player); err != nil {
+		return nil, err
+	}
+
+	invite, err := s.storage.GetInvite(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	player.Region = invite.Region
+	player.GameMode = invite.GameMode
+
+	joined, players, err := s.storage.JoinInvite(ctx, invite, player)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.JoinPrivateMatchResponse{
+		InviteCode:    code,
+		PlayersJoined: joined,
+		PlayersNeeded: invite.PlayersNeeded,
+	}
+	if players == nil {
+		return resp, nil
+	}
+
+	match := s.buildMatch(players)
+	s.assignServer(ctx, match)
+	if err := s.storage.SaveMatch(ctx, match); err != nil {
+		s.releaseServer(ctx, match)
+		return nil, fmt.Errorf("failed to save private match: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("Private match formed",
+		zap.String("invite_code", code),
+		zap.String("match_id", match.MatchID),
+		zap.Int("players_count", len(match.Players)),
+	)
+
+	// Статистика очереди не обновляется: игроки приватного матча в ней не ждали
+	if err := s.createLobbyInGameService(ctx, match); err != nil {
+		logging.FromContext(ctx).Warn("Failed to create lobby in game-service",
+			zap.String("match_id", match.MatchID),
+			zap.Error(err),
+		)
+	}
+	s.publishMatchFormed(ctx, invite.Region, invite.GameMode, match)
+
+	resp.Match = match
+	return resp, nil
+}
+
+// generateInviteCode возвращает случайный код приглашения
+func generateInviteCode() (string, error) {
+	code := make([]byte, inviteCodeLength)
+	max := big.NewInt(int64(len(inviteCodeAlphabet)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate invite code: %w", err)
+		}
+		code[i] = inviteCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/metrics"
+	"go.uber.org/zap"
+)
+
+// queueProcessInterval период обработки очередей
+const queueProcessInterval = 10 * time.Second
+
+// queueAutoScaleInterval период проверки заполненности канала заданий
+const queueAutoScaleInterval = 30 * time.Second
+
+// queueJobBufferSize емкость канала заданий воркеров
+const queueJobBufferSize = 64
+
+// DefaultQueueWorkerUtilization целевая заполненность канала заданий по умолчанию
+const DefaultQueueWorkerUtilization = 0.7
+
+// queueError ошибка обработки очереди региона/режима
+type queueError struct {
+	region   string
+	gameMode string
+	err      error
+}
+
+// queueJob задание воркеру: обработать очередь региона/режима в рамках прохода
+type queueJob struct {
+	ctx      context.Context
+	region   string
+	gameMode string
+	done     func(err error) // Вызывается воркером после обработки очереди
+}
+
+// QueueProcessor периодически обрабатывает очереди всех регионов и режимов.
+// Очереди прохода отправляются в канал заданий, из которого их разбирает пул
+// воркеров; размер пула меняет AutoScaleQueueWorkers.
+type QueueProcessor struct {
+	matcher  *MatcherService
+	logger   *zap.Logger
+	poolSize int           // Размер пула при запуске
+	jobs     chan queueJob // Очереди, ожидающие свободного воркера
+
+	mu        sync.Mutex
+	workers   []context.CancelFunc // Остановка каждого воркера; последний запущенный останавливается первым
+	wg        sync.WaitGroup       // Запущенные воркеры
+	peakFill  float64              // Наибольшая заполненность канала заданий с последней проверки
+	regions   []string             // Регионы последнего прохода
+	gameModes []string             // Режимы последнего прохода
+}
+
+// NewQueueProcessor создает обработчик очередей с пулом из poolSize воркеров
+func NewQueueProcessor(matcher *MatcherService, poolSize int, logger *zap.Logger) *QueueProcessor {
+	if poolSize <= 0 {
+		poolSize = 1
+	}
+	return &QueueProcessor{
+		matcher:   matcher,
+		logger:    logger,
+		poolSize:  poolSize,
+		jobs:      make(chan queueJob, queueJobBufferSize),
+		regions:   DefaultRegions(),
+		gameModes: GameModeNames(DefaultGameModes()),
+	}
+}
+
+// Run запускает пул воркеров и обрабатывает очереди каждые 10 секунд до отмены
+// ctx. Списки регионов и режимов перечитываются в начале каждого прохода; если
+// их не удалось получить, используются списки предыдущего прохода. Следующий
+// проход начинается только после завершения предыдущего, поэтому одна очередь
+// не обрабатывается двумя воркерами одновременно. После отмены ctx Run
+// дожидается завершения всех воркеров.
+func (p *QueueProcessor) Run(ctx context.Context) {
+	if p.WorkerCount() < p.poolSize {
+		p.scaleTo(ctx, p.poolSize)
+	}
+	defer p.stopWorkers()
+
+	ticker := time.NewTicker(queueProcessInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			regions, gameModes := p.refreshQueues(ctx)
+			p.processAll(ctx, regions, gameModes)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// refreshQueues перечитывает списки регионов и режимов, оставляя прежние при ошибке
+func (p *QueueProcessor) refreshQueues(ctx context.Context) ([]string, []string) {
+	p.mu.Lock()
+	regions, gameModes := p.regions, p.gameModes
+	p.mu.Unlock()
+
+	if current, err := p.matcher.GetRegions(ctx); err != nil {
+		logging.FromContext(ctx).Warn("Failed to get active regions, using previous list", zap.Error(err))
+	} else {
+		regions = current
+	}
+	if modes, err := p.matcher.GetGameModes(ctx); err != nil {
+		logging.FromContext(ctx).Warn("Failed to get game modes, using previous list", zap.Error(err))
+	} else {
+		gameModes = GameModeNames(modes)
+	}
+
+	p.mu.Lock()
+	p.regions, p.gameModes = regions, gameModes
+	p.mu.Unlock()
+	return regions, gameModes
+}
+
+// processAll отправляет каждую очередь в канал заданий, дожидается обработки
+// всех отправленных очередей и пишет в лог их ошибки
+func (p *QueueProcessor) processAll(ctx context.Context, regions, gameModes []string) {
+	errs := make(chan queueError, len(regions)*len(gameModes))
+
+	var wg sync.WaitGroup
+dispatch:
+	for _, region := range regions {
+		for _, gameMode := range gameModes {
+			region, gameMode := region, gameMode
+			wg.Add(1)
+			job := queueJob{
+				ctx:      ctx,
+				region:   region,
+				gameMode: gameMode,
+				done: func(err error) {
+					if err != nil {
+						errs <- queueError{region: region, gameMode: gameMode, err: err}
+					}
+					wg.Done()
+				},
+			}
+
+			select {
+			case p.jobs <- job:
+				p.recordFill()
+			case <-ctx.Done():
+				wg.Done()
+				break dispatch
+			}
+		}
+	}
+
+	go func() {
+		wg.Wait()
+		close(errs)
+	}()
+
+	for {
+		select {
+		case qe, ok := <-errs:
+			if !ok {
+				return
+			}
+			logging.FromContext(ctx).Warn("Failed to process queue",
+				zap.String("region", qe.region),
+				zap.String("game_mode", qe.gameMode),
+				zap.Error(qe.err),
+			)
+		case <-ctx.Done():
+			// Оставшиеся в канале очереди уже не будут обработаны
+			return
+		}
+	}
+}
+
+// recordFill запоминает заполненность канала заданий, если она выше прежней
+func (p *QueueProcessor) recordFill() {
+	fill := float64(len(p.jobs)) / float64(cap(p.jobs))
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if fill > p.peakFill {
+		p.peakFill = fill
+	}
+}
+
+// worker обрабатывает задания из канала до отмены своего контекста. Отмена не
+// прерывает обработку текущей очереди: задание выполняется в контексте прохода.
+func (p *QueueProcessor) worker(ctx context.Context) {
+	defer p.wg.Done()
+
+	for {
+		select {
+		case job := <-p.jobs:
+			job.done(p.processQueue(job.ctx, job.region, job.gameMode))
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// WorkerCount возвращает количество запущенных воркеров
+func (p *QueueProcessor) WorkerCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.workers)
+}
+
+// scaleTo запускает или останавливает воркеров, пока их не станет n
+func (p *QueueProcessor) scaleTo(ctx context.Context, n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for len(p.workers) < n {
+		workerCtx, cancel := context.WithCancel(ctx)
+		p.workers = append(p.workers, cancel)
+		p.wg.Add(1)
+		go p.worker(workerCtx)
+	}
+	for len(p.workers) > n {
+		last := len(p.workers) - 1
+		p.workers[last]()
+		p.workers = p.workers[:last]
+	}
+	metrics.QueueWorkerCount.Set(float64(len(p.workers)))
+}
+
+// stopWorkers останавливает всех воркеров и дожидается их завершения
+func (p *QueueProcessor) stopWorkers() {
+	p.scaleTo(context.Background(), 0)
+	p.wg.Wait()
+}
+
+// AutoScaleQueueWorkers каждые 30 секунд сравнивает наибольшую заполненность
+// канала заданий за прошедший период с targetUtilization и пропорционально
+// меняет число воркеров. Воркеров не меньше, чем очередей (регионы × режимы),
+// и не больше MaxWorkers из конфигурации. Работает до отмены ctx.
+func (p *QueueProcessor) AutoScaleQueueWorkers(ctx context.Context, targetUtilization float64) error {
+	if targetUtilization <= 0 || targetUtilization > 1 {
+		return fmt.Errorf("target utilization must be in (0, 1], got %v", targetUtilization)
+	}
+
+	ticker := time.NewTicker(queueAutoScaleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.autoScale(ctx, targetUtilization)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// autoScale выполняет одну проверку автомасштабирования и возвращает новое число воркеров
+func (p *QueueProcessor) autoScale(ctx context.Context, targetUtilization float64) int {
+	p.mu.Lock()
+	fill := p.peakFill
+	p.peakFill = float64(len(p.jobs)) / float64(cap(p.jobs))
+	current := len(p.workers)
+	minWorkers := len(p.regions) * len(p.gameModes)
+	p.mu.Unlock()
+
+	maxWorkers := p.matcher.currentConfig().MaxWorkers
+	if minWorkers < 1 {
+		minWorkers = 1
+	}
+	if maxWorkers < minWorkers {
+		maxWorkers = minWorkers
+	}
+
+	desired := int(math.Ceil(float64(current) * fill / targetUtilization))
+	if desired < minWorkers {
+		desired = minWorkers
+	}
+	if desired > maxWorkers {
+		desired = maxWorkers
+	}
+	if desired == current {
+		return current
+	}
+
+	logging.FromContext(ctx).Info("Scaling queue workers",
+		zap.Int("from", current),
+		zap.Int("to", desired),
+		zap.Float64("fill_ratio", fill),
+	)
+	p.scaleTo(ctx, desired)
+	return desired
+}
+
+// processQueue обрабатывает очередь, если эта реплика является ее лидером
+func (p *QueueProcessor) processQueue(ctx context.Context, region, gameMode string) error {
+	leader, err := p.matcher.BecomeLeader(ctx, region, gameMode)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to acquire queue leadership",
+			zap.String("region", region),
+			zap.String("game_mode", gameMode),
+			zap.Error(err),
+		)
+		return nil
+	}
+	if !leader {
+		return nil
+	}
+
+	return p.matcher.ProcessQueue(ctx, region, gameMode)
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"chrono-matchmaking/storage"
+	"go.uber.org/zap"
+)
+
+// fillQueueJobs заполняет канал заданий до n заданий и запоминает заполненность
+func fillQueueJobs(p *QueueProcessor, n int) {
+	for i := 0; i < n; i++ {
+		p.jobs <- queueJob{ctx: context.Background(), region: "eu", gameMode: "ranked", done: func(error) {}}
+	}
+	p.recordFill()
+}
+
+func TestAutoScaleQueueWorkersScalesUp(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	matcher := NewMatcherService(storage.NewInMemoryStorage(), zap.NewNop(), DefaultMatcherConfig())
+	p := NewQueueProcessor(matcher, 4, zap.NewNop())
+	p.regions = []string{"eu"}
+	p.gameModes = []string{"ranked"}
+	defer p.stopWorkers()
+
+	// Канал заполнен больше чем на 90%
+	fillQueueJobs(p, cap(p.jobs)*95/100)
+	p.scaleTo(ctx, 4)
+
+	if got := p.autoScale(ctx, DefaultQueueWorkerUtilization); got <= 4 {
+		t.Fatalf("expected scale-up from 4 workers, got %d", got)
+	}
+	if got := p.WorkerCount(); got != 6 {
+		t.Errorf("expected 6 workers, got %d", got)
+	}
+}
+
+func TestAutoScaleQueueWorkersBounds(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := DefaultMatcherConfig()
+	cfg.MaxWorkers = 5
+	matcher := NewMatcherService(storage.NewInMemoryStorage(), zap.NewNop(), cfg)
+	p := NewQueueProcessor(matcher, 4, zap.NewNop())
+	p.regions = []string{"eu", "na"}
+	p.gameModes = []string{"ranked"}
+	defer p.stopWorkers()
+
+	fillQueueJobs(p, cap(p.jobs))
+	p.scaleTo(ctx, 4)
+	if got := p.autoScale(ctx, DefaultQueueWorkerUtilization); got != 5 {
+		t.Errorf("expected scale-up capped at MaxWorkers 5, got %d", got)
+	}
+
+	// Без заданий пул сокращается до числа очередей
+	p.mu.Lock()
+	p.peakFill = 0
+	p.mu.Unlock()
+	if got := p.autoScale(ctx, DefaultQueueWorkerUtilization); got != 2 {
+		t.Errorf("expected scale-down to 2 queues, got %d", got)
+	}
+}
+
+func TestAutoScaleQueueWorkersRejectsTarget(t *testing.T) {
+	matcher := NewMatcherService(storage.NewInMemoryStorage(), zap.NewNop(), DefaultMatcherConfig())
+	p := NewQueueProcessor(matcher, 1, zap.NewNop())
+
+	for _, target := range []float64{0, -0.5, 1.5} {
+		if err := p.AutoScaleQueueWorkers(context.Background(), target); err == nil {
+			t.Errorf("expected error for target utilization %v", target)
+		}
+	}
+}
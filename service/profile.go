@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/storage"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrProfileNotFound возвращается, если игрок не зарегистрирован
+var ErrProfileNotFound = storage.ErrProfileNotFound
+
+// RegisterPlayer создает постоянный профиль игрока. Идентификатор выводится из email,
+// поэтому повторная регистрация возвращает существующий профиль и created == false.
+func (s *MatcherService) RegisterPlayer(ctx context.Context, req *models.RegisterRequest) (profile *models.PlayerProfile, created bool, err error) {
+	ctx, span := startSpan(ctx, "RegisterPlayer")
+	defer span.End()
+
+	profile = models.NewPlayerProfile(req.DisplayName, req.Email, req.Platform)
+
+	created, err = s.storage.CreateProfile(ctx, profile)
+	if err != nil {
+		return nil, false, err
+	}
+	if !created {
+		profile, err = s.storage.GetProfile(ctx, profile.PlayerID)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	return profile, created, nil
+}
+
+// GetPlayerProfile возвращает профиль зарегистрированного игрока
+func (s *MatcherService) GetPlayerProfile(ctx context.Context, playerID string) (*models.PlayerProfile, error) {
+	ctx, span := startSpan(ctx, "GetPlayerProfile", attribute.String("player_id", playerID))
+	defer span.End()
+
+	return s.storage.GetProfile(ctx, playerID)
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"chrono-matchmaking/logging"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// positionImprovementThreshold на сколько позиций игрок должен продвинуться
+// за один цикл обработки очереди, чтобы получить уведомление
+const positionImprovementThreshold = 5
+
+// NotifyQueuePositionChange сравнивает позиции игроков с предыдущим циклом ProcessQueue
+// и отправляет push-уведомление тем, кто продвинулся больше чем на 5 позиций.
+// Снимок позиций хранится в Redis (см. storage.SwapQueuePositions), поэтому после
+// смены лидера очереди новый лидер сравнивает позиции со снимком прежнего.
+func (s *MatcherService) NotifyQueuePositionChange(ctx context.Context, region, gameMode string) {
+	ctx, span := startSpan(ctx, "NotifyQueuePositionChange",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	if s.pushProvider == nil {
+		return
+	}
+
+	players, err := s.buildWaitingPlayers(ctx, region, gameMode)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to load queue positions",
+			zap.String("region", region),
+			zap.String("game_mode", gameMode),
+			zap.Error(err),
+		)
+		return
+	}
+
+	current := make(map[string]int64, len(players))
+	for _, p := range players {
+		current[p.PlayerID] = p.Position
+	}
+
+	previous, err := s.storage.SwapQueuePositions(ctx, region, gameMode, current)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to swap queue position snapshot",
+			zap.String("region", region),
+			zap.String("game_mode", gameMode),
+			zap.Error(err),
+		)
+		return
+	}
+
+	for playerID, position := range current {
+		before, ok := previous[playerID]
+		if !ok || before-position <= positionImprovementThreshold {
+			continue
+		}
+
+		body := fmt.Sprintf("You moved up to position %d in the %s %s queue", position, region, gameMode)
+		if err := s.pushProvider.Send(ctx, playerID, "Match almost ready", body); err != nil {
+			logging.FromContext(ctx).Warn("Failed to send queue position notification",
+				zap.String("player_id", playerID),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// RegisterPushToken сохраняет токен устройства игрока для push-уведомлений.
+// Возвращает ErrProfileNotFound, если игрок не зарегистрирован.
+func (s *MatcherService) RegisterPushToken(ctx context.Context, playerID, token string) error {
+	ctx, span := startSpan(ctx, "RegisterPushToken", attribute.String("player_id", playerID))
+	defer span.End()
+
+	return s.storage.AddDeviceToken(ctx, playerID, token)
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/storage"
+	"go.uber.org/zap"
+)
+
+// recordingPushProvider запоминает игроков, которым отправлены уведомления
+type recordingPushProvider struct {
+	mu   sync.Mutex
+	sent []string
+}
+
+func (p *recordingPushProvider) Send(ctx context.Context, playerID, title, body string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sent = append(p.sent, playerID)
+	return nil
+}
+
+// TestNotifyQueuePositionChangeAfterLeaderChange проверяет, что снимок позиций общий
+// для реплик: новый лидер очереди уведомляет игроков, продвинувшихся со времени
+// последнего цикла прежнего лидера
+func TestNotifyQueuePositionChangeAfterLeaderChange(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewInMemoryStorage()
+
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		p := &models.Player{ID: fmt.Sprintf("p%d", i), Rating: 1500, Region: "EU", GameMode: "3v3", JoinedAt: now.Add(time.Duration(i-10) * time.Minute)}
+		if err := store.AddPlayerToQueue(ctx, p); err != nil {
+			t.Fatalf("AddPlayerToQueue: %v", err)
+		}
+	}
+
+	oldLeader := NewMatcherService(store, zap.NewNop(), DefaultMatcherConfig())
+	oldLeader.SetPushProvider(&recordingPushProvider{})
+	oldLeader.NotifyQueuePositionChange(ctx, "EU", "3v3")
+
+	// Шесть игроков, ждавших дольше всех, ушли из очереди
+	for i := 0; i < 6; i++ {
+		if err := store.RemovePlayerFromQueue(ctx, fmt.Sprintf("p%d", i)); err != nil {
+			t.Fatalf("RemovePlayerFromQueue: %v", err)
+		}
+	}
+
+	push := &recordingPushProvider{}
+	newLeader := NewMatcherService(store, zap.NewNop(), DefaultMatcherConfig())
+	newLeader.SetPushProvider(push)
+	newLeader.NotifyQueuePositionChange(ctx, "EU", "3v3")
+
+	if len(push.sent) != 4 {
+		t.Errorf("notified %v, want the 4 remaining players", push.sent)
+	}
+
+	// Без перемещений повторный цикл никого не уведомляет
+	push.sent = nil
+	newLeader.NotifyQueuePositionChange(ctx, "EU", "3v3")
+	if len(push.sent) != 0 {
+		t.Errorf("notified %v without position changes", push.sent)
+	}
+}
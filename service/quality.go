@@ -0,0 +1,56 @@
+package service
+
+import (
+	"math"
+
+	"chrono-matchmaking/models"
+)
+
+const (
+	qualityGapScale    = 200.0 // Разница средних рейтингов команд, снижающая качество вдвое
+	qualitySpreadScale = 400.0 // Стандартное отклонение рейтинга внутри команд, снижающее качество вдвое
+)
+
+// ComputeMatchQuality оценивает баланс матча от 0 до 1. Игроки передаются в порядке команд:
+// первая половина — команда A, вторая — команда B. Качество тем выше, чем меньше разница
+// средних рейтингов команд и разброс рейтинга внутри каждой из них:
+//
+//	quality = 1 / (1 + gap/200 + spread/400)
+//
+// где gap — разница средних рейтингов команд, spread — среднее стандартное отклонение
+// рейтинга в командах. Равные по рейтингу игроки дают 1.
+func ComputeMatchQuality(players []models.Player) float64 {
+	if len(players) < 2 {
+		return 0
+	}
+
+	half := len(players) / 2
+	meanA, stdA := ratingStats(players[:half])
+	meanB, stdB := ratingStats(players[half:])
+
+	gap := math.Abs(meanA - meanB)
+	spread := (stdA + stdB) / 2
+
+	return 1 / (1 + gap/qualityGapScale + spread/qualitySpreadScale)
+}
+
+// ratingStats возвращает средний рейтинг игроков и его стандартное отклонение
+func ratingStats(players []models.Player) (mean, std float64) {
+	if len(players) == 0 {
+		return 0, 0
+	}
+
+	for _, p := range players {
+		mean += float64(p.Rating)
+	}
+	mean /= float64(len(players))
+
+	var variance float64
+	for _, p := range players {
+		d := float64(p.Rating) - mean
+		variance += d * d
+	}
+	variance /= float64(len(players))
+
+	return mean, math.Sqrt(variance)
+}
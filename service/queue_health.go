@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/metrics"
+	"chrono-matchmaking/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// topWaitingCacheTTL время жизни кэша списка долго ожидающих игроков
+const topWaitingCacheTTL = 5 * time.Second
+
+// topWaitingCacheEntry закэшированный список игроков очереди, упорядоченный по времени ожидания
+type topWaitingCacheEntry struct {
+	players   []*models.WaitingPlayerInfo
+	expiresAt time.Time
+}
+
+// GetTopWaitingPlayers возвращает до limit игроков, дольше всех ожидающих в очереди.
+// Результат кэшируется на 5 секунд для каждой пары регион/режим.
+func (s *MatcherService) GetTopWaitingPlayers(ctx context.Context, region, gameMode string, limit int) ([]*models.WaitingPlayerInfo, error) {
+	ctx, span := startSpan(ctx, "GetTopWaitingPlayers",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	cacheKey := region + ":" + gameMode
+
+	s.topWaitingMu.Lock()
+	entry, ok := s.topWaitingCache[cacheKey]
+	s.topWaitingMu.Unlock()
+
+	if !ok || time.Now().After(entry.expiresAt) {
+		players, err := s.buildWaitingPlayers(ctx, region, gameMode)
+		if err != nil {
+			return nil, err
+		}
+
+		entry = topWaitingCacheEntry{
+			players:   players,
+			expiresAt: time.Now().Add(topWaitingCacheTTL),
+		}
+		s.topWaitingMu.Lock()
+		s.topWaitingCache[cacheKey] = entry
+		s.topWaitingMu.Unlock()
+	}
+
+	if limit <= 0 || limit > len(entry.players) {
+		limit = len(entry.players)
+	}
+
+	result := make([]*models.WaitingPlayerInfo, limit)
+	copy(result, entry.players[:limit])
+	return result, nil
+}
+
+// buildWaitingPlayers загружает очередь и упорядочивает игроков по времени входа
+func (s *MatcherService) buildWaitingPlayers(ctx context.Context, region, gameMode string) ([]*models.WaitingPlayerInfo, error) {
+	players, err := s.storage.GetQueuePlayers(ctx, region, gameMode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get queue players: %w", err)
+	}
+
+	sort.Slice(players, func(i, j int) bool {
+		return players[i].JoinedAt.Before(players[j].JoinedAt)
+	})
+
+	maxSearchTime := s.configForContext(region, gameMode).MaxSearchTime
+	now := time.Now()
+
+	result := make([]*models.WaitingPlayerInfo, 0, len(players))
+	for i, p := range players {
+		wait := now.Sub(p.JoinedAt)
+
+		// После MaxSearchTime диапазон рейтинга максимален, поэтому оцениваем
+		// оставшееся ожидание как время до этого момента
+		remaining := math.Max((maxSearchTime - wait).Seconds(), 0)
+
+		result = append(result, &models.WaitingPlayerInfo{
+			PlayerID:                      p.ID,
+			WaitSeconds:                   wait.Seconds(),
+			Rating:                        p.Rating,
+			Position:                      int64(i + 1),
+			EstimatedWaitSecondsRemaining: remaining,
+		})
+	}
+
+	return result, nil
+}
+
+// updateQueueHealthMetrics обновляет метрики состояния очереди
+func (s *MatcherService) updateQueueHealthMetrics(ctx context.Context, region, gameMode string) error {
+	oldest, err := s.GetTopWaitingPlayers(ctx, region, gameMode, 1)
+	if err != nil {
+		return err
+	}
+
+	waitSeconds := 0.0
+	if len(oldest) > 0 {
+		waitSeconds = oldest[0].WaitSeconds
+	}
+	metrics.QueueOldestWaiterSeconds.WithLabelValues(region, gameMode).Set(waitSeconds)
+
+	s.updateQueueDepth(ctx, region, gameMode)
+
+	return nil
+}
+
+// updateQueueDepth обновляет метрику queue_depth по текущему размеру очереди
+func (s *MatcherService) updateQueueDepth(ctx context.Context, region, gameMode string) {
+	size, err := s.storage.GetQueueSize(ctx, region, gameMode)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to update queue depth metric",
+			zap.String("region", region),
+			zap.String("game_mode", gameMode),
+			zap.Error(err),
+		)
+		return
+	}
+	metrics.QueueDepth.WithLabelValues(region, gameMode).Set(float64(size))
+}
+
+// recordQueueFull учитывает в метрике queue_full_rejections_total вход, отклоненный
+// из-за размера очереди
+func (s *MatcherService) recordQueueFull(player *models.Player) {
+	metrics.QueueFullRejectionsTotal.WithLabelValues(player.Region, player.GameMode).Inc()
+	s.logger.Warn("Queue is full, join rejected",
+		zap.String("player_id", player.ID),
+		zap.String("region", player.Region),
+		zap.String("game_mode", player.GameMode),
+	)
+}
+
+// GetQueueMemberCount возвращает количество активных и устаревших игроков в очереди.
+// Устаревшими считаются игроки, ожидающие дольше MaxSearchTime.
+func (s *MatcherService) GetQueueMemberCount(ctx context.Context, region, gameMode string) (active, stale, total int64, err error) {
+	ctx, span := startSpan(ctx, "GetQueueMemberCount",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	return s.storage.GetQueueMemberCount(ctx, region, gameMode, s.configForContext(region, gameMode).MaxSearchTime)
+}
+
+// PurgeInactivePlayers удаляет из очереди игроков, ожидающих дольше MaxSearchTime.
+// Очередь изменяется, только если в ней есть устаревшие игроки.
+func (s *MatcherService) PurgeInactivePlayers(ctx context.Context, region, gameMode string) (int64, error) {
+	ctx, span := startSpan(ctx, "PurgeInactivePlayers",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	_, stale, _, err := s.GetQueueMemberCount(ctx, region, gameMode)
+	if err != nil {
+		return 0, err
+	}
+	if stale == 0 {
+		return 0, nil
+	}
+
+	removed, err := s.storage.RemoveStalePlayers(ctx, region, gameMode, s.configForContext(region, gameMode).MaxSearchTime)
+	if err != nil {
+		return 0, err
+	}
+
+	// Плановая очистка тоже откладывает автоматическую (AutoPurgeStalePlayers)
+	if err := s.storage.MarkQueuePurged(ctx, region, gameMode, autoPurgeCooldown); err != nil {
+		logging.FromContext(ctx).Warn("Failed to record queue purge",
+			zap.String("region", region),
+			zap.String("game_mode", gameMode),
+			zap.Error(err),
+		)
+	}
+
+	return removed, nil
+}
+
+// ReindexPlayerRatings пересортировывает очередь после массового пересчета рейтингов
+// (например, сброса сезона): score каждого элемента приводится к актуальному рейтингу
+// из player:{id}. Возвращает количество обновленных элементов.
+func (s *MatcherService) ReindexPlayerRatings(ctx context.Context, region, gameMode string) (int64, error) {
+	ctx, span := startSpan(ctx, "ReindexPlayerRatings",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	return s.storage.ReindexQueueScores(ctx, region, gameMode)
+}
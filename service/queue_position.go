@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/storage"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrPlayerNotInQueue возвращается, если игрока нет в очереди
+var ErrPlayerNotInQueue = storage.ErrPlayerNotInQueue
+
+// GetQueuePosition возвращает позицию игрока в очереди и текущий диапазон поиска соперников
+func (s *MatcherService) GetQueuePosition(ctx context.Context, playerID string) (*models.QueuePosition, error) {
+	ctx, span := startSpan(ctx, "GetQueuePosition", attribute.String("player_id", playerID))
+	defer span.End()
+
+	player, err := s.storage.GetPlayerByID(ctx, playerID)
+	if err != nil {
+		return nil, ErrPlayerNotInQueue
+	}
+
+	position, err := s.storage.GetPlayerQueuePosition(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.storage.GetQueueSize(ctx, player.Region, player.GameMode)
+	if err != nil {
+		return nil, err
+	}
+
+	ratingRange := s.calculateRatingRange(player.Region, player.GameMode, time.Since(player.JoinedAt))
+
+	return &models.QueuePosition{
+		PlayerID:     player.ID,
+		Position:     position,
+		TotalInQueue: total,
+		Rating:       player.Rating,
+		Region:       player.Region,
+		GameMode:     player.GameMode,
+		RatingRange:  ratingRange,
+		MinRating:    player.Rating - ratingRange,
+		MaxRating:    player.Rating + ratingRange,
+		WaitSeconds:  time.Since(player.JoinedAt).Seconds(),
+	}, nil
+}
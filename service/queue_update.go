@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrRegionNotActive возвращается при переходе в регион, которого нет в списке активных
+	ErrRegionNotActive = errors.New("region is not active")
+
+	// ErrPartyMemberUpdate возвращается при попытке сменить очередь одному игроку группы
+	ErrPartyMemberUpdate = errors.New("party members cannot change queue individually")
+)
+
+// UpdateQueuedPlayer меняет регион, режим игры и рейтинг игрока, не выводя его из очереди:
+// игрок убирается из старой очереди и ставится в новую с прежним JoinedAt, поэтому уже
+// заработанное расширение диапазона поиска сохраняется. Пустые newRegion и newGameMode
+// и нулевой newRating означают «не менять». Регион должен быть в списке активных.
+// Если поставить игрока в новую очередь не удалось, он возвращается в старую.
+func (s *MatcherService) UpdateQueuedPlayer(ctx context.Context, playerID, newRegion, newGameMode string, newRating int) error {
+	ctx, span := startSpan(ctx, "UpdateQueuedPlayer",
+		attribute.String("player_id", playerID),
+		attribute.String("region", newRegion),
+		attribute.String("game_mode", newGameMode),
+	)
+	defer span.End()
+
+	player, err := s.storage.GetPlayerByID(ctx, playerID)
+	if err != nil {
+		return ErrPlayerNotInQueue
+	}
+	if player.PartyID != "" {
+		return ErrPartyMemberUpdate
+	}
+
+	updated := *player
+	if newRegion != "" && newRegion != player.Region {
+		regions, err := s.GetRegions(ctx)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(regions, newRegion) {
+			return fmt.Errorf("%w: %s", ErrRegionNotActive, newRegion)
+		}
+		updated.Region = newRegion
+	}
+	if newGameMode != "" {
+		updated.GameMode = newGameMode
+	}
+	if newRating != 0 {
+		// Рейтинг сыгравшего игрока задается только результатами матчей
+		rating, err := s.storedRating(ctx, playerID, newRating)
+		if err != nil {
+			return err
+		}
+		updated.Rating = rating
+	}
+	if updated.Region == player.Region && updated.GameMode == player.GameMode && updated.Rating == player.Rating {
+		return nil
+	}
+
+	if err := s.storage.RemovePlayerFromQueue(ctx, playerID); err != nil {
+		// Игрока успели забрать в матч или он вышел из очереди
+		return ErrPlayerNotInQueue
+	}
+	if err := s.storage.AddPlayerToQueue(ctx, &updated); err != nil {
+		if restoreErr := s.storage.AddPlayerToQueue(ctx, player); restoreErr != nil {
+			logging.FromContext(ctx).Error("Failed to restore player to previous queue",
+				zap.String("player_id", playerID),
+				zap.Error(restoreErr),
+			)
+		}
+		if errors.Is(err, ErrQueueFull) {
+			s.recordQueueFull(&updated)
+		}
+		return err
+	}
+
+	s.updateQueueDepth(ctx, player.Region, player.GameMode)
+	if updated.Region != player.Region || updated.GameMode != player.GameMode {
+		s.updateQueueDepth(ctx, updated.Region, updated.GameMode)
+		s.publishEvent(models.QueueEventLeave, models.QueueEvent{
+			Region:   player.Region,
+			GameMode: player.GameMode,
+			PlayerID: player.ID,
+		})
+		s.publishQueueJoin(&updated)
+	}
+
+	logging.FromContext(ctx).Info("Queued player updated",
+		zap.String("player_id", playerID),
+		zap.String("region", updated.Region),
+		zap.String("game_mode", updated.GameMode),
+		zap.Int("rating", updated.Rating),
+	)
+	return nil
+}
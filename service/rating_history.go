@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"chrono-matchmaking/models"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// RecordRatingSnapshot сохраняет текущий рейтинг игрока в его историю.
+// Вызывается после обработки результата матча.
+func (s *MatcherService) RecordRatingSnapshot(ctx context.Context, playerID string, rating, matchesPlayed int) error {
+	ctx, span := startSpan(ctx, "RecordRatingSnapshot", attribute.String("player_id", playerID))
+	defer span.End()
+
+	return s.storage.AppendRatingSnapshot(ctx, playerID, models.RatingPoint{
+		// Точность до миллисекунд совпадает со score в Redis
+		Timestamp:     time.Now().UTC().Truncate(time.Millisecond),
+		Rating:        rating,
+		MatchesPlayed: matchesPlayed,
+	})
+}
+
+// GetRatingProgression возвращает траекторию рейтинга игрока в хронологическом порядке
+// с изменением рейтинга относительно предыдущей точки
+func (s *MatcherService) GetRatingProgression(ctx context.Context, playerID string) ([]models.RatingPoint, error) {
+	ctx, span := startSpan(ctx, "GetRatingProgression", attribute.String("player_id", playerID))
+	defer span.End()
+
+	points, err := s.storage.GetRatingHistory(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range points {
+		if i == 0 {
+			points[i].DeltaFromPrev = 0
+			continue
+		}
+		points[i].DeltaFromPrev = points[i].Rating - points[i-1].Rating
+	}
+
+	return points, nil
+}
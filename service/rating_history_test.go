@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/storage"
+	"go.uber.org/zap"
+)
+
+func TestGetRatingProgressionDeltas(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewInMemoryStorage()
+	matcher := NewMatcherService(store, zap.NewNop(), DefaultMatcherConfig())
+
+	// Точки добавляются не по порядку: прогрессия строится по времени, а не по порядку записи
+	start := time.Now().UTC().Truncate(time.Millisecond).Add(-time.Hour)
+	ratings := []int{1500, 1520, 1490, 1490, 1600}
+	for _, i := range []int{3, 0, 4, 1, 2} {
+		point := models.RatingPoint{Timestamp: start.Add(time.Duration(i) * time.Minute), Rating: ratings[i], MatchesPlayed: i + 1}
+		if err := store.AppendRatingSnapshot(ctx, "player", point); err != nil {
+			t.Fatalf("AppendRatingSnapshot: %v", err)
+		}
+	}
+
+	points, err := matcher.GetRatingProgression(ctx, "player")
+	if err != nil {
+		t.Fatalf("GetRatingProgression: %v", err)
+	}
+	wantDeltas := []int{0, 20, -30, 0, 110}
+	if len(points) != len(wantDeltas) {
+		t.Fatalf("got %d points, want %d", len(points), len(wantDeltas))
+	}
+	for i, point := range points {
+		if point.Rating != ratings[i] || point.MatchesPlayed != i+1 {
+			t.Errorf("point %d = rating %d after %d matches, want %d after %d", i, point.Rating, point.MatchesPlayed, ratings[i], i+1)
+		}
+		if point.DeltaFromPrev != wantDeltas[i] {
+			t.Errorf("point %d DeltaFromPrev = %d, want %d", i, point.DeltaFromPrev, wantDeltas[i])
+		}
+	}
+}
+
+func TestGetRatingProgressionWithoutHistory(t *testing.T) {
+	matcher := NewMatcherService(storage.NewInMemoryStorage(), zap.NewNop(), DefaultMatcherConfig())
+
+	points, err := matcher.GetRatingProgression(context.Background(), "newcomer")
+	if err != nil {
+		t.Fatalf("GetRatingProgression: %v", err)
+	}
+	if len(points) != 0 {
+		t.Errorf("got %d points for a player without matches, want 0", len(points))
+	}
+}
+
+// TestMatchResultRecordsRatingSnapshot проверяет, что результат матча добавляет
+// в историю точку, изменение которой совпадает с изменением рейтинга
+func TestMatchResultRecordsRatingSnapshot(t *testing.T) {
+	ctx := context.Background()
+	matcher := NewMatcherService(storage.NewInMemoryStorage(), zap.NewNop(), DefaultMatcherConfig())
+	// Число сыгранных матчей берется из профиля
+	for _, id := range []string{"winner", "loser"} {
+		if _, err := matcher.storage.CreateProfile(ctx, &models.PlayerProfile{PlayerID: id, CreatedAt: time.Now()}); err != nil {
+			t.Fatalf("CreateProfile(%s): %v", id, err)
+		}
+	}
+
+	for round := 1; round <= 2; round++ {
+		for _, id := range []string{"winner", "loser"} {
+			player := &models.Player{ID: id, Rating: 1500, Region: "EU", GameMode: "1v1"}
+			if err := matcher.AddPlayerToQueue(ctx, player); err != nil {
+				t.Fatalf("AddPlayerToQueue(%s): %v", id, err)
+			}
+		}
+		if err := matcher.ProcessQueue(ctx, "EU", "1v1"); err != nil {
+			t.Fatalf("ProcessQueue: %v", err)
+		}
+		match, err := matcher.GetPlayerMatch(ctx, "winner")
+		if err != nil {
+			t.Fatalf("GetPlayerMatch: %v", err)
+		}
+		if _, err := matcher.ReportMatchResult(ctx, match.MatchID, []string{"winner"}); err != nil {
+			t.Fatalf("ReportMatchResult: %v", err)
+		}
+		if err := matcher.storage.RemoveMatch(ctx, "winner"); err != nil {
+			t.Fatalf("RemoveMatch: %v", err)
+		}
+		if err := matcher.storage.RemoveMatch(ctx, "loser"); err != nil {
+			t.Fatalf("RemoveMatch: %v", err)
+		}
+	}
+
+	points, err := matcher.GetRatingProgression(ctx, "winner")
+	if err != nil {
+		t.Fatalf("GetRatingProgression: %v", err)
+	}
+	if len(points) != 2 {
+		t.Fatalf("got %d points, want 2", len(points))
+	}
+	if points[0].MatchesPlayed != 1 || points[1].MatchesPlayed != 2 {
+		t.Errorf("MatchesPlayed = %d, %d; want 1, 2", points[0].MatchesPlayed, points[1].MatchesPlayed)
+	}
+	rating, _, _ := matcher.storage.GetPlayerRating(ctx, "winner")
+	if points[1].Rating != rating {
+		t.Errorf("last point rating = %d, want the stored %d", points[1].Rating, rating)
+	}
+	if points[1].DeltaFromPrev <= 0 || points[1].DeltaFromPrev != points[1].Rating-points[0].Rating {
+		t.Errorf("DeltaFromPrev = %d, want the positive change %d", points[1].DeltaFromPrev, points[1].Rating-points[0].Rating)
+	}
+}
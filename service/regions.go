@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"chrono-matchmaking/logging"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// ErrInvalidRegions возвращается при попытке сохранить пустой или некорректный список регионов
+var ErrInvalidRegions = errors.New("invalid regions list")
+
+// DefaultRegions возвращает регионы, обрабатываемые, пока список не задан через API
+func DefaultRegions() []string {
+	return []string{"EU", "US", "ASIA"}
+}
+
+// GetRegions возвращает список активных регионов. Если список не сохранен
+// в хранилище, возвращаются регионы по умолчанию.
+func (s *MatcherService) GetRegions(ctx context.Context) ([]string, error) {
+	ctx, span := startSpan(ctx, "GetRegions")
+	defer span.End()
+
+	regions, err := s.storage.GetRegions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(regions) == 0 {
+		return DefaultRegions(), nil
+	}
+	return regions, nil
+}
+
+// SetRegions заменяет список активных регионов. Очереди новых регионов начинают
+// обрабатываться со следующего цикла, очереди удаленных регионов больше не обрабатываются.
+func (s *MatcherService) SetRegions(ctx context.Context, regions []string) error {
+	ctx, span := startSpan(ctx, "SetRegions", attribute.StringSlice("regions", regions))
+	defer span.End()
+
+	if len(regions) == 0 {
+		return fmt.Errorf("%w: at least one region is required", ErrInvalidRegions)
+	}
+	seen := make(map[string]bool, len(regions))
+	for _, region := range regions {
+		if strings.TrimSpace(region) == "" || strings.Contains(region, ":") {
+			return fmt.Errorf("%w: region %q must be non-empty and must not contain ':'", ErrInvalidRegions, region)
+		}
+		if seen[region] {
+			return fmt.Errorf("%w: duplicate region %q", ErrInvalidRegions, region)
+		}
+		seen[region] = true
+	}
+
+	if err := s.storage.SetRegions(ctx, regions); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).Info("Active regions updated", zap.Strings("regions", regions))
+	return nil
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"chrono-matchmaking/models"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	satisfactionRequeueWindow = 5 * time.Minute  // Вход в очередь в этом окне после матча считается повторным
+	satisfactionMinMatchAge   = 10 * time.Minute // Оценка вычисляется, когда окно гарантированно закрыто
+)
+
+// ErrSatisfactionNotReady возвращается, если матч слишком новый для оценки
+var ErrSatisfactionNotReady = errors.New("match is too recent to compute satisfaction")
+
+// ComputeSatisfactionScore оценивает удовлетворенность игроков матчем как долю игроков,
+// вернувшихся в очередь в течение 5 минут после его создания. Оценка вычисляется один раз,
+// когда матчу исполнилось 10 минут, и сохраняется в Metadata матча.
+func (s *MatcherService) ComputeSatisfactionScore(ctx context.Context, matchID string) (*models.SatisfactionScore, error) {
+	ctx, span := startSpan(ctx, "ComputeSatisfactionScore", attribute.String("match_id", matchID))
+	defer span.End()
+
+	match, err := s.storage.GetMatchByID(ctx, matchID)
+	if err != nil {
+		return nil, err
+	}
+
+	if cached, ok := satisfactionFromMetadata(match); ok {
+		return cached, nil
+	}
+
+	startedAt := match.CreatedAt
+	if match.ScheduledStartTime != nil {
+		startedAt = *match.ScheduledStartTime
+	}
+	if time.Since(startedAt) < satisfactionMinMatchAge {
+		return nil, ErrSatisfactionNotReady
+	}
+
+	result := &models.SatisfactionScore{
+		MatchID:    matchID,
+		Total:      len(match.Players),
+		ComputedAt: time.Now().UTC(),
+	}
+
+	windowEnd := startedAt.Add(satisfactionRequeueWindow)
+	for _, p := range match.Players {
+		joins, err := s.storage.GetPlayerQueueHistory(ctx, p.ID, startedAt)
+		if err != nil {
+			return nil, err
+		}
+		for _, joinedAt := range joins {
+			if joinedAt.Before(windowEnd) {
+				result.Requeued++
+				break
+			}
+		}
+	}
+	if result.Total > 0 {
+		result.Score = float64(result.Requeued) / float64(result.Total)
+	}
+
+	if _, err := s.storage.UpdateMatchMetadata(ctx, matchID, map[string]interface{}{
+		"satisfaction_score":       result.Score,
+		"satisfaction_requeued":    result.Requeued,
+		"satisfaction_computed_at": result.ComputedAt.Format(time.RFC3339),
+	}); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// satisfactionFromMetadata возвращает ранее вычисленную оценку из Metadata матча
+func satisfactionFromMetadata(match *models.Match) (*models.SatisfactionScore, bool) {
+	score, ok := match.Metadata["satisfaction_score"].(float64)
+	if !ok {
+		return nil, false
+	}
+
+	// После JSON числа в Metadata приходят как float64
+	requeued, _ := match.Metadata["satisfaction_requeued"].(float64)
+	computedRaw, _ := match.Metadata["satisfaction_computed_at"].(string)
+	computedAt, _ := time.Parse(time.RFC3339, computedRaw)
+
+	return &models.SatisfactionScore{
+		MatchID:    match.MatchID,
+		Score:      score,
+		Requeued:   int(requeued),
+		Total:      len(match.Players),
+		ComputedAt: computedAt,
+	}, true
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// ScheduleMatch создает матч турнира заранее. Игроки получат его через FindMatch
+// только после наступления startTime.
+func (s *MatcherService) ScheduleMatch(ctx context.Context, req *models.ScheduleMatchRequest) (*models.Match, error) {
+	ctx, span := startSpan(ctx, "ScheduleMatch")
+	defer span.End()
+
+	expected, err := s.GetPlayersPerMatch(ctx, req.GameMode)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.PlayerIDs) != expected {
+		return nil, fmt.Errorf("game mode %s requires %d players, got %d", req.GameMode, expected, len(req.PlayerIDs))
+	}
+
+	players := make([]models.Player, 0, len(req.PlayerIDs))
+	seen := make(map[string]bool, len(req.PlayerIDs))
+	for _, playerID := range req.PlayerIDs {
+		if seen[playerID] {
+			return nil, fmt.Errorf("player %s is listed more than once", playerID)
+		}
+		seen[playerID] = true
+
+		profile, err := s.storage.GetProfile(ctx, playerID)
+		if err != nil {
+			return nil, fmt.Errorf("player %s: %w", playerID, err)
+		}
+		players = append(players, models.Player{
+			ID:       profile.PlayerID,
+			Region:   req.Region,
+			GameMode: req.GameMode,
+		})
+	}
+
+	startTime := req.StartTime.UTC()
+	match := s.buildMatch(players)
+	match.ScheduledStartTime = &startTime
+	match.ExpiresAt = startTime.Add(match.ExpiresAt.Sub(match.CreatedAt)) // Срок отсчитывается от начала матча
+
+	if err := s.storage.SaveScheduledMatch(ctx, req.Region, req.GameMode, match); err != nil {
+		return nil, err
+	}
+
+	return match, nil
+}
+
+// PromoteScheduledMatches активирует запланированные матчи, время начала которых наступило
+func (s *MatcherService) PromoteScheduledMatches(ctx context.Context, region, gameMode string) error {
+	ctx, span := startSpan(ctx, "PromoteScheduledMatches",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	matches, err := s.storage.PromoteScheduledMatches(ctx, region, gameMode, time.Now())
+	for _, match := range matches {
+		logging.FromContext(ctx).Info("Scheduled match activated",
+			zap.String("match_id", match.MatchID),
+			zap.String("region", region),
+			zap.String("game_mode", gameMode),
+		)
+
+		if err := s.createLobbyInGameService(ctx, match); err != nil {
+			logging.FromContext(ctx).Warn("Failed to create lobby in game-service",
+				zap.String("match_id", match.MatchID),
+				zap.Error(err),
+			)
+		}
+		s.publishMatchFormed(ctx, region, gameMode, match)
+	}
+	return err
+}
+
+// isScheduledForLater сообщает, что время начала запланированного матча еще не наступило
+func isScheduledForLater(match *models.Match) bool {
+	return match.ScheduledStartTime != nil && time.Now().Before(*match.ScheduledStartTime)
+}
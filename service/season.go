@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/storage"
+	"go.uber.org/zap"
+)
+
+// seasonBaseRating рейтинг, к которому приближаются игроки при мягком сбросе
+const seasonBaseRating = 1000
+
+// ErrSeasonExists возвращается при повторном начале уже начатого сезона
+var ErrSeasonExists = storage.ErrSeasonExists
+
+// SeasonManager управляет рейтинговыми сезонами
+type SeasonManager struct {
+	storage storage.Storage
+	logger  *zap.Logger
+}
+
+// NewSeasonManager создает менеджер сезонов
+func NewSeasonManager(storage storage.Storage, logger *zap.Logger) *SeasonManager {
+	return &SeasonManager{
+		storage: storage,
+		logger:  logger,
+	}
+}
+
+// StartSeason сохраняет границу сезона и выполняет мягкий сброс рейтингов.
+// Один и тот же сезон нельзя начать дважды (ErrSeasonExists).
+func (m *SeasonManager) StartSeason(ctx context.Context, seasonID string, compressionFactor float64) (*models.Season, error) {
+	if err := validateCompressionFactor(compressionFactor); err != nil {
+		return nil, err
+	}
+
+	season := &models.Season{
+		SeasonID:          seasonID,
+		StartedAt:         time.Now().UTC(),
+		CompressionFactor: compressionFactor,
+	}
+	if err := m.storage.CreateSeason(ctx, season); err != nil {
+		return nil, err
+	}
+
+	affected, err := m.resetRatings(ctx, compressionFactor)
+	if err != nil {
+		return nil, err
+	}
+
+	season.PlayersAffected = affected
+	if err := m.storage.UpdateSeason(ctx, season); err != nil {
+		logging.FromContext(ctx).Warn("Failed to save season result",
+			zap.String("season_id", seasonID),
+			zap.Error(err),
+		)
+	}
+
+	return season, nil
+}
+
+// ResetRatings приближает рейтинг каждого игрока к базовому:
+// newRating = base + (rating - base) * compressionFactor
+func (m *SeasonManager) ResetRatings(ctx context.Context, compressionFactor float64) error {
+	if err := validateCompressionFactor(compressionFactor); err != nil {
+		return err
+	}
+	_, err := m.resetRatings(ctx, compressionFactor)
+	return err
+}
+
+// resetRatings выполняет сброс и возвращает количество обновленных игроков
+func (m *SeasonManager) resetRatings(ctx context.Context, compressionFactor float64) (int64, error) {
+	affected, err := m.storage.UpdateAllPlayerRatings(ctx, func(rating int) int {
+		return seasonBaseRating + int(math.Round(float64(rating-seasonBaseRating)*compressionFactor))
+	})
+	if err != nil {
+		return affected, fmt.Errorf("failed to reset ratings: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("Season ratings reset",
+		zap.Float64("compression_factor", compressionFactor),
+		zap.Int64("players_affected", affected),
+	)
+
+	return affected, nil
+}
+
+// validateCompressionFactor проверяет, что сброс не раздвигает рейтинги
+func validateCompressionFactor(compressionFactor float64) error {
+	if compressionFactor < 0 || compressionFactor > 1 {
+		return fmt.Errorf("compression factor must be between 0 and 1, got %g", compressionFactor)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// segmentBracketWidth ширина рейтингового сегмента для статистики очереди
+const segmentBracketWidth = 500
+
+// processQueueBatch сколько игроков очереди рассматривает один проход ProcessQueue
+const processQueueBatch = 100
+
+// queueSegment статистика рейтингового сегмента вместе с его нижней границей
+type queueSegment struct {
+	lower int
+	stats models.SegmentStats
+}
+
+// GetQueueSegmentStats возвращает статистику очереди по рейтинговым сегментам,
+// отсортированную по возрастанию рейтинга
+func (s *MatcherService) GetQueueSegmentStats(ctx context.Context, region, gameMode string) ([]models.SegmentStats, error) {
+	ctx, span := startSpan(ctx, "GetQueueSegmentStats",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	players, err := s.storage.GetQueuePlayers(ctx, region, gameMode)
+	if err != nil {
+		return nil, err
+	}
+
+	segments, err := s.queueSegments(ctx, region, gameMode, players)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.SegmentStats, 0, len(segments))
+	for _, segment := range segments {
+		result = append(result, segment.stats)
+	}
+	return result, nil
+}
+
+// queueSegments группирует игроков очереди по рейтинговым сегментам и считает их
+// статистику; сегменты отсортированы по возрастанию рейтинга
+func (s *MatcherService) queueSegments(ctx context.Context, region, gameMode string, players []*models.Player) ([]queueSegment, error) {
+	now := time.Now()
+	bySegment := make(map[int]*models.SegmentStats)
+	for _, p := range players {
+		lower := segmentLowerBound(p.Rating)
+		stats, ok := bySegment[lower]
+		if !ok {
+			stats = &models.SegmentStats{Bracket: segmentBracket(lower)}
+			bySegment[lower] = stats
+		}
+		stats.PlayerCount++
+		stats.AvgWaitSeconds += now.Sub(p.JoinedAt).Seconds()
+	}
+
+	bounds := make([]int, 0, len(bySegment))
+	for lower := range bySegment {
+		bounds = append(bounds, lower)
+	}
+	sort.Ints(bounds)
+
+	result := make([]queueSegment, 0, len(bounds))
+	for _, lower := range bounds {
+		stats := bySegment[lower]
+		stats.AvgWaitSeconds /= float64(stats.PlayerCount)
+
+		matches, err := s.storage.CountSegmentMatchesLastHour(ctx, region, gameMode, stats.Bracket)
+		if err != nil {
+			return nil, err
+		}
+		stats.MatchesLastHour = matches
+
+		divisor := matches
+		if divisor < 1 {
+			divisor = 1
+		}
+		stats.BottleneckScore = float64(stats.PlayerCount) / float64(divisor)
+
+		result = append(result, queueSegment{lower: lower, stats: *stats})
+	}
+
+	return result, nil
+}
+
+// queueCandidates возвращает до 100 игроков очереди для прохода ProcessQueue. Пока
+// в очереди не больше 100 игроков, рассматриваются все. В большей очереди первыми
+// берутся подписчики, а затем игроки рейтинговых сегментов в порядке убывания
+// BottleneckScore (см. GetQueueSegmentStats), внутри сегмента — по возрастанию
+// рейтинга: сегменты, где ожидание копится быстрее всего, не ждут, пока разберут
+// игроков с низким рейтингом. Если статистику сегментов получить не удалось,
+// берутся игроки с наименьшим рейтингом.
+func (s *MatcherService) queueCandidates(ctx context.Context, region, gameMode string) ([]*models.Player, error) {
+	players, err := s.storage.GetPlayersInRange(ctx, region, gameMode, 0, math.MaxInt, processQueueBatch)
+	if err != nil {
+		return nil, err
+	}
+	if len(players) < processQueueBatch {
+		return players, nil
+	}
+
+	all, err := s.storage.GetQueuePlayers(ctx, region, gameMode)
+	if err != nil {
+		return nil, err
+	}
+	if len(all) <= processQueueBatch {
+		return players, nil
+	}
+
+	segments, err := s.queueSegments(ctx, region, gameMode, all)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to get queue segment stats, taking lowest ratings first",
+			zap.String("region", region),
+			zap.String("game_mode", gameMode),
+			zap.Error(err),
+		)
+		segments = nil
+	}
+	sort.SliceStable(segments, func(i, j int) bool {
+		return segments[i].stats.BottleneckScore > segments[j].stats.BottleneckScore
+	})
+
+	order := make(map[int]int, len(segments)) // Нижняя граница сегмента -> место в очереди обработки
+	for i, segment := range segments {
+		order[segment.lower] = i
+	}
+	rank := func(p *models.Player) int {
+		if p.IsPremium {
+			return -1
+		}
+		if i, ok := order[segmentLowerBound(p.Rating)]; ok {
+			return i
+		}
+		return len(segments)
+	}
+
+	// Игроки уже отсортированы по рейтингу: стабильная сортировка сохраняет этот
+	// порядок внутри сегмента
+	sort.SliceStable(all, func(i, j int) bool {
+		return rank(all[i]) < rank(all[j])
+	})
+	return all[:processQueueBatch], nil
+}
+
+// recordSegmentMatch учитывает матч в сегменте по среднему рейтингу его игроков
+func (s *MatcherService) recordSegmentMatch(ctx context.Context, region, gameMode string, match *models.Match) {
+	if len(match.Players) == 0 {
+		return
+	}
+
+	total := 0
+	for _, p := range match.Players {
+		total += p.Rating
+	}
+	bracket := segmentBracket(segmentLowerBound(total / len(match.Players)))
+
+	if err := s.storage.RecordSegmentMatch(ctx, region, gameMode, bracket, match.MatchID, match.CreatedAt); err != nil {
+		logging.FromContext(ctx).Warn("Failed to record segment match",
+			zap.String("match_id", match.MatchID),
+			zap.String("bracket", bracket),
+			zap.Error(err),
+		)
+	}
+}
+
+// segmentLowerBound возвращает нижнюю границу рейтингового сегмента
+func segmentLowerBound(rating int) int {
+	lower := rating / segmentBracketWidth * segmentBracketWidth
+	if rating < 0 && rating%segmentBracketWidth != 0 {
+		lower -= segmentBracketWidth
+	}
+	return lower
+}
+
+// segmentBracket возвращает название сегмента вида "1000-1500"
+func segmentBracket(lower int) string {
+	return fmt.Sprintf("%d-%d", lower, lower+segmentBracketWidth)
+}
+
+// DefaultQueueBrackets диапазоны рейтинга для разбивки глубины очереди в статусе
+var DefaultQueueBrackets = []models.RatingBracket{
+	{Min: 0, Max: 799},
+	{Min: 800, Max: 999},
+	{Min: 1000, Max: 1199},
+	{Min: 1200, Max: 1499},
+	{Min: 1500, Max: 0},
+}
+
+// GetQueueDepthByBracket возвращает количество игроков очереди по диапазонам DefaultQueueBrackets
+func (s *MatcherService) GetQueueDepthByBracket(ctx context.Context, region, gameMode string) ([]models.BracketDepth, error) {
+	ctx, span := startSpan(ctx, "GetQueueDepthByBracket",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	return s.storage.GetQueueDepthByBracket(ctx, region, gameMode, DefaultQueueBrackets)
+}
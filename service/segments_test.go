@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/storage"
+	"go.uber.org/zap"
+)
+
+// TestQueueCandidatesPrioritizesBottleneckSegments проверяет, что в очереди больше
+// 100 игроков проход берет сначала сегмент с наибольшим BottleneckScore, а не
+// игроков с наименьшим рейтингом
+func TestQueueCandidatesPrioritizesBottleneckSegments(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewInMemoryStorage()
+	matcher := NewMatcherService(store, zap.NewNop(), DefaultMatcherConfig())
+
+	now := time.Now()
+	var players []*models.Player
+	for i := 0; i < processQueueBatch; i++ {
+		players = append(players, &models.Player{ID: fmt.Sprintf("low-%d", i), Rating: 100 + i, Region: "EU", GameMode: "1v1", JoinedAt: now})
+	}
+	for i := 0; i < 10; i++ {
+		players = append(players, &models.Player{ID: fmt.Sprintf("high-%d", i), Rating: 2000 + i, Region: "EU", GameMode: "1v1", JoinedAt: now})
+	}
+	for _, p := range players {
+		if err := store.AddPlayerToQueue(ctx, p); err != nil {
+			t.Fatalf("AddPlayerToQueue(%s): %v", p.ID, err)
+		}
+	}
+	// Сегмент 0-500 быстро разбирается: 100 игроков на 200 матчей в час
+	for i := 0; i < 200; i++ {
+		if err := store.RecordSegmentMatch(ctx, "EU", "1v1", "0-500", fmt.Sprintf("m%d", i), now); err != nil {
+			t.Fatalf("RecordSegmentMatch: %v", err)
+		}
+	}
+
+	candidates, err := matcher.queueCandidates(ctx, "EU", "1v1")
+	if err != nil {
+		t.Fatalf("queueCandidates: %v", err)
+	}
+	if len(candidates) != processQueueBatch {
+		t.Fatalf("got %d candidates, want %d", len(candidates), processQueueBatch)
+	}
+	for i := 0; i < 10; i++ {
+		if candidates[i].Rating < 2000 {
+			t.Fatalf("candidate %d has rating %d, want the 2000-2500 segment first", i, candidates[i].Rating)
+		}
+	}
+}
+
+func TestQueueCandidatesSmallQueue(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewInMemoryStorage()
+	matcher := NewMatcherService(store, zap.NewNop(), DefaultMatcherConfig())
+
+	for _, rating := range []int{2000, 100} {
+		p := &models.Player{ID: fmt.Sprintf("p%d", rating), Rating: rating, Region: "EU", GameMode: "1v1", JoinedAt: time.Now()}
+		if err := store.AddPlayerToQueue(ctx, p); err != nil {
+			t.Fatalf("AddPlayerToQueue: %v", err)
+		}
+	}
+
+	candidates, err := matcher.queueCandidates(ctx, "EU", "1v1")
+	if err != nil {
+		t.Fatalf("queueCandidates: %v", err)
+	}
+	if len(candidates) != 2 || candidates[0].Rating != 100 {
+		t.Errorf("candidates = %v, want both players by rating", candidates)
+	}
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/storage"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrServerNotFound возвращается, если игровой сервер не зарегистрирован
+	ErrServerNotFound = storage.ErrServerNotFound
+
+	// ErrNoServerAvailable возвращается, если в регионе нет серверов со свободными слотами
+	ErrNoServerAvailable = storage.ErrNoServerAvailable
+)
+
+// Server игровой сервер, на котором проводятся матчи
+type Server = models.Server
+
+// ServerRegistry учитывает игровые серверы по регионам и их загрузку
+type ServerRegistry struct {
+	storage storage.Storage
+	logger  *zap.Logger
+}
+
+// NewServerRegistry создает реестр игровых серверов
+func NewServerRegistry(storage storage.Storage, logger *zap.Logger) *ServerRegistry {
+	return &ServerRegistry{
+		storage: storage,
+		logger:  logger,
+	}
+}
+
+// RegisterServer регистрирует игровой сервер в регионе
+func (r *ServerRegistry) RegisterServer(ctx context.Context, serverID, addr, region string, capacity int) error {
+	return r.storage.RegisterServer(ctx, &models.Server{
+		ServerID:     serverID,
+		Addr:         addr,
+		Region:       region,
+		Capacity:     capacity,
+		RegisteredAt: time.Now().UTC(),
+	})
+}
+
+// DeregisterServer снимает игровой сервер с учета. Новые матчи на него не назначаются.
+func (r *ServerRegistry) DeregisterServer(ctx context.Context, serverID string) error {
+	return r.storage.DeregisterServer(ctx, serverID)
+}
+
+// SelectServer выбирает наименее загруженный сервер региона и занимает на нем слот
+func (r *ServerRegistry) SelectServer(ctx context.Context, region string) (*Server, error) {
+	return r.storage.AcquireLeastLoadedServer(ctx, region)
+}
+
+// ReleaseServer освобождает слот сервера после окончания матча
+func (r *ServerRegistry) ReleaseServer(ctx context.Context, region, serverID string) error {
+	return r.storage.ReleaseServer(ctx, region, serverID)
+}
+
+// SetServerRegistry включает назначение игровых серверов создаваемым матчам
+func (s *MatcherService) SetServerRegistry(r *ServerRegistry) {
+	s.serverRegistry = r
+}
+
+// assignServer назначает матчу наименее загруженный сервер в регионе ServerRegion.
+// Если свободных серверов нет, матч создается без сервера.
+func (s *MatcherService) assignServer(ctx context.Context, match *models.Match) {
+	if s.serverRegistry == nil || match.ServerRegion == "" {
+		return
+	}
+
+	server, err := s.serverRegistry.SelectServer(ctx, match.ServerRegion)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to assign game server",
+			zap.String("match_id", match.MatchID),
+			zap.String("region", match.ServerRegion),
+			zap.Error(err),
+		)
+		return
+	}
+
+	match.ServerID = server.ServerID
+	match.ServerAddr = server.Addr
+}
+
+// releaseServer освобождает сервер матча, который не удалось сохранить или который закончился
+func (s *MatcherService) releaseServer(ctx context.Context, match *models.Match) {
+	if s.serverRegistry == nil || match.ServerID == "" {
+		return
+	}
+
+	if err := s.serverRegistry.ReleaseServer(ctx, match.ServerRegion, match.ServerID); err != nil {
+		logging.FromContext(ctx).Warn("Failed to release game server",
+			zap.String("match_id", match.MatchID),
+			zap.String("server_id", match.ServerID),
+			zap.Error(err),
+		)
+	}
+}
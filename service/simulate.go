@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"chrono-matchmaking/models"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// SimulateQueue показывает, какие матчи сформировала бы обработка очереди сейчас:
+// подбор идет тем же алгоритмом и с теми же проходами (сначала подписчики), что и
+// в ProcessQueue, но матчи не сохраняются, игроки не удаляются из очереди, а
+// серверы, уведомления и метрики не затрагиваются. injected добавляются к игрокам
+// очереди только на время симуляции; пустые region, game_mode, ID и время входа
+// заполняются значениями очереди.
+func (s *MatcherService) SimulateQueue(ctx context.Context, region, gameMode string, injected []*models.Player) ([]*models.SimulatedMatch, error) {
+	ctx, span := startSpan(ctx, "SimulateQueue",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+		attribute.Int("injected_players", len(injected)),
+	)
+	defer span.End()
+
+	playersPerMatch, err := s.GetPlayersPerMatch(ctx, gameMode)
+	if err != nil {
+		return nil, err
+	}
+
+	players, err := s.storage.GetPlayersInRange(ctx, region, gameMode, 0, math.MaxInt, 100)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get players: %w", err)
+	}
+
+	now := time.Now()
+	for _, p := range injected {
+		if p == nil {
+			continue
+		}
+		player := *p
+		if player.ID == "" {
+			player.ID = "sim_" + uuid.New().String()
+		}
+		if player.Region == "" {
+			player.Region = region
+		}
+		if player.GameMode == "" {
+			player.GameMode = gameMode
+		}
+		if player.JoinedAt.IsZero() {
+			player.JoinedAt = now
+		}
+		players = append(players, &player)
+	}
+
+	simulated := make([]*models.SimulatedMatch, 0)
+	if len(players) < playersPerMatch {
+		return simulated, nil
+	}
+
+	premium := make([]*models.Player, 0)
+	for _, p := range players {
+		if p.IsPremium {
+			premium = append(premium, p)
+		}
+	}
+	groups := make([][]models.Player, 0)
+	matched := make(map[string]bool)
+	if len(premium) >= playersPerMatch {
+		for _, group := range s.arrangeMatchGroups(ctx, region, gameMode, premium) {
+			for _, p := range group {
+				matched[p.ID] = true
+			}
+			groups = append(groups, group)
+		}
+	}
+
+	remaining := make([]*models.Player, 0, len(players))
+	for _, p := range players {
+		if !matched[p.ID] {
+			remaining = append(remaining, p)
+		}
+	}
+	groups = append(groups, s.arrangeMatchGroups(ctx, region, gameMode, remaining)...)
+
+	for _, group := range groups {
+		match := s.buildMatch(group)
+		simulated = append(simulated, &models.SimulatedMatch{
+			Players:      match.Players,
+			QualityScore: match.QualityScore,
+			RatingSpread: ratingSpread(match.Players),
+
+			TeamAVoiceCompatible: match.TeamAVoiceCompatible,
+			TeamBVoiceCompatible: match.TeamBVoiceCompatible,
+			VoiceGroupBonus:      match.VoiceGroupBonus,
+		})
+	}
+
+	return simulated, nil
+}
+
+// ratingSpread возвращает разницу между наибольшим и наименьшим рейтингом игроков
+func ratingSpread(players []models.Player) int {
+	if len(players) == 0 {
+		return 0
+	}
+	lowest, highest := players[0].Rating, players[0].Rating
+	for _, p := range players[1:] {
+		if p.Rating < lowest {
+			lowest = p.Rating
+		}
+		if p.Rating > highest {
+			highest = p.Rating
+		}
+	}
+	return highest - lowest
+}
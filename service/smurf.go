@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/storage"
+	"go.uber.org/zap"
+)
+
+// SmurfDetector ищет игроков, рейтинг которых растет слишком быстро для честного
+// новичка: вероятно, это опытный игрок на новом аккаунте
+type SmurfDetector struct {
+	storage storage.Storage
+}
+
+// NewSmurfDetector создает детектор по истории изменений рейтинга
+func NewSmurfDetector(storage storage.Storage) *SmurfDetector {
+	return &SmurfDetector{storage: storage}
+}
+
+// IsSuspicious проверяет, что за последние windowGames матчей игрок набрал не меньше
+// threshold рейтинга. Если матчей меньше windowGames, учитываются все.
+func (d *SmurfDetector) IsSuspicious(ctx context.Context, playerID string, windowGames int, threshold int) (bool, error) {
+	if windowGames <= 0 {
+		return false, nil
+	}
+
+	deltas, err := d.storage.GetRecentRatingChanges(ctx, playerID, windowGames)
+	if err != nil {
+		return false, err
+	}
+
+	total := 0
+	for _, delta := range deltas {
+		total += delta
+	}
+	return total >= threshold, nil
+}
+
+// markSuspicious отмечает игрока, подозреваемого в смурфинге: такой игрок ждет в
+// очереди queue:suspect:{region}:{game_mode} и подбирается только к таким же игрокам.
+// При ошибке хранилища игрок считается обычным.
+func (s *MatcherService) markSuspicious(ctx context.Context, player *models.Player) {
+	config := s.configForContext(player.Region, player.GameMode)
+
+	suspicious, err := s.smurfDetector.IsSuspicious(ctx, player.ID, config.SmurfWindowGames, config.SmurfRatingThreshold)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to check rating velocity",
+			zap.String("player_id", player.ID),
+			zap.Error(err),
+		)
+		return
+	}
+
+	player.IsSuspicious = suspicious
+	if suspicious {
+		logging.FromContext(ctx).Info("Player routed to suspect queue",
+			zap.String("player_id", player.ID),
+			zap.String("region", player.Region),
+			zap.String("game_mode", player.GameMode),
+		)
+	}
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// SnapshotQueues возвращает содержимое всех очередей, упорядоченное по ключу очереди
+func (s *MatcherService) SnapshotQueues(ctx context.Context) ([]models.QueueSnapshotEntry, error) {
+	ctx, span := startSpan(ctx, "SnapshotQueues")
+	defer span.End()
+
+	snapshot, err := s.storage.SnapshotAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]models.QueueSnapshotEntry, 0, len(snapshot))
+	for queue, players := range snapshot {
+		entries = append(entries, models.QueueSnapshotEntry{Queue: queue, Players: players})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Queue < entries[j].Queue
+	})
+	return entries, nil
+}
+
+// RestoreQueue возвращает в очередь игроков одной очереди снимка. Очередь игрока
+// определяется его данными (регион, режим, is_premium, is_suspicious), а не ключом
+// в снимке; время входа сохраняется. Уже стоящие в очереди игроки пропускаются.
+// Итоги добавляются к result, чтобы снимок можно было восстанавливать построчно.
+func (s *MatcherService) RestoreQueue(ctx context.Context, entry models.QueueSnapshotEntry, result *models.QueueRestoreResult) {
+	ctx, span := startSpan(ctx, "RestoreQueue", attribute.String("queue", entry.Queue))
+	defer span.End()
+
+	result.Queues++
+	if len(entry.Players) == 0 {
+		return
+	}
+
+	type queueRef struct{ region, gameMode string }
+	updated := make(map[queueRef]bool)
+	for i, err := range s.storage.AddPlayersToQueue(ctx, entry.Players) {
+		player := entry.Players[i]
+		switch {
+		case err == nil:
+			result.Restored++
+			updated[queueRef{player.Region, player.GameMode}] = true
+		case errors.Is(err, ErrPlayerAlreadyQueued):
+			result.AlreadyQueued++
+		default:
+			result.Failed++
+			logging.FromContext(ctx).Warn("Failed to restore player to queue",
+				zap.String("queue", entry.Queue),
+				zap.String("player_id", player.ID),
+				zap.Error(err),
+			)
+		}
+	}
+	for q := range updated {
+		s.updateQueueDepth(ctx, q.region, q.gameMode)
+	}
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"sort"
+
+	"chrono-matchmaking/models"
+)
+
+// StableMatchingAlgorithm распределяет игроков по группам вариантом алгоритма
+// Гейла — Шепли с вместимостью (больницы и резиденты). Дольше всех ожидающие единицы
+// подбора становятся лидерами групп, остальные «делают предложения» лидерам в порядке
+// близости рейтинга, а лидер оставляет за собой самых близких по рейтингу кандидатов.
+// В результате ни один игрок и ни один лидер не предпочли бы друг друга своим текущим
+// группам, в отличие от жадного подбора, где ранние группы забирают лучших кандидатов.
+type StableMatchingAlgorithm struct {
+	constraints GroupConstraints
+}
+
+// setConstraints реализует constrainedAlgorithm
+func (a *StableMatchingAlgorithm) setConstraints(c GroupConstraints) {
+	a.constraints = c
+}
+
+// FormGroups реализует MatchingAlgorithm
+func (a *StableMatchingAlgorithm) FormGroups(players []*models.Player, groupSize int) [][]*models.Player {
+	units := groupPartyUnits(players)
+	groupCount := len(players) / groupSize
+	if groupCount == 0 {
+		return nil
+	}
+
+	// Лидеры — дольше всех ожидающие единицы подбора
+	sort.SliceStable(units, func(i, j int) bool {
+		return units[i][0].JoinedAt.Before(units[j][0].JoinedAt)
+	})
+	if groupCount > len(units) {
+		groupCount = len(units)
+	}
+	leaders := units[:groupCount]
+	residents := units[groupCount:]
+
+	// Предпочтения резидентов: совместимые лидеры по близости рейтинга
+	prefs := make([][]int, len(residents))
+	for r, resident := range residents {
+		for l, leader := range leaders {
+			if len(leader)+len(resident) > groupSize {
+				continue
+			}
+			if a.constraints.CanJoin != nil && !a.constraints.CanJoin(leader, resident) {
+				continue
+			}
+			prefs[r] = append(prefs[r], l)
+		}
+		sort.SliceStable(prefs[r], func(i, j int) bool {
+			return unitRatingDistance(resident, leaders[prefs[r][i]]) < unitRatingDistance(resident, leaders[prefs[r][j]])
+		})
+	}
+
+	members := make([][]int, len(leaders)) // Резиденты, условно принятые лидером
+	next := make([]int, len(residents))    // Следующий лидер в списке предпочтений резидента
+	free := make([]int, len(residents))
+	for r := range residents {
+		free[r] = r
+	}
+
+	for len(free) > 0 {
+		r := free[0]
+		free = free[1:]
+		if next[r] >= len(prefs[r]) {
+			continue // Резидент отвергнут всеми совместимыми лидерами
+		}
+		l := prefs[r][next[r]]
+		next[r]++
+
+		// Лидер заново набирает группу из прежних участников и нового кандидата
+		// в порядке своих предпочтений; не поместившиеся продолжают предлагать себя другим
+		candidates := append(append([]int{}, members[l]...), r)
+		sort.SliceStable(candidates, func(i, j int) bool {
+			di := unitRatingDistance(leaders[l], residents[candidates[i]])
+			dj := unitRatingDistance(leaders[l], residents[candidates[j]])
+			if di != dj {
+				return di < dj
+			}
+			return residents[candidates[i]][0].JoinedAt.Before(residents[candidates[j]][0].JoinedAt)
+		})
+
+		group := append([]*models.Player{}, leaders[l]...)
+		accepted := candidates[:0:0]
+		for _, c := range candidates {
+			unit := residents[c]
+			if len(group)+len(unit) <= groupSize && (a.constraints.CanJoin == nil || a.constraints.CanJoin(group, unit)) {
+				group = append(group, unit...)
+				accepted = append(accepted, c)
+				continue
+			}
+			free = append(free, c)
+		}
+		members[l] = accepted
+	}
+
+	var groups [][]*models.Player
+	for l, leader := range leaders {
+		group := append([]*models.Player{}, leader...)
+		for _, r := range members[l] {
+			group = append(group, residents[r]...)
+		}
+		if len(group) != groupSize {
+			continue
+		}
+		if a.constraints.Complete != nil && !a.constraints.Complete(group) {
+			continue
+		}
+		groups = append(groups, group)
+	}
+
+	return groups
+}
+
+// unitRatingDistance разница средних рейтингов двух единиц подбора
+func unitRatingDistance(a, b []*models.Player) int {
+	d := unitAverageRating(a) - unitAverageRating(b)
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// unitAverageRating средний рейтинг единицы подбора
+func unitAverageRating(unit []*models.Player) int {
+	total := 0
+	for _, p := range unit {
+		total += p.Rating
+	}
+	return total / len(unit)
+}
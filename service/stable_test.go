@@ -0,0 +1,240 @@
+package service
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"chrono-matchmaking/models"
+)
+
+// stableTestPlayers возвращает игроков с указанными рейтингами; игроки входят в очередь
+// по порядку, поэтому первые становятся лидерами групп
+func stableTestPlayers(ratings ...int) []*models.Player {
+	start := time.Now().Add(-time.Hour)
+	players := make([]*models.Player, len(ratings))
+	for i, rating := range ratings {
+		players[i] = &models.Player{
+			ID:       fmt.Sprintf("p%d", i),
+			Rating:   rating,
+			Region:   "EU",
+			GameMode: "3v3",
+			JoinedAt: start.Add(time.Duration(i) * time.Second),
+		}
+	}
+	return players
+}
+
+// groupRatings возвращает рейтинги игроков каждой группы: сначала лидер, затем
+// остальные по возрастанию, а группы — по рейтингу лидера
+func groupRatings(groups [][]*models.Player) [][]int {
+	result := make([][]int, len(groups))
+	for i, group := range groups {
+		for _, p := range group {
+			result[i] = append(result[i], p.Rating)
+		}
+		sort.Ints(result[i][1:])
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i][0] < result[j][0] })
+	return result
+}
+
+// prefersResident сообщает, предпочитает ли лидер игрока a игроку b
+func prefersResident(leader, a, b *models.Player) bool {
+	da, db := ratingDistance(leader, a), ratingDistance(leader, b)
+	if da != db {
+		return da < db
+	}
+	return a.JoinedAt.Before(b.JoinedAt)
+}
+
+func ratingDistance(a, b *models.Player) int {
+	if a.Rating > b.Rating {
+		return a.Rating - b.Rating
+	}
+	return b.Rating - a.Rating
+}
+
+// checkStable проверяет, что у распределения одиночных игроков без ограничений нет
+// блокирующей пары: игрока и чужого лидера, которые предпочли бы друг друга своим
+// текущим группам. Лидер группы — ее первый игрок.
+func checkStable(t *testing.T, players []*models.Player, groups [][]*models.Player, groupSize int) {
+	t.Helper()
+
+	seen := make(map[string]bool)
+	for _, group := range groups {
+		if len(group) != groupSize {
+			t.Fatalf("group of %d players, want %d", len(group), groupSize)
+		}
+		for _, p := range group {
+			if seen[p.ID] {
+				t.Fatalf("player %s is in more than one group", p.ID)
+			}
+			seen[p.ID] = true
+		}
+	}
+	if want := len(players) / groupSize * groupSize; len(seen) != want {
+		t.Fatalf("%d players grouped, want %d", len(seen), want)
+	}
+
+	for gi, group := range groups {
+		leader := group[0]
+		for _, resident := range group[1:] {
+			for gj, other := range groups {
+				if gi == gj || ratingDistance(resident, other[0]) >= ratingDistance(resident, leader) {
+					continue // Игрок не предпочитает этого лидера своему
+				}
+				// Группа другого лидера заполнена: пара блокирующая, если лидер
+				// предпочел бы игрока хотя бы одному из своих участников
+				for _, member := range other[1:] {
+					if prefersResident(other[0], resident, member) {
+						t.Errorf("blocking pair: player %s (%d) and leader %s (%d) prefer each other over %s (%d) and leader %d",
+							resident.ID, resident.Rating, other[0].ID, other[0].Rating, member.ID, member.Rating, leader.Rating)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestStableMatchingKnownInputs(t *testing.T) {
+	tests := []struct {
+		name      string
+		ratings   []int
+		groupSize int
+		want      [][]int
+	}{
+		{
+			// Жадный подбор отдал бы первому лидеру ближайшего к нему игрока очереди,
+			// даже если тот гораздо ближе ко второму лидеру
+			name:      "residents go to the closest leader",
+			ratings:   []int{1500, 1600, 1590, 1510},
+			groupSize: 2,
+			want:      [][]int{{1500, 1510}, {1600, 1590}},
+		},
+		{
+			// Оба лидера одинаково далеки от 1050: при равенстве выбирается первый
+			name:      "tie goes to the earlier leader",
+			ratings:   []int{1000, 1100, 1090, 1050},
+			groupSize: 2,
+			want:      [][]int{{1000, 1050}, {1100, 1090}},
+		},
+		{
+			// Все трое предлагают себя лидеру 1300; он оставляет двух ближайших,
+			// а отвергнутый 1200 уходит к следующему лидеру в своем списке
+			name:      "rejected resident proposes to next leader",
+			ratings:   []int{1000, 1300, 1200, 1250, 1280, 1010},
+			groupSize: 3,
+			want:      [][]int{{1000, 1010, 1200}, {1300, 1250, 1280}},
+		},
+		{
+			// Новый кандидат вытесняет уже принятого, если он ближе к лидеру
+			name:      "closer proposal displaces accepted resident",
+			ratings:   []int{2000, 1000, 1900, 1990},
+			groupSize: 2,
+			want:      [][]int{{1000, 1900}, {2000, 1990}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			players := stableTestPlayers(tt.ratings...)
+			groups := (&StableMatchingAlgorithm{}).FormGroups(players, tt.groupSize)
+			if got := groupRatings(groups); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("groups = %v, want %v", got, tt.want)
+			}
+			checkStable(t, players, groups, tt.groupSize)
+		})
+	}
+}
+
+func TestStableMatchingIsStable(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+	for _, groupSize := range []int{2, 4, 6, 10} {
+		for round := 0; round < 20; round++ {
+			ratings := make([]int, 5*groupSize+rng.Intn(groupSize))
+			for i := range ratings {
+				ratings[i] = 800 + rng.Intn(1600)
+			}
+			players := stableTestPlayers(ratings...)
+			groups := (&StableMatchingAlgorithm{}).FormGroups(players, groupSize)
+			if len(groups) != len(players)/groupSize {
+				t.Fatalf("group size %d: %d groups, want %d", groupSize, len(groups), len(players)/groupSize)
+			}
+			checkStable(t, players, groups, groupSize)
+		}
+	}
+}
+
+func TestStableMatchingLeadersAreLongestWaiting(t *testing.T) {
+	players := stableTestPlayers(1000, 1500, 2000, 1010, 1490, 1990, 1020, 1510)
+	groups := (&StableMatchingAlgorithm{}).FormGroups(players, 4)
+
+	leaders := make([]string, 0, len(groups))
+	for _, group := range groups {
+		leaders = append(leaders, group[0].ID)
+	}
+	sort.Strings(leaders)
+	if want := []string{"p0", "p1"}; !reflect.DeepEqual(leaders, want) {
+		t.Errorf("leaders = %v, want the two longest waiting players %v", leaders, want)
+	}
+}
+
+func TestStableMatchingKeepsPartiesTogether(t *testing.T) {
+	players := stableTestPlayers(1000, 2000, 1010, 1990, 1020, 1980)
+	// Игроки 1010 и 1990 в одной группе: по отдельности они разошлись бы к разным лидерам
+	for _, p := range players[2:4] {
+		p.PartyID = "party"
+		p.PartySize = 2
+	}
+
+	groups := (&StableMatchingAlgorithm{}).FormGroups(players, 4)
+	if len(groups) != 1 {
+		t.Fatalf("got %d groups, want 1", len(groups))
+	}
+	inGroup := make(map[string]bool)
+	for _, p := range groups[0] {
+		inGroup[p.ID] = true
+	}
+	if !inGroup["p2"] || !inGroup["p3"] {
+		t.Errorf("group %v splits the party", groupRatings(groups))
+	}
+}
+
+func TestStableMatchingRespectsConstraints(t *testing.T) {
+	players := stableTestPlayers(1000, 1100, 1010, 1050)
+	// Игрок 1010 несовместим с лидером 1000 и уходит к следующему в своем списке,
+	// освобождая место для 1050
+	algorithm := &StableMatchingAlgorithm{}
+	algorithm.setConstraints(GroupConstraints{
+		CanJoin: func(group, unit []*models.Player) bool {
+			return !(group[0].ID == "p0" && unit[0].ID == "p2")
+		},
+	})
+
+	groups := algorithm.FormGroups(players, 2)
+	if got, want := groupRatings(groups), [][]int{{1000, 1050}, {1100, 1010}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("groups = %v, want %v", got, want)
+	}
+}
+
+func TestStableMatchingDropsIncompleteGroups(t *testing.T) {
+	players := stableTestPlayers(1000, 1100, 1010, 1090)
+	algorithm := &StableMatchingAlgorithm{}
+	algorithm.setConstraints(GroupConstraints{
+		Complete: func(group []*models.Player) bool { return group[0].ID != "p1" },
+	})
+
+	groups := algorithm.FormGroups(players, 2)
+	if got, want := groupRatings(groups), [][]int{{1000, 1010}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("groups = %v, want %v", got, want)
+	}
+}
+
+func TestStableMatchingNotEnoughPlayers(t *testing.T) {
+	if groups := (&StableMatchingAlgorithm{}).FormGroups(stableTestPlayers(1000, 1010, 1020), 4); groups != nil {
+		t.Errorf("groups = %v, want none", groupRatings(groups))
+	}
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/storage"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// tournamentLockTTL время блокировки турнира на переход к следующему раунду
+const tournamentLockTTL = 10 * time.Second
+
+var (
+	// ErrTournamentNotFound возвращается, если турнир не найден или его срок хранения истек
+	ErrTournamentNotFound = storage.ErrTournamentNotFound
+
+	// ErrNotEnoughTournamentPlayers возвращается, если в очереди меньше двух участников
+	ErrNotEnoughTournamentPlayers = errors.New("not enough players in queue for tournament")
+
+	// ErrTournamentCompleted возвращается при попытке продолжить завершенный турнир
+	ErrTournamentCompleted = errors.New("tournament is already completed")
+
+	// ErrInvalidTournamentResult возвращается, если победители не совпадают с участниками матчей раунда
+	ErrInvalidTournamentResult = errors.New("invalid tournament round result")
+
+	// ErrTournamentBusy возвращается, если раунд турнира уже продвигается другим запросом
+	ErrTournamentBusy = errors.New("tournament round is being advanced")
+)
+
+// TournamentService проводит турниры на выбывание среди лучших игроков очереди
+type TournamentService struct {
+	storage storage.Storage
+	matcher *MatcherService // Размеры матчей режимов
+	logger  *zap.Logger
+}
+
+// NewTournamentService создает сервис турниров
+func NewTournamentService(storage storage.Storage, matcher *MatcherService, logger *zap.Logger) *TournamentService {
+	return &TournamentService{
+		storage: storage,
+		matcher: matcher,
+		logger:  logger,
+	}
+}
+
+// CreateTournament создает турнир и заполняет первый раунд сетки игроками очереди
+func (t *TournamentService) CreateTournament(ctx context.Context, region, gameMode string, maxPlayers int) (*models.Tournament, error) {
+	playersPerMatch, err := t.matcher.GetPlayersPerMatch(ctx, gameMode)
+	if err != nil {
+		return nil, err
+	}
+	teamSize := playersPerMatch / 2
+	if maxPlayers < 2*teamSize {
+		return nil, fmt.Errorf("game mode %s requires max_players of at least %d", gameMode, 2*teamSize)
+	}
+
+	tournament := &models.Tournament{
+		TournamentID: uuid.New().String(),
+		Region:       region,
+		GameMode:     gameMode,
+		MaxPlayers:   maxPlayers,
+		Status:       models.TournamentStatusInProgress,
+		CreatedAt:    time.Now().UTC(),
+	}
+	if err := t.FillBracket(ctx, tournament); err != nil {
+		return nil, err
+	}
+
+	if err := t.storage.SaveTournament(ctx, tournament); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).Info("Tournament created",
+		zap.String("tournament_id", tournament.TournamentID),
+		zap.String("region", region),
+		zap.String("game_mode", gameMode),
+		zap.Int("matches", len(tournament.Rounds[0])),
+	)
+
+	return tournament, nil
+}
+
+// FillBracket забирает из очереди до MaxPlayers игроков с наибольшим рейтингом и
+// расставляет их в первый раунд по стандартному посеву (1-й против последнего и т.д.).
+// Участник — команда из половины игроков матча режима, команды собираются по порядку
+// рейтинга. Число участников округляется вниз до степени двойки; лишние игроки
+// возвращаются в очередь. Игроки в составе party в турнир не берутся.
+func (t *TournamentService) FillBracket(ctx context.Context, tournament *models.Tournament) error {
+	playersPerMatch, err := t.matcher.GetPlayersPerMatch(ctx, tournament.GameMode)
+	if err != nil {
+		return err
+	}
+	teamSize := playersPerMatch / 2
+
+	candidates, err := t.storage.GetTopRatedPlayers(ctx, tournament.Region, tournament.GameMode, int64(tournament.MaxPlayers))
+	if err != nil {
+		return err
+	}
+
+	// Забираем игроков из очереди; успевшие попасть в матч пропускаются
+	claimed := make([]*models.Player, 0, len(candidates))
+	for _, p := range candidates {
+		if p.PartyID != "" {
+			continue
+		}
+		if err := t.storage.RemovePlayerFromQueue(ctx, p.ID); err != nil {
+			continue
+		}
+		claimed = append(claimed, p)
+	}
+
+	entrants := 1
+	for entrants*2*teamSize <= len(claimed) {
+		entrants *= 2
+	}
+	if entrants < 2 {
+		t.returnToQueue(ctx, claimed)
+		return ErrNotEnoughTournamentPlayers
+	}
+	t.returnToQueue(ctx, claimed[entrants*teamSize:])
+
+	teams := make([][]models.Player, entrants)
+	for i := range teams {
+		for _, p := range claimed[i*teamSize : (i+1)*teamSize] {
+			teams[i] = append(teams[i], *p)
+		}
+	}
+
+	seeds := bracketSeeds(entrants)
+	round := make([][]models.Player, 0, entrants/2)
+	for i := 0; i < entrants; i += 2 {
+		match := append(append([]models.Player{}, teams[seeds[i]]...), teams[seeds[i+1]]...)
+		round = append(round, match)
+	}
+	tournament.Rounds = [][][]models.Player{round}
+
+	return nil
+}
+
+// returnToQueue возвращает в очередь игроков, не попавших в турнир
+func (t *TournamentService) returnToQueue(ctx context.Context, players []*models.Player) {
+	for _, p := range players {
+		if err := t.storage.AddPlayerToQueue(ctx, p); err != nil {
+			logging.FromContext(ctx).Warn("Failed to return player to queue after tournament fill",
+				zap.String("player_id", p.ID),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// bracketSeeds возвращает индексы посева (0 — сильнейший) в порядке позиций сетки
+// для n участников (n — степень двойки): соседние пары встречаются в первом раунде,
+// а сильнейшие посевы могут встретиться только в поздних раундах.
+// Для 8 участников: 1-8, 4-5, 2-7, 3-6.
+func bracketSeeds(n int) []int {
+	seeds := []int{0}
+	for size := 2; size <= n; size *= 2 {
+		next := make([]int, 0, size)
+		for _, seed := range seeds {
+			next = append(next, seed, size-1-seed)
+		}
+		seeds = next
+	}
+	return seeds
+}
+
+// GetTournament возвращает турнир или ErrTournamentNotFound
+func (t *TournamentService) GetTournament(ctx context.Context, tournamentID string) (*models.Tournament, error) {
+	return t.storage.GetTournament(ctx, tournamentID)
+}
+
+// AdvanceRound принимает победителей текущего раунда и формирует следующий: победители
+// соседних матчей сетки встречаются друг с другом. После финала турнир завершается,
+// а игроки победившего участника записываются в Winners.
+func (t *TournamentService) AdvanceRound(ctx context.Context, tournamentID string, winnerIDs []string) (*models.Tournament, error) {
+	lockKey := "lock:tournament:" + tournamentID
+	owner := uuid.New().String()
+	acquired, err := t.storage.AcquireLock(ctx, lockKey, owner, tournamentLockTTL)
+	if err != nil {
+		return nil, err
+	}
+	if !acquired {
+		return nil, ErrTournamentBusy
+	}
+	defer func() {
+		if err := t.storage.ReleaseLock(ctx, lockKey, owner); err != nil {
+			logging.FromContext(ctx).Warn("Failed to release tournament lock",
+				zap.String("tournament_id", tournamentID),
+				zap.Error(err),
+			)
+		}
+	}()
+
+	tournament, err := t.storage.GetTournament(ctx, tournamentID)
+	if err != nil {
+		return nil, err
+	}
+	if tournament.Status == models.TournamentStatusCompleted {
+		return nil, ErrTournamentCompleted
+	}
+
+	winners, err := roundWinners(tournament.Rounds[len(tournament.Rounds)-1], winnerIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(winners) == 1 {
+		tournament.Status = models.TournamentStatusCompleted
+		tournament.Winners = winners[0]
+	} else {
+		next := make([][]models.Player, 0, len(winners)/2)
+		for i := 0; i < len(winners); i += 2 {
+			next = append(next, append(append([]models.Player{}, winners[i]...), winners[i+1]...))
+		}
+		tournament.Rounds = append(tournament.Rounds, next)
+	}
+
+	if err := t.storage.SaveTournament(ctx, tournament); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).Info("Tournament round advanced",
+		zap.String("tournament_id", tournamentID),
+		zap.Int("round", len(tournament.Rounds)),
+		zap.String("status", tournament.Status),
+	)
+
+	return tournament, nil
+}
+
+// roundWinners возвращает победившего участника каждого матча раунда в порядке сетки.
+// winnerIDs должны содержать ровно всех игроков одного участника каждого матча.
+func roundWinners(round [][]models.Player, winnerIDs []string) ([][]models.Player, error) {
+	winners := make(map[string]bool, len(winnerIDs))
+	for _, id := range winnerIDs {
+		winners[id] = true
+	}
+
+	result := make([][]models.Player, 0, len(round))
+	total := 0
+	for i, match := range round {
+		half := len(match) / 2
+		sides := [2][]models.Player{match[:half], match[half:]}
+
+		won := -1
+		for side, players := range sides {
+			if !allWon(players, winners) {
+				continue
+			}
+			if won >= 0 {
+				won = -1 // Победителями отмечены обе стороны
+				break
+			}
+			won = side
+		}
+		if won < 0 {
+			return nil, fmt.Errorf("%w: match %d needs the players of exactly one side", ErrInvalidTournamentResult, i+1)
+		}
+
+		result = append(result, sides[won])
+		total += len(sides[won])
+	}
+
+	if total != len(winners) {
+		return nil, fmt.Errorf("%w: winners include players outside the current round", ErrInvalidTournamentResult)
+	}
+	return result, nil
+}
+
+// allWon проверяет, что все игроки отмечены победителями
+func allWon(players []models.Player, winners map[string]bool) bool {
+	for _, p := range players {
+		if !winners[p.ID] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,18 @@
+package service
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer создает спаны методов сервиса. Провайдер задается в main через otel.SetTracerProvider;
+// без него спаны ничего не делают
+var tracer = otel.Tracer("chrono-matchmaking/service")
+
+// startSpan открывает спан метода MatcherService с переданными атрибутами
+func startSpan(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "MatcherService."+method, trace.WithAttributes(attrs...))
+}
@@ -0,0 +1,48 @@
+package service
+
+import "chrono-matchmaking/models"
+
+// sharesVoiceLanguage проверяет, что у игроков указан один и тот же язык голосового чата
+func sharesVoiceLanguage(p1, p2 *models.Player) bool {
+	return p1.VoiceLanguage != "" && p1.VoiceLanguage == p2.VoiceLanguage
+}
+
+// voiceCompatibleWithGroup проверяет, что кандидат не нарушает требований к голосовому
+// чату ни у одного игрока группы: при VoicePreference == "required" у любой из сторон
+// языки должны совпадать
+func voiceCompatibleWithGroup(group []*models.Player, candidate *models.Player) bool {
+	for _, p := range group {
+		required := p.VoicePreference == models.VoicePreferenceRequired ||
+			candidate.VoicePreference == models.VoicePreferenceRequired
+		if required && p.VoiceLanguage != candidate.VoiceLanguage {
+			return false
+		}
+	}
+	return true
+}
+
+// VoiceGroupBonus возвращает надбавку к качеству матча: bonus за каждую команду,
+// все игроки которой говорят на одном языке голосового чата
+func VoiceGroupBonus(bonus float64, teamAVoiceCompatible, teamBVoiceCompatible bool) float64 {
+	var total float64
+	if teamAVoiceCompatible {
+		total += bonus
+	}
+	if teamBVoiceCompatible {
+		total += bonus
+	}
+	return total
+}
+
+// teamVoiceCompatible проверяет, что все игроки команды говорят на одном языке голосового чата
+func teamVoiceCompatible(team []models.Player) bool {
+	if len(team) == 0 || team[0].VoiceLanguage == "" {
+		return false
+	}
+	for _, p := range team[1:] {
+		if p.VoiceLanguage != team[0].VoiceLanguage {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"math"
+	"testing"
+
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/storage"
+	"go.uber.org/zap"
+)
+
+func TestBuildMatchVoiceGroupBonus(t *testing.T) {
+	matcher := NewMatcherService(storage.NewInMemoryStorage(), zap.NewNop(), DefaultMatcherConfig())
+
+	// Разница рейтингов 200 дает базовое качество 0.5
+	tests := []struct {
+		name       string
+		languages  [4]string
+		wantBonus  float64
+		wantVoiceA bool
+		wantVoiceB bool
+	}{
+		{"both teams", [4]string{"en", "en", "ru", "ru"}, 0.2, true, true},
+		{"one team", [4]string{"en", "en", "ru", "de"}, 0.1, true, false},
+		{"no language", [4]string{}, 0, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			players := make([]models.Player, 4)
+			for i := range players {
+				players[i] = models.Player{ID: string(rune('a' + i)), Rating: 1500, Region: "EU", GameMode: "2v2", VoiceLanguage: tt.languages[i]}
+			}
+			players[0].PartyID, players[1].PartyID = "party-a", "party-a" // Состав команд не перестраивается
+			players[0].Rating, players[1].Rating = 1700, 1700
+
+			match := matcher.buildMatch(players)
+			if match.TeamAVoiceCompatible != tt.wantVoiceA || match.TeamBVoiceCompatible != tt.wantVoiceB {
+				t.Errorf("voice compatible = %v/%v, want %v/%v",
+					match.TeamAVoiceCompatible, match.TeamBVoiceCompatible, tt.wantVoiceA, tt.wantVoiceB)
+			}
+			if math.Abs(match.VoiceGroupBonus-tt.wantBonus) > 1e-9 {
+				t.Errorf("VoiceGroupBonus = %v, want %v", match.VoiceGroupBonus, tt.wantBonus)
+			}
+			if want := 0.5 + tt.wantBonus; math.Abs(match.QualityScore-want) > 1e-9 {
+				t.Errorf("QualityScore = %v, want %v", match.QualityScore, want)
+			}
+		})
+	}
+}
+
+func TestVoiceGroupBonusCapsQuality(t *testing.T) {
+	matcher := NewMatcherService(storage.NewInMemoryStorage(), zap.NewNop(), DefaultMatcherConfig())
+	players := []models.Player{
+		{ID: "a", Rating: 1500, Region: "EU", GameMode: "1v1", VoiceLanguage: "en"},
+		{ID: "b", Rating: 1500, Region: "EU", GameMode: "1v1", VoiceLanguage: "en"},
+	}
+
+	if match := matcher.buildMatch(players); match.QualityScore != 1 {
+		t.Errorf("QualityScore = %v, want capped at 1", match.QualityScore)
+	}
+}
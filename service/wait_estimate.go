@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"chrono-matchmaking/models"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// minWaitEstimateSamples минимальное число матчей за час для оценки ожидания
+const minWaitEstimateSamples = 5
+
+// ErrInsufficientWaitData возвращается, если матчей за последний час слишком мало для оценки
+var ErrInsufficientWaitData = errors.New("not enough recent matches to estimate wait time")
+
+// EstimateWaitTime оценивает ожидание в очереди как среднее время ожидания игроков,
+// попавших в матч за последний час. Если в очереди меньше игроков, чем нужно на матч,
+// оценка увеличивается пропорционально недостающим игрокам.
+func (s *MatcherService) EstimateWaitTime(ctx context.Context, region, gameMode string) (*models.WaitEstimate, error) {
+	ctx, span := startSpan(ctx, "EstimateWaitTime",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	samples, err := s.storage.GetWaitTimeSamples(ctx, region, gameMode)
+	if err != nil {
+		return nil, err
+	}
+	if len(samples) < minWaitEstimateSamples {
+		return nil, ErrInsufficientWaitData
+	}
+
+	var total time.Duration
+	for _, d := range samples {
+		total += d
+	}
+	avg := total / time.Duration(len(samples))
+
+	depth, err := s.storage.GetQueueSize(ctx, region, gameMode)
+	if err != nil {
+		return nil, err
+	}
+
+	playersPerMatch, err := s.GetPlayersPerMatch(ctx, gameMode)
+	if err != nil {
+		return nil, err
+	}
+
+	// Новому игроку нужно дождаться, пока очередь доберется до полного матча
+	estimate := avg.Seconds()
+	if depth+1 < int64(playersPerMatch) {
+		estimate *= float64(playersPerMatch) / float64(depth+1)
+	}
+
+	return &models.WaitEstimate{
+		Region:           region,
+		GameMode:         gameMode,
+		EstimatedSeconds: estimate,
+		SampleSize:       len(samples),
+		QueueDepth:       depth,
+	}, nil
+}
@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// AbandonWindow сколько живет счетчик выходов игрока из очереди без матча,
+// считая от первого выхода
+const AbandonWindow = 24 * time.Hour
+
+// flaggedAbandonKey sorted set игроков, отмеченных для проверки из-за частых выходов
+// из очереди; score — Unix-время снятия отметки
+const flaggedAbandonKey = "flagged:abandon"
+
+// IncrementAbandonCount увеличивает счетчик abandons:{playerID} выходов игрока из очереди
+// без матча и возвращает его новое значение. Срок жизни AbandonWindow ставится при
+// первом выходе, поэтому счетчик считает выходы за сутки с него.
+func (s *RedisStorage) IncrementAbandonCount(ctx context.Context, playerID string) (int64, error) {
+	ctx, span := startSpan(ctx, "IncrementAbandonCount", attribute.String("player_id", playerID))
+	defer span.End()
+
+	key := s.abandonKey(playerID)
+	count, err := s.client.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment abandon count: %w", err)
+	}
+	if count == 1 {
+		if err := s.client.Expire(ctx, key, AbandonWindow).Err(); err != nil {
+			return 0, fmt.Errorf("failed to set abandon count ttl: %w", err)
+		}
+	}
+	return count, nil
+}
+
+// FlagPlayer отмечает игрока в flagged:abandon на время duration. Повторная отметка
+// заменяет срок предыдущей; истекшие отметки удаляются из набора.
+func (s *RedisStorage) FlagPlayer(ctx context.Context, playerID string, duration time.Duration) error {
+	ctx, span := startSpan(ctx, "FlagPlayer", attribute.String("player_id", playerID))
+	defer span.End()
+
+	now := time.Now()
+	pipe := s.client.TxPipeline()
+	pipe.ZRemRangeByScore(ctx, flaggedAbandonKey, "-inf", strconv.FormatInt(now.Unix(), 10))
+	pipe.ZAdd(ctx, flaggedAbandonKey, &redis.Z{Score: float64(now.Add(duration).Unix()), Member: playerID})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to flag player: %w", err)
+	}
+
+	logging.FromContext(ctx).Warn("Player flagged for queue abandonment",
+		zap.String("player_id", playerID),
+		zap.Duration("duration", duration),
+	)
+	return nil
+}
+
+// IsPlayerFlagged проверяет, действует ли отметка игрока
+func (s *RedisStorage) IsPlayerFlagged(ctx context.Context, playerID string) (bool, error) {
+	ctx, span := startSpan(ctx, "IsPlayerFlagged", attribute.String("player_id", playerID))
+	defer span.End()
+
+	flaggedUntil, err := s.client.ZScore(ctx, flaggedAbandonKey, playerID).Result()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check player flag: %w", err)
+	}
+	return int64(flaggedUntil) > time.Now().Unix(), nil
+}
+
+// UnflagPlayer снимает отметку игрока и сбрасывает его счетчик выходов из очереди,
+// чтобы следующий выход не отметил игрока снова. Возвращает false, если действующей
+// отметки не было.
+func (s *RedisStorage) UnflagPlayer(ctx context.Context, playerID string) (bool, error) {
+	ctx, span := startSpan(ctx, "UnflagPlayer", attribute.String("player_id", playerID))
+	defer span.End()
+
+	flagged, err := s.IsPlayerFlagged(ctx, playerID)
+	if err != nil {
+		return false, err
+	}
+
+	pipe := s.client.TxPipeline()
+	pipe.ZRem(ctx, flaggedAbandonKey, playerID)
+	pipe.Del(ctx, s.abandonKey(playerID))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, fmt.Errorf("failed to unflag player: %w", err)
+	}
+	return flagged, nil
+}
+
+// GetFlaggedPlayers возвращает ID игроков с действующей отметкой по алфавиту
+func (s *RedisStorage) GetFlaggedPlayers(ctx context.Context) ([]string, error) {
+	ctx, span := startSpan(ctx, "GetFlaggedPlayers")
+	defer span.End()
+
+	ids, err := s.client.ZRangeByScore(ctx, flaggedAbandonKey, &redis.ZRangeBy{
+		Min: "(" + strconv.FormatInt(time.Now().Unix(), 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flagged players: %w", err)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// abandonKey возвращает ключ счетчика выходов игрока из очереди без матча
+func (s *RedisStorage) abandonKey(playerID string) string {
+	return fmt.Sprintf("abandons:%s", playerID)
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// GetAllPlayers обходит все ключи player:* и возвращает данные игроков, стоящих в очереди.
+// SCAN обходит все пространство ключей, поэтому метод предназначен для фоновых задач.
+func (s *RedisStorage) GetAllPlayers(ctx context.Context) ([]*models.Player, error) {
+	ctx, span := startSpan(ctx, "GetAllPlayers")
+	defer span.End()
+
+	players := make([]*models.Player, 0)
+	seen := make(map[string]bool) // SCAN может вернуть ключ несколько раз
+	err := s.scanKeys(ctx, s.playerKey("*"), playerScanCount, func(keys []string) error {
+		pipe := s.client.Pipeline()
+		cmds := make([]*redis.StringCmd, 0, len(keys))
+		for _, key := range keys {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			cmds = append(cmds, pipe.Get(ctx, key))
+		}
+		if len(cmds) == 0 {
+			return nil
+		}
+		if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+			return fmt.Errorf("failed to get players: %w", err)
+		}
+
+		for _, cmd := range cmds {
+			playerJSON, err := cmd.Result()
+			if err != nil {
+				continue // Ключ истек между SCAN и GET
+			}
+			var player models.Player
+			if err := json.Unmarshal([]byte(playerJSON), &player); err != nil {
+				logging.FromContext(ctx).Warn("Failed to unmarshal player",
+					zap.Error(err),
+					zap.String("data", playerJSON),
+				)
+				continue
+			}
+			players = append(players, &player)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan players: %w", err)
+	}
+
+	return players, nil
+}
+
+// SetLastActive сохраняет время последнего сыгранного игроком матча в last_active:{playerID} без TTL
+func (s *RedisStorage) SetLastActive(ctx context.Context, playerID string, at time.Time) error {
+	ctx, span := startSpan(ctx, "SetLastActive", attribute.String("player_id", playerID))
+	defer span.End()
+
+	if err := s.client.Set(ctx, s.lastActiveKey(playerID), at.Unix(), 0).Err(); err != nil {
+		return fmt.Errorf("failed to set last active: %w", err)
+	}
+	return nil
+}
+
+// GetLastActive возвращает время последнего сыгранного игроком матча (нулевое, если неизвестно)
+func (s *RedisStorage) GetLastActive(ctx context.Context, playerID string) (time.Time, error) {
+	ctx, span := startSpan(ctx, "GetLastActive", attribute.String("player_id", playerID))
+	defer span.End()
+
+	unix, err := s.client.Get(ctx, s.lastActiveKey(playerID)).Int64()
+	if err == redis.Nil {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get last active: %w", err)
+	}
+	return time.Unix(unix, 0), nil
+}
+
+// lastActiveKey возвращает ключ времени последней активности игрока
+func (s *RedisStorage) lastActiveKey(playerID string) string {
+	return fmt.Sprintf("last_active:%s", playerID)
+}
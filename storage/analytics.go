@@ -0,0 +1,227 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"chrono-matchmaking/models"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// HoursPerDay количество часовых интервалов в суточной статистике
+const HoursPerDay = 24
+
+// IncrementModePopularity увеличивает счетчик созданных матчей режима в указанный час суток (UTC)
+func (s *RedisStorage) IncrementModePopularity(ctx context.Context, region, gameMode string, hour int) error {
+	ctx, span := startSpan(ctx, "IncrementModePopularity",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	err := s.client.HIncrBy(ctx, s.modePopularityKey(region, gameMode), strconv.Itoa(hour), 1).Err()
+	if err != nil {
+		return fmt.Errorf("failed to increment mode popularity: %w", err)
+	}
+	return nil
+}
+
+// RecordWaitTime добавляет время ожидания игрока в почасовую статистику режима
+func (s *RedisStorage) RecordWaitTime(ctx context.Context, region, gameMode string, hour int, waitSeconds float64) error {
+	ctx, span := startSpan(ctx, "RecordWaitTime",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	key := s.waitStatsKey(region, gameMode)
+
+	pipe := s.client.TxPipeline()
+	pipe.HIncrByFloat(ctx, key, fmt.Sprintf("sum:%d", hour), waitSeconds)
+	pipe.HIncrBy(ctx, key, fmt.Sprintf("count:%d", hour), 1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record wait time: %w", err)
+	}
+	return nil
+}
+
+// GetHourlyAvgWait возвращает среднее время ожидания (в секундах) для каждого часа суток.
+// Для часов без данных возвращается 0.
+func (s *RedisStorage) GetHourlyAvgWait(ctx context.Context, region, gameMode string) ([HoursPerDay]float64, error) {
+	ctx, span := startSpan(ctx, "GetHourlyAvgWait",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	var result [HoursPerDay]float64
+
+	values, err := s.client.HGetAll(ctx, s.waitStatsKey(region, gameMode)).Result()
+	if err != nil && err != redis.Nil {
+		return result, fmt.Errorf("failed to get wait stats: %w", err)
+	}
+
+	for hour := 0; hour < HoursPerDay; hour++ {
+		sum, _ := strconv.ParseFloat(values[fmt.Sprintf("sum:%d", hour)], 64)
+		count, _ := strconv.ParseInt(values[fmt.Sprintf("count:%d", hour)], 10, 64)
+		if count > 0 {
+			result[hour] = sum / float64(count)
+		}
+	}
+
+	return result, nil
+}
+
+// GetHourlyMatchCounts возвращает количество созданных матчей для каждого часа суток
+func (s *RedisStorage) GetHourlyMatchCounts(ctx context.Context, region, gameMode string) ([HoursPerDay]int64, error) {
+	ctx, span := startSpan(ctx, "GetHourlyMatchCounts",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	var result [HoursPerDay]int64
+
+	values, err := s.client.HGetAll(ctx, s.modePopularityKey(region, gameMode)).Result()
+	if err != nil && err != redis.Nil {
+		return result, fmt.Errorf("failed to get mode popularity: %w", err)
+	}
+
+	for hour := 0; hour < HoursPerDay; hour++ {
+		result[hour], _ = strconv.ParseInt(values[strconv.Itoa(hour)], 10, 64)
+	}
+
+	return result, nil
+}
+
+// segmentMatchesWindow период, за который хранятся матчи рейтинговых сегментов
+const segmentMatchesWindow = time.Hour
+
+// RecordSegmentMatch отмечает матч, созданный в рейтинговом сегменте, и удаляет
+// записи старше часа
+func (s *RedisStorage) RecordSegmentMatch(ctx context.Context, region, gameMode, bracket, matchID string, createdAt time.Time) error {
+	ctx, span := startSpan(ctx, "RecordSegmentMatch",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+		attribute.String("match_id", matchID),
+	)
+	defer span.End()
+
+	key := s.segmentMatchesKey(region, gameMode, bracket)
+
+	pipe := s.client.TxPipeline()
+	pipe.ZAdd(ctx, key, &redis.Z{
+		Score:  float64(createdAt.Unix()),
+		Member: matchID,
+	})
+	pipe.ZRemRangeByScore(ctx, key, "-inf", fmt.Sprintf("(%d", createdAt.Add(-segmentMatchesWindow).Unix()))
+	pipe.Expire(ctx, key, segmentMatchesWindow)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record segment match: %w", err)
+	}
+	return nil
+}
+
+// CountSegmentMatchesLastHour возвращает количество матчей сегмента за последний час
+func (s *RedisStorage) CountSegmentMatchesLastHour(ctx context.Context, region, gameMode, bracket string) (int64, error) {
+	ctx, span := startSpan(ctx, "CountSegmentMatchesLastHour",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	since := time.Now().Add(-segmentMatchesWindow).Unix()
+	count, err := s.client.ZCount(ctx, s.segmentMatchesKey(region, gameMode, bracket), strconv.FormatInt(since, 10), "+inf").Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count segment matches: %w", err)
+	}
+	return count, nil
+}
+
+// matchedRatingsWindow период, за который хранятся рейтинги игроков, попавших в матч
+const matchedRatingsWindow = 24 * time.Hour
+
+// RecordMatchedRatings сохраняет рейтинги игроков матча для сравнения распределений
+// и удаляет записи старше суток
+func (s *RedisStorage) RecordMatchedRatings(ctx context.Context, region, gameMode string, match *models.Match) error {
+	ctx, span := startSpan(ctx, "RecordMatchedRatings",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+		attribute.String("match_id", match.MatchID),
+	)
+	defer span.End()
+
+	key := s.matchedRatingsKey(region, gameMode)
+	score := float64(match.CreatedAt.Unix())
+
+	members := make([]*redis.Z, 0, len(match.Players))
+	for _, p := range match.Players {
+		members = append(members, &redis.Z{
+			Score:  score,
+			Member: fmt.Sprintf("%s|%s|%d", match.MatchID, p.ID, p.Rating),
+		})
+	}
+
+	pipe := s.client.TxPipeline()
+	pipe.ZAdd(ctx, key, members...)
+	pipe.ZRemRangeByScore(ctx, key, "-inf", fmt.Sprintf("(%d", match.CreatedAt.Add(-matchedRatingsWindow).Unix()))
+	pipe.Expire(ctx, key, matchedRatingsWindow)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record matched ratings: %w", err)
+	}
+	return nil
+}
+
+// GetMatchedRatings возвращает рейтинги игроков, попавших в матч за последние сутки
+func (s *RedisStorage) GetMatchedRatings(ctx context.Context, region, gameMode string) ([]int, error) {
+	ctx, span := startSpan(ctx, "GetMatchedRatings",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	since := time.Now().Add(-matchedRatingsWindow).Unix()
+	members, err := s.client.ZRangeByScore(ctx, s.matchedRatingsKey(region, gameMode), &redis.ZRangeBy{
+		Min: strconv.FormatInt(since, 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get matched ratings: %w", err)
+	}
+
+	ratings := make([]int, 0, len(members))
+	for _, member := range members {
+		parts := strings.Split(member, "|")
+		rating, err := strconv.Atoi(parts[len(parts)-1])
+		if err != nil {
+			continue
+		}
+		ratings = append(ratings, rating)
+	}
+
+	return ratings, nil
+}
+
+// modePopularityKey возвращает ключ почасовых счетчиков матчей режима
+func (s *RedisStorage) modePopularityKey(region, gameMode string) string {
+	return fmt.Sprintf("stats:matches:%s:%s", region, gameMode)
+}
+
+// segmentMatchesKey возвращает ключ матчей рейтингового сегмента за последний час
+func (s *RedisStorage) segmentMatchesKey(region, gameMode, bracket string) string {
+	return fmt.Sprintf("stats:segment:%s:%s:%s", region, gameMode, bracket)
+}
+
+// matchedRatingsKey возвращает ключ рейтингов игроков, попавших в матч
+func (s *RedisStorage) matchedRatingsKey(region, gameMode string) string {
+	return fmt.Sprintf("stats:matched-ratings:%s:%s", region, gameMode)
+}
+
+// waitStatsKey возвращает ключ почасовой статистики времени ожидания
+func (s *RedisStorage) waitStatsKey(region, gameMode string) string {
+	return fmt.Sprintf("stats:wait:%s:%s", region, gameMode)
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// ClaimBackfillPlayers атомарно (тем же скриптом, что и RunAtomicMatchFormation) убирает
+// из очереди игроков, добранных в уже созданный матч, и сохраняет им обновленный матч.
+// Если кто-то из них уже покинул очередь, ничего не меняется и возвращается ErrMatchConflict.
+// Ключи остальных игроков матча обновляет UpdateMatch.
+func (s *RedisStorage) ClaimBackfillPlayers(ctx context.Context, match *models.Match, newPlayers []models.Player) error {
+	ctx, span := startSpan(ctx, "ClaimBackfillPlayers", attribute.String("match_id", match.MatchID))
+	defer span.End()
+
+	if len(newPlayers) == 0 {
+		return fmt.Errorf("no backfill players")
+	}
+
+	matchJSON, err := json.Marshal(match)
+	if err != nil {
+		return fmt.Errorf("failed to marshal match: %w", err)
+	}
+
+	n := len(newPlayers)
+	keys := make([]string, 0, 1+3*n)
+	for _, p := range newPlayers {
+		keys = append(keys, s.playerQueueKey(&p))
+	}
+	for _, p := range newPlayers {
+		keys = append(keys, s.playerKey(p.ID))
+	}
+	for _, p := range newPlayers {
+		keys = append(keys, s.matchKey(p.ID))
+	}
+	keys = append(keys, s.matchByIDKey(match.MatchID))
+	otherKeys, owners := s.otherQueueKeys(newPlayers)
+	keys = append(keys, otherKeys...)
+
+	args := append([]interface{}{matchJSON, int64(MatchTTL.Seconds()), int64(matchRecordTTL.Seconds()), len(otherKeys)}, owners...)
+	res, err := formMatchScript.Run(ctx, s.client, keys, args...).Int()
+	if err != nil {
+		return fmt.Errorf("failed to run backfill script: %w", err)
+	}
+	if res == 0 {
+		return ErrMatchConflict
+	}
+
+	s.incrementQueueLeaves(ctx, newPlayers)
+	for _, p := range newPlayers {
+		if err := s.AppendMatchHistory(ctx, p.ID, match); err != nil {
+			logging.FromContext(ctx).Warn("Failed to record match history",
+				zap.String("match_id", match.MatchID),
+				zap.String("player_id", p.ID),
+				zap.Error(err),
+			)
+		}
+	}
+
+	logging.FromContext(ctx).Info("Backfill players claimed",
+		zap.String("match_id", match.MatchID),
+		zap.Int("players_count", n),
+	)
+
+	return nil
+}
+
+// UpdateMatch перезаписывает сохраненный матч по его ID и в ключах матча всех его игроков,
+// не меняя срок хранения. Истекшие ключи не восстанавливаются.
+func (s *RedisStorage) UpdateMatch(ctx context.Context, match *models.Match) error {
+	ctx, span := startSpan(ctx, "UpdateMatch", attribute.String("match_id", match.MatchID))
+	defer span.End()
+
+	matchJSON, err := json.Marshal(match)
+	if err != nil {
+		return fmt.Errorf("failed to marshal match: %w", err)
+	}
+
+	args := redis.SetArgs{Mode: "XX", KeepTTL: true}
+
+	pipe := s.client.TxPipeline()
+	record := pipe.SetArgs(ctx, s.matchByIDKey(match.MatchID), matchJSON, args)
+	for _, p := range match.Players {
+		pipe.SetArgs(ctx, s.matchKey(p.ID), matchJSON, args)
+	}
+	// redis.Nil означает, что XX не нашел ключ — это не ошибка для ключей игроков
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return fmt.Errorf("failed to update match: %w", err)
+	}
+	if record.Err() == redis.Nil {
+		return ErrMatchNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// BanPlayer запрещает игроку вход в очередь на время duration.
+// Повторный бан заменяет срок предыдущего.
+func (s *RedisStorage) BanPlayer(ctx context.Context, playerID string, duration time.Duration) error {
+	ctx, span := startSpan(ctx, "BanPlayer", attribute.String("player_id", playerID))
+	defer span.End()
+
+	bannedUntil := time.Now().Add(duration)
+	if err := s.client.Set(ctx, s.banKey(playerID), bannedUntil.Unix(), duration).Err(); err != nil {
+		return fmt.Errorf("failed to ban player: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("Player banned",
+		zap.String("player_id", playerID),
+		zap.Duration("duration", duration),
+		zap.Time("banned_until", bannedUntil),
+	)
+
+	return nil
+}
+
+// IsBanned проверяет, действует ли бан игрока
+func (s *RedisStorage) IsBanned(ctx context.Context, playerID string) (bool, error) {
+	ctx, span := startSpan(ctx, "IsBanned", attribute.String("player_id", playerID))
+	defer span.End()
+
+	n, err := s.client.Exists(ctx, s.banKey(playerID)).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check ban: %w", err)
+	}
+	return n > 0, nil
+}
+
+// GetBanExpiry возвращает время окончания бана игрока (нулевое, если бана нет)
+func (s *RedisStorage) GetBanExpiry(ctx context.Context, playerID string) (time.Time, error) {
+	ctx, span := startSpan(ctx, "GetBanExpiry", attribute.String("player_id", playerID))
+	defer span.End()
+
+	unix, err := s.client.Get(ctx, s.banKey(playerID)).Int64()
+	if err == redis.Nil {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get ban: %w", err)
+	}
+	return time.Unix(unix, 0), nil
+}
+
+// banKey возвращает ключ бана игрока
+func (s *RedisStorage) banKey(playerID string) string {
+	return fmt.Sprintf("ban:%s", playerID)
+}
@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	// dlqKey список групп, матч которых не удалось сохранить
+	dlqKey = "dlq:matches"
+
+	// dlqLimit максимальное количество хранимых записей; старые вытесняются
+	dlqLimit = 1000
+)
+
+// ErrDLQEntryNotFound возвращается, если записи с таким индексом нет или она уже изменилась
+var ErrDLQEntryNotFound = errors.New("dlq entry not found")
+
+// AddToDLQ добавляет группу в конец dlq:matches с причиной ошибки. Хранятся последние 1000 записей.
+func (s *RedisStorage) AddToDLQ(ctx context.Context, region, gameMode string, group []*models.Player, reason string) error {
+	ctx, span := startSpan(ctx, "AddToDLQ",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	entryJSON, err := json.Marshal(newDLQEntry(region, gameMode, group, reason))
+	if err != nil {
+		return fmt.Errorf("failed to marshal dlq entry: %w", err)
+	}
+
+	pipe := s.client.TxPipeline()
+	pipe.RPush(ctx, dlqKey, entryJSON)
+	pipe.LTrim(ctx, dlqKey, -dlqLimit, -1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to add group to dlq: %w", err)
+	}
+	return nil
+}
+
+// GetDLQ возвращает записи dlq:matches от старых к новым. Нечитаемые записи пропускаются.
+func (s *RedisStorage) GetDLQ(ctx context.Context) ([]*models.DLQEntry, error) {
+	ctx, span := startSpan(ctx, "GetDLQ")
+	defer span.End()
+
+	items, err := s.client.LRange(ctx, dlqKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dlq: %w", err)
+	}
+
+	entries := make([]*models.DLQEntry, 0, len(items))
+	for _, item := range items {
+		var entry models.DLQEntry
+		if err := json.Unmarshal([]byte(item), &entry); err != nil {
+			logging.FromContext(ctx).Warn("Skipping malformed dlq entry", zap.String("data", item), zap.Error(err))
+			continue
+		}
+		entries = append(entries, &entry)
+	}
+	return entries, nil
+}
+
+// GetDLQEntry возвращает запись dlq:matches по индексу или ErrDLQEntryNotFound
+func (s *RedisStorage) GetDLQEntry(ctx context.Context, index int64) (*models.DLQEntry, error) {
+	ctx, span := startSpan(ctx, "GetDLQEntry", attribute.Int64("index", index))
+	defer span.End()
+
+	item, err := s.client.LIndex(ctx, dlqKey, index).Result()
+	if err == redis.Nil {
+		return nil, ErrDLQEntryNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dlq entry: %w", err)
+	}
+
+	var entry models.DLQEntry
+	if err := json.Unmarshal([]byte(item), &entry); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal dlq entry: %w", err)
+	}
+	return &entry, nil
+}
+
+// RemoveDLQEntry удаляет запись dlq:matches с индексом index, если это все еще запись
+// entryID (индексы сдвигаются, когда старые записи вытесняются). Иначе возвращает
+// ErrDLQEntryNotFound.
+func (s *RedisStorage) RemoveDLQEntry(ctx context.Context, index int64, entryID string) error {
+	ctx, span := startSpan(ctx, "RemoveDLQEntry", attribute.Int64("index", index))
+	defer span.End()
+
+	item, err := s.client.LIndex(ctx, dlqKey, index).Result()
+	if err == redis.Nil {
+		return ErrDLQEntryNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get dlq entry: %w", err)
+	}
+
+	var entry models.DLQEntry
+	if err := json.Unmarshal([]byte(item), &entry); err != nil || entry.ID != entryID {
+		return ErrDLQEntryNotFound
+	}
+
+	if err := s.client.LRem(ctx, dlqKey, 1, item).Err(); err != nil {
+		return fmt.Errorf("failed to remove dlq entry: %w", err)
+	}
+	return nil
+}
+
+// newDLQEntry создает запись DLQ для группы
+func newDLQEntry(region, gameMode string, group []*models.Player, reason string) *models.DLQEntry {
+	return &models.DLQEntry{
+		ID:       uuid.New().String(),
+		Region:   region,
+		GameMode: gameMode,
+		Players:  group,
+		Reason:   reason,
+		FailedAt: time.Now().UTC(),
+	}
+}
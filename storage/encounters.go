@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// RecordEncounter запоминает, что игроки сыграли друг против друга. Каждый игрок попадает
+// в множество недавних соперников другого; срок жизни множества продлевается до ttl.
+func (s *RedisStorage) RecordEncounter(ctx context.Context, p1ID, p2ID string, ttl time.Duration) error {
+	ctx, span := startSpan(ctx, "RecordEncounter",
+		attribute.String("player_id", p1ID),
+		attribute.String("opponent_id", p2ID),
+	)
+	defer span.End()
+
+	pipe := s.client.TxPipeline()
+	pipe.SAdd(ctx, s.encountersKey(p1ID), p2ID)
+	pipe.Expire(ctx, s.encountersKey(p1ID), ttl)
+	pipe.SAdd(ctx, s.encountersKey(p2ID), p1ID)
+	pipe.Expire(ctx, s.encountersKey(p2ID), ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record encounter: %w", err)
+	}
+	return nil
+}
+
+// HaveRecentlyMet проверяет, играли ли игроки друг против друга за время жизни журнала встреч
+func (s *RedisStorage) HaveRecentlyMet(ctx context.Context, p1ID, p2ID string) (bool, error) {
+	ctx, span := startSpan(ctx, "HaveRecentlyMet",
+		attribute.String("player_id", p1ID),
+		attribute.String("opponent_id", p2ID),
+	)
+	defer span.End()
+
+	met, err := s.client.SIsMember(ctx, s.encountersKey(p1ID), p2ID).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check encounter: %w", err)
+	}
+	return met, nil
+}
+
+// encountersKey возвращает ключ множества недавних соперников игрока
+func (s *RedisStorage) encountersKey(playerID string) string {
+	return fmt.Sprintf("encounters:%s", playerID)
+}
@@ -0,0 +1,432 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+const (
+	matchExpiryStream         = "stream:match:expiry" // Поток с записями {matchID, expiresAt}
+	matchExpiryGroup          = "expiry-checker"      // Группа потребителей потока
+	matchExpiryConsumerPrefix = "expiry-checker-"     // Префикс имени потребителя; у каждого процесса свой потребитель
+	matchExpiryMaxLen         = 100000                // Приблизительный предел длины потока
+	matchExpiryBatch          = 100                   // Количество записей за одно чтение
+	matchExpiryBlock          = 5 * time.Second       // Время ожидания новых записей
+	matchExpiryReclaim        = time.Minute           // Период проверки зависших записей
+	matchExpiryRetryDelay     = 30 * time.Second      // Пауза перед повторной обработкой после ошибки
+	matchExpiryMaxDeliveries  = 5                     // Сколько раз запись обрабатывается, прежде чем будет отброшена
+)
+
+// matchExpiresAt возвращает время, до которого матч выдается игрокам: Match.ExpiresAt,
+// а для матчей, сохраненных без него, — from + MatchTTL
+func matchExpiresAt(match *models.Match, from time.Time) time.Time {
+	if match.ExpiresAt.IsZero() {
+		return from.Add(MatchTTL)
+	}
+	return match.ExpiresAt
+}
+
+// matchKeyTTL возвращает срок жизни ключей match:{playerID} от now до истечения матча.
+// Срок не короче секунды: нулевой TTL в Redis означает ключ без срока.
+func matchKeyTTL(expiresAt, now time.Time) time.Duration {
+	if ttl := expiresAt.Sub(now); ttl > time.Second {
+		return ttl
+	}
+	return time.Second
+}
+
+// MatchExpiry запись об истечении матча: созданного или ожидающего подтверждения
+type MatchExpiry struct {
+	MatchID  string // ID матча или PendingID ожидающего матча
+	Pending  bool   // Истек срок подтверждения ожидающего матча
+	Region   string // Регион и режим ожидающего матча; у созданного матча не заполняются
+	GameMode string
+}
+
+// MatchExpiryHandler вызывается для каждого матча, срок которого истек.
+// Если обработчик возвращает ошибку, запись не подтверждается и будет обработана повторно.
+type MatchExpiryHandler func(ctx context.Context, expiry MatchExpiry) error
+
+// appendMatchExpiry добавляет созданный матч в поток истечения
+func (s *RedisStorage) appendMatchExpiry(ctx context.Context, matchID string, expiresAt time.Time) error {
+	return s.appendExpiry(ctx, MatchExpiry{MatchID: matchID}, expiresAt)
+}
+
+// appendPendingExpiry добавляет в поток истечения срок подтверждения ожидающего матча
+func (s *RedisStorage) appendPendingExpiry(ctx context.Context, pending *models.PendingMatch) error {
+	return s.appendExpiry(ctx, MatchExpiry{
+		MatchID:  pending.PendingID,
+		Pending:  true,
+		Region:   pending.Region,
+		GameMode: pending.GameMode,
+	}, pending.ExpiresAt)
+}
+
+// appendExpiry добавляет запись в поток истечения
+func (s *RedisStorage) appendExpiry(ctx context.Context, expiry MatchExpiry, expiresAt time.Time) error {
+	values := map[string]interface{}{
+		"matchID":   expiry.MatchID,
+		"expiresAt": expiresAt.Unix(),
+	}
+	if expiry.Pending {
+		values["pending"] = "1"
+		values["region"] = expiry.Region
+		values["gameMode"] = expiry.GameMode
+	}
+	return s.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: matchExpiryStream,
+		MaxLen: matchExpiryMaxLen,
+		Approx: true,
+		Values: values,
+	}).Err()
+}
+
+// matchExpiryConsumerName возвращает имя потребителя этого процесса. Раздельные
+// потребители нужны, чтобы записи упавшей реплики переназначались живым.
+func matchExpiryConsumerName() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s%s-%d", matchExpiryConsumerPrefix, host, os.Getpid())
+}
+
+// WatchForMatchExpiry читает поток истечения матчей через группу потребителей
+// и вызывает handler для матчей, срок которых прошел. Блокируется до отмены ctx.
+//
+// Записи подтверждаются (XACK) только после успешной обработки, поэтому доставка
+// происходит как минимум один раз. Еще не истекшие и необработанные записи остаются
+// в списке ожидающих потребителя; на каждом проходе он просматривается через XPENDING
+// и XRANGE, которые, в отличие от повторного XREADGROUP с ID "0", не сбрасывают
+// время простоя записей. После ошибки запись повторяется не раньше чем через
+// matchExpiryRetryDelay, а после matchExpiryMaxDeliveries попыток отбрасывается.
+// Записи, которые простаивают без подтверждения дольше 2×MatchTTL (например,
+// у потребителя упавшей реплики), переназначаются этому потребителю.
+func (s *RedisStorage) WatchForMatchExpiry(ctx context.Context, handler MatchExpiryHandler) error {
+	err := s.client.XGroupCreateMkStream(ctx, matchExpiryStream, matchExpiryGroup, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create expiry consumer group: %w", err)
+	}
+
+	consumer := matchExpiryConsumerName()
+	lastReclaim := time.Now()
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// Ждем новые записи
+		if err := s.readMatchExpiry(ctx, consumer, matchExpiryBlock, handler); err != nil && ctx.Err() == nil {
+			logging.FromContext(ctx).Warn("Failed to read match expiry stream", zap.Error(err))
+			// Не крутимся в цикле, если Redis недоступен
+			select {
+			case <-time.After(matchExpiryBlock):
+			case <-ctx.Done():
+			}
+		}
+
+		// Перепроверяем ранее доставленные, но не подтвержденные записи
+		if err := s.processPendingMatchExpiry(ctx, consumer, handler); err != nil && ctx.Err() == nil {
+			logging.FromContext(ctx).Warn("Failed to process pending match expiry entries", zap.Error(err))
+		}
+
+		if time.Since(lastReclaim) >= matchExpiryReclaim {
+			if err := s.reclaimStaleMatchExpiry(ctx, consumer); err != nil && ctx.Err() == nil {
+				logging.FromContext(ctx).Warn("Failed to reclaim stale match expiry entries", zap.Error(err))
+			}
+			lastReclaim = time.Now()
+		}
+	}
+}
+
+// readMatchExpiry читает новые записи группы и обрабатывает их.
+// Отрицательный block отключает ожидание.
+func (s *RedisStorage) readMatchExpiry(ctx context.Context, consumer string, block time.Duration, handler MatchExpiryHandler) error {
+	streams, err := s.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    matchExpiryGroup,
+		Consumer: consumer,
+		Streams:  []string{matchExpiryStream, ">"},
+		Count:    matchExpiryBatch,
+		Block:    block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, stream := range streams {
+		for _, msg := range stream.Messages {
+			s.processMatchExpiry(ctx, consumer, msg, 1, handler)
+		}
+	}
+
+	return nil
+}
+
+// processPendingMatchExpiry проходит по списку ожидающих записей потребителя и
+// обрабатывает истекшие. Записи читаются через XRANGE, поэтому время простоя и
+// счетчик доставок меняются только после неудачной обработки.
+func (s *RedisStorage) processPendingMatchExpiry(ctx context.Context, consumer string, handler MatchExpiryHandler) error {
+	start := "-"
+	for {
+		pending, err := s.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+			Stream:   matchExpiryStream,
+			Group:    matchExpiryGroup,
+			Consumer: consumer,
+			Start:    start,
+			End:      "+",
+			Count:    matchExpiryBatch,
+		}).Result()
+		if err != nil {
+			return err
+		}
+
+		// Запись после неудачной обработки ждет matchExpiryRetryDelay
+		due := make([]redis.XPendingExt, 0, len(pending))
+		for _, p := range pending {
+			if p.RetryCount <= 1 || p.Idle >= matchExpiryRetryDelay {
+				due = append(due, p)
+			}
+		}
+
+		if len(due) > 0 {
+			pipe := s.client.Pipeline()
+			cmds := make([]*redis.XMessageSliceCmd, len(due))
+			for i, p := range due {
+				cmds[i] = pipe.XRange(ctx, matchExpiryStream, p.ID, p.ID)
+			}
+			if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+				return err
+			}
+			for i, p := range due {
+				msgs, err := cmds[i].Result()
+				if err != nil {
+					return err
+				}
+				if len(msgs) == 0 {
+					// Запись вытеснена из потока по MAXLEN: обработать ее уже нельзя
+					s.ackMatchExpiry(ctx, p.ID)
+					continue
+				}
+				s.processMatchExpiry(ctx, consumer, msgs[0], p.RetryCount, handler)
+			}
+		}
+
+		if len(pending) < matchExpiryBatch {
+			return nil
+		}
+		start = nextStreamID(pending[len(pending)-1].ID)
+	}
+}
+
+// nextStreamID возвращает ID, следующий за id в потоке: начало следующей страницы XPENDING
+func nextStreamID(id string) string {
+	ms, seq, ok := strings.Cut(id, "-")
+	if !ok {
+		return id
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return id
+	}
+	return fmt.Sprintf("%s-%d", ms, n+1)
+}
+
+// parseMatchExpiry разбирает запись потока. Второе значение равно false для
+// поврежденной записи.
+func parseMatchExpiry(msg redis.XMessage) (MatchExpiry, int64, bool) {
+	matchID, _ := msg.Values["matchID"].(string)
+	expiresRaw, _ := msg.Values["expiresAt"].(string)
+	expiresAt, err := strconv.ParseInt(expiresRaw, 10, 64)
+	if matchID == "" || err != nil {
+		return MatchExpiry{}, 0, false
+	}
+
+	expiry := MatchExpiry{MatchID: matchID}
+	if pending, _ := msg.Values["pending"].(string); pending == "1" {
+		expiry.Pending = true
+		expiry.Region, _ = msg.Values["region"].(string)
+		expiry.GameMode, _ = msg.Values["gameMode"].(string)
+		if expiry.Region == "" || expiry.GameMode == "" {
+			return MatchExpiry{}, 0, false
+		}
+	}
+	return expiry, expiresAt, true
+}
+
+// processMatchExpiry обрабатывает одну запись потока, доставленную deliveries раз,
+// и подтверждает ее при успехе. После неудачи запись переназначается этому же
+// потребителю (XCLAIM увеличивает счетчик доставок и сбрасывает время простоя),
+// а после matchExpiryMaxDeliveries попыток подтверждается без обработки.
+func (s *RedisStorage) processMatchExpiry(ctx context.Context, consumer string, msg redis.XMessage, deliveries int64, handler MatchExpiryHandler) {
+	expiry, expiresAt, ok := parseMatchExpiry(msg)
+	if !ok {
+		// Поврежденную запись повторно обрабатывать бессмысленно
+		logging.FromContext(ctx).Warn("Dropping malformed match expiry entry",
+			zap.String("entry_id", msg.ID),
+			zap.Any("values", msg.Values),
+		)
+		s.ackMatchExpiry(ctx, msg.ID)
+		return
+	}
+
+	if time.Now().Unix() < expiresAt {
+		return // Еще не истек, останется в списке ожидающих
+	}
+
+	err := handler(ctx, expiry)
+	if err == nil {
+		s.ackMatchExpiry(ctx, msg.ID)
+		return
+	}
+
+	if deliveries >= matchExpiryMaxDeliveries {
+		logging.FromContext(ctx).Error("Dropping match expiry entry after repeated failures",
+			zap.String("match_id", expiry.MatchID),
+			zap.Bool("pending", expiry.Pending),
+			zap.Int64("deliveries", deliveries),
+			zap.Error(err),
+		)
+		s.ackMatchExpiry(ctx, msg.ID)
+		return
+	}
+
+	logging.FromContext(ctx).Warn("Failed to handle expired match",
+		zap.String("match_id", expiry.MatchID),
+		zap.Bool("pending", expiry.Pending),
+		zap.Int64("deliveries", deliveries),
+		zap.Error(err),
+	)
+	if err := s.client.XClaim(ctx, &redis.XClaimArgs{
+		Stream:   matchExpiryStream,
+		Group:    matchExpiryGroup,
+		Consumer: consumer,
+		Messages: []string{msg.ID},
+	}).Err(); err != nil {
+		logging.FromContext(ctx).Warn("Failed to count match expiry delivery",
+			zap.String("entry_id", msg.ID),
+			zap.Error(err),
+		)
+	}
+}
+
+// ackMatchExpiry подтверждает обработку записи
+func (s *RedisStorage) ackMatchExpiry(ctx context.Context, entryID string) {
+	if err := s.client.XAck(ctx, matchExpiryStream, matchExpiryGroup, entryID).Err(); err != nil {
+		logging.FromContext(ctx).Warn("Failed to ack match expiry entry",
+			zap.String("entry_id", entryID),
+			zap.Error(err),
+		)
+	}
+}
+
+// reclaimStaleMatchExpiry переназначает потребителю записи, не подтвержденные дольше
+// 2×MatchTTL. Счетчик доставок при этом не меняется (JUSTID), и записи обрабатываются
+// при следующем проходе processPendingMatchExpiry.
+func (s *RedisStorage) reclaimStaleMatchExpiry(ctx context.Context, consumer string) error {
+	minIdle := 2 * MatchTTL
+
+	pending, err := s.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: matchExpiryStream,
+		Group:  matchExpiryGroup,
+		Idle:   minIdle,
+		Start:  "-",
+		End:    "+",
+		Count:  matchExpiryBatch,
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(pending))
+	for _, p := range pending {
+		if p.Consumer != consumer {
+			ids = append(ids, p.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if err := s.client.XClaimJustID(ctx, &redis.XClaimArgs{
+		Stream:   matchExpiryStream,
+		Group:    matchExpiryGroup,
+		Consumer: consumer,
+		MinIdle:  minIdle,
+		Messages: ids,
+	}).Err(); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).Info("Reclaimed stale match expiry entries",
+		zap.Int("count", len(ids)),
+	)
+
+	return nil
+}
+
+// expiredMatchScanCount размер пачки SCAN при поиске истекших матчей
+const expiredMatchScanCount = 200
+
+// ScanExpiredMatches обходит записи матчей match:id:* командой SCAN и возвращает матчи,
+// у которых ExpiresAt уже прошел. Записи хранятся matchRecordTTL (сутки), поэтому
+// возвращаются матчи, истекшие за последние сутки; матчи без ExpiresAt пропускаются.
+func (s *RedisStorage) ScanExpiredMatches(ctx context.Context) ([]*models.Match, error) {
+	ctx, span := startSpan(ctx, "ScanExpiredMatches")
+	defer span.End()
+
+	now := time.Now()
+	seen := make(map[string]bool)
+	expired := make([]*models.Match, 0)
+	err := s.scanKeys(ctx, s.matchByIDKey("*"), expiredMatchScanCount, func(keys []string) error {
+		pipe := s.client.Pipeline()
+		cmds := make([]*redis.StringCmd, 0, len(keys))
+		for _, key := range keys {
+			if seen[key] {
+				continue // SCAN может вернуть ключ несколько раз
+			}
+			seen[key] = true
+			cmds = append(cmds, pipe.Get(ctx, key))
+		}
+		if len(cmds) == 0 {
+			return nil
+		}
+		if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+			return fmt.Errorf("failed to read match records: %w", err)
+		}
+
+		for _, cmd := range cmds {
+			data, err := cmd.Result()
+			if err != nil {
+				continue // Запись истекла между SCAN и GET
+			}
+			var match models.Match
+			if err := json.Unmarshal([]byte(data), &match); err != nil {
+				logging.FromContext(ctx).Warn("Skipping malformed match record", zap.Error(err))
+				continue
+			}
+			if !match.ExpiresAt.IsZero() && match.ExpiresAt.Before(now) {
+				expired = append(expired, &match)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan expired matches: %w", err)
+	}
+
+	return expired, nil
+}
@@ -0,0 +1,335 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"chrono-matchmaking/models"
+	"github.com/go-redis/redis/v8"
+)
+
+// newTestExpiryStream возвращает хранилище с созданной группой потребителей потока истечения
+func newTestExpiryStream(t *testing.T) (*RedisStorage, *redis.Client) {
+	t.Helper()
+	s, raw := newTestRedisStorage(t)
+	if err := raw.XGroupCreateMkStream(context.Background(), matchExpiryStream, matchExpiryGroup, "0").Err(); err != nil {
+		t.Fatalf("XGroupCreateMkStream: %v", err)
+	}
+	return s, raw
+}
+
+// pendingExpiryCount возвращает число доставленных, но не подтвержденных записей потока
+func pendingExpiryCount(t *testing.T, raw *redis.Client) int64 {
+	t.Helper()
+	pending, err := raw.XPending(context.Background(), matchExpiryStream, matchExpiryGroup).Result()
+	if err != nil {
+		t.Fatalf("XPending: %v", err)
+	}
+	return pending.Count
+}
+
+// testExpiryConsumer потребитель потока истечения в тестах
+const testExpiryConsumer = "test-consumer"
+
+// ageExpiryEntries выставляет всем недоставленным записям время простоя idle,
+// сохраняя владельца и счетчик доставок
+func ageExpiryEntries(t *testing.T, raw *redis.Client, idle time.Duration) {
+	t.Helper()
+	ctx := context.Background()
+	pending, err := raw.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: matchExpiryStream,
+		Group:  matchExpiryGroup,
+		Start:  "-",
+		End:    "+",
+		Count:  matchExpiryBatch,
+	}).Result()
+	if err != nil {
+		t.Fatalf("XPendingExt: %v", err)
+	}
+	for _, p := range pending {
+		err := raw.Do(ctx, "XCLAIM", matchExpiryStream, matchExpiryGroup, p.Consumer, 0, p.ID,
+			"IDLE", idle.Milliseconds(), "RETRYCOUNT", p.RetryCount, "JUSTID").Err()
+		if err != nil {
+			t.Fatalf("XCLAIM IDLE: %v", err)
+		}
+	}
+}
+
+// pendingExpiryEntry возвращает единственную недоставленную запись потока
+func pendingExpiryEntry(t *testing.T, raw *redis.Client) redis.XPendingExt {
+	t.Helper()
+	pending, err := raw.XPendingExt(context.Background(), &redis.XPendingExtArgs{
+		Stream: matchExpiryStream,
+		Group:  matchExpiryGroup,
+		Start:  "-",
+		End:    "+",
+		Count:  10,
+	}).Result()
+	if err != nil || len(pending) != 1 {
+		t.Fatalf("XPendingExt = %v, %v; want one entry", pending, err)
+	}
+	return pending[0]
+}
+
+// recordingHandler запоминает переданные матчи и возвращает ошибку, пока fail = true
+type recordingHandler struct {
+	handled []string
+	fail    bool
+}
+
+func (h *recordingHandler) handle(ctx context.Context, expiry MatchExpiry) error {
+	h.handled = append(h.handled, expiry.MatchID)
+	if h.fail {
+		return fmt.Errorf("temporary failure")
+	}
+	return nil
+}
+
+func TestMatchExpiryAckedAfterHandling(t *testing.T) {
+	ctx := context.Background()
+	s, raw := newTestExpiryStream(t)
+
+	if err := s.appendMatchExpiry(ctx, "expired", time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("appendMatchExpiry: %v", err)
+	}
+	handler := &recordingHandler{}
+	if err := s.readMatchExpiry(ctx, testExpiryConsumer, -1, handler.handle); err != nil {
+		t.Fatalf("readMatchExpiry: %v", err)
+	}
+	if len(handler.handled) != 1 || handler.handled[0] != "expired" {
+		t.Fatalf("handled = %v, want [expired]", handler.handled)
+	}
+	if count := pendingExpiryCount(t, raw); count != 0 {
+		t.Errorf("pending entries after successful handling = %d, want 0", count)
+	}
+
+	// Подтвержденная запись больше не обрабатывается
+	if err := s.processPendingMatchExpiry(ctx, testExpiryConsumer, handler.handle); err != nil {
+		t.Fatalf("processPendingMatchExpiry: %v", err)
+	}
+	if len(handler.handled) != 1 {
+		t.Errorf("acked entry handled again: %v", handler.handled)
+	}
+}
+
+func TestMatchExpiryReprocessedAfterFailure(t *testing.T) {
+	ctx := context.Background()
+	s, raw := newTestExpiryStream(t)
+
+	if err := s.appendMatchExpiry(ctx, "expired", time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("appendMatchExpiry: %v", err)
+	}
+	handler := &recordingHandler{fail: true}
+	if err := s.readMatchExpiry(ctx, testExpiryConsumer, -1, handler.handle); err != nil {
+		t.Fatalf("readMatchExpiry: %v", err)
+	}
+	if entry := pendingExpiryEntry(t, raw); entry.RetryCount != 2 {
+		t.Fatalf("delivery count after failed handling = %d, want 2", entry.RetryCount)
+	}
+
+	// До matchExpiryRetryDelay запись не повторяется
+	handler.fail = false
+	if err := s.processPendingMatchExpiry(ctx, testExpiryConsumer, handler.handle); err != nil {
+		t.Fatalf("processPendingMatchExpiry: %v", err)
+	}
+	if len(handler.handled) != 1 {
+		t.Fatalf("entry retried before the retry delay: %v", handler.handled)
+	}
+
+	ageExpiryEntries(t, raw, matchExpiryRetryDelay)
+	if err := s.processPendingMatchExpiry(ctx, testExpiryConsumer, handler.handle); err != nil {
+		t.Fatalf("processPendingMatchExpiry: %v", err)
+	}
+	if want := []string{"expired", "expired"}; !reflect.DeepEqual(handler.handled, want) {
+		t.Errorf("handled = %v, want %v", handler.handled, want)
+	}
+	if count := pendingExpiryCount(t, raw); count != 0 {
+		t.Errorf("pending entries after retry = %d, want 0", count)
+	}
+}
+
+func TestMatchExpiryDropsEntryAfterMaxDeliveries(t *testing.T) {
+	ctx := context.Background()
+	s, raw := newTestExpiryStream(t)
+
+	if err := s.appendMatchExpiry(ctx, "poison", time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("appendMatchExpiry: %v", err)
+	}
+	handler := &recordingHandler{fail: true}
+	if err := s.readMatchExpiry(ctx, testExpiryConsumer, -1, handler.handle); err != nil {
+		t.Fatalf("readMatchExpiry: %v", err)
+	}
+	for i := 0; i < 2*matchExpiryMaxDeliveries; i++ {
+		ageExpiryEntries(t, raw, matchExpiryRetryDelay)
+		if err := s.processPendingMatchExpiry(ctx, testExpiryConsumer, handler.handle); err != nil {
+			t.Fatalf("processPendingMatchExpiry: %v", err)
+		}
+	}
+	if len(handler.handled) != matchExpiryMaxDeliveries {
+		t.Errorf("handler calls = %d, want %d", len(handler.handled), matchExpiryMaxDeliveries)
+	}
+	if count := pendingExpiryCount(t, raw); count != 0 {
+		t.Errorf("pending entries = %d, want the entry dropped", count)
+	}
+}
+
+func TestMatchExpiryWaitsForExpiresAt(t *testing.T) {
+	ctx := context.Background()
+	s, raw := newTestExpiryStream(t)
+
+	if err := s.appendMatchExpiry(ctx, "active", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("appendMatchExpiry: %v", err)
+	}
+	handler := &recordingHandler{}
+	if err := s.readMatchExpiry(ctx, testExpiryConsumer, -1, handler.handle); err != nil {
+		t.Fatalf("readMatchExpiry: %v", err)
+	}
+	if len(handler.handled) != 0 {
+		t.Errorf("handler called for an active match: %v", handler.handled)
+	}
+	if count := pendingExpiryCount(t, raw); count != 1 {
+		t.Errorf("pending entries = %d, want the active match kept pending", count)
+	}
+}
+
+// TestMatchExpiryPendingScanKeepsIdleTime проверяет, что просмотр еще не истекших
+// записей не сбрасывает их время простоя: иначе reclaimStaleMatchExpiry не сработает
+func TestMatchExpiryPendingScanKeepsIdleTime(t *testing.T) {
+	ctx := context.Background()
+	s, raw := newTestExpiryStream(t)
+
+	if err := s.appendMatchExpiry(ctx, "active", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("appendMatchExpiry: %v", err)
+	}
+	handler := &recordingHandler{}
+	if err := s.readMatchExpiry(ctx, testExpiryConsumer, -1, handler.handle); err != nil {
+		t.Fatalf("readMatchExpiry: %v", err)
+	}
+	ageExpiryEntries(t, raw, time.Hour)
+	if err := s.processPendingMatchExpiry(ctx, testExpiryConsumer, handler.handle); err != nil {
+		t.Fatalf("processPendingMatchExpiry: %v", err)
+	}
+
+	entry := pendingExpiryEntry(t, raw)
+	if entry.Idle < time.Hour {
+		t.Errorf("idle time after pending scan = %v, want at least 1h", entry.Idle)
+	}
+	if entry.RetryCount != 1 {
+		t.Errorf("delivery count after pending scan = %d, want 1", entry.RetryCount)
+	}
+}
+
+func TestMatchExpiryDropsMalformedEntry(t *testing.T) {
+	ctx := context.Background()
+	s, raw := newTestExpiryStream(t)
+
+	raw.XAdd(ctx, &redis.XAddArgs{Stream: matchExpiryStream, Values: map[string]interface{}{"matchID": "broken"}})
+	handler := &recordingHandler{}
+	if err := s.readMatchExpiry(ctx, testExpiryConsumer, -1, handler.handle); err != nil {
+		t.Fatalf("readMatchExpiry: %v", err)
+	}
+	if len(handler.handled) != 0 {
+		t.Errorf("handler called for a malformed entry: %v", handler.handled)
+	}
+	if count := pendingExpiryCount(t, raw); count != 0 {
+		t.Errorf("pending entries = %d, want the malformed entry acked", count)
+	}
+}
+
+// TestMatchExpiryReclaimsStaleEntries проверяет, что запись, зависшая у потребителя
+// упавшей реплики дольше 2×MatchTTL, переназначается и обрабатывается
+func TestMatchExpiryReclaimsStaleEntries(t *testing.T) {
+	ctx := context.Background()
+	s, raw := newTestExpiryStream(t)
+
+	if err := s.appendMatchExpiry(ctx, "orphaned", time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("appendMatchExpiry: %v", err)
+	}
+	streams, err := raw.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    matchExpiryGroup,
+		Consumer: "crashed-consumer",
+		Streams:  []string{matchExpiryStream, ">"},
+		Block:    -1,
+	}).Result()
+	if err != nil || len(streams) != 1 || len(streams[0].Messages) != 1 {
+		t.Fatalf("XReadGroup by another consumer = %v, %v", streams, err)
+	}
+
+	handler := &recordingHandler{}
+	if err := s.reclaimStaleMatchExpiry(ctx, testExpiryConsumer); err != nil {
+		t.Fatalf("reclaimStaleMatchExpiry: %v", err)
+	}
+	if entry := pendingExpiryEntry(t, raw); entry.Consumer != "crashed-consumer" {
+		t.Fatalf("recently delivered entry was reclaimed by %s", entry.Consumer)
+	}
+
+	// Выставляем записи время простоя больше 2×MatchTTL, как после падения процесса
+	ageExpiryEntries(t, raw, 2*MatchTTL+time.Minute)
+	if err := s.reclaimStaleMatchExpiry(ctx, testExpiryConsumer); err != nil {
+		t.Fatalf("reclaimStaleMatchExpiry: %v", err)
+	}
+	if err := s.processPendingMatchExpiry(ctx, testExpiryConsumer, handler.handle); err != nil {
+		t.Fatalf("processPendingMatchExpiry: %v", err)
+	}
+	if len(handler.handled) != 1 || handler.handled[0] != "orphaned" {
+		t.Errorf("handled = %v, want [orphaned]", handler.handled)
+	}
+	if count := pendingExpiryCount(t, raw); count != 0 {
+		t.Errorf("pending entries after reclaim = %d, want 0", count)
+	}
+}
+
+func TestPendingMatchExpiryEntry(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestExpiryStream(t)
+
+	pending := &models.PendingMatch{PendingID: "pending", Region: "EU", GameMode: "3v3", ExpiresAt: time.Now().Add(-time.Second)}
+	if err := s.appendPendingExpiry(ctx, pending); err != nil {
+		t.Fatalf("appendPendingExpiry: %v", err)
+	}
+	var handled []MatchExpiry
+	err := s.readMatchExpiry(ctx, testExpiryConsumer, -1, func(ctx context.Context, expiry MatchExpiry) error {
+		handled = append(handled, expiry)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("readMatchExpiry: %v", err)
+	}
+	want := []MatchExpiry{{MatchID: "pending", Pending: true, Region: "EU", GameMode: "3v3"}}
+	if !reflect.DeepEqual(handled, want) {
+		t.Errorf("handled = %+v, want %+v", handled, want)
+	}
+}
+
+func TestNextStreamID(t *testing.T) {
+	tests := map[string]string{
+		"1700000000000-0": "1700000000000-1",
+		"1700000000000-9": "1700000000000-10",
+		"broken":          "broken",
+	}
+	for id, want := range tests {
+		if got := nextStreamID(id); got != want {
+			t.Errorf("nextStreamID(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
+
+func TestSaveMatchAppendsExpiryEntry(t *testing.T) {
+	ctx := context.Background()
+	s, raw := newTestRedisStorage(t)
+
+	match := testMatch("match", "a", "b")
+	if err := s.SaveMatch(ctx, match); err != nil {
+		t.Fatalf("SaveMatch: %v", err)
+	}
+	entries, err := raw.XRange(ctx, matchExpiryStream, "-", "+").Result()
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expiry stream = %v, %v; want one entry", entries, err)
+	}
+	if entries[0].Values["matchID"] != "match" || entries[0].Values["expiresAt"] != fmt.Sprint(match.ExpiresAt.Unix()) {
+		t.Errorf("expiry entry = %v", entries[0].Values)
+	}
+}
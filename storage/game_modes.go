@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// gameModesKey ключ режимов игры и размеров их матчей
+const gameModesKey = "config:game_modes"
+
+// GetGameModes возвращает режимы игры из config:game_modes: режим -> количество
+// игроков в матче. Если режимы не заданы, возвращает nil.
+func (s *RedisStorage) GetGameModes(ctx context.Context) (map[string]int, error) {
+	ctx, span := startSpan(ctx, "GetGameModes")
+	defer span.End()
+
+	modesJSON, err := s.client.Get(ctx, gameModesKey).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get game modes: %w", err)
+	}
+
+	var modes map[string]int
+	if err := json.Unmarshal([]byte(modesJSON), &modes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal game modes: %w", err)
+	}
+	return modes, nil
+}
+
+// SetGameModes сохраняет режимы игры в config:game_modes без срока хранения
+func (s *RedisStorage) SetGameModes(ctx context.Context, modes map[string]int) error {
+	ctx, span := startSpan(ctx, "SetGameModes")
+	defer span.End()
+
+	modesJSON, err := json.Marshal(modes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal game modes: %w", err)
+	}
+
+	if err := s.client.Set(ctx, gameModesKey, modesJSON, 0).Err(); err != nil {
+		return fmt.Errorf("failed to save game modes: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// RefreshPlayerTTL продлевает ключ player:{id} на ttl. Если ключ уже истек,
+// возвращает ErrPlayerNotInQueue
+func (s *RedisStorage) RefreshPlayerTTL(ctx context.Context, playerID string, ttl time.Duration) error {
+	ctx, span := startSpan(ctx, "RefreshPlayerTTL", attribute.String("player_id", playerID))
+	defer span.End()
+
+	ok, err := s.client.Expire(ctx, s.playerKey(playerID), ttl).Result()
+	if err != nil {
+		return fmt.Errorf("failed to refresh player TTL: %w", err)
+	}
+	if !ok {
+		return ErrPlayerNotInQueue
+	}
+	return nil
+}
+
+// GetExpiringPlayers обходит ключи player:* и возвращает ID игроков, чей ключ истечет
+// раньше чем через threshold, вместе с оставшимся временем
+func (s *RedisStorage) GetExpiringPlayers(ctx context.Context, threshold time.Duration) (map[string]time.Duration, error) {
+	ctx, span := startSpan(ctx, "GetExpiringPlayers")
+	defer span.End()
+
+	prefix := s.playerKey("")
+	expiring := make(map[string]time.Duration)
+	err := s.scanKeys(ctx, s.playerKey("*"), playerScanCount, func(keys []string) error {
+		pipe := s.client.Pipeline()
+		cmds := make([]*redis.DurationCmd, len(keys))
+		for i, key := range keys {
+			cmds[i] = pipe.TTL(ctx, key)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return fmt.Errorf("failed to get player TTLs: %w", err)
+		}
+
+		// Отрицательный TTL: ключ без срока (-1) или уже удален (-2)
+		for i, cmd := range cmds {
+			if ttl := cmd.Val(); ttl >= 0 && ttl < threshold {
+				expiring[strings.TrimPrefix(keys[i], prefix)] = ttl
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan players: %w", err)
+	}
+
+	return expiring, nil
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// IdempotencyKeyTTL время, в течение которого повтор запроса с тем же ключом идемпотентности
+// возвращает исходный результат
+const IdempotencyKeyTTL = 5 * time.Minute
+
+// ErrIdempotencyKeyNotFound возвращается, если ключ идемпотентности не использовался или истек
+var ErrIdempotencyKeyNotFound = errors.New("idempotency key not found")
+
+// SaveIdempotencyKey запоминает игрока, вставшего в очередь по ключу идемпотентности,
+// на IdempotencyKeyTTL. Уже сохраненный ключ не перезаписывается; в этом случае
+// возвращается false.
+func (s *RedisStorage) SaveIdempotencyKey(ctx context.Context, key, playerID string) (bool, error) {
+	ctx, span := startSpan(ctx, "SaveIdempotencyKey")
+	defer span.End()
+
+	saved, err := s.client.SetNX(ctx, s.idempotencyKey(key), playerID, IdempotencyKeyTTL).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to save idempotency key: %w", err)
+	}
+	return saved, nil
+}
+
+// GetIdempotencyKey возвращает ID игрока, сохраненный по ключу идемпотентности,
+// или ErrIdempotencyKeyNotFound
+func (s *RedisStorage) GetIdempotencyKey(ctx context.Context, key string) (string, error) {
+	ctx, span := startSpan(ctx, "GetIdempotencyKey")
+	defer span.End()
+
+	playerID, err := s.client.Get(ctx, s.idempotencyKey(key)).Result()
+	if err == redis.Nil {
+		return "", ErrIdempotencyKeyNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	return playerID, nil
+}
+
+// idempotencyKey возвращает ключ Redis для ключа идемпотентности клиента
+func (s *RedisStorage) idempotencyKey(key string) string {
+	return fmt.Sprintf("idempotent:%s", key)
+}
@@ -0,0 +1,165 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/scripts"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	// ErrInviteNotFound возвращается, если приглашения нет, срок его истек или матч уже собран
+	ErrInviteNotFound = errors.New("invite not found")
+
+	// ErrInviteCodeTaken возвращается, если приглашение с таким кодом уже существует
+	ErrInviteCodeTaken = errors.New("invite code already in use")
+
+	// ErrAlreadyInInvite возвращается, если игрок уже вошел в приватный матч
+	ErrAlreadyInInvite = errors.New("player already joined the invite")
+)
+
+// joinInviteScript скрипт атомарного входа в приватный матч
+var joinInviteScript = redis.NewScript(scripts.JoinInvite)
+
+// CreateInvite сохраняет приглашение в invite:{code} до ExpiresAt. Если код занят,
+// возвращается ErrInviteCodeTaken.
+func (s *RedisStorage) CreateInvite(ctx context.Context, invite *models.InviteMatch) error {
+	ctx, span := startSpan(ctx, "CreateInvite", attribute.String("invite_code", invite.InviteCode))
+	defer span.End()
+
+	inviteJSON, err := json.Marshal(invite)
+	if err != nil {
+		return fmt.Errorf("failed to marshal invite: %w", err)
+	}
+
+	created, err := s.client.SetNX(ctx, s.inviteKey(invite.InviteCode), inviteJSON, inviteTTL(invite)).Result()
+	if err != nil {
+		return fmt.Errorf("failed to create invite: %w", err)
+	}
+	if !created {
+		return ErrInviteCodeTaken
+	}
+	return nil
+}
+
+// GetInvite возвращает приглашение с уже присоединившимися игроками или ErrInviteNotFound
+func (s *RedisStorage) GetInvite(ctx context.Context, code string) (*models.InviteMatch, error) {
+	ctx, span := startSpan(ctx, "GetInvite", attribute.String("invite_code", code))
+	defer span.End()
+
+	inviteJSON, err := s.client.Get(ctx, s.inviteKey(code)).Result()
+	if err == redis.Nil {
+		return nil, ErrInviteNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invite: %w", err)
+	}
+
+	var invite models.InviteMatch
+	if err := json.Unmarshal([]byte(inviteJSON), &invite); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal invite: %w", err)
+	}
+
+	members, err := s.client.LRange(ctx, s.invitePlayersKey(code), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invite players: %w", err)
+	}
+	if invite.Players, err = unmarshalInvitePlayers(members); err != nil {
+		return nil, err
+	}
+
+	return &invite, nil
+}
+
+// JoinInvite одним Lua-скриптом добавляет игрока в приватный матч. Возвращает число
+// присоединившихся игроков; если вошел последний, возвращает и весь состав в порядке
+// входа, а приглашение удаляется, поэтому матч собирает только один вызов.
+func (s *RedisStorage) JoinInvite(ctx context.Context, invite *models.InviteMatch, player *models.Player) (int, []models.Player, error) {
+	ctx, span := startSpan(ctx, "JoinInvite",
+		attribute.String("invite_code", invite.InviteCode),
+		attribute.String("player_id", player.ID),
+	)
+	defer span.End()
+
+	playerJSON, err := json.Marshal(player)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to marshal player: %w", err)
+	}
+
+	keys := []string{
+		s.inviteKey(invite.InviteCode),
+		s.invitePlayersKey(invite.InviteCode),
+		s.inviteMembersKey(invite.InviteCode),
+	}
+	res, err := joinInviteScript.Run(ctx, s.client, keys, player.ID, playerJSON, invite.PlayersNeeded).Slice()
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to run join invite script: %w", err)
+	}
+
+	status, _ := res[0].(int64)
+	switch status {
+	case 0:
+		return 0, nil, ErrInviteNotFound
+	case -1:
+		return 0, nil, ErrAlreadyInInvite
+	case 1:
+		count, _ := res[1].(int64)
+		return int(count), nil, nil
+	}
+
+	members := make([]string, 0, len(res)-1)
+	for _, member := range res[1:] {
+		if str, ok := member.(string); ok {
+			members = append(members, str)
+		}
+	}
+	players, err := unmarshalInvitePlayers(members)
+	if err != nil {
+		return 0, nil, err
+	}
+	return len(players), players, nil
+}
+
+// unmarshalInvitePlayers разбирает JSON игроков приглашения
+func unmarshalInvitePlayers(members []string) ([]models.Player, error) {
+	players := make([]models.Player, 0, len(members))
+	for _, member := range members {
+		var player models.Player
+		if err := json.Unmarshal([]byte(member), &player); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal invite player: %w", err)
+		}
+		players = append(players, player)
+	}
+	return players, nil
+}
+
+// inviteTTL возвращает срок хранения приглашения (не меньше секунды)
+func inviteTTL(invite *models.InviteMatch) time.Duration {
+	ttl := time.Until(invite.ExpiresAt)
+	if ttl < time.Second {
+		ttl = time.Second
+	}
+	return ttl
+}
+
+// inviteKey возвращает ключ приглашения. Код в фигурных скобках — хеш-тег: в Redis
+// Cluster ключи одного приглашения лежат в одном слоте, что нужно скрипту входа.
+func (s *RedisStorage) inviteKey(code string) string {
+	return fmt.Sprintf("invite:{%s}", code)
+}
+
+// invitePlayersKey возвращает ключ списка JSON игроков приглашения
+func (s *RedisStorage) invitePlayersKey(code string) string {
+	return fmt.Sprintf("invite:{%s}:players", code)
+}
+
+// inviteMembersKey возвращает ключ набора ID игроков приглашения
+func (s *RedisStorage) inviteMembersKey(code string) string {
+	return fmt.Sprintf("invite:{%s}:members", code)
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// renewLockScript продлевает блокировку, только если она принадлежит владельцу
+var renewLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseLockScript снимает блокировку, только если она принадлежит владельцу
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// AcquireLock пытается захватить блокировку key для owner на время ttl
+func (s *RedisStorage) AcquireLock(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	ctx, span := startSpan(ctx, "AcquireLock")
+	defer span.End()
+
+	ok, err := s.client.SetNX(ctx, key, owner, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	return ok, nil
+}
+
+// RenewLock продлевает блокировку; возвращает false, если она уже принадлежит другому владельцу
+func (s *RedisStorage) RenewLock(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	ctx, span := startSpan(ctx, "RenewLock")
+	defer span.End()
+
+	res, err := renewLockScript.Run(ctx, s.client, []string{key}, owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to renew lock: %w", err)
+	}
+	return res == 1, nil
+}
+
+// ReleaseLock снимает блокировку, если она принадлежит owner
+func (s *RedisStorage) ReleaseLock(ctx context.Context, key, owner string) error {
+	ctx, span := startSpan(ctx, "ReleaseLock")
+	defer span.End()
+
+	if err := releaseLockScript.Run(ctx, s.client, []string{key}, owner).Err(); err != nil && !errors.Is(err, redis.Nil) {
+		return fmt.Errorf("failed to release lock: %w", err)
+	}
+	return nil
+}
+
+// Publish публикует сообщение в канал Redis pub/sub
+func (s *RedisStorage) Publish(ctx context.Context, channel string, payload []byte) error {
+	ctx, span := startSpan(ctx, "Publish")
+	defer span.End()
+
+	if err := s.client.Publish(ctx, channel, payload).Err(); err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", channel, err)
+	}
+	return nil
+}
+
+// Subscribe подписывается на канал Redis pub/sub. Канал сообщений закрывается после отмены ctx.
+func (s *RedisStorage) Subscribe(ctx context.Context, channel string) (<-chan []byte, error) {
+	ctx, span := startSpan(ctx, "Subscribe")
+	defer span.End()
+
+	pubsub := s.client.Subscribe(ctx, channel)
+
+	// Дожидаемся подтверждения подписки, чтобы не потерять первые сообщения
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", channel, err)
+	}
+
+	out := make(chan []byte)
+	go func() {
+		defer close(out)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				select {
+				case out <- []byte(msg.Payload):
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
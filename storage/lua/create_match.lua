@@ -0,0 +1,34 @@
+-- Выбор игроков для CreateMatchAtomic.
+--
+-- KEYS[1] — очередь региона и режима (sorted set, элементы — JSON игроков)
+-- ARGV[1] — n, сколько игроков нужно матчу
+-- ARGV[2] — префикс ключей игроков ("player:")
+--
+-- Возвращает первых n элементов очереди, на которые еще указывает ключ игрока
+-- player:{id}, или пустой список, если таких элементов меньше n. Элементы без ключа
+-- игрока (вышедшие из очереди или истекшие) пропускаются. Очередь не меняется:
+-- выбранных игроков забирает form_match.lua, который повторно проверяет, что они
+-- все еще в очереди.
+
+local n = tonumber(ARGV[1])
+local picked = {}
+local offset = 0
+
+while #picked < n do
+	local batch = redis.call('ZRANGE', KEYS[1], offset, offset + n - 1)
+	if #batch == 0 then
+		return {}
+	end
+	for _, member in ipairs(batch) do
+		local player = cjson.decode(member)
+		if redis.call('GET', ARGV[2] .. player.id) == member then
+			picked[#picked + 1] = member
+			if #picked == n then
+				break
+			end
+		end
+	end
+	offset = offset + #batch
+end
+
+return picked
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// PublishMatch публикует созданный матч в канал matchmaking:events:{region}:{gameMode}
+// для внешних сервисов (например, игровых серверов). Регион и режим передаются явно:
+// в самом матче их нет, а у межрегионального матча игроки из разных регионов.
+func (s *RedisStorage) PublishMatch(ctx context.Context, region, gameMode string, match *models.Match) error {
+	ctx, span := startSpan(ctx, "PublishMatch", attribute.String("match_id", match.MatchID))
+	defer span.End()
+
+	payload, err := json.Marshal(match)
+	if err != nil {
+		return fmt.Errorf("failed to marshal match: %w", err)
+	}
+	return s.Publish(ctx, matchEventsChannel(region, gameMode), payload)
+}
+
+// SubscribeMatches подписывается на матчи, создаваемые в регионе и режиме.
+// Канал матчей закрывается после отмены ctx.
+func (s *RedisStorage) SubscribeMatches(ctx context.Context, region, gameMode string) (<-chan *models.Match, error) {
+	ctx, span := startSpan(ctx, "SubscribeMatches",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	payloads, err := s.Subscribe(ctx, matchEventsChannel(region, gameMode))
+	if err != nil {
+		return nil, err
+	}
+	return decodeMatchEvents(ctx, payloads, func(err error) {
+		logging.FromContext(ctx).Warn("Failed to unmarshal match event", zap.Error(err))
+	}), nil
+}
+
+// decodeMatchEvents разбирает сообщения канала событий в матчи. Сообщения, которые не
+// удалось разобрать, передаются в onError и пропускаются.
+func decodeMatchEvents(ctx context.Context, payloads <-chan []byte, onError func(err error)) <-chan *models.Match {
+	out := make(chan *models.Match)
+	go func() {
+		defer close(out)
+		for payload := range payloads {
+			var match models.Match
+			if err := json.Unmarshal(payload, &match); err != nil {
+				onError(err)
+				continue
+			}
+			select {
+			case out <- &match:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// matchEventsChannel возвращает канал событий о матчах региона и режима
+func matchEventsChannel(region, gameMode string) string {
+	return fmt.Sprintf("matchmaking:events:%s:%s", region, gameMode)
+}
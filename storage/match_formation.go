@@ -0,0 +1,254 @@
+package storage
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/scripts"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// ErrMatchConflict возвращается, если кто-то из игроков матча уже покинул очередь
+var ErrMatchConflict = errors.New("match players are no longer in queue")
+
+// ErrMatchNotFound возвращается, если матч не найден или его срок хранения истек
+var ErrMatchNotFound = errors.New("match not found")
+
+// ErrNotEnoughPlayers возвращается CreateMatchAtomic, если в очереди меньше игроков, чем нужно матчу
+var ErrNotEnoughPlayers = errors.New("not enough players in queue")
+
+// formMatchScript скрипт атомарного формирования матча
+var formMatchScript = redis.NewScript(scripts.FormMatch)
+
+// createMatchLua выбирает игроков очереди для CreateMatchAtomic
+//
+//go:embed lua/create_match.lua
+var createMatchLua string
+
+// createMatchScript скрипт выбора игроков для CreateMatchAtomic
+var createMatchScript = redis.NewScript(createMatchLua)
+
+// RunAtomicMatchFormation одним Lua-скриптом проверяет, что все игроки матча еще в очереди,
+// сохраняет матч по его ID и для каждого из игроков, удаляет их из очереди и ключей игроков.
+// Игроки межрегионального матча удаляются из очередей своих регионов.
+// Если хотя бы один игрок уже удален, ничего не меняется и возвращается ErrMatchConflict.
+func (s *RedisStorage) RunAtomicMatchFormation(ctx context.Context, match *models.Match) error {
+	ctx, span := startSpan(ctx, "RunAtomicMatchFormation", attribute.String("match_id", match.MatchID))
+	defer span.End()
+
+	if len(match.Players) == 0 {
+		return fmt.Errorf("match has no players")
+	}
+
+	matchJSON, err := json.Marshal(match)
+	if err != nil {
+		return fmt.Errorf("failed to marshal match: %w", err)
+	}
+
+	// Боты не стояли в очереди: скрипт проверяет и убирает только живых игроков
+	humans := match.HumanPlayers()
+	n := len(humans)
+	keys := make([]string, 0, 1+3*n)
+	for _, p := range humans {
+		keys = append(keys, s.playerQueueKey(&p))
+	}
+	for _, p := range humans {
+		keys = append(keys, s.playerKey(p.ID))
+	}
+	for _, p := range humans {
+		keys = append(keys, s.matchKey(p.ID))
+	}
+	keys = append(keys, s.matchByIDKey(match.MatchID))
+	otherKeys, owners := s.otherQueueKeys(humans)
+	keys = append(keys, otherKeys...)
+
+	expiresAt := matchExpiresAt(match, match.CreatedAt)
+	matchTTL := matchKeyTTL(expiresAt, time.Now())
+	args := append([]interface{}{matchJSON, int64(matchTTL.Seconds()), int64(matchRecordTTL.Seconds()), len(otherKeys)}, owners...)
+	res, err := formMatchScript.Run(ctx, s.client, keys, args...).Int()
+	if err != nil {
+		return fmt.Errorf("failed to run match formation script: %w", err)
+	}
+	if res == 0 {
+		return ErrMatchConflict
+	}
+
+	s.incrementQueueLeaves(ctx, humans)
+	s.recordMatchHistory(ctx, match)
+
+	// Регистрируем матч в потоке истечения для WatchForMatchExpiry
+	if err := s.appendMatchExpiry(ctx, match.MatchID, expiresAt); err != nil {
+		logging.FromContext(ctx).Warn("Failed to append match to expiry stream",
+			zap.String("match_id", match.MatchID),
+			zap.Error(err),
+		)
+	}
+
+	logging.FromContext(ctx).Info("Match formed atomically",
+		zap.String("match_id", match.MatchID),
+		zap.Int("players_count", n),
+	)
+
+	return nil
+}
+
+// CreateMatchAtomic создает матч из первых n игроков очереди региона и режима в порядке
+// рейтинга и делит их на команды по рейтингу. Игроков выбирает скрипт
+// lua/create_match.lua, а матч сохраняет RunAtomicMatchFormation: если кого-то из них
+// параллельно забрал другой матч, очередь не меняется и возвращается ErrMatchConflict.
+// Если живых игроков в очереди меньше n, возвращается ErrNotEnoughPlayers. Алгоритм
+// подбора, проверки совместимости и подтверждение матча не применяются.
+func (s *RedisStorage) CreateMatchAtomic(ctx context.Context, region, gameMode string, n int) (*models.Match, error) {
+	ctx, span := startSpan(ctx, "CreateMatchAtomic",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	if n <= 0 {
+		return nil, fmt.Errorf("match size must be positive, got %d", n)
+	}
+
+	members, err := createMatchScript.Run(ctx, s.client, []string{s.queueKey(region, gameMode)}, n, s.playerKey("")).StringSlice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run match candidates script: %w", err)
+	}
+	if len(members) < n {
+		return nil, ErrNotEnoughPlayers
+	}
+
+	players := make([]models.Player, 0, n)
+	for _, member := range members {
+		var player models.Player
+		if err := json.Unmarshal([]byte(member), &player); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal player: %w", err)
+		}
+		players = append(players, player)
+	}
+
+	teams := models.NewBalancedTeamAssignment(players)
+	now := time.Now()
+	match := &models.Match{
+		MatchID:      fmt.Sprintf("match_%d", now.UnixNano()),
+		Players:      append(append(make([]models.Player, 0, n), teams[0]...), teams[1]...),
+		GameMode:     gameMode,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(MatchTTL),
+		Teams:        teams,
+		ServerRegion: region,
+	}
+
+	if err := s.RunAtomicMatchFormation(ctx, match); err != nil {
+		return nil, err
+	}
+	return match, nil
+}
+
+// otherQueueKeys возвращает для скриптов формирования матча очереди, из которых нужно
+// убрать игроков с несколькими режимами поиска, кроме очереди самого матча, и номер
+// игрока (с 1) для каждой из них
+func (s *RedisStorage) otherQueueKeys(players []models.Player) ([]string, []interface{}) {
+	var keys []string
+	var owners []interface{}
+	for i := range players {
+		if !players[i].IsMultiQueue() {
+			continue
+		}
+		matched := s.playerQueueKey(&players[i])
+		for _, key := range s.playerQueueKeys(&players[i]) {
+			if key != matched {
+				keys = append(keys, key)
+				owners = append(owners, i+1)
+			}
+		}
+	}
+	return keys, owners
+}
+
+// GetMatchByID возвращает матч по его ID
+func (s *RedisStorage) GetMatchByID(ctx context.Context, matchID string) (*models.Match, error) {
+	ctx, span := startSpan(ctx, "GetMatchByID", attribute.String("match_id", matchID))
+	defer span.End()
+
+	matchJSON, err := s.client.Get(ctx, s.matchByIDKey(matchID)).Result()
+	if err == redis.Nil {
+		return nil, ErrMatchNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get match: %w", err)
+	}
+
+	var match models.Match
+	if err := json.Unmarshal([]byte(matchJSON), &match); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal match: %w", err)
+	}
+
+	return &match, nil
+}
+
+// UpdateMatchMetadata добавляет поля в Metadata сохраненного матча, не меняя срок его хранения
+func (s *RedisStorage) UpdateMatchMetadata(ctx context.Context, matchID string, metadata map[string]interface{}) (*models.Match, error) {
+	ctx, span := startSpan(ctx, "UpdateMatchMetadata", attribute.String("match_id", matchID))
+	defer span.End()
+
+	match, err := s.GetMatchByID(ctx, matchID)
+	if err != nil {
+		return nil, err
+	}
+
+	if match.Metadata == nil {
+		match.Metadata = make(map[string]interface{}, len(metadata))
+	}
+	for k, v := range metadata {
+		match.Metadata[k] = v
+	}
+
+	matchJSON, err := json.Marshal(match)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal match: %w", err)
+	}
+
+	// XX: не воскрешаем запись, если она истекла между чтением и записью
+	if err := s.client.SetArgs(ctx, s.matchByIDKey(matchID), matchJSON, redis.SetArgs{
+		Mode:    "XX",
+		KeepTTL: true,
+	}).Err(); err != nil {
+		if err == redis.Nil {
+			return nil, ErrMatchNotFound
+		}
+		return nil, fmt.Errorf("failed to update match metadata: %w", err)
+	}
+
+	return match, nil
+}
+
+// incrementQueueLeaves учитывает уход игроков в счетчиках оттока их очередей
+func (s *RedisStorage) incrementQueueLeaves(ctx context.Context, players []models.Player) {
+	type queueRef struct{ region, gameMode string }
+
+	order := make([]queueRef, 0, 1)
+	leaves := make(map[queueRef]int64)
+	for _, p := range players {
+		q := queueRef{p.Region, p.GameMode}
+		if leaves[q] == 0 {
+			order = append(order, q)
+		}
+		leaves[q]++
+	}
+	for _, q := range order {
+		s.incrementQueueFlow(ctx, q.region, q.gameMode, "leaves", leaves[q])
+	}
+}
+
+// matchByIDKey возвращает ключ матча по его ID
+func (s *RedisStorage) matchByIDKey(matchID string) string {
+	return fmt.Sprintf("match:id:%s", matchID)
+}
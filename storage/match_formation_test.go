@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestRunAtomicMatchFormation(t *testing.T) {
+	ctx := context.Background()
+	s, raw := newTestRedisStorage(t)
+	addPlayers(t, s, queuedPlayer("a", 1500), queuedPlayer("b", 1500))
+
+	if err := s.RunAtomicMatchFormation(ctx, testMatch("match", "a", "b")); err != nil {
+		t.Fatalf("RunAtomicMatchFormation: %v", err)
+	}
+	if size, _ := s.GetQueueSize(ctx, "EU", "3v3"); size != 0 {
+		t.Errorf("queue size = %d, want 0", size)
+	}
+	for _, id := range []string{"a", "b"} {
+		if exists, _ := raw.Exists(ctx, s.playerKey(id)).Result(); exists != 0 {
+			t.Errorf("player key of %s was not removed", id)
+		}
+		if match, err := s.GetMatchByPlayerID(ctx, id); err != nil || match.MatchID != "match" {
+			t.Errorf("GetMatchByPlayerID(%s) = %v, %v; want match", id, match, err)
+		}
+	}
+	if _, err := s.GetMatchByID(ctx, "match"); err != nil {
+		t.Errorf("GetMatchByID: %v", err)
+	}
+}
+
+// TestRunAtomicMatchFormationCASFailure проверяет, что скрипт ничего не меняет,
+// если игрок пропал из очереди между выбором игроков и созданием матча
+func TestRunAtomicMatchFormationCASFailure(t *testing.T) {
+	ctx := context.Background()
+	s, raw := newTestRedisStorage(t)
+	addPlayers(t, s, queuedPlayer("a", 1500), queuedPlayer("b", 1500))
+
+	// Элемент очереди удален, а ключ игрока еще на месте
+	playerJSON, _ := raw.Get(ctx, s.playerKey("b")).Result()
+	raw.ZRem(ctx, s.queueKey("EU", "3v3"), playerJSON)
+
+	if err := s.RunAtomicMatchFormation(ctx, testMatch("match", "a", "b")); !errors.Is(err, ErrMatchConflict) {
+		t.Fatalf("RunAtomicMatchFormation = %v, want ErrMatchConflict", err)
+	}
+	if size, _ := s.GetQueueSize(ctx, "EU", "3v3"); size != 1 {
+		t.Errorf("queue size = %d, want a still queued", size)
+	}
+	if exists, _ := raw.Exists(ctx, s.playerKey("a"), s.matchKey("a"), s.matchKey("b"), s.matchByIDKey("match")).Result(); exists != 1 {
+		t.Errorf("%d keys exist, want only the player key of a", exists)
+	}
+}
+
+// TestRunAtomicMatchFormationRace проверяет, что из двух матчей с общим игроком,
+// формируемых одновременно, создается ровно один
+func TestRunAtomicMatchFormationRace(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestRedisStorage(t)
+	addPlayers(t, s, queuedPlayer("shared", 1500), queuedPlayer("a", 1500), queuedPlayer("b", 1500))
+
+	matches := []string{"match-a", "match-b"}
+	errs := make([]error, len(matches))
+	var wg sync.WaitGroup
+	for i, id := range []string{"a", "b"} {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			errs[i] = s.RunAtomicMatchFormation(ctx, testMatch(matches[i], "shared", id))
+		}(i, id)
+	}
+	wg.Wait()
+
+	formed := 0
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			formed++
+		case !errors.Is(err, ErrMatchConflict):
+			t.Errorf("RunAtomicMatchFormation(%s) = %v, want nil or ErrMatchConflict", matches[i], err)
+		}
+	}
+	if formed != 1 {
+		t.Fatalf("%d matches formed, want 1", formed)
+	}
+
+	// Второй игрок проигравшего матча остается в очереди
+	players, _ := s.GetQueuePlayers(ctx, "EU", "3v3")
+	if len(players) != 1 || players[0].ID == "shared" {
+		t.Errorf("queue = %v, want one player of the conflicting match", playerIDs(players))
+	}
+	match, err := s.GetMatchByPlayerID(ctx, "shared")
+	if err != nil {
+		t.Fatalf("GetMatchByPlayerID: %v", err)
+	}
+	winner := matches[0]
+	if errs[0] != nil {
+		winner = matches[1]
+	}
+	if match.MatchID != winner {
+		t.Errorf("shared player match = %s, want %s", match.MatchID, winner)
+	}
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// MatchHistoryLimit максимальное количество матчей в истории игрока
+const MatchHistoryLimit = 50
+
+// AppendMatchHistory добавляет матч в начало истории игрока и обрезает ее до 50 записей
+func (s *RedisStorage) AppendMatchHistory(ctx context.Context, playerID string, match *models.Match) error {
+	ctx, span := startSpan(ctx, "AppendMatchHistory",
+		attribute.String("player_id", playerID),
+		attribute.String("match_id", match.MatchID),
+	)
+	defer span.End()
+
+	matchJSON, err := json.Marshal(match)
+	if err != nil {
+		return fmt.Errorf("failed to marshal match: %w", err)
+	}
+
+	key := s.matchHistoryKey(playerID)
+	pipe := s.client.TxPipeline()
+	pipe.LPush(ctx, key, matchJSON)
+	pipe.LTrim(ctx, key, 0, MatchHistoryLimit-1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to append match history: %w", err)
+	}
+
+	return nil
+}
+
+// GetMatchHistory возвращает до limit последних матчей игрока, начиная с самого нового
+func (s *RedisStorage) GetMatchHistory(ctx context.Context, playerID string, limit int) ([]*models.Match, error) {
+	ctx, span := startSpan(ctx, "GetMatchHistory", attribute.String("player_id", playerID))
+	defer span.End()
+
+	if limit <= 0 || limit > MatchHistoryLimit {
+		limit = MatchHistoryLimit
+	}
+
+	results, err := s.client.LRange(ctx, s.matchHistoryKey(playerID), 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get match history: %w", err)
+	}
+
+	matches := make([]*models.Match, 0, len(results))
+	for _, result := range results {
+		var match models.Match
+		if err := json.Unmarshal([]byte(result), &match); err != nil {
+			logging.FromContext(ctx).Warn("Failed to unmarshal match history entry",
+				zap.String("player_id", playerID),
+				zap.Error(err),
+			)
+			continue
+		}
+		matches = append(matches, &match)
+	}
+
+	return matches, nil
+}
+
+// recordMatchHistory добавляет матч в историю каждого его игрока, кроме ботов, и увеличивает
+// их match_count
+func (s *RedisStorage) recordMatchHistory(ctx context.Context, match *models.Match) {
+	humans := match.HumanPlayers()
+	for _, p := range humans {
+		if err := s.AppendMatchHistory(ctx, p.ID, match); err != nil {
+			logging.FromContext(ctx).Warn("Failed to record match history",
+				zap.String("match_id", match.MatchID),
+				zap.String("player_id", p.ID),
+				zap.Error(err),
+			)
+		}
+	}
+	s.incrementMatchCounts(ctx, humans)
+}
+
+// matchHistoryKey возвращает ключ истории матчей игрока
+func (s *RedisStorage) matchHistoryKey(playerID string) string {
+	return fmt.Sprintf("history:%s", playerID)
+}
@@ -0,0 +1,2745 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"chrono-matchmaking/models"
+)
+
+const (
+	memSweepInterval    = time.Minute // Как часто удаляются истекшие значения
+	memExpiryPoll       = time.Second // Период проверки истекших матчей в WatchForMatchExpiry
+	memSubscriberBuffer = 64          // Размер буфера канала подписчика
+)
+
+// memQueue идентифицирует очередь или статистику региона/режима
+type memQueue struct {
+	region   string
+	gameMode string
+}
+
+// memSegment идентифицирует рейтинговый сегмент очереди
+type memSegment struct {
+	memQueue
+	bracket string
+}
+
+// memValue строковое значение с необязательным сроком жизни (аналог ключа Redis с TTL)
+type memValue struct {
+	data      string
+	expiresAt time.Time // Нулевое время — без срока
+}
+
+// alive проверяет, что срок жизни значения не истек
+func (v memValue) alive(now time.Time) bool {
+	return v.expiresAt.IsZero() || now.Before(v.expiresAt)
+}
+
+// memExpiresAt возвращает время истечения значения с TTL ttl (нулевое при ttl <= 0, как в Redis)
+func memExpiresAt(now time.Time, ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return now.Add(ttl)
+}
+
+// memValues набор значений с TTL. Истекшие значения считаются отсутствующими
+// и удаляются при очередной очистке.
+type memValues[K comparable] map[K]memValue
+
+// get возвращает живое значение
+func (v memValues[K]) get(key K, now time.Time) (memValue, bool) {
+	value, ok := v[key]
+	if !ok || !value.alive(now) {
+		return memValue{}, false
+	}
+	return value, true
+}
+
+// sweep удаляет истекшие значения
+func (v memValues[K]) sweep(now time.Time) {
+	for key, value := range v {
+		if !value.alive(now) {
+			delete(v, key)
+		}
+	}
+}
+
+// memZSet упорядоченное множество: элемент -> score. Порядок как в Redis:
+// по score, при равенстве — лексикографически по элементу.
+type memZSet map[string]float64
+
+// rangeByScore возвращает элементы с score в [min, max] по возрастанию
+func (z memZSet) rangeByScore(min, max float64) []string {
+	members := make([]string, 0, len(z))
+	for member, score := range z {
+		if score >= min && score <= max {
+			members = append(members, member)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		si, sj := z[members[i]], z[members[j]]
+		if si != sj {
+			return si < sj
+		}
+		return members[i] < members[j]
+	})
+	return members
+}
+
+// count возвращает количество элементов с score в [min, max]
+func (z memZSet) count(min, max float64) int64 {
+	var n int64
+	for _, score := range z {
+		if score >= min && score <= max {
+			n++
+		}
+	}
+	return n
+}
+
+// removeBelow удаляет элементы с score строго меньше min
+func (z memZSet) removeBelow(min float64) {
+	for member, score := range z {
+		if score < min {
+			delete(z, member)
+		}
+	}
+}
+
+// trimToLast оставляет limit элементов с наибольшим рангом
+func (z memZSet) trimToLast(limit int) {
+	if len(z) <= limit {
+		return
+	}
+	members := z.rangeByScore(math.Inf(-1), math.Inf(1))
+	for _, member := range members[:len(members)-limit] {
+		delete(z, member)
+	}
+}
+
+// memSet множество строк с необязательным сроком жизни
+type memSet struct {
+	members   map[string]struct{}
+	expiresAt time.Time
+}
+
+// memExpiry матч, зарегистрированный для WatchForMatchExpiry
+type memExpiry struct {
+	expiry    MatchExpiry
+	expiresAt time.Time
+	attempts  int       // Число неудачных вызовов обработчика
+	retryAt   time.Time // Не повторять обработку раньше этого времени
+}
+
+// memWaitStat сумма и количество времен ожидания за час суток
+type memWaitStat struct {
+	sum   float64
+	count int64
+}
+
+// memFlow счетчики входов и выходов очереди
+type memFlow struct {
+	joins  int64
+	leaves int64
+}
+
+// InMemoryStorage реализация Storage в памяти процесса для тестов и локальной разработки
+// без Redis. Повторяет модель данных RedisStorage: элемент очереди совпадает с JSON игрока,
+// сроки жизни ключей соблюдаются, а операции, которые в Redis выполняются Lua-скриптами,
+// атомарны за счет общей блокировки. Данные не разделяются между процессами, поэтому
+// несколько реплик сервиса с этим хранилищем работают каждая со своей очередью.
+type InMemoryStorage struct {
+	mu        sync.RWMutex
+	lastSweep time.Time
+
+	players       memValues[string]    // player:{id} -> JSON игрока
+	queues        map[memQueue]memZSet // Обычные очереди: JSON игрока -> рейтинг
+	premiumQueues map[memQueue]memZSet // Приоритетные очереди подписчиков
+	suspectQueues map[memQueue]memZSet // Очереди игроков, подозреваемых в смурфинге
+	calibQueues   map[memQueue]memZSet // Очереди игроков, проходящих калибровку
+	parties       memValues[string]    // party:{id} -> JSON группы
+	queueFlow     map[memQueue]*memFlow
+	queueHistory  map[string]memZSet // Моменты входа игрока в очередь (мс)
+	lastPurge     memValues[memQueue]
+
+	matches      memValues[string]    // match:{playerID} -> JSON матча
+	matchRecords memValues[string]    // match:id:{matchID} -> JSON матча
+	matchHistory map[string][]string  // JSON матчей игрока, начиная с самого нового
+	scheduled    map[memQueue]memZSet // JSON матча -> время начала
+	expiries     []memExpiry
+
+	pending         memValues[string]    // pending:{id} -> JSON ожидающего матча
+	playerPending   memValues[string]    // pending:player:{id} -> JSON ожидающего матча
+	pendingIndex    map[memQueue]memZSet // JSON ожидающего матча -> время истечения
+	pendingAccepted map[string]*memSet
+	cooldowns       memValues[string]
+
+	bans          memValues[string] // Значение — время окончания бана (unix)
+	abandons      memValues[string] // abandons:{playerID} — выходы из очереди без матча
+	flagged       memValues[string] // flagged:abandon
+	profiles      map[string]models.PlayerProfile
+	deviceTokens  map[string][]string
+	ratingHistory map[string]memZSet // JSON точки -> время в мс
+	ratingChanges map[string]memZSet // "{время в нс}:{delta}" -> время в мс
+	encounters    map[string]*memSet // Недавние соперники игрока
+	ratings       map[string]int     // Рейтинг игрока после последнего матча
+	matchResults  memValues[string]  // match:result:{matchID} — результат матча применен
+	momentum      memValues[string]  // momentum:{playerID} -> импульс рейтинга
+	lastActive    map[string]time.Time
+	playerStats   map[string]map[string]float64 // stats:{playerID} -> счетчик -> значение
+	playerWaits   map[string][]time.Duration    // stats:{playerID}:waits, начиная с самого нового
+
+	modePopularity map[memQueue]*[HoursPerDay]int64
+	waitStats      map[memQueue]*[HoursPerDay]memWaitStat
+	segmentMatches map[memSegment]memZSet
+	matchedRatings map[memQueue]memZSet
+	queuePositions map[memQueue]map[string]int64 // Снимок позиций игроков на прошлом цикле ProcessQueue
+	waitTimes      map[memQueue]memZSet
+
+	seasons     map[string]string                   // ID сезона -> JSON сезона
+	tournaments memValues[string]                   // ID турнира -> JSON турнира
+	invites     memValues[string]                   // Код приглашения -> JSON приватного матча с игроками
+	servers     map[string]map[string]models.Server // Регион -> ID сервера -> сервер
+	serverIndex map[string]string                   // ID сервера -> регион
+
+	locks       memValues[string] // Ключ блокировки -> владелец
+	rateLimits  memValues[string] // Счетчики запросов клиентов за секунду
+	idempotency memValues[string] // Ключ идемпотентности -> ID игрока
+	subscribers map[string][]chan []byte
+
+	regions   []string       // Список активных регионов; nil — не задан
+	gameModes map[string]int // Режим -> игроков в матче; nil — не заданы
+	dlq       []string       // dlq:matches — JSON записей от старых к новым
+
+	maxQueueSize int // Максимальный размер очереди региона и режима; 0 — без ограничения
+}
+
+// NewInMemoryStorage создает пустое хранилище в памяти
+func NewInMemoryStorage() *InMemoryStorage {
+	return &InMemoryStorage{
+		lastSweep:       time.Now(),
+		players:         make(memValues[string]),
+		queues:          make(map[memQueue]memZSet),
+		premiumQueues:   make(map[memQueue]memZSet),
+		suspectQueues:   make(map[memQueue]memZSet),
+		calibQueues:     make(map[memQueue]memZSet),
+		parties:         make(memValues[string]),
+		queueFlow:       make(map[memQueue]*memFlow),
+		queueHistory:    make(map[string]memZSet),
+		lastPurge:       make(memValues[memQueue]),
+		matches:         make(memValues[string]),
+		matchRecords:    make(memValues[string]),
+		matchHistory:    make(map[string][]string),
+		scheduled:       make(map[memQueue]memZSet),
+		pending:         make(memValues[string]),
+		playerPending:   make(memValues[string]),
+		pendingIndex:    make(map[memQueue]memZSet),
+		pendingAccepted: make(map[string]*memSet),
+		cooldowns:       make(memValues[string]),
+		bans:            make(memValues[string]),
+		abandons:        make(memValues[string]),
+		flagged:         make(memValues[string]),
+		profiles:        make(map[string]models.PlayerProfile),
+		deviceTokens:    make(map[string][]string),
+		ratingHistory:   make(map[string]memZSet),
+		ratingChanges:   make(map[string]memZSet),
+		encounters:      make(map[string]*memSet),
+		ratings:         make(map[string]int),
+		matchResults:    make(memValues[string]),
+		momentum:        make(memValues[string]),
+		lastActive:      make(map[string]time.Time),
+		playerStats:     make(map[string]map[string]float64),
+		playerWaits:     make(map[string][]time.Duration),
+		modePopularity:  make(map[memQueue]*[HoursPerDay]int64),
+		waitStats:       make(map[memQueue]*[HoursPerDay]memWaitStat),
+		segmentMatches:  make(map[memSegment]memZSet),
+		matchedRatings:  make(map[memQueue]memZSet),
+		queuePositions:  make(map[memQueue]map[string]int64),
+		waitTimes:       make(map[memQueue]memZSet),
+		seasons:         make(map[string]string),
+		tournaments:     make(memValues[string]),
+		invites:         make(memValues[string]),
+		servers:         make(map[string]map[string]models.Server),
+		serverIndex:     make(map[string]string),
+		locks:           make(memValues[string]),
+		rateLimits:      make(memValues[string]),
+		idempotency:     make(memValues[string]),
+		subscribers:     make(map[string][]chan []byte),
+	}
+}
+
+// Close ничего не делает: хранилищу в памяти нечего закрывать
+func (m *InMemoryStorage) Close() error {
+	return nil
+}
+
+// Ping всегда успешен: хранилище находится в памяти процесса
+func (m *InMemoryStorage) Ping(ctx context.Context) error {
+	return nil
+}
+
+// lock захватывает блокировку на запись и не чаще раза в минуту удаляет истекшие
+// значения, чтобы долгоживущий процесс не копил их. Возвращает текущее время.
+func (m *InMemoryStorage) lock() time.Time {
+	m.mu.Lock()
+	now := time.Now()
+	if now.Sub(m.lastSweep) >= memSweepInterval {
+		m.sweepExpired(now)
+		m.lastSweep = now
+	}
+	return now
+}
+
+// sweepExpired удаляет истекшие значения
+func (m *InMemoryStorage) sweepExpired(now time.Time) {
+	m.players.sweep(now)
+	m.parties.sweep(now)
+	m.lastPurge.sweep(now)
+	m.matches.sweep(now)
+	m.matchRecords.sweep(now)
+	m.pending.sweep(now)
+	m.playerPending.sweep(now)
+	m.cooldowns.sweep(now)
+	m.bans.sweep(now)
+	m.abandons.sweep(now)
+	m.flagged.sweep(now)
+	m.matchResults.sweep(now)
+	m.momentum.sweep(now)
+	m.locks.sweep(now)
+	m.rateLimits.sweep(now)
+	m.idempotency.sweep(now)
+	m.tournaments.sweep(now)
+	m.invites.sweep(now)
+	for id, set := range m.pendingAccepted {
+		if !set.expiresAt.IsZero() && !now.Before(set.expiresAt) {
+			delete(m.pendingAccepted, id)
+		}
+	}
+	for id, set := range m.encounters {
+		if !set.expiresAt.IsZero() && !now.Before(set.expiresAt) {
+			delete(m.encounters, id)
+		}
+	}
+}
+
+// memZSetOf возвращает упорядоченное множество из набора, создавая его при необходимости
+func memZSetOf[K comparable](sets map[K]memZSet, key K) memZSet {
+	z, ok := sets[key]
+	if !ok {
+		z = make(memZSet)
+		sets[key] = z
+	}
+	return z
+}
+
+// playerQueue возвращает очередь, в которой ждет игрок
+func (m *InMemoryStorage) playerQueue(player *models.Player) memZSet {
+	q := memQueue{player.Region, player.GameMode}
+	if player.IsSuspicious {
+		return memZSetOf(m.suspectQueues, q)
+	}
+	if player.IsCalibrating {
+		return memZSetOf(m.calibQueues, q)
+	}
+	if player.IsPremium {
+		return memZSetOf(m.premiumQueues, q)
+	}
+	return memZSetOf(m.queues, q)
+}
+
+// playerQueues возвращает очереди всех режимов поиска игрока, начиная с режима входа
+func (m *InMemoryStorage) playerQueues(player *models.Player) []memZSet {
+	modes := player.QueueGameModes()
+	queues := make([]memZSet, 0, len(modes))
+	for _, mode := range modes {
+		p := *player
+		p.GameMode = mode
+		queues = append(queues, m.playerQueue(&p))
+	}
+	return queues
+}
+
+// queuesOf возвращает очереди в порядке обработки: приоритетную, подозрительных игроков,
+// калибрующихся игроков и обычную
+func (m *InMemoryStorage) queuesOf(region, gameMode string) []memZSet {
+	q := memQueue{region, gameMode}
+	return []memZSet{m.premiumQueues[q], m.suspectQueues[q], m.calibQueues[q], m.queues[q]}
+}
+
+// AddPlayerToQueue добавляет игрока в очередь. Повторный вызов возвращает ErrPlayerAlreadyQueued.
+func (m *InMemoryStorage) AddPlayerToQueue(ctx context.Context, player *models.Player) error {
+	playerJSON, err := json.Marshal(player)
+	if err != nil {
+		return fmt.Errorf("failed to marshal player: %w", err)
+	}
+
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.players.get(player.ID, now); ok {
+		return ErrPlayerAlreadyQueued
+	}
+	queues := m.playerQueues(player)
+	if _, ok := queues[0][string(playerJSON)]; ok {
+		return ErrPlayerAlreadyQueued
+	}
+	if m.maxQueueSize > 0 {
+		for _, mode := range player.QueueGameModes() {
+			q := memQueue{player.Region, mode}
+			if len(m.queues[q])+len(m.premiumQueues[q])+len(m.suspectQueues[q])+len(m.calibQueues[q]) >= m.maxQueueSize {
+				return ErrQueueFull
+			}
+		}
+	}
+
+	m.players[player.ID] = memValue{data: string(playerJSON), expiresAt: now.Add(PlayerTTL)}
+	for _, queue := range queues {
+		queue[string(playerJSON)] = float64(player.Rating)
+	}
+
+	for _, mode := range player.QueueGameModes() {
+		m.incrementQueueFlow(player.Region, mode, 1, 0)
+	}
+	m.recordQueueJoin(player.ID, player.JoinedAt)
+	return nil
+}
+
+// AddPlayersToQueue добавляет в очередь сразу нескольких игроков и возвращает ошибку
+// для каждого из них в порядке players (nil — добавлен)
+func (m *InMemoryStorage) AddPlayersToQueue(ctx context.Context, players []*models.Player) []error {
+	errs := make([]error, len(players))
+	for i, player := range players {
+		errs[i] = m.AddPlayerToQueue(ctx, player)
+	}
+	return errs
+}
+
+// RemovePlayerFromQueue удаляет игрока из очереди
+func (m *InMemoryStorage) RemovePlayerFromQueue(ctx context.Context, playerID string) error {
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	value, ok := m.players.get(playerID, now)
+	if !ok {
+		return fmt.Errorf("player not found")
+	}
+
+	var player models.Player
+	if err := json.Unmarshal([]byte(value.data), &player); err != nil {
+		return fmt.Errorf("failed to unmarshal player: %w", err)
+	}
+
+	for _, queue := range m.playerQueues(&player) {
+		delete(queue, value.data)
+	}
+	delete(m.players, playerID)
+
+	for _, mode := range player.QueueGameModes() {
+		m.incrementQueueFlow(player.Region, mode, 0, 1)
+	}
+	return nil
+}
+
+// GetPlayersInRange возвращает игроков в диапазоне рейтинга: сначала из приоритетной
+// очереди, затем из обычной, всего не больше limit (0 — без ограничения)
+func (m *InMemoryStorage) GetPlayersInRange(ctx context.Context, region, gameMode string, minRating, maxRating int, limit int64) ([]*models.Player, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	players := make([]*models.Player, 0)
+	for _, queue := range m.queuesOf(region, gameMode) {
+		members := queue.rangeByScore(float64(minRating), float64(maxRating))
+		if limit > 0 {
+			remaining := limit - int64(len(players))
+			if remaining <= 0 {
+				break
+			}
+			if int64(len(members)) > remaining {
+				members = members[:remaining]
+			}
+		}
+		players = append(players, unmarshalMemPlayers(members, gameMode)...)
+	}
+
+	return players, nil
+}
+
+// GetQueuePlayers возвращает всех игроков обеих очередей, отсортированных по рейтингу
+func (m *InMemoryStorage) GetQueuePlayers(ctx context.Context, region, gameMode string) ([]*models.Player, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	players := make([]*models.Player, 0)
+	for _, queue := range m.queuesOf(region, gameMode) {
+		players = append(players, unmarshalMemPlayers(queue.rangeByScore(math.Inf(-1), math.Inf(1)), gameMode)...)
+	}
+
+	sort.SliceStable(players, func(i, j int) bool {
+		return players[i].Rating < players[j].Rating
+	})
+
+	return players, nil
+}
+
+// GetTopRatedPlayers возвращает до limit игроков очереди с наибольшим рейтингом
+// (обычная и приоритетная очереди вместе) по убыванию рейтинга
+func (m *InMemoryStorage) GetTopRatedPlayers(ctx context.Context, region, gameMode string, limit int64) ([]*models.Player, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	players := make([]*models.Player, 0)
+	for _, queue := range m.queuesOf(region, gameMode) {
+		players = append(players, unmarshalMemPlayers(queue.rangeByScore(math.Inf(-1), math.Inf(1)), gameMode)...)
+	}
+
+	sort.SliceStable(players, func(i, j int) bool {
+		return players[i].Rating > players[j].Rating
+	})
+	if limit < 0 {
+		limit = 0
+	}
+	if int64(len(players)) > limit {
+		players = players[:limit]
+	}
+
+	return players, nil
+}
+
+// unmarshalMemPlayers разбирает элементы очереди, пропуская нечитаемые. Игрокам,
+// стоящим в нескольких очередях, в GameMode записывается режим прочитанной очереди
+// gameMode (пустой — оставить режим входа).
+func unmarshalMemPlayers(members []string, gameMode string) []*models.Player {
+	players := make([]*models.Player, 0, len(members))
+	for _, member := range members {
+		var player models.Player
+		if err := json.Unmarshal([]byte(member), &player); err != nil {
+			continue
+		}
+		if gameMode != "" && player.IsMultiQueue() {
+			player.GameMode = gameMode
+		}
+		players = append(players, &player)
+	}
+	return players
+}
+
+// GetPlayerByID возвращает игрока по ID
+func (m *InMemoryStorage) GetPlayerByID(ctx context.Context, playerID string) (*models.Player, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	value, ok := m.players.get(playerID, time.Now())
+	if !ok {
+		return nil, fmt.Errorf("player not found")
+	}
+
+	var player models.Player
+	if err := json.Unmarshal([]byte(value.data), &player); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal player: %w", err)
+	}
+	return &player, nil
+}
+
+// GetQueueSize возвращает количество игроков в обеих очередях
+func (m *InMemoryStorage) GetQueueSize(ctx context.Context, region, gameMode string) (int64, error) {
+	standard, premium, err := m.GetQueueSizes(ctx, region, gameMode)
+	return standard + premium, err
+}
+
+// GetQueueSizes возвращает размеры обычной и приоритетной очередей. Очереди
+// подозрительных и калибрующихся игроков учитываются в обычной.
+func (m *InMemoryStorage) GetQueueSizes(ctx context.Context, region, gameMode string) (standard, premium int64, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	q := memQueue{region, gameMode}
+	return int64(len(m.queues[q]) + len(m.suspectQueues[q]) + len(m.calibQueues[q])), int64(len(m.premiumQueues[q])), nil
+}
+
+// GetPlayerQueuePosition возвращает количество игроков обеих очередей с рейтингом не выше,
+// чем у игрока (1 — самый низкий рейтинг)
+func (m *InMemoryStorage) GetPlayerQueuePosition(ctx context.Context, playerID string) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	value, ok := m.players.get(playerID, time.Now())
+	if !ok {
+		return 0, ErrPlayerNotInQueue
+	}
+
+	var player models.Player
+	if err := json.Unmarshal([]byte(value.data), &player); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal player: %w", err)
+	}
+
+	score, ok := m.playerQueue(&player)[value.data]
+	if !ok {
+		return 0, ErrPlayerNotInQueue
+	}
+
+	var position int64
+	for _, queue := range m.queuesOf(player.Region, player.GameMode) {
+		position += queue.count(math.Inf(-1), score)
+	}
+	return position, nil
+}
+
+// SwapQueuePositions заменяет снимок позиций игроков очереди на positions и
+// возвращает предыдущий снимок
+func (m *InMemoryStorage) SwapQueuePositions(ctx context.Context, region, gameMode string, positions map[string]int64) (map[string]int64, error) {
+	m.lock()
+	defer m.mu.Unlock()
+
+	q := memQueue{region, gameMode}
+	previous := m.queuePositions[q]
+	snapshot := make(map[string]int64, len(positions))
+	for playerID, position := range positions {
+		snapshot[playerID] = position
+	}
+	m.queuePositions[q] = snapshot
+	return previous, nil
+}
+
+// RefreshPlayerTTL продлевает ключ игрока на ttl. Если ключ уже истек, возвращает ErrPlayerNotInQueue
+func (m *InMemoryStorage) RefreshPlayerTTL(ctx context.Context, playerID string, ttl time.Duration) error {
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	value, ok := m.players.get(playerID, now)
+	if !ok {
+		return ErrPlayerNotInQueue
+	}
+	value.expiresAt = memExpiresAt(now, ttl)
+	m.players[playerID] = value
+	return nil
+}
+
+// GetExpiringPlayers возвращает ID игроков, чей ключ истечет раньше чем через threshold,
+// вместе с оставшимся временем
+func (m *InMemoryStorage) GetExpiringPlayers(ctx context.Context, threshold time.Duration) (map[string]time.Duration, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	expiring := make(map[string]time.Duration)
+	for id, value := range m.players {
+		if value.expiresAt.IsZero() || !value.alive(now) {
+			continue
+		}
+		if ttl := value.expiresAt.Sub(now); ttl < threshold {
+			expiring[id] = ttl
+		}
+	}
+	return expiring, nil
+}
+
+// GetAllPlayers возвращает данные всех игроков, стоящих в очереди
+func (m *InMemoryStorage) GetAllPlayers(ctx context.Context) ([]*models.Player, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	players := make([]*models.Player, 0, len(m.players))
+	for _, value := range m.players {
+		if !value.alive(now) {
+			continue
+		}
+		var player models.Player
+		if err := json.Unmarshal([]byte(value.data), &player); err != nil {
+			continue
+		}
+		players = append(players, &player)
+	}
+	return players, nil
+}
+
+// SnapshotAll возвращает содержимое всех непустых очередей по ключу очереди в формате
+// ключей Redis; игроки каждой очереди упорядочены по рейтингу
+func (m *InMemoryStorage) SnapshotAll(ctx context.Context) (map[string][]*models.Player, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	snapshot := make(map[string][]*models.Player)
+	add := func(prefix string, queues map[memQueue]memZSet) {
+		for q, queue := range queues {
+			if len(queue) == 0 {
+				continue
+			}
+			key := fmt.Sprintf("%s%s:%s", prefix, q.region, q.gameMode)
+			snapshot[key] = unmarshalMemPlayers(queue.rangeByScore(math.Inf(-1), math.Inf(1)), "")
+		}
+	}
+	add("queue:", m.queues)
+	add("queue:premium:", m.premiumQueues)
+	add("queue:suspect:", m.suspectQueues)
+	add("queue:calibration:", m.calibQueues)
+	return snapshot, nil
+}
+
+// SetLastActive сохраняет время последнего сыгранного игроком матча
+func (m *InMemoryStorage) SetLastActive(ctx context.Context, playerID string, at time.Time) error {
+	m.lock()
+	defer m.mu.Unlock()
+
+	m.lastActive[playerID] = at
+	return nil
+}
+
+// GetLastActive возвращает время последнего сыгранного игроком матча (нулевое, если неизвестно)
+func (m *InMemoryStorage) GetLastActive(ctx context.Context, playerID string) (time.Time, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.lastActive[playerID], nil
+}
+
+// AddPartyToQueue атомарно добавляет в очередь всех игроков группы и сохраняет саму группу.
+// Если кто-то из игроков уже в очереди, никто не добавляется и возвращается ErrPlayerAlreadyQueued.
+func (m *InMemoryStorage) AddPartyToQueue(ctx context.Context, party *models.Party, players []*models.Player) error {
+	if len(players) == 0 {
+		return fmt.Errorf("party has no players")
+	}
+
+	partyJSON, err := json.Marshal(party)
+	if err != nil {
+		return fmt.Errorf("failed to marshal party: %w", err)
+	}
+	playersJSON := make([]string, len(players))
+	for i, p := range players {
+		playerJSON, err := json.Marshal(p)
+		if err != nil {
+			return fmt.Errorf("failed to marshal player: %w", err)
+		}
+		playersJSON[i] = string(playerJSON)
+	}
+
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	for _, p := range players {
+		if _, ok := m.players.get(p.ID, now); ok {
+			return ErrPlayerAlreadyQueued
+		}
+	}
+
+	// Группа всегда ждет в обычной очереди
+	queue := memZSetOf(m.queues, memQueue{party.Region, party.GameMode})
+	expiresAt := now.Add(PlayerTTL)
+	for i, p := range players {
+		m.players[p.ID] = memValue{data: playersJSON[i], expiresAt: expiresAt}
+		queue[playersJSON[i]] = float64(p.Rating)
+	}
+	m.parties[party.PartyID] = memValue{data: string(partyJSON), expiresAt: expiresAt}
+
+	m.incrementQueueFlow(party.Region, party.GameMode, int64(len(players)), 0)
+	for _, p := range players {
+		m.recordQueueJoin(p.ID, p.JoinedAt)
+	}
+	return nil
+}
+
+// GetParty возвращает группу по ее ID
+func (m *InMemoryStorage) GetParty(ctx context.Context, partyID string) (*models.Party, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	value, ok := m.parties.get(partyID, time.Now())
+	if !ok {
+		return nil, ErrPartyNotFound
+	}
+
+	var party models.Party
+	if err := json.Unmarshal([]byte(value.data), &party); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal party: %w", err)
+	}
+	return &party, nil
+}
+
+// GetQueueMemberCount считает участников обеих очередей: stale — ожидающие дольше
+// staleAfter или нечитаемые, active — остальные, total — все элементы
+func (m *InMemoryStorage) GetQueueMemberCount(ctx context.Context, region, gameMode string, staleAfter time.Duration) (active, stale, total int64, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	for _, queue := range m.queuesOf(region, gameMode) {
+		for member := range queue {
+			total++
+
+			var player models.Player
+			if err := json.Unmarshal([]byte(member), &player); err != nil || now.Sub(player.JoinedAt) > staleAfter {
+				stale++
+			} else {
+				active++
+			}
+		}
+	}
+	return active, stale, total, nil
+}
+
+// ScheduleQueueRemoval ничего не делает: очереди в памяти не переживают остановку процесса
+func (m *InMemoryStorage) ScheduleQueueRemoval(ctx context.Context, region, gameMode string, ttl time.Duration) (int64, error) {
+	return 0, nil
+}
+
+// RemoveDrainedPlayers ничего не делает, см. ScheduleQueueRemoval
+func (m *InMemoryStorage) RemoveDrainedPlayers(ctx context.Context, region, gameMode string, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+// RemoveStalePlayers удаляет из обеих очередей игроков, ожидающих дольше staleAfter,
+// и возвращает количество удаленных
+func (m *InMemoryStorage) RemoveStalePlayers(ctx context.Context, region, gameMode string, staleAfter time.Duration) (int64, error) {
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	var removed int64
+	for _, queue := range m.queuesOf(region, gameMode) {
+		for member := range queue {
+			var player models.Player
+			if err := json.Unmarshal([]byte(member), &player); err == nil && now.Sub(player.JoinedAt) <= staleAfter {
+				continue
+			}
+
+			delete(queue, member)
+			if player.ID != "" {
+				delete(m.players, player.ID)
+			}
+			removed++
+		}
+	}
+
+	if removed > 0 {
+		m.incrementQueueFlow(region, gameMode, 0, removed)
+	}
+	return removed, nil
+}
+
+// ReindexQueueScores приводит элементы обеих очередей в соответствие с актуальными
+// данными игроков и возвращает количество обновленных элементов
+func (m *InMemoryStorage) ReindexQueueScores(ctx context.Context, region, gameMode string) (int64, error) {
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	var updated int64
+	for _, queue := range m.queuesOf(region, gameMode) {
+		for member, score := range queue {
+			var queued models.Player
+			if err := json.Unmarshal([]byte(member), &queued); err != nil || queued.ID == "" {
+				continue
+			}
+
+			// Ключ игрока истек — такого участника уберет очистка очереди
+			value, ok := m.players.get(queued.ID, now)
+			if !ok {
+				continue
+			}
+
+			var player models.Player
+			if err := json.Unmarshal([]byte(value.data), &player); err != nil {
+				continue
+			}
+
+			if float64(player.Rating) == score && value.data == member {
+				continue
+			}
+
+			delete(queue, member)
+			queue[value.data] = float64(player.Rating)
+			updated++
+		}
+	}
+	return updated, nil
+}
+
+// GetQueueDepthByBracket считает игроков обеих очередей в каждом диапазоне рейтинга
+// (Max 0 — без верхней границы)
+func (m *InMemoryStorage) GetQueueDepthByBracket(ctx context.Context, region, gameMode string, brackets []models.RatingBracket) ([]models.BracketDepth, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]models.BracketDepth, len(brackets))
+	for i, b := range brackets {
+		max := math.Inf(1)
+		if b.Max > 0 {
+			max = float64(b.Max)
+		}
+		result[i] = models.BracketDepth{Min: b.Min, Max: b.Max}
+		for _, queue := range m.queuesOf(region, gameMode) {
+			result[i].Count += queue.count(float64(b.Min), max)
+		}
+	}
+	return result, nil
+}
+
+// GetQueueFlowCounters возвращает накопленное количество входов в очередь и выходов из нее
+func (m *InMemoryStorage) GetQueueFlowCounters(ctx context.Context, region, gameMode string) (joins, leaves int64, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if flow, ok := m.queueFlow[memQueue{region, gameMode}]; ok {
+		return flow.joins, flow.leaves, nil
+	}
+	return 0, 0, nil
+}
+
+// TryStartQueuePurge отмечает начало очистки очереди, если предыдущая была больше cooldown назад
+func (m *InMemoryStorage) TryStartQueuePurge(ctx context.Context, region, gameMode string, cooldown time.Duration) (bool, error) {
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	q := memQueue{region, gameMode}
+	if _, ok := m.lastPurge.get(q, now); ok {
+		return false, nil
+	}
+	m.lastPurge[q] = memValue{expiresAt: memExpiresAt(now, cooldown)}
+	return true, nil
+}
+
+// MarkQueuePurged запоминает время последней очистки очереди на cooldown
+func (m *InMemoryStorage) MarkQueuePurged(ctx context.Context, region, gameMode string, cooldown time.Duration) error {
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	m.lastPurge[memQueue{region, gameMode}] = memValue{expiresAt: memExpiresAt(now, cooldown)}
+	return nil
+}
+
+// GetPlayerQueueHistory возвращает моменты входа игрока в очередь начиная с since
+func (m *InMemoryStorage) GetPlayerQueueHistory(ctx context.Context, playerID string, since time.Time) ([]time.Time, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	members := m.queueHistory[playerID].rangeByScore(float64(since.UnixMilli()), math.Inf(1))
+	joins := make([]time.Time, 0, len(members))
+	for _, member := range members {
+		ms, err := strconv.ParseInt(member, 10, 64)
+		if err != nil {
+			continue
+		}
+		joins = append(joins, time.UnixMilli(ms))
+	}
+	return joins, nil
+}
+
+// recordQueueJoin добавляет вход в очередь в историю игрока
+func (m *InMemoryStorage) recordQueueJoin(playerID string, joinedAt time.Time) {
+	ms := joinedAt.UnixMilli()
+	history := memZSetOf(m.queueHistory, playerID)
+	history[strconv.FormatInt(ms, 10)] = float64(ms)
+	history.trimToLast(queueHistoryLimit)
+}
+
+// incrementQueueFlow увеличивает счетчики входов и выходов очереди
+func (m *InMemoryStorage) incrementQueueFlow(region, gameMode string, joins, leaves int64) {
+	q := memQueue{region, gameMode}
+	flow, ok := m.queueFlow[q]
+	if !ok {
+		flow = &memFlow{}
+		m.queueFlow[q] = flow
+	}
+	flow.joins += joins
+	flow.leaves += leaves
+}
+
+// incrementQueueLeaves учитывает уход игроков в счетчиках оттока их очередей
+func (m *InMemoryStorage) incrementQueueLeaves(players []models.Player) {
+	for _, p := range players {
+		m.incrementQueueFlow(p.Region, p.GameMode, 0, 1)
+	}
+}
+
+// UpdateAllPlayerRatings заменяет рейтинг каждого игрока на newRating(rating) вместе
+// с элементом его очереди, а также рейтинг после последнего матча, и возвращает
+// количество обновленных игроков
+func (m *InMemoryStorage) UpdateAllPlayerRatings(ctx context.Context, newRating func(rating int) int) (int64, error) {
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	updated := make(map[string]bool)
+	for id := range m.players {
+		ok, err := m.updatePlayerRating(id, now, newRating)
+		if err != nil {
+			return int64(len(updated)), err
+		}
+		if ok {
+			updated[id] = true
+		}
+	}
+	for id, rating := range m.ratings {
+		if newValue := newRating(rating); newValue != rating {
+			m.ratings[id] = newValue
+			updated[id] = true
+		}
+	}
+	return int64(len(updated)), nil
+}
+
+// updatePlayerRating заменяет рейтинг одного игрока в player:{id} и в элементе очереди.
+// Вызывается под блокировкой на запись.
+func (m *InMemoryStorage) updatePlayerRating(id string, now time.Time, newRating func(rating int) int) (bool, error) {
+	value, ok := m.players.get(id, now)
+	if !ok {
+		return false, nil
+	}
+
+	var player models.Player
+	if err := json.Unmarshal([]byte(value.data), &player); err != nil {
+		return false, nil
+	}
+
+	rating := newRating(player.Rating)
+	if rating == player.Rating {
+		return false, nil
+	}
+	player.Rating = rating
+
+	updatedJSON, err := json.Marshal(&player)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal player: %w", err)
+	}
+
+	m.players[id] = memValue{data: string(updatedJSON), expiresAt: value.expiresAt}
+	for _, queue := range m.playerQueues(&player) {
+		if _, ok := queue[value.data]; ok {
+			delete(queue, value.data)
+			queue[string(updatedJSON)] = float64(rating)
+		}
+	}
+	return true, nil
+}
+
+// UpdatePlayerRating сохраняет рейтинг игрока после матча. Если игрок уже снова стоит
+// в очереди, рейтинг обновляется и в его данных, и в элементе очереди.
+func (m *InMemoryStorage) UpdatePlayerRating(ctx context.Context, playerID string, newRating int) error {
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	m.ratings[playerID] = newRating
+	_, err := m.updatePlayerRating(playerID, now, func(int) int { return newRating })
+	return err
+}
+
+// GetPlayerRating возвращает рейтинг игрока, сохраненный после его последнего матча
+func (m *InMemoryStorage) GetPlayerRating(ctx context.Context, playerID string) (int, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rating, ok := m.ratings[playerID]
+	return rating, ok, nil
+}
+
+// SaveMatchResultRatings закрепляет результат матча и сохраняет новые рейтинги игроков.
+// Возвращает false, если результат уже применен: тогда рейтинги не меняются.
+func (m *InMemoryStorage) SaveMatchResultRatings(ctx context.Context, matchID string, ratings map[string]int) (bool, error) {
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.matchResults.get(matchID, now); ok {
+		return false, nil
+	}
+	m.matchResults[matchID] = memValue{data: "1", expiresAt: now.Add(matchRecordTTL)}
+	for playerID, rating := range ratings {
+		m.ratings[playerID] = rating
+	}
+	for playerID, rating := range ratings {
+		rating := rating
+		if _, err := m.updatePlayerRating(playerID, now, func(int) int { return rating }); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// InspectMemoryUsage оценивает объем данных по тем же шаблонам ключей, что и RedisStorage.
+// Размер ключа — длина хранимых строк без служебных затрат, поэтому оценка ниже, чем в Redis.
+func (m *InMemoryStorage) InspectMemoryUsage(ctx context.Context) (*models.MemoryUsageReport, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	sizes := make(map[string][]int, len(memoryKeyPatterns))
+	add := func(pattern string, size int) {
+		sizes[pattern] = append(sizes[pattern], size)
+	}
+	addZSets := func(pattern string, sets map[memQueue]memZSet) {
+		for _, z := range sets {
+			if len(z) > 0 {
+				add(pattern, memZSetSize(z))
+			}
+		}
+	}
+	addValues := func(pattern string, values memValues[string]) {
+		for _, value := range values {
+			if value.alive(now) {
+				add(pattern, len(value.data))
+			}
+		}
+	}
+
+	addZSets("queue:*", m.queues)
+	addZSets("queue:*", m.premiumQueues)
+	addZSets("queue:*", m.suspectQueues)
+	addZSets("queue:*", m.calibQueues)
+	addValues("player:*", m.players)
+	addValues("match:*", m.matches)
+	addValues("match:*", m.matchRecords)
+	for _, history := range m.matchHistory {
+		size := 0
+		for _, match := range history {
+			size += len(match)
+		}
+		add("history:*", size)
+	}
+	for _, history := range m.queueHistory {
+		add("history:*", memZSetSize(history))
+	}
+	for id, profile := range m.profiles {
+		profileJSON, _ := json.Marshal(profile)
+		add("profile:*", len(profileJSON))
+		if tokens := m.deviceTokens[id]; len(tokens) > 0 {
+			add("profile:*", len(strings.Join(tokens, "")))
+		}
+	}
+	for _, history := range m.ratingHistory {
+		add("rating:*", memZSetSize(history))
+	}
+	for _, rating := range m.ratings {
+		add("rating:*", len(strconv.Itoa(rating)))
+	}
+	for _, changes := range m.ratingChanges {
+		add("rating_history:*", memZSetSize(changes))
+	}
+	for _, at := range m.lastActive {
+		add("last_active:*", len(strconv.FormatInt(at.Unix(), 10)))
+	}
+	for range m.modePopularity {
+		add("stats:*", HoursPerDay*8)
+	}
+	for range m.waitStats {
+		add("stats:*", HoursPerDay*16)
+	}
+	for range m.queueFlow {
+		add("stats:*", 16)
+	}
+	for _, z := range m.segmentMatches {
+		add("stats:*", memZSetSize(z))
+	}
+	addZSets("stats:*", m.matchedRatings)
+	addZSets("scheduled:*", m.scheduled)
+
+	report := &models.MemoryUsageReport{
+		Patterns:    make([]models.KeyPatternUsage, 0, len(memoryKeyPatterns)),
+		GeneratedAt: now.UTC(),
+	}
+	for _, pattern := range memoryKeyPatterns {
+		usage := models.KeyPatternUsage{
+			Pattern:     pattern,
+			TotalKeys:   int64(len(sizes[pattern])),
+			SampledKeys: len(sizes[pattern]),
+		}
+		if usage.SampledKeys > 0 {
+			total := 0
+			for _, size := range sizes[pattern] {
+				total += size
+			}
+			usage.AvgBytesPerKey = float64(total) / float64(usage.SampledKeys)
+			usage.EstimatedTotalMB = float64(total) / (1024 * 1024)
+		}
+		report.Patterns = append(report.Patterns, usage)
+		report.EstimatedTotalMB += usage.EstimatedTotalMB
+	}
+	return report, nil
+}
+
+// memZSetSize оценивает размер упорядоченного множества: элементы и их score
+func memZSetSize(z memZSet) int {
+	size := 0
+	for member := range z {
+		size += len(member) + 8
+	}
+	return size
+}
+
+// SaveMatch сохраняет матч для всех игроков и по его ID
+func (m *InMemoryStorage) SaveMatch(ctx context.Context, match *models.Match) error {
+	matchJSON, err := json.Marshal(match)
+	if err != nil {
+		return fmt.Errorf("failed to marshal match: %w", err)
+	}
+
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	expiresAt := matchExpiresAt(match, match.CreatedAt)
+	for _, p := range match.Players {
+		m.matches[p.ID] = memValue{data: string(matchJSON), expiresAt: now.Add(matchKeyTTL(expiresAt, now))}
+	}
+	m.matchRecords[match.MatchID] = memValue{data: string(matchJSON), expiresAt: now.Add(matchRecordTTL)}
+	m.recordMatchHistory(match.Players, string(matchJSON))
+	m.expiries = append(m.expiries, memExpiry{expiry: MatchExpiry{MatchID: match.MatchID}, expiresAt: expiresAt})
+	return nil
+}
+
+// RunAtomicMatchFormation проверяет, что все игроки матча еще в очереди, сохраняет матч
+// и удаляет игроков из очереди. Если хотя бы один игрок уже удален, ничего не меняется
+// и возвращается ErrMatchConflict.
+func (m *InMemoryStorage) RunAtomicMatchFormation(ctx context.Context, match *models.Match) error {
+	if len(match.Players) == 0 {
+		return fmt.Errorf("match has no players")
+	}
+
+	matchJSON, err := json.Marshal(match)
+	if err != nil {
+		return fmt.Errorf("failed to marshal match: %w", err)
+	}
+
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	// Боты не стояли в очереди: проверяются и убираются только живые игроки
+	humans := match.HumanPlayers()
+	expiresAt := matchExpiresAt(match, match.CreatedAt)
+	if !m.claimQueuedPlayers(humans, now, func(p models.Player) {
+		m.matches[p.ID] = memValue{data: string(matchJSON), expiresAt: now.Add(matchKeyTTL(expiresAt, now))}
+	}) {
+		return ErrMatchConflict
+	}
+	m.matchRecords[match.MatchID] = memValue{data: string(matchJSON), expiresAt: now.Add(matchRecordTTL)}
+
+	m.incrementQueueLeaves(humans)
+	m.recordMatchHistory(humans, string(matchJSON))
+	m.expiries = append(m.expiries, memExpiry{expiry: MatchExpiry{MatchID: match.MatchID}, expiresAt: expiresAt})
+	return nil
+}
+
+// claimQueuedPlayers проверяет, что все игроки еще в очереди, и только тогда убирает их
+// из очереди и ключей игроков, вызывая claim для каждого. Аналог проверки в Lua-скриптах
+// form_match и create_pending_match.
+func (m *InMemoryStorage) claimQueuedPlayers(players []models.Player, now time.Time, claim func(p models.Player)) bool {
+	members := make([]string, len(players))
+	for i := range players {
+		value, ok := m.players.get(players[i].ID, now)
+		if !ok {
+			return false
+		}
+		if _, ok := m.playerQueue(&players[i])[value.data]; !ok {
+			return false
+		}
+		members[i] = value.data
+	}
+
+	for i, p := range players {
+		claim(p)
+		// Игрок с несколькими режимами поиска уходит из очередей всех режимов
+		for _, queue := range m.playerQueues(&players[i]) {
+			delete(queue, members[i])
+		}
+		delete(m.players, p.ID)
+	}
+	return true
+}
+
+// GetMatchByPlayerID возвращает матч для игрока
+func (m *InMemoryStorage) GetMatchByPlayerID(ctx context.Context, playerID string) (*models.Match, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return unmarshalMemMatch(m.matches.get(playerID, time.Now()))
+}
+
+// GetMatchByID возвращает матч по его ID
+func (m *InMemoryStorage) GetMatchByID(ctx context.Context, matchID string) (*models.Match, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return unmarshalMemMatch(m.matchRecords.get(matchID, time.Now()))
+}
+
+// unmarshalMemMatch разбирает сохраненный матч или возвращает ErrMatchNotFound
+func unmarshalMemMatch(value memValue, ok bool) (*models.Match, error) {
+	if !ok {
+		return nil, ErrMatchNotFound
+	}
+
+	var match models.Match
+	if err := json.Unmarshal([]byte(value.data), &match); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal match: %w", err)
+	}
+	return &match, nil
+}
+
+// RemoveMatch удаляет матч для игрока
+func (m *InMemoryStorage) RemoveMatch(ctx context.Context, playerID string) error {
+	m.lock()
+	defer m.mu.Unlock()
+
+	delete(m.matches, playerID)
+	return nil
+}
+
+// UpdateMatch перезаписывает сохраненный матч по его ID и у всех его игроков, не меняя
+// срок хранения. Истекшие значения не восстанавливаются.
+func (m *InMemoryStorage) UpdateMatch(ctx context.Context, match *models.Match) error {
+	matchJSON, err := json.Marshal(match)
+	if err != nil {
+		return fmt.Errorf("failed to marshal match: %w", err)
+	}
+
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	for _, p := range match.Players {
+		if value, ok := m.matches.get(p.ID, now); ok {
+			m.matches[p.ID] = memValue{data: string(matchJSON), expiresAt: value.expiresAt}
+		}
+	}
+
+	record, ok := m.matchRecords.get(match.MatchID, now)
+	if !ok {
+		return ErrMatchNotFound
+	}
+	m.matchRecords[match.MatchID] = memValue{data: string(matchJSON), expiresAt: record.expiresAt}
+	return nil
+}
+
+// UpdateMatchMetadata добавляет поля в Metadata сохраненного матча, не меняя срок его хранения
+func (m *InMemoryStorage) UpdateMatchMetadata(ctx context.Context, matchID string, metadata map[string]interface{}) (*models.Match, error) {
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	record, ok := m.matchRecords.get(matchID, now)
+	match, err := unmarshalMemMatch(record, ok)
+	if err != nil {
+		return nil, err
+	}
+
+	if match.Metadata == nil {
+		match.Metadata = make(map[string]interface{}, len(metadata))
+	}
+	for k, v := range metadata {
+		match.Metadata[k] = v
+	}
+
+	matchJSON, err := json.Marshal(match)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal match: %w", err)
+	}
+	m.matchRecords[matchID] = memValue{data: string(matchJSON), expiresAt: record.expiresAt}
+	return match, nil
+}
+
+// ClaimBackfillPlayers атомарно убирает из очереди игроков, добранных в уже созданный матч,
+// и сохраняет им обновленный матч. Если кто-то из них уже покинул очередь, ничего
+// не меняется и возвращается ErrMatchConflict.
+func (m *InMemoryStorage) ClaimBackfillPlayers(ctx context.Context, match *models.Match, newPlayers []models.Player) error {
+	if len(newPlayers) == 0 {
+		return fmt.Errorf("no backfill players")
+	}
+
+	matchJSON, err := json.Marshal(match)
+	if err != nil {
+		return fmt.Errorf("failed to marshal match: %w", err)
+	}
+
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	if !m.claimQueuedPlayers(newPlayers, now, func(p models.Player) {
+		m.matches[p.ID] = memValue{data: string(matchJSON), expiresAt: now.Add(MatchTTL)}
+	}) {
+		return ErrMatchConflict
+	}
+	m.matchRecords[match.MatchID] = memValue{data: string(matchJSON), expiresAt: now.Add(matchRecordTTL)}
+
+	m.incrementQueueLeaves(newPlayers)
+	m.recordMatchHistory(newPlayers, string(matchJSON))
+	return nil
+}
+
+// AppendMatchHistory добавляет матч в начало истории игрока и обрезает ее до MatchHistoryLimit записей
+func (m *InMemoryStorage) AppendMatchHistory(ctx context.Context, playerID string, match *models.Match) error {
+	matchJSON, err := json.Marshal(match)
+	if err != nil {
+		return fmt.Errorf("failed to marshal match: %w", err)
+	}
+
+	m.lock()
+	defer m.mu.Unlock()
+
+	m.appendMatchHistory(playerID, string(matchJSON))
+	return nil
+}
+
+// appendMatchHistory добавляет JSON матча в начало истории игрока
+func (m *InMemoryStorage) appendMatchHistory(playerID, matchJSON string) {
+	history := append([]string{matchJSON}, m.matchHistory[playerID]...)
+	if len(history) > MatchHistoryLimit {
+		history = history[:MatchHistoryLimit]
+	}
+	m.matchHistory[playerID] = history
+}
+
+// recordMatchHistory добавляет матч в историю каждого из игроков и увеличивает их match_count
+func (m *InMemoryStorage) recordMatchHistory(players []models.Player, matchJSON string) {
+	for _, p := range players {
+		m.appendMatchHistory(p.ID, matchJSON)
+		m.incrementStat(p.ID, StatMatchCount, 1)
+	}
+}
+
+// IncrementStat увеличивает счетчик statName статистики игрока на delta
+func (m *InMemoryStorage) IncrementStat(ctx context.Context, playerID, statName string, delta float64) error {
+	m.lock()
+	defer m.mu.Unlock()
+
+	m.incrementStat(playerID, statName, delta)
+	return nil
+}
+
+// incrementStat увеличивает счетчик статистики игрока. Вызывается под m.mu.
+func (m *InMemoryStorage) incrementStat(playerID, statName string, delta float64) {
+	stats, ok := m.playerStats[playerID]
+	if !ok {
+		stats = make(map[string]float64)
+		m.playerStats[playerID] = stats
+	}
+	stats[statName] += delta
+}
+
+// GetPlayerStats возвращает все счетчики статистики игрока (пустой набор, если их нет)
+func (m *InMemoryStorage) GetPlayerStats(ctx context.Context, playerID string) (map[string]float64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stats := make(map[string]float64, len(m.playerStats[playerID]))
+	for name, value := range m.playerStats[playerID] {
+		stats[name] = value
+	}
+	return stats, nil
+}
+
+// RecordPlayerWaitTime добавляет ожидание матча в начало списка игрока (до PlayerWaitHistoryLimit записей)
+func (m *InMemoryStorage) RecordPlayerWaitTime(ctx context.Context, playerID string, wait time.Duration) error {
+	m.lock()
+	defer m.mu.Unlock()
+
+	waits := append([]time.Duration{wait}, m.playerWaits[playerID]...)
+	if len(waits) > PlayerWaitHistoryLimit {
+		waits = waits[:PlayerWaitHistoryLimit]
+	}
+	m.playerWaits[playerID] = waits
+	return nil
+}
+
+// GetPlayerWaitTimes возвращает последние ожидания матча игрока, начиная с самого нового
+func (m *InMemoryStorage) GetPlayerWaitTimes(ctx context.Context, playerID string) ([]time.Duration, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return append([]time.Duration{}, m.playerWaits[playerID]...), nil
+}
+
+// GetMatchHistory возвращает до limit последних матчей игрока, начиная с самого нового
+func (m *InMemoryStorage) GetMatchHistory(ctx context.Context, playerID string, limit int) ([]*models.Match, error) {
+	if limit <= 0 || limit > MatchHistoryLimit {
+		limit = MatchHistoryLimit
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	history := m.matchHistory[playerID]
+	if len(history) > limit {
+		history = history[:limit]
+	}
+
+	matches := make([]*models.Match, 0, len(history))
+	for _, entry := range history {
+		var match models.Match
+		if err := json.Unmarshal([]byte(entry), &match); err != nil {
+			continue
+		}
+		matches = append(matches, &match)
+	}
+	return matches, nil
+}
+
+// SaveScheduledMatch сохраняет матч в набор запланированных матчей региона/режима
+func (m *InMemoryStorage) SaveScheduledMatch(ctx context.Context, region, gameMode string, match *models.Match) error {
+	if match.ScheduledStartTime == nil {
+		return fmt.Errorf("match has no scheduled start time")
+	}
+
+	matchJSON, err := json.Marshal(match)
+	if err != nil {
+		return fmt.Errorf("failed to marshal match: %w", err)
+	}
+
+	m.lock()
+	defer m.mu.Unlock()
+
+	memZSetOf(m.scheduled, memQueue{region, gameMode})[string(matchJSON)] = float64(match.ScheduledStartTime.Unix())
+	return nil
+}
+
+// PromoteScheduledMatches делает доступными игрокам запланированные матчи, время начала
+// которых наступило к моменту now
+func (m *InMemoryStorage) PromoteScheduledMatches(ctx context.Context, region, gameMode string, now time.Time) ([]*models.Match, error) {
+	current := m.lock()
+	defer m.mu.Unlock()
+
+	scheduled := m.scheduled[memQueue{region, gameMode}]
+	members := scheduled.rangeByScore(0, float64(now.Unix()))
+
+	promoted := make([]*models.Match, 0, len(members))
+	for _, member := range members {
+		delete(scheduled, member)
+
+		var match models.Match
+		if err := json.Unmarshal([]byte(member), &match); err != nil {
+			continue
+		}
+
+		expiresAt := matchExpiresAt(&match, now)
+		for _, p := range match.Players {
+			m.matches[p.ID] = memValue{data: member, expiresAt: current.Add(matchKeyTTL(expiresAt, now))}
+		}
+		m.matchRecords[match.MatchID] = memValue{data: member, expiresAt: current.Add(matchRecordTTL)}
+		m.recordMatchHistory(match.Players, member)
+		m.expiries = append(m.expiries, memExpiry{expiry: MatchExpiry{MatchID: match.MatchID}, expiresAt: expiresAt})
+
+		promoted = append(promoted, &match)
+	}
+	return promoted, nil
+}
+
+// ScanExpiredMatches возвращает сохраненные матчи, у которых ExpiresAt уже прошел
+func (m *InMemoryStorage) ScanExpiredMatches(ctx context.Context) ([]*models.Match, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	expired := make([]*models.Match, 0)
+	for matchID := range m.matchRecords {
+		value, ok := m.matchRecords.get(matchID, now)
+		if !ok {
+			continue
+		}
+		var match models.Match
+		if err := json.Unmarshal([]byte(value.data), &match); err != nil {
+			continue
+		}
+		if !match.ExpiresAt.IsZero() && match.ExpiresAt.Before(now) {
+			expired = append(expired, &match)
+		}
+	}
+	return expired, nil
+}
+
+// WatchForMatchExpiry раз в секунду вызывает handler для матчей, срок которых прошел.
+// Матч, для которого handler вернул ошибку, обрабатывается повторно не раньше чем через
+// matchExpiryRetryDelay и не более matchExpiryMaxDeliveries раз. Блокируется до отмены ctx.
+func (m *InMemoryStorage) WatchForMatchExpiry(ctx context.Context, handler MatchExpiryHandler) error {
+	ticker := time.NewTicker(memExpiryPoll)
+	defer ticker.Stop()
+
+	for {
+		m.processMatchExpiry(ctx, handler)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// processMatchExpiry передает handler истекшие матчи и возвращает в список необработанные
+func (m *InMemoryStorage) processMatchExpiry(ctx context.Context, handler MatchExpiryHandler) {
+	m.mu.Lock()
+	now := time.Now()
+	var due []memExpiry
+	waiting := make([]memExpiry, 0, len(m.expiries))
+	for _, e := range m.expiries {
+		if now.Before(e.expiresAt) || now.Before(e.retryAt) {
+			waiting = append(waiting, e)
+		} else {
+			due = append(due, e)
+		}
+	}
+	m.expiries = waiting
+	m.mu.Unlock()
+
+	var failed []memExpiry
+	for _, e := range due {
+		if err := handler(ctx, e.expiry); err == nil {
+			continue
+		}
+		if e.attempts++; e.attempts >= matchExpiryMaxDeliveries {
+			continue // Как и в Redis, после matchExpiryMaxDeliveries попыток запись отбрасывается
+		}
+		e.retryAt = time.Now().Add(matchExpiryRetryDelay)
+		failed = append(failed, e)
+	}
+
+	if len(failed) > 0 {
+		m.mu.Lock()
+		m.expiries = append(m.expiries, failed...)
+		m.mu.Unlock()
+	}
+}
+
+// CreatePendingMatch проверяет, что все игроки еще в очереди, убирает их из очереди
+// и сохраняет матч, ожидающий подтверждения, до ExpiresAt. Если хотя бы один игрок
+// уже удален, ничего не меняется и возвращается ErrMatchConflict.
+func (m *InMemoryStorage) CreatePendingMatch(ctx context.Context, pending *models.PendingMatch) error {
+	if len(pending.Players) == 0 {
+		return fmt.Errorf("pending match has no players")
+	}
+
+	pendingJSON, err := json.Marshal(pending)
+	if err != nil {
+		return fmt.Errorf("failed to marshal pending match: %w", err)
+	}
+
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	ttl := time.Until(pending.ExpiresAt).Truncate(time.Second)
+	if ttl < time.Second {
+		ttl = time.Second
+	}
+	value := memValue{data: string(pendingJSON), expiresAt: now.Add(ttl)}
+
+	if !m.claimQueuedPlayers(pending.Players, now, func(p models.Player) {
+		m.playerPending[p.ID] = value
+	}) {
+		return ErrMatchConflict
+	}
+	m.pending[pending.PendingID] = value
+	memZSetOf(m.pendingIndex, memQueue{pending.Region, pending.GameMode})[string(pendingJSON)] = float64(pending.ExpiresAt.Unix())
+	m.expiries = append(m.expiries, memExpiry{
+		expiry:    MatchExpiry{MatchID: pending.PendingID, Pending: true, Region: pending.Region, GameMode: pending.GameMode},
+		expiresAt: pending.ExpiresAt,
+	})
+
+	m.incrementQueueLeaves(pending.Players)
+	return nil
+}
+
+// GetPendingMatch возвращает матч, ожидающий подтверждения
+func (m *InMemoryStorage) GetPendingMatch(ctx context.Context, pendingID string) (*models.PendingMatch, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return unmarshalMemPendingMatch(m.pending.get(pendingID, time.Now()))
+}
+
+// GetPlayerPendingMatch возвращает матч игрока, ожидающий подтверждения
+func (m *InMemoryStorage) GetPlayerPendingMatch(ctx context.Context, playerID string) (*models.PendingMatch, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return unmarshalMemPendingMatch(m.playerPending.get(playerID, time.Now()))
+}
+
+// unmarshalMemPendingMatch разбирает ожидающий матч или возвращает ErrPendingMatchNotFound
+func unmarshalMemPendingMatch(value memValue, ok bool) (*models.PendingMatch, error) {
+	if !ok {
+		return nil, ErrPendingMatchNotFound
+	}
+
+	var pending models.PendingMatch
+	if err := json.Unmarshal([]byte(value.data), &pending); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal pending match: %w", err)
+	}
+	return &pending, nil
+}
+
+// AcceptPendingMatch отмечает подтверждение игрока. Возвращает true, если именно это
+// подтверждение оказалось последним недостающим.
+func (m *InMemoryStorage) AcceptPendingMatch(ctx context.Context, pending *models.PendingMatch, playerID string) (bool, error) {
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	set, ok := m.pendingAccepted[pending.PendingID]
+	if !ok || (!set.expiresAt.IsZero() && !now.Before(set.expiresAt)) {
+		set = &memSet{members: make(map[string]struct{})}
+		m.pendingAccepted[pending.PendingID] = set
+	}
+	set.expiresAt = pending.ExpiresAt.Add(pendingAcceptedGrace)
+
+	_, accepted := set.members[playerID]
+	set.members[playerID] = struct{}{}
+
+	return !accepted && len(set.members) == len(pending.PlayerIDs), nil
+}
+
+// GetAcceptedPlayers возвращает ID игроков, подтвердивших матч
+func (m *InMemoryStorage) GetAcceptedPlayers(ctx context.Context, pendingID string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ids := make([]string, 0)
+	set, ok := m.pendingAccepted[pendingID]
+	if !ok || (!set.expiresAt.IsZero() && !time.Now().Before(set.expiresAt)) {
+		return ids, nil
+	}
+	for id := range set.members {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// ClaimPendingMatch снимает матч с ожидания. Возвращает false, если матч уже снят другим вызовом.
+func (m *InMemoryStorage) ClaimPendingMatch(ctx context.Context, pending *models.PendingMatch) (bool, error) {
+	pendingJSON, err := json.Marshal(pending)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal pending match: %w", err)
+	}
+
+	m.lock()
+	defer m.mu.Unlock()
+
+	index := m.pendingIndex[memQueue{pending.Region, pending.GameMode}]
+	if _, ok := index[string(pendingJSON)]; !ok {
+		return false, nil
+	}
+	delete(index, string(pendingJSON))
+
+	delete(m.pending, pending.PendingID)
+	delete(m.pendingAccepted, pending.PendingID)
+	for _, playerID := range pending.PlayerIDs {
+		delete(m.playerPending, playerID)
+	}
+	return true, nil
+}
+
+// GetExpiredPendingMatches возвращает ожидающие матчи региона/режима, срок подтверждения
+// которых истек к моменту now. Матчи не снимаются с ожидания — см. ClaimPendingMatch.
+func (m *InMemoryStorage) GetExpiredPendingMatches(ctx context.Context, region, gameMode string, now time.Time) ([]*models.PendingMatch, error) {
+	m.lock()
+	defer m.mu.Unlock()
+
+	index := m.pendingIndex[memQueue{region, gameMode}]
+	members := index.rangeByScore(0, float64(now.Unix()))
+
+	expired := make([]*models.PendingMatch, 0, len(members))
+	for _, member := range members {
+		var pending models.PendingMatch
+		if err := json.Unmarshal([]byte(member), &pending); err != nil {
+			delete(index, member)
+			continue
+		}
+		expired = append(expired, &pending)
+	}
+	return expired, nil
+}
+
+// SetQueueCooldown запрещает игроку вход в очередь на время duration
+func (m *InMemoryStorage) SetQueueCooldown(ctx context.Context, playerID string, duration time.Duration) error {
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	m.cooldowns[playerID] = memValue{expiresAt: memExpiresAt(now, duration)}
+	return nil
+}
+
+// GetQueueCooldown возвращает оставшееся время запрета на вход в очередь (0 — запрета нет)
+func (m *InMemoryStorage) GetQueueCooldown(ctx context.Context, playerID string) (time.Duration, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	value, ok := m.cooldowns.get(playerID, now)
+	if !ok || value.expiresAt.IsZero() {
+		return 0, nil
+	}
+	return value.expiresAt.Sub(now), nil
+}
+
+// BanPlayer запрещает игроку вход в очередь на время duration.
+// Повторный бан заменяет срок предыдущего.
+func (m *InMemoryStorage) BanPlayer(ctx context.Context, playerID string, duration time.Duration) error {
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	bannedUntil := now.Add(duration)
+	m.bans[playerID] = memValue{
+		data:      strconv.FormatInt(bannedUntil.Unix(), 10),
+		expiresAt: memExpiresAt(now, duration),
+	}
+	return nil
+}
+
+// IsBanned проверяет, действует ли бан игрока
+func (m *InMemoryStorage) IsBanned(ctx context.Context, playerID string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, ok := m.bans.get(playerID, time.Now())
+	return ok, nil
+}
+
+// GetBanExpiry возвращает время окончания бана игрока (нулевое, если бана нет)
+func (m *InMemoryStorage) GetBanExpiry(ctx context.Context, playerID string) (time.Time, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	value, ok := m.bans.get(playerID, time.Now())
+	if !ok {
+		return time.Time{}, nil
+	}
+	unix, err := strconv.ParseInt(value.data, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get ban: %w", err)
+	}
+	return time.Unix(unix, 0), nil
+}
+
+// IncrementAbandonCount увеличивает счетчик выходов игрока из очереди без матча и
+// возвращает его новое значение. Срок жизни AbandonWindow ставится при первом выходе.
+func (m *InMemoryStorage) IncrementAbandonCount(ctx context.Context, playerID string) (int64, error) {
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	var count int64
+	expiresAt := now.Add(AbandonWindow)
+	if value, ok := m.abandons.get(playerID, now); ok {
+		count, _ = strconv.ParseInt(value.data, 10, 64)
+		expiresAt = value.expiresAt
+	}
+	count++
+	m.abandons[playerID] = memValue{data: strconv.FormatInt(count, 10), expiresAt: expiresAt}
+	return count, nil
+}
+
+// FlagPlayer отмечает игрока на время duration. Повторная отметка заменяет срок предыдущей.
+func (m *InMemoryStorage) FlagPlayer(ctx context.Context, playerID string, duration time.Duration) error {
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	m.flagged[playerID] = memValue{expiresAt: memExpiresAt(now, duration)}
+	return nil
+}
+
+// IsPlayerFlagged проверяет, действует ли отметка игрока
+func (m *InMemoryStorage) IsPlayerFlagged(ctx context.Context, playerID string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, ok := m.flagged.get(playerID, time.Now())
+	return ok, nil
+}
+
+// UnflagPlayer снимает отметку игрока и сбрасывает его счетчик выходов из очереди.
+// Возвращает false, если действующей отметки не было.
+func (m *InMemoryStorage) UnflagPlayer(ctx context.Context, playerID string) (bool, error) {
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	_, flagged := m.flagged.get(playerID, now)
+	delete(m.flagged, playerID)
+	delete(m.abandons, playerID)
+	return flagged, nil
+}
+
+// GetFlaggedPlayers возвращает ID игроков с действующей отметкой по алфавиту
+func (m *InMemoryStorage) GetFlaggedPlayers(ctx context.Context) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	ids := make([]string, 0, len(m.flagged))
+	for id := range m.flagged {
+		if _, ok := m.flagged.get(id, now); ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// CreateProfile сохраняет профиль игрока. Возвращает false, если профиль с таким ID
+// уже существует (он не перезаписывается).
+func (m *InMemoryStorage) CreateProfile(ctx context.Context, profile *models.PlayerProfile) (bool, error) {
+	m.lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.profiles[profile.PlayerID]; ok {
+		return false, nil
+	}
+
+	stored := *profile
+	stored.CreatedAt = profile.CreatedAt.UTC()
+	stored.DeviceTokens = nil
+	m.profiles[profile.PlayerID] = stored
+	return true, nil
+}
+
+// GetProfile возвращает профиль игрока или ErrProfileNotFound
+func (m *InMemoryStorage) GetProfile(ctx context.Context, playerID string) (*models.PlayerProfile, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	profile, ok := m.profiles[playerID]
+	if !ok {
+		return nil, ErrProfileNotFound
+	}
+	profile.DeviceTokens = append(make([]string, 0, len(m.deviceTokens[playerID])), m.deviceTokens[playerID]...)
+	return &profile, nil
+}
+
+// IncrementTotalMatches увеличивает счетчик сыгранных матчей в профиле игрока.
+// Игрок без профиля пропускается.
+func (m *InMemoryStorage) IncrementTotalMatches(ctx context.Context, playerID string) error {
+	m.lock()
+	defer m.mu.Unlock()
+
+	profile, ok := m.profiles[playerID]
+	if !ok {
+		return nil
+	}
+	profile.TotalMatches++
+	m.profiles[playerID] = profile
+	return nil
+}
+
+// RecordMatchOutcome продлевает серию побед или поражений в профиле игрока и
+// обнуляет противоположную серию. Игрок без профиля пропускается.
+func (m *InMemoryStorage) RecordMatchOutcome(ctx context.Context, playerID string, won bool) error {
+	m.lock()
+	defer m.mu.Unlock()
+
+	profile, ok := m.profiles[playerID]
+	if !ok {
+		return nil
+	}
+	if won {
+		profile.WinStreak++
+		profile.LossStreak = 0
+	} else {
+		profile.LossStreak++
+		profile.WinStreak = 0
+	}
+	m.profiles[playerID] = profile
+	return nil
+}
+
+// SetMomentum сохраняет импульс рейтинга игрока на ttl
+func (m *InMemoryStorage) SetMomentum(ctx context.Context, playerID string, momentum int, ttl time.Duration) error {
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	m.momentum[playerID] = memValue{data: strconv.Itoa(momentum), expiresAt: memExpiresAt(now, ttl)}
+	return nil
+}
+
+// GetMomentum возвращает сохраненный импульс рейтинга игрока
+func (m *InMemoryStorage) GetMomentum(ctx context.Context, playerID string) (int, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	value, ok := m.momentum.get(playerID, time.Now())
+	if !ok {
+		return 0, false, nil
+	}
+	momentum, err := strconv.Atoi(value.data)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid momentum: %w", err)
+	}
+	return momentum, true, nil
+}
+
+// AddDeviceToken регистрирует токен устройства игрока для push-уведомлений.
+// Возвращает ErrProfileNotFound, если игрок не зарегистрирован.
+func (m *InMemoryStorage) AddDeviceToken(ctx context.Context, playerID, token string) error {
+	m.lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.profiles[playerID]; !ok {
+		return ErrProfileNotFound
+	}
+	for _, existing := range m.deviceTokens[playerID] {
+		if existing == token {
+			return nil
+		}
+	}
+	m.deviceTokens[playerID] = append(m.deviceTokens[playerID], token)
+	return nil
+}
+
+// GetDeviceTokens возвращает токены устройств игрока
+func (m *InMemoryStorage) GetDeviceTokens(ctx context.Context, playerID string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return append(make([]string, 0, len(m.deviceTokens[playerID])), m.deviceTokens[playerID]...), nil
+}
+
+// AppendRatingSnapshot добавляет точку в историю рейтинга игрока и обрезает историю
+// до последних 200 записей
+func (m *InMemoryStorage) AppendRatingSnapshot(ctx context.Context, playerID string, point models.RatingPoint) error {
+	pointJSON, err := json.Marshal(point)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rating point: %w", err)
+	}
+
+	m.lock()
+	defer m.mu.Unlock()
+
+	history := memZSetOf(m.ratingHistory, playerID)
+	history[string(pointJSON)] = float64(point.Timestamp.UnixMilli())
+	history.trimToLast(ratingHistoryLimit)
+	return nil
+}
+
+// GetRatingHistory возвращает историю рейтинга игрока в хронологическом порядке
+func (m *InMemoryStorage) GetRatingHistory(ctx context.Context, playerID string) ([]models.RatingPoint, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	members := m.ratingHistory[playerID].rangeByScore(math.Inf(-1), math.Inf(1))
+	points := make([]models.RatingPoint, 0, len(members))
+	for _, member := range members {
+		var point models.RatingPoint
+		if err := json.Unmarshal([]byte(member), &point); err != nil {
+			continue
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+// RecordRatingChange добавляет изменение рейтинга игрока после матча и обрезает
+// историю до последних 100 записей
+func (m *InMemoryStorage) RecordRatingChange(ctx context.Context, playerID string, delta int, timestamp time.Time) error {
+	m.lock()
+	defer m.mu.Unlock()
+
+	changes := memZSetOf(m.ratingChanges, playerID)
+	changes[ratingChangeMember(delta, timestamp)] = float64(timestamp.UnixMilli())
+	changes.trimToLast(ratingChangesLimit)
+	return nil
+}
+
+// GetRecentRatingChanges возвращает до limit последних изменений рейтинга игрока,
+// начиная с самого нового
+func (m *InMemoryStorage) GetRecentRatingChanges(ctx context.Context, playerID string, limit int) ([]int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if limit <= 0 {
+		return []int{}, nil
+	}
+
+	members := m.ratingChanges[playerID].rangeByScore(math.Inf(-1), math.Inf(1))
+	if len(members) > limit {
+		members = members[len(members)-limit:]
+	}
+	for i, j := 0, len(members)-1; i < j; i, j = i+1, j-1 {
+		members[i], members[j] = members[j], members[i]
+	}
+	return parseRatingChanges(members), nil
+}
+
+// RecordEncounter запоминает, что игроки сыграли друг против друга. Каждый игрок попадает
+// в множество недавних соперников другого; срок жизни множества продлевается до ttl.
+func (m *InMemoryStorage) RecordEncounter(ctx context.Context, p1ID, p2ID string, ttl time.Duration) error {
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	m.addEncounter(p1ID, p2ID, now, ttl)
+	m.addEncounter(p2ID, p1ID, now, ttl)
+	return nil
+}
+
+// addEncounter добавляет соперника в множество игрока. Вызывается под блокировкой на запись.
+func (m *InMemoryStorage) addEncounter(playerID, opponentID string, now time.Time, ttl time.Duration) {
+	set, ok := m.encounters[playerID]
+	if !ok || (!set.expiresAt.IsZero() && !now.Before(set.expiresAt)) {
+		set = &memSet{members: make(map[string]struct{})}
+		m.encounters[playerID] = set
+	}
+	set.members[opponentID] = struct{}{}
+	set.expiresAt = memExpiresAt(now, ttl)
+}
+
+// HaveRecentlyMet проверяет, играли ли игроки друг против друга за время жизни журнала встреч
+func (m *InMemoryStorage) HaveRecentlyMet(ctx context.Context, p1ID, p2ID string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	set, ok := m.encounters[p1ID]
+	if !ok || (!set.expiresAt.IsZero() && !time.Now().Before(set.expiresAt)) {
+		return false, nil
+	}
+	_, met := set.members[p2ID]
+	return met, nil
+}
+
+// IncrementModePopularity увеличивает счетчик созданных матчей режима в указанный час суток (UTC)
+func (m *InMemoryStorage) IncrementModePopularity(ctx context.Context, region, gameMode string, hour int) error {
+	if hour < 0 || hour >= HoursPerDay {
+		return fmt.Errorf("invalid hour %d", hour)
+	}
+
+	m.lock()
+	defer m.mu.Unlock()
+
+	q := memQueue{region, gameMode}
+	counts, ok := m.modePopularity[q]
+	if !ok {
+		counts = &[HoursPerDay]int64{}
+		m.modePopularity[q] = counts
+	}
+	counts[hour]++
+	return nil
+}
+
+// RecordWaitTime добавляет время ожидания игрока в почасовую статистику режима
+func (m *InMemoryStorage) RecordWaitTime(ctx context.Context, region, gameMode string, hour int, waitSeconds float64) error {
+	if hour < 0 || hour >= HoursPerDay {
+		return fmt.Errorf("invalid hour %d", hour)
+	}
+
+	m.lock()
+	defer m.mu.Unlock()
+
+	q := memQueue{region, gameMode}
+	stats, ok := m.waitStats[q]
+	if !ok {
+		stats = &[HoursPerDay]memWaitStat{}
+		m.waitStats[q] = stats
+	}
+	stats[hour].sum += waitSeconds
+	stats[hour].count++
+	return nil
+}
+
+// GetHourlyAvgWait возвращает среднее время ожидания (в секундах) для каждого часа суток.
+// Для часов без данных возвращается 0.
+func (m *InMemoryStorage) GetHourlyAvgWait(ctx context.Context, region, gameMode string) ([HoursPerDay]float64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var result [HoursPerDay]float64
+	if stats, ok := m.waitStats[memQueue{region, gameMode}]; ok {
+		for hour, stat := range stats {
+			if stat.count > 0 {
+				result[hour] = stat.sum / float64(stat.count)
+			}
+		}
+	}
+	return result, nil
+}
+
+// GetHourlyMatchCounts возвращает количество созданных матчей для каждого часа суток
+func (m *InMemoryStorage) GetHourlyMatchCounts(ctx context.Context, region, gameMode string) ([HoursPerDay]int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var result [HoursPerDay]int64
+	if counts, ok := m.modePopularity[memQueue{region, gameMode}]; ok {
+		result = *counts
+	}
+	return result, nil
+}
+
+// RecordSegmentMatch отмечает матч, созданный в рейтинговом сегменте, и удаляет записи старше часа
+func (m *InMemoryStorage) RecordSegmentMatch(ctx context.Context, region, gameMode, bracket, matchID string, createdAt time.Time) error {
+	m.lock()
+	defer m.mu.Unlock()
+
+	matches := memZSetOf(m.segmentMatches, memSegment{memQueue{region, gameMode}, bracket})
+	matches[matchID] = float64(createdAt.Unix())
+	matches.removeBelow(float64(createdAt.Add(-segmentMatchesWindow).Unix()))
+	return nil
+}
+
+// CountSegmentMatchesLastHour возвращает количество матчей сегмента за последний час
+func (m *InMemoryStorage) CountSegmentMatchesLastHour(ctx context.Context, region, gameMode, bracket string) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	since := time.Now().Add(-segmentMatchesWindow).Unix()
+	return m.segmentMatches[memSegment{memQueue{region, gameMode}, bracket}].count(float64(since), math.Inf(1)), nil
+}
+
+// RecordMatchedRatings сохраняет рейтинги игроков матча и удаляет записи старше суток
+func (m *InMemoryStorage) RecordMatchedRatings(ctx context.Context, region, gameMode string, match *models.Match) error {
+	m.lock()
+	defer m.mu.Unlock()
+
+	ratings := memZSetOf(m.matchedRatings, memQueue{region, gameMode})
+	score := float64(match.CreatedAt.Unix())
+	for _, p := range match.Players {
+		ratings[fmt.Sprintf("%s|%s|%d", match.MatchID, p.ID, p.Rating)] = score
+	}
+	ratings.removeBelow(float64(match.CreatedAt.Add(-matchedRatingsWindow).Unix()))
+	return nil
+}
+
+// GetMatchedRatings возвращает рейтинги игроков, попавших в матч за последние сутки
+func (m *InMemoryStorage) GetMatchedRatings(ctx context.Context, region, gameMode string) ([]int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	since := time.Now().Add(-matchedRatingsWindow).Unix()
+	members := m.matchedRatings[memQueue{region, gameMode}].rangeByScore(float64(since), math.Inf(1))
+
+	ratings := make([]int, 0, len(members))
+	for _, member := range members {
+		parts := strings.Split(member, "|")
+		rating, err := strconv.Atoi(parts[len(parts)-1])
+		if err != nil {
+			continue
+		}
+		ratings = append(ratings, rating)
+	}
+	return ratings, nil
+}
+
+// RecordMatchCreationTime сохраняет время ожидания игрока до создания матча
+// и удаляет записи старше часа
+func (m *InMemoryStorage) RecordMatchCreationTime(ctx context.Context, region, gameMode string, waitDuration time.Duration) error {
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	samples := memZSetOf(m.waitTimes, memQueue{region, gameMode})
+	samples[fmt.Sprintf("%d|%d", now.UnixNano(), waitDuration.Milliseconds())] = float64(now.Unix())
+	samples.removeBelow(float64(now.Add(-waitTimesWindow).Unix()))
+	return nil
+}
+
+// GetWaitTimeSamples возвращает времена ожидания игроков до матча за последний час
+func (m *InMemoryStorage) GetWaitTimeSamples(ctx context.Context, region, gameMode string) ([]time.Duration, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	since := time.Now().Add(-waitTimesWindow).Unix()
+	members := m.waitTimes[memQueue{region, gameMode}].rangeByScore(float64(since), math.Inf(1))
+
+	samples := make([]time.Duration, 0, len(members))
+	for _, member := range members {
+		_, rawWait, ok := strings.Cut(member, "|")
+		if !ok {
+			continue
+		}
+		waitMs, err := strconv.ParseInt(rawWait, 10, 64)
+		if err != nil {
+			continue
+		}
+		samples = append(samples, time.Duration(waitMs)*time.Millisecond)
+	}
+	return samples, nil
+}
+
+// GetAverageWaitTime возвращает среднее время ожидания до матча за последний час
+// (0, если матчей не было)
+func (m *InMemoryStorage) GetAverageWaitTime(ctx context.Context, region, gameMode string) (time.Duration, error) {
+	samples, err := m.GetWaitTimeSamples(ctx, region, gameMode)
+	if err != nil || len(samples) == 0 {
+		return 0, err
+	}
+
+	var total time.Duration
+	for _, d := range samples {
+		total += d
+	}
+	return total / time.Duration(len(samples)), nil
+}
+
+// CreateSeason сохраняет границу сезона. Если сезон уже начат, возвращается ErrSeasonExists.
+func (m *InMemoryStorage) CreateSeason(ctx context.Context, season *models.Season) error {
+	seasonJSON, err := json.Marshal(season)
+	if err != nil {
+		return fmt.Errorf("failed to marshal season: %w", err)
+	}
+
+	m.lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.seasons[season.SeasonID]; ok {
+		return ErrSeasonExists
+	}
+	m.seasons[season.SeasonID] = string(seasonJSON)
+	return nil
+}
+
+// UpdateSeason перезаписывает данные уже начатого сезона
+func (m *InMemoryStorage) UpdateSeason(ctx context.Context, season *models.Season) error {
+	seasonJSON, err := json.Marshal(season)
+	if err != nil {
+		return fmt.Errorf("failed to marshal season: %w", err)
+	}
+
+	m.lock()
+	defer m.mu.Unlock()
+
+	m.seasons[season.SeasonID] = string(seasonJSON)
+	return nil
+}
+
+// SaveTournament сохраняет турнир на TournamentTTL
+func (m *InMemoryStorage) SaveTournament(ctx context.Context, tournament *models.Tournament) error {
+	tournamentJSON, err := json.Marshal(tournament)
+	if err != nil {
+		return fmt.Errorf("failed to marshal tournament: %w", err)
+	}
+
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	m.tournaments[tournament.TournamentID] = memValue{
+		data:      string(tournamentJSON),
+		expiresAt: memExpiresAt(now, TournamentTTL),
+	}
+	return nil
+}
+
+// GetTournament возвращает турнир или ErrTournamentNotFound
+func (m *InMemoryStorage) GetTournament(ctx context.Context, tournamentID string) (*models.Tournament, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	value, ok := m.tournaments.get(tournamentID, time.Now())
+	if !ok {
+		return nil, ErrTournamentNotFound
+	}
+
+	var tournament models.Tournament
+	if err := json.Unmarshal([]byte(value.data), &tournament); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal tournament: %w", err)
+	}
+	return &tournament, nil
+}
+
+// RegisterServer регистрирует игровой сервер. Повторная регистрация обновляет адрес
+// и вместимость, сохраняя текущую загрузку.
+func (m *InMemoryStorage) RegisterServer(ctx context.Context, server *models.Server) error {
+	m.lock()
+	defer m.mu.Unlock()
+
+	if oldRegion, ok := m.serverIndex[server.ServerID]; ok {
+		if oldRegion == server.Region {
+			// Идущие на сервере матчи продолжают занимать слоты
+			server.Load = m.servers[oldRegion][server.ServerID].Load
+		} else {
+			// Сервер переехал в другой регион — убираем его из прежнего
+			delete(m.servers[oldRegion], server.ServerID)
+		}
+	}
+
+	servers, ok := m.servers[server.Region]
+	if !ok {
+		servers = make(map[string]models.Server)
+		m.servers[server.Region] = servers
+	}
+	servers[server.ServerID] = *server
+	m.serverIndex[server.ServerID] = server.Region
+	return nil
+}
+
+// DeregisterServer снимает игровой сервер с учета
+func (m *InMemoryStorage) DeregisterServer(ctx context.Context, serverID string) error {
+	m.lock()
+	defer m.mu.Unlock()
+
+	region, ok := m.serverIndex[serverID]
+	if !ok {
+		return ErrServerNotFound
+	}
+	delete(m.servers[region], serverID)
+	delete(m.serverIndex, serverID)
+	return nil
+}
+
+// AcquireLeastLoadedServer выбирает сервер региона с наименьшей долей занятых слотов
+// и занимает на нем слот. Если свободных серверов нет, возвращается ErrNoServerAvailable.
+func (m *InMemoryStorage) AcquireLeastLoadedServer(ctx context.Context, region string) (*models.Server, error) {
+	m.lock()
+	defer m.mu.Unlock()
+
+	servers := m.servers[region]
+	ids := make([]string, 0, len(servers))
+	for id := range servers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var best *models.Server
+	var bestRatio float64
+	for _, id := range ids {
+		server := servers[id]
+		if server.Capacity <= 0 || server.Load >= server.Capacity {
+			continue
+		}
+		ratio := float64(server.Load) / float64(server.Capacity)
+		if best == nil || ratio < bestRatio {
+			best = &server
+			bestRatio = ratio
+		}
+	}
+	if best == nil {
+		return nil, ErrNoServerAvailable
+	}
+
+	best.Load++
+	servers[best.ServerID] = *best
+	return best, nil
+}
+
+// ReleaseServer освобождает слот сервера после окончания матча
+func (m *InMemoryStorage) ReleaseServer(ctx context.Context, region, serverID string) error {
+	m.lock()
+	defer m.mu.Unlock()
+
+	server, ok := m.servers[region][serverID]
+	if !ok {
+		return nil // Сервер уже снят с учета
+	}
+	if server.Load > 0 {
+		server.Load--
+	}
+	m.servers[region][serverID] = server
+	return nil
+}
+
+// AcquireLock пытается захватить блокировку key для owner на время ttl
+func (m *InMemoryStorage) AcquireLock(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.locks.get(key, now); ok {
+		return false, nil
+	}
+	m.locks[key] = memValue{data: owner, expiresAt: memExpiresAt(now, ttl)}
+	return true, nil
+}
+
+// RenewLock продлевает блокировку; возвращает false, если она уже принадлежит другому владельцу
+func (m *InMemoryStorage) RenewLock(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	value, ok := m.locks.get(key, now)
+	if !ok || value.data != owner {
+		return false, nil
+	}
+	m.locks[key] = memValue{data: owner, expiresAt: memExpiresAt(now, ttl)}
+	return true, nil
+}
+
+// ReleaseLock снимает блокировку, если она принадлежит owner
+func (m *InMemoryStorage) ReleaseLock(ctx context.Context, key, owner string) error {
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	if value, ok := m.locks.get(key, now); ok && value.data == owner {
+		delete(m.locks, key)
+	}
+	return nil
+}
+
+// Publish рассылает сообщение подписчикам канала этого процесса. Как и в Redis pub/sub,
+// доставка не гарантируется: подписчику с заполненным буфером сообщение не достается.
+func (m *InMemoryStorage) Publish(ctx context.Context, channel string, payload []byte) error {
+	message := append([]byte(nil), payload...)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, sub := range m.subscribers[channel] {
+		select {
+		case sub <- message:
+		default:
+		}
+	}
+	return nil
+}
+
+// Subscribe подписывается на канал. Канал сообщений закрывается после отмены ctx.
+func (m *InMemoryStorage) Subscribe(ctx context.Context, channel string) (<-chan []byte, error) {
+	sub := make(chan []byte, memSubscriberBuffer)
+
+	m.mu.Lock()
+	m.subscribers[channel] = append(m.subscribers[channel], sub)
+	m.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		subs := m.subscribers[channel]
+		for i, s := range subs {
+			if s == sub {
+				m.subscribers[channel] = append(subs[:i], subs[i+1:]...)
+				break
+			}
+		}
+		if len(m.subscribers[channel]) == 0 {
+			delete(m.subscribers, channel)
+		}
+		close(sub)
+	}()
+
+	return sub, nil
+}
+
+// PublishMatch публикует матч в канал событий региона и режима
+func (m *InMemoryStorage) PublishMatch(ctx context.Context, region, gameMode string, match *models.Match) error {
+	payload, err := json.Marshal(match)
+	if err != nil {
+		return fmt.Errorf("failed to marshal match: %w", err)
+	}
+	return m.Publish(ctx, matchEventsChannel(region, gameMode), payload)
+}
+
+// SubscribeMatches подписывается на матчи региона и режима. Канал матчей закрывается после отмены ctx.
+func (m *InMemoryStorage) SubscribeMatches(ctx context.Context, region, gameMode string) (<-chan *models.Match, error) {
+	payloads, err := m.Subscribe(ctx, matchEventsChannel(region, gameMode))
+	if err != nil {
+		return nil, err
+	}
+	return decodeMatchEvents(ctx, payloads, func(error) {}), nil
+}
+
+// SetMaxQueueSize задает максимальное число игроков в очереди региона и режима; 0 — без ограничения
+func (m *InMemoryStorage) SetMaxQueueSize(size int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.maxQueueSize = size
+}
+
+// GetRegions возвращает список активных регионов или nil, если он не задан
+func (m *InMemoryStorage) GetRegions(ctx context.Context) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.regions == nil {
+		return nil, nil
+	}
+	return append([]string{}, m.regions...), nil
+}
+
+// SetRegions сохраняет список активных регионов
+func (m *InMemoryStorage) SetRegions(ctx context.Context, regions []string) error {
+	m.lock()
+	defer m.mu.Unlock()
+
+	m.regions = append([]string{}, regions...)
+	return nil
+}
+
+// GetGameModes возвращает режимы игры с количеством игроков в матче или nil, если они не заданы
+func (m *InMemoryStorage) GetGameModes(ctx context.Context) (map[string]int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.gameModes == nil {
+		return nil, nil
+	}
+	modes := make(map[string]int, len(m.gameModes))
+	for mode, players := range m.gameModes {
+		modes[mode] = players
+	}
+	return modes, nil
+}
+
+// SetGameModes сохраняет режимы игры
+func (m *InMemoryStorage) SetGameModes(ctx context.Context, modes map[string]int) error {
+	m.lock()
+	defer m.mu.Unlock()
+
+	m.gameModes = make(map[string]int, len(modes))
+	for mode, players := range modes {
+		m.gameModes[mode] = players
+	}
+	return nil
+}
+
+// AddToDLQ добавляет группу в конец DLQ с причиной ошибки. Хранятся последние 1000 записей.
+func (m *InMemoryStorage) AddToDLQ(ctx context.Context, region, gameMode string, group []*models.Player, reason string) error {
+	entryJSON, err := json.Marshal(newDLQEntry(region, gameMode, group, reason))
+	if err != nil {
+		return fmt.Errorf("failed to marshal dlq entry: %w", err)
+	}
+
+	m.lock()
+	defer m.mu.Unlock()
+
+	m.dlq = append(m.dlq, string(entryJSON))
+	if len(m.dlq) > dlqLimit {
+		m.dlq = append([]string{}, m.dlq[len(m.dlq)-dlqLimit:]...)
+	}
+	return nil
+}
+
+// GetDLQ возвращает записи DLQ от старых к новым
+func (m *InMemoryStorage) GetDLQ(ctx context.Context) ([]*models.DLQEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entries := make([]*models.DLQEntry, 0, len(m.dlq))
+	for _, item := range m.dlq {
+		var entry models.DLQEntry
+		if err := json.Unmarshal([]byte(item), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, &entry)
+	}
+	return entries, nil
+}
+
+// GetDLQEntry возвращает запись DLQ по индексу или ErrDLQEntryNotFound
+func (m *InMemoryStorage) GetDLQEntry(ctx context.Context, index int64) (*models.DLQEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if index < 0 || index >= int64(len(m.dlq)) {
+		return nil, ErrDLQEntryNotFound
+	}
+	var entry models.DLQEntry
+	if err := json.Unmarshal([]byte(m.dlq[index]), &entry); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal dlq entry: %w", err)
+	}
+	return &entry, nil
+}
+
+// RemoveDLQEntry удаляет запись DLQ с индексом index, если это все еще запись entryID
+func (m *InMemoryStorage) RemoveDLQEntry(ctx context.Context, index int64, entryID string) error {
+	m.lock()
+	defer m.mu.Unlock()
+
+	if index < 0 || index >= int64(len(m.dlq)) {
+		return ErrDLQEntryNotFound
+	}
+	var entry models.DLQEntry
+	if err := json.Unmarshal([]byte(m.dlq[index]), &entry); err != nil || entry.ID != entryID {
+		return ErrDLQEntryNotFound
+	}
+	m.dlq = append(m.dlq[:index], m.dlq[index+1:]...)
+	return nil
+}
+
+// CreateInvite сохраняет приглашение до ExpiresAt. Если код занят, возвращается ErrInviteCodeTaken.
+func (m *InMemoryStorage) CreateInvite(ctx context.Context, invite *models.InviteMatch) error {
+	inviteJSON, err := json.Marshal(invite)
+	if err != nil {
+		return fmt.Errorf("failed to marshal invite: %w", err)
+	}
+
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.invites.get(invite.InviteCode, now); ok {
+		return ErrInviteCodeTaken
+	}
+	m.invites[invite.InviteCode] = memValue{data: string(inviteJSON), expiresAt: now.Add(inviteTTL(invite))}
+	return nil
+}
+
+// GetInvite возвращает приглашение с уже присоединившимися игроками или ErrInviteNotFound
+func (m *InMemoryStorage) GetInvite(ctx context.Context, code string) (*models.InviteMatch, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	value, ok := m.invites.get(code, time.Now())
+	if !ok {
+		return nil, ErrInviteNotFound
+	}
+	var invite models.InviteMatch
+	if err := json.Unmarshal([]byte(value.data), &invite); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal invite: %w", err)
+	}
+	return &invite, nil
+}
+
+// JoinInvite добавляет игрока в приватный матч. Возвращает число присоединившихся
+// игроков; если вошел последний, возвращает и весь состав, а приглашение удаляется.
+func (m *InMemoryStorage) JoinInvite(ctx context.Context, invite *models.InviteMatch, player *models.Player) (int, []models.Player, error) {
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	value, ok := m.invites.get(invite.InviteCode, now)
+	if !ok {
+		return 0, nil, ErrInviteNotFound
+	}
+	var stored models.InviteMatch
+	if err := json.Unmarshal([]byte(value.data), &stored); err != nil {
+		return 0, nil, fmt.Errorf("failed to unmarshal invite: %w", err)
+	}
+	for _, p := range stored.Players {
+		if p.ID == player.ID {
+			return 0, nil, ErrAlreadyInInvite
+		}
+	}
+
+	stored.Players = append(stored.Players, *player)
+	if len(stored.Players) >= invite.PlayersNeeded {
+		delete(m.invites, invite.InviteCode)
+		return len(stored.Players), stored.Players, nil
+	}
+
+	inviteJSON, err := json.Marshal(&stored)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to marshal invite: %w", err)
+	}
+	m.invites[invite.InviteCode] = memValue{data: string(inviteJSON), expiresAt: value.expiresAt}
+	return len(stored.Players), nil, nil
+}
+
+// IncrementRateLimit увеличивает счетчик запросов клиента за текущую секунду и
+// возвращает его новое значение
+func (m *InMemoryStorage) IncrementRateLimit(ctx context.Context, clientID string, now time.Time) (int64, error) {
+	current := m.lock()
+	defer m.mu.Unlock()
+
+	key := fmt.Sprintf("%s:%d", clientID, now.Unix())
+	var count int64
+	if value, ok := m.rateLimits.get(key, current); ok {
+		count, _ = strconv.ParseInt(value.data, 10, 64)
+	}
+	count++
+	m.rateLimits[key] = memValue{data: strconv.FormatInt(count, 10), expiresAt: current.Add(2 * time.Second)}
+	return count, nil
+}
+
+// SaveIdempotencyKey запоминает игрока по ключу идемпотентности на IdempotencyKeyTTL,
+// не перезаписывая уже сохраненный ключ
+func (m *InMemoryStorage) SaveIdempotencyKey(ctx context.Context, key, playerID string) (bool, error) {
+	now := m.lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.idempotency.get(key, now); ok {
+		return false, nil
+	}
+	m.idempotency[key] = memValue{data: playerID, expiresAt: now.Add(IdempotencyKeyTTL)}
+	return true, nil
+}
+
+// GetIdempotencyKey возвращает ID игрока по ключу идемпотентности или ErrIdempotencyKeyNotFound
+func (m *InMemoryStorage) GetIdempotencyKey(ctx context.Context, key string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	value, ok := m.idempotency.get(key, time.Now())
+	if !ok {
+		return "", ErrIdempotencyKeyNotFound
+	}
+	return value.data, nil
+}
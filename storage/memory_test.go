@@ -0,0 +1,1082 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"chrono-matchmaking/models"
+)
+
+// Хранилище в памяти должно быть взаимозаменяемо с RedisStorage
+var _ Storage = (*InMemoryStorage)(nil)
+
+// queuedPlayer возвращает игрока очереди EU/3v3, вошедшего только что
+func queuedPlayer(id string, rating int) *models.Player {
+	return &models.Player{ID: id, Rating: rating, Region: "EU", GameMode: "3v3", JoinedAt: time.Now()}
+}
+
+// addPlayers ставит игроков в очередь и останавливает тест при ошибке
+func addPlayers(t *testing.T, s Storage, players ...*models.Player) {
+	t.Helper()
+	for _, p := range players {
+		if err := s.AddPlayerToQueue(context.Background(), p); err != nil {
+			t.Fatalf("AddPlayerToQueue(%s): %v", p.ID, err)
+		}
+	}
+}
+
+// playerIDs возвращает ID игроков по порядку
+func playerIDs(players []*models.Player) []string {
+	ids := make([]string, len(players))
+	for i, p := range players {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+// expireValue переводит срок жизни значения в прошлое, как если бы TTL ключа Redis истек
+func expireValue[K comparable](values memValues[K], key K) {
+	value := values[key]
+	value.expiresAt = time.Now().Add(-time.Second)
+	values[key] = value
+}
+
+func TestInMemoryQueueAddAndRemove(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStorage()
+	addPlayers(t, m, queuedPlayer("a", 1500), queuedPlayer("b", 1400))
+
+	if err := m.AddPlayerToQueue(ctx, queuedPlayer("a", 1600)); !errors.Is(err, ErrPlayerAlreadyQueued) {
+		t.Fatalf("second AddPlayerToQueue = %v, want ErrPlayerAlreadyQueued", err)
+	}
+	if size, _ := m.GetQueueSize(ctx, "EU", "3v3"); size != 2 {
+		t.Fatalf("GetQueueSize = %d, want 2", size)
+	}
+	player, err := m.GetPlayerByID(ctx, "a")
+	if err != nil {
+		t.Fatalf("GetPlayerByID: %v", err)
+	}
+	if player.Rating != 1500 {
+		t.Errorf("rating after duplicate join = %d, want the original 1500", player.Rating)
+	}
+
+	if err := m.RemovePlayerFromQueue(ctx, "a"); err != nil {
+		t.Fatalf("RemovePlayerFromQueue: %v", err)
+	}
+	if err := m.RemovePlayerFromQueue(ctx, "a"); err == nil {
+		t.Error("RemovePlayerFromQueue of a removed player returned nil")
+	}
+	if _, err := m.GetPlayerByID(ctx, "a"); err == nil {
+		t.Error("GetPlayerByID found a removed player")
+	}
+	if size, _ := m.GetQueueSize(ctx, "EU", "3v3"); size != 1 {
+		t.Errorf("GetQueueSize after remove = %d, want 1", size)
+	}
+
+	joins, leaves, _ := m.GetQueueFlowCounters(ctx, "EU", "3v3")
+	if joins != 2 || leaves != 1 {
+		t.Errorf("flow counters = %d joins, %d leaves; want 2 and 1", joins, leaves)
+	}
+}
+
+func TestInMemoryQueueSeparatesQueues(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStorage()
+
+	premium := queuedPlayer("premium", 1500)
+	premium.IsPremium = true
+	suspect := queuedPlayer("suspect", 1500)
+	suspect.IsSuspicious = true
+	other := queuedPlayer("other-region", 1500)
+	other.Region = "US"
+	addPlayers(t, m, queuedPlayer("standard", 1500), premium, suspect, other)
+
+	standard, premiumSize, err := m.GetQueueSizes(ctx, "EU", "3v3")
+	if err != nil {
+		t.Fatalf("GetQueueSizes: %v", err)
+	}
+	if standard != 2 || premiumSize != 1 {
+		t.Errorf("GetQueueSizes = %d standard, %d premium; want 2 and 1", standard, premiumSize)
+	}
+
+	snapshot, _ := m.SnapshotAll(ctx)
+	for key, want := range map[string]string{
+		"queue:EU:3v3":         "standard",
+		"queue:premium:EU:3v3": "premium",
+		"queue:suspect:EU:3v3": "suspect",
+		"queue:US:3v3":         "other-region",
+	} {
+		if got := playerIDs(snapshot[key]); !reflect.DeepEqual(got, []string{want}) {
+			t.Errorf("snapshot[%s] = %v, want [%s]", key, got, want)
+		}
+	}
+}
+
+func TestInMemoryGetPlayersInRange(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStorage()
+
+	premium := queuedPlayer("p1600", 1600)
+	premium.IsPremium = true
+	addPlayers(t, m, queuedPlayer("s1000", 1000), queuedPlayer("s1500", 1500), queuedPlayer("s1550", 1550), premium)
+
+	players, _ := m.GetPlayersInRange(ctx, "EU", "3v3", 1400, 1700, 0)
+	if got, want := playerIDs(players), []string{"p1600", "s1500", "s1550"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetPlayersInRange = %v, want %v (premium first)", got, want)
+	}
+
+	players, _ = m.GetPlayersInRange(ctx, "EU", "3v3", 1400, 1700, 2)
+	if got, want := playerIDs(players), []string{"p1600", "s1500"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetPlayersInRange with limit = %v, want %v", got, want)
+	}
+
+	players, _ = m.GetQueuePlayers(ctx, "EU", "3v3")
+	if got, want := playerIDs(players), []string{"s1000", "s1500", "s1550", "p1600"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetQueuePlayers = %v, want %v", got, want)
+	}
+
+	top, _ := m.GetTopRatedPlayers(ctx, "EU", "3v3", 2)
+	if got, want := playerIDs(top), []string{"p1600", "s1550"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetTopRatedPlayers = %v, want %v", got, want)
+	}
+}
+
+func TestInMemoryQueuePosition(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStorage()
+	addPlayers(t, m, queuedPlayer("low", 1000), queuedPlayer("mid", 1500), queuedPlayer("high", 2000))
+
+	for id, want := range map[string]int64{"low": 1, "mid": 2, "high": 3} {
+		if got, err := m.GetPlayerQueuePosition(ctx, id); err != nil || got != want {
+			t.Errorf("GetPlayerQueuePosition(%s) = %d, %v; want %d", id, got, err, want)
+		}
+	}
+	if _, err := m.GetPlayerQueuePosition(ctx, "missing"); !errors.Is(err, ErrPlayerNotInQueue) {
+		t.Errorf("GetPlayerQueuePosition(missing) = %v, want ErrPlayerNotInQueue", err)
+	}
+}
+
+func TestInMemoryMultiModePlayer(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStorage()
+
+	player := queuedPlayer("multi", 1500)
+	player.GameModes = []string{"3v3", "5v5"}
+	addPlayers(t, m, player)
+
+	for _, mode := range player.GameModes {
+		players, _ := m.GetQueuePlayers(ctx, "EU", mode)
+		if len(players) != 1 || players[0].GameMode != mode {
+			t.Errorf("queue %s = %v, want the player with GameMode %s", mode, playerIDs(players), mode)
+		}
+	}
+
+	if err := m.RemovePlayerFromQueue(ctx, "multi"); err != nil {
+		t.Fatalf("RemovePlayerFromQueue: %v", err)
+	}
+	for _, mode := range player.GameModes {
+		if size, _ := m.GetQueueSize(ctx, "EU", mode); size != 0 {
+			t.Errorf("queue %s size after remove = %d, want 0", mode, size)
+		}
+	}
+}
+
+func TestInMemoryMaxQueueSize(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStorage()
+	m.SetMaxQueueSize(2)
+	addPlayers(t, m, queuedPlayer("a", 1500), queuedPlayer("b", 1500))
+
+	if err := m.AddPlayerToQueue(ctx, queuedPlayer("c", 1500)); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("AddPlayerToQueue over the cap = %v, want ErrQueueFull", err)
+	}
+	other := queuedPlayer("d", 1500)
+	other.GameMode = "1v1"
+	if err := m.AddPlayerToQueue(ctx, other); err != nil {
+		t.Errorf("cap of one queue rejected a player of another: %v", err)
+	}
+
+	errs := m.AddPlayersToQueue(ctx, []*models.Player{queuedPlayer("a", 1500), queuedPlayer("e", 1500)})
+	if !errors.Is(errs[0], ErrPlayerAlreadyQueued) || !errors.Is(errs[1], ErrQueueFull) {
+		t.Errorf("AddPlayersToQueue errors = %v, want [ErrPlayerAlreadyQueued ErrQueueFull]", errs)
+	}
+}
+
+func TestInMemoryPlayerTTL(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStorage()
+	addPlayers(t, m, queuedPlayer("a", 1500), queuedPlayer("b", 1500))
+
+	if err := m.RefreshPlayerTTL(ctx, "a", 5*time.Second); err != nil {
+		t.Fatalf("RefreshPlayerTTL: %v", err)
+	}
+	expiring, _ := m.GetExpiringPlayers(ctx, time.Minute)
+	if _, ok := expiring["a"]; !ok || len(expiring) != 1 {
+		t.Errorf("GetExpiringPlayers = %v, want only a", expiring)
+	}
+
+	expireValue(m.players, "b")
+	if _, err := m.GetPlayerByID(ctx, "b"); err == nil {
+		t.Error("GetPlayerByID returned a player whose key expired")
+	}
+	if err := m.RefreshPlayerTTL(ctx, "b", time.Minute); !errors.Is(err, ErrPlayerNotInQueue) {
+		t.Errorf("RefreshPlayerTTL of an expired player = %v, want ErrPlayerNotInQueue", err)
+	}
+	// Как в Redis, элемент очереди переживает ключ игрока и может снова встать в очередь
+	if err := m.AddPlayerToQueue(ctx, queuedPlayer("b", 1500)); err != nil {
+		t.Errorf("AddPlayerToQueue after the key expired: %v", err)
+	}
+}
+
+func TestInMemoryStalePlayersAndReindex(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStorage()
+
+	stale := queuedPlayer("stale", 1500)
+	stale.JoinedAt = time.Now().Add(-time.Hour)
+	addPlayers(t, m, stale, queuedPlayer("fresh", 1500))
+
+	active, staleCount, total, _ := m.GetQueueMemberCount(ctx, "EU", "3v3", 10*time.Minute)
+	if active != 1 || staleCount != 1 || total != 2 {
+		t.Errorf("GetQueueMemberCount = %d/%d/%d, want 1/1/2", active, staleCount, total)
+	}
+	if removed, _ := m.RemoveStalePlayers(ctx, "EU", "3v3", 10*time.Minute); removed != 1 {
+		t.Errorf("RemoveStalePlayers = %d, want 1", removed)
+	}
+	if _, err := m.GetPlayerByID(ctx, "stale"); err == nil {
+		t.Error("stale player key was not removed")
+	}
+
+	// Рейтинг в ключе игрока изменился, а элемент очереди остался прежним
+	if err := m.UpdatePlayerRating(ctx, "fresh", 1700); err != nil {
+		t.Fatalf("UpdatePlayerRating: %v", err)
+	}
+	if updated, _ := m.ReindexQueueScores(ctx, "EU", "3v3"); updated != 0 {
+		t.Errorf("ReindexQueueScores after UpdatePlayerRating = %d, want 0", updated)
+	}
+	players, _ := m.GetQueuePlayers(ctx, "EU", "3v3")
+	if len(players) != 1 || players[0].Rating != 1700 {
+		t.Errorf("queue after rating update = %+v, want fresh with 1700", players)
+	}
+}
+
+func TestInMemoryQueueDepthByBracket(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStorage()
+	addPlayers(t, m, queuedPlayer("a", 900), queuedPlayer("b", 1200), queuedPlayer("c", 1250), queuedPlayer("d", 2600))
+
+	depth, _ := m.GetQueueDepthByBracket(ctx, "EU", "3v3", []models.RatingBracket{
+		{Min: 0, Max: 999},
+		{Min: 1000, Max: 1499},
+		{Min: 1500, Max: 0},
+	})
+	counts := []int64{depth[0].Count, depth[1].Count, depth[2].Count}
+	if want := []int64{1, 2, 1}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("bracket counts = %v, want %v", counts, want)
+	}
+}
+
+func TestInMemoryParty(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStorage()
+	addPlayers(t, m, queuedPlayer("queued", 1500))
+
+	party := &models.Party{PartyID: "party", Region: "EU", GameMode: "3v3"}
+	err := m.AddPartyToQueue(ctx, party, []*models.Player{queuedPlayer("p1", 1500), queuedPlayer("queued", 1500)})
+	if !errors.Is(err, ErrPlayerAlreadyQueued) {
+		t.Fatalf("AddPartyToQueue with a queued member = %v, want ErrPlayerAlreadyQueued", err)
+	}
+	if _, err := m.GetPlayerByID(ctx, "p1"); err == nil {
+		t.Error("party was partially added")
+	}
+
+	if err := m.AddPartyToQueue(ctx, party, []*models.Player{queuedPlayer("p1", 1500), queuedPlayer("p2", 1500)}); err != nil {
+		t.Fatalf("AddPartyToQueue: %v", err)
+	}
+	if _, err := m.GetParty(ctx, "party"); err != nil {
+		t.Errorf("GetParty: %v", err)
+	}
+	if _, err := m.GetParty(ctx, "missing"); !errors.Is(err, ErrPartyNotFound) {
+		t.Errorf("GetParty(missing) = %v, want ErrPartyNotFound", err)
+	}
+	if size, _ := m.GetQueueSize(ctx, "EU", "3v3"); size != 3 {
+		t.Errorf("GetQueueSize = %d, want 3", size)
+	}
+}
+
+// testMatch возвращает матч из игроков с указанными ID
+func testMatch(matchID string, ids ...string) *models.Match {
+	now := time.Now()
+	match := &models.Match{MatchID: matchID, CreatedAt: now, ExpiresAt: now.Add(MatchTTL), GameMode: "3v3"}
+	for _, id := range ids {
+		match.Players = append(match.Players, *queuedPlayer(id, 1500))
+	}
+	return match
+}
+
+func TestInMemoryAtomicMatchFormation(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStorage()
+	addPlayers(t, m, queuedPlayer("a", 1500), queuedPlayer("b", 1500), queuedPlayer("c", 1500))
+
+	// Игрок c уже ушел из очереди — матч не создается, и никто не удаляется
+	if err := m.RemovePlayerFromQueue(ctx, "c"); err != nil {
+		t.Fatalf("RemovePlayerFromQueue: %v", err)
+	}
+	if err := m.RunAtomicMatchFormation(ctx, testMatch("conflict", "a", "b", "c")); !errors.Is(err, ErrMatchConflict) {
+		t.Fatalf("RunAtomicMatchFormation = %v, want ErrMatchConflict", err)
+	}
+	if size, _ := m.GetQueueSize(ctx, "EU", "3v3"); size != 2 {
+		t.Fatalf("queue size after conflict = %d, want 2", size)
+	}
+	if _, err := m.GetMatchByID(ctx, "conflict"); !errors.Is(err, ErrMatchNotFound) {
+		t.Errorf("GetMatchByID after conflict = %v, want ErrMatchNotFound", err)
+	}
+
+	bot := testMatch("match", "a", "b", "bot")
+	bot.Players[2].IsBotPlayer = true
+	if err := m.RunAtomicMatchFormation(ctx, bot); err != nil {
+		t.Fatalf("RunAtomicMatchFormation: %v", err)
+	}
+	if size, _ := m.GetQueueSize(ctx, "EU", "3v3"); size != 0 {
+		t.Errorf("queue size after match = %d, want 0", size)
+	}
+	for _, id := range []string{"a", "b"} {
+		match, err := m.GetMatchByPlayerID(ctx, id)
+		if err != nil || match.MatchID != "match" {
+			t.Errorf("GetMatchByPlayerID(%s) = %v, %v; want match", id, match, err)
+		}
+		if history, _ := m.GetMatchHistory(ctx, id, 10); len(history) != 1 {
+			t.Errorf("history of %s has %d matches, want 1", id, len(history))
+		}
+	}
+	if _, err := m.GetMatchByPlayerID(ctx, "bot"); !errors.Is(err, ErrMatchNotFound) {
+		t.Errorf("bot got a match key: %v", err)
+	}
+}
+
+func TestInMemoryMatchUpdates(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStorage()
+
+	match := testMatch("match", "a", "b")
+	if err := m.SaveMatch(ctx, match); err != nil {
+		t.Fatalf("SaveMatch: %v", err)
+	}
+
+	match.ServerID = "server-1"
+	if err := m.UpdateMatch(ctx, match); err != nil {
+		t.Fatalf("UpdateMatch: %v", err)
+	}
+	if stored, _ := m.GetMatchByPlayerID(ctx, "b"); stored.ServerID != "server-1" {
+		t.Errorf("player match ServerID = %q, want server-1", stored.ServerID)
+	}
+
+	updated, err := m.UpdateMatchMetadata(ctx, "match", map[string]interface{}{"winner": "a"})
+	if err != nil || updated.Metadata["winner"] != "a" {
+		t.Fatalf("UpdateMatchMetadata = %v, %v", updated, err)
+	}
+	if stored, _ := m.GetMatchByID(ctx, "match"); stored.Metadata["winner"] != "a" || stored.ServerID != "server-1" {
+		t.Errorf("stored match = %+v, want metadata and ServerID kept", stored)
+	}
+	if _, err := m.UpdateMatchMetadata(ctx, "missing", nil); !errors.Is(err, ErrMatchNotFound) {
+		t.Errorf("UpdateMatchMetadata(missing) = %v, want ErrMatchNotFound", err)
+	}
+
+	if err := m.RemoveMatch(ctx, "a"); err != nil {
+		t.Fatalf("RemoveMatch: %v", err)
+	}
+	if _, err := m.GetMatchByPlayerID(ctx, "a"); !errors.Is(err, ErrMatchNotFound) {
+		t.Errorf("GetMatchByPlayerID after RemoveMatch = %v, want ErrMatchNotFound", err)
+	}
+	if _, err := m.GetMatchByID(ctx, "match"); err != nil {
+		t.Errorf("RemoveMatch removed the match record: %v", err)
+	}
+}
+
+func TestInMemoryMatchExpiry(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStorage()
+
+	expired := testMatch("expired", "a")
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+	if err := m.SaveMatch(ctx, expired); err != nil {
+		t.Fatalf("SaveMatch: %v", err)
+	}
+	if err := m.SaveMatch(ctx, testMatch("active", "b")); err != nil {
+		t.Fatalf("SaveMatch: %v", err)
+	}
+
+	matches, _ := m.ScanExpiredMatches(ctx)
+	if len(matches) != 1 || matches[0].MatchID != "expired" {
+		t.Errorf("ScanExpiredMatches = %v, want only expired", matches)
+	}
+
+	// Обработчик с ошибкой получает матч повторно, но не раньше matchExpiryRetryDelay
+	var handled []string
+	fail := true
+	handler := func(ctx context.Context, expiry MatchExpiry) error {
+		handled = append(handled, expiry.MatchID)
+		if fail {
+			return fmt.Errorf("temporary failure")
+		}
+		return nil
+	}
+	m.processMatchExpiry(ctx, handler)
+	fail = false
+	m.processMatchExpiry(ctx, handler)
+	if want := []string{"expired"}; !reflect.DeepEqual(handled, want) {
+		t.Fatalf("handled = %v, want %v before the retry delay", handled, want)
+	}
+	for i := range m.expiries {
+		m.expiries[i].retryAt = time.Time{}
+	}
+	m.processMatchExpiry(ctx, handler)
+	m.processMatchExpiry(ctx, handler)
+	if want := []string{"expired", "expired"}; !reflect.DeepEqual(handled, want) {
+		t.Errorf("handled = %v, want %v", handled, want)
+	}
+}
+
+func TestInMemoryMatchExpiryDeliveryLimit(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStorage()
+
+	expired := testMatch("expired", "a")
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+	if err := m.SaveMatch(ctx, expired); err != nil {
+		t.Fatalf("SaveMatch: %v", err)
+	}
+
+	calls := 0
+	handler := func(ctx context.Context, expiry MatchExpiry) error {
+		calls++
+		return fmt.Errorf("permanent failure")
+	}
+	for i := 0; i < 2*matchExpiryMaxDeliveries; i++ {
+		m.processMatchExpiry(ctx, handler)
+		for j := range m.expiries {
+			m.expiries[j].retryAt = time.Time{}
+		}
+	}
+	if calls != matchExpiryMaxDeliveries {
+		t.Errorf("handler calls = %d, want %d", calls, matchExpiryMaxDeliveries)
+	}
+	if len(m.expiries) != 0 {
+		t.Errorf("entry kept after %d failures: %v", matchExpiryMaxDeliveries, m.expiries)
+	}
+}
+
+func TestInMemoryPendingMatchExpiry(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStorage()
+
+	a, b := queuedPlayer("a", 1500), queuedPlayer("b", 1500)
+	addPlayers(t, m, a, b)
+	pending := &models.PendingMatch{
+		PendingID: "pending",
+		Region:    "EU",
+		GameMode:  "3v3",
+		Players:   []models.Player{*a, *b},
+		PlayerIDs: []string{"a", "b"},
+		ExpiresAt: time.Now().Add(-time.Second),
+	}
+	if err := m.CreatePendingMatch(ctx, pending); err != nil {
+		t.Fatalf("CreatePendingMatch: %v", err)
+	}
+
+	var handled []MatchExpiry
+	m.processMatchExpiry(ctx, func(ctx context.Context, expiry MatchExpiry) error {
+		handled = append(handled, expiry)
+		return nil
+	})
+	want := []MatchExpiry{{MatchID: "pending", Pending: true, Region: "EU", GameMode: "3v3"}}
+	if !reflect.DeepEqual(handled, want) {
+		t.Errorf("handled = %+v, want %+v", handled, want)
+	}
+}
+
+func TestInMemoryScheduledMatches(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStorage()
+
+	start := time.Now().Add(time.Hour)
+	match := testMatch("scheduled", "a")
+	match.ScheduledStartTime = &start
+	if err := m.SaveScheduledMatch(ctx, "EU", "3v3", match); err != nil {
+		t.Fatalf("SaveScheduledMatch: %v", err)
+	}
+
+	if promoted, _ := m.PromoteScheduledMatches(ctx, "EU", "3v3", time.Now()); len(promoted) != 0 {
+		t.Fatalf("match promoted before its start: %v", promoted)
+	}
+	promoted, _ := m.PromoteScheduledMatches(ctx, "EU", "3v3", start)
+	if len(promoted) != 1 {
+		t.Fatalf("PromoteScheduledMatches = %v, want the scheduled match", promoted)
+	}
+	if _, err := m.GetMatchByPlayerID(ctx, "a"); err != nil {
+		t.Errorf("promoted match is not visible to the player: %v", err)
+	}
+	if promoted, _ := m.PromoteScheduledMatches(ctx, "EU", "3v3", start); len(promoted) != 0 {
+		t.Errorf("match promoted twice")
+	}
+}
+
+func TestInMemoryBackfill(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStorage()
+	addPlayers(t, m, queuedPlayer("a", 1500), queuedPlayer("b", 1500), queuedPlayer("c", 1500))
+	match := testMatch("match", "a", "b")
+	if err := m.RunAtomicMatchFormation(ctx, match); err != nil {
+		t.Fatalf("RunAtomicMatchFormation: %v", err)
+	}
+
+	newPlayer := *queuedPlayer("c", 1500)
+	if err := m.ClaimBackfillPlayers(ctx, match, []models.Player{newPlayer, *queuedPlayer("gone", 1500)}); !errors.Is(err, ErrMatchConflict) {
+		t.Fatalf("ClaimBackfillPlayers with a missing player = %v, want ErrMatchConflict", err)
+	}
+	match.Players = append(match.Players, newPlayer)
+	if err := m.ClaimBackfillPlayers(ctx, match, []models.Player{newPlayer}); err != nil {
+		t.Fatalf("ClaimBackfillPlayers: %v", err)
+	}
+	if stored, err := m.GetMatchByPlayerID(ctx, "c"); err != nil || len(stored.Players) != 3 {
+		t.Errorf("backfilled player match = %v, %v; want 3 players", stored, err)
+	}
+}
+
+func TestInMemoryPendingMatch(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStorage()
+	addPlayers(t, m, queuedPlayer("a", 1500), queuedPlayer("b", 1500))
+
+	pending := &models.PendingMatch{
+		PendingID: "pending",
+		PlayerIDs: []string{"a", "b"},
+		Players:   []models.Player{*queuedPlayer("a", 1500), *queuedPlayer("b", 1500)},
+		Region:    "EU",
+		GameMode:  "3v3",
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(30 * time.Second),
+	}
+	if err := m.CreatePendingMatch(ctx, pending); err != nil {
+		t.Fatalf("CreatePendingMatch: %v", err)
+	}
+	if err := m.CreatePendingMatch(ctx, pending); !errors.Is(err, ErrMatchConflict) {
+		t.Errorf("second CreatePendingMatch = %v, want ErrMatchConflict", err)
+	}
+	if got, err := m.GetPlayerPendingMatch(ctx, "b"); err != nil || got.PendingID != "pending" {
+		t.Errorf("GetPlayerPendingMatch = %v, %v", got, err)
+	}
+
+	if last, _ := m.AcceptPendingMatch(ctx, pending, "a"); last {
+		t.Error("first accept reported as the last one")
+	}
+	if last, _ := m.AcceptPendingMatch(ctx, pending, "a"); last {
+		t.Error("repeated accept reported as the last one")
+	}
+	if last, _ := m.AcceptPendingMatch(ctx, pending, "b"); !last {
+		t.Error("final accept not reported as the last one")
+	}
+	if accepted, _ := m.GetAcceptedPlayers(ctx, "pending"); len(accepted) != 2 {
+		t.Errorf("GetAcceptedPlayers = %v, want both players", accepted)
+	}
+
+	expired, _ := m.GetExpiredPendingMatches(ctx, "EU", "3v3", pending.ExpiresAt)
+	if len(expired) != 1 {
+		t.Errorf("GetExpiredPendingMatches = %v, want the pending match", expired)
+	}
+	if claimed, _ := m.ClaimPendingMatch(ctx, pending); !claimed {
+		t.Fatal("ClaimPendingMatch = false, want true")
+	}
+	if claimed, _ := m.ClaimPendingMatch(ctx, pending); claimed {
+		t.Error("pending match claimed twice")
+	}
+	if _, err := m.GetPendingMatch(ctx, "pending"); !errors.Is(err, ErrPendingMatchNotFound) {
+		t.Errorf("GetPendingMatch after claim = %v, want ErrPendingMatchNotFound", err)
+	}
+}
+
+func TestInMemoryCooldownAndBan(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStorage()
+
+	if cooldown, _ := m.GetQueueCooldown(ctx, "a"); cooldown != 0 {
+		t.Errorf("GetQueueCooldown without cooldown = %v, want 0", cooldown)
+	}
+	if err := m.SetQueueCooldown(ctx, "a", time.Minute); err != nil {
+		t.Fatalf("SetQueueCooldown: %v", err)
+	}
+	if cooldown, _ := m.GetQueueCooldown(ctx, "a"); cooldown <= 0 || cooldown > time.Minute {
+		t.Errorf("GetQueueCooldown = %v, want up to a minute", cooldown)
+	}
+
+	if err := m.BanPlayer(ctx, "a", time.Hour); err != nil {
+		t.Fatalf("BanPlayer: %v", err)
+	}
+	if banned, _ := m.IsBanned(ctx, "a"); !banned {
+		t.Error("IsBanned = false after BanPlayer")
+	}
+	expiry, _ := m.GetBanExpiry(ctx, "a")
+	if until := time.Until(expiry); until < 59*time.Minute || until > time.Hour {
+		t.Errorf("ban expires in %v, want an hour", until)
+	}
+
+	expireValue(m.bans, "a")
+	if banned, _ := m.IsBanned(ctx, "a"); banned {
+		t.Error("IsBanned = true after the ban expired")
+	}
+	if expiry, _ := m.GetBanExpiry(ctx, "a"); !expiry.IsZero() {
+		t.Errorf("GetBanExpiry after expiry = %v, want zero", expiry)
+	}
+}
+
+func TestInMemoryAbandonFlags(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStorage()
+
+	for want := int64(1); want <= 3; want++ {
+		if count, _ := m.IncrementAbandonCount(ctx, "a"); count != want {
+			t.Fatalf("IncrementAbandonCount = %d, want %d", count, want)
+		}
+	}
+
+	if err := m.FlagPlayer(ctx, "b", time.Hour); err != nil {
+		t.Fatalf("FlagPlayer: %v", err)
+	}
+	if err := m.FlagPlayer(ctx, "a", time.Hour); err != nil {
+		t.Fatalf("FlagPlayer: %v", err)
+	}
+	if flagged, _ := m.GetFlaggedPlayers(ctx); !reflect.DeepEqual(flagged, []string{"a", "b"}) {
+		t.Errorf("GetFlaggedPlayers = %v, want [a b]", flagged)
+	}
+
+	if unflagged, _ := m.UnflagPlayer(ctx, "a"); !unflagged {
+		t.Error("UnflagPlayer = false for a flagged player")
+	}
+	if flagged, _ := m.IsPlayerFlagged(ctx, "a"); flagged {
+		t.Error("player still flagged after UnflagPlayer")
+	}
+	if count, _ := m.IncrementAbandonCount(ctx, "a"); count != 1 {
+		t.Errorf("abandon count after UnflagPlayer = %d, want a fresh count of 1", count)
+	}
+	if unflagged, _ := m.UnflagPlayer(ctx, "a"); unflagged {
+		t.Error("UnflagPlayer = true for a player without a flag")
+	}
+
+	expireValue(m.flagged, "b")
+	if flagged, _ := m.IsPlayerFlagged(ctx, "b"); flagged {
+		t.Error("flag did not expire")
+	}
+	if flagged, _ := m.GetFlaggedPlayers(ctx); len(flagged) != 0 {
+		t.Errorf("GetFlaggedPlayers after expiry = %v, want none", flagged)
+	}
+}
+
+func TestInMemoryProfiles(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStorage()
+
+	profile := &models.PlayerProfile{PlayerID: "a", DisplayName: "A", CreatedAt: time.Now()}
+	if created, _ := m.CreateProfile(ctx, profile); !created {
+		t.Fatal("CreateProfile = false for a new profile")
+	}
+	if created, _ := m.CreateProfile(ctx, &models.PlayerProfile{PlayerID: "a", DisplayName: "B"}); created {
+		t.Error("CreateProfile overwrote an existing profile")
+	}
+
+	if err := m.AddDeviceToken(ctx, "a", "token"); err != nil {
+		t.Fatalf("AddDeviceToken: %v", err)
+	}
+	if err := m.AddDeviceToken(ctx, "a", "token"); err != nil {
+		t.Fatalf("AddDeviceToken: %v", err)
+	}
+	if err := m.AddDeviceToken(ctx, "missing", "token"); !errors.Is(err, ErrProfileNotFound) {
+		t.Errorf("AddDeviceToken without a profile = %v, want ErrProfileNotFound", err)
+	}
+
+	if err := m.IncrementTotalMatches(ctx, "a"); err != nil {
+		t.Fatalf("IncrementTotalMatches: %v", err)
+	}
+	if err := m.IncrementTotalMatches(ctx, "missing"); err != nil {
+		t.Errorf("IncrementTotalMatches without a profile: %v", err)
+	}
+
+	stored, err := m.GetProfile(ctx, "a")
+	if err != nil {
+		t.Fatalf("GetProfile: %v", err)
+	}
+	if stored.DisplayName != "A" || stored.TotalMatches != 1 || !reflect.DeepEqual(stored.DeviceTokens, []string{"token"}) {
+		t.Errorf("GetProfile = %+v", stored)
+	}
+	if _, err := m.GetProfile(ctx, "missing"); !errors.Is(err, ErrProfileNotFound) {
+		t.Errorf("GetProfile(missing) = %v, want ErrProfileNotFound", err)
+	}
+}
+
+func TestInMemoryRatings(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStorage()
+
+	if _, found, _ := m.GetPlayerRating(ctx, "a"); found {
+		t.Error("GetPlayerRating found a rating before any match")
+	}
+	if err := m.UpdatePlayerRating(ctx, "a", 1600); err != nil {
+		t.Fatalf("UpdatePlayerRating: %v", err)
+	}
+	if rating, found, _ := m.GetPlayerRating(ctx, "a"); !found || rating != 1600 {
+		t.Errorf("GetPlayerRating = %d, %v; want 1600", rating, found)
+	}
+
+	if saved, _ := m.SaveMatchResultRatings(ctx, "match", map[string]int{"a": 1600}); !saved {
+		t.Error("first SaveMatchResultRatings = false")
+	}
+	if saved, _ := m.SaveMatchResultRatings(ctx, "match", map[string]int{"a": 1640}); saved {
+		t.Error("match result saved twice")
+	}
+	if rating, _, _ := m.GetPlayerRating(ctx, "a"); rating != 1600 {
+		t.Errorf("rating after a repeated result = %d, want 1600", rating)
+	}
+
+	addPlayers(t, m, queuedPlayer("b", 1200))
+	updated, _ := m.UpdateAllPlayerRatings(ctx, func(rating int) int { return (rating + 1000) / 2 })
+	if updated != 2 {
+		t.Errorf("UpdateAllPlayerRatings = %d, want 2", updated)
+	}
+	if rating, _, _ := m.GetPlayerRating(ctx, "a"); rating != 1300 {
+		t.Errorf("stored rating after reset = %d, want 1300", rating)
+	}
+	if player, _ := m.GetPlayerByID(ctx, "b"); player.Rating != 1100 {
+		t.Errorf("queued rating after reset = %d, want 1100", player.Rating)
+	}
+}
+
+func TestInMemoryRatingHistory(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStorage()
+
+	start := time.Now().Add(-time.Hour)
+	for i := 0; i < ratingHistoryLimit+5; i++ {
+		point := models.RatingPoint{Timestamp: start.Add(time.Duration(i) * time.Second), Rating: 1000 + i}
+		if err := m.AppendRatingSnapshot(ctx, "a", point); err != nil {
+			t.Fatalf("AppendRatingSnapshot: %v", err)
+		}
+	}
+	history, _ := m.GetRatingHistory(ctx, "a")
+	if len(history) != ratingHistoryLimit {
+		t.Fatalf("history has %d points, want %d", len(history), ratingHistoryLimit)
+	}
+	if history[0].Rating != 1005 || history[len(history)-1].Rating != 1000+ratingHistoryLimit+4 {
+		t.Errorf("history spans %d..%d, want the latest points", history[0].Rating, history[len(history)-1].Rating)
+	}
+
+	for i, delta := range []int{10, -5, 20} {
+		if err := m.RecordRatingChange(ctx, "a", delta, start.Add(time.Duration(i)*time.Second)); err != nil {
+			t.Fatalf("RecordRatingChange: %v", err)
+		}
+	}
+	if changes, _ := m.GetRecentRatingChanges(ctx, "a", 2); !reflect.DeepEqual(changes, []int{20, -5}) {
+		t.Errorf("GetRecentRatingChanges = %v, want [20 -5]", changes)
+	}
+}
+
+func TestInMemoryPlayerStats(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStorage()
+
+	_ = m.IncrementStat(ctx, "a", StatWins, 1)
+	_ = m.IncrementStat(ctx, "a", StatWins, 2)
+	stats, _ := m.GetPlayerStats(ctx, "a")
+	if stats[StatWins] != 3 {
+		t.Errorf("wins = %v, want 3", stats[StatWins])
+	}
+	stats[StatWins] = 100
+	if again, _ := m.GetPlayerStats(ctx, "a"); again[StatWins] != 3 {
+		t.Error("GetPlayerStats returned the internal map")
+	}
+
+	for i := 1; i <= PlayerWaitHistoryLimit+1; i++ {
+		_ = m.RecordPlayerWaitTime(ctx, "a", time.Duration(i)*time.Second)
+	}
+	waits, _ := m.GetPlayerWaitTimes(ctx, "a")
+	if len(waits) != PlayerWaitHistoryLimit || waits[0] != time.Duration(PlayerWaitHistoryLimit+1)*time.Second {
+		t.Errorf("GetPlayerWaitTimes = %v, want the latest %d, newest first", waits, PlayerWaitHistoryLimit)
+	}
+
+	_ = m.RecordEncounter(ctx, "a", "b", time.Hour)
+	if met, _ := m.HaveRecentlyMet(ctx, "b", "a"); !met {
+		t.Error("HaveRecentlyMet(b, a) = false after RecordEncounter(a, b)")
+	}
+	if met, _ := m.HaveRecentlyMet(ctx, "a", "c"); met {
+		t.Error("HaveRecentlyMet(a, c) = true")
+	}
+}
+
+func TestInMemoryQueueStats(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStorage()
+
+	_ = m.RecordWaitTime(ctx, "EU", "3v3", 5, 10)
+	_ = m.RecordWaitTime(ctx, "EU", "3v3", 5, 20)
+	_ = m.IncrementModePopularity(ctx, "EU", "3v3", 5)
+	if err := m.RecordWaitTime(ctx, "EU", "3v3", HoursPerDay, 1); err == nil {
+		t.Error("RecordWaitTime accepted an invalid hour")
+	}
+	if avg, _ := m.GetHourlyAvgWait(ctx, "EU", "3v3"); avg[5] != 15 || avg[6] != 0 {
+		t.Errorf("hourly average = %v at 5, %v at 6; want 15 and 0", avg[5], avg[6])
+	}
+	if counts, _ := m.GetHourlyMatchCounts(ctx, "EU", "3v3"); counts[5] != 1 {
+		t.Errorf("hourly match count = %d, want 1", counts[5])
+	}
+
+	_ = m.RecordMatchCreationTime(ctx, "EU", "3v3", 10*time.Second)
+	_ = m.RecordMatchCreationTime(ctx, "EU", "3v3", 30*time.Second)
+	if avg, _ := m.GetAverageWaitTime(ctx, "EU", "3v3"); avg != 20*time.Second {
+		t.Errorf("GetAverageWaitTime = %v, want 20s", avg)
+	}
+
+	now := time.Now()
+	_ = m.RecordSegmentMatch(ctx, "EU", "3v3", "gold", "old", now.Add(-2*time.Hour))
+	_ = m.RecordSegmentMatch(ctx, "EU", "3v3", "gold", "new", now)
+	if count, _ := m.CountSegmentMatchesLastHour(ctx, "EU", "3v3", "gold"); count != 1 {
+		t.Errorf("CountSegmentMatchesLastHour = %d, want 1", count)
+	}
+
+	_ = m.RecordMatchedRatings(ctx, "EU", "3v3", testMatch("match", "a", "b"))
+	if ratings, _ := m.GetMatchedRatings(ctx, "EU", "3v3"); !reflect.DeepEqual(ratings, []int{1500, 1500}) {
+		t.Errorf("GetMatchedRatings = %v, want [1500 1500]", ratings)
+	}
+}
+
+func TestInMemoryServers(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStorage()
+
+	_ = m.RegisterServer(ctx, &models.Server{ServerID: "big", Region: "EU", Capacity: 4})
+	_ = m.RegisterServer(ctx, &models.Server{ServerID: "small", Region: "EU", Capacity: 1})
+
+	var acquired []string
+	for i := 0; i < 5; i++ {
+		server, err := m.AcquireLeastLoadedServer(ctx, "EU")
+		if err != nil {
+			t.Fatalf("AcquireLeastLoadedServer #%d: %v", i+1, err)
+		}
+		acquired = append(acquired, server.ServerID)
+	}
+	if want := []string{"big", "small", "big", "big", "big"}; !reflect.DeepEqual(acquired, want) {
+		t.Errorf("acquired servers = %v, want %v", acquired, want)
+	}
+	if _, err := m.AcquireLeastLoadedServer(ctx, "EU"); !errors.Is(err, ErrNoServerAvailable) {
+		t.Errorf("AcquireLeastLoadedServer on full servers = %v, want ErrNoServerAvailable", err)
+	}
+
+	_ = m.ReleaseServer(ctx, "EU", "small")
+	if server, _ := m.AcquireLeastLoadedServer(ctx, "EU"); server == nil || server.ServerID != "small" {
+		t.Errorf("released slot not reused: %v", server)
+	}
+
+	if err := m.DeregisterServer(ctx, "small"); err != nil {
+		t.Fatalf("DeregisterServer: %v", err)
+	}
+	if err := m.DeregisterServer(ctx, "small"); !errors.Is(err, ErrServerNotFound) {
+		t.Errorf("second DeregisterServer = %v, want ErrServerNotFound", err)
+	}
+}
+
+func TestInMemoryLocks(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStorage()
+
+	if ok, _ := m.AcquireLock(ctx, "lock", "a", time.Minute); !ok {
+		t.Fatal("AcquireLock of a free lock = false")
+	}
+	if ok, _ := m.AcquireLock(ctx, "lock", "b", time.Minute); ok {
+		t.Error("AcquireLock of a held lock = true")
+	}
+	if ok, _ := m.RenewLock(ctx, "lock", "b", time.Minute); ok {
+		t.Error("RenewLock by another owner = true")
+	}
+	if ok, _ := m.RenewLock(ctx, "lock", "a", time.Minute); !ok {
+		t.Error("RenewLock by the owner = false")
+	}
+
+	_ = m.ReleaseLock(ctx, "lock", "b")
+	if ok, _ := m.AcquireLock(ctx, "lock", "b", time.Minute); ok {
+		t.Error("ReleaseLock by another owner released the lock")
+	}
+	_ = m.ReleaseLock(ctx, "lock", "a")
+	if ok, _ := m.AcquireLock(ctx, "lock", "b", time.Minute); !ok {
+		t.Error("lock not free after ReleaseLock by the owner")
+	}
+
+	expireValue(m.locks, "lock")
+	if ok, _ := m.AcquireLock(ctx, "lock", "c", time.Minute); !ok {
+		t.Error("expired lock was not acquired")
+	}
+}
+
+func TestInMemoryPubSub(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	m := NewInMemoryStorage()
+
+	matches, err := m.SubscribeMatches(ctx, "EU", "3v3")
+	if err != nil {
+		t.Fatalf("SubscribeMatches: %v", err)
+	}
+	if err := m.PublishMatch(ctx, "EU", "3v3", testMatch("match", "a")); err != nil {
+		t.Fatalf("PublishMatch: %v", err)
+	}
+	select {
+	case match := <-matches:
+		if match.MatchID != "match" {
+			t.Errorf("received match %s, want match", match.MatchID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("published match was not delivered")
+	}
+
+	cancel()
+	select {
+	case _, ok := <-matches:
+		if ok {
+			t.Error("unexpected match after cancel")
+		}
+	case <-time.After(time.Second):
+		t.Error("subscription channel not closed after cancel")
+	}
+}
+
+func TestInMemoryRateLimitAndIdempotency(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStorage()
+
+	second := time.Now()
+	for want := int64(1); want <= 3; want++ {
+		if count, _ := m.IncrementRateLimit(ctx, "client", second); count != want {
+			t.Errorf("IncrementRateLimit = %d, want %d", count, want)
+		}
+	}
+	if count, _ := m.IncrementRateLimit(ctx, "client", second.Add(time.Second)); count != 1 {
+		t.Errorf("IncrementRateLimit in the next second = %d, want 1", count)
+	}
+
+	if saved, _ := m.SaveIdempotencyKey(ctx, "key", "a"); !saved {
+		t.Fatal("SaveIdempotencyKey of a new key = false")
+	}
+	if saved, _ := m.SaveIdempotencyKey(ctx, "key", "b"); saved {
+		t.Error("SaveIdempotencyKey overwrote an existing key")
+	}
+	if playerID, _ := m.GetIdempotencyKey(ctx, "key"); playerID != "a" {
+		t.Errorf("GetIdempotencyKey = %q, want a", playerID)
+	}
+	if _, err := m.GetIdempotencyKey(ctx, "missing"); !errors.Is(err, ErrIdempotencyKeyNotFound) {
+		t.Errorf("GetIdempotencyKey(missing) = %v, want ErrIdempotencyKeyNotFound", err)
+	}
+}
+
+func TestInMemoryDLQ(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStorage()
+
+	for i := 0; i < 3; i++ {
+		group := []*models.Player{queuedPlayer(fmt.Sprintf("p%d", i), 1500)}
+		if err := m.AddToDLQ(ctx, "EU", "3v3", group, "save failed"); err != nil {
+			t.Fatalf("AddToDLQ: %v", err)
+		}
+	}
+	entries, _ := m.GetDLQ(ctx)
+	if len(entries) != 3 {
+		t.Fatalf("GetDLQ returned %d entries, want 3", len(entries))
+	}
+
+	if err := m.RemoveDLQEntry(ctx, 1, "stale-id"); !errors.Is(err, ErrDLQEntryNotFound) {
+		t.Errorf("RemoveDLQEntry with another entry ID = %v, want ErrDLQEntryNotFound", err)
+	}
+	if err := m.RemoveDLQEntry(ctx, 1, entries[1].ID); err != nil {
+		t.Fatalf("RemoveDLQEntry: %v", err)
+	}
+	second, err := m.GetDLQEntry(ctx, 1)
+	if err != nil || second.ID != entries[2].ID {
+		t.Errorf("GetDLQEntry(1) after removal = %v, %v; want the former third entry", second, err)
+	}
+	if _, err := m.GetDLQEntry(ctx, 2); !errors.Is(err, ErrDLQEntryNotFound) {
+		t.Errorf("GetDLQEntry out of range = %v, want ErrDLQEntryNotFound", err)
+	}
+}
+
+func TestInMemoryInvites(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStorage()
+
+	invite := &models.InviteMatch{InviteCode: "CODE", PlayersNeeded: 2, ExpiresAt: time.Now().Add(time.Minute)}
+	if err := m.CreateInvite(ctx, invite); err != nil {
+		t.Fatalf("CreateInvite: %v", err)
+	}
+	if err := m.CreateInvite(ctx, invite); !errors.Is(err, ErrInviteCodeTaken) {
+		t.Errorf("second CreateInvite = %v, want ErrInviteCodeTaken", err)
+	}
+
+	if joined, players, err := m.JoinInvite(ctx, invite, queuedPlayer("a", 1500)); err != nil || joined != 1 || players != nil {
+		t.Fatalf("JoinInvite(a) = %d, %v, %v; want 1 player and no roster yet", joined, players, err)
+	}
+	if _, _, err := m.JoinInvite(ctx, invite, queuedPlayer("a", 1500)); !errors.Is(err, ErrAlreadyInInvite) {
+		t.Errorf("repeated JoinInvite = %v, want ErrAlreadyInInvite", err)
+	}
+	joined, players, err := m.JoinInvite(ctx, invite, queuedPlayer("b", 1500))
+	if err != nil || joined != 2 || len(players) != 2 {
+		t.Fatalf("JoinInvite(b) = %d, %v, %v; want the full roster", joined, players, err)
+	}
+	if _, err := m.GetInvite(ctx, "CODE"); !errors.Is(err, ErrInviteNotFound) {
+		t.Errorf("GetInvite of a filled invite = %v, want ErrInviteNotFound", err)
+	}
+}
+
+func TestInMemorySettings(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStorage()
+
+	if regions, _ := m.GetRegions(ctx); regions != nil {
+		t.Errorf("GetRegions before SetRegions = %v, want nil", regions)
+	}
+	regions := []string{"EU", "US"}
+	_ = m.SetRegions(ctx, regions)
+	regions[0] = "ASIA"
+	if got, _ := m.GetRegions(ctx); !reflect.DeepEqual(got, []string{"EU", "US"}) {
+		t.Errorf("GetRegions = %v, want a copy of [EU US]", got)
+	}
+
+	_ = m.SetGameModes(ctx, map[string]int{"1v1": 2})
+	if modes, _ := m.GetGameModes(ctx); !reflect.DeepEqual(modes, map[string]int{"1v1": 2}) {
+		t.Errorf("GetGameModes = %v", modes)
+	}
+
+	season := &models.Season{SeasonID: "s1"}
+	if err := m.CreateSeason(ctx, season); err != nil {
+		t.Fatalf("CreateSeason: %v", err)
+	}
+	if err := m.CreateSeason(ctx, season); !errors.Is(err, ErrSeasonExists) {
+		t.Errorf("second CreateSeason = %v, want ErrSeasonExists", err)
+	}
+}
+
+func TestInMemorySweepExpired(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStorage()
+	addPlayers(t, m, queuedPlayer("a", 1500))
+	_ = m.SetQueueCooldown(ctx, "a", time.Minute)
+
+	expireValue(m.players, "a")
+	expireValue(m.cooldowns, "a")
+	m.lastSweep = time.Now().Add(-memSweepInterval)
+	_ = m.SetRegions(ctx, []string{"EU"}) // Любая запись запускает очистку
+
+	if _, ok := m.players["a"]; ok {
+		t.Error("expired player key survived the sweep")
+	}
+	if _, ok := m.cooldowns["a"]; ok {
+		t.Error("expired cooldown survived the sweep")
+	}
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"chrono-matchmaking/models"
+)
+
+const (
+	memorySampleSize = 50   // Сколько ключей каждого шаблона измеряется через MEMORY USAGE
+	memoryScanCount  = 1000 // Подсказка COUNT для SCAN
+)
+
+// memoryKeyPatterns шаблоны ключей, которые создает сервис
+var memoryKeyPatterns = []string{
+	"queue:*",
+	"player:*",
+	"match:*",
+	"history:*",
+	"profile:*",
+	"rating:*",
+	"rating_history:*",
+	"last_active:*",
+	"stats:*",
+	"scheduled:*",
+}
+
+// InspectMemoryUsage оценивает память, занимаемую ключами каждого шаблона: ключи
+// считаются через SCAN, а средний размер измеряется MEMORY USAGE на выборке
+// до 50 ключей. SCAN обходит все пространство ключей, поэтому метод предназначен
+// только для административных запросов.
+func (s *RedisStorage) InspectMemoryUsage(ctx context.Context) (*models.MemoryUsageReport, error) {
+	ctx, span := startSpan(ctx, "InspectMemoryUsage")
+	defer span.End()
+
+	report := &models.MemoryUsageReport{
+		Patterns:    make([]models.KeyPatternUsage, 0, len(memoryKeyPatterns)),
+		GeneratedAt: time.Now().UTC(),
+	}
+
+	for _, pattern := range memoryKeyPatterns {
+		usage, err := s.inspectPattern(ctx, pattern)
+		if err != nil {
+			return nil, err
+		}
+		report.Patterns = append(report.Patterns, usage)
+		report.EstimatedTotalMB += usage.EstimatedTotalMB
+	}
+
+	return report, nil
+}
+
+// inspectPattern считает ключи шаблона и оценивает их суммарный размер
+func (s *RedisStorage) inspectPattern(ctx context.Context, pattern string) (models.KeyPatternUsage, error) {
+	usage := models.KeyPatternUsage{Pattern: pattern}
+
+	sample := make([]string, 0, memorySampleSize)
+	err := s.scanKeys(ctx, pattern, memoryScanCount, func(keys []string) error {
+		usage.TotalKeys += int64(len(keys))
+		for _, key := range keys {
+			if len(sample) < memorySampleSize {
+				sample = append(sample, key)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return usage, fmt.Errorf("failed to scan %s: %w", pattern, err)
+	}
+
+	var totalBytes int64
+	for _, key := range sample {
+		bytes, err := s.client.MemoryUsage(ctx, key).Result()
+		if err != nil {
+			// Ключ мог истечь между SCAN и MEMORY USAGE
+			continue
+		}
+		totalBytes += bytes
+		usage.SampledKeys++
+	}
+
+	if usage.SampledKeys > 0 {
+		usage.AvgBytesPerKey = float64(totalBytes) / float64(usage.SampledKeys)
+		usage.EstimatedTotalMB = usage.AvgBytesPerKey * float64(usage.TotalKeys) / (1024 * 1024)
+	}
+
+	return usage, nil
+}
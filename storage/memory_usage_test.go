@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// memoryUsageExecutor отвечает на SCAN ключами из sizes (по scanPage за вызов)
+// и на MEMORY USAGE их размерами. Ключи без размера считаются истекшими.
+// Остальные команды не реализованы.
+type memoryUsageExecutor struct {
+	redisExecutor
+	keys     []string
+	sizes    map[string]int64
+	scanPage int
+	sampled  int // Число вызовов MEMORY USAGE
+}
+
+func newMemoryUsageExecutor(sizes map[string]int64, expired ...string) *memoryUsageExecutor {
+	e := &memoryUsageExecutor{sizes: sizes, scanPage: 7}
+	for key := range sizes {
+		e.keys = append(e.keys, key)
+	}
+	e.keys = append(e.keys, expired...)
+	sort.Strings(e.keys)
+	return e
+}
+
+func (e *memoryUsageExecutor) Scan(ctx context.Context, cursor uint64, match string, count int64) *redis.ScanCmd {
+	var page []string
+	next := int(cursor)
+	for ; next < len(e.keys) && len(page) < e.scanPage; next++ {
+		if ok, _ := path.Match(match, e.keys[next]); ok {
+			page = append(page, e.keys[next])
+		}
+	}
+	if next >= len(e.keys) {
+		next = 0
+	}
+	return redis.NewScanCmdResult(page, uint64(next), nil)
+}
+
+func (e *memoryUsageExecutor) MemoryUsage(ctx context.Context, key string, samples ...int) *redis.IntCmd {
+	e.sampled++
+	size, ok := e.sizes[key]
+	if !ok {
+		return redis.NewIntResult(0, redis.Nil)
+	}
+	return redis.NewIntResult(size, nil)
+}
+
+func TestInspectMemoryUsage(t *testing.T) {
+	sizes := map[string]int64{
+		"queue:EU:3v3": 4 * 1024 * 1024,
+		"queue:US:3v3": 2 * 1024 * 1024,
+		"match:m1":     300,
+		"match:m2":     500,
+	}
+	for i := 0; i < 10; i++ {
+		sizes[fmt.Sprintf("player:%d", i)] = 200
+	}
+	executor := newMemoryUsageExecutor(sizes, "match:expired")
+	s := &RedisStorage{client: executor}
+
+	report, err := s.InspectMemoryUsage(context.Background())
+	if err != nil {
+		t.Fatalf("InspectMemoryUsage: %v", err)
+	}
+	if len(report.Patterns) != len(memoryKeyPatterns) {
+		t.Fatalf("report has %d patterns, want %d", len(report.Patterns), len(memoryKeyPatterns))
+	}
+
+	tests := []struct {
+		pattern     string
+		totalKeys   int64
+		sampledKeys int
+		avgBytes    float64
+		totalMB     float64
+	}{
+		{"queue:*", 2, 2, 3 * 1024 * 1024, 6},
+		{"player:*", 10, 10, 200, 2000.0 / (1024 * 1024)},
+		// Истекший ключ учитывается в SCAN, но не в среднем размере
+		{"match:*", 3, 2, 400, 1200.0 / (1024 * 1024)},
+		{"history:*", 0, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			for _, usage := range report.Patterns {
+				if usage.Pattern != tt.pattern {
+					continue
+				}
+				if usage.TotalKeys != tt.totalKeys || usage.SampledKeys != tt.sampledKeys {
+					t.Errorf("keys = %d total, %d sampled; want %d and %d", usage.TotalKeys, usage.SampledKeys, tt.totalKeys, tt.sampledKeys)
+				}
+				if usage.AvgBytesPerKey != tt.avgBytes {
+					t.Errorf("AvgBytesPerKey = %v, want %v", usage.AvgBytesPerKey, tt.avgBytes)
+				}
+				if math.Abs(usage.EstimatedTotalMB-tt.totalMB) > 1e-9 {
+					t.Errorf("EstimatedTotalMB = %v, want %v", usage.EstimatedTotalMB, tt.totalMB)
+				}
+				return
+			}
+			t.Errorf("pattern %s missing from the report", tt.pattern)
+		})
+	}
+
+	wantTotal := 6 + 3200.0/(1024*1024)
+	if math.Abs(report.EstimatedTotalMB-wantTotal) > 1e-9 {
+		t.Errorf("EstimatedTotalMB = %v, want %v", report.EstimatedTotalMB, wantTotal)
+	}
+}
+
+func TestInspectMemoryUsageSamplesKeys(t *testing.T) {
+	sizes := make(map[string]int64)
+	for i := 0; i < 3*memorySampleSize; i++ {
+		size := int64(100)
+		if i%2 == 1 {
+			size = 300
+		}
+		sizes[fmt.Sprintf("stats:%03d", i)] = size
+	}
+	executor := newMemoryUsageExecutor(sizes)
+	s := &RedisStorage{client: executor}
+
+	usage, err := s.inspectPattern(context.Background(), "stats:*")
+	if err != nil {
+		t.Fatalf("inspectPattern: %v", err)
+	}
+	if usage.TotalKeys != 3*memorySampleSize || usage.SampledKeys != memorySampleSize {
+		t.Errorf("keys = %d total, %d sampled; want %d and %d", usage.TotalKeys, usage.SampledKeys, 3*memorySampleSize, memorySampleSize)
+	}
+	if executor.sampled != memorySampleSize {
+		t.Errorf("MEMORY USAGE called %d times, want %d", executor.sampled, memorySampleSize)
+	}
+	// Среднее по выборке экстраполируется на все ключи шаблона
+	if want := 200.0 * 3 * memorySampleSize / (1024 * 1024); math.Abs(usage.EstimatedTotalMB-want) > 1e-9 {
+		t.Errorf("EstimatedTotalMB = %v, want %v", usage.EstimatedTotalMB, want)
+	}
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"context"
+
+	"chrono-matchmaking/metrics"
+	"github.com/go-redis/redis/v8"
+)
+
+// metricsHook считает ошибки команд Redis в метрике redis_op_errors_total.
+// redis.Nil (отсутствие ключа) ошибкой не считается.
+type metricsHook struct{}
+
+// BeforeProcess реализует redis.Hook
+func (metricsHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+// AfterProcess реализует redis.Hook
+func (metricsHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	recordRedisError(cmd)
+	return nil
+}
+
+// BeforeProcessPipeline реализует redis.Hook
+func (metricsHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+// AfterProcessPipeline реализует redis.Hook
+func (metricsHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	for _, cmd := range cmds {
+		recordRedisError(cmd)
+	}
+	return nil
+}
+
+// recordRedisError увеличивает счетчик ошибок, если команда завершилась ошибкой
+func recordRedisError(cmd redis.Cmder) {
+	if err := cmd.Err(); err != nil && err != redis.Nil {
+		metrics.RedisOpErrorsTotal.WithLabelValues(cmd.Name()).Inc()
+	}
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// SetMomentum сохраняет импульс рейтинга игрока (положительный — серия побед,
+// отрицательный — серия поражений) в momentum:{playerID} на ttl
+func (s *RedisStorage) SetMomentum(ctx context.Context, playerID string, momentum int, ttl time.Duration) error {
+	ctx, span := startSpan(ctx, "SetMomentum", attribute.String("player_id", playerID))
+	defer span.End()
+
+	if err := s.client.Set(ctx, s.momentumKey(playerID), momentum, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save momentum: %w", err)
+	}
+	return nil
+}
+
+// GetMomentum возвращает сохраненный импульс рейтинга игрока; found == false,
+// если импульс не сохранялся или его TTL истек
+func (s *RedisStorage) GetMomentum(ctx context.Context, playerID string) (int, bool, error) {
+	ctx, span := startSpan(ctx, "GetMomentum", attribute.String("player_id", playerID))
+	defer span.End()
+
+	momentum, err := s.client.Get(ctx, s.momentumKey(playerID)).Int()
+	if errors.Is(err, redis.Nil) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get momentum: %w", err)
+	}
+	return momentum, true, nil
+}
+
+// momentumKey возвращает ключ импульса рейтинга игрока
+func (s *RedisStorage) momentumKey(playerID string) string {
+	return fmt.Sprintf("momentum:%s", playerID)
+}
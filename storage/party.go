@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/scripts"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// ErrPartyNotFound возвращается, если группа не найдена или ее срок хранения истек
+var ErrPartyNotFound = errors.New("party not found")
+
+// joinPartyScript скрипт атомарного входа группы в очередь
+var joinPartyScript = redis.NewScript(scripts.JoinParty)
+
+// AddPartyToQueue атомарно добавляет в очередь всех игроков группы и сохраняет саму группу.
+// Если кто-то из игроков уже в очереди, никто не добавляется и возвращается ErrPlayerAlreadyQueued.
+func (s *RedisStorage) AddPartyToQueue(ctx context.Context, party *models.Party, players []*models.Player) error {
+	ctx, span := startSpan(ctx, "AddPartyToQueue")
+	defer span.End()
+
+	if len(players) == 0 {
+		return fmt.Errorf("party has no players")
+	}
+
+	partyJSON, err := json.Marshal(party)
+	if err != nil {
+		return fmt.Errorf("failed to marshal party: %w", err)
+	}
+
+	keys := make([]string, 0, len(players)+2)
+	args := make([]interface{}, 0, 2+2*len(players))
+	keys = append(keys, s.queueKey(party.Region, party.GameMode))
+	args = append(args, int64(PlayerTTL.Seconds()), partyJSON)
+	for _, p := range players {
+		playerJSON, err := json.Marshal(p)
+		if err != nil {
+			return fmt.Errorf("failed to marshal player: %w", err)
+		}
+		keys = append(keys, s.playerKey(p.ID))
+		args = append(args, p.Rating, playerJSON)
+	}
+	keys = append(keys, s.partyKey(party.PartyID))
+
+	res, err := joinPartyScript.Run(ctx, s.client, keys, args...).Int()
+	if err != nil {
+		return fmt.Errorf("failed to run party join script: %w", err)
+	}
+	if res == 0 {
+		return ErrPlayerAlreadyQueued
+	}
+
+	s.incrementQueueFlow(ctx, party.Region, party.GameMode, "joins", int64(len(players)))
+	for _, p := range players {
+		s.recordQueueJoin(ctx, p.ID, p.JoinedAt)
+	}
+
+	logging.FromContext(ctx).Info("Party added to queue",
+		zap.String("party_id", party.PartyID),
+		zap.String("region", party.Region),
+		zap.String("game_mode", party.GameMode),
+		zap.Int("players_count", len(players)),
+	)
+
+	return nil
+}
+
+// GetParty возвращает группу по ее ID
+func (s *RedisStorage) GetParty(ctx context.Context, partyID string) (*models.Party, error) {
+	ctx, span := startSpan(ctx, "GetParty")
+	defer span.End()
+
+	partyJSON, err := s.client.Get(ctx, s.partyKey(partyID)).Result()
+	if err == redis.Nil {
+		return nil, ErrPartyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get party: %w", err)
+	}
+
+	var party models.Party
+	if err := json.Unmarshal([]byte(partyJSON), &party); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal party: %w", err)
+	}
+
+	return &party, nil
+}
+
+// partyKey возвращает ключ группы
+func (s *RedisStorage) partyKey(partyID string) string {
+	return fmt.Sprintf("party:%s", partyID)
+}
@@ -0,0 +1,277 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/scripts"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// ErrPendingMatchNotFound возвращается, если матч не ожидает подтверждения
+// (истек, отклонен или уже подтвержден)
+var ErrPendingMatchNotFound = errors.New("pending match not found")
+
+// createPendingMatchScript скрипт атомарного создания ожидающего матча
+var createPendingMatchScript = redis.NewScript(scripts.CreatePendingMatch)
+
+// CreatePendingMatch одним Lua-скриптом проверяет, что все игроки еще в очереди, убирает их
+// из очереди и сохраняет матч, ожидающий подтверждения, с TTL до ExpiresAt. Если хотя бы
+// один игрок уже удален, ничего не меняется и возвращается ErrMatchConflict.
+func (s *RedisStorage) CreatePendingMatch(ctx context.Context, pending *models.PendingMatch) error {
+	ctx, span := startSpan(ctx, "CreatePendingMatch")
+	defer span.End()
+
+	if len(pending.Players) == 0 {
+		return fmt.Errorf("pending match has no players")
+	}
+
+	pendingJSON, err := json.Marshal(pending)
+	if err != nil {
+		return fmt.Errorf("failed to marshal pending match: %w", err)
+	}
+
+	n := len(pending.Players)
+	keys := make([]string, 0, 2+3*n)
+	for _, p := range pending.Players {
+		keys = append(keys, s.playerQueueKey(&p))
+	}
+	for _, p := range pending.Players {
+		keys = append(keys, s.playerKey(p.ID))
+	}
+	for _, p := range pending.Players {
+		keys = append(keys, s.playerPendingKey(p.ID))
+	}
+	keys = append(keys, s.pendingKey(pending.PendingID), s.pendingIndexKey(pending.Region, pending.GameMode))
+	otherKeys, owners := s.otherQueueKeys(pending.Players)
+	keys = append(keys, otherKeys...)
+
+	ttl := int64(time.Until(pending.ExpiresAt).Seconds())
+	if ttl < 1 {
+		ttl = 1
+	}
+
+	args := append([]interface{}{pendingJSON, ttl, pending.ExpiresAt.Unix(), len(otherKeys)}, owners...)
+	res, err := createPendingMatchScript.Run(ctx, s.client, keys, args...).Int()
+	if err != nil {
+		return fmt.Errorf("failed to run pending match script: %w", err)
+	}
+	if res == 0 {
+		return ErrMatchConflict
+	}
+
+	s.incrementQueueLeaves(ctx, pending.Players)
+
+	// Регистрируем срок подтверждения в потоке истечения для WatchForMatchExpiry
+	if err := s.appendPendingExpiry(ctx, pending); err != nil {
+		logging.FromContext(ctx).Warn("Failed to append pending match to expiry stream",
+			zap.String("pending_id", pending.PendingID),
+			zap.Error(err),
+		)
+	}
+
+	logging.FromContext(ctx).Info("Pending match created",
+		zap.String("pending_id", pending.PendingID),
+		zap.Int("players_count", n),
+		zap.Time("expires_at", pending.ExpiresAt),
+	)
+
+	return nil
+}
+
+// GetPendingMatch возвращает матч, ожидающий подтверждения
+func (s *RedisStorage) GetPendingMatch(ctx context.Context, pendingID string) (*models.PendingMatch, error) {
+	ctx, span := startSpan(ctx, "GetPendingMatch")
+	defer span.End()
+
+	return s.getPendingMatch(ctx, s.pendingKey(pendingID))
+}
+
+// GetPlayerPendingMatch возвращает матч игрока, ожидающий подтверждения
+func (s *RedisStorage) GetPlayerPendingMatch(ctx context.Context, playerID string) (*models.PendingMatch, error) {
+	ctx, span := startSpan(ctx, "GetPlayerPendingMatch", attribute.String("player_id", playerID))
+	defer span.End()
+
+	return s.getPendingMatch(ctx, s.playerPendingKey(playerID))
+}
+
+// getPendingMatch читает ожидающий матч по ключу
+func (s *RedisStorage) getPendingMatch(ctx context.Context, key string) (*models.PendingMatch, error) {
+	pendingJSON, err := s.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return nil, ErrPendingMatchNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending match: %w", err)
+	}
+
+	var pending models.PendingMatch
+	if err := json.Unmarshal([]byte(pendingJSON), &pending); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal pending match: %w", err)
+	}
+
+	return &pending, nil
+}
+
+// AcceptPendingMatch отмечает подтверждение игрока. Возвращает true, если именно это
+// подтверждение оказалось последним недостающим: матч финализирует только этот вызов.
+// Набор подтверждений живет дольше самого матча, чтобы ExpirePendingMatches мог вернуть
+// в очередь подтвердивших игроков.
+func (s *RedisStorage) AcceptPendingMatch(ctx context.Context, pending *models.PendingMatch, playerID string) (bool, error) {
+	ctx, span := startSpan(ctx, "AcceptPendingMatch", attribute.String("player_id", playerID))
+	defer span.End()
+
+	key := s.pendingAcceptedKey(pending.PendingID)
+
+	pipe := s.client.TxPipeline()
+	added := pipe.SAdd(ctx, key, playerID)
+	pipe.ExpireAt(ctx, key, pending.ExpiresAt.Add(pendingAcceptedGrace))
+	count := pipe.SCard(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, fmt.Errorf("failed to accept pending match: %w", err)
+	}
+
+	return added.Val() == 1 && count.Val() == int64(len(pending.PlayerIDs)), nil
+}
+
+// GetAcceptedPlayers возвращает ID игроков, подтвердивших матч
+func (s *RedisStorage) GetAcceptedPlayers(ctx context.Context, pendingID string) ([]string, error) {
+	ctx, span := startSpan(ctx, "GetAcceptedPlayers")
+	defer span.End()
+
+	ids, err := s.client.SMembers(ctx, s.pendingAcceptedKey(pendingID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accepted players: %w", err)
+	}
+	return ids, nil
+}
+
+// ClaimPendingMatch снимает матч с ожидания. Матч удаляется из набора ожидающих через ZREM,
+// поэтому из нескольких конкурирующих завершений (последнее подтверждение, отказ, истечение)
+// выполняется только одно. Возвращает false, если матч уже снят другим вызовом.
+func (s *RedisStorage) ClaimPendingMatch(ctx context.Context, pending *models.PendingMatch) (bool, error) {
+	ctx, span := startSpan(ctx, "ClaimPendingMatch")
+	defer span.End()
+
+	pendingJSON, err := json.Marshal(pending)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal pending match: %w", err)
+	}
+
+	removed, err := s.client.ZRem(ctx, s.pendingIndexKey(pending.Region, pending.GameMode), pendingJSON).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim pending match: %w", err)
+	}
+	if removed == 0 {
+		return false, nil
+	}
+
+	keys := []string{s.pendingKey(pending.PendingID), s.pendingAcceptedKey(pending.PendingID)}
+	for _, playerID := range pending.PlayerIDs {
+		keys = append(keys, s.playerPendingKey(playerID))
+	}
+	if err := s.client.Del(ctx, keys...).Err(); err != nil {
+		logging.FromContext(ctx).Warn("Failed to delete pending match keys",
+			zap.String("pending_id", pending.PendingID),
+			zap.Error(err),
+		)
+	}
+
+	return true, nil
+}
+
+// GetExpiredPendingMatches возвращает ожидающие матчи региона/режима, срок подтверждения
+// которых истек к моменту now. Матчи не снимаются с ожидания — см. ClaimPendingMatch.
+func (s *RedisStorage) GetExpiredPendingMatches(ctx context.Context, region, gameMode string, now time.Time) ([]*models.PendingMatch, error) {
+	ctx, span := startSpan(ctx, "GetExpiredPendingMatches",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	members, err := s.client.ZRangeByScore(ctx, s.pendingIndexKey(region, gameMode), &redis.ZRangeBy{
+		Min: "0",
+		Max: strconv.FormatInt(now.Unix(), 10),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expired pending matches: %w", err)
+	}
+
+	expired := make([]*models.PendingMatch, 0, len(members))
+	for _, member := range members {
+		var pending models.PendingMatch
+		if err := json.Unmarshal([]byte(member), &pending); err != nil {
+			logging.FromContext(ctx).Warn("Dropping malformed pending match",
+				zap.String("data", member),
+				zap.Error(err),
+			)
+			s.client.ZRem(ctx, s.pendingIndexKey(region, gameMode), member)
+			continue
+		}
+		expired = append(expired, &pending)
+	}
+
+	return expired, nil
+}
+
+// SetQueueCooldown запрещает игроку вход в очередь на время duration
+func (s *RedisStorage) SetQueueCooldown(ctx context.Context, playerID string, duration time.Duration) error {
+	ctx, span := startSpan(ctx, "SetQueueCooldown", attribute.String("player_id", playerID))
+	defer span.End()
+
+	if err := s.client.Set(ctx, s.queueCooldownKey(playerID), time.Now().Add(duration).Unix(), duration).Err(); err != nil {
+		return fmt.Errorf("failed to set queue cooldown: %w", err)
+	}
+	return nil
+}
+
+// GetQueueCooldown возвращает оставшееся время запрета на вход в очередь (0 — запрета нет)
+func (s *RedisStorage) GetQueueCooldown(ctx context.Context, playerID string) (time.Duration, error) {
+	ctx, span := startSpan(ctx, "GetQueueCooldown", attribute.String("player_id", playerID))
+	defer span.End()
+
+	ttl, err := s.client.TTL(ctx, s.queueCooldownKey(playerID)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get queue cooldown: %w", err)
+	}
+	if ttl < 0 {
+		return 0, nil // Ключа нет (-2) или он без TTL (-1)
+	}
+	return ttl, nil
+}
+
+// pendingAcceptedGrace сколько набор подтверждений живет после истечения матча
+const pendingAcceptedGrace = time.Minute
+
+// pendingKey возвращает ключ ожидающего матча
+func (s *RedisStorage) pendingKey(pendingID string) string {
+	return fmt.Sprintf("pending:%s", pendingID)
+}
+
+// pendingAcceptedKey возвращает ключ набора игроков, подтвердивших матч
+func (s *RedisStorage) pendingAcceptedKey(pendingID string) string {
+	return fmt.Sprintf("pending:%s:accepted", pendingID)
+}
+
+// playerPendingKey возвращает ключ ожидающего матча игрока
+func (s *RedisStorage) playerPendingKey(playerID string) string {
+	return fmt.Sprintf("pending:player:%s", playerID)
+}
+
+// pendingIndexKey возвращает ключ набора ожидающих матчей региона/режима
+func (s *RedisStorage) pendingIndexKey(region, gameMode string) string {
+	return fmt.Sprintf("pending:matches:%s:%s", region, gameMode)
+}
+
+// queueCooldownKey возвращает ключ запрета на вход в очередь
+func (s *RedisStorage) queueCooldownKey(playerID string) string {
+	return fmt.Sprintf("cooldown:queue:%s", playerID)
+}
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// Имена счетчиков статистики игрока в stats:{playerID}
+const (
+	StatMatchCount = "match_count"
+	StatWins       = "wins"
+	StatLosses     = "losses"
+
+	// StatCalibrationOpponentRating сумма средних рейтингов соперников по калибровочным матчам
+	StatCalibrationOpponentRating = "calibration_opponent_rating"
+)
+
+// PlayerWaitHistoryLimit сколько последних ожиданий матча хранится для игрока
+const PlayerWaitHistoryLimit = 100
+
+// IncrementStat увеличивает счетчик statName в хеше stats:{playerID} на delta
+func (s *RedisStorage) IncrementStat(ctx context.Context, playerID, statName string, delta float64) error {
+	ctx, span := startSpan(ctx, "IncrementStat",
+		attribute.String("player_id", playerID),
+		attribute.String("stat", statName),
+	)
+	defer span.End()
+
+	if err := s.client.HIncrByFloat(ctx, s.playerStatsKey(playerID), statName, delta).Err(); err != nil {
+		return fmt.Errorf("failed to increment player stat: %w", err)
+	}
+	return nil
+}
+
+// GetPlayerStats возвращает все счетчики статистики игрока (пустой набор, если их нет)
+func (s *RedisStorage) GetPlayerStats(ctx context.Context, playerID string) (map[string]float64, error) {
+	ctx, span := startSpan(ctx, "GetPlayerStats", attribute.String("player_id", playerID))
+	defer span.End()
+
+	values, err := s.client.HGetAll(ctx, s.playerStatsKey(playerID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get player stats: %w", err)
+	}
+
+	stats := make(map[string]float64, len(values))
+	for name, raw := range values {
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			logging.FromContext(ctx).Warn("Skipping malformed player stat",
+				zap.String("player_id", playerID),
+				zap.String("stat", name),
+				zap.Error(err),
+			)
+			continue
+		}
+		stats[name] = value
+	}
+	return stats, nil
+}
+
+// RecordPlayerWaitTime добавляет ожидание матча в начало списка stats:{playerID}:waits
+// и обрезает его до PlayerWaitHistoryLimit записей
+func (s *RedisStorage) RecordPlayerWaitTime(ctx context.Context, playerID string, wait time.Duration) error {
+	ctx, span := startSpan(ctx, "RecordPlayerWaitTime", attribute.String("player_id", playerID))
+	defer span.End()
+
+	key := s.playerWaitsKey(playerID)
+	pipe := s.client.TxPipeline()
+	pipe.LPush(ctx, key, wait.Seconds())
+	pipe.LTrim(ctx, key, 0, PlayerWaitHistoryLimit-1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record player wait time: %w", err)
+	}
+	return nil
+}
+
+// GetPlayerWaitTimes возвращает последние ожидания матча игрока, начиная с самого нового
+func (s *RedisStorage) GetPlayerWaitTimes(ctx context.Context, playerID string) ([]time.Duration, error) {
+	ctx, span := startSpan(ctx, "GetPlayerWaitTimes", attribute.String("player_id", playerID))
+	defer span.End()
+
+	values, err := s.client.LRange(ctx, s.playerWaitsKey(playerID), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get player wait times: %w", err)
+	}
+
+	waits := make([]time.Duration, 0, len(values))
+	for _, raw := range values {
+		seconds, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			continue
+		}
+		waits = append(waits, time.Duration(seconds*float64(time.Second)))
+	}
+	return waits, nil
+}
+
+// incrementMatchCounts увеличивает match_count каждого игрока матча
+func (s *RedisStorage) incrementMatchCounts(ctx context.Context, players []models.Player) {
+	for _, p := range players {
+		if err := s.IncrementStat(ctx, p.ID, StatMatchCount, 1); err != nil {
+			logging.FromContext(ctx).Warn("Failed to increment match count",
+				zap.String("player_id", p.ID),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// playerStatsKey возвращает ключ хеша статистики игрока
+func (s *RedisStorage) playerStatsKey(playerID string) string {
+	return fmt.Sprintf("stats:%s", playerID)
+}
+
+// playerWaitsKey возвращает ключ списка ожиданий матча игрока
+func (s *RedisStorage) playerWaitsKey(playerID string) string {
+	return fmt.Sprintf("stats:%s:waits", playerID)
+}
@@ -0,0 +1,186 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// ErrProfileNotFound возвращается, если профиль игрока не зарегистрирован
+var ErrProfileNotFound = errors.New("player profile not found")
+
+// CreateProfile сохраняет профиль игрока в хэше profile:{playerID} без TTL.
+// Возвращает false, если профиль с таким ID уже существует (он не перезаписывается).
+func (s *RedisStorage) CreateProfile(ctx context.Context, profile *models.PlayerProfile) (bool, error) {
+	ctx, span := startSpan(ctx, "CreateProfile")
+	defer span.End()
+
+	key := s.profileKey(profile.PlayerID)
+
+	// HSETNX по обязательному полю гарантирует, что параллельные регистрации не перезапишут профиль
+	created, err := s.client.HSetNX(ctx, key, "player_id", profile.PlayerID).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to create profile: %w", err)
+	}
+	if !created {
+		return false, nil
+	}
+
+	err = s.client.HSet(ctx, key,
+		"display_name", profile.DisplayName,
+		"email", profile.Email,
+		"platform", profile.Platform,
+		"total_matches", profile.TotalMatches,
+		"created_at", profile.CreatedAt.UTC().Format(time.RFC3339Nano),
+	).Err()
+	if err != nil {
+		return false, fmt.Errorf("failed to save profile: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("Player profile created",
+		zap.String("player_id", profile.PlayerID),
+		zap.String("platform", profile.Platform),
+	)
+
+	return true, nil
+}
+
+// GetProfile возвращает профиль игрока или ErrProfileNotFound
+func (s *RedisStorage) GetProfile(ctx context.Context, playerID string) (*models.PlayerProfile, error) {
+	ctx, span := startSpan(ctx, "GetProfile", attribute.String("player_id", playerID))
+	defer span.End()
+
+	fields, err := s.client.HGetAll(ctx, s.profileKey(playerID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get profile: %w", err)
+	}
+	if len(fields) == 0 {
+		return nil, ErrProfileNotFound
+	}
+
+	profile := &models.PlayerProfile{
+		PlayerID:    fields["player_id"],
+		DisplayName: fields["display_name"],
+		Email:       fields["email"],
+		Platform:    fields["platform"],
+	}
+	if v, ok := fields["total_matches"]; ok {
+		profile.TotalMatches, _ = strconv.Atoi(v)
+	}
+	if v, ok := fields["win_streak"]; ok {
+		profile.WinStreak, _ = strconv.Atoi(v)
+	}
+	if v, ok := fields["loss_streak"]; ok {
+		profile.LossStreak, _ = strconv.Atoi(v)
+	}
+	if v, ok := fields["created_at"]; ok {
+		profile.CreatedAt, _ = time.Parse(time.RFC3339Nano, v)
+	}
+
+	tokens, err := s.GetDeviceTokens(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+	profile.DeviceTokens = tokens
+
+	return profile, nil
+}
+
+// IncrementTotalMatches увеличивает счетчик сыгранных матчей в профиле игрока.
+// Игрок без профиля пропускается: HINCRBY создал бы профиль из одного поля.
+func (s *RedisStorage) IncrementTotalMatches(ctx context.Context, playerID string) error {
+	ctx, span := startSpan(ctx, "IncrementTotalMatches", attribute.String("player_id", playerID))
+	defer span.End()
+
+	key := s.profileKey(playerID)
+	exists, err := s.client.Exists(ctx, key).Result()
+	if err != nil {
+		return fmt.Errorf("failed to check profile: %w", err)
+	}
+	if exists == 0 {
+		return nil
+	}
+
+	if err := s.client.HIncrBy(ctx, key, "total_matches", 1).Err(); err != nil {
+		return fmt.Errorf("failed to increment total matches: %w", err)
+	}
+	return nil
+}
+
+// RecordMatchOutcome продлевает серию побед или поражений в профиле игрока и
+// обнуляет противоположную серию. Игрок без профиля пропускается.
+func (s *RedisStorage) RecordMatchOutcome(ctx context.Context, playerID string, won bool) error {
+	ctx, span := startSpan(ctx, "RecordMatchOutcome", attribute.String("player_id", playerID))
+	defer span.End()
+
+	key := s.profileKey(playerID)
+	exists, err := s.client.Exists(ctx, key).Result()
+	if err != nil {
+		return fmt.Errorf("failed to check profile: %w", err)
+	}
+	if exists == 0 {
+		return nil
+	}
+
+	streak, broken := "loss_streak", "win_streak"
+	if won {
+		streak, broken = broken, streak
+	}
+	pipe := s.client.TxPipeline()
+	pipe.HIncrBy(ctx, key, streak, 1)
+	pipe.HSet(ctx, key, broken, 0)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record match outcome: %w", err)
+	}
+	return nil
+}
+
+// AddDeviceToken регистрирует токен устройства игрока для push-уведомлений.
+// Возвращает ErrProfileNotFound, если игрок не зарегистрирован.
+func (s *RedisStorage) AddDeviceToken(ctx context.Context, playerID, token string) error {
+	ctx, span := startSpan(ctx, "AddDeviceToken", attribute.String("player_id", playerID))
+	defer span.End()
+
+	exists, err := s.client.Exists(ctx, s.profileKey(playerID)).Result()
+	if err != nil {
+		return fmt.Errorf("failed to check profile: %w", err)
+	}
+	if exists == 0 {
+		return ErrProfileNotFound
+	}
+
+	if err := s.client.SAdd(ctx, s.deviceTokensKey(playerID), token).Err(); err != nil {
+		return fmt.Errorf("failed to add device token: %w", err)
+	}
+
+	return nil
+}
+
+// GetDeviceTokens возвращает токены устройств игрока
+func (s *RedisStorage) GetDeviceTokens(ctx context.Context, playerID string) ([]string, error) {
+	ctx, span := startSpan(ctx, "GetDeviceTokens", attribute.String("player_id", playerID))
+	defer span.End()
+
+	tokens, err := s.client.SMembers(ctx, s.deviceTokensKey(playerID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// profileKey возвращает ключ профиля игрока
+func (s *RedisStorage) profileKey(playerID string) string {
+	return fmt.Sprintf("profile:%s", playerID)
+}
+
+// deviceTokensKey возвращает ключ множества токенов устройств игрока
+func (s *RedisStorage) deviceTokensKey(playerID string) string {
+	return fmt.Sprintf("profile:%s:device-tokens", playerID)
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"chrono-matchmaking/models"
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrQueueFull возвращается, если очередь региона и режима достигла максимального размера
+var ErrQueueFull = errors.New("queue is full")
+
+// SetMaxQueueSize задает максимальное число игроков в очереди региона и режима
+// (обычная, приоритетная и очередь подозрительных вместе); 0 — без ограничения
+func (s *RedisStorage) SetMaxQueueSize(size int) {
+	s.maxQueueSize.Store(int64(size))
+}
+
+// queueRoom возвращает, сколько игроков еще можно добавить в очередь региона и режима.
+// Размер проверяется до добавления, поэтому при одновременных входах очередь может
+// ненадолго превысить ограничение на число этих входов.
+func (s *RedisStorage) queueRoom(ctx context.Context, region, gameMode string) (int64, error) {
+	limit := s.maxQueueSize.Load()
+	if limit <= 0 {
+		return math.MaxInt64, nil
+	}
+
+	pipe := s.client.Pipeline()
+	keys := s.queueKeys(region, gameMode)
+	cards := make([]*redis.IntCmd, len(keys))
+	for i, key := range keys {
+		cards[i] = pipe.ZCard(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to get queue size: %w", err)
+	}
+
+	size := int64(0)
+	for _, card := range cards {
+		size += card.Val()
+	}
+	return limit - size, nil
+}
+
+// reserveQueueRoom отмечает ErrQueueFull игроков, которым не хватило места в очереди
+// их региона и режима. Места распределяются в порядке players; игроки с ошибкой пропускаются.
+func (s *RedisStorage) reserveQueueRoom(ctx context.Context, players []*models.Player, errs []error) {
+	if s.maxQueueSize.Load() <= 0 {
+		return
+	}
+
+	rooms := make(map[string]int64)
+	for i, player := range players {
+		if errs[i] != nil {
+			continue
+		}
+		queue := player.Region + ":" + player.GameMode
+		room, ok := rooms[queue]
+		if !ok {
+			var err error
+			if room, err = s.queueRoom(ctx, player.Region, player.GameMode); err != nil {
+				errs[i] = err
+				continue
+			}
+		}
+		if room <= 0 {
+			errs[i] = ErrQueueFull
+		}
+		rooms[queue] = room - 1
+	}
+}
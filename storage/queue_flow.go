@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// GetQueueFlowCounters возвращает накопленное количество входов в очередь и выходов из нее
+// (добровольных, в матч и при очистке)
+func (s *RedisStorage) GetQueueFlowCounters(ctx context.Context, region, gameMode string) (joins, leaves int64, err error) {
+	ctx, span := startSpan(ctx, "GetQueueFlowCounters",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	values, err := s.client.HMGet(ctx, s.queueFlowKey(region, gameMode), "joins", "leaves").Result()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get queue flow counters: %w", err)
+	}
+
+	if v, ok := values[0].(string); ok {
+		joins, _ = strconv.ParseInt(v, 10, 64)
+	}
+	if v, ok := values[1].(string); ok {
+		leaves, _ = strconv.ParseInt(v, 10, 64)
+	}
+
+	return joins, leaves, nil
+}
+
+// TryStartQueuePurge отмечает начало очистки очереди, если предыдущая была больше cooldown назад.
+// Возвращает false, если очистка выполнялась недавно.
+func (s *RedisStorage) TryStartQueuePurge(ctx context.Context, region, gameMode string, cooldown time.Duration) (bool, error) {
+	ctx, span := startSpan(ctx, "TryStartQueuePurge",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	started, err := s.client.SetNX(ctx, s.lastPurgeKey(region, gameMode), time.Now().Unix(), cooldown).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check last purge: %w", err)
+	}
+	return started, nil
+}
+
+// MarkQueuePurged запоминает время последней очистки очереди на cooldown
+func (s *RedisStorage) MarkQueuePurged(ctx context.Context, region, gameMode string, cooldown time.Duration) error {
+	ctx, span := startSpan(ctx, "MarkQueuePurged",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	if err := s.client.Set(ctx, s.lastPurgeKey(region, gameMode), time.Now().Unix(), cooldown).Err(); err != nil {
+		return fmt.Errorf("failed to mark queue purge: %w", err)
+	}
+	return nil
+}
+
+// queueHistoryLimit максимальное количество входов в очередь в истории игрока
+const queueHistoryLimit = 100
+
+// GetPlayerQueueHistory возвращает моменты входа игрока в очередь начиная с since
+// (история хранится 24 часа)
+func (s *RedisStorage) GetPlayerQueueHistory(ctx context.Context, playerID string, since time.Time) ([]time.Time, error) {
+	ctx, span := startSpan(ctx, "GetPlayerQueueHistory", attribute.String("player_id", playerID))
+	defer span.End()
+
+	results, err := s.client.ZRangeByScore(ctx, s.queueHistoryKey(playerID), &redis.ZRangeBy{
+		Min: strconv.FormatInt(since.UnixMilli(), 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get queue history: %w", err)
+	}
+
+	joins := make([]time.Time, 0, len(results))
+	for _, result := range results {
+		ms, err := strconv.ParseInt(result, 10, 64)
+		if err != nil {
+			continue
+		}
+		joins = append(joins, time.UnixMilli(ms))
+	}
+
+	return joins, nil
+}
+
+// recordQueueJoin добавляет вход в очередь в историю игрока
+func (s *RedisStorage) recordQueueJoin(ctx context.Context, playerID string, joinedAt time.Time) {
+	key := s.queueHistoryKey(playerID)
+	ms := joinedAt.UnixMilli()
+
+	pipe := s.client.TxPipeline()
+	pipe.ZAdd(ctx, key, &redis.Z{Score: float64(ms), Member: ms})
+	pipe.ZRemRangeByRank(ctx, key, 0, -queueHistoryLimit-1)
+	pipe.Expire(ctx, key, matchRecordTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		logging.FromContext(ctx).Warn("Failed to record queue join",
+			zap.String("player_id", playerID),
+			zap.Error(err),
+		)
+	}
+}
+
+// incrementQueueFlow увеличивает счетчик входов или выходов очереди.
+// Счетчики нужны только для статистики, поэтому ошибка лишь логируется.
+func (s *RedisStorage) incrementQueueFlow(ctx context.Context, region, gameMode, field string, n int64) {
+	if err := s.client.HIncrBy(ctx, s.queueFlowKey(region, gameMode), field, n).Err(); err != nil {
+		logging.FromContext(ctx).Warn("Failed to update queue flow counter",
+			zap.String("region", region),
+			zap.String("game_mode", gameMode),
+			zap.String("field", field),
+			zap.Error(err),
+		)
+	}
+}
+
+// queueFlowKey возвращает ключ счетчиков входов и выходов очереди
+func (s *RedisStorage) queueFlowKey(region, gameMode string) string {
+	return fmt.Sprintf("stats:flow:%s:%s", region, gameMode)
+}
+
+// queueHistoryKey возвращает ключ истории входов игрока в очередь
+func (s *RedisStorage) queueHistoryKey(playerID string) string {
+	return fmt.Sprintf("history:queue:%s", playerID)
+}
+
+// lastPurgeKey возвращает ключ времени последней очистки очереди
+func (s *RedisStorage) lastPurgeKey(region, gameMode string) string {
+	return fmt.Sprintf("last_purge:%s:%s", region, gameMode)
+}
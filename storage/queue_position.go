@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"chrono-matchmaking/models"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrPlayerNotInQueue возвращается, если игрока нет в очереди
+var ErrPlayerNotInQueue = errors.New("player not in queue")
+
+// queuePositionsTTL сколько хранится снимок позиций очереди; более старый снимок
+// не сравнивается с текущими позициями
+const queuePositionsTTL = 5 * time.Minute
+
+// GetPlayerQueuePosition возвращает позицию игрока среди ожидающих в его регионе и режиме:
+// количество игроков с рейтингом не выше, чем у него (1 — самый низкий рейтинг)
+func (s *RedisStorage) GetPlayerQueuePosition(ctx context.Context, playerID string) (int64, error) {
+	ctx, span := startSpan(ctx, "GetPlayerQueuePosition", attribute.String("player_id", playerID))
+	defer span.End()
+
+	// Элемент очереди совпадает с JSON ключа игрока
+	playerJSON, err := s.client.Get(ctx, s.playerKey(playerID)).Result()
+	if err == redis.Nil {
+		return 0, ErrPlayerNotInQueue
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get player: %w", err)
+	}
+
+	var player models.Player
+	if err := json.Unmarshal([]byte(playerJSON), &player); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal player: %w", err)
+	}
+
+	score, err := s.client.ZScore(ctx, s.playerQueueKey(&player), playerJSON).Result()
+	if err == redis.Nil {
+		return 0, ErrPlayerNotInQueue
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get player score: %w", err)
+	}
+
+	// Позиция считается среди игроков обеих очередей
+	var position int64
+	for _, key := range s.queueKeys(player.Region, player.GameMode) {
+		count, err := s.client.ZCount(ctx, key, "-inf", strconv.FormatFloat(score, 'f', -1, 64)).Result()
+		if err != nil {
+			return 0, fmt.Errorf("failed to count players before: %w", err)
+		}
+		position += count
+	}
+
+	return position, nil
+}
+
+// SwapQueuePositions заменяет снимок позиций игроков очереди (хэш
+// queue:positions:{region}:{game_mode}, игрок -> позиция) на positions и возвращает
+// предыдущий снимок. Чтение и замена выполняются одной транзакцией, поэтому снимок
+// общий для всех реплик: новый лидер очереди сравнивает позиции со снимком прежнего.
+// Снимок хранится 5 минут.
+func (s *RedisStorage) SwapQueuePositions(ctx context.Context, region, gameMode string, positions map[string]int64) (map[string]int64, error) {
+	ctx, span := startSpan(ctx, "SwapQueuePositions",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	key := s.queuePositionsKey(region, gameMode)
+	pipe := s.client.TxPipeline()
+	previousCmd := pipe.HGetAll(ctx, key)
+	pipe.Del(ctx, key)
+	if len(positions) > 0 {
+		fields := make(map[string]interface{}, len(positions))
+		for playerID, position := range positions {
+			fields[playerID] = position
+		}
+		pipe.HSet(ctx, key, fields)
+		pipe.Expire(ctx, key, queuePositionsTTL)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to swap queue positions: %w", err)
+	}
+
+	previous := make(map[string]int64, len(previousCmd.Val()))
+	for playerID, raw := range previousCmd.Val() {
+		position, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			continue
+		}
+		previous[playerID] = position
+	}
+	return previous, nil
+}
+
+// queuePositionsKey возвращает ключ снимка позиций игроков очереди
+func (s *RedisStorage) queuePositionsKey(region, gameMode string) string {
+	return fmt.Sprintf("queue:positions:%s:%s", region, gameMode)
+}
@@ -0,0 +1,343 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/scripts"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// GetQueueMemberCount считает участников обеих очередей, разбирая JSON каждого элемента.
+// stale — игроки, ожидающие дольше staleAfter, active — остальные, total — все элементы.
+func (s *RedisStorage) GetQueueMemberCount(ctx context.Context, region, gameMode string, staleAfter time.Duration) (active, stale, total int64, err error) {
+	ctx, span := startSpan(ctx, "GetQueueMemberCount",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	var members []string
+	for _, key := range s.queueKeys(region, gameMode) {
+		keyMembers, err := s.client.ZRange(ctx, key, 0, -1).Result()
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("failed to get queue members: %w", err)
+		}
+		members = append(members, keyMembers...)
+	}
+
+	now := time.Now()
+	for _, member := range members {
+		total++
+
+		var player models.Player
+		if err := json.Unmarshal([]byte(member), &player); err != nil {
+			// Нечитаемый элемент никогда не попадет в матч
+			stale++
+			continue
+		}
+
+		if now.Sub(player.JoinedAt) > staleAfter {
+			stale++
+		} else {
+			active++
+		}
+	}
+
+	return active, stale, total, nil
+}
+
+// RemoveStalePlayers удаляет из обеих очередей игроков, ожидающих дольше staleAfter,
+// и возвращает количество удаленных
+func (s *RedisStorage) RemoveStalePlayers(ctx context.Context, region, gameMode string, staleAfter time.Duration) (int64, error) {
+	ctx, span := startSpan(ctx, "RemoveStalePlayers",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	now := time.Now()
+	pipe := s.client.TxPipeline()
+	var removed int64
+	for _, key := range s.queueKeys(region, gameMode) {
+		members, err := s.client.ZRange(ctx, key, 0, -1).Result()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get queue members: %w", err)
+		}
+
+		for _, member := range members {
+			var player models.Player
+			if err := json.Unmarshal([]byte(member), &player); err == nil && now.Sub(player.JoinedAt) <= staleAfter {
+				continue
+			}
+
+			pipe.ZRem(ctx, key, member)
+			if player.ID != "" {
+				pipe.Del(ctx, s.playerKey(player.ID))
+			}
+			removed++
+		}
+	}
+
+	if removed == 0 {
+		return 0, nil
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to remove stale players: %w", err)
+	}
+	s.incrementQueueFlow(ctx, region, gameMode, "leaves", removed)
+
+	logging.FromContext(ctx).Info("Stale players removed from queue",
+		zap.String("region", region),
+		zap.String("game_mode", gameMode),
+		zap.Int64("removed", removed),
+	)
+
+	return removed, nil
+}
+
+// ReindexQueueScores приводит элементы очереди в соответствие с актуальными данными
+// player:{id}. Если рейтинг игрока изменился, старый элемент удаляется и добавляется
+// новый с текущим JSON и рейтингом в качестве score — элемент очереди должен совпадать
+// с ключом игрока, иначе RemovePlayerFromQueue не найдет его. Возвращает количество
+// обновленных элементов в обеих очередях.
+func (s *RedisStorage) ReindexQueueScores(ctx context.Context, region, gameMode string) (int64, error) {
+	ctx, span := startSpan(ctx, "ReindexQueueScores",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	var updated int64
+	for _, key := range s.queueKeys(region, gameMode) {
+		n, err := s.reindexQueueKey(ctx, key)
+		if err != nil {
+			return updated, err
+		}
+		updated += n
+	}
+
+	if updated > 0 {
+		logging.FromContext(ctx).Info("Queue scores reindexed",
+			zap.String("region", region),
+			zap.String("game_mode", gameMode),
+			zap.Int64("updated", updated),
+		)
+	}
+
+	return updated, nil
+}
+
+// reindexQueueKey приводит элементы одной очереди в соответствие с ключами игроков
+func (s *RedisStorage) reindexQueueKey(ctx context.Context, key string) (int64, error) {
+	members, err := s.client.ZRangeWithScores(ctx, key, 0, -1).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get queue members: %w", err)
+	}
+	if len(members) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]string, 0, len(members))
+	playerKeys := make([]string, 0, len(members))
+	queued := make([]redis.Z, 0, len(members))
+	for _, member := range members {
+		raw, _ := member.Member.(string)
+		var player models.Player
+		if err := json.Unmarshal([]byte(raw), &player); err != nil || player.ID == "" {
+			continue
+		}
+		ids = append(ids, player.ID)
+		playerKeys = append(playerKeys, s.playerKey(player.ID))
+		queued = append(queued, member)
+	}
+	if len(playerKeys) == 0 {
+		return 0, nil
+	}
+
+	stored, err := s.client.MGet(ctx, playerKeys...).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get players: %w", err)
+	}
+
+	pipe := s.client.TxPipeline()
+	var updated int64
+	for i, value := range stored {
+		playerJSON, ok := value.(string)
+		if !ok {
+			// Ключ игрока истек — такого участника уберет очистка очереди
+			continue
+		}
+
+		var player models.Player
+		if err := json.Unmarshal([]byte(playerJSON), &player); err != nil {
+			logging.FromContext(ctx).Warn("Failed to unmarshal player",
+				zap.String("player_id", ids[i]),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		if float64(player.Rating) == queued[i].Score && playerJSON == queued[i].Member {
+			continue
+		}
+
+		pipe.ZRem(ctx, key, queued[i].Member)
+		pipe.ZAdd(ctx, key, &redis.Z{
+			Score:  float64(player.Rating),
+			Member: playerJSON,
+		})
+		updated++
+	}
+
+	if updated == 0 {
+		return 0, nil
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to reindex queue: %w", err)
+	}
+
+	return updated, nil
+}
+
+// GetQueueDepthByBracket считает игроков обеих очередей в каждом диапазоне рейтинга.
+// Все ZCOUNT отправляются одним пайплайном
+func (s *RedisStorage) GetQueueDepthByBracket(ctx context.Context, region, gameMode string, brackets []models.RatingBracket) ([]models.BracketDepth, error) {
+	ctx, span := startSpan(ctx, "GetQueueDepthByBracket",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	keys := s.queueKeys(region, gameMode)
+	pipe := s.client.Pipeline()
+	cmds := make([][]*redis.IntCmd, len(brackets))
+	for i, b := range brackets {
+		max := "+inf"
+		if b.Max > 0 {
+			max = strconv.Itoa(b.Max)
+		}
+		for _, key := range keys {
+			cmds[i] = append(cmds[i], pipe.ZCount(ctx, key, strconv.Itoa(b.Min), max))
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to count queue brackets: %w", err)
+	}
+
+	result := make([]models.BracketDepth, len(brackets))
+	for i, b := range brackets {
+		result[i] = models.BracketDepth{Min: b.Min, Max: b.Max}
+		for _, cmd := range cmds[i] {
+			result[i].Count += cmd.Val()
+		}
+	}
+	return result, nil
+}
+
+// removeDrainedPlayerScript скрипт удаления игрока, оставшегося после остановки реплики
+var removeDrainedPlayerScript = redis.NewScript(scripts.RemoveDrainedPlayer)
+
+// drainKeyGrace сколько отметки ScheduleQueueRemoval хранятся после срока удаления
+const drainKeyGrace = 24 * time.Hour
+
+// drainKey возвращает ключ игроков очереди, которые будут удалены после остановки
+// реплики (sorted set: элемент очереди -> unix-время удаления). Ключ лежит вне queue:*,
+// чтобы не попадать в снимки очередей.
+func (s *RedisStorage) drainKey(region, gameMode string) string {
+	return fmt.Sprintf("drain:queue:%s:%s", region, gameMode)
+}
+
+// ScheduleQueueRemoval отмечает игроков, стоящих сейчас в очередях региона/режима,
+// для удаления через ttl, чтобы оставшиеся после остановки сервиса игроки не висели в
+// очереди бессрочно. Сами очереди срока жизни не получают: игроки, вставшие в них
+// позже, не затрагиваются. Удаляет отмеченных RemoveDrainedPlayers. Возвращает
+// количество отмеченных игроков.
+func (s *RedisStorage) ScheduleQueueRemoval(ctx context.Context, region, gameMode string, ttl time.Duration) (int64, error) {
+	ctx, span := startSpan(ctx, "ScheduleQueueRemoval",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	deadline := float64(time.Now().Add(ttl).Unix())
+	var scheduled []*redis.Z
+	for _, key := range s.queueKeys(region, gameMode) {
+		members, err := s.client.ZRange(ctx, key, 0, -1).Result()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get queue members: %w", err)
+		}
+		for _, member := range members {
+			scheduled = append(scheduled, &redis.Z{Score: deadline, Member: member})
+		}
+	}
+	if len(scheduled) == 0 {
+		return 0, nil
+	}
+
+	// Если очередь больше никто не обработает, отметки не должны жить вечно
+	key := s.drainKey(region, gameMode)
+	pipe := s.client.TxPipeline()
+	pipe.ZAdd(ctx, key, scheduled...)
+	pipe.Expire(ctx, key, ttl+drainKeyGrace)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to schedule queue removal: %w", err)
+	}
+	return int64(len(scheduled)), nil
+}
+
+// RemoveDrainedPlayers удаляет из очередей региона/режима игроков, отмеченных
+// ScheduleQueueRemoval, срок которых к now прошел, вместе с их ключами player:{id}.
+// Игрок, который перезашел в очередь или получил новый рейтинг, не удаляется.
+// Возвращает количество удаленных игроков.
+func (s *RedisStorage) RemoveDrainedPlayers(ctx context.Context, region, gameMode string, now time.Time) (int64, error) {
+	ctx, span := startSpan(ctx, "RemoveDrainedPlayers",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	key := s.drainKey(region, gameMode)
+	members, err := s.client.ZRangeByScore(ctx, key, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(now.Unix(), 10),
+	}).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get drained players: %w", err)
+	}
+
+	var removed int64
+	for _, member := range members {
+		var player models.Player
+		if err := json.Unmarshal([]byte(member), &player); err == nil && player.ID != "" {
+			keys := append([]string{s.playerKey(player.ID)}, s.queueKeys(region, gameMode)...)
+			n, err := removeDrainedPlayerScript.Run(ctx, s.client, keys, member).Int()
+			if err != nil {
+				return removed, fmt.Errorf("failed to remove drained player: %w", err)
+			}
+			removed += int64(n)
+		}
+		if err := s.client.ZRem(ctx, key, member).Err(); err != nil {
+			return removed, fmt.Errorf("failed to remove drained player: %w", err)
+		}
+	}
+
+	if removed > 0 {
+		s.incrementQueueFlow(ctx, region, gameMode, "leaves", removed)
+		logging.FromContext(ctx).Info("Players left after shutdown removed from queue",
+			zap.String("region", region),
+			zap.String("game_mode", gameMode),
+			zap.Int64("removed", removed),
+		)
+	}
+	return removed, nil
+}
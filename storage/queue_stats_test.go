@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"chrono-matchmaking/models"
+)
+
+// testReindexQueueScores ставит в очередь 10 игроков, у пятерых из которых рейтинг
+// в ключе игрока затем меняется в обход очереди (как при массовом пересчете), и
+// проверяет, что ReindexQueueScores обновляет ровно эти пять элементов
+func testReindexQueueScores(t *testing.T, s Storage, setPlayerKey func(player *models.Player)) {
+	t.Helper()
+	ctx := context.Background()
+
+	players := make([]*models.Player, 10)
+	for i := range players {
+		players[i] = queuedPlayer(fmt.Sprintf("p%d", i), 1000+10*i)
+	}
+	addPlayers(t, s, players...)
+
+	for _, p := range players[:5] {
+		recalculated := *p
+		recalculated.Rating = 2000 + p.Rating
+		setPlayerKey(&recalculated)
+	}
+
+	updated, err := s.ReindexQueueScores(ctx, "EU", "3v3")
+	if err != nil {
+		t.Fatalf("ReindexQueueScores: %v", err)
+	}
+	if updated != 5 {
+		t.Errorf("ReindexQueueScores = %d, want 5", updated)
+	}
+	if updated, _ := s.ReindexQueueScores(ctx, "EU", "3v3"); updated != 0 {
+		t.Errorf("second ReindexQueueScores = %d, want 0", updated)
+	}
+
+	queue, _ := s.GetQueuePlayers(ctx, "EU", "3v3")
+	want := []string{"p5", "p6", "p7", "p8", "p9", "p0", "p1", "p2", "p3", "p4"}
+	if got := playerIDs(queue); !reflect.DeepEqual(got, want) {
+		t.Errorf("queue order = %v, want %v", got, want)
+	}
+	if queue[5].Rating != 3000 {
+		t.Errorf("reindexed rating of p0 = %d, want 3000", queue[5].Rating)
+	}
+
+	// Элемент очереди совпадает с ключом игрока, поэтому выход из очереди находит его
+	if err := s.RemovePlayerFromQueue(ctx, "p0"); err != nil {
+		t.Fatalf("RemovePlayerFromQueue: %v", err)
+	}
+	if size, _ := s.GetQueueSize(ctx, "EU", "3v3"); size != 9 {
+		t.Errorf("queue size after removing a reindexed player = %d, want 9", size)
+	}
+}
+
+func TestInMemoryReindexQueueScores(t *testing.T) {
+	m := NewInMemoryStorage()
+	testReindexQueueScores(t, m, func(player *models.Player) {
+		data, _ := json.Marshal(player)
+		value := m.players[player.ID]
+		value.data = string(data)
+		m.players[player.ID] = value
+	})
+}
+
+func TestRedisReindexQueueScores(t *testing.T) {
+	s, raw := newTestRedisStorage(t)
+	testReindexQueueScores(t, s, func(player *models.Player) {
+		data, _ := json.Marshal(player)
+		if err := raw.Set(context.Background(), s.playerKey(player.ID), data, PlayerTTL).Err(); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	})
+}
+
+// TestRedisRemoveDrainedPlayers проверяет, что после остановки реплики удаляются только
+// игроки, стоявшие в очереди в момент остановки, вместе с их ключами, а сама общая
+// очередь срока жизни не получает
+func TestRedisRemoveDrainedPlayers(t *testing.T) {
+	ctx := context.Background()
+	s, raw := newTestRedisStorage(t)
+
+	left, rejoined := queuedPlayer("left", 1500), queuedPlayer("rejoined", 1500)
+	addPlayers(t, s, left, rejoined)
+	if scheduled, err := s.ScheduleQueueRemoval(ctx, "EU", "3v3", time.Minute); err != nil || scheduled != 2 {
+		t.Fatalf("ScheduleQueueRemoval = %d, %v; want 2", scheduled, err)
+	}
+	if ttl, _ := raw.TTL(ctx, s.queueKey("EU", "3v3")).Result(); ttl >= 0 {
+		t.Errorf("shared queue got TTL %v", ttl)
+	}
+
+	// Игрок перезашел в очередь, а другой встал в нее после остановки
+	if err := s.RemovePlayerFromQueue(ctx, "rejoined"); err != nil {
+		t.Fatalf("RemovePlayerFromQueue: %v", err)
+	}
+	rejoined.JoinedAt = rejoined.JoinedAt.Add(time.Second)
+	addPlayers(t, s, rejoined, queuedPlayer("fresh", 1500))
+
+	if removed, _ := s.RemoveDrainedPlayers(ctx, "EU", "3v3", time.Now()); removed != 0 {
+		t.Fatalf("RemoveDrainedPlayers before the deadline = %d, want 0", removed)
+	}
+	removed, err := s.RemoveDrainedPlayers(ctx, "EU", "3v3", time.Now().Add(2*time.Minute))
+	if err != nil || removed != 1 {
+		t.Fatalf("RemoveDrainedPlayers = %d, %v; want 1", removed, err)
+	}
+
+	if _, err := s.GetPlayerByID(ctx, "left"); err == nil {
+		t.Error("player key of the drained player kept")
+	}
+	for _, id := range []string{"rejoined", "fresh"} {
+		if _, err := s.GetPlayerQueuePosition(ctx, id); err != nil {
+			t.Errorf("player %s removed from queue: %v", id, err)
+		}
+	}
+	if err := s.AddPlayerToQueue(ctx, queuedPlayer("left", 1500)); err != nil {
+		t.Errorf("drained player cannot rejoin: %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// IncrementRateLimit увеличивает счетчик запросов клиента за текущую секунду и
+// возвращает его новое значение. Ключ живет две секунды, чтобы пережить сдвиг часов реплик.
+func (s *RedisStorage) IncrementRateLimit(ctx context.Context, clientID string, now time.Time) (int64, error) {
+	ctx, span := startSpan(ctx, "IncrementRateLimit")
+	defer span.End()
+
+	key := s.rateLimitKey(clientID, now.Unix())
+
+	pipe := s.client.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, 2*time.Second)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to increment rate limit: %w", err)
+	}
+
+	return incr.Val(), nil
+}
+
+// rateLimitKey возвращает ключ счетчика запросов клиента за секунду
+func (s *RedisStorage) rateLimitKey(clientID string, second int64) string {
+	return fmt.Sprintf("ratelimit:%s:%d", clientID, second)
+}
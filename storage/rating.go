@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/scripts"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// UpdatePlayerRating сохраняет рейтинг игрока после матча в rating:current:{playerID} без TTL.
+// Если игрок уже снова стоит в очереди, рейтинг обновляется и в его ключе player:{id},
+// и в элементе очереди.
+func (s *RedisStorage) UpdatePlayerRating(ctx context.Context, playerID string, newRating int) error {
+	ctx, span := startSpan(ctx, "UpdatePlayerRating", attribute.String("player_id", playerID))
+	defer span.End()
+
+	if err := s.client.Set(ctx, s.currentRatingKey(playerID), newRating, 0).Err(); err != nil {
+		return fmt.Errorf("failed to save player rating: %w", err)
+	}
+
+	if _, err := s.updatePlayerRating(ctx, s.playerKey(playerID), func(int) int { return newRating }); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).Debug("Player rating updated",
+		zap.String("player_id", playerID),
+		zap.Int("rating", newRating),
+	)
+
+	return nil
+}
+
+// GetPlayerRating возвращает рейтинг игрока, сохраненный после его последнего матча.
+// found == false, если игрок еще не сыграл ни одного матча с отправленным результатом.
+func (s *RedisStorage) GetPlayerRating(ctx context.Context, playerID string) (rating int, found bool, err error) {
+	ctx, span := startSpan(ctx, "GetPlayerRating", attribute.String("player_id", playerID))
+	defer span.End()
+
+	rating, err = s.client.Get(ctx, s.currentRatingKey(playerID)).Int()
+	if errors.Is(err, redis.Nil) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get player rating: %w", err)
+	}
+	return rating, true, nil
+}
+
+// saveMatchResultScript скрипт атомарного закрепления результата матча с рейтингами
+var saveMatchResultScript = redis.NewScript(scripts.SaveMatchResult)
+
+// SaveMatchResultRatings одним Lua-скриптом закрепляет результат матча (SET NX
+// match:result:{matchID}) и сохраняет новые рейтинги игроков (ID игрока -> рейтинг)
+// в rating:current:{playerID}. Возвращает false, если результат уже применен: тогда
+// рейтинги не меняются. Отметка живет столько же, сколько запись матча. Рейтинг игроков,
+// снова стоящих в очереди, затем обновляется и в их записи очереди.
+func (s *RedisStorage) SaveMatchResultRatings(ctx context.Context, matchID string, ratings map[string]int) (bool, error) {
+	ctx, span := startSpan(ctx, "SaveMatchResultRatings", attribute.String("match_id", matchID))
+	defer span.End()
+
+	playerIDs := make([]string, 0, len(ratings))
+	for id := range ratings {
+		playerIDs = append(playerIDs, id)
+	}
+	sort.Strings(playerIDs)
+
+	keys := make([]string, 0, 1+len(playerIDs))
+	args := make([]interface{}, 0, 1+len(playerIDs))
+	keys = append(keys, s.matchResultKey(matchID))
+	args = append(args, int64(matchRecordTTL.Seconds()))
+	for _, id := range playerIDs {
+		keys = append(keys, s.currentRatingKey(id))
+		args = append(args, ratings[id])
+	}
+
+	saved, err := saveMatchResultScript.Run(ctx, s.client, keys, args...).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to save match result: %w", err)
+	}
+	if saved == 0 {
+		return false, nil
+	}
+
+	for _, id := range playerIDs {
+		rating := ratings[id]
+		if _, err := s.updatePlayerRating(ctx, s.playerKey(id), func(int) int { return rating }); err != nil {
+			logging.FromContext(ctx).Warn("Failed to update queued player rating",
+				zap.String("match_id", matchID),
+				zap.String("player_id", id),
+				zap.Error(err),
+			)
+		}
+	}
+
+	return true, nil
+}
+
+// matchResultKey возвращает ключ отметки о примененном результате матча
+func (s *RedisStorage) matchResultKey(matchID string) string {
+	return fmt.Sprintf("match:result:%s", matchID)
+}
+
+// currentRatingKey возвращает ключ текущего рейтинга игрока
+func (s *RedisStorage) currentRatingKey(playerID string) string {
+	return fmt.Sprintf("rating:current:%s", playerID)
+}
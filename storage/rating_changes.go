@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ratingChangesLimit максимальное количество изменений рейтинга, хранимых на игрока
+const ratingChangesLimit = 100
+
+// RecordRatingChange добавляет изменение рейтинга игрока после матча в
+// rating_history:{id} (score — время в мс) и обрезает историю до последних 100 записей
+func (s *RedisStorage) RecordRatingChange(ctx context.Context, playerID string, delta int, timestamp time.Time) error {
+	ctx, span := startSpan(ctx, "RecordRatingChange", attribute.String("player_id", playerID))
+	defer span.End()
+
+	key := s.ratingChangesKey(playerID)
+	pipe := s.client.TxPipeline()
+	pipe.ZAdd(ctx, key, &redis.Z{
+		Score:  float64(timestamp.UnixMilli()),
+		Member: ratingChangeMember(delta, timestamp),
+	})
+	pipe.ZRemRangeByRank(ctx, key, 0, -ratingChangesLimit-1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record rating change: %w", err)
+	}
+	return nil
+}
+
+// GetRecentRatingChanges возвращает до limit последних изменений рейтинга игрока,
+// начиная с самого нового
+func (s *RedisStorage) GetRecentRatingChanges(ctx context.Context, playerID string, limit int) ([]int, error) {
+	ctx, span := startSpan(ctx, "GetRecentRatingChanges", attribute.String("player_id", playerID))
+	defer span.End()
+
+	if limit <= 0 {
+		return []int{}, nil
+	}
+
+	members, err := s.client.ZRevRange(ctx, s.ratingChangesKey(playerID), 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rating changes: %w", err)
+	}
+	return parseRatingChanges(members), nil
+}
+
+// ratingChangeMember возвращает элемент истории изменений "{время в нс}:{delta}";
+// время делает элементы уникальными при одинаковых delta
+func ratingChangeMember(delta int, timestamp time.Time) string {
+	return fmt.Sprintf("%d:%d", timestamp.UnixNano(), delta)
+}
+
+// parseRatingChanges извлекает delta из элементов истории изменений, пропуская поврежденные
+func parseRatingChanges(members []string) []int {
+	deltas := make([]int, 0, len(members))
+	for _, member := range members {
+		_, raw, ok := strings.Cut(member, ":")
+		if !ok {
+			continue
+		}
+		delta, err := strconv.Atoi(raw)
+		if err != nil {
+			continue
+		}
+		deltas = append(deltas, delta)
+	}
+	return deltas
+}
+
+// ratingChangesKey возвращает ключ истории изменений рейтинга игрока
+func (s *RedisStorage) ratingChangesKey(playerID string) string {
+	return fmt.Sprintf("rating_history:%s", playerID)
+}
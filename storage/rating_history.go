@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// ratingHistoryLimit максимальное количество точек истории рейтинга на игрока
+const ratingHistoryLimit = 200
+
+// AppendRatingSnapshot добавляет точку в историю рейтинга игрока (score — время в мс)
+// и обрезает историю до последних 200 записей
+func (s *RedisStorage) AppendRatingSnapshot(ctx context.Context, playerID string, point models.RatingPoint) error {
+	ctx, span := startSpan(ctx, "AppendRatingSnapshot", attribute.String("player_id", playerID))
+	defer span.End()
+
+	pointJSON, err := json.Marshal(point)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rating point: %w", err)
+	}
+
+	key := s.ratingHistoryKey(playerID)
+	pipe := s.client.TxPipeline()
+	pipe.ZAdd(ctx, key, &redis.Z{
+		Score:  float64(point.Timestamp.UnixMilli()),
+		Member: pointJSON,
+	})
+	pipe.ZRemRangeByRank(ctx, key, 0, -ratingHistoryLimit-1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to append rating snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// GetRatingHistory возвращает историю рейтинга игрока в хронологическом порядке
+func (s *RedisStorage) GetRatingHistory(ctx context.Context, playerID string) ([]models.RatingPoint, error) {
+	ctx, span := startSpan(ctx, "GetRatingHistory", attribute.String("player_id", playerID))
+	defer span.End()
+
+	results, err := s.client.ZRange(ctx, s.ratingHistoryKey(playerID), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rating history: %w", err)
+	}
+
+	points := make([]models.RatingPoint, 0, len(results))
+	for _, result := range results {
+		var point models.RatingPoint
+		if err := json.Unmarshal([]byte(result), &point); err != nil {
+			logging.FromContext(ctx).Warn("Failed to unmarshal rating point",
+				zap.String("player_id", playerID),
+				zap.Error(err),
+			)
+			continue
+		}
+		points = append(points, point)
+	}
+
+	return points, nil
+}
+
+// ratingHistoryKey возвращает ключ истории рейтинга игрока
+func (s *RedisStorage) ratingHistoryKey(playerID string) string {
+	return fmt.Sprintf("rating:history:%s", playerID)
+}
@@ -3,85 +3,379 @@ package storage
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"sync/atomic"
 	"time"
 
-	"github.com/go-redis/redis/v8"
+	"chrono-matchmaking/logging"
 	"chrono-matchmaking/models"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
+const (
+	MatchTTL       = 10 * time.Minute // Время жизни матча, выдаваемого игрокам
+	matchRecordTTL = 24 * time.Hour   // Время хранения записи матча по ID для аналитики
+	PlayerTTL      = 30 * time.Minute // Время жизни ключа игрока в очереди
+)
+
+// redisExecutor команды Redis, которыми пользуется хранилище. Ему удовлетворяет
+// *redis.Client; обертки (повторы, тестовые подмены) встраивают интерфейс.
+// Eval, EvalSha, ScriptExists и ScriptLoad нужны для запуска Lua-скриптов через redis.Script.
+type redisExecutor interface {
+	Ping(ctx context.Context) *redis.StatusCmd
+	AddHook(hook redis.Hook)
+	Close() error
+
+	Get(ctx context.Context, key string) *redis.StringCmd
+	MGet(ctx context.Context, keys ...string) *redis.SliceCmd
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd
+	SetArgs(ctx context.Context, key string, value interface{}, a redis.SetArgs) *redis.StatusCmd
+	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd
+	Del(ctx context.Context, keys ...string) *redis.IntCmd
+	Incr(ctx context.Context, key string) *redis.IntCmd
+	Exists(ctx context.Context, keys ...string) *redis.IntCmd
+	Expire(ctx context.Context, key string, expiration time.Duration) *redis.BoolCmd
+	TTL(ctx context.Context, key string) *redis.DurationCmd
+	Scan(ctx context.Context, cursor uint64, match string, count int64) *redis.ScanCmd
+	MemoryUsage(ctx context.Context, key string, samples ...int) *redis.IntCmd
+
+	HGet(ctx context.Context, key, field string) *redis.StringCmd
+	HGetAll(ctx context.Context, key string) *redis.StringStringMapCmd
+	HMGet(ctx context.Context, key string, fields ...string) *redis.SliceCmd
+	HSet(ctx context.Context, key string, values ...interface{}) *redis.IntCmd
+	HSetNX(ctx context.Context, key, field string, value interface{}) *redis.BoolCmd
+	HIncrBy(ctx context.Context, key, field string, incr int64) *redis.IntCmd
+	HIncrByFloat(ctx context.Context, key, field string, incr float64) *redis.FloatCmd
+
+	LIndex(ctx context.Context, key string, index int64) *redis.StringCmd
+	LRange(ctx context.Context, key string, start, stop int64) *redis.StringSliceCmd
+	LRem(ctx context.Context, key string, count int64, value interface{}) *redis.IntCmd
+	SAdd(ctx context.Context, key string, members ...interface{}) *redis.IntCmd
+	SMembers(ctx context.Context, key string) *redis.StringSliceCmd
+	SIsMember(ctx context.Context, key string, member interface{}) *redis.BoolCmd
+
+	ZAdd(ctx context.Context, key string, members ...*redis.Z) *redis.IntCmd
+	ZAddNX(ctx context.Context, key string, members ...*redis.Z) *redis.IntCmd
+	ZRem(ctx context.Context, key string, members ...interface{}) *redis.IntCmd
+	ZCard(ctx context.Context, key string) *redis.IntCmd
+	ZCount(ctx context.Context, key, min, max string) *redis.IntCmd
+	ZScore(ctx context.Context, key, member string) *redis.FloatCmd
+	ZRange(ctx context.Context, key string, start, stop int64) *redis.StringSliceCmd
+	ZRevRange(ctx context.Context, key string, start, stop int64) *redis.StringSliceCmd
+	ZRangeWithScores(ctx context.Context, key string, start, stop int64) *redis.ZSliceCmd
+	ZRangeByScore(ctx context.Context, key string, opt *redis.ZRangeBy) *redis.StringSliceCmd
+	ZRemRangeByScore(ctx context.Context, key, min, max string) *redis.IntCmd
+
+	XAdd(ctx context.Context, a *redis.XAddArgs) *redis.StringCmd
+	XAck(ctx context.Context, stream, group string, ids ...string) *redis.IntCmd
+	XClaim(ctx context.Context, a *redis.XClaimArgs) *redis.XMessageSliceCmd
+	XClaimJustID(ctx context.Context, a *redis.XClaimArgs) *redis.StringSliceCmd
+	XGroupCreateMkStream(ctx context.Context, stream, group, start string) *redis.StatusCmd
+	XPendingExt(ctx context.Context, a *redis.XPendingExtArgs) *redis.XPendingExtCmd
+	XReadGroup(ctx context.Context, a *redis.XReadGroupArgs) *redis.XStreamSliceCmd
+
+	Publish(ctx context.Context, channel string, message interface{}) *redis.IntCmd
+	Subscribe(ctx context.Context, channels ...string) *redis.PubSub
+
+	PoolStats() *redis.PoolStats
+
+	Pipeline() redis.Pipeliner
+	TxPipeline() redis.Pipeliner
+
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd
+	EvalSha(ctx context.Context, sha1 string, keys []string, args ...interface{}) *redis.Cmd
+	ScriptExists(ctx context.Context, hashes ...string) *redis.BoolSliceCmd
+	ScriptLoad(ctx context.Context, script string) *redis.StringCmd
+}
+
 // RedisStorage управляет очередью игроков в Redis
 type RedisStorage struct {
-	client *redis.Client
+	client redisExecutor
 	logger *zap.Logger
+
+	maxQueueSize atomic.Int64 // Максимальный размер очереди региона и режима; 0 — без ограничения
+}
+
+// RedisPoolConfig настройки пула соединений с Redis. Нулевые поля оставляют значения
+// клиента по умолчанию (10 соединений на CPU, бессрочные соединения, закрытие
+// простаивающих через 5 минут).
+type RedisPoolConfig struct {
+	MaxConnections  int           `yaml:"max_connections"`    // Максимум соединений в пуле
+	MinIdleConns    int           `yaml:"min_idle_conns"`     // Сколько простаивающих соединений держать открытыми
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`  // Через сколько соединение пересоздается
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"` // Через сколько простоя соединение закрывается
 }
 
 // NewRedisStorage создает новое хранилище Redis
-func NewRedisStorage(addr string, password string, db int, logger *zap.Logger) (*RedisStorage, error) {
+func NewRedisStorage(addr string, password string, db int, pool RedisPoolConfig, logger *zap.Logger) (Storage, error) {
 	client := redis.NewClient(&redis.Options{
-		Addr:     addr,
-		Password: password,
-		DB:       db,
+		Addr:         addr,
+		Password:     password,
+		DB:           db,
+		PoolSize:     pool.MaxConnections,
+		MinIdleConns: pool.MinIdleConns,
+		MaxConnAge:   pool.ConnMaxLifetime,
+		IdleTimeout:  pool.ConnMaxIdleTime,
 	})
+	s, err := newStorage(client, logger)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// newStorage подключает метрики к клиенту и проверяет соединение
+func newStorage(client redisExecutor, logger *zap.Logger) (*RedisStorage, error) {
+	client.AddHook(metricsHook{})
 
 	ctx := context.Background()
 	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
 	return &RedisStorage{
-		client: client,
+		client: retryingExecutor{client},
 		logger: logger,
 	}, nil
 }
 
+// PoolStats возвращает состояние пула соединений
+func (s *RedisStorage) PoolStats() redis.PoolStats {
+	return *s.client.PoolStats()
+}
+
+// scanKeys обходит ключи по шаблону командой SCAN и передает каждую пачку в fn
+func (s *RedisStorage) scanKeys(ctx context.Context, pattern string, count int64, fn func(keys []string) error) error {
+	var cursor uint64
+	for {
+		keys, next, err := s.client.Scan(ctx, cursor, pattern, count).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				return err
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
 // Close закрывает соединение с Redis
 func (s *RedisStorage) Close() error {
 	return s.client.Close()
 }
 
-// AddPlayerToQueue добавляет игрока в очередь
+// Ping проверяет, что Redis отвечает
+func (s *RedisStorage) Ping(ctx context.Context) error {
+	if err := s.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to ping redis: %w", err)
+	}
+	return nil
+}
+
+// ErrPlayerAlreadyQueued возвращается при повторной постановке игрока в очередь
+var ErrPlayerAlreadyQueued = errors.New("player already queued")
+
+// AddPlayerToQueue добавляет игрока в очередь. Игрок может находиться в очереди
+// только один раз: повторный вызов возвращает ErrPlayerAlreadyQueued и не меняет
+// его позицию.
 func (s *RedisStorage) AddPlayerToQueue(ctx context.Context, player *models.Player) error {
-	key := s.queueKey(player.Region, player.GameMode)
-	
+	ctx, span := startSpan(ctx, "AddPlayerToQueue",
+		attribute.String("player_id", player.ID),
+		attribute.String("region", player.Region),
+		attribute.String("game_mode", player.GameMode),
+	)
+	defer span.End()
+
+	keys := s.playerQueueKeys(player)
+
 	playerJSON, err := json.Marshal(player)
 	if err != nil {
 		return fmt.Errorf("failed to marshal player: %w", err)
 	}
 
-	// Добавляем игрока в отсортированный набор (sorted set) по рейтингу
-	score := float64(player.Rating)
-	err = s.client.ZAdd(ctx, key, &redis.Z{
-		Score:  score,
-		Member: playerJSON,
-	}).Err()
-	
-	if err != nil {
-		return fmt.Errorf("failed to add player to queue: %w", err)
+	for _, mode := range player.QueueGameModes() {
+		room, err := s.queueRoom(ctx, player.Region, mode)
+		if err != nil {
+			return err
+		}
+		if room <= 0 {
+			return ErrQueueFull
+		}
 	}
 
-	// Устанавливаем TTL для игрока (30 минут)
+	// Элемент очереди содержит время входа, поэтому повторный вход дал бы новый
+	// элемент — занимаем ключ игрока (TTL 30 минут) через SETNX
 	playerKey := s.playerKey(player.ID)
-	err = s.client.Set(ctx, playerKey, playerJSON, 30*time.Minute).Err()
+	claimed, err := s.client.SetNX(ctx, playerKey, playerJSON, PlayerTTL).Result()
 	if err != nil {
 		return fmt.Errorf("failed to set player TTL: %w", err)
 	}
+	if !claimed {
+		return ErrPlayerAlreadyQueued
+	}
 
-	s.logger.Info("Player added to queue",
+	// Добавляем игрока в отсортированный набор (sorted set) по рейтингу; игрок с
+	// несколькими режимами поиска — одним и тем же элементом в очередь каждого режима
+	for i, key := range keys {
+		added, err := s.ZAddIfNotExists(ctx, key, float64(player.Rating), playerJSON)
+		if err != nil {
+			for _, k := range keys[:i] {
+				s.client.ZRem(ctx, k, playerJSON)
+			}
+			s.client.Del(ctx, playerKey)
+			return fmt.Errorf("failed to add player to queue: %w", err)
+		}
+		if !added && i == 0 {
+			// Ключ игрока заняли выше; без этого удаления игрок не смог бы встать
+			// в очередь до истечения PlayerTTL
+			s.client.Del(ctx, playerKey)
+			return ErrPlayerAlreadyQueued
+		}
+	}
+
+	for _, mode := range player.QueueGameModes() {
+		s.incrementQueueFlow(ctx, player.Region, mode, "joins", 1)
+	}
+	s.recordQueueJoin(ctx, player.ID, player.JoinedAt)
+
+	logging.FromContext(ctx).Info("Player added to queue",
 		zap.String("player_id", player.ID),
 		zap.String("region", player.Region),
 		zap.String("game_mode", player.GameMode),
 		zap.Int("rating", player.Rating),
+		zap.Bool("premium", player.IsPremium),
 	)
 
 	return nil
 }
 
+// AddPlayersToQueue добавляет в очередь сразу нескольких игроков тремя пайплайнами
+// независимо от их числа: занимает ключи игроков, добавляет их в очереди и обновляет
+// статистику. Возвращает ошибку для каждого игрока в порядке players (nil — добавлен);
+// уже стоящие в очереди игроки получают ErrPlayerAlreadyQueued.
+func (s *RedisStorage) AddPlayersToQueue(ctx context.Context, players []*models.Player) []error {
+	ctx, span := startSpan(ctx, "AddPlayersToQueue")
+	defer span.End()
+
+	errs := make([]error, len(players))
+	playersJSON := make([][]byte, len(players))
+	s.reserveQueueRoom(ctx, players, errs)
+
+	// Занимаем ключи игроков (SETNX), как в AddPlayerToQueue
+	pipe := s.client.Pipeline()
+	claims := make([]*redis.BoolCmd, len(players))
+	for i, player := range players {
+		if errs[i] != nil {
+			continue
+		}
+		playerJSON, err := json.Marshal(player)
+		if err != nil {
+			errs[i] = fmt.Errorf("failed to marshal player: %w", err)
+			continue
+		}
+		playersJSON[i] = playerJSON
+		claims[i] = pipe.SetNX(ctx, s.playerKey(player.ID), playerJSON, PlayerTTL)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		for i := range players {
+			if errs[i] == nil && claims[i].Err() != nil {
+				errs[i] = fmt.Errorf("failed to set player TTL: %w", claims[i].Err())
+			}
+		}
+	}
+
+	pipe = s.client.Pipeline()
+	adds := make([]*redis.IntCmd, len(players))
+	for i, player := range players {
+		if errs[i] != nil {
+			continue
+		}
+		if !claims[i].Val() {
+			errs[i] = ErrPlayerAlreadyQueued
+			continue
+		}
+		keys := s.playerQueueKeys(player)
+		adds[i] = pipe.ZAddNX(ctx, keys[0], &redis.Z{
+			Score:  float64(player.Rating),
+			Member: playersJSON[i],
+		})
+		for _, key := range keys[1:] {
+			pipe.ZAddNX(ctx, key, &redis.Z{Score: float64(player.Rating), Member: playersJSON[i]})
+		}
+	}
+	pipe.Exec(ctx) // Ошибки разбираются по каждой команде
+
+	pipe = s.client.Pipeline()
+	var added int
+	for i, player := range players {
+		if adds[i] == nil {
+			continue
+		}
+		if err := adds[i].Err(); err != nil {
+			pipe.Del(ctx, s.playerKey(player.ID))
+			errs[i] = fmt.Errorf("failed to add player to queue: %w", err)
+			continue
+		}
+		if adds[i].Val() == 0 {
+			errs[i] = ErrPlayerAlreadyQueued
+			continue
+		}
+
+		pipe.HIncrBy(ctx, s.queueFlowKey(player.Region, player.GameMode), "joins", 1)
+		historyKey := s.queueHistoryKey(player.ID)
+		ms := player.JoinedAt.UnixMilli()
+		pipe.ZAdd(ctx, historyKey, &redis.Z{Score: float64(ms), Member: ms})
+		pipe.ZRemRangeByRank(ctx, historyKey, 0, -queueHistoryLimit-1)
+		pipe.Expire(ctx, historyKey, matchRecordTTL)
+		added++
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		// Счетчики и история нужны только для статистики
+		logging.FromContext(ctx).Warn("Failed to update queue stats for batch join", zap.Error(err))
+	}
+
+	logging.FromContext(ctx).Info("Players added to queue in batch",
+		zap.Int("requested", len(players)),
+		zap.Int("added", added),
+	)
+
+	return errs
+}
+
+// ZAddIfNotExists добавляет элемент в sorted set, только если его там еще нет (ZADD NX).
+// Возвращает true, если элемент был добавлен.
+func (s *RedisStorage) ZAddIfNotExists(ctx context.Context, key string, score float64, member interface{}) (bool, error) {
+	ctx, span := startSpan(ctx, "ZAddIfNotExists")
+	defer span.End()
+
+	added, err := s.client.ZAddNX(ctx, key, &redis.Z{
+		Score:  score,
+		Member: member,
+	}).Result()
+	if err != nil {
+		return false, err
+	}
+	return added == 1, nil
+}
+
 // RemovePlayerFromQueue удаляет игрока из очереди
 func (s *RedisStorage) RemovePlayerFromQueue(ctx context.Context, playerID string) error {
+	ctx, span := startSpan(ctx, "RemovePlayerFromQueue", attribute.String("player_id", playerID))
+	defer span.End()
+
 	playerKey := s.playerKey(playerID)
-	
+
 	// Получаем данные игрока
 	playerJSON, err := s.client.Get(ctx, playerKey).Result()
 	if err == redis.Nil {
@@ -96,44 +390,97 @@ func (s *RedisStorage) RemovePlayerFromQueue(ctx context.Context, playerID strin
 		return fmt.Errorf("failed to unmarshal player: %w", err)
 	}
 
-	// Удаляем из очереди
-	key := s.queueKey(player.Region, player.GameMode)
-	err = s.client.ZRem(ctx, key, playerJSON).Err()
-	if err != nil {
+	// Удаляем из очередей всех режимов и ключ игрока одной транзакцией, чтобы игрок
+	// с несколькими режимами поиска не остался в части очередей
+	pipe := s.client.TxPipeline()
+	for _, key := range s.playerQueueKeys(&player) {
+		pipe.ZRem(ctx, key, playerJSON)
+	}
+	pipe.Del(ctx, playerKey)
+	if _, err := pipe.Exec(ctx); err != nil {
 		return fmt.Errorf("failed to remove player from queue: %w", err)
 	}
 
-	// Удаляем ключ игрока
-	err = s.client.Del(ctx, playerKey).Err()
-	if err != nil {
-		return fmt.Errorf("failed to delete player key: %w", err)
+	for _, mode := range player.QueueGameModes() {
+		s.incrementQueueFlow(ctx, player.Region, mode, "leaves", 1)
 	}
 
-	s.logger.Info("Player removed from queue",
+	logging.FromContext(ctx).Info("Player removed from queue",
 		zap.String("player_id", playerID),
 	)
 
 	return nil
 }
 
-// GetPlayersInRange возвращает игроков в диапазоне рейтинга
+// GetPlayersInRange возвращает игроков в диапазоне рейтинга: сначала из приоритетной
+// очереди, затем из обычной, всего не больше limit
 func (s *RedisStorage) GetPlayersInRange(ctx context.Context, region, gameMode string, minRating, maxRating int, limit int64) ([]*models.Player, error) {
-	key := s.queueKey(region, gameMode)
-	
+	ctx, span := startSpan(ctx, "GetPlayersInRange",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
 	minScore := fmt.Sprintf("%d", minRating)
 	maxScore := fmt.Sprintf("%d", maxRating)
 
-	// Получаем игроков в диапазоне рейтинга
-	results, err := s.client.ZRangeByScore(ctx, key, &redis.ZRangeBy{
-		Min:   minScore,
-		Max:   maxScore,
-		Count: limit,
-	}).Result()
-	
-	if err != nil {
-		return nil, fmt.Errorf("failed to get players in range: %w", err)
+	players := make([]*models.Player, 0)
+	for _, key := range s.queueKeys(region, gameMode) {
+		var remaining int64 // 0 — без ограничения
+		if limit > 0 {
+			remaining = limit - int64(len(players))
+			if remaining <= 0 {
+				break
+			}
+		}
+
+		// Получаем игроков в диапазоне рейтинга
+		results, err := s.client.ZRangeByScore(ctx, key, &redis.ZRangeBy{
+			Min:   minScore,
+			Max:   maxScore,
+			Count: remaining,
+		}).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get players in range: %w", err)
+		}
+
+		players = append(players, s.unmarshalQueueMembers(results, gameMode)...)
+	}
+
+	return players, nil
+}
+
+// GetQueuePlayers возвращает всех игроков обеих очередей, отсортированных по рейтингу
+func (s *RedisStorage) GetQueuePlayers(ctx context.Context, region, gameMode string) ([]*models.Player, error) {
+	ctx, span := startSpan(ctx, "GetQueuePlayers",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	players := make([]*models.Player, 0)
+	for _, key := range s.queueKeys(region, gameMode) {
+		results, err := s.client.ZRangeByScore(ctx, key, &redis.ZRangeBy{
+			Min: "-inf",
+			Max: "+inf",
+		}).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get queue players: %w", err)
+		}
+		players = append(players, s.unmarshalQueueMembers(results, gameMode)...)
 	}
 
+	sort.SliceStable(players, func(i, j int) bool {
+		return players[i].Rating < players[j].Rating
+	})
+
+	return players, nil
+}
+
+// unmarshalQueueMembers разбирает элементы очереди, пропуская нечитаемые. Игрокам,
+// стоящим в нескольких очередях, в GameMode записывается режим прочитанной очереди
+// gameMode (пустой — оставить режим входа).
+func (s *RedisStorage) unmarshalQueueMembers(results []string, gameMode string) []*models.Player {
 	players := make([]*models.Player, 0, len(results))
 	for _, result := range results {
 		var player models.Player
@@ -144,16 +491,21 @@ func (s *RedisStorage) GetPlayersInRange(ctx context.Context, region, gameMode s
 			)
 			continue
 		}
+		if gameMode != "" && player.IsMultiQueue() {
+			player.GameMode = gameMode
+		}
 		players = append(players, &player)
 	}
-
-	return players, nil
+	return players
 }
 
 // GetPlayerByID возвращает игрока по ID
 func (s *RedisStorage) GetPlayerByID(ctx context.Context, playerID string) (*models.Player, error) {
+	ctx, span := startSpan(ctx, "GetPlayerByID", attribute.String("player_id", playerID))
+	defer span.End()
+
 	playerKey := s.playerKey(playerID)
-	
+
 	playerJSON, err := s.client.Get(ctx, playerKey).Result()
 	if err == redis.Nil {
 		return nil, fmt.Errorf("player not found")
@@ -170,10 +522,36 @@ func (s *RedisStorage) GetPlayerByID(ctx context.Context, playerID string) (*mod
 	return &player, nil
 }
 
-// GetQueueSize возвращает размер очереди
+// GetQueueSize возвращает количество игроков в обеих очередях
 func (s *RedisStorage) GetQueueSize(ctx context.Context, region, gameMode string) (int64, error) {
-	key := s.queueKey(region, gameMode)
-	return s.client.ZCard(ctx, key).Result()
+	ctx, span := startSpan(ctx, "GetQueueSize",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	standard, premium, err := s.GetQueueSizes(ctx, region, gameMode)
+	return standard + premium, err
+}
+
+// GetQueueSizes возвращает размеры обычной и приоритетной очередей. Очереди
+// подозрительных и калибрующихся игроков учитываются в обычной.
+func (s *RedisStorage) GetQueueSizes(ctx context.Context, region, gameMode string) (standard, premium int64, err error) {
+	ctx, span := startSpan(ctx, "GetQueueSizes",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	pipe := s.client.Pipeline()
+	standardCmd := pipe.ZCard(ctx, s.queueKey(region, gameMode))
+	premiumCmd := pipe.ZCard(ctx, s.premiumQueueKey(region, gameMode))
+	suspectCmd := pipe.ZCard(ctx, s.suspectQueueKey(region, gameMode))
+	calibrationCmd := pipe.ZCard(ctx, s.calibrationQueueKey(region, gameMode))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, 0, fmt.Errorf("failed to get queue size: %w", err)
+	}
+	return standardCmd.Val() + suspectCmd.Val() + calibrationCmd.Val(), premiumCmd.Val(), nil
 }
 
 // queueKey возвращает ключ для очереди
@@ -181,6 +559,60 @@ func (s *RedisStorage) queueKey(region, gameMode string) string {
 	return fmt.Sprintf("queue:%s:%s", region, gameMode)
 }
 
+// premiumQueueKey возвращает ключ приоритетной очереди подписчиков
+func (s *RedisStorage) premiumQueueKey(region, gameMode string) string {
+	return fmt.Sprintf("queue:premium:%s:%s", region, gameMode)
+}
+
+// suspectQueueKey возвращает ключ очереди игроков, подозреваемых в смурфинге
+func (s *RedisStorage) suspectQueueKey(region, gameMode string) string {
+	return fmt.Sprintf("queue:suspect:%s:%s", region, gameMode)
+}
+
+// calibrationQueueKey возвращает ключ очереди игроков, проходящих калибровку
+func (s *RedisStorage) calibrationQueueKey(region, gameMode string) string {
+	return fmt.Sprintf("queue:calibration:%s:%s", region, gameMode)
+}
+
+// queueKeys возвращает ключи очередей в порядке обработки. Небольшие очереди
+// подозрительных и калибрующихся игроков идут перед обычной, чтобы не отсекаться
+// лимитом выборки.
+func (s *RedisStorage) queueKeys(region, gameMode string) []string {
+	return []string{
+		s.premiumQueueKey(region, gameMode),
+		s.suspectQueueKey(region, gameMode),
+		s.calibrationQueueKey(region, gameMode),
+		s.queueKey(region, gameMode),
+	}
+}
+
+// playerQueueKey возвращает ключ очереди, в которой ждет игрок
+func (s *RedisStorage) playerQueueKey(player *models.Player) string {
+	if player.IsSuspicious {
+		return s.suspectQueueKey(player.Region, player.GameMode)
+	}
+	if player.IsCalibrating {
+		return s.calibrationQueueKey(player.Region, player.GameMode)
+	}
+	if player.IsPremium {
+		return s.premiumQueueKey(player.Region, player.GameMode)
+	}
+	return s.queueKey(player.Region, player.GameMode)
+}
+
+// playerQueueKeys возвращает ключи всех очередей игрока: для игрока с несколькими
+// режимами поиска — очередь каждого режима, начиная с режима входа
+func (s *RedisStorage) playerQueueKeys(player *models.Player) []string {
+	modes := player.QueueGameModes()
+	keys := make([]string, 0, len(modes))
+	for _, mode := range modes {
+		p := *player
+		p.GameMode = mode
+		keys = append(keys, s.playerQueueKey(&p))
+	}
+	return keys
+}
+
 // playerKey возвращает ключ для игрока
 func (s *RedisStorage) playerKey(playerID string) string {
 	return fmt.Sprintf("player:%s", playerID)
@@ -188,26 +620,37 @@ func (s *RedisStorage) playerKey(playerID string) string {
 
 // SaveMatch сохраняет матч для всех игроков
 func (s *RedisStorage) SaveMatch(ctx context.Context, match *models.Match) error {
+	ctx, span := startSpan(ctx, "SaveMatch", attribute.String("match_id", match.MatchID))
+	defer span.End()
+
 	matchJSON, err := json.Marshal(match)
 	if err != nil {
 		return fmt.Errorf("failed to marshal match: %w", err)
 	}
 
-	// Сохраняем матч для каждого игрока
+	// Матч игроков и запись матча по ID (для аналитики и проверок целостности)
+	// пишутся одной транзакцией: матч видят либо все игроки, либо никто
+	expiresAt := matchExpiresAt(match, match.CreatedAt)
+	pipe := s.client.TxPipeline()
 	for _, player := range match.Players {
-		matchKey := s.matchKey(player.ID)
-		err = s.client.Set(ctx, matchKey, matchJSON, 10*time.Minute).Err()
-		if err != nil {
-			s.logger.Warn("Failed to save match for player",
-				zap.String("player_id", player.ID),
-				zap.Error(err),
-			)
-			// Продолжаем для остальных игроков
-			continue
-		}
+		pipe.Set(ctx, s.matchKey(player.ID), matchJSON, matchKeyTTL(expiresAt, time.Now()))
+	}
+	pipe.Set(ctx, s.matchByIDKey(match.MatchID), matchJSON, matchRecordTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save match: %w", err)
+	}
+
+	s.recordMatchHistory(ctx, match)
+
+	// Регистрируем матч в потоке истечения для WatchForMatchExpiry
+	if err := s.appendMatchExpiry(ctx, match.MatchID, expiresAt); err != nil {
+		logging.FromContext(ctx).Warn("Failed to append match to expiry stream",
+			zap.String("match_id", match.MatchID),
+			zap.Error(err),
+		)
 	}
 
-	s.logger.Info("Match saved for all players",
+	logging.FromContext(ctx).Info("Match saved for all players",
 		zap.String("match_id", match.MatchID),
 		zap.Int("players_count", len(match.Players)),
 	)
@@ -217,11 +660,14 @@ func (s *RedisStorage) SaveMatch(ctx context.Context, match *models.Match) error
 
 // GetMatchByPlayerID возвращает матч для игрока
 func (s *RedisStorage) GetMatchByPlayerID(ctx context.Context, playerID string) (*models.Match, error) {
+	ctx, span := startSpan(ctx, "GetMatchByPlayerID", attribute.String("player_id", playerID))
+	defer span.End()
+
 	matchKey := s.matchKey(playerID)
-	
+
 	matchJSON, err := s.client.Get(ctx, matchKey).Result()
 	if err == redis.Nil {
-		return nil, fmt.Errorf("match not found")
+		return nil, ErrMatchNotFound
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get match: %w", err)
@@ -242,6 +688,9 @@ func (s *RedisStorage) matchKey(playerID string) string {
 
 // RemoveMatch удаляет матч для игрока (опционально, для очистки)
 func (s *RedisStorage) RemoveMatch(ctx context.Context, playerID string) error {
+	ctx, span := startSpan(ctx, "RemoveMatch", attribute.String("player_id", playerID))
+	defer span.End()
+
 	matchKey := s.matchKey(playerID)
 	err := s.client.Del(ctx, matchKey).Err()
 	if err != nil {
@@ -249,4 +698,3 @@ func (s *RedisStorage) RemoveMatch(ctx context.Context, playerID string) error {
 	}
 	return nil
 }
-
@@ -0,0 +1,365 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"chrono-matchmaking/models"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// Тесты RedisStorage работают с настоящим Redis по адресу из REDIS_TEST_ADDR.
+// База очищается перед каждым тестом, поэтому адрес задается отдельно от REDIS_ADDR;
+// без него тесты пропускаются.
+const redisTestDB = 15
+
+// newTestRedisStorage возвращает хранилище поверх очищенной тестовой базы Redis
+// и клиент этой базы для проверки ключей в обход хранилища
+func newTestRedisStorage(t *testing.T) (*RedisStorage, *redis.Client) {
+	t.Helper()
+	addr := os.Getenv("REDIS_TEST_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_TEST_ADDR is not set")
+	}
+
+	ctx := context.Background()
+	raw := redis.NewClient(&redis.Options{Addr: addr, DB: redisTestDB})
+	if err := raw.Ping(ctx).Err(); err != nil {
+		raw.Close()
+		t.Skipf("redis at %s is unavailable: %v", addr, err)
+	}
+	if err := raw.FlushDB(ctx).Err(); err != nil {
+		t.Fatalf("FlushDB: %v", err)
+	}
+	t.Cleanup(func() { raw.Close() })
+
+	s, err := newStorage(redis.NewClient(&redis.Options{Addr: addr, DB: redisTestDB}), zap.NewNop())
+	if err != nil {
+		t.Fatalf("newStorage: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s, raw
+}
+
+func TestRedisQueueFull(t *testing.T) {
+	const queueCap = 500
+	ctx := context.Background()
+	s, raw := newTestRedisStorage(t)
+	s.SetMaxQueueSize(queueCap)
+
+	players := make([]*models.Player, queueCap)
+	for i := range players {
+		players[i] = queuedPlayer(fmt.Sprintf("player-%d", i), 1000+i)
+	}
+	for i, err := range s.AddPlayersToQueue(ctx, players) {
+		if err != nil {
+			t.Fatalf("AddPlayersToQueue(%s): %v", players[i].ID, err)
+		}
+	}
+	if size, _ := s.GetQueueSize(ctx, "EU", "3v3"); size != queueCap {
+		t.Fatalf("GetQueueSize = %d, want %d", size, queueCap)
+	}
+
+	// Одновременные входы в заполненную очередь отклоняются и не оставляют ключей игроков
+	const rejected = 50
+	var wg sync.WaitGroup
+	errs := make([]error, rejected)
+	for i := 0; i < rejected; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = s.AddPlayerToQueue(ctx, queuedPlayer(fmt.Sprintf("late-%d", i), 1500))
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if !errors.Is(err, ErrQueueFull) {
+			t.Errorf("AddPlayerToQueue(late-%d) = %v, want ErrQueueFull", i, err)
+		}
+		if exists, _ := raw.Exists(ctx, s.playerKey(fmt.Sprintf("late-%d", i))).Result(); exists != 0 {
+			t.Errorf("rejected player late-%d has a player key", i)
+		}
+	}
+	if size, _ := s.GetQueueSize(ctx, "EU", "3v3"); size != queueCap {
+		t.Errorf("GetQueueSize after rejections = %d, want %d", size, queueCap)
+	}
+
+	batch := s.AddPlayersToQueue(ctx, []*models.Player{queuedPlayer("batch", 1500)})
+	if !errors.Is(batch[0], ErrQueueFull) {
+		t.Errorf("AddPlayersToQueue into a full queue = %v, want ErrQueueFull", batch[0])
+	}
+
+	// Ограничение действует на очередь региона и режима, а не на все очереди сразу
+	other := queuedPlayer("other-mode", 1500)
+	other.GameMode = "1v1"
+	if err := s.AddPlayerToQueue(ctx, other); err != nil {
+		t.Errorf("AddPlayerToQueue into another mode: %v", err)
+	}
+
+	// Освободившееся место снова доступно
+	if err := s.RemovePlayerFromQueue(ctx, "player-0"); err != nil {
+		t.Fatalf("RemovePlayerFromQueue: %v", err)
+	}
+	if err := s.AddPlayerToQueue(ctx, queuedPlayer("late-0", 1500)); err != nil {
+		t.Errorf("AddPlayerToQueue after a player left: %v", err)
+	}
+}
+
+func TestRedisZAddIfNotExists(t *testing.T) {
+	ctx := context.Background()
+	s, raw := newTestRedisStorage(t)
+
+	if added, err := s.ZAddIfNotExists(ctx, "zset", 1, "member"); err != nil || !added {
+		t.Fatalf("first ZAddIfNotExists = %v, %v; want true", added, err)
+	}
+	if added, err := s.ZAddIfNotExists(ctx, "zset", 2, "member"); err != nil || added {
+		t.Fatalf("second ZAddIfNotExists = %v, %v; want false", added, err)
+	}
+	if score, _ := raw.ZScore(ctx, "zset", "member").Result(); score != 1 {
+		t.Errorf("score = %v, want the original 1", score)
+	}
+}
+
+func TestRedisAddPlayerToQueueIdempotent(t *testing.T) {
+	ctx := context.Background()
+	s, raw := newTestRedisStorage(t)
+
+	player := queuedPlayer("a", 1500)
+	if err := s.AddPlayerToQueue(ctx, player); err != nil {
+		t.Fatalf("AddPlayerToQueue: %v", err)
+	}
+	rejoin := *player
+	rejoin.Rating = 1800
+	if err := s.AddPlayerToQueue(ctx, &rejoin); !errors.Is(err, ErrPlayerAlreadyQueued) {
+		t.Fatalf("second AddPlayerToQueue = %v, want ErrPlayerAlreadyQueued", err)
+	}
+
+	members, _ := raw.ZRangeWithScores(ctx, s.queueKey("EU", "3v3"), 0, -1).Result()
+	if len(members) != 1 || members[0].Score != 1500 {
+		t.Errorf("queue = %v, want one member with the original score 1500", members)
+	}
+	if stored, _ := s.GetPlayerByID(ctx, "a"); stored == nil || stored.Rating != 1500 {
+		t.Errorf("stored player = %+v, want the original rating 1500", stored)
+	}
+}
+
+// TestRedisAddPlayerToQueueReleasesPlayerKey проверяет, что вход, отклоненный из-за
+// элемента очереди без ключа игрока, не оставляет занятым ключ игрока
+func TestRedisAddPlayerToQueueReleasesPlayerKey(t *testing.T) {
+	ctx := context.Background()
+	s, raw := newTestRedisStorage(t)
+
+	player := queuedPlayer("a", 1500)
+	if err := s.AddPlayerToQueue(ctx, player); err != nil {
+		t.Fatalf("AddPlayerToQueue: %v", err)
+	}
+	raw.Del(ctx, s.playerKey("a"))
+
+	if err := s.AddPlayerToQueue(ctx, player); !errors.Is(err, ErrPlayerAlreadyQueued) {
+		t.Fatalf("AddPlayerToQueue with a queued member = %v, want ErrPlayerAlreadyQueued", err)
+	}
+	if exists, _ := raw.Exists(ctx, s.playerKey("a")).Result(); exists != 0 {
+		t.Error("player key claimed by the rejected join was not released")
+	}
+	if size, _ := raw.ZCard(ctx, s.queueKey("EU", "3v3")).Result(); size != 1 {
+		t.Errorf("queue size = %d, want 1", size)
+	}
+}
+
+// cutConn обрывает соединение посреди записи, пока включен флаг cut: отправляет
+// только первую половину данных, как процесс, упавший во время отправки команд
+type cutConn struct {
+	net.Conn
+	cut *atomic.Bool
+}
+
+func (c cutConn) Write(b []byte) (int, error) {
+	if !c.cut.Load() {
+		return c.Conn.Write(b)
+	}
+	n, _ := c.Conn.Write(b[:len(b)/2])
+	c.Conn.Close()
+	return n, errors.New("connection cut mid-write")
+}
+
+// TestSaveMatchAllOrNothing проверяет, что обрыв соединения посреди SaveMatch
+// не оставляет матч видимым части игроков
+func TestSaveMatchAllOrNothing(t *testing.T) {
+	ctx := context.Background()
+	_, raw := newTestRedisStorage(t)
+
+	var cut atomic.Bool
+	client := redis.NewClient(&redis.Options{
+		Addr: raw.Options().Addr,
+		DB:   redisTestDB,
+		Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := (&net.Dialer{}).DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			return cutConn{Conn: conn, cut: &cut}, nil
+		},
+	})
+	s, err := newStorage(client, zap.NewNop())
+	if err != nil {
+		t.Fatalf("newStorage: %v", err)
+	}
+	defer s.Close()
+
+	match := testMatch("match", "a", "b", "c", "d")
+	keys := []string{s.matchByIDKey("match")}
+	for _, p := range match.Players {
+		keys = append(keys, s.matchKey(p.ID))
+	}
+
+	cut.Store(true)
+	if err := s.SaveMatch(ctx, match); err == nil {
+		t.Fatal("SaveMatch succeeded over a cut connection")
+	}
+	cut.Store(false)
+
+	if exists, _ := raw.Exists(ctx, keys...).Result(); exists != 0 {
+		t.Fatalf("%d of %d match keys visible after a failed SaveMatch", exists, len(keys))
+	}
+	if _, err := s.GetMatchByID(ctx, "match"); !errors.Is(err, ErrMatchNotFound) {
+		t.Errorf("GetMatchByID after a failed SaveMatch = %v, want ErrMatchNotFound", err)
+	}
+
+	if err := s.SaveMatch(ctx, match); err != nil {
+		t.Fatalf("SaveMatch: %v", err)
+	}
+	if exists, _ := raw.Exists(ctx, keys...).Result(); exists != int64(len(keys)) {
+		t.Errorf("%d of %d match keys visible after SaveMatch", exists, len(keys))
+	}
+}
+
+// TestRedisSaveMatchResultRatings проверяет, что результат матча закрепляется вместе с
+// рейтингами, повторный результат их не меняет, а рейтинг игрока в очереди обновляется
+func TestRedisSaveMatchResultRatings(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestRedisStorage(t)
+
+	if err := s.AddPlayerToQueue(ctx, queuedPlayer("a", 1500)); err != nil {
+		t.Fatalf("AddPlayerToQueue: %v", err)
+	}
+	saved, err := s.SaveMatchResultRatings(ctx, "match", map[string]int{"a": 1516, "b": 1484})
+	if err != nil || !saved {
+		t.Fatalf("SaveMatchResultRatings = %v, %v; want true", saved, err)
+	}
+	if saved, err := s.SaveMatchResultRatings(ctx, "match", map[string]int{"a": 1532, "b": 1468}); err != nil || saved {
+		t.Fatalf("repeated SaveMatchResultRatings = %v, %v; want false", saved, err)
+	}
+
+	for id, want := range map[string]int{"a": 1516, "b": 1484} {
+		if rating, found, _ := s.GetPlayerRating(ctx, id); !found || rating != want {
+			t.Errorf("GetPlayerRating(%s) = %d, %v; want %d", id, rating, found, want)
+		}
+	}
+	if queued, _ := s.GetPlayerByID(ctx, "a"); queued == nil || queued.Rating != 1516 {
+		t.Errorf("queued player rating = %v, want 1516", queued)
+	}
+}
+
+func TestRedisRecordMatchOutcome(t *testing.T) {
+	ctx := context.Background()
+	s, client := newTestRedisStorage(t)
+
+	// Игроку без профиля серии не записываются
+	if err := s.RecordMatchOutcome(ctx, "guest", true); err != nil {
+		t.Fatalf("RecordMatchOutcome(guest): %v", err)
+	}
+	if n, _ := client.Exists(ctx, s.profileKey("guest")).Result(); n != 0 {
+		t.Error("RecordMatchOutcome created a profile for an unregistered player")
+	}
+
+	if _, err := s.CreateProfile(ctx, &models.PlayerProfile{PlayerID: "p"}); err != nil {
+		t.Fatalf("CreateProfile: %v", err)
+	}
+	for _, won := range []bool{false, true, true} {
+		if err := s.RecordMatchOutcome(ctx, "p", won); err != nil {
+			t.Fatalf("RecordMatchOutcome: %v", err)
+		}
+	}
+	profile, err := s.GetProfile(ctx, "p")
+	if err != nil {
+		t.Fatalf("GetProfile: %v", err)
+	}
+	if profile.WinStreak != 2 || profile.LossStreak != 0 {
+		t.Errorf("streaks = %d/%d, want 2/0", profile.WinStreak, profile.LossStreak)
+	}
+
+	if err := s.SetMomentum(ctx, "p", 2, time.Minute); err != nil {
+		t.Fatalf("SetMomentum: %v", err)
+	}
+	if momentum, found, err := s.GetMomentum(ctx, "p"); err != nil || !found || momentum != 2 {
+		t.Errorf("GetMomentum = %d, %v, %v; want 2, true, nil", momentum, found, err)
+	}
+	if ttl, _ := client.TTL(ctx, s.momentumKey("p")).Result(); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("momentum TTL = %v, want up to 1m", ttl)
+	}
+}
+
+func TestRedisCreateMatchAtomic(t *testing.T) {
+	ctx := context.Background()
+	s, client := newTestRedisStorage(t)
+
+	addPlayers(t, s, queuedPlayer("a", 1400), queuedPlayer("b", 1500), queuedPlayer("c", 1600))
+	// Элемент без ключа игрока пропускается
+	if err := client.Del(ctx, s.playerKey("a")).Err(); err != nil {
+		t.Fatalf("Del: %v", err)
+	}
+
+	match, err := s.CreateMatchAtomic(ctx, "EU", "3v3", 2)
+	if err != nil {
+		t.Fatalf("CreateMatchAtomic: %v", err)
+	}
+	ids := map[string]bool{}
+	for _, p := range match.Players {
+		ids[p.ID] = true
+	}
+	if len(ids) != 2 || !ids["b"] || !ids["c"] {
+		t.Errorf("match players = %v, want b and c", ids)
+	}
+	if saved, err := s.GetMatchByPlayerID(ctx, "b"); err != nil || saved.MatchID != match.MatchID {
+		t.Errorf("GetMatchByPlayerID(b) = %v, %v; want %s", saved, err, match.MatchID)
+	}
+	if p, _ := s.GetPlayerByID(ctx, "c"); p != nil {
+		t.Error("matched player c is still queued")
+	}
+
+	if _, err := s.CreateMatchAtomic(ctx, "EU", "3v3", 2); !errors.Is(err, ErrNotEnoughPlayers) {
+		t.Errorf("CreateMatchAtomic on a drained queue = %v, want ErrNotEnoughPlayers", err)
+	}
+}
+
+func TestRedisSwapQueuePositions(t *testing.T) {
+	ctx := context.Background()
+	s, client := newTestRedisStorage(t)
+
+	previous, err := s.SwapQueuePositions(ctx, "EU", "3v3", map[string]int64{"a": 10, "b": 3})
+	if err != nil || len(previous) != 0 {
+		t.Fatalf("first SwapQueuePositions = %v, %v; want empty snapshot", previous, err)
+	}
+
+	previous, err = s.SwapQueuePositions(ctx, "EU", "3v3", map[string]int64{"a": 2})
+	if err != nil {
+		t.Fatalf("SwapQueuePositions: %v", err)
+	}
+	if len(previous) != 2 || previous["a"] != 10 || previous["b"] != 3 {
+		t.Errorf("previous snapshot = %v, want a:10 b:3", previous)
+	}
+	if fields, _ := client.HGetAll(ctx, s.queuePositionsKey("EU", "3v3")).Result(); len(fields) != 1 || fields["a"] != "2" {
+		t.Errorf("stored snapshot = %v, want only a:2", fields)
+	}
+	if ttl, _ := client.TTL(ctx, s.queuePositionsKey("EU", "3v3")).Result(); ttl <= 0 {
+		t.Errorf("snapshot TTL = %v, want positive", ttl)
+	}
+}
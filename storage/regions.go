@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// regionsKey ключ списка активных регионов
+const regionsKey = "config:regions"
+
+// GetRegions возвращает список активных регионов из config:regions.
+// Если список не задан, возвращает nil.
+func (s *RedisStorage) GetRegions(ctx context.Context) ([]string, error) {
+	ctx, span := startSpan(ctx, "GetRegions")
+	defer span.End()
+
+	regionsJSON, err := s.client.Get(ctx, regionsKey).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get regions: %w", err)
+	}
+
+	var regions []string
+	if err := json.Unmarshal([]byte(regionsJSON), &regions); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal regions: %w", err)
+	}
+	return regions, nil
+}
+
+// SetRegions сохраняет список активных регионов в config:regions без срока хранения
+func (s *RedisStorage) SetRegions(ctx context.Context, regions []string) error {
+	ctx, span := startSpan(ctx, "SetRegions")
+	defer span.End()
+
+	regionsJSON, err := json.Marshal(regions)
+	if err != nil {
+		return fmt.Errorf("failed to marshal regions: %w", err)
+	}
+
+	if err := s.client.Set(ctx, regionsKey, regionsJSON, 0).Err(); err != nil {
+		return fmt.Errorf("failed to save regions: %w", err)
+	}
+	return nil
+}
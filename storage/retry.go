@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"chrono-matchmaking/metrics"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	writeRetryAttempts = 3                      // Попыток для ZAdd, ZRem, Set и Del
+	readRetryAttempts  = 2                      // Попыток для чтения: один повтор
+	retryBaseDelay     = 100 * time.Millisecond // Задержка перед первым повтором
+)
+
+// WithRetry вызывает fn до attempts раз, пока она возвращает временную ошибку Redis
+// (обрыв соединения, таймаут, LOADING/TRYAGAIN/CLUSTERDOWN). Перед повтором n ждет
+// base*2^(n-1) со случайным разбросом в половину задержки. Отмена ctx прерывает
+// ожидание сразу и возвращает ctx.Err(). Каждый повтор считается в storage_retry_total.
+func WithRetry(ctx context.Context, op string, attempts int, base time.Duration, fn func() error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil || attempt >= attempts || !isTransientRedisError(err) {
+			return err
+		}
+
+		delay := base << (attempt - 1)
+		delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		metrics.StorageRetryTotal.WithLabelValues(op, strconv.Itoa(attempt+1)).Inc()
+	}
+}
+
+// isTransientRedisError проверяет, имеет ли смысл повторить команду. Ответы Redis
+// об ошибке (кроме временных состояний узла), redis.Nil и отмена контекста не повторяются.
+func isTransientRedisError(err error) bool {
+	switch {
+	case err == nil, err == redis.Nil, errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return true
+	}
+
+	var timeout interface{ Timeout() bool }
+	if errors.As(err, &timeout) {
+		return true
+	}
+
+	msg := err.Error()
+	for _, prefix := range []string{"LOADING ", "TRYAGAIN ", "CLUSTERDOWN ", "ERR max number of clients reached"} {
+		if strings.HasPrefix(msg, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryCmd выполняет команду через WithRetry и возвращает последнюю попытку. Если
+// повторы прервала отмена контекста, ошибка контекста записывается в команду.
+func retryCmd[C redis.Cmder](ctx context.Context, op string, attempts int, run func() C) C {
+	var cmd C
+	err := WithRetry(ctx, op, attempts, retryBaseDelay, func() error {
+		cmd = run()
+		return cmd.Err()
+	})
+	if ctxErr := ctx.Err(); ctxErr != nil && err == ctxErr {
+		cmd.SetErr(err)
+	}
+	return cmd
+}
+
+// retryingExecutor повторяет при временных ошибках одиночные команды хранилища:
+// идемпотентные записи ZAdd, ZRem, Set и Del — до writeRetryAttempts раз, основные
+// чтения — один раз. Команды с условием (SetNX, ZAddNX), инкременты, конвейеры и
+// Lua-скрипты не повторяются: после потерянного ответа повтор мог бы применить их дважды.
+type retryingExecutor struct {
+	redisExecutor
+}
+
+func (e retryingExecutor) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
+	return retryCmd(ctx, "set", writeRetryAttempts, func() *redis.StatusCmd {
+		return e.redisExecutor.Set(ctx, key, value, expiration)
+	})
+}
+
+func (e retryingExecutor) Del(ctx context.Context, keys ...string) *redis.IntCmd {
+	return retryCmd(ctx, "del", writeRetryAttempts, func() *redis.IntCmd {
+		return e.redisExecutor.Del(ctx, keys...)
+	})
+}
+
+func (e retryingExecutor) ZAdd(ctx context.Context, key string, members ...*redis.Z) *redis.IntCmd {
+	return retryCmd(ctx, "zadd", writeRetryAttempts, func() *redis.IntCmd {
+		return e.redisExecutor.ZAdd(ctx, key, members...)
+	})
+}
+
+func (e retryingExecutor) ZRem(ctx context.Context, key string, members ...interface{}) *redis.IntCmd {
+	return retryCmd(ctx, "zrem", writeRetryAttempts, func() *redis.IntCmd {
+		return e.redisExecutor.ZRem(ctx, key, members...)
+	})
+}
+
+func (e retryingExecutor) Get(ctx context.Context, key string) *redis.StringCmd {
+	return retryCmd(ctx, "get", readRetryAttempts, func() *redis.StringCmd {
+		return e.redisExecutor.Get(ctx, key)
+	})
+}
+
+func (e retryingExecutor) MGet(ctx context.Context, keys ...string) *redis.SliceCmd {
+	return retryCmd(ctx, "mget", readRetryAttempts, func() *redis.SliceCmd {
+		return e.redisExecutor.MGet(ctx, keys...)
+	})
+}
+
+func (e retryingExecutor) Exists(ctx context.Context, keys ...string) *redis.IntCmd {
+	return retryCmd(ctx, "exists", readRetryAttempts, func() *redis.IntCmd {
+		return e.redisExecutor.Exists(ctx, keys...)
+	})
+}
+
+func (e retryingExecutor) HGet(ctx context.Context, key, field string) *redis.StringCmd {
+	return retryCmd(ctx, "hget", readRetryAttempts, func() *redis.StringCmd {
+		return e.redisExecutor.HGet(ctx, key, field)
+	})
+}
+
+func (e retryingExecutor) HGetAll(ctx context.Context, key string) *redis.StringStringMapCmd {
+	return retryCmd(ctx, "hgetall", readRetryAttempts, func() *redis.StringStringMapCmd {
+		return e.redisExecutor.HGetAll(ctx, key)
+	})
+}
+
+func (e retryingExecutor) ZCard(ctx context.Context, key string) *redis.IntCmd {
+	return retryCmd(ctx, "zcard", readRetryAttempts, func() *redis.IntCmd {
+		return e.redisExecutor.ZCard(ctx, key)
+	})
+}
+
+func (e retryingExecutor) ZScore(ctx context.Context, key, member string) *redis.FloatCmd {
+	return retryCmd(ctx, "zscore", readRetryAttempts, func() *redis.FloatCmd {
+		return e.redisExecutor.ZScore(ctx, key, member)
+	})
+}
+
+func (e retryingExecutor) ZRange(ctx context.Context, key string, start, stop int64) *redis.StringSliceCmd {
+	return retryCmd(ctx, "zrange", readRetryAttempts, func() *redis.StringSliceCmd {
+		return e.redisExecutor.ZRange(ctx, key, start, stop)
+	})
+}
+
+func (e retryingExecutor) ZRevRange(ctx context.Context, key string, start, stop int64) *redis.StringSliceCmd {
+	return retryCmd(ctx, "zrevrange", readRetryAttempts, func() *redis.StringSliceCmd {
+		return e.redisExecutor.ZRevRange(ctx, key, start, stop)
+	})
+}
+
+func (e retryingExecutor) ZRangeWithScores(ctx context.Context, key string, start, stop int64) *redis.ZSliceCmd {
+	return retryCmd(ctx, "zrange", readRetryAttempts, func() *redis.ZSliceCmd {
+		return e.redisExecutor.ZRangeWithScores(ctx, key, start, stop)
+	})
+}
+
+func (e retryingExecutor) ZRangeByScore(ctx context.Context, key string, opt *redis.ZRangeBy) *redis.StringSliceCmd {
+	return retryCmd(ctx, "zrangebyscore", readRetryAttempts, func() *redis.StringSliceCmd {
+		return e.redisExecutor.ZRangeByScore(ctx, key, opt)
+	})
+}
@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// SaveScheduledMatch сохраняет матч в набор запланированных матчей региона/режима.
+// Игрокам матч станет доступен только после PromoteScheduledMatches.
+func (s *RedisStorage) SaveScheduledMatch(ctx context.Context, region, gameMode string, match *models.Match) error {
+	ctx, span := startSpan(ctx, "SaveScheduledMatch",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+		attribute.String("match_id", match.MatchID),
+	)
+	defer span.End()
+
+	if match.ScheduledStartTime == nil {
+		return fmt.Errorf("match has no scheduled start time")
+	}
+
+	matchJSON, err := json.Marshal(match)
+	if err != nil {
+		return fmt.Errorf("failed to marshal match: %w", err)
+	}
+
+	err = s.client.ZAdd(ctx, s.scheduledMatchesKey(region, gameMode), &redis.Z{
+		Score:  float64(match.ScheduledStartTime.Unix()),
+		Member: matchJSON,
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to save scheduled match: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("Match scheduled",
+		zap.String("match_id", match.MatchID),
+		zap.Time("start_time", *match.ScheduledStartTime),
+	)
+
+	return nil
+}
+
+// PromoteScheduledMatches переносит запланированные матчи, время начала которых наступило,
+// в ключи матчей игроков match:{id}, откуда их выдает FindMatch. Матч удаляется из набора
+// через ZREM до сохранения, поэтому при нескольких репликах его активирует только одна.
+func (s *RedisStorage) PromoteScheduledMatches(ctx context.Context, region, gameMode string, now time.Time) ([]*models.Match, error) {
+	ctx, span := startSpan(ctx, "PromoteScheduledMatches",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	key := s.scheduledMatchesKey(region, gameMode)
+
+	members, err := s.client.ZRangeByScore(ctx, key, &redis.ZRangeBy{
+		Min: "0",
+		Max: strconv.FormatInt(now.Unix(), 10),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduled matches: %w", err)
+	}
+
+	promoted := make([]*models.Match, 0, len(members))
+	for _, member := range members {
+		removed, err := s.client.ZRem(ctx, key, member).Result()
+		if err != nil {
+			return promoted, fmt.Errorf("failed to claim scheduled match: %w", err)
+		}
+		if removed == 0 {
+			continue // Матч уже активировала другая реплика
+		}
+
+		var match models.Match
+		if err := json.Unmarshal([]byte(member), &match); err != nil {
+			logging.FromContext(ctx).Warn("Dropping malformed scheduled match",
+				zap.String("data", member),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		expiresAt := matchExpiresAt(&match, now)
+		pipe := s.client.TxPipeline()
+		for _, p := range match.Players {
+			pipe.Set(ctx, s.matchKey(p.ID), member, matchKeyTTL(expiresAt, now))
+		}
+		pipe.Set(ctx, s.matchByIDKey(match.MatchID), member, matchRecordTTL)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return promoted, fmt.Errorf("failed to activate scheduled match %s: %w", match.MatchID, err)
+		}
+		s.recordMatchHistory(ctx, &match)
+
+		if err := s.appendMatchExpiry(ctx, match.MatchID, expiresAt); err != nil {
+			logging.FromContext(ctx).Warn("Failed to append match to expiry stream",
+				zap.String("match_id", match.MatchID),
+				zap.Error(err),
+			)
+		}
+
+		promoted = append(promoted, &match)
+	}
+
+	return promoted, nil
+}
+
+// scheduledMatchesKey возвращает ключ набора запланированных матчей
+func (s *RedisStorage) scheduledMatchesKey(region, gameMode string) string {
+	return fmt.Sprintf("scheduled:matches:%s:%s", region, gameMode)
+}
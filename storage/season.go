@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/scripts"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// ErrSeasonExists возвращается при повторном начале уже начатого сезона
+var ErrSeasonExists = errors.New("season already exists")
+
+// playerScanCount размер пачки SCAN при обходе ключей игроков
+const playerScanCount = 500
+
+// updatePlayerRatingScript скрипт атомарной замены рейтинга игрока
+var updatePlayerRatingScript = redis.NewScript(scripts.UpdatePlayerRating)
+
+// UpdateAllPlayerRatings обходит все ключи player:* и rating:current:* и заменяет рейтинг
+// каждого игрока на newRating(rating). Вместе с ключом player:* обновляется элемент
+// очереди игрока. Игроки, чьи данные в очереди изменились во время обхода, пропускаются.
+// Возвращает количество игроков, у которых изменился хотя бы один из рейтингов.
+func (s *RedisStorage) UpdateAllPlayerRatings(ctx context.Context, newRating func(rating int) int) (int64, error) {
+	ctx, span := startSpan(ctx, "UpdateAllPlayerRatings")
+	defer span.End()
+
+	updated := make(map[string]bool)
+	seen := make(map[string]bool) // SCAN может вернуть ключ несколько раз
+	var updateErr error
+	err := s.scanKeys(ctx, s.playerKey("*"), playerScanCount, func(keys []string) error {
+		for _, key := range keys {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			ok, err := s.updatePlayerRating(ctx, key, newRating)
+			if err != nil {
+				updateErr = err
+				return err
+			}
+			if ok {
+				updated[strings.TrimPrefix(key, s.playerKey(""))] = true
+			}
+		}
+		return nil
+	})
+	if updateErr != nil {
+		return int64(len(updated)), updateErr
+	}
+	if err != nil {
+		return int64(len(updated)), fmt.Errorf("failed to scan players: %w", err)
+	}
+
+	// Рейтинги после последнего матча: по ним игрок встает в очередь
+	err = s.scanKeys(ctx, s.currentRatingKey("*"), playerScanCount, func(keys []string) error {
+		for _, key := range keys {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			rating, err := s.client.Get(ctx, key).Int()
+			if err == redis.Nil {
+				continue
+			}
+			if err != nil {
+				updateErr = fmt.Errorf("failed to get player rating: %w", err)
+				return updateErr
+			}
+			if newValue := newRating(rating); newValue != rating {
+				if err := s.client.Set(ctx, key, newValue, 0).Err(); err != nil {
+					updateErr = fmt.Errorf("failed to save player rating: %w", err)
+					return updateErr
+				}
+				updated[strings.TrimPrefix(key, s.currentRatingKey(""))] = true
+			}
+		}
+		return nil
+	})
+	if updateErr != nil {
+		return int64(len(updated)), updateErr
+	}
+	if err != nil {
+		return int64(len(updated)), fmt.Errorf("failed to scan player ratings: %w", err)
+	}
+
+	return int64(len(updated)), nil
+}
+
+// updatePlayerRating заменяет рейтинг одного игрока
+func (s *RedisStorage) updatePlayerRating(ctx context.Context, key string, newRating func(rating int) int) (bool, error) {
+	playerJSON, err := s.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return false, nil // Ключ истек между SCAN и GET
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get player: %w", err)
+	}
+
+	var player models.Player
+	if err := json.Unmarshal([]byte(playerJSON), &player); err != nil {
+		logging.FromContext(ctx).Warn("Failed to unmarshal player",
+			zap.String("key", key),
+			zap.Error(err),
+		)
+		return false, nil
+	}
+
+	rating := newRating(player.Rating)
+	if rating == player.Rating {
+		return false, nil
+	}
+	player.Rating = rating
+
+	updatedJSON, err := json.Marshal(&player)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal player: %w", err)
+	}
+
+	keys := append([]string{key}, s.playerQueueKeys(&player)...)
+	result, err := updatePlayerRatingScript.Run(ctx, s.client, keys, playerJSON, updatedJSON, rating).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to update player rating: %w", err)
+	}
+	return result == 1, nil
+}
+
+// CreateSeason сохраняет границу сезона в season:{season_id}.
+// Если сезон уже начат, возвращается ErrSeasonExists.
+func (s *RedisStorage) CreateSeason(ctx context.Context, season *models.Season) error {
+	ctx, span := startSpan(ctx, "CreateSeason")
+	defer span.End()
+
+	seasonJSON, err := json.Marshal(season)
+	if err != nil {
+		return fmt.Errorf("failed to marshal season: %w", err)
+	}
+
+	created, err := s.client.SetNX(ctx, s.seasonKey(season.SeasonID), seasonJSON, 0).Result()
+	if err != nil {
+		return fmt.Errorf("failed to save season: %w", err)
+	}
+	if !created {
+		return ErrSeasonExists
+	}
+	return nil
+}
+
+// UpdateSeason перезаписывает данные уже начатого сезона
+func (s *RedisStorage) UpdateSeason(ctx context.Context, season *models.Season) error {
+	ctx, span := startSpan(ctx, "UpdateSeason")
+	defer span.End()
+
+	seasonJSON, err := json.Marshal(season)
+	if err != nil {
+		return fmt.Errorf("failed to marshal season: %w", err)
+	}
+
+	if err := s.client.Set(ctx, s.seasonKey(season.SeasonID), seasonJSON, 0).Err(); err != nil {
+		return fmt.Errorf("failed to save season: %w", err)
+	}
+	return nil
+}
+
+// seasonKey возвращает ключ границы сезона
+func (s *RedisStorage) seasonKey(seasonID string) string {
+	return fmt.Sprintf("season:%s", seasonID)
+}
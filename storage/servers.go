@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"chrono-matchmaking/logging"
+	"chrono-matchmaking/models"
+	"chrono-matchmaking/scripts"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrServerNotFound возвращается, если игровой сервер не зарегистрирован
+	ErrServerNotFound = errors.New("server not found")
+
+	// ErrNoServerAvailable возвращается, если в регионе нет серверов со свободными слотами
+	ErrNoServerAvailable = errors.New("no game server available")
+)
+
+// serversIndexKey хеш ID сервера -> регион, чтобы снимать сервер с учета по ID
+const serversIndexKey = "servers:index"
+
+var (
+	// selectServerScript скрипт выбора наименее загруженного сервера
+	selectServerScript = redis.NewScript(scripts.SelectServer)
+
+	// releaseServerScript скрипт освобождения слота сервера
+	releaseServerScript = redis.NewScript(scripts.ReleaseServer)
+)
+
+// RegisterServer добавляет игровой сервер в servers:{region}. Повторная регистрация
+// обновляет адрес и вместимость, сохраняя текущую загрузку.
+func (s *RedisStorage) RegisterServer(ctx context.Context, server *models.Server) error {
+	ctx, span := startSpan(ctx, "RegisterServer")
+	defer span.End()
+
+	pipe := s.client.TxPipeline()
+	oldRegion, err := s.client.HGet(ctx, serversIndexKey, server.ServerID).Result()
+	switch {
+	case err == redis.Nil:
+	case err != nil:
+		return fmt.Errorf("failed to get server region: %w", err)
+	case oldRegion == server.Region:
+		// Идущие на сервере матчи продолжают занимать слоты
+		if existing, err := s.getServer(ctx, oldRegion, server.ServerID); err == nil {
+			server.Load = existing.Load
+		}
+	default:
+		// Сервер переехал в другой регион — убираем его из прежнего
+		pipe.HDel(ctx, s.serversKey(oldRegion), server.ServerID)
+	}
+
+	serverJSON, err := json.Marshal(server)
+	if err != nil {
+		return fmt.Errorf("failed to marshal server: %w", err)
+	}
+	pipe.HSet(ctx, s.serversKey(server.Region), server.ServerID, serverJSON)
+	pipe.HSet(ctx, serversIndexKey, server.ServerID, server.Region)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to register server: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("Game server registered",
+		zap.String("server_id", server.ServerID),
+		zap.String("addr", server.Addr),
+		zap.String("region", server.Region),
+		zap.Int("capacity", server.Capacity),
+	)
+
+	return nil
+}
+
+// DeregisterServer снимает игровой сервер с учета
+func (s *RedisStorage) DeregisterServer(ctx context.Context, serverID string) error {
+	ctx, span := startSpan(ctx, "DeregisterServer")
+	defer span.End()
+
+	region, err := s.client.HGet(ctx, serversIndexKey, serverID).Result()
+	if err == redis.Nil {
+		return ErrServerNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get server region: %w", err)
+	}
+
+	pipe := s.client.TxPipeline()
+	pipe.HDel(ctx, s.serversKey(region), serverID)
+	pipe.HDel(ctx, serversIndexKey, serverID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to deregister server: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("Game server deregistered",
+		zap.String("server_id", serverID),
+		zap.String("region", region),
+	)
+
+	return nil
+}
+
+// AcquireLeastLoadedServer атомарно выбирает сервер региона с наименьшей долей занятых
+// слотов и занимает на нем слот. Если свободных серверов нет, возвращается ErrNoServerAvailable.
+func (s *RedisStorage) AcquireLeastLoadedServer(ctx context.Context, region string) (*models.Server, error) {
+	ctx, span := startSpan(ctx, "AcquireLeastLoadedServer", attribute.String("region", region))
+	defer span.End()
+
+	serverJSON, err := selectServerScript.Run(ctx, s.client, []string{s.serversKey(region)}).Text()
+	if err == redis.Nil {
+		return nil, ErrNoServerAvailable
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to select server: %w", err)
+	}
+
+	var server models.Server
+	if err := json.Unmarshal([]byte(serverJSON), &server); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal server: %w", err)
+	}
+	return &server, nil
+}
+
+// ReleaseServer освобождает слот сервера после окончания матча
+func (s *RedisStorage) ReleaseServer(ctx context.Context, region, serverID string) error {
+	ctx, span := startSpan(ctx, "ReleaseServer", attribute.String("region", region))
+	defer span.End()
+
+	if err := releaseServerScript.Run(ctx, s.client, []string{s.serversKey(region)}, serverID).Err(); err != nil {
+		return fmt.Errorf("failed to release server: %w", err)
+	}
+	return nil
+}
+
+// getServer возвращает сервер из хеша региона
+func (s *RedisStorage) getServer(ctx context.Context, region, serverID string) (*models.Server, error) {
+	serverJSON, err := s.client.HGet(ctx, s.serversKey(region), serverID).Result()
+	if err == redis.Nil {
+		return nil, ErrServerNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server: %w", err)
+	}
+
+	var server models.Server
+	if err := json.Unmarshal([]byte(serverJSON), &server); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal server: %w", err)
+	}
+	return &server, nil
+}
+
+// serversKey возвращает ключ хеша серверов региона
+func (s *RedisStorage) serversKey(region string) string {
+	return fmt.Sprintf("servers:%s", region)
+}
@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"chrono-matchmaking/models"
+	"github.com/go-redis/redis/v8"
+)
+
+// snapshotScanCount размер пачки SCAN при обходе ключей очередей
+const snapshotScanCount = 100
+
+// SnapshotAll возвращает содержимое всех очередей (queue:*: обычных, приоритетных и
+// подозрительных) по ключу очереди; игроки каждой очереди упорядочены по рейтингу.
+// Нечитаемые элементы пропускаются.
+func (s *RedisStorage) SnapshotAll(ctx context.Context) (map[string][]*models.Player, error) {
+	ctx, span := startSpan(ctx, "SnapshotAll")
+	defer span.End()
+
+	snapshot := make(map[string][]*models.Player)
+	err := s.scanKeys(ctx, "queue:*", snapshotScanCount, func(keys []string) error {
+		for _, key := range keys {
+			if _, seen := snapshot[key]; seen {
+				continue // SCAN может вернуть ключ несколько раз
+			}
+
+			members, err := s.client.ZRangeByScore(ctx, key, &redis.ZRangeBy{Min: "-inf", Max: "+inf"}).Result()
+			if err != nil {
+				return fmt.Errorf("failed to read queue %s: %w", key, err)
+			}
+			snapshot[key] = s.unmarshalQueueMembers(members, "")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot queues: %w", err)
+	}
+
+	return snapshot, nil
+}
@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"chrono-matchmaking/models"
+)
+
+// Storage хранилище очередей, матчей и связанных с ними данных сервиса.
+// Реализации: RedisStorage и InMemoryStorage
+// (для тестов и локальной разработки без Redis).
+type Storage interface {
+	Close() error
+	Ping(ctx context.Context) error
+
+	// Очередь
+	SetMaxQueueSize(size int)
+	AddPlayerToQueue(ctx context.Context, player *models.Player) error
+	AddPlayersToQueue(ctx context.Context, players []*models.Player) []error
+	RemovePlayerFromQueue(ctx context.Context, playerID string) error
+	GetPlayersInRange(ctx context.Context, region, gameMode string, minRating, maxRating int, limit int64) ([]*models.Player, error)
+	GetQueuePlayers(ctx context.Context, region, gameMode string) ([]*models.Player, error)
+	GetPlayerByID(ctx context.Context, playerID string) (*models.Player, error)
+	GetQueueSize(ctx context.Context, region, gameMode string) (int64, error)
+	GetQueueSizes(ctx context.Context, region, gameMode string) (standard, premium int64, err error)
+	GetPlayerQueuePosition(ctx context.Context, playerID string) (int64, error)
+	SwapQueuePositions(ctx context.Context, region, gameMode string, positions map[string]int64) (map[string]int64, error)
+	RefreshPlayerTTL(ctx context.Context, playerID string, ttl time.Duration) error
+	GetExpiringPlayers(ctx context.Context, threshold time.Duration) (map[string]time.Duration, error)
+	AddPartyToQueue(ctx context.Context, party *models.Party, players []*models.Player) error
+	GetParty(ctx context.Context, partyID string) (*models.Party, error)
+	GetAllPlayers(ctx context.Context) ([]*models.Player, error)
+	SnapshotAll(ctx context.Context) (map[string][]*models.Player, error)
+	SaveIdempotencyKey(ctx context.Context, key, playerID string) (bool, error)
+	GetIdempotencyKey(ctx context.Context, key string) (string, error)
+
+	// Обслуживание очереди
+	GetQueueMemberCount(ctx context.Context, region, gameMode string, staleAfter time.Duration) (active, stale, total int64, err error)
+	RemoveStalePlayers(ctx context.Context, region, gameMode string, staleAfter time.Duration) (int64, error)
+	ScheduleQueueRemoval(ctx context.Context, region, gameMode string, ttl time.Duration) (int64, error)
+	RemoveDrainedPlayers(ctx context.Context, region, gameMode string, now time.Time) (int64, error)
+	ReindexQueueScores(ctx context.Context, region, gameMode string) (int64, error)
+	GetQueueDepthByBracket(ctx context.Context, region, gameMode string, brackets []models.RatingBracket) ([]models.BracketDepth, error)
+	GetQueueFlowCounters(ctx context.Context, region, gameMode string) (joins, leaves int64, err error)
+	TryStartQueuePurge(ctx context.Context, region, gameMode string, cooldown time.Duration) (bool, error)
+	MarkQueuePurged(ctx context.Context, region, gameMode string, cooldown time.Duration) error
+	GetPlayerQueueHistory(ctx context.Context, playerID string, since time.Time) ([]time.Time, error)
+	UpdateAllPlayerRatings(ctx context.Context, newRating func(rating int) int) (int64, error)
+	InspectMemoryUsage(ctx context.Context) (*models.MemoryUsageReport, error)
+
+	// Матчи
+	SaveMatch(ctx context.Context, match *models.Match) error
+	RunAtomicMatchFormation(ctx context.Context, match *models.Match) error
+	GetMatchByPlayerID(ctx context.Context, playerID string) (*models.Match, error)
+	GetMatchByID(ctx context.Context, matchID string) (*models.Match, error)
+	RemoveMatch(ctx context.Context, playerID string) error
+	UpdateMatch(ctx context.Context, match *models.Match) error
+	UpdateMatchMetadata(ctx context.Context, matchID string, metadata map[string]interface{}) (*models.Match, error)
+	ClaimBackfillPlayers(ctx context.Context, match *models.Match, newPlayers []models.Player) error
+	AppendMatchHistory(ctx context.Context, playerID string, match *models.Match) error
+	GetMatchHistory(ctx context.Context, playerID string, limit int) ([]*models.Match, error)
+	SaveScheduledMatch(ctx context.Context, region, gameMode string, match *models.Match) error
+	PromoteScheduledMatches(ctx context.Context, region, gameMode string, now time.Time) ([]*models.Match, error)
+	WatchForMatchExpiry(ctx context.Context, handler MatchExpiryHandler) error
+	ScanExpiredMatches(ctx context.Context) ([]*models.Match, error)
+
+	// Группы, матч которых не удалось сохранить
+	AddToDLQ(ctx context.Context, region, gameMode string, group []*models.Player, reason string) error
+	GetDLQ(ctx context.Context) ([]*models.DLQEntry, error)
+	GetDLQEntry(ctx context.Context, index int64) (*models.DLQEntry, error)
+	RemoveDLQEntry(ctx context.Context, index int64, entryID string) error
+
+	// Приватные матчи по приглашению
+	CreateInvite(ctx context.Context, invite *models.InviteMatch) error
+	GetInvite(ctx context.Context, code string) (*models.InviteMatch, error)
+	JoinInvite(ctx context.Context, invite *models.InviteMatch, player *models.Player) (int, []models.Player, error)
+
+	// Матчи, ожидающие подтверждения
+	CreatePendingMatch(ctx context.Context, pending *models.PendingMatch) error
+	GetPendingMatch(ctx context.Context, pendingID string) (*models.PendingMatch, error)
+	GetPlayerPendingMatch(ctx context.Context, playerID string) (*models.PendingMatch, error)
+	AcceptPendingMatch(ctx context.Context, pending *models.PendingMatch, playerID string) (bool, error)
+	GetAcceptedPlayers(ctx context.Context, pendingID string) ([]string, error)
+	ClaimPendingMatch(ctx context.Context, pending *models.PendingMatch) (bool, error)
+	GetExpiredPendingMatches(ctx context.Context, region, gameMode string, now time.Time) ([]*models.PendingMatch, error)
+	SetQueueCooldown(ctx context.Context, playerID string, duration time.Duration) error
+	GetQueueCooldown(ctx context.Context, playerID string) (time.Duration, error)
+
+	// Игроки
+	BanPlayer(ctx context.Context, playerID string, duration time.Duration) error
+	IsBanned(ctx context.Context, playerID string) (bool, error)
+	GetBanExpiry(ctx context.Context, playerID string) (time.Time, error)
+	IncrementAbandonCount(ctx context.Context, playerID string) (int64, error)
+	FlagPlayer(ctx context.Context, playerID string, duration time.Duration) error
+	IsPlayerFlagged(ctx context.Context, playerID string) (bool, error)
+	UnflagPlayer(ctx context.Context, playerID string) (bool, error)
+	GetFlaggedPlayers(ctx context.Context) ([]string, error)
+	CreateProfile(ctx context.Context, profile *models.PlayerProfile) (bool, error)
+	GetProfile(ctx context.Context, playerID string) (*models.PlayerProfile, error)
+	AddDeviceToken(ctx context.Context, playerID, token string) error
+	GetDeviceTokens(ctx context.Context, playerID string) ([]string, error)
+	AppendRatingSnapshot(ctx context.Context, playerID string, point models.RatingPoint) error
+	GetRatingHistory(ctx context.Context, playerID string) ([]models.RatingPoint, error)
+	RecordRatingChange(ctx context.Context, playerID string, delta int, timestamp time.Time) error
+	GetRecentRatingChanges(ctx context.Context, playerID string, limit int) ([]int, error)
+	UpdatePlayerRating(ctx context.Context, playerID string, newRating int) error
+	GetPlayerRating(ctx context.Context, playerID string) (rating int, found bool, err error)
+	SaveMatchResultRatings(ctx context.Context, matchID string, ratings map[string]int) (bool, error)
+	IncrementTotalMatches(ctx context.Context, playerID string) error
+	RecordMatchOutcome(ctx context.Context, playerID string, won bool) error
+	SetMomentum(ctx context.Context, playerID string, momentum int, ttl time.Duration) error
+	GetMomentum(ctx context.Context, playerID string) (momentum int, found bool, err error)
+	SetLastActive(ctx context.Context, playerID string, at time.Time) error
+	GetLastActive(ctx context.Context, playerID string) (time.Time, error)
+	RecordEncounter(ctx context.Context, p1ID, p2ID string, ttl time.Duration) error
+	HaveRecentlyMet(ctx context.Context, p1ID, p2ID string) (bool, error)
+	IncrementStat(ctx context.Context, playerID, statName string, delta float64) error
+	GetPlayerStats(ctx context.Context, playerID string) (map[string]float64, error)
+	RecordPlayerWaitTime(ctx context.Context, playerID string, wait time.Duration) error
+	GetPlayerWaitTimes(ctx context.Context, playerID string) ([]time.Duration, error)
+
+	// Статистика
+	IncrementModePopularity(ctx context.Context, region, gameMode string, hour int) error
+	RecordWaitTime(ctx context.Context, region, gameMode string, hour int, waitSeconds float64) error
+	GetHourlyAvgWait(ctx context.Context, region, gameMode string) ([HoursPerDay]float64, error)
+	GetHourlyMatchCounts(ctx context.Context, region, gameMode string) ([HoursPerDay]int64, error)
+	RecordSegmentMatch(ctx context.Context, region, gameMode, bracket, matchID string, createdAt time.Time) error
+	CountSegmentMatchesLastHour(ctx context.Context, region, gameMode, bracket string) (int64, error)
+	RecordMatchedRatings(ctx context.Context, region, gameMode string, match *models.Match) error
+	GetMatchedRatings(ctx context.Context, region, gameMode string) ([]int, error)
+	RecordMatchCreationTime(ctx context.Context, region, gameMode string, waitDuration time.Duration) error
+	GetWaitTimeSamples(ctx context.Context, region, gameMode string) ([]time.Duration, error)
+	GetAverageWaitTime(ctx context.Context, region, gameMode string) (time.Duration, error)
+
+	// Сезоны
+	CreateSeason(ctx context.Context, season *models.Season) error
+	UpdateSeason(ctx context.Context, season *models.Season) error
+
+	// Турниры
+	GetTopRatedPlayers(ctx context.Context, region, gameMode string, limit int64) ([]*models.Player, error)
+	SaveTournament(ctx context.Context, tournament *models.Tournament) error
+	GetTournament(ctx context.Context, tournamentID string) (*models.Tournament, error)
+
+	// Игровые серверы
+	RegisterServer(ctx context.Context, server *models.Server) error
+	DeregisterServer(ctx context.Context, serverID string) error
+	AcquireLeastLoadedServer(ctx context.Context, region string) (*models.Server, error)
+	ReleaseServer(ctx context.Context, region, serverID string) error
+
+	// Координация реплик
+	AcquireLock(ctx context.Context, key, owner string, ttl time.Duration) (bool, error)
+	RenewLock(ctx context.Context, key, owner string, ttl time.Duration) (bool, error)
+	ReleaseLock(ctx context.Context, key, owner string) error
+	Publish(ctx context.Context, channel string, payload []byte) error
+	Subscribe(ctx context.Context, channel string) (<-chan []byte, error)
+	IncrementRateLimit(ctx context.Context, clientID string, now time.Time) (int64, error)
+
+	// События о матчах для внешних сервисов
+	PublishMatch(ctx context.Context, region, gameMode string, match *models.Match) error
+	SubscribeMatches(ctx context.Context, region, gameMode string) (<-chan *models.Match, error)
+
+	// Настройки, изменяемые без перезапуска
+	GetRegions(ctx context.Context) ([]string, error)
+	SetRegions(ctx context.Context, regions []string) error
+	GetGameModes(ctx context.Context) (map[string]int, error)
+	SetGameModes(ctx context.Context, modes map[string]int) error
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"chrono-matchmaking/models"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// TournamentTTL время хранения турнира
+const TournamentTTL = 7 * 24 * time.Hour
+
+// ErrTournamentNotFound возвращается, если турнир не найден или его срок хранения истек
+var ErrTournamentNotFound = errors.New("tournament not found")
+
+// GetTopRatedPlayers возвращает до limit игроков очереди с наибольшим рейтингом
+// (обычная и приоритетная очереди вместе) по убыванию рейтинга
+func (s *RedisStorage) GetTopRatedPlayers(ctx context.Context, region, gameMode string, limit int64) ([]*models.Player, error) {
+	ctx, span := startSpan(ctx, "GetTopRatedPlayers",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	if limit <= 0 {
+		return []*models.Player{}, nil
+	}
+
+	players := make([]*models.Player, 0, limit)
+	for _, key := range s.queueKeys(region, gameMode) {
+		results, err := s.client.ZRevRange(ctx, key, 0, limit-1).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get top rated players: %w", err)
+		}
+		players = append(players, s.unmarshalQueueMembers(results, gameMode)...)
+	}
+
+	sort.SliceStable(players, func(i, j int) bool {
+		return players[i].Rating > players[j].Rating
+	})
+	if int64(len(players)) > limit {
+		players = players[:limit]
+	}
+
+	return players, nil
+}
+
+// SaveTournament сохраняет турнир в tournament:{id} на TournamentTTL
+func (s *RedisStorage) SaveTournament(ctx context.Context, tournament *models.Tournament) error {
+	ctx, span := startSpan(ctx, "SaveTournament", attribute.String("tournament_id", tournament.TournamentID))
+	defer span.End()
+
+	tournamentJSON, err := json.Marshal(tournament)
+	if err != nil {
+		return fmt.Errorf("failed to marshal tournament: %w", err)
+	}
+
+	if err := s.client.Set(ctx, s.tournamentKey(tournament.TournamentID), tournamentJSON, TournamentTTL).Err(); err != nil {
+		return fmt.Errorf("failed to save tournament: %w", err)
+	}
+	return nil
+}
+
+// GetTournament возвращает турнир или ErrTournamentNotFound
+func (s *RedisStorage) GetTournament(ctx context.Context, tournamentID string) (*models.Tournament, error) {
+	ctx, span := startSpan(ctx, "GetTournament", attribute.String("tournament_id", tournamentID))
+	defer span.End()
+
+	tournamentJSON, err := s.client.Get(ctx, s.tournamentKey(tournamentID)).Result()
+	if err == redis.Nil {
+		return nil, ErrTournamentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tournament: %w", err)
+	}
+
+	var tournament models.Tournament
+	if err := json.Unmarshal([]byte(tournamentJSON), &tournament); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal tournament: %w", err)
+	}
+	return &tournament, nil
+}
+
+// tournamentKey возвращает ключ турнира
+func (s *RedisStorage) tournamentKey(tournamentID string) string {
+	return fmt.Sprintf("tournament:%s", tournamentID)
+}
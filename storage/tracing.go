@@ -0,0 +1,18 @@
+package storage
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer создает спаны методов хранилища. Провайдер задается в main через otel.SetTracerProvider;
+// без него спаны ничего не делают
+var tracer = otel.Tracer("chrono-matchmaking/storage")
+
+// startSpan открывает спан метода RedisStorage с переданными атрибутами
+func startSpan(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "RedisStorage."+method, trace.WithAttributes(attrs...))
+}
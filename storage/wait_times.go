@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// waitTimesWindow период, за который хранятся времена ожидания игроков до матча
+const waitTimesWindow = time.Hour
+
+// RecordMatchCreationTime сохраняет время ожидания игрока до создания матча
+// и удаляет записи старше часа
+func (s *RedisStorage) RecordMatchCreationTime(ctx context.Context, region, gameMode string, waitDuration time.Duration) error {
+	ctx, span := startSpan(ctx, "RecordMatchCreationTime",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	key := s.waitTimesKey(region, gameMode)
+	now := time.Now()
+
+	// Score — время записи; элемент уникален за счет времени в наносекундах
+	pipe := s.client.TxPipeline()
+	pipe.ZAdd(ctx, key, &redis.Z{
+		Score:  float64(now.Unix()),
+		Member: fmt.Sprintf("%d|%d", now.UnixNano(), waitDuration.Milliseconds()),
+	})
+	pipe.ZRemRangeByScore(ctx, key, "-inf", fmt.Sprintf("(%d", now.Add(-waitTimesWindow).Unix()))
+	pipe.Expire(ctx, key, waitTimesWindow)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record match creation time: %w", err)
+	}
+	return nil
+}
+
+// GetWaitTimeSamples возвращает времена ожидания игроков до матча за последний час
+func (s *RedisStorage) GetWaitTimeSamples(ctx context.Context, region, gameMode string) ([]time.Duration, error) {
+	ctx, span := startSpan(ctx, "GetWaitTimeSamples",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	since := time.Now().Add(-waitTimesWindow).Unix()
+	members, err := s.client.ZRangeByScore(ctx, s.waitTimesKey(region, gameMode), &redis.ZRangeBy{
+		Min: strconv.FormatInt(since, 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wait times: %w", err)
+	}
+
+	samples := make([]time.Duration, 0, len(members))
+	for _, member := range members {
+		_, rawWait, ok := strings.Cut(member, "|")
+		if !ok {
+			continue
+		}
+		waitMs, err := strconv.ParseInt(rawWait, 10, 64)
+		if err != nil {
+			continue
+		}
+		samples = append(samples, time.Duration(waitMs)*time.Millisecond)
+	}
+
+	return samples, nil
+}
+
+// GetAverageWaitTime возвращает среднее время ожидания до матча за последний час
+// (0, если матчей не было)
+func (s *RedisStorage) GetAverageWaitTime(ctx context.Context, region, gameMode string) (time.Duration, error) {
+	ctx, span := startSpan(ctx, "GetAverageWaitTime",
+		attribute.String("region", region),
+		attribute.String("game_mode", gameMode),
+	)
+	defer span.End()
+
+	samples, err := s.GetWaitTimeSamples(ctx, region, gameMode)
+	if err != nil || len(samples) == 0 {
+		return 0, err
+	}
+
+	var total time.Duration
+	for _, d := range samples {
+		total += d
+	}
+	return total / time.Duration(len(samples)), nil
+}
+
+// waitTimesKey возвращает ключ времен ожидания до матча
+func (s *RedisStorage) waitTimesKey(region, gameMode string) string {
+	return fmt.Sprintf("wait_times:%s:%s", region, gameMode)
+}